@@ -193,29 +193,1029 @@ type AIGatewayRouteSpec struct {
 	//	                namespace: io.envoy.ai_gateway
 	//	                key: llm_total_token
 	// ```
+	//
+	// The costs above are only known once the response completes, so a single large request can exceed the budget
+	// before it is charged. The "EstimatedInputToken" type is the number of input tokens estimated by the AI Gateway
+	// filter before the request is sent to the backend, and it is stored in the dynamic metadata at the request path.
+	// Using it as the request cost, i.e. `request.from: Metadata` with the metadata key of the estimated cost,
+	// allows the rate limit to reject the request before the upstream call.
+	// +optional
+	// +kubebuilder:validation:MaxItems=36
+	LLMRequestCosts []LLMRequestCost `json:"llmRequestCosts,omitempty"`
+
+	// ResponseCache enables the exact-match cache of the chat completion responses for this AIGatewayRoute.
+	//
+	// When this is set, the AI Gateway filter serves the cached response for a request identical to
+	// a previously successful one without sending it to the backend. Requests are compared after being
+	// normalized, so the differences in the JSON formatting or the order of the fields do not matter.
+	// Streaming and non-streaming requests are cached separately, and a cached streaming response is
+	// replayed to the client as server-sent events at once.
+	//
+	// The responses are cached per rule and per backend selected for the request, so they are not shared across
+	// the rules or the backends, and are no longer served once the rule is changed or removed.
+	//
+	// Note that the cache is kept per AI Gateway filter instance, and only the identical requests are matched.
+	// The semantically similar requests are not served from the cache.
+	//
+	// +optional
+	ResponseCache *ResponseCache `json:"responseCache,omitempty"`
+
+	// Guardrails configures the content checks applied to the chat completion requests and responses
+	// by the AI Gateway filter.
+	//
+	// The requests are checked before being translated for the selected backend, and the non-streaming
+	// responses are checked after being translated back to the OpenAI schema. Streaming responses are
+	// not checked.
+	//
+	// +optional
+	Guardrails *Guardrails `json:"guardrails,omitempty"`
+
+	// SpendBudgets enforces the monthly budgets in USD of the clients of this AIGatewayRoute.
+	//
+	// The AI Gateway filter computes the cost of each request from the token usage in the response with
+	// the configured pricing, and accumulates it per client and calendar month (UTC) in the store.
+	// Once the spend of a client reaches its budget, the subsequent requests of the client are rejected
+	// with 429 Too Many Requests in the OpenAI error format until the next month.
+	//
+	// Unlike the token-based rate limiting with LLMRequestCosts, this accounts for the different prices
+	// of the models and backends.
+	//
+	// +optional
+	SpendBudgets *SpendBudgets `json:"spendBudgets,omitempty"`
+
+	// UsageRecords exports a structured record of the usage of each request to the configured sinks,
+	// for example, for the chargeback of the LLM usage to the teams.
+	//
+	// A record is emitted when the response completes, and contains the request ID, the configured request headers
+	// identifying the client, the requested model, the backend and the model sent to it, the input, output and total
+	// tokens, the latency, the response status, and the cost in nano USD when SpendBudgets is configured.
+	//
+	// The records are exported in the background, and dropped when a sink cannot keep up with the traffic.
+	//
+	// +optional
+	UsageRecords *UsageRecords `json:"usageRecords,omitempty"`
+
+	// Metrics configures the AI Gateway metrics of the requests of this AIGatewayRoute, such as
+	// gen_ai.client.token.usage and gen_ai.server.request.duration.
+	//
+	// +optional
+	Metrics *Metrics `json:"metrics,omitempty"`
+
+	// AuditLog records the chat completion requests sent to the backends and their completions to the configured
+	// sinks, for example, to retain what was sent to the external LLM providers for compliance.
+	//
+	// An entry is emitted when the response completes, and contains the request ID, the configured request headers,
+	// the requested model, the backend and the model sent to it, the response status, the request body, and the
+	// completion. The chunks of the streaming response are assembled into a single completion. The sensitive values
+	// are removed according to the redaction rules before the entry leaves the AI Gateway filter.
+	//
+	// The entries are exported in the background, and dropped when a sink cannot keep up with the traffic.
+	//
+	// +optional
+	AuditLog *AuditLog `json:"auditLog,omitempty"`
+
+	// MirrorResults exports the comparison of each mirrored request of the rules with the Mirror to the configured
+	// sinks, in addition to the metrics.
+	//
+	// A result is emitted when both the selected and the shadow backends have responded, and contains the request ID,
+	// the requested model, and the backend, the model, the response status, the latency, the token usage and the
+	// finish reason of each side, together with the similarity score when the Comparison is configured.
+	//
+	// The results are exported in the background, and dropped when a sink cannot keep up with the traffic.
+	//
+	// +optional
+	MirrorResults *MirrorResults `json:"mirrorResults,omitempty"`
+}
+
+// MirrorResults configures the export of the results of the mirrored requests of AIGatewayRoute.
+type MirrorResults struct {
+	// Sinks are where the results are exported. Each result is exported to all of them.
+	//
+	// The sinks are the same as the ones of the AuditLog, except the File sink names the file
+	// "mirror-<index of the sink>.jsonl".
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=8
+	Sinks []AuditLogSink `json:"sinks"`
+}
+
+// Metrics configures the AI Gateway metrics of AIGatewayRoute.
+type Metrics struct {
+	// RequestHeaderAttributes adds the values of the request headers as the attributes of all the metrics,
+	// for example, to break down the latency and the token usage by tenant.
+	//
+	// The attribute is omitted when the request doesn't have the header.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	RequestHeaderAttributes []MetricsRequestHeaderAttribute `json:"requestHeaderAttributes,omitempty"`
+	// MaxAttributeValues is the maximum number of the distinct values of each attribute in RequestHeaderAttributes
+	// to bound the cardinality of the metrics. Once reached, the new values are reported as "_OTHER".
+	// Defaults to 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10000
+	// +kubebuilder:default=100
+	MaxAttributeValues *int32 `json:"maxAttributeValues,omitempty"`
+}
+
+// MetricsRequestHeaderAttribute is the metric attribute whose value is taken from the request header.
+type MetricsRequestHeaderAttribute struct {
+	// Header is the name of the request header, e.g. "x-tenant-id".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Header string `json:"header"`
+	// Attribute is the name of the metric attribute, e.g. "tenant.id".
+	//
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_.]*$`
+	// +kubebuilder:validation:MaxLength=64
+	Attribute string `json:"attribute"`
+}
+
+// UsageRecords configures the export of the usage records of AIGatewayRoute.
+type UsageRecords struct {
+	// Headers are the names of the request headers included in the records to identify the client,
+	// e.g. "x-user-id" or "x-team-id".
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Headers []string `json:"headers,omitempty"`
+	// Sinks are where the records are exported. Each record is exported to all of them.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=8
+	Sinks []UsageRecordSink `json:"sinks"`
+}
+
+// UsageRecordSink is where the usage records are exported.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'File' || !has(self.file)", message="file must be set only for the File type"
+// +kubebuilder:validation:XValidation:rule="self.type == 'Webhook' ? has(self.webhook) : !has(self.webhook)", message="webhook must be set only for the Webhook type"
+// +kubebuilder:validation:XValidation:rule="self.type == 'OTLP' ? has(self.otlp) : !has(self.otlp)", message="otlp must be set only for the OTLP type"
+type UsageRecordSink struct {
+	// Type is the type of the sink.
+	//
+	// +kubebuilder:validation:Enum=File;Webhook;OTLP
+	Type UsageRecordSinkType `json:"type"`
+	// File configures the File sink.
+	//
+	// +optional
+	File *UsageRecordFileSink `json:"file,omitempty"`
+	// Webhook configures the Webhook sink.
+	//
+	// +optional
+	Webhook *UsageRecordWebhookSink `json:"webhook,omitempty"`
+	// OTLP configures the OTLP sink.
+	//
+	// +optional
+	OTLP *UsageRecordOTLPSink `json:"otlp,omitempty"`
+}
+
+// UsageRecordSinkType specifies the type of the UsageRecordSink.
+type UsageRecordSinkType string
+
+const (
+	// UsageRecordSinkTypeFile writes the records as JSON lines to a file in the AI Gateway filter pod,
+	// which is rotated by size.
+	UsageRecordSinkTypeFile UsageRecordSinkType = "File"
+	// UsageRecordSinkTypeWebhook sends the batches of the records as JSON arrays with HTTP POST requests.
+	UsageRecordSinkTypeWebhook UsageRecordSinkType = "Webhook"
+	// UsageRecordSinkTypeOTLP exports the records as OpenTelemetry log records with OTLP over gRPC.
+	UsageRecordSinkTypeOTLP UsageRecordSinkType = "OTLP"
+)
+
+// UsageRecordFileSink configures the File sink.
+type UsageRecordFileSink struct {
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim in the same namespace as the AIGatewayRoute
+	// mounted on the AI Gateway filter pod to keep the files. When unset, the files are kept in an emptyDir volume.
+	//
+	// The file is named "usage-<index of the sink>.jsonl", and the rotated ones have the timestamp in their names.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	PersistentVolumeClaimName *string `json:"persistentVolumeClaimName,omitempty"`
+	// MaxSizeMegabytes is the size of the file in megabytes at which it is rotated. Defaults to 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	MaxSizeMegabytes *int32 `json:"maxSizeMegabytes,omitempty"`
+	// MaxBackups is the maximum number of the rotated files kept. Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10
+	MaxBackups *int32 `json:"maxBackups,omitempty"`
+}
+
+// UsageRecordWebhookSink configures the Webhook sink.
+type UsageRecordWebhookSink struct {
+	// URL is the URL where the batches of the records are sent.
+	//
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+	// Batch configures the batching of the records.
+	//
+	// +optional
+	Batch *UsageRecordBatch `json:"batch,omitempty"`
+}
+
+// UsageRecordOTLPSink configures the OTLP sink.
+type UsageRecordOTLPSink struct {
+	// Endpoint is the "host:port" address of the OTLP gRPC receiver, e.g. "otel-collector.monitoring:4317".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Endpoint string `json:"endpoint"`
+	// Insecure disables TLS for the connection to the receiver.
+	//
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+	// Batch configures the batching of the records.
+	//
+	// +optional
+	Batch *UsageRecordBatch `json:"batch,omitempty"`
+}
+
+// UsageRecordBatch configures the batching of the usage records, which also applies to the audit log entries.
+type UsageRecordBatch struct {
+	// MaxSize is the maximum number of the records in a batch. Defaults to 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10000
+	// +kubebuilder:default=100
+	MaxSize *int32 `json:"maxSize,omitempty"`
+	// FlushInterval is the maximum duration a record waits in a batch before being exported. Defaults to 5s.
+	//
+	// +optional
+	// +kubebuilder:default="5s"
+	FlushInterval *gwapiv1.Duration `json:"flushInterval,omitempty"`
+	// MaxRetries is the maximum number of the retries of a failed export with an exponential backoff.
+	// The batch is dropped when all of them fail. Defaults to 3.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +kubebuilder:default=3
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+}
+
+// AuditLog configures the audit log of AIGatewayRoute.
+type AuditLog struct {
+	// SamplingPercentage is the percentage of the requests recorded, from 0 to 100. Defaults to 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=100
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
+	// Headers are the names of the request headers included in the entries, e.g. "x-user-id".
+	// The value of the "authorization" header is always redacted.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Headers []string `json:"headers,omitempty"`
+	// Redaction configures the sensitive values removed from the entries.
+	//
+	// +optional
+	Redaction *AuditLogRedaction `json:"redaction,omitempty"`
+	// Sinks are where the entries are exported. Each entry is exported to all of them.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=8
+	Sinks []AuditLogSink `json:"sinks"`
+}
+
+// AuditLogRedaction configures the sensitive values replaced with "[REDACTED]" in the audit log entries.
+type AuditLogRedaction struct {
+	// Patterns are the RE2 regular expressions whose matches in all the string values of the request body and
+	// the completion are redacted, e.g. "\b\d{3}-\d{2}-\d{4}\b" for the US social security numbers.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	Patterns []string `json:"patterns,omitempty"`
+	// Fields are the paths of the values redacted as a whole. A path starts with either "request" for the request
+	// body or "completion" for the completion, followed by the object keys separated by dots and the array indexes
+	// in brackets. The wildcard "*" matches all the keys or all the elements.
+	//
+	// For example, "request.messages[*].content" redacts the content of all the messages, and "request.user"
+	// redacts the end-user ID.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	Fields []string `json:"fields,omitempty"`
+	// Headers are the names of the request headers in Headers of AuditLog whose values are redacted
+	// in addition to the "authorization" header.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Headers []string `json:"headers,omitempty"`
+}
+
+// AuditLogSink is where the audit log entries are exported.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'File' || !has(self.file)", message="file must be set only for the File type"
+// +kubebuilder:validation:XValidation:rule="self.type == 'HTTP' ? has(self.http) : !has(self.http)", message="http must be set only for the HTTP type"
+type AuditLogSink struct {
+	// Type is the type of the sink.
+	//
+	// +kubebuilder:validation:Enum=File;HTTP
+	Type AuditLogSinkType `json:"type"`
+	// File configures the File sink.
+	//
+	// +optional
+	File *AuditLogFileSink `json:"file,omitempty"`
+	// HTTP configures the HTTP sink.
+	//
+	// +optional
+	HTTP *AuditLogHTTPSink `json:"http,omitempty"`
+}
+
+// AuditLogSinkType specifies the type of the AuditLogSink.
+type AuditLogSinkType string
+
+const (
+	// AuditLogSinkTypeFile writes the entries as JSON lines to a file in the AI Gateway filter pod,
+	// which is rotated by size.
+	AuditLogSinkTypeFile AuditLogSinkType = "File"
+	// AuditLogSinkTypeHTTP sends the batches of the entries as JSON arrays with HTTP POST requests.
+	AuditLogSinkTypeHTTP AuditLogSinkType = "HTTP"
+)
+
+// AuditLogFileSink configures the File sink of the audit log.
+type AuditLogFileSink struct {
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim in the same namespace as the AIGatewayRoute
+	// mounted on the AI Gateway filter pod to keep the files. When unset, the files are kept in an emptyDir volume.
+	//
+	// The file is named "audit-<index of the sink>.jsonl", and the rotated ones have the timestamp in their names.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	PersistentVolumeClaimName *string `json:"persistentVolumeClaimName,omitempty"`
+	// MaxSizeMegabytes is the size of the file in megabytes at which it is rotated. Defaults to 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	MaxSizeMegabytes *int32 `json:"maxSizeMegabytes,omitempty"`
+	// MaxBackups is the maximum number of the rotated files kept. Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10
+	MaxBackups *int32 `json:"maxBackups,omitempty"`
+}
+
+// AuditLogHTTPSink configures the HTTP sink of the audit log.
+type AuditLogHTTPSink struct {
+	// URL is the URL where the batches of the entries are sent.
+	//
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+	// Batch configures the batching of the entries.
+	//
+	// +optional
+	Batch *UsageRecordBatch `json:"batch,omitempty"`
+}
+
+// SpendBudgets configures the monthly spend budgets of AIGatewayRoute.
+//
+// The budget is checked before the request is sent to the backend, and the cost is charged after the response
+// completes. No budget is reserved for the requests in flight, so the concurrent requests of a client all pass the
+// check until one of them is charged. Hence, the spend can exceed the budget by the cost of the requests in flight
+// when the budget is reached.
+type SpendBudgets struct {
+	// ClientIdentifier specifies how the client of the request is identified.
+	// Requests without the client identifier are neither charged nor limited.
+	ClientIdentifier SpendBudgetClientIdentifier `json:"clientIdentifier"`
+	// Pricing is the list of the prices of the models. The cost of a request to a model without the price is zero.
+	//
+	// When multiple entries match a request, the one with the backend takes precedence.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=256
+	Pricing []ModelPricing `json:"pricing"`
+	// Clients is the list of the budgets of the individual clients.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=1024
+	Clients []ClientSpendBudget `json:"clients,omitempty"`
+	// DefaultMonthlyLimit is the monthly budget in USD of the clients not listed in Clients, e.g. "100" or "12.5".
+	// When unset, the spend of such clients is recorded but not limited.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]{1,9})?$`
+	DefaultMonthlyLimit *string `json:"defaultMonthlyLimit,omitempty"`
+	// Store is where the spend of the clients is persisted.
+	Store SpendBudgetStore `json:"store"`
+	// FailOpen lets the requests through when the spend cannot be read from the store, so that an outage of the
+	// store does not stop the traffic. Defaults to true. When false, the requests of the identified clients are
+	// rejected with 503 Service Unavailable instead.
+	//
+	// Either way, the costs that cannot be written to the store are logged and not charged.
+	//
+	// +optional
+	// +kubebuilder:default=true
+	FailOpen *bool `json:"failOpen,omitempty"`
+}
+
+// SpendBudgetClientIdentifier specifies how the client of the request is identified.
+// Exactly one of the fields must be set.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.header) != has(self.jwtClaim)", message="exactly one of header or jwtClaim must be set"
+type SpendBudgetClientIdentifier struct {
+	// Header is the name of the request header whose value identifies the client, e.g. "x-user-id".
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Header *string `json:"header,omitempty"`
+	// JWTClaim is the name of the claim in the JWT bearer token of the "authorization" request header
+	// whose value identifies the client, e.g. "sub". The claim must be a string or a number.
+	//
+	// The token is NOT verified by the AI Gateway filter, so it must be verified beforehand, for example,
+	// with the JWT authentication of the Envoy Gateway's SecurityPolicy. Alternatively, the claim can be copied to a
+	// header with the claimToHeaders of the SecurityPolicy, and that header can be used as the identifier.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	JWTClaim *string `json:"jwtClaim,omitempty"`
+}
+
+// ModelPricing is the price of a model.
+type ModelPricing struct {
+	// Model is the name of the model sent to the backend, i.e. after the model name mappings of the backend, if any.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Model string `json:"model"`
+	// Backend is the name of the AIServiceBackend that serves the model at this price.
+	// When unset, the price applies to all the backends.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Backend *string `json:"backend,omitempty"`
+	// InputPerMillionTokens is the price in USD of one million input tokens, e.g. "2.5".
+	//
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]{1,9})?$`
+	InputPerMillionTokens string `json:"inputPerMillionTokens"`
+	// OutputPerMillionTokens is the price in USD of one million output tokens, e.g. "10".
+	//
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]{1,9})?$`
+	OutputPerMillionTokens string `json:"outputPerMillionTokens"`
+}
+
+// ClientSpendBudget is the monthly budget of a client.
+type ClientSpendBudget struct {
+	// Client is the value of the client identifier.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Client string `json:"client"`
+	// MonthlyLimit is the monthly budget in USD of the client, e.g. "100" or "12.5".
+	//
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]{1,9})?$`
+	MonthlyLimit string `json:"monthlyLimit"`
+}
+
+// SpendBudgetStore configures where the spend of the clients is persisted.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'Redis' ? has(self.redis) : !has(self.redis)", message="redis must be set only for the Redis type"
+// +kubebuilder:validation:XValidation:rule="self.type == 'Local' || !has(self.local)", message="local must be set only for the Local type"
+type SpendBudgetStore struct {
+	// Type is the type of the store.
+	//
+	// +kubebuilder:validation:Enum=Local;Redis
+	Type SpendBudgetStoreType `json:"type"`
+	// Local configures the Local store.
+	//
+	// +optional
+	Local *SpendBudgetLocalStore `json:"local,omitempty"`
+	// Redis configures the Redis store.
+	//
+	// +optional
+	Redis *SpendBudgetRedisStore `json:"redis,omitempty"`
+}
+
+// SpendBudgetStoreType specifies the type of the SpendBudgetStore.
+type SpendBudgetStoreType string
+
+const (
+	// SpendBudgetStoreTypeLocal persists the spend in a file in the AI Gateway filter pod.
+	// Since each pod has its own file, this is only suitable when the external processor has a single replica.
+	SpendBudgetStoreTypeLocal SpendBudgetStoreType = "Local"
+	// SpendBudgetStoreTypeRedis persists the spend in Redis shared by all the replicas of the AI Gateway filter.
+	SpendBudgetStoreTypeRedis SpendBudgetStoreType = "Redis"
+)
+
+// SpendBudgetLocalStore configures the Local store.
+type SpendBudgetLocalStore struct {
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim in the same namespace as the AIGatewayRoute
+	// mounted on the AI Gateway filter pod to keep the file.
+	//
+	// When unset, the file is kept in an emptyDir volume, so the spend survives the container restarts
+	// but is lost when the pod is deleted.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	PersistentVolumeClaimName *string `json:"persistentVolumeClaimName,omitempty"`
+}
+
+// SpendBudgetRedisStore configures the Redis store.
+type SpendBudgetRedisStore struct {
+	// Address is the address of the Redis server in the "host:port" format.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+	// PasswordSecretRef is the reference to the secret in the same namespace as the AIGatewayRoute
+	// containing the password of the Redis server at the key "password".
+	//
+	// +optional
+	PasswordSecretRef *gwapiv1.SecretObjectReference `json:"passwordSecretRef,omitempty"`
+	// TLS enables TLS for the connection to the Redis server.
+	//
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+	// KeyPrefix is the prefix of the keys of the spend stored in Redis. Defaults to "ai-gateway:spend:".
+	//
+	// +optional
+	// +kubebuilder:default="ai-gateway:spend:"
+	KeyPrefix *string `json:"keyPrefix,omitempty"`
+}
+
+// Guardrails configures the content checks of AIGatewayRoute.
+//
+// When multiple rules match, the most restrictive action among them is taken in the order of
+// Block, Redact and Annotate. The moderation service is called after the rules are applied, so it
+// sees the redacted content.
+type Guardrails struct {
+	// Rules are the built-in rules evaluated within the AI Gateway filter.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	Rules []GuardrailRule `json:"rules,omitempty"`
+	// Moderation is the external content moderation service called by the AI Gateway filter.
+	//
+	// +optional
+	Moderation *GuardrailModeration `json:"moderation,omitempty"`
+}
+
+// GuardrailRule is a built-in content check.
+//
+// +kubebuilder:validation:XValidation:rule="self.type == 'RegularExpression' ? has(self.regularExpression) : !has(self.regularExpression)", message="regularExpression must be set only for the RegularExpression type"
+// +kubebuilder:validation:XValidation:rule="self.type == 'PII' ? has(self.pii) : !has(self.pii)", message="pii must be set only for the PII type"
+type GuardrailRule struct {
+	// Name is the name of the rule, which is reported when the rule matches.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Type is the type of the rule.
+	//
+	// +kubebuilder:validation:Enum=RegularExpression;PII
+	Type GuardrailRuleType `json:"type"`
+	// RegularExpression is the RE2 regular expression matched against the content.
+	// This must be set when the type is RegularExpression.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	RegularExpression *string `json:"regularExpression,omitempty"`
+	// PII is the list of the kinds of the personally identifiable information detected by the rule.
+	// This must be set when the type is PII.
+	//
+	// +optional
+	// +kubebuilder:validation:MinItems=1
+	PII []GuardrailPIIType `json:"pii,omitempty"`
+	// Action is the action taken when the rule matches.
+	//
+	// +kubebuilder:validation:Enum=Block;Redact;Annotate
+	Action GuardrailAction `json:"action"`
+	// Stages are the stages where the rule is applied. Defaults to both Request and Response.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=2
+	Stages []GuardrailStage `json:"stages,omitempty"`
+}
+
+// GuardrailRuleType specifies the type of the GuardrailRule.
+type GuardrailRuleType string
+
+const (
+	// GuardrailRuleTypeRegularExpression matches the content against the regular expression.
+	GuardrailRuleTypeRegularExpression GuardrailRuleType = "RegularExpression"
+	// GuardrailRuleTypePII detects the personally identifiable information in the content.
+	GuardrailRuleTypePII GuardrailRuleType = "PII"
+)
+
+// GuardrailPIIType specifies the kind of the personally identifiable information.
+//
+// +kubebuilder:validation:Enum=Email;PhoneNumber;CreditCardNumber;USSocialSecurityNumber;IPAddress
+type GuardrailPIIType string
+
+const (
+	// GuardrailPIITypeEmail detects the email addresses.
+	GuardrailPIITypeEmail GuardrailPIIType = "Email"
+	// GuardrailPIITypePhoneNumber detects the phone numbers.
+	GuardrailPIITypePhoneNumber GuardrailPIIType = "PhoneNumber"
+	// GuardrailPIITypeCreditCardNumber detects the credit card numbers.
+	GuardrailPIITypeCreditCardNumber GuardrailPIIType = "CreditCardNumber"
+	// GuardrailPIITypeUSSocialSecurityNumber detects the US social security numbers.
+	GuardrailPIITypeUSSocialSecurityNumber GuardrailPIIType = "USSocialSecurityNumber"
+	// GuardrailPIITypeIPAddress detects the IPv4 addresses.
+	GuardrailPIITypeIPAddress GuardrailPIIType = "IPAddress"
+)
+
+// GuardrailAction specifies the action taken when the content is flagged.
+type GuardrailAction string
+
+const (
+	// GuardrailActionBlock rejects the request with 400 Bad Request in the OpenAI error format.
+	GuardrailActionBlock GuardrailAction = "Block"
+	// GuardrailActionRedact replaces the matched content with "[REDACTED]".
+	GuardrailActionRedact GuardrailAction = "Redact"
+	// GuardrailActionAnnotate lets the content through while setting the names of the matched rules
+	// in the "x-ai-eg-guardrail-flagged" header of the request to the backend, or of the response to the client.
+	GuardrailActionAnnotate GuardrailAction = "Annotate"
+)
+
+// GuardrailStage specifies where the guardrail is applied.
+//
+// +kubebuilder:validation:Enum=Request;Response
+type GuardrailStage string
+
+const (
+	// GuardrailStageRequest is the stage before the request is sent to the backend.
+	GuardrailStageRequest GuardrailStage = "Request"
+	// GuardrailStageResponse is the stage after the response is received from the backend.
+	GuardrailStageResponse GuardrailStage = "Response"
+)
+
+// GuardrailModeration configures the external content moderation service.
+//
+// The service must implement the OpenAI moderation API (https://platform.openai.com/docs/api-reference/moderations).
+// The AI Gateway filter sends the texts as the "input" array and treats the content as flagged when
+// any of the results is flagged. The flagged categories are reported as the reasons.
+type GuardrailModeration struct {
+	// URL is the URL of the moderation endpoint, e.g. "http://moderation.default.svc.cluster.local/v1/moderations".
+	//
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+	// Action is the action taken when the content is flagged. Redact is not supported.
+	//
+	// +kubebuilder:validation:Enum=Block;Annotate
+	Action GuardrailAction `json:"action"`
+	// Stages are the stages where the moderation is applied. Defaults to both Request and Response.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=2
+	Stages []GuardrailStage `json:"stages,omitempty"`
+	// Timeout is the timeout of the call to the moderation service. Defaults to 5s.
+	//
+	// +optional
+	// +kubebuilder:default="5s"
+	Timeout *gwapiv1.Duration `json:"timeout,omitempty"`
+	// FailOpen lets the content through when the moderation service fails. Defaults to false,
+	// in which case the request fails with 500 Internal Server Error.
+	//
+	// +optional
+	FailOpen bool `json:"failOpen,omitempty"`
+}
+
+// ResponseCache configures the response cache of AIGatewayRoute.
+type ResponseCache struct {
+	// TTL is the duration for which a cached response is served. Defaults to 1h.
+	//
+	// +optional
+	// +kubebuilder:default="1h"
+	TTL *gwapiv1.Duration `json:"ttl,omitempty"`
+	// MaxEntries is the maximum number of responses cached by each AI Gateway filter instance.
+	// When the cache is full, the least recently used response is evicted. Defaults to 1024.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1024
+	MaxEntries *int32 `json:"maxEntries,omitempty"`
+}
+
+// AIGatewayRouteRule is a rule that defines the routing behavior of the AIGatewayRoute.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.experiment) || self.experiment.arms.all(a, a.backendRefs.all(n, self.backendRefs.exists(b, b.name == n)))", message="the backendRefs of the arms must be in the backendRefs of the rule"
+// +kubebuilder:validation:XValidation:rule="!has(self.experiment) || self.backendRefs.all(b, self.experiment.arms.exists_one(a, b.name in a.backendRefs))", message="each backendRef must belong to exactly one arm of the experiment"
+type AIGatewayRouteRule struct {
+	// BackendRefs is the list of AIServiceBackend that this rule will route the traffic to.
+	// Each backend can have a weight that determines the traffic distribution.
+	//
+	// The namespace of each backend is "local", i.e. the same namespace as the AIGatewayRoute.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=128
+	BackendRefs []AIGatewayRouteRuleBackendRef `json:"backendRefs,omitempty"`
+
+	// Matches is the list of AIGatewayRouteMatch that this rule will match the traffic to.
+	// This is a subset of the HTTPRouteMatch in the Gateway API. See for the details:
+	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.HTTPRouteMatch
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=128
+	Matches []AIGatewayRouteRuleMatch `json:"matches,omitempty"`
+
+	// Fallback configures the failover to the other backends of this rule when the selected backend
+	// responds with a retriable status code before any response body is streamed to the client.
+	// This includes the connection failure to the backend, which is reported as 503 by Envoy.
+	//
+	// When the failover happens, the request is translated again into the API schema of the next backend,
+	// and the backend security policy of the next backend is applied. The backends are tried in the ascending
+	// order of their Priority.
+	//
+	// The request to the fallback backend is sent directly by the AI Gateway filter, so all the backends of this rule
+	// must be AIServiceBackends, and the InferencePool is not supported. The Backend of Envoy Gateway referenced by
+	// them must have a single endpoint. The request is sent with TLS when a BackendTLSPolicy targets the Service or
+	// the Backend, in which case the policy must use the system CA certificates and the hostname of the endpoint.
+	// Otherwise, the AIGatewayRoute is not accepted.
+	//
+	// The response from the fallback backend is buffered entirely before being sent to the client. For the streaming
+	// requests, the retriable status code arrives in the response headers before any event is streamed, so they are
+	// failed over as well, and all the events of the fallback backend are sent to the client at once.
+	//
+	// +optional
+	Fallback *AIGatewayRouteRuleFallback `json:"fallback,omitempty"`
+
+	// Hedging configures the hedged requests to cut the tail latency of the latency-critical traffic.
+	//
+	// The request is sent to the selected backend, and when it has not responded within the delay, the duplicate
+	// request is sent to the next backend of this rule in the ascending order of their Priority. The response that
+	// arrives first is returned to the client, and the other request is cancelled. A response with 429 or 5xx
+	// status code doesn't count as a response as long as the other request is in flight.
+	//
+	// Both requests are sent directly by the AI Gateway filter instead of Envoy, so the response is buffered
+	// entirely before being sent to the client. Hence, only the non-streaming requests are hedged, and the
+	// streaming requests to the backends of this rule are routed to the selected backend as usual without hedging.
+	// The backends have the same requirements as the ones of Fallback.
+	//
+	// Since the backend bills the prompt of the cancelled request as well, the input tokens of the cancelled
+	// request are counted in the LLMRequestCosts in addition to the token usage of the response. When the other
+	// request has been responded by the time it is cancelled, the token usage of its response is counted instead.
+	//
 	// +optional
-	// +kubebuilder:validation:MaxItems=36
-	LLMRequestCosts []LLMRequestCost `json:"llmRequestCosts,omitempty"`
+	Hedging *AIGatewayRouteRuleHedging `json:"hedging,omitempty"`
+
+	// Mirror sends a copy of a percentage of the requests matching this rule to a shadow backend, for example,
+	// to evaluate a new model or provider with the real traffic before migrating to it.
+	//
+	// The copy is translated for the shadow backend with its own APISchema and BackendSecurityPolicy, and sent
+	// directly by the AI Gateway filter in the background. The response of the shadow backend is never returned to
+	// the client. Instead, it is compared with the response of the selected backend, and the comparison is reported
+	// in the metrics and exported to the sinks of MirrorResults of the AIGatewayRoute.
+	//
+	// Note that the shadow backend consumes the tokens as usual, but they are not counted in LLMRequestCosts.
+	//
+	// +optional
+	Mirror *AIGatewayRouteRuleMirror `json:"mirror,omitempty"`
+
+	// CircuitBreaker configures the circuit breaking of the backends of this rule.
+	//
+	// The AI Gateway filter selects the backend before Envoy selects the endpoint of its cluster, so Envoy's outlier
+	// detection cannot take a failing backend out of the selection. Instead, the filter tracks the responses of each
+	// backend of this rule, and opens its circuit when it keeps failing. A backend with the open circuit is excluded
+	// from the selection, so the requests go to the other backends of the same priority, or to the backends of the next
+	// priority if none is left. After OpenDuration, the circuit becomes half-open, and a few probe requests are
+	// sent to the backend. The circuit closes when they succeed, and opens again otherwise.
+	//
+	// A response with 429 or 5xx status code counts as a failure. This includes the connection failure to the backend,
+	// which is reported as 503 by Envoy. When all the backends of the rule have the open circuit, the backend is selected
+	// as if there were no circuit breaker.
+	//
+	// The state of the circuits is kept in each replica of the AI Gateway filter, and across the configuration
+	// updates as long as this CircuitBreaker is unchanged.
+	//
+	// +optional
+	CircuitBreaker *AIGatewayRouteRuleCircuitBreaker `json:"circuitBreaker,omitempty"`
+
+	// StickyRouting makes the selection of the backend by their weights deterministic for each client identified by
+	// a request header, e.g. a user or session ID, so that the requests of the same client keep going to the same
+	// backend. This applies to the selection of the arm of the Experiment as well.
+	//
+	// The backends are selected by the consistent hashing, so adding or removing a backend only moves the clients of
+	// that backend. When the header is missing in the request, the backend is selected randomly by the weights.
+	//
+	// +optional
+	StickyRouting *AIGatewayRouteRuleStickyRouting `json:"stickyRouting,omitempty"`
+
+	// Experiment splits the traffic of this rule into the named arms of an A/B experiment. Each backendRef of this
+	// rule belongs to exactly one arm. An arm is selected by the weights of the arms first, and then a backend of the
+	// arm is selected by the weights and priorities of the backends as usual.
+	//
+	// The experiment and the selected arm are returned to the client in the x-ai-eg-experiment and
+	// x-ai-eg-experiment-arm response headers, set in the "experiment" and "experiment_arm" fields of the dynamic
+	// metadata, and added to the metrics as the aigw.experiment and aigw.experiment.arm attributes.
+	//
+	// Without StickyRouting, the arm is selected randomly for each request.
+	//
+	// +optional
+	Experiment *AIGatewayRouteRuleExperiment `json:"experiment,omitempty"`
 }
 
-// AIGatewayRouteRule is a rule that defines the routing behavior of the AIGatewayRoute.
-type AIGatewayRouteRule struct {
-	// BackendRefs is the list of AIServiceBackend that this rule will route the traffic to.
-	// Each backend can have a weight that determines the traffic distribution.
+// AIGatewayRouteRuleStickyRouting specifies the sticky routing of an AIGatewayRouteRule.
+type AIGatewayRouteRuleStickyRouting struct {
+	// HeaderName is the name of the request header whose value identifies the client, e.g. x-user-id.
 	//
-	// The namespace of each backend is "local", i.e. the same namespace as the AIGatewayRoute.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	HeaderName string `json:"headerName"`
+}
+
+// AIGatewayRouteRuleExperiment specifies the A/B experiment of an AIGatewayRouteRule.
+//
+// +kubebuilder:validation:XValidation:rule="self.arms.all(a, self.arms.exists_one(b, b.name == a.name))", message="the names of the arms must be unique"
+type AIGatewayRouteRuleExperiment struct {
+	// Name is the name of the experiment.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// Arms are the arms of the experiment.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	Arms []AIGatewayRouteRuleExperimentArm `json:"arms"`
+}
+
+// AIGatewayRouteRuleExperimentArm is an arm of an AIGatewayRouteRuleExperiment.
+type AIGatewayRouteRuleExperimentArm struct {
+	// Name is the name of the arm, e.g. "control" or "treatment".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// Weight is the weight of the arm, which determines the share of the traffic, or the clients with StickyRouting,
+	// assigned to this arm. Defaults to 1.
 	//
 	// +optional
-	// +kubebuilder:validation:MaxItems=128
-	BackendRefs []AIGatewayRouteRuleBackendRef `json:"backendRefs,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	Weight *int32 `json:"weight,omitempty"`
+	// BackendRefs are the names of the backendRefs of the rule serving this arm.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:MaxLength=253
+	BackendRefs []string `json:"backendRefs"`
+}
 
-	// Matches is the list of AIGatewayRouteMatch that this rule will match the traffic to.
-	// This is a subset of the HTTPRouteMatch in the Gateway API. See for the details:
-	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.HTTPRouteMatch
+// AIGatewayRouteRuleMirror specifies the traffic mirroring of an AIGatewayRouteRule.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.comparison) || self.comparison.type != 'EmbeddingSimilarity' || has(self.comparison.embeddingSimilarity)", message="embeddingSimilarity must be set for the EmbeddingSimilarity type"
+type AIGatewayRouteRuleMirror struct {
+	// Name is the name of the AIServiceBackend in the same namespace the requests are mirrored to.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// ModelNameMappings maps the model names requested by the clients to the ones of the shadow backend.
 	//
 	// +optional
 	// +kubebuilder:validation:MaxItems=128
-	Matches []AIGatewayRouteRuleMatch `json:"matches,omitempty"`
+	ModelNameMappings []ModelNameMapping `json:"modelNameMappings,omitempty"`
+	// Percentage is the percentage of the requests mirrored, from 0 to 100. Defaults to 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=100
+	Percentage *int32 `json:"percentage,omitempty"`
+	// Comparison configures the score of the similarity between the outputs of the selected and the shadow backends.
+	// When unset, only the latency, the token usage and the finish reason are compared.
+	//
+	// +optional
+	Comparison *MirrorComparison `json:"comparison,omitempty"`
+}
+
+// MirrorComparison configures the score of the similarity between the outputs of the mirrored requests.
+type MirrorComparison struct {
+	// Type is the method of the comparison.
+	//
+	// +kubebuilder:validation:Enum=ExactMatch;EmbeddingSimilarity
+	Type MirrorComparisonType `json:"type"`
+	// EmbeddingSimilarity configures the EmbeddingSimilarity comparison.
+	//
+	// +optional
+	EmbeddingSimilarity *MirrorEmbeddingSimilarity `json:"embeddingSimilarity,omitempty"`
+}
+
+// MirrorComparisonType specifies the method of the MirrorComparison.
+type MirrorComparisonType string
+
+const (
+	// MirrorComparisonTypeExactMatch scores 1 if the outputs are the same after trimming the leading and trailing
+	// white spaces, and 0 otherwise.
+	MirrorComparisonTypeExactMatch MirrorComparisonType = "ExactMatch"
+	// MirrorComparisonTypeEmbeddingSimilarity scores the cosine similarity of the embeddings of the outputs,
+	// which is from -1 to 1.
+	MirrorComparisonTypeEmbeddingSimilarity MirrorComparisonType = "EmbeddingSimilarity"
+)
+
+// MirrorEmbeddingSimilarity configures the backend computing the embeddings of the outputs.
+type MirrorEmbeddingSimilarity struct {
+	// BackendName is the name of the AIServiceBackend in the same namespace serving the embeddings with the OpenAI
+	// schema. The embeddings are requested directly by the AI Gateway filter in the background.
+	//
+	// +kubebuilder:validation:MinLength=1
+	BackendName string `json:"backendName"`
+	// Model is the embedding model, e.g. "text-embedding-3-small".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Model string `json:"model"`
+}
+
+// AIGatewayRouteRuleCircuitBreaker specifies the circuit breaking of the backends of an AIGatewayRouteRule.
+type AIGatewayRouteRuleCircuitBreaker struct {
+	// ConsecutiveFailures is the number of the consecutive failures of a backend that opens its circuit.
+	// Defaults to 5.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	ConsecutiveFailures *int32 `json:"consecutiveFailures,omitempty"`
+	// ErrorRatePercentage is the percentage of the failures among the responses of a backend within the Interval that
+	// opens its circuit, from 1 to 100. The error rate is only checked when the backend has responded at least
+	// MinimumRequests times within the Interval. When unset, only the consecutive failures are checked.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	ErrorRatePercentage *int32 `json:"errorRatePercentage,omitempty"`
+	// MinimumRequests is the minimum number of the responses within the Interval to check the error rate.
+	// Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=10
+	MinimumRequests *int32 `json:"minimumRequests,omitempty"`
+	// Interval is the duration of the window in which the error rate is calculated. The counts are reset at the end
+	// of each window. Defaults to 10s.
+	//
+	// +optional
+	// +kubebuilder:default="10s"
+	Interval *gwapiv1.Duration `json:"interval,omitempty"`
+	// SlowResponseThreshold is the duration after which the response headers of a backend count as a failure even
+	// when the status code is successful. When unset, the latency is not checked.
+	//
+	// +optional
+	SlowResponseThreshold *gwapiv1.Duration `json:"slowResponseThreshold,omitempty"`
+	// OpenDuration is the duration a circuit stays open before it becomes half-open. Defaults to 30s.
+	//
+	// +optional
+	// +kubebuilder:default="30s"
+	OpenDuration *gwapiv1.Duration `json:"openDuration,omitempty"`
+	// HalfOpenRequests is the number of the probe requests sent to a backend with the half-open circuit. The circuit
+	// closes when all of them succeed. Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	HalfOpenRequests *int32 `json:"halfOpenRequests,omitempty"`
+}
+
+// AIGatewayRouteRuleHedging specifies the hedged requests of an AIGatewayRouteRule.
+type AIGatewayRouteRuleHedging struct {
+	// Delay is the duration to wait for the response from the selected backend before sending the duplicate
+	// request to the next backend, e.g. "2s". This is usually around the 95th percentile of the latency.
+	//
+	// +kubebuilder:validation:Required
+	Delay gwapiv1.Duration `json:"delay"`
+
+	// Timeout is the timeout of the requests to both backends including reading the whole response, e.g. "30s".
+	// Defaults to 5 minutes.
+	//
+	// +optional
+	// +kubebuilder:default="5m"
+	Timeout *gwapiv1.Duration `json:"timeout,omitempty"`
+}
+
+// AIGatewayRouteRuleFallback specifies the failover behavior of an AIGatewayRouteRule.
+type AIGatewayRouteRuleFallback struct {
+	// RetriableStatusCodes is the list of HTTP status codes of the response that trigger the failover
+	// to the next backend.
+	//
+	// Default is 429 and all 5xx status codes.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	RetriableStatusCodes []gwapiv1.HTTPRouteRetryStatusCode `json:"retriableStatusCodes,omitempty"`
+
+	// MaxAttempts is the maximum number of attempts including the first one to the originally selected backend.
+	//
+	// Default is the number of backends in the rule, which means all the backends are tried at most once.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxAttempts *int32 `json:"maxAttempts,omitempty"`
+
+	// Timeout is the timeout of each request to the fallback backend including reading the whole response,
+	// e.g. "30s". Defaults to 5 minutes.
+	//
+	// +optional
+	// +kubebuilder:default="5m"
+	Timeout *gwapiv1.Duration `json:"timeout,omitempty"`
 }
 
 // AIGatewayRouteRuleBackendRefKind specifies the kind of the backend reference.
@@ -230,6 +1230,8 @@ const (
 )
 
 // AIGatewayRouteRuleBackendRef is a reference to a backend with a weight.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.loadBalancing) || (has(self.kind) && self.kind == 'InferencePool')", message="loadBalancing is only valid for the InferencePool kind"
 type AIGatewayRouteRuleBackendRef struct {
 	// Kind is the kind of the backend, which is either "AIServiceBackend" or "InferencePool" in Gateway API Inference Extension.
 	//
@@ -246,6 +1248,7 @@ type AIGatewayRouteRuleBackendRef struct {
 	//
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
 	Name string `json:"name"`
 
 	// Weight is the weight of the AIServiceBackend. This is exactly the same as the weight in
@@ -258,20 +1261,205 @@ type AIGatewayRouteRuleBackendRef struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:default=1
 	Weight int `json:"weight,omitempty"`
+
+	// Priority is the priority of the backend in the rule. Only the backends with the lowest priority
+	// are selected by their weights, and the others are used only when the Fallback is configured on the rule,
+	// in which case they are tried in the ascending order of the priority.
+	//
+	// Default is 0.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Priority *uint32 `json:"priority,omitempty"`
+
+	// LoadBalancing is the configuration of how the endpoints of the InferencePool are selected for each request.
+	// This is only valid when the Kind is InferencePool.
+	//
+	// When this is not specified, the endpoint with the least outstanding requests is selected.
+	//
+	// +optional
+	LoadBalancing *InferencePoolLoadBalancing `json:"loadBalancing,omitempty"`
+
+	// ModelNameMappings maps the model names requested by the clients to the ones of this backend.
+	// For example, the client-facing name "chat-large" can be mapped to "gpt-4o" on OpenAI, a deployment name
+	// on Azure OpenAI, or an inference profile ARN on AWS Bedrock, so that the clients don't need to know the
+	// provider-specific model IDs.
+	//
+	// The mapped name replaces the model name in the request sent to the backend, including the request path
+	// for the schemas that put the model name in the path. The model names not listed here are sent as-is.
+	// The names in From are also listed by the /v1/models endpoint.
+	//
+	// +listType=map
+	// +listMapKey=from
+	// +optional
+	// +kubebuilder:validation:MaxItems=128
+	ModelNameMappings []ModelNameMapping `json:"modelNameMappings,omitempty"`
+}
+
+// ModelNameMapping maps the model name requested by the clients to the one of the backend.
+type ModelNameMapping struct {
+	// From is the model name requested by the clients.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	From string `json:"from"`
+
+	// To is the model name sent to the backend.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	To string `json:"to"`
+}
+
+// InferencePoolLoadBalancing is the configuration of the load balancing across the endpoints of the InferencePool.
+type InferencePoolLoadBalancing struct {
+	// Policy is the algorithm to select the endpoint.
+	//
+	// Default is LeastRequest.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=LeastRequest;Random;EndpointMetrics
+	// +kubebuilder:default=LeastRequest
+	Policy InferencePoolLoadBalancingPolicy `json:"policy,omitempty"`
+
+	// EndpointMetrics is the configuration of the metrics scraped from the endpoints.
+	// This is only used when the Policy is EndpointMetrics.
+	//
+	// +optional
+	EndpointMetrics *InferencePoolEndpointMetrics `json:"endpointMetrics,omitempty"`
+
+	// SessionAffinity routes the requests of the same conversation to the same endpoint so that the endpoint
+	// can reuse the prefix cache, e.g. the KV cache of vLLM, of the previous turns instead of recomputing the whole
+	// context. When the endpoint is overloaded, the request is routed to the next endpoint in the hash ring.
+	//
+	// The requests without the session, e.g. the ones without any user message, are routed by the Policy.
+	//
+	// +optional
+	SessionAffinity *InferencePoolSessionAffinity `json:"sessionAffinity,omitempty"`
+
+	// TLS enables the TLS connections to the endpoints of the InferencePool, e.g. the managed model servers outside
+	// the cluster. When this is not specified, the requests are sent to the endpoints in plaintext.
+	//
+	// +optional
+	TLS *InferencePoolTLS `json:"tls,omitempty"`
+}
+
+// InferencePoolTLS is the configuration of the TLS connections to the endpoints of the InferencePool.
+//
+// The SNI and the Host header of the requests are set to the hostname which the selected endpoint is resolved from,
+// and the certificate of the endpoint is verified against it. The endpoints specified by the IP addresses are
+// connected without the SNI, so their certificates cannot be verified by the hostname.
+type InferencePoolTLS struct {
+	// CACertificateRef is the reference to the Secret containing the PEM-encoded CA certificates to verify the
+	// certificates of the endpoints under the "ca.crt" key. The Secret must be in the same namespace as the
+	// AIGatewayRoute, otherwise the AIGatewayRoute is not accepted.
+	//
+	// The Secret is also mounted on the external processor to scrape the metrics of the endpoints with the
+	// EndpointMetrics policy.
+	//
+	// When this is not specified, the system trust store of Envoy and the external processor is used.
+	//
+	// +optional
+	CACertificateRef *gwapiv1.SecretObjectReference `json:"caCertificateRef,omitempty"`
+}
+
+// InferencePoolSessionAffinity is the configuration of the session affinity of the InferencePool.
+//
+// The session of a chat completion request is identified by the value of the HeaderName header if present.
+// Otherwise, it is identified by the model and the leading messages up to and including the first user message,
+// which stay the same across the turns of a conversation. The sessions are mapped onto the endpoints by the
+// consistent hashing with bounded loads, so that adding or removing an endpoint only moves a small portion of them.
+type InferencePoolSessionAffinity struct {
+	// HeaderName is the name of the request header identifying the session, e.g. "x-session-id".
+	// When this is not specified or the request does not have the header, the session is identified by the
+	// leading messages of the request.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	HeaderName *string `json:"headerName,omitempty"`
+
+	// MaxLoadPercentage is the maximum number of the outstanding requests of an endpoint relative to the
+	// average across the endpoints, above which the endpoint is regarded as overloaded and the session is routed
+	// to the next endpoint in the hash ring. A smaller value balances the load better at the cost of the affinity.
+	// An endpoint with fewer than 8 outstanding requests is never regarded as overloaded regardless of this.
+	//
+	// Default is 125, i.e. an endpoint can have up to 1.25 times the average outstanding requests.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=101
+	// +kubebuilder:validation:Maximum=1000
+	// +kubebuilder:default=125
+	MaxLoadPercentage *int32 `json:"maxLoadPercentage,omitempty"`
+}
+
+// InferencePoolLoadBalancingPolicy specifies the algorithm to select the endpoint of the InferencePool.
+type InferencePoolLoadBalancingPolicy string
+
+const (
+	// InferencePoolLoadBalancingPolicyLeastRequest selects the endpoint with the least outstanding requests
+	// sent by the AI Gateway filter.
+	InferencePoolLoadBalancingPolicyLeastRequest InferencePoolLoadBalancingPolicy = "LeastRequest"
+	// InferencePoolLoadBalancingPolicyRandom selects the endpoint randomly.
+	InferencePoolLoadBalancingPolicyRandom InferencePoolLoadBalancingPolicy = "Random"
+	// InferencePoolLoadBalancingPolicyEndpointMetrics selects the endpoint based on the metrics scraped from the
+	// endpoints in the Prometheus format exposed by the vLLM compatible model servers. The endpoints are scored by
+	// the KV cache utilization, the queue depth and whether the requested model (e.g. LoRA adapter) is already loaded.
+	//
+	// The endpoints whose metrics are not available fall back to the outstanding requests.
+	InferencePoolLoadBalancingPolicyEndpointMetrics InferencePoolLoadBalancingPolicy = "EndpointMetrics"
+)
+
+// InferencePoolEndpointMetrics is the configuration of the metrics scraped from the endpoints of the InferencePool.
+type InferencePoolEndpointMetrics struct {
+	// Path is the HTTP path of the metrics endpoint served on the same port as the model server.
+	//
+	// Default is "/metrics".
+	//
+	// +optional
+	// +kubebuilder:default="/metrics"
+	Path *string `json:"path,omitempty"`
+
+	// Interval is the interval between the scrapes of the metrics.
+	// The metrics are scraped in the background regardless of the requests being served.
+	//
+	// Default is "1s".
+	//
+	// +optional
+	// +kubebuilder:default="1s"
+	Interval *gwapiv1.Duration `json:"interval,omitempty"`
 }
 
 type AIGatewayRouteRuleMatch struct {
 	// Headers specifies HTTP request header matchers. See HeaderMatch in the Gateway API for the details:
 	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.HTTPHeaderMatch
 	//
-	// Currently, only the exact header matching is supported.
+	// Both Exact and RegularExpression types are supported. The regular expressions use the RE2 syntax
+	// and must match the whole header value.
 	//
 	// +listType=map
 	// +listMapKey=name
 	// +optional
 	// +kubebuilder:validation:MaxItems=16
-	// +kubebuilder:validation:XValidation:rule="self.all(match, match.type != 'RegularExpression')", message="currently only exact match is supported"
 	Headers []gwapiv1.HTTPHeaderMatch `json:"headers,omitempty"`
+
+	// QueryParams specifies HTTP query parameter matchers. See HTTPQueryParamMatch in the Gateway API for the details:
+	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.HTTPQueryParamMatch
+	//
+	// Both Exact and RegularExpression types are supported.
+	//
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	QueryParams []gwapiv1.HTTPQueryParamMatch `json:"queryParams,omitempty"`
+
+	// Path specifies a HTTP request path matcher. See HTTPPathMatch in the Gateway API for the details:
+	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.HTTPPathMatch
+	//
+	// The path is matched against the path of the original request without the query string.
+	//
+	// +optional
+	Path *gwapiv1.HTTPPathMatch `json:"path,omitempty"`
 }
 
 type AIGatewayFilterConfig struct {
@@ -344,6 +1532,8 @@ type AIServiceBackendList struct {
 }
 
 // AIServiceBackendSpec details the AIServiceBackend configuration.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.awsBedrockGuardrail) || self.schema.name == 'AWSBedrock'", message="awsBedrockGuardrail is only valid for the AWSBedrock schema"
 type AIServiceBackendSpec struct {
 	// APISchema specifies the API schema of the output format of requests from
 	// Envoy that this AIServiceBackend can accept as incoming requests.
@@ -374,10 +1564,33 @@ type AIServiceBackendSpec struct {
 	// +optional
 	Timeouts *gwapiv1.HTTPRouteTimeouts `json:"timeouts,omitempty"`
 
+	// AWSBedrockGuardrail is the Amazon Bedrock guardrail applied natively by Bedrock to the requests to this backend.
+	// This is only valid when the APISchema is AWSBedrock.
+	// See https://docs.aws.amazon.com/bedrock/latest/userguide/guardrails-use-converse-api.html
+	//
+	// +optional
+	AWSBedrockGuardrail *AWSBedrockGuardrail `json:"awsBedrockGuardrail,omitempty"`
+
 	// TODO: maybe add backend-level LLMRequestCost configuration that overrides the AIGatewayRoute-level LLMRequestCost.
 	// 	That may be useful for the backend that has a different cost calculation logic.
 }
 
+// AWSBedrockGuardrail specifies the Amazon Bedrock guardrail used in the Converse API.
+type AWSBedrockGuardrail struct {
+	// Identifier is the ID or the ARN of the guardrail.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Identifier string `json:"identifier"`
+	// Version is the version of the guardrail, e.g. "1" or "DRAFT".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// Trace enables the guardrail trace in the response. Defaults to false.
+	//
+	// +optional
+	Trace bool `json:"trace,omitempty"`
+}
+
 // VersionedAPISchema defines the API schema of either AIGatewayRoute (the input) or AIServiceBackend (the output).
 //
 // This allows the ai-gateway to understand the input and perform the necessary transformation
@@ -388,7 +1601,7 @@ type AIServiceBackendSpec struct {
 type VersionedAPISchema struct {
 	// Name is the name of the API schema of the AIGatewayRoute or AIServiceBackend.
 	//
-	// +kubebuilder:validation:Enum=OpenAI;AWSBedrock;AzureOpenAI
+	// +kubebuilder:validation:Enum=OpenAI;AWSBedrock;AzureOpenAI;Anthropic;GCPVertexAI
 	Name APISchema `json:"name"`
 
 	// Version is the version of the API schema.
@@ -411,6 +1624,16 @@ const (
 	//
 	// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#api-specs
 	APISchemaAzureOpenAI APISchema = "AzureOpenAI"
+	// APISchemaAnthropic is the Anthropic Messages API schema.
+	// The version is used as the value of the anthropic-version header, and defaults to 2023-06-01 if not specified.
+	//
+	// https://docs.anthropic.com/en/api/messages
+	APISchemaAnthropic APISchema = "Anthropic"
+	// APISchemaGCPVertexAI is the GCP Vertex AI schema for the Gemini models.
+	// The backend must be authenticated with the GCPCredentials BackendSecurityPolicy, which specifies the project and region.
+	//
+	// https://cloud.google.com/vertex-ai/docs/reference/rest/v1/projects.locations.publishers.models/generateContent
+	APISchemaGCPVertexAI APISchema = "GCPVertexAI"
 )
 
 const (
@@ -426,6 +1649,7 @@ const (
 	BackendSecurityPolicyTypeAPIKey           BackendSecurityPolicyType = "APIKey"
 	BackendSecurityPolicyTypeAWSCredentials   BackendSecurityPolicyType = "AWSCredentials"
 	BackendSecurityPolicyTypeAzureCredentials BackendSecurityPolicyType = "AzureCredentials"
+	BackendSecurityPolicyTypeGCPCredentials   BackendSecurityPolicyType = "GCPCredentials"
 )
 
 // BackendSecurityPolicy specifies configuration for authentication and authorization rules on the traffic
@@ -448,9 +1672,10 @@ type BackendSecurityPolicy struct {
 // Only one type of BackendSecurityPolicy can be defined.
 // +kubebuilder:validation:MaxProperties=2
 type BackendSecurityPolicySpec struct {
-	// Type specifies the auth mechanism used to access the provider. Currently, only "APIKey", "AWSCredentials", and "AzureCredentials" are supported.
+	// Type specifies the auth mechanism used to access the provider. Currently, only "APIKey", "AWSCredentials", "AzureCredentials",
+	// and "GCPCredentials" are supported.
 	//
-	// +kubebuilder:validation:Enum=APIKey;AWSCredentials;AzureCredentials
+	// +kubebuilder:validation:Enum=APIKey;AWSCredentials;AzureCredentials;GCPCredentials
 	Type BackendSecurityPolicyType `json:"type"`
 
 	// APIKey is a mechanism to access a backend(s). The API key will be injected into the Authorization header.
@@ -467,6 +1692,11 @@ type BackendSecurityPolicySpec struct {
 	//
 	// +optional
 	AzureCredentials *BackendSecurityPolicyAzureCredentials `json:"azureCredentials,omitempty"`
+
+	// GCPCredentials is a mechanism to access a backend(s). GCP Vertex AI specific logic will be applied.
+	//
+	// +optional
+	GCPCredentials *BackendSecurityPolicyGCPCredentials `json:"gcpCredentials,omitempty"`
 }
 
 // BackendSecurityPolicyList contains a list of BackendSecurityPolicy
@@ -506,6 +1736,71 @@ type BackendSecurityPolicyAzureCredentials struct {
 	ClientSecretRef *gwapiv1.SecretObjectReference `json:"clientSecretRef"`
 }
 
+// BackendSecurityPolicyGCPCredentials contains the supported authentication mechanisms to access GCP Vertex AI.
+// The controller exchanges the credentials for a short-lived access token and rotates it before it expires.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.serviceAccountKeyRef) != has(self.workloadIdentityFederation)", message="exactly one of serviceAccountKeyRef or workloadIdentityFederation must be specified"
+type BackendSecurityPolicyGCPCredentials struct {
+	// ProjectName is the name of the GCP project hosting the Vertex AI models.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ProjectName string `json:"projectName"`
+
+	// Region is the GCP region of the Vertex AI endpoint, e.g. "us-central1".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Region string `json:"region"`
+
+	// ServiceAccountKeyRef is the reference to the secret containing the service account key in JSON format.
+	// ai-gateway must be given the permission to read this secret.
+	// The key of the secret should be "service_account.json".
+	//
+	// +optional
+	ServiceAccountKeyRef *gwapiv1.SecretObjectReference `json:"serviceAccountKeyRef,omitempty"`
+
+	// WorkloadIdentityFederation specifies the configuration to exchange an OIDC token for a GCP access token
+	// via the Workload Identity Federation.
+	//
+	// +optional
+	WorkloadIdentityFederation *GCPWorkloadIdentityFederation `json:"workloadIdentityFederation,omitempty"`
+}
+
+// GCPWorkloadIdentityFederation specifies the configuration to obtain a GCP access token from an OIDC token.
+// The controller obtains the OIDC token from the SSO server, exchanges it via the GCP Security Token Service,
+// and optionally impersonates the service account.
+type GCPWorkloadIdentityFederation struct {
+	// ProjectNumber is the number of the GCP project hosting the workload identity pool.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ProjectNumber string `json:"projectNumber"`
+
+	// WorkloadIdentityPoolName is the name of the workload identity pool.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	WorkloadIdentityPoolName string `json:"workloadIdentityPoolName"`
+
+	// WorkloadIdentityProviderName is the name of the OIDC provider in the workload identity pool.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	WorkloadIdentityProviderName string `json:"workloadIdentityProviderName"`
+
+	// OIDC is used to obtain oidc tokens via an SSO server which will be exchanged for the GCP access token.
+	//
+	// +kubebuilder:validation:Required
+	OIDC egv1a1.OIDC `json:"oidc"`
+
+	// ServiceAccountEmail is the email of the service account to impersonate. If not specified, the federated
+	// access token is used as is, which requires the permissions to be granted to the federated identity directly.
+	//
+	// +optional
+	ServiceAccountEmail string `json:"serviceAccountEmail,omitempty"`
+}
+
 // BackendSecurityPolicyAWSCredentials contains the supported authentication mechanisms to access aws.
 type BackendSecurityPolicyAWSCredentials struct {
 	// Region specifies the AWS region associated with the policy.
@@ -574,9 +1869,9 @@ type LLMRequestCost struct {
 	MetadataKey string `json:"metadataKey"`
 	// Type specifies the type of the request cost. The default is "OutputToken",
 	// and it uses "output token" as the cost. The other types are "InputToken", "TotalToken",
-	// and "CEL".
+	// "EstimatedInputToken", and "CEL".
 	//
-	// +kubebuilder:validation:Enum=OutputToken;InputToken;TotalToken;CEL
+	// +kubebuilder:validation:Enum=OutputToken;InputToken;TotalToken;EstimatedInputToken;CEL
 	Type LLMRequestCostType `json:"type"`
 	// CEL is the CEL expression to calculate the cost of the request.
 	// The CEL expression must return a signed or unsigned integer. If the
@@ -589,6 +1884,7 @@ type LLMRequestCost struct {
 	//	* input_tokens: the number of input tokens. Type: unsigned integer.
 	//	* output_tokens: the number of output tokens. Type: unsigned integer.
 	//	* total_tokens: the total number of tokens. Type: unsigned integer.
+	//	* estimated_input_tokens: the number of input tokens estimated before the request is sent. Type: unsigned integer.
 	//
 	// For example, the following expressions are valid:
 	//
@@ -611,6 +1907,12 @@ const (
 	LLMRequestCostTypeOutputToken LLMRequestCostType = "OutputToken"
 	// LLMRequestCostTypeTotalToken is the cost type of the total token.
 	LLMRequestCostTypeTotalToken LLMRequestCostType = "TotalToken"
+	// LLMRequestCostTypeEstimatedInputToken is the cost type of the input token estimated before the request
+	// is sent to the backend. Unlike the other types, this is set in the dynamic metadata at the request path.
+	//
+	// The number of tokens is counted with the tokenizer of the model for the OpenAI models, and approximated
+	// from the text for the other models.
+	LLMRequestCostTypeEstimatedInputToken LLMRequestCostType = "EstimatedInputToken"
 	// LLMRequestCostTypeCEL is for calculating the cost using the CEL expression.
 	LLMRequestCostTypeCEL LLMRequestCostType = "CEL"
 )
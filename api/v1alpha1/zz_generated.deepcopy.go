@@ -138,6 +138,36 @@ func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Fallback != nil {
+		in, out := &in.Fallback, &out.Fallback
+		*out = new(AIGatewayRouteRuleFallback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hedging != nil {
+		in, out := &in.Hedging, &out.Hedging
+		*out = new(AIGatewayRouteRuleHedging)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(AIGatewayRouteRuleMirror)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(AIGatewayRouteRuleCircuitBreaker)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StickyRouting != nil {
+		in, out := &in.StickyRouting, &out.StickyRouting
+		*out = new(AIGatewayRouteRuleStickyRouting)
+		**out = **in
+	}
+	if in.Experiment != nil {
+		in, out := &in.Experiment, &out.Experiment
+		*out = new(AIGatewayRouteRuleExperiment)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRule.
@@ -158,6 +188,21 @@ func (in *AIGatewayRouteRuleBackendRef) DeepCopyInto(out *AIGatewayRouteRuleBack
 		*out = new(AIGatewayRouteRuleBackendRefKind)
 		**out = **in
 	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.LoadBalancing != nil {
+		in, out := &in.LoadBalancing, &out.LoadBalancing
+		*out = new(InferencePoolLoadBalancing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ModelNameMappings != nil {
+		in, out := &in.ModelNameMappings, &out.ModelNameMappings
+		*out = make([]ModelNameMapping, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleBackendRef.
@@ -170,6 +215,153 @@ func (in *AIGatewayRouteRuleBackendRef) DeepCopy() *AIGatewayRouteRuleBackendRef
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleCircuitBreaker) DeepCopyInto(out *AIGatewayRouteRuleCircuitBreaker) {
+	*out = *in
+	if in.ConsecutiveFailures != nil {
+		in, out := &in.ConsecutiveFailures, &out.ConsecutiveFailures
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ErrorRatePercentage != nil {
+		in, out := &in.ErrorRatePercentage, &out.ErrorRatePercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinimumRequests != nil {
+		in, out := &in.MinimumRequests, &out.MinimumRequests
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.SlowResponseThreshold != nil {
+		in, out := &in.SlowResponseThreshold, &out.SlowResponseThreshold
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.OpenDuration != nil {
+		in, out := &in.OpenDuration, &out.OpenDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HalfOpenRequests != nil {
+		in, out := &in.HalfOpenRequests, &out.HalfOpenRequests
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleCircuitBreaker.
+func (in *AIGatewayRouteRuleCircuitBreaker) DeepCopy() *AIGatewayRouteRuleCircuitBreaker {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleCircuitBreaker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleExperiment) DeepCopyInto(out *AIGatewayRouteRuleExperiment) {
+	*out = *in
+	if in.Arms != nil {
+		in, out := &in.Arms, &out.Arms
+		*out = make([]AIGatewayRouteRuleExperimentArm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleExperiment.
+func (in *AIGatewayRouteRuleExperiment) DeepCopy() *AIGatewayRouteRuleExperiment {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleExperiment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleExperimentArm) DeepCopyInto(out *AIGatewayRouteRuleExperimentArm) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BackendRefs != nil {
+		in, out := &in.BackendRefs, &out.BackendRefs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleExperimentArm.
+func (in *AIGatewayRouteRuleExperimentArm) DeepCopy() *AIGatewayRouteRuleExperimentArm {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleExperimentArm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleFallback) DeepCopyInto(out *AIGatewayRouteRuleFallback) {
+	*out = *in
+	if in.RetriableStatusCodes != nil {
+		in, out := &in.RetriableStatusCodes, &out.RetriableStatusCodes
+		*out = make([]v1.HTTPRouteRetryStatusCode, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxAttempts != nil {
+		in, out := &in.MaxAttempts, &out.MaxAttempts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleFallback.
+func (in *AIGatewayRouteRuleFallback) DeepCopy() *AIGatewayRouteRuleFallback {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleFallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleHedging) DeepCopyInto(out *AIGatewayRouteRuleHedging) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleHedging.
+func (in *AIGatewayRouteRuleHedging) DeepCopy() *AIGatewayRouteRuleHedging {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleHedging)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteRuleMatch) DeepCopyInto(out *AIGatewayRouteRuleMatch) {
 	*out = *in
@@ -180,6 +372,18 @@ func (in *AIGatewayRouteRuleMatch) DeepCopyInto(out *AIGatewayRouteRuleMatch) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.QueryParams != nil {
+		in, out := &in.QueryParams, &out.QueryParams
+		*out = make([]v1.HTTPQueryParamMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(v1.HTTPPathMatch)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMatch.
@@ -192,6 +396,51 @@ func (in *AIGatewayRouteRuleMatch) DeepCopy() *AIGatewayRouteRuleMatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMirror) DeepCopyInto(out *AIGatewayRouteRuleMirror) {
+	*out = *in
+	if in.ModelNameMappings != nil {
+		in, out := &in.ModelNameMappings, &out.ModelNameMappings
+		*out = make([]ModelNameMapping, len(*in))
+		copy(*out, *in)
+	}
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Comparison != nil {
+		in, out := &in.Comparison, &out.Comparison
+		*out = new(MirrorComparison)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMirror.
+func (in *AIGatewayRouteRuleMirror) DeepCopy() *AIGatewayRouteRuleMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleStickyRouting) DeepCopyInto(out *AIGatewayRouteRuleStickyRouting) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleStickyRouting.
+func (in *AIGatewayRouteRuleStickyRouting) DeepCopy() *AIGatewayRouteRuleStickyRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleStickyRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
 	*out = *in
@@ -222,6 +471,41 @@ func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ResponseCache != nil {
+		in, out := &in.ResponseCache, &out.ResponseCache
+		*out = new(ResponseCache)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Guardrails != nil {
+		in, out := &in.Guardrails, &out.Guardrails
+		*out = new(Guardrails)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpendBudgets != nil {
+		in, out := &in.SpendBudgets, &out.SpendBudgets
+		*out = new(SpendBudgets)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UsageRecords != nil {
+		in, out := &in.UsageRecords, &out.UsageRecords
+		*out = new(UsageRecords)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(Metrics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(AuditLog)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MirrorResults != nil {
+		in, out := &in.MirrorResults, &out.MirrorResults
+		*out = new(MirrorResults)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteSpec.
@@ -330,6 +614,11 @@ func (in *AIServiceBackendSpec) DeepCopyInto(out *AIServiceBackendSpec) {
 		*out = new(v1.HTTPRouteTimeouts)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AWSBedrockGuardrail != nil {
+		in, out := &in.AWSBedrockGuardrail, &out.AWSBedrockGuardrail
+		*out = new(AWSBedrockGuardrail)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendSpec.
@@ -364,6 +653,21 @@ func (in *AIServiceBackendStatus) DeepCopy() *AIServiceBackendStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSBedrockGuardrail) DeepCopyInto(out *AWSBedrockGuardrail) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSBedrockGuardrail.
+func (in *AWSBedrockGuardrail) DeepCopy() *AWSBedrockGuardrail {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSBedrockGuardrail)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWSCredentialsFile) DeepCopyInto(out *AWSCredentialsFile) {
 	*out = *in
@@ -400,6 +704,148 @@ func (in *AWSOIDCExchangeToken) DeepCopy() *AWSOIDCExchangeToken {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLog) DeepCopyInto(out *AuditLog) {
+	*out = *in
+	if in.SamplingPercentage != nil {
+		in, out := &in.SamplingPercentage, &out.SamplingPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Redaction != nil {
+		in, out := &in.Redaction, &out.Redaction
+		*out = new(AuditLogRedaction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]AuditLogSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLog.
+func (in *AuditLog) DeepCopy() *AuditLog {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogFileSink) DeepCopyInto(out *AuditLogFileSink) {
+	*out = *in
+	if in.PersistentVolumeClaimName != nil {
+		in, out := &in.PersistentVolumeClaimName, &out.PersistentVolumeClaimName
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxSizeMegabytes != nil {
+		in, out := &in.MaxSizeMegabytes, &out.MaxSizeMegabytes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogFileSink.
+func (in *AuditLogFileSink) DeepCopy() *AuditLogFileSink {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogFileSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogHTTPSink) DeepCopyInto(out *AuditLogHTTPSink) {
+	*out = *in
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(UsageRecordBatch)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogHTTPSink.
+func (in *AuditLogHTTPSink) DeepCopy() *AuditLogHTTPSink {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogHTTPSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogRedaction) DeepCopyInto(out *AuditLogRedaction) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogRedaction.
+func (in *AuditLogRedaction) DeepCopy() *AuditLogRedaction {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogRedaction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogSink) DeepCopyInto(out *AuditLogSink) {
+	*out = *in
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(AuditLogFileSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(AuditLogHTTPSink)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogSink.
+func (in *AuditLogSink) DeepCopy() *AuditLogSink {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicy) DeepCopyInto(out *BackendSecurityPolicy) {
 	*out = *in
@@ -492,6 +938,31 @@ func (in *BackendSecurityPolicyAzureCredentials) DeepCopy() *BackendSecurityPoli
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyGCPCredentials) DeepCopyInto(out *BackendSecurityPolicyGCPCredentials) {
+	*out = *in
+	if in.ServiceAccountKeyRef != nil {
+		in, out := &in.ServiceAccountKeyRef, &out.ServiceAccountKeyRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WorkloadIdentityFederation != nil {
+		in, out := &in.WorkloadIdentityFederation, &out.WorkloadIdentityFederation
+		*out = new(GCPWorkloadIdentityFederation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyGCPCredentials.
+func (in *BackendSecurityPolicyGCPCredentials) DeepCopy() *BackendSecurityPolicyGCPCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyGCPCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyList) DeepCopyInto(out *BackendSecurityPolicyList) {
 	*out = *in
@@ -542,6 +1013,11 @@ func (in *BackendSecurityPolicySpec) DeepCopyInto(out *BackendSecurityPolicySpec
 		*out = new(BackendSecurityPolicyAzureCredentials)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.GCPCredentials != nil {
+		in, out := &in.GCPCredentials, &out.GCPCredentials
+		*out = new(BackendSecurityPolicyGCPCredentials)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicySpec.
@@ -576,6 +1052,219 @@ func (in *BackendSecurityPolicyStatus) DeepCopy() *BackendSecurityPolicyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientSpendBudget) DeepCopyInto(out *ClientSpendBudget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientSpendBudget.
+func (in *ClientSpendBudget) DeepCopy() *ClientSpendBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientSpendBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPWorkloadIdentityFederation) DeepCopyInto(out *GCPWorkloadIdentityFederation) {
+	*out = *in
+	in.OIDC.DeepCopyInto(&out.OIDC)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPWorkloadIdentityFederation.
+func (in *GCPWorkloadIdentityFederation) DeepCopy() *GCPWorkloadIdentityFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPWorkloadIdentityFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuardrailModeration) DeepCopyInto(out *GuardrailModeration) {
+	*out = *in
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]GuardrailStage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuardrailModeration.
+func (in *GuardrailModeration) DeepCopy() *GuardrailModeration {
+	if in == nil {
+		return nil
+	}
+	out := new(GuardrailModeration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuardrailRule) DeepCopyInto(out *GuardrailRule) {
+	*out = *in
+	if in.RegularExpression != nil {
+		in, out := &in.RegularExpression, &out.RegularExpression
+		*out = new(string)
+		**out = **in
+	}
+	if in.PII != nil {
+		in, out := &in.PII, &out.PII
+		*out = make([]GuardrailPIIType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]GuardrailStage, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuardrailRule.
+func (in *GuardrailRule) DeepCopy() *GuardrailRule {
+	if in == nil {
+		return nil
+	}
+	out := new(GuardrailRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Guardrails) DeepCopyInto(out *Guardrails) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]GuardrailRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Moderation != nil {
+		in, out := &in.Moderation, &out.Moderation
+		*out = new(GuardrailModeration)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Guardrails.
+func (in *Guardrails) DeepCopy() *Guardrails {
+	if in == nil {
+		return nil
+	}
+	out := new(Guardrails)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InferencePoolEndpointMetrics) DeepCopyInto(out *InferencePoolEndpointMetrics) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(string)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InferencePoolEndpointMetrics.
+func (in *InferencePoolEndpointMetrics) DeepCopy() *InferencePoolEndpointMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(InferencePoolEndpointMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InferencePoolLoadBalancing) DeepCopyInto(out *InferencePoolLoadBalancing) {
+	*out = *in
+	if in.EndpointMetrics != nil {
+		in, out := &in.EndpointMetrics, &out.EndpointMetrics
+		*out = new(InferencePoolEndpointMetrics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SessionAffinity != nil {
+		in, out := &in.SessionAffinity, &out.SessionAffinity
+		*out = new(InferencePoolSessionAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(InferencePoolTLS)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InferencePoolLoadBalancing.
+func (in *InferencePoolLoadBalancing) DeepCopy() *InferencePoolLoadBalancing {
+	if in == nil {
+		return nil
+	}
+	out := new(InferencePoolLoadBalancing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InferencePoolSessionAffinity) DeepCopyInto(out *InferencePoolSessionAffinity) {
+	*out = *in
+	if in.HeaderName != nil {
+		in, out := &in.HeaderName, &out.HeaderName
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxLoadPercentage != nil {
+		in, out := &in.MaxLoadPercentage, &out.MaxLoadPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InferencePoolSessionAffinity.
+func (in *InferencePoolSessionAffinity) DeepCopy() *InferencePoolSessionAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(InferencePoolSessionAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InferencePoolTLS) DeepCopyInto(out *InferencePoolTLS) {
+	*out = *in
+	if in.CACertificateRef != nil {
+		in, out := &in.CACertificateRef, &out.CACertificateRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InferencePoolTLS.
+func (in *InferencePoolTLS) DeepCopy() *InferencePoolTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(InferencePoolTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LLMRequestCost) DeepCopyInto(out *LLMRequestCost) {
 	*out = *in
@@ -596,6 +1285,454 @@ func (in *LLMRequestCost) DeepCopy() *LLMRequestCost {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metrics) DeepCopyInto(out *Metrics) {
+	*out = *in
+	if in.RequestHeaderAttributes != nil {
+		in, out := &in.RequestHeaderAttributes, &out.RequestHeaderAttributes
+		*out = make([]MetricsRequestHeaderAttribute, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxAttributeValues != nil {
+		in, out := &in.MaxAttributeValues, &out.MaxAttributeValues
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metrics.
+func (in *Metrics) DeepCopy() *Metrics {
+	if in == nil {
+		return nil
+	}
+	out := new(Metrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsRequestHeaderAttribute) DeepCopyInto(out *MetricsRequestHeaderAttribute) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsRequestHeaderAttribute.
+func (in *MetricsRequestHeaderAttribute) DeepCopy() *MetricsRequestHeaderAttribute {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsRequestHeaderAttribute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorComparison) DeepCopyInto(out *MirrorComparison) {
+	*out = *in
+	if in.EmbeddingSimilarity != nil {
+		in, out := &in.EmbeddingSimilarity, &out.EmbeddingSimilarity
+		*out = new(MirrorEmbeddingSimilarity)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorComparison.
+func (in *MirrorComparison) DeepCopy() *MirrorComparison {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorComparison)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorEmbeddingSimilarity) DeepCopyInto(out *MirrorEmbeddingSimilarity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorEmbeddingSimilarity.
+func (in *MirrorEmbeddingSimilarity) DeepCopy() *MirrorEmbeddingSimilarity {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorEmbeddingSimilarity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorResults) DeepCopyInto(out *MirrorResults) {
+	*out = *in
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]AuditLogSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorResults.
+func (in *MirrorResults) DeepCopy() *MirrorResults {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorResults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelNameMapping) DeepCopyInto(out *ModelNameMapping) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelNameMapping.
+func (in *ModelNameMapping) DeepCopy() *ModelNameMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelNameMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModelPricing) DeepCopyInto(out *ModelPricing) {
+	*out = *in
+	if in.Backend != nil {
+		in, out := &in.Backend, &out.Backend
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelPricing.
+func (in *ModelPricing) DeepCopy() *ModelPricing {
+	if in == nil {
+		return nil
+	}
+	out := new(ModelPricing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResponseCache) DeepCopyInto(out *ResponseCache) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxEntries != nil {
+		in, out := &in.MaxEntries, &out.MaxEntries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResponseCache.
+func (in *ResponseCache) DeepCopy() *ResponseCache {
+	if in == nil {
+		return nil
+	}
+	out := new(ResponseCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpendBudgetClientIdentifier) DeepCopyInto(out *SpendBudgetClientIdentifier) {
+	*out = *in
+	if in.Header != nil {
+		in, out := &in.Header, &out.Header
+		*out = new(string)
+		**out = **in
+	}
+	if in.JWTClaim != nil {
+		in, out := &in.JWTClaim, &out.JWTClaim
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpendBudgetClientIdentifier.
+func (in *SpendBudgetClientIdentifier) DeepCopy() *SpendBudgetClientIdentifier {
+	if in == nil {
+		return nil
+	}
+	out := new(SpendBudgetClientIdentifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpendBudgetLocalStore) DeepCopyInto(out *SpendBudgetLocalStore) {
+	*out = *in
+	if in.PersistentVolumeClaimName != nil {
+		in, out := &in.PersistentVolumeClaimName, &out.PersistentVolumeClaimName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpendBudgetLocalStore.
+func (in *SpendBudgetLocalStore) DeepCopy() *SpendBudgetLocalStore {
+	if in == nil {
+		return nil
+	}
+	out := new(SpendBudgetLocalStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpendBudgetRedisStore) DeepCopyInto(out *SpendBudgetRedisStore) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(v1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KeyPrefix != nil {
+		in, out := &in.KeyPrefix, &out.KeyPrefix
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpendBudgetRedisStore.
+func (in *SpendBudgetRedisStore) DeepCopy() *SpendBudgetRedisStore {
+	if in == nil {
+		return nil
+	}
+	out := new(SpendBudgetRedisStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpendBudgetStore) DeepCopyInto(out *SpendBudgetStore) {
+	*out = *in
+	if in.Local != nil {
+		in, out := &in.Local, &out.Local
+		*out = new(SpendBudgetLocalStore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(SpendBudgetRedisStore)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpendBudgetStore.
+func (in *SpendBudgetStore) DeepCopy() *SpendBudgetStore {
+	if in == nil {
+		return nil
+	}
+	out := new(SpendBudgetStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpendBudgets) DeepCopyInto(out *SpendBudgets) {
+	*out = *in
+	in.ClientIdentifier.DeepCopyInto(&out.ClientIdentifier)
+	if in.Pricing != nil {
+		in, out := &in.Pricing, &out.Pricing
+		*out = make([]ModelPricing, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Clients != nil {
+		in, out := &in.Clients, &out.Clients
+		*out = make([]ClientSpendBudget, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultMonthlyLimit != nil {
+		in, out := &in.DefaultMonthlyLimit, &out.DefaultMonthlyLimit
+		*out = new(string)
+		**out = **in
+	}
+	in.Store.DeepCopyInto(&out.Store)
+	if in.FailOpen != nil {
+		in, out := &in.FailOpen, &out.FailOpen
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpendBudgets.
+func (in *SpendBudgets) DeepCopy() *SpendBudgets {
+	if in == nil {
+		return nil
+	}
+	out := new(SpendBudgets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordBatch) DeepCopyInto(out *UsageRecordBatch) {
+	*out = *in
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FlushInterval != nil {
+		in, out := &in.FlushInterval, &out.FlushInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordBatch.
+func (in *UsageRecordBatch) DeepCopy() *UsageRecordBatch {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordBatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordFileSink) DeepCopyInto(out *UsageRecordFileSink) {
+	*out = *in
+	if in.PersistentVolumeClaimName != nil {
+		in, out := &in.PersistentVolumeClaimName, &out.PersistentVolumeClaimName
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxSizeMegabytes != nil {
+		in, out := &in.MaxSizeMegabytes, &out.MaxSizeMegabytes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordFileSink.
+func (in *UsageRecordFileSink) DeepCopy() *UsageRecordFileSink {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordFileSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordOTLPSink) DeepCopyInto(out *UsageRecordOTLPSink) {
+	*out = *in
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(UsageRecordBatch)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordOTLPSink.
+func (in *UsageRecordOTLPSink) DeepCopy() *UsageRecordOTLPSink {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordOTLPSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordSink) DeepCopyInto(out *UsageRecordSink) {
+	*out = *in
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(UsageRecordFileSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(UsageRecordWebhookSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OTLP != nil {
+		in, out := &in.OTLP, &out.OTLP
+		*out = new(UsageRecordOTLPSink)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordSink.
+func (in *UsageRecordSink) DeepCopy() *UsageRecordSink {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecordWebhookSink) DeepCopyInto(out *UsageRecordWebhookSink) {
+	*out = *in
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(UsageRecordBatch)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecordWebhookSink.
+func (in *UsageRecordWebhookSink) DeepCopy() *UsageRecordWebhookSink {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecordWebhookSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageRecords) DeepCopyInto(out *UsageRecords) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]UsageRecordSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageRecords.
+func (in *UsageRecords) DeepCopy() *UsageRecords {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageRecords)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionedAPISchema) DeepCopyInto(out *VersionedAPISchema) {
 	*out = *in
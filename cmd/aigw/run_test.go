@@ -85,7 +85,7 @@ func TestRun(t *testing.T) {
 		t.Logf("status=%d, body: %s", resp.StatusCode, body)
 		// This ensures that the response is returned from the external processor where the body says about the
 		// matching rule not found since we send an empty JSON.
-		if resp.StatusCode != http.StatusNotFound || body != "no matching rule found" {
+		if resp.StatusCode != http.StatusNotFound || !strings.Contains(body, "no matching rule found") {
 			return false
 		}
 		return true
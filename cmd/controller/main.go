@@ -17,6 +17,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/envoyproxy/ai-gateway/internal/controller"
@@ -116,8 +117,14 @@ func main() {
 	ctx := ctrl.SetupSignalHandler()
 
 	// Start the extension server running alongside the controller.
+	// The client is not cached as the extension server only reads the resources when Envoy Gateway translates them.
+	extSrvClient, err := client.New(k8sConfig, client.Options{Scheme: controller.Scheme})
+	if err != nil {
+		setupLog.Error(err, "failed to create client for extension server")
+		os.Exit(1)
+	}
 	s := grpc.NewServer()
-	extSrv := extensionserver.New(ctrl.Log)
+	extSrv := extensionserver.New(ctrl.Log, extSrvClient)
 	egextension.RegisterEnvoyGatewayExtensionServer(s, extSrv)
 	grpc_health_v1.RegisterHealthServer(s, extSrv)
 	go func() {
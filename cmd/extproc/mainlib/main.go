@@ -15,14 +15,15 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
-	"go.opentelemetry.io/otel/metric"
 	metricsdk "go.opentelemetry.io/otel/sdk/metric"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -30,6 +31,7 @@ import (
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
 	"github.com/envoyproxy/ai-gateway/internal/extproc"
 	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
 	"github.com/envoyproxy/ai-gateway/internal/version"
 )
 
@@ -39,6 +41,15 @@ type extProcFlags struct {
 	extProcAddr string     // gRPC address for the external processor.
 	logLevel    slog.Level // log level for the external processor.
 	metricsAddr string     // HTTP address for the metrics server.
+	metricsOTLP metricsOTLPConfig
+	tracing     tracing.Config
+}
+
+// metricsOTLPConfig is the configuration of the OTLP push exporter of the metrics.
+type metricsOTLPConfig struct {
+	endpoint       string        // host:port address of the OTLP gRPC receiver of the metrics.
+	insecure       bool          // disables TLS for the connection to the receiver.
+	exportInterval time.Duration // interval between the exports.
 }
 
 // parseAndValidateFlags parses and validates the flags passed to the external processor.
@@ -66,6 +77,38 @@ func parseAndValidateFlags(args []string) (extProcFlags, error) {
 		"log level for the external processor. One of 'debug', 'info', 'warn', or 'error'.",
 	)
 	fs.StringVar(&flags.metricsAddr, "metricsAddr", ":9190", "HTTP address for the metrics server.")
+	fs.StringVar(&flags.metricsOTLP.endpoint,
+		"metricsOTLPEndpoint",
+		"",
+		"host:port address of the OTLP gRPC receiver the metrics are pushed to in addition to the Prometheus endpoint. "+
+			"When empty, the standard OTEL_EXPORTER_OTLP_METRICS_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT environment variable "+
+			"is used, and the push is disabled if neither is set.",
+	)
+	fs.BoolVar(&flags.metricsOTLP.insecure, "metricsOTLPInsecure", false, "disables TLS for the connection to the OTLP receiver of the metrics.")
+	fs.DurationVar(&flags.metricsOTLP.exportInterval,
+		"metricsOTLPExportInterval",
+		0,
+		"interval between the pushes of the metrics to the OTLP receiver. "+
+			"When zero, the standard OTEL_METRIC_EXPORT_INTERVAL environment variable or 60s is used.",
+	)
+	fs.StringVar(&flags.tracing.Endpoint,
+		"tracingEndpoint",
+		"",
+		"host:port address of the OTLP gRPC receiver of the spans. When empty, the standard "+
+			"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used, "+
+			"and the tracing is disabled if neither is set.",
+	)
+	fs.BoolVar(&flags.tracing.Insecure, "tracingInsecure", false, "disables TLS for the connection to the OTLP receiver of the spans.")
+	fs.Float64Var(&flags.tracing.SampleRatio,
+		"tracingSampleRatio",
+		1.0,
+		"ratio of the traces sampled when the request has no sampling decision in the traceparent header. From 0 to 1.",
+	)
+	fs.BoolVar(&flags.tracing.RecordContent,
+		"tracingRecordContent",
+		false,
+		"records the prompts and the completions as span events. They can contain sensitive information.",
+	)
 
 	if err := fs.Parse(args); err != nil {
 		return extProcFlags{}, fmt.Errorf("failed to parse extProcFlags: %w", err)
@@ -77,6 +120,12 @@ func parseAndValidateFlags(args []string) (extProcFlags, error) {
 	if err := flags.logLevel.UnmarshalText([]byte(*logLevelPtr)); err != nil {
 		errs = append(errs, fmt.Errorf("failed to unmarshal log level: %w", err))
 	}
+	if flags.metricsOTLP.exportInterval < 0 {
+		errs = append(errs, fmt.Errorf("metricsOTLPExportInterval must not be negative: %v", flags.metricsOTLP.exportInterval))
+	}
+	if r := flags.tracing.SampleRatio; r < 0 || r > 1 {
+		errs = append(errs, fmt.Errorf("tracingSampleRatio must be between 0 and 1: %v", r))
+	}
 
 	return flags, errors.Join(errs...)
 }
@@ -114,14 +163,28 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	metricsServer, meter := startMetricsServer(flags.metricsAddr, l)
+	otlpReaders, err := newMetricsOTLPReaders(ctx, flags.metricsOTLP)
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP metrics exporter: %w", err)
+	}
+	metricsServer, meterProvider := startMetricsServer(flags.metricsAddr, l, otlpReaders...)
+	meter := meterProvider.Meter("envoyproxy/ai-gateway")
 	chatCompletionMetrics := metrics.NewChatCompletion(meter, x.NewCustomChatCompletionMetrics)
+	embeddingsMetrics := metrics.NewEmbeddings(meter, x.NewCustomEmbeddingsMetrics)
+	// The responses are the chat operation in terms of the GenAI semantic conventions, so they share the metrics.
+	responsesMetrics := metrics.NewChatCompletion(meter, x.NewCustomChatCompletionMetrics)
 
-	server, err := extproc.NewServer(l)
+	tracer, err := tracing.New(ctx, flags.tracing)
+	if err != nil {
+		return fmt.Errorf("failed to create tracing: %w", err)
+	}
+	server, err := extproc.NewServer(l, tracer)
 	if err != nil {
 		return fmt.Errorf("failed to create external processor server: %w", err)
 	}
 	server.Register("/v1/chat/completions", extproc.ChatCompletionProcessorFactory(chatCompletionMetrics))
+	server.Register("/v1/embeddings", extproc.EmbeddingsProcessorFactory(embeddingsMetrics))
+	server.Register("/v1/responses", extproc.ResponsesProcessorFactory(responsesMetrics))
 	server.Register("/v1/models", extproc.NewModelsProcessor)
 
 	if err := extproc.StartConfigWatcher(ctx, flags.configPath, server, l, time.Second*5); err != nil {
@@ -141,6 +204,13 @@ func Main(ctx context.Context, args []string, stderr io.Writer) (err error) {
 		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
 			l.Error("Failed to shutdown metrics server gracefully", "error", err)
 		}
+		// This pushes the remaining metrics to the OTLP receiver, if any.
+		if err := meterProvider.Shutdown(shutdownCtx); err != nil {
+			l.Error("Failed to shutdown meter provider gracefully", "error", err)
+		}
+		if err := tracer.Shutdown(shutdownCtx); err != nil {
+			l.Error("Failed to shutdown tracing gracefully", "error", err)
+		}
 	}()
 
 	return s.Serve(lis)
@@ -154,15 +224,44 @@ func listenAddress(addrFlag string) (string, string) {
 	return "tcp", addrFlag
 }
 
-// startMetricsServer starts the HTTP server for Prometheus metrics.
-func startMetricsServer(addr string, logger *slog.Logger) (*http.Server, metric.Meter) {
+// newMetricsOTLPReaders creates the reader pushing the metrics to the OTLP receiver periodically.
+// This returns no reader if the push is disabled.
+func newMetricsOTLPReaders(ctx context.Context, config metricsOTLPConfig) ([]metricsdk.Reader, error) {
+	if config.endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") == "" &&
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return nil, nil
+	}
+	var opts []otlpmetricgrpc.Option
+	if config.endpoint != "" {
+		opts = append(opts, otlpmetricgrpc.WithEndpoint(config.endpoint))
+	}
+	if config.insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	exporter, err := otlpmetricgrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var readerOpts []metricsdk.PeriodicReaderOption
+	if config.exportInterval > 0 {
+		readerOpts = append(readerOpts, metricsdk.WithInterval(config.exportInterval))
+	}
+	return []metricsdk.Reader{metricsdk.NewPeriodicReader(exporter, readerOpts...)}, nil
+}
+
+// startMetricsServer starts the HTTP server for Prometheus metrics, and returns the meter provider
+// exporting the metrics to it as well as to the given additional readers.
+func startMetricsServer(addr string, logger *slog.Logger, readers ...metricsdk.Reader) (*http.Server, *metricsdk.MeterProvider) {
 	registry := prometheus.NewRegistry()
 	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
 	if err != nil {
 		log.Fatal("failed to create metrics exporter")
 	}
-	provider := metricsdk.NewMeterProvider(metricsdk.WithReader(exporter))
-	meter := provider.Meter("envoyproxy/ai-gateway")
+	opts := []metricsdk.Option{metricsdk.WithReader(exporter)}
+	for _, r := range readers {
+		opts = append(opts, metricsdk.WithReader(r))
+	}
+	provider := metricsdk.NewMeterProvider(opts...)
 
 	// Create a new HTTP server for metrics.
 	mux := http.NewServeMux()
@@ -194,5 +293,5 @@ func startMetricsServer(addr string, logger *slog.Logger) (*http.Server, metric.
 		}
 	}()
 
-	return server, meter
+	return server, provider
 }
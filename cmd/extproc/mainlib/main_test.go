@@ -10,9 +10,14 @@ import (
 	"log/slog"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
 )
 
 func Test_parseAndValidateFlags(t *testing.T) {
@@ -81,10 +86,49 @@ func Test_parseAndValidateFlags(t *testing.T) {
 		}
 	})
 
+	t.Run("tracing extProcFlags", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		assert.Equal(t, tracing.Config{SampleRatio: 1}, flags.tracing)
+
+		flags, err = parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-tracingEndpoint", "otel-collector:4317",
+			"-tracingInsecure",
+			"-tracingSampleRatio", "0.25",
+			"-tracingRecordContent",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, tracing.Config{
+			Endpoint: "otel-collector:4317", Insecure: true, SampleRatio: 0.25, RecordContent: true,
+		}, flags.tracing)
+	})
+
+	t.Run("metrics OTLP extProcFlags", func(t *testing.T) {
+		flags, err := parseAndValidateFlags([]string{"-configPath", "/path/to/config.yaml"})
+		require.NoError(t, err)
+		assert.Equal(t, metricsOTLPConfig{}, flags.metricsOTLP)
+
+		flags, err = parseAndValidateFlags([]string{
+			"-configPath", "/path/to/config.yaml",
+			"-metricsOTLPEndpoint", "otel-collector:4317",
+			"-metricsOTLPInsecure",
+			"-metricsOTLPExportInterval", "15s",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, metricsOTLPConfig{
+			endpoint: "otel-collector:4317", insecure: true, exportInterval: 15 * time.Second,
+		}, flags.metricsOTLP)
+	})
+
 	t.Run("invalid extProcFlags", func(t *testing.T) {
-		_, err := parseAndValidateFlags([]string{"-logLevel", "invalid"})
+		_, err := parseAndValidateFlags([]string{
+			"-logLevel", "invalid", "-metricsOTLPExportInterval", "-1s", "-tracingSampleRatio", "2",
+		})
 		assert.EqualError(t, err, `configPath must be provided
-failed to unmarshal log level: slog: level string "invalid": unknown name`)
+failed to unmarshal log level: slog: level string "invalid": unknown name
+metricsOTLPExportInterval must not be negative: -1s
+tracingSampleRatio must be between 0 and 1: 2`)
 	})
 }
 
@@ -107,12 +151,43 @@ func TestListenAddress(t *testing.T) {
 	}
 }
 
+func TestNewMetricsOTLPReaders(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	readers, err := newMetricsOTLPReaders(t.Context(), metricsOTLPConfig{})
+	require.NoError(t, err)
+	require.Empty(t, readers)
+
+	readers, err = newMetricsOTLPReaders(t.Context(), metricsOTLPConfig{
+		endpoint: "127.0.0.1:4317", insecure: true, exportInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.Len(t, readers, 1)
+	require.NoError(t, readers[0].Shutdown(t.Context()))
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://127.0.0.1:4317")
+	readers, err = newMetricsOTLPReaders(t.Context(), metricsOTLPConfig{})
+	require.NoError(t, err)
+	require.Len(t, readers, 1)
+	require.NoError(t, readers[0].Shutdown(t.Context()))
+}
+
 func TestStartMetricsServer(t *testing.T) {
-	s, m := startMetricsServer("127.0.0.1:", slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})))
+	reader := metricsdk.NewManualReader()
+	s, p := startMetricsServer("127.0.0.1:", slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})), reader)
 	t.Cleanup(func() { _ = s.Shutdown(t.Context()) })
 
 	require.NotNil(t, s)
-	require.NotNil(t, m)
+	require.NotNil(t, p)
+
+	// The metrics are also exported to the additional reader.
+	c, err := p.Meter("test").Int64Counter("test_counter")
+	require.NoError(t, err)
+	c.Add(t.Context(), 1)
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Equal(t, "test_counter", rm.ScopeMetrics[0].Metrics[0].Name)
 
 	require.HTTPStatusCode(t, s.Handler.ServeHTTP, http.MethodGet, "/", nil, http.StatusNotFound)
 
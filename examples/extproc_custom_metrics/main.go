@@ -39,6 +39,13 @@ func main() {
 	}
 }
 
+var (
+	_ x.ResponseCacheMetrics  = &myCustomChatCompletionMetrics{}
+	_ x.HedgeMetrics          = &myCustomChatCompletionMetrics{}
+	_ x.MirrorMetrics         = &myCustomChatCompletionMetrics{}
+	_ x.CircuitBreakerMetrics = &myCustomChatCompletionMetrics{}
+)
+
 // newCustomChatCompletionMetrics implements [x.NewCustomChatCompletionMetrics].
 func newCustomChatCompletionMetrics(meter metric.Meter) x.ChatCompletionMetrics {
 	return &myCustomChatCompletionMetrics{
@@ -47,7 +54,7 @@ func newCustomChatCompletionMetrics(meter metric.Meter) x.ChatCompletionMetrics
 	}
 }
 
-// myCustomChatCompletionMetrics implements [x.ChatCompletionMetrics].
+// myCustomChatCompletionMetrics implements [x.ChatCompletionMetrics] as well as its optional interfaces.
 type myCustomChatCompletionMetrics struct {
 	meter  metric.Meter
 	logger *slog.Logger
@@ -76,3 +83,19 @@ func (m *myCustomChatCompletionMetrics) RecordRequestCompletion(_ context.Contex
 func (m *myCustomChatCompletionMetrics) RecordTokenLatency(_ context.Context, tokens uint32, _ ...attribute.KeyValue) {
 	m.logger.Info("RecordTokenLatency", "tokens", tokens)
 }
+
+func (m *myCustomChatCompletionMetrics) RecordResponseCacheLookup(_ context.Context, hit bool, _ ...attribute.KeyValue) {
+	m.logger.Info("RecordResponseCacheLookup", "hit", hit)
+}
+
+func (m *myCustomChatCompletionMetrics) RecordHedge(_ context.Context, result x.HedgeResult, _ ...attribute.KeyValue) {
+	m.logger.Info("RecordHedge", "result", result)
+}
+
+func (m *myCustomChatCompletionMetrics) RecordMirror(_ context.Context, comparison *x.MirrorComparison, _ ...attribute.KeyValue) {
+	m.logger.Info("RecordMirror", "shadowBackend", comparison.ShadowBackend, "shadowSucceeded", comparison.ShadowSucceeded)
+}
+
+func (m *myCustomChatCompletionMetrics) RecordCircuitBreakerTransition(_ context.Context, backend string, from, to x.CircuitBreakerState) {
+	m.logger.Info("RecordCircuitBreakerTransition", "backend", backend, "from", from, "to", to)
+}
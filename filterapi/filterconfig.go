@@ -16,6 +16,7 @@ package filterapi
 
 import (
 	"os"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/yaml"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -88,6 +89,288 @@ type Config struct {
 	// Rules is the routing rules to be used by the filter to make the routing decision.
 	// Inside the routing rules, the header ModelNameHeaderKey may be used to make the routing decision.
 	Rules []RouteRule `json:"rules"`
+	// ResponseCache configures the cache of the chat completion responses. Optional.
+	// If this is provided, the filter serves the cached response for the identical request without
+	// sending it to the backend.
+	ResponseCache *ResponseCacheConfig `json:"responseCache,omitempty"`
+	// Guardrails configures the content checks of the chat completion requests and responses. Optional.
+	Guardrails *GuardrailsConfig `json:"guardrails,omitempty"`
+	// SpendBudgets configures the monthly spend budgets of the clients. Optional.
+	SpendBudgets *SpendBudgetsConfig `json:"spendBudgets,omitempty"`
+	// UsageRecords configures the export of the usage records of the requests. Optional.
+	UsageRecords *UsageRecordsConfig `json:"usageRecords,omitempty"`
+	// Metrics configures the attributes of the metrics of the requests. Optional.
+	Metrics *MetricsConfig `json:"metrics,omitempty"`
+	// AuditLog configures the audit log of the chat completion requests and their completions. Optional.
+	AuditLog *AuditLogConfig `json:"auditLog,omitempty"`
+	// MirrorResults configures the export of the results of the mirrored requests. Optional.
+	MirrorResults *MirrorResultsConfig `json:"mirrorResults,omitempty"`
+}
+
+// MetricsConfig corresponds to Metrics in api/v1alpha1/api.go.
+type MetricsConfig struct {
+	// RequestHeaderAttributes are the metric attributes whose values are taken from the request headers.
+	RequestHeaderAttributes []MetricsRequestHeaderAttribute `json:"requestHeaderAttributes,omitempty"`
+	// MaxAttributeValues is the maximum number of the distinct values of each attribute in RequestHeaderAttributes.
+	MaxAttributeValues int `json:"maxAttributeValues"`
+}
+
+// MetricsRequestHeaderAttribute corresponds to MetricsRequestHeaderAttribute in api/v1alpha1/api.go.
+type MetricsRequestHeaderAttribute struct {
+	// Header is the name of the request header.
+	Header string `json:"header"`
+	// Attribute is the name of the metric attribute.
+	Attribute string `json:"attribute"`
+}
+
+// UsageRecordsConfig corresponds to UsageRecords in api/v1alpha1/api.go.
+type UsageRecordsConfig struct {
+	// Headers are the names of the request headers included in the records.
+	Headers []string `json:"headers,omitempty"`
+	// Sinks are where the records are exported.
+	Sinks []UsageRecordSink `json:"sinks"`
+}
+
+// UsageRecordSink corresponds to UsageRecordSink in api/v1alpha1/api.go.
+type UsageRecordSink struct {
+	// Type is the type of the sink.
+	Type UsageRecordSinkType `json:"type"`
+	// Path is the path to the file of the File sink.
+	Path string `json:"path,omitempty"`
+	// MaxSizeMegabytes is the size in megabytes at which the file of the File sink is rotated.
+	MaxSizeMegabytes int `json:"maxSizeMegabytes,omitempty"`
+	// MaxBackups is the maximum number of the rotated files of the File sink.
+	MaxBackups int `json:"maxBackups,omitempty"`
+	// URL is the URL of the Webhook sink.
+	URL string `json:"url,omitempty"`
+	// OTLPEndpoint is the "host:port" address of the OTLP gRPC receiver of the OTLP sink.
+	OTLPEndpoint string `json:"otlpEndpoint,omitempty"`
+	// OTLPInsecure disables TLS for the OTLP sink.
+	OTLPInsecure bool `json:"otlpInsecure,omitempty"`
+	// BatchSize is the maximum number of the records exported at once.
+	BatchSize int `json:"batchSize"`
+	// FlushInterval is the maximum duration a record waits before being exported.
+	FlushInterval time.Duration `json:"flushInterval"`
+	// MaxRetries is the maximum number of the retries of a failed export.
+	MaxRetries int `json:"maxRetries,omitempty"`
+}
+
+// UsageRecordSinkType corresponds to UsageRecordSinkType in api/v1alpha1/api.go.
+type UsageRecordSinkType string
+
+const (
+	// UsageRecordSinkTypeFile writes the records as JSON lines to a rotated file.
+	UsageRecordSinkTypeFile UsageRecordSinkType = "File"
+	// UsageRecordSinkTypeWebhook sends the records as JSON arrays with HTTP POST requests.
+	UsageRecordSinkTypeWebhook UsageRecordSinkType = "Webhook"
+	// UsageRecordSinkTypeOTLP exports the records as OpenTelemetry log records with OTLP over gRPC.
+	UsageRecordSinkTypeOTLP UsageRecordSinkType = "OTLP"
+)
+
+// AuditLogConfig corresponds to AuditLog in api/v1alpha1/api.go.
+type AuditLogConfig struct {
+	// SamplingPercentage is the percentage of the requests recorded, from 0 to 100.
+	SamplingPercentage int `json:"samplingPercentage"`
+	// Headers are the names of the request headers included in the entries.
+	Headers []string `json:"headers,omitempty"`
+	// Redaction configures the values removed from the entries. Optional.
+	Redaction *AuditLogRedaction `json:"redaction,omitempty"`
+	// Sinks are where the entries are exported.
+	Sinks []AuditLogSink `json:"sinks"`
+}
+
+// AuditLogRedaction corresponds to AuditLogRedaction in api/v1alpha1/api.go.
+type AuditLogRedaction struct {
+	// Patterns are the regular expressions whose matches in the string values are redacted.
+	Patterns []string `json:"patterns,omitempty"`
+	// Fields are the paths of the values redacted as a whole, e.g. "request.messages[*].content".
+	Fields []string `json:"fields,omitempty"`
+	// Headers are the names of the request headers whose values are redacted.
+	Headers []string `json:"headers,omitempty"`
+}
+
+// AuditLogSink corresponds to AuditLogSink in api/v1alpha1/api.go.
+type AuditLogSink struct {
+	// Type is the type of the sink.
+	Type AuditLogSinkType `json:"type"`
+	// Path is the path to the file of the File sink.
+	Path string `json:"path,omitempty"`
+	// MaxSizeMegabytes is the size in megabytes at which the file of the File sink is rotated.
+	MaxSizeMegabytes int `json:"maxSizeMegabytes,omitempty"`
+	// MaxBackups is the maximum number of the rotated files of the File sink.
+	MaxBackups int `json:"maxBackups,omitempty"`
+	// URL is the URL of the HTTP sink.
+	URL string `json:"url,omitempty"`
+	// BatchSize is the maximum number of the entries exported at once.
+	BatchSize int `json:"batchSize"`
+	// FlushInterval is the maximum duration an entry waits before being exported.
+	FlushInterval time.Duration `json:"flushInterval"`
+	// MaxRetries is the maximum number of the retries of a failed export.
+	MaxRetries int `json:"maxRetries,omitempty"`
+}
+
+// AuditLogSinkType corresponds to AuditLogSinkType in api/v1alpha1/api.go.
+type AuditLogSinkType string
+
+const (
+	// AuditLogSinkTypeFile writes the entries as JSON lines to a rotated file.
+	AuditLogSinkTypeFile AuditLogSinkType = "File"
+	// AuditLogSinkTypeHTTP sends the entries as JSON arrays with HTTP POST requests.
+	AuditLogSinkTypeHTTP AuditLogSinkType = "HTTP"
+)
+
+// MirrorResultsConfig corresponds to MirrorResults in api/v1alpha1/api.go.
+type MirrorResultsConfig struct {
+	// Sinks are where the results are exported.
+	Sinks []AuditLogSink `json:"sinks"`
+}
+
+// SpendBudgetsConfig corresponds to SpendBudgets in api/v1alpha1/api.go.
+//
+// All the amounts are in nano USD, i.e. 1e-9 USD.
+type SpendBudgetsConfig struct {
+	// ClientIDHeader is the request header identifying the client. Either this or ClientIDJWTClaim is set.
+	ClientIDHeader string `json:"clientIDHeader,omitempty"`
+	// ClientIDJWTClaim is the claim in the JWT bearer token of the authorization header identifying the client.
+	ClientIDJWTClaim string `json:"clientIDJWTClaim,omitempty"`
+	// Pricing is the list of the prices of the models.
+	Pricing []ModelPricing `json:"pricing"`
+	// Clients is the list of the monthly budgets of the individual clients.
+	Clients []ClientSpendBudget `json:"clients,omitempty"`
+	// DefaultMonthlyLimit is the monthly budget of the clients not listed in Clients. Zero means unlimited.
+	DefaultMonthlyLimit int64 `json:"defaultMonthlyLimit,omitempty"`
+	// Store configures where the spend is persisted.
+	Store SpendBudgetStoreConfig `json:"store"`
+	// FailOpen lets the requests through when the spend cannot be read from the store.
+	FailOpen bool `json:"failOpen,omitempty"`
+}
+
+// ModelPricing corresponds to ModelPricing in api/v1alpha1/api.go.
+type ModelPricing struct {
+	// Model is the name of the model sent to the backend.
+	Model string `json:"model"`
+	// Backend is the name of the backend. Empty means all the backends.
+	Backend string `json:"backend,omitempty"`
+	// InputPerMillionTokens is the price of one million input tokens in nano USD.
+	InputPerMillionTokens int64 `json:"inputPerMillionTokens"`
+	// OutputPerMillionTokens is the price of one million output tokens in nano USD.
+	OutputPerMillionTokens int64 `json:"outputPerMillionTokens"`
+}
+
+// ClientSpendBudget corresponds to ClientSpendBudget in api/v1alpha1/api.go.
+type ClientSpendBudget struct {
+	// Client is the value of the client identifier.
+	Client string `json:"client"`
+	// MonthlyLimit is the monthly budget of the client in nano USD.
+	MonthlyLimit int64 `json:"monthlyLimit"`
+}
+
+// SpendBudgetStoreConfig corresponds to SpendBudgetStore in api/v1alpha1/api.go.
+type SpendBudgetStoreConfig struct {
+	// Type is the type of the store.
+	Type SpendBudgetStoreType `json:"type"`
+	// Path is the path to the file of the Local store.
+	Path string `json:"path,omitempty"`
+	// RedisAddress is the "host:port" address of the Redis store.
+	RedisAddress string `json:"redisAddress,omitempty"`
+	// RedisPasswordFile is the path to the file containing the password of the Redis store. Optional.
+	RedisPasswordFile string `json:"redisPasswordFile,omitempty"`
+	// RedisTLS enables TLS for the connection to the Redis store.
+	RedisTLS bool `json:"redisTLS,omitempty"`
+	// RedisKeyPrefix is the prefix of the keys in the Redis store.
+	RedisKeyPrefix string `json:"redisKeyPrefix,omitempty"`
+}
+
+// SpendBudgetStoreType corresponds to SpendBudgetStoreType in api/v1alpha1/api.go.
+type SpendBudgetStoreType string
+
+const (
+	// SpendBudgetStoreTypeLocal persists the spend in a local file.
+	SpendBudgetStoreTypeLocal SpendBudgetStoreType = "Local"
+	// SpendBudgetStoreTypeRedis persists the spend in Redis.
+	SpendBudgetStoreTypeRedis SpendBudgetStoreType = "Redis"
+)
+
+// GuardrailsConfig corresponds to Guardrails in api/v1alpha1/api.go.
+type GuardrailsConfig struct {
+	// Rules are the built-in rules evaluated by the filter.
+	Rules []GuardrailRule `json:"rules,omitempty"`
+	// Moderation is the external content moderation service. Optional.
+	Moderation *GuardrailModeration `json:"moderation,omitempty"`
+}
+
+// GuardrailRule corresponds to GuardrailRule in api/v1alpha1/api.go.
+type GuardrailRule struct {
+	// Name is the name of the rule reported when the rule matches.
+	Name string `json:"name"`
+	// RegularExpression is the regular expression matched against the content. Either this or PII is set.
+	RegularExpression string `json:"regularExpression,omitempty"`
+	// PII is the list of the kinds of the personally identifiable information detected by the rule.
+	PII []GuardrailPIIType `json:"pii,omitempty"`
+	// Action is the action taken when the rule matches.
+	Action GuardrailAction `json:"action"`
+	// Stages are the stages where the rule is applied. Empty means all stages.
+	Stages []GuardrailStage `json:"stages,omitempty"`
+}
+
+// GuardrailPIIType corresponds to GuardrailPIIType in api/v1alpha1/api.go.
+type GuardrailPIIType string
+
+const (
+	// GuardrailPIITypeEmail detects the email addresses.
+	GuardrailPIITypeEmail GuardrailPIIType = "Email"
+	// GuardrailPIITypePhoneNumber detects the phone numbers.
+	GuardrailPIITypePhoneNumber GuardrailPIIType = "PhoneNumber"
+	// GuardrailPIITypeCreditCardNumber detects the credit card numbers.
+	GuardrailPIITypeCreditCardNumber GuardrailPIIType = "CreditCardNumber"
+	// GuardrailPIITypeUSSocialSecurityNumber detects the US social security numbers.
+	GuardrailPIITypeUSSocialSecurityNumber GuardrailPIIType = "USSocialSecurityNumber"
+	// GuardrailPIITypeIPAddress detects the IPv4 addresses.
+	GuardrailPIITypeIPAddress GuardrailPIIType = "IPAddress"
+)
+
+// GuardrailModeration corresponds to GuardrailModeration in api/v1alpha1/api.go.
+type GuardrailModeration struct {
+	// URL is the URL of the moderation endpoint implementing the OpenAI moderation API.
+	URL string `json:"url"`
+	// Action is the action taken when the content is flagged.
+	Action GuardrailAction `json:"action"`
+	// Stages are the stages where the moderation is applied. Empty means all stages.
+	Stages []GuardrailStage `json:"stages,omitempty"`
+	// Timeout is the timeout of the call to the moderation service.
+	Timeout time.Duration `json:"timeout"`
+	// FailOpen lets the content through when the moderation service fails.
+	FailOpen bool `json:"failOpen,omitempty"`
+}
+
+// GuardrailAction corresponds to GuardrailAction in api/v1alpha1/api.go.
+type GuardrailAction string
+
+const (
+	// GuardrailActionBlock rejects the request.
+	GuardrailActionBlock GuardrailAction = "Block"
+	// GuardrailActionRedact replaces the matched content.
+	GuardrailActionRedact GuardrailAction = "Redact"
+	// GuardrailActionAnnotate lets the content through with the header reporting the matched rules.
+	GuardrailActionAnnotate GuardrailAction = "Annotate"
+)
+
+// GuardrailStage corresponds to GuardrailStage in api/v1alpha1/api.go.
+type GuardrailStage string
+
+const (
+	// GuardrailStageRequest is the stage before the request is sent to the backend.
+	GuardrailStageRequest GuardrailStage = "Request"
+	// GuardrailStageResponse is the stage after the response is received from the backend.
+	GuardrailStageResponse GuardrailStage = "Response"
+)
+
+// ResponseCacheConfig corresponds to ResponseCache in api/v1alpha1/api.go.
+type ResponseCacheConfig struct {
+	// TTL is the duration for which the cached response is served.
+	TTL time.Duration `json:"ttl"`
+	// MaxEntries is the maximum number of the cached responses kept by the filter.
+	MaxEntries int `json:"maxEntries"`
 }
 
 // LLMRequestCost specifies "where" the request cost is stored in the filter metadata as well as
@@ -117,6 +400,9 @@ const (
 	LLMRequestCostTypeInputToken LLMRequestCostType = "InputToken"
 	// LLMRequestCostTypeTotalToken specifies that the request cost is calculated from the total token.
 	LLMRequestCostTypeTotalToken LLMRequestCostType = "TotalToken"
+	// LLMRequestCostTypeEstimatedInputToken specifies that the request cost is the input token estimated before
+	// the request is sent to the backend. This is populated in the filter metadata at the request body processing.
+	LLMRequestCostTypeEstimatedInputToken LLMRequestCostType = "EstimatedInputToken"
 	// LLMRequestCostTypeCEL specifies that the request cost is calculated from the CEL expression.
 	LLMRequestCostTypeCEL LLMRequestCostType = "CEL"
 )
@@ -136,22 +422,171 @@ const (
 	APISchemaOpenAI      APISchemaName = "OpenAI"
 	APISchemaAWSBedrock  APISchemaName = "AWSBedrock"
 	APISchemaAzureOpenAI APISchemaName = "AzureOpenAI"
+	APISchemaAnthropic   APISchemaName = "Anthropic"
+	APISchemaGCPVertexAI APISchemaName = "GCPVertexAI"
 )
 
 // HeaderMatch is an alias for HTTPHeaderMatch of the Gateway API.
 type HeaderMatch = gwapiv1.HTTPHeaderMatch
 
+// QueryParamMatch is an alias for HTTPQueryParamMatch of the Gateway API.
+type QueryParamMatch = gwapiv1.HTTPQueryParamMatch
+
+// PathMatch is an alias for HTTPPathMatch of the Gateway API.
+type PathMatch = gwapiv1.HTTPPathMatch
+
 // RouteRule corresponds to AIGatewayRoute in api/v1alpha1/api.go
 // besides the `Backends` field is modified to abstract the concept of a backend
 // at Envoy Gateway level to a simple name.
 type RouteRule struct {
-	// Headers is the list of headers to match for the routing decision.
-	// Currently, only exact match is supported.
-	Headers []HeaderMatch `json:"headers"`
+	// Headers is the list of headers to match for the routing decision. The rule matches
+	// if any of the headers matches, i.e. each header is a separate match. The header type is respected in the same way as Matches.
+	//
+	// This is kept for the compatibility, and Matches should be used instead.
+	Headers []HeaderMatch `json:"headers,omitempty"`
+	// Matches is the list of the matches for the routing decision. The rule matches
+	// if any of the matches or any of the Headers matches.
+	Matches []RouteRuleMatch `json:"matches,omitempty"`
 	// Backends is the list of backends to which the request should be routed to when the headers match.
 	Backends []Backend `json:"backends"`
+	// Fallback is the failover configuration of this rule. Optional.
+	//
+	// When this is specified, the filter retries the request on the other backends in the ascending order
+	// of [Backend.Priority] when the selected backend responds with a retriable status code.
+	Fallback *FallbackPolicy `json:"fallback,omitempty"`
+	// Hedging is the hedged request configuration of this rule. Optional.
+	//
+	// When this is specified, the filter sends the non-streaming request directly to the selected backend,
+	// and the duplicate to the next backend in the ascending order of [Backend.Priority] when the selected
+	// one hasn't responded within the delay. The streaming requests are not hedged.
+	Hedging *HedgingPolicy `json:"hedging,omitempty"`
+	// Mirror is the traffic mirroring configuration of this rule. Optional.
+	//
+	// When this is specified, the filter sends a copy of the sampled requests directly to the shadow backend in the
+	// background, and compares its response with the one of the selected backend.
+	Mirror *MirrorPolicy `json:"mirror,omitempty"`
+	// CircuitBreaker is the circuit breaking configuration of the backends of this rule. Optional.
+	//
+	// When this is specified, the router excludes the backends with the open circuit from the selection.
+	CircuitBreaker *CircuitBreakerPolicy `json:"circuitBreaker,omitempty"`
+	// StickyRouting is the sticky routing configuration of this rule. Optional.
+	//
+	// When this is specified, the router selects the backend and the arm of the Experiment by the consistent hashing
+	// of the value of the header instead of randomly.
+	StickyRouting *StickyRouting `json:"stickyRouting,omitempty"`
+	// Experiment is the A/B experiment among the backends of this rule. Optional.
+	Experiment *Experiment `json:"experiment,omitempty"`
+}
+
+// RouteRuleMatch corresponds to AIGatewayRouteRuleMatch in api/v1alpha1/api.go.
+// All the conditions must be satisfied for the match.
+type RouteRuleMatch struct {
+	// Headers is the list of the header matches. Both Exact and RegularExpression types are supported, where
+	// the regular expression must match the whole value.
+	Headers []HeaderMatch `json:"headers,omitempty"`
+	// QueryParams is the list of the query parameter matches. Both Exact and RegularExpression types are supported,
+	// where the regular expression must match the whole value.
+	QueryParams []QueryParamMatch `json:"queryParams,omitempty"`
+	// Path is the match of the request path without the query. Exact, PathPrefix and RegularExpression types
+	// are supported, where the regular expression must match the whole path.
+	Path *PathMatch `json:"path,omitempty"`
+}
+
+// FallbackPolicy corresponds to AIGatewayRouteRuleFallback in api/v1alpha1/api.go.
+type FallbackPolicy struct {
+	// RetriableStatusCodes is the list of status codes that trigger the failover.
+	// When empty, 429 and all 5xx status codes are retriable.
+	RetriableStatusCodes []int `json:"retriableStatusCodes,omitempty"`
+	// MaxAttempts is the maximum number of attempts including the first one.
+	// When zero, all the backends in the rule are tried at most once.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// Timeout is the timeout of each request to the fallback backend. When zero, it defaults to 5 minutes.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// HedgingPolicy corresponds to AIGatewayRouteRuleHedging in api/v1alpha1/api.go.
+type HedgingPolicy struct {
+	// Delay is the duration to wait for the response before sending the duplicate request.
+	Delay time.Duration `json:"delay"`
+	// Timeout is the timeout of the requests to both backends. When zero, it defaults to 5 minutes.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// CircuitBreakerPolicy corresponds to AIGatewayRouteRuleCircuitBreaker in api/v1alpha1/api.go.
+// All the fields are set by the controller, with the defaults applied.
+type CircuitBreakerPolicy struct {
+	// ConsecutiveFailures is the number of the consecutive failures that opens the circuit.
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// ErrorRatePercentage is the percentage of the failures within the Interval that opens the circuit.
+	// Zero disables the check of the error rate.
+	ErrorRatePercentage int `json:"errorRatePercentage,omitempty"`
+	// MinimumRequests is the minimum number of the responses within the Interval to check the error rate.
+	MinimumRequests int `json:"minimumRequests"`
+	// Interval is the duration of the window in which the error rate is calculated.
+	Interval time.Duration `json:"interval"`
+	// SlowResponseThreshold is the latency of the response headers that counts as a failure. Zero disables the check.
+	SlowResponseThreshold time.Duration `json:"slowResponseThreshold,omitempty"`
+	// OpenDuration is the duration the circuit stays open before it becomes half-open.
+	OpenDuration time.Duration `json:"openDuration"`
+	// HalfOpenRequests is the number of the probe requests while the circuit is half-open.
+	HalfOpenRequests int `json:"halfOpenRequests"`
 }
 
+// StickyRouting corresponds to AIGatewayRouteRuleStickyRouting in api/v1alpha1/api.go.
+type StickyRouting struct {
+	// HeaderName is the lower-cased name of the request header identifying the client.
+	HeaderName string `json:"headerName"`
+}
+
+// Experiment corresponds to AIGatewayRouteRuleExperiment in api/v1alpha1/api.go.
+type Experiment struct {
+	// Name is the name of the experiment.
+	Name string `json:"name"`
+	// Arms are the arms of the experiment. Each backend of the rule belongs to exactly one arm.
+	Arms []ExperimentArm `json:"arms"`
+}
+
+// ExperimentArm corresponds to AIGatewayRouteRuleExperimentArm in api/v1alpha1/api.go.
+type ExperimentArm struct {
+	// Name is the name of the arm.
+	Name string `json:"name"`
+	// Weight is the weight of the arm.
+	Weight int `json:"weight"`
+	// Backends are the names of the backends of the rule serving this arm, i.e. the Name of the Backend.
+	Backends []string `json:"backends"`
+}
+
+// MirrorPolicy corresponds to AIGatewayRouteRuleMirror in api/v1alpha1/api.go.
+type MirrorPolicy struct {
+	// Backend is the shadow backend, whose Endpoint is always set.
+	Backend Backend `json:"backend"`
+	// Percentage is the percentage of the requests mirrored, from 0 to 100.
+	Percentage int `json:"percentage"`
+	// Comparison configures the similarity score of the outputs. Optional.
+	Comparison *MirrorComparison `json:"comparison,omitempty"`
+}
+
+// MirrorComparison corresponds to MirrorComparison in api/v1alpha1/api.go.
+type MirrorComparison struct {
+	// Type is the method of the comparison.
+	Type MirrorComparisonType `json:"type"`
+	// EmbeddingBackend is the backend serving the embeddings with the OpenAI schema, whose Endpoint is always set.
+	// This is only set for the EmbeddingSimilarity type.
+	EmbeddingBackend *Backend `json:"embeddingBackend,omitempty"`
+	// EmbeddingModel is the embedding model. This is only set for the EmbeddingSimilarity type.
+	EmbeddingModel string `json:"embeddingModel,omitempty"`
+}
+
+// MirrorComparisonType corresponds to MirrorComparisonType in api/v1alpha1/api.go.
+type MirrorComparisonType string
+
+const (
+	// MirrorComparisonTypeExactMatch scores 1 if the outputs are the same, and 0 otherwise.
+	MirrorComparisonTypeExactMatch MirrorComparisonType = "ExactMatch"
+	// MirrorComparisonTypeEmbeddingSimilarity scores the cosine similarity of the embeddings of the outputs.
+	MirrorComparisonTypeEmbeddingSimilarity MirrorComparisonType = "EmbeddingSimilarity"
+)
+
 // Backend corresponds to AIGatewayRouteRuleBackendRef in api/v1alpha1/api.go
 // besides that this abstracts the concept of a backend at Envoy Gateway level to a simple name.
 type Backend struct {
@@ -164,6 +599,16 @@ type Backend struct {
 	//
 	// When DynamicLoadBalancing is specified, the weight is ignored.
 	Weight int `json:"weight"`
+	// Priority is the priority of the backend in the rule. Only the backends with the lowest priority
+	// are selected by the weight, and the others are used as the fallback in the ascending order.
+	Priority int `json:"priority,omitempty"`
+	// Endpoint is the base URL of the backend, e.g. "https://api.openai.com:443". This is used by the filter
+	// to send the request directly to the backend when failing over from another backend, hedging or mirroring.
+	// Optional.
+	//
+	// This is required for all the backends of the rule with the fallback policy. When this is empty, the request
+	// is not hedged.
+	Endpoint string `json:"endpoint,omitempty"`
 	// Auth is the authn/z configuration for the backend. Optional.
 	Auth *BackendAuth `json:"auth,omitempty"`
 	// DynamicLoadBalancing is the dynamic backend configuration which forces the AI filter to
@@ -172,10 +617,50 @@ type Backend struct {
 	// When this is specified, the AI Gateway filter assume that the ORIGINAL_DST cluster is configured
 	// to route the request to an endpoint from `x-ai-eg-original-dst` header.
 	DynamicLoadBalancing *DynamicLoadBalancing `json:"dynamicLoadBalancing,omitempty"`
+	// AWSBedrockGuardrail is the Amazon Bedrock guardrail set in the translated requests. Optional.
+	AWSBedrockGuardrail *AWSBedrockGuardrail `json:"awsBedrockGuardrail,omitempty"`
+	// ModelNameMappings maps the model names in the requests to the ones sent to this backend. Optional.
+	//
+	// When DynamicLoadBalancing is specified, the mappings apply to all the endpoints selected by it.
+	ModelNameMappings []ModelNameMapping `json:"modelNameMappings,omitempty"`
+}
+
+// ModelNameMapping corresponds to ModelNameMapping in api/v1alpha1/api.go.
+type ModelNameMapping struct {
+	// From is the model name in the requests.
+	From string `json:"from"`
+	// To is the model name sent to the backend.
+	To string `json:"to"`
+}
+
+// AWSBedrockGuardrail corresponds to AWSBedrockGuardrail in api/v1alpha1/api.go.
+type AWSBedrockGuardrail struct {
+	// Identifier is the ID or the ARN of the guardrail.
+	Identifier string `json:"identifier"`
+	// Version is the version of the guardrail.
+	Version string `json:"version"`
+	// Trace enables the guardrail trace in the response.
+	Trace bool `json:"trace,omitempty"`
 }
 
 // DynamicLoadBalancing corresponds to InferencePool and InferenceModels belonging to the same pool.
 type DynamicLoadBalancing struct {
+	// Policy is the algorithm to select the endpoint. Defaults to DynamicLoadBalancingPolicyLeastRequest.
+	Policy DynamicLoadBalancingPolicy `json:"policy,omitempty"`
+	// EndpointMetrics is the configuration of the metrics scraped from the endpoints.
+	// This must be set when the policy is DynamicLoadBalancingPolicyEndpointMetrics.
+	EndpointMetrics *DynamicLoadBalancingEndpointMetrics `json:"endpointMetrics,omitempty"`
+	// SessionAffinity routes the requests of the same session to the same endpoint if set. Optional.
+	SessionAffinity *DynamicLoadBalancingSessionAffinity `json:"sessionAffinity,omitempty"`
+	// ClusterName is the name of the ORIGINAL_DST cluster to route the requests to the selected endpoints, which is
+	// set in the selected backend header. Defaults to "original_destination_cluster" if empty.
+	ClusterName string `json:"clusterName,omitempty"`
+	// TLS is true if the cluster connects to the endpoints with TLS. This is used to omit the default port from the
+	// Host header of the requests to the endpoints resolved from the hostnames.
+	TLS bool `json:"tls,omitempty"`
+	// CACertificatesFile is the path to the file of the PEM-encoded CA certificates to verify the certificates of the
+	// endpoints when their metrics are scraped with TLS. When empty, the system trust store is used.
+	CACertificatesFile string `json:"caCertificatesFile,omitempty"`
 	// Models that can be served by this backend. If not matched, the 404 is returned to the client.
 	//
 	// If multiple models are provided, the request is routed to the backend based on the weights, criticality, etc.
@@ -184,13 +669,72 @@ type DynamicLoadBalancing struct {
 	Backends []DynamicLoadBalancingBackend `json:"backends,omitempty"`
 }
 
+// DynamicLoadBalancingPolicy corresponds to InferencePoolLoadBalancingPolicy in api/v1alpha1/api.go.
+type DynamicLoadBalancingPolicy string
+
+const (
+	// DynamicLoadBalancingPolicyLeastRequest selects the endpoint with the least outstanding requests.
+	DynamicLoadBalancingPolicyLeastRequest DynamicLoadBalancingPolicy = "LeastRequest"
+	// DynamicLoadBalancingPolicyRandom selects the endpoint randomly.
+	DynamicLoadBalancingPolicyRandom DynamicLoadBalancingPolicy = "Random"
+	// DynamicLoadBalancingPolicyEndpointMetrics selects the endpoint based on the metrics scraped from the endpoints.
+	DynamicLoadBalancingPolicyEndpointMetrics DynamicLoadBalancingPolicy = "EndpointMetrics"
+)
+
+// DynamicLoadBalancingEndpointMetrics corresponds to InferencePoolEndpointMetrics in api/v1alpha1/api.go.
+type DynamicLoadBalancingEndpointMetrics struct {
+	// Path is the HTTP path of the metrics endpoint, e.g. "/metrics".
+	Path string `json:"path"`
+	// Interval is the interval between the scrapes of the metrics.
+	Interval time.Duration `json:"interval"`
+}
+
+// DynamicLoadBalancingSessionAffinity corresponds to InferencePoolSessionAffinity in api/v1alpha1/api.go.
+type DynamicLoadBalancingSessionAffinity struct {
+	// HeaderName is the lower-cased name of the request header identifying the session. When this is empty or
+	// the request does not have the header, the session is identified by the leading messages of the request.
+	HeaderName string `json:"headerName,omitempty"`
+	// MaxLoadPercentage is the maximum number of the outstanding requests of an endpoint relative to the average,
+	// above which the session is routed to the next endpoint in the hash ring. This must be greater than 100.
+	MaxLoadPercentage int `json:"maxLoadPercentage"`
+}
+
 // DynamicLoadBalancingModel corresponds to InferenceModel in the Inference Extension.
 type DynamicLoadBalancingModel struct {
-	// Name is the name of the model.
+	// Name is the name of the model in the requests.
 	Name string `json:"name"`
-	// Weight is the weight of the model in the routing decision when multiple models are provided.
+	// Weight was the weight of the model in the routing decision when multiple models are provided.
+	//
+	// Deprecated: this is ignored and only kept to accept the configurations written by the older controllers.
+	// Use TargetModels instead.
 	Weight *int `json:"weight,omitempty"`
-	// TODO: Criticality?
+	// Criticality is the criticality of the requests to the model. Defaults to DynamicLoadBalancingCriticalityStandard.
+	Criticality DynamicLoadBalancingCriticality `json:"criticality,omitempty"`
+	// TargetModels are the models served by the endpoints that the requests to the model are sent to, e.g. the LoRA
+	// adapters. One of them is selected by the weights for each request, and the model name in the request is
+	// rewritten to it. When this is empty, the requests are sent with the model name as-is.
+	TargetModels []DynamicLoadBalancingTargetModel `json:"targetModels,omitempty"`
+}
+
+// DynamicLoadBalancingCriticality corresponds to Criticality in the Inference Extension.
+type DynamicLoadBalancingCriticality string
+
+const (
+	// DynamicLoadBalancingCriticalityCritical is the criticality of the requests never rejected by the filter.
+	DynamicLoadBalancingCriticalityCritical DynamicLoadBalancingCriticality = "Critical"
+	// DynamicLoadBalancingCriticalityStandard is the default criticality, which is never rejected by the filter either.
+	DynamicLoadBalancingCriticalityStandard DynamicLoadBalancingCriticality = "Standard"
+	// DynamicLoadBalancingCriticalitySheddable is the criticality of the requests rejected when all the endpoints
+	// are saturated. The endpoints without the fresh metrics are regarded as saturated.
+	DynamicLoadBalancingCriticalitySheddable DynamicLoadBalancingCriticality = "Sheddable"
+)
+
+// DynamicLoadBalancingTargetModel corresponds to TargetModel in the Inference Extension.
+type DynamicLoadBalancingTargetModel struct {
+	// Name is the name of the model or the LoRA adapter served by the endpoints.
+	Name string `json:"name"`
+	// Weight is the weight of the target model relative to the others of the same model.
+	Weight int `json:"weight"`
 }
 
 // DynamicLoadBalancingBackend corresponds to a single AIServiceBackend that is selected by the
@@ -200,6 +744,10 @@ type DynamicLoadBalancingBackend struct {
 	Backend
 	// Hostnames is the hostname of this backend. The filter will resolve the hostname to the IP address
 	// asynchronously and use the resolved IP address to route the request.
+	//
+	// Both IPv4 and IPv6 addresses are resolved, and they are re-resolved in the background when their TTLs expire.
+	// The hostname starting with an underscore, e.g. "_http._tcp.vllm.default.svc.cluster.local", is resolved as
+	// the SRV records, in which case the ports of the records are used instead of Port.
 	Hostnames []string `json:"hostNames,omitempty"`
 	// IP is the IP address of the endpoint.
 	IPs []string `json:"ips,omitempty"`
@@ -215,6 +763,8 @@ type BackendAuth struct {
 	AWSAuth *AWSAuth `json:"aws,omitempty"`
 	// AzureAuth specifies the location of Azure access token file.
 	AzureAuth *AzureAuth `json:"azure,omitempty"`
+	// GCPAuth specifies the location of GCP access token file, and the project and region of Vertex AI.
+	GCPAuth *GCPAuth `json:"gcp,omitempty"`
 }
 
 // AWSAuth defines the credentials needed to access AWS.
@@ -233,6 +783,14 @@ type AzureAuth struct {
 	Filename string `json:"filename"`
 }
 
+// GCPAuth defines the file containing GCP access token that will be mounted to the external proc,
+// as well as the project and region that are used to build the Vertex AI request path.
+type GCPAuth struct {
+	Filename    string `json:"filename"`
+	ProjectName string `json:"projectName"`
+	Region      string `json:"region"`
+}
+
 // UnmarshalConfigYaml reads the file at the given path and unmarshals it into a Config struct.
 func UnmarshalConfigYaml(path string) (*Config, []byte, error) {
 	raw, err := os.ReadFile(path)
@@ -18,7 +18,7 @@ import (
 )
 
 func TestDefaultConfig(t *testing.T) {
-	server, err := extproc.NewServer(slog.Default())
+	server, err := extproc.NewServer(slog.Default(), nil)
 	require.NoError(t, err)
 	require.NotNil(t, server)
 
@@ -9,6 +9,7 @@ package x
 import (
 	"context"
 	"errors"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -52,6 +53,10 @@ var NewCustomChatCompletionMetrics NewCustomChatCompletionMetricsFn
 type NewCustomChatCompletionMetricsFn func(meter metric.Meter) ChatCompletionMetrics
 
 // ChatCompletionMetrics is the interface for the chat completion AI Gateway metrics.
+//
+// The implementation can also implement the optional interfaces [ResponseCacheMetrics], [HedgeMetrics],
+// [MirrorMetrics] and [CircuitBreakerMetrics] to record the metrics of the respective features. Otherwise,
+// those metrics are not recorded.
 type ChatCompletionMetrics interface {
 	// StartRequest initializes timing for a new request.
 	StartRequest(headers map[string]string)
@@ -63,8 +68,109 @@ type ChatCompletionMetrics interface {
 
 	// RecordTokenUsage records token usage metrics.
 	RecordTokenUsage(ctx context.Context, inputTokens, outputTokens, totalTokens uint32, extraAttrs ...attribute.KeyValue)
-	// RecordRequestCompletion records latency metrics for the entire request
+	// RecordRequestCompletion records latency metrics for the entire request.
+	// When the request failed, extraAttrs usually contain the "error.type" attribute classifying the failure,
+	// e.g. "rate_limited" or "upstream_5xx".
 	RecordRequestCompletion(ctx context.Context, success bool, extraAttrs ...attribute.KeyValue)
 	// RecordTokenLatency records latency metrics for token generation.
 	RecordTokenLatency(ctx context.Context, tokens uint32, extraAttrs ...attribute.KeyValue)
 }
+
+// ResponseCacheMetrics is the optional interface of [ChatCompletionMetrics] recording the response cache lookups.
+type ResponseCacheMetrics interface {
+	// RecordResponseCacheLookup records the result of the response cache lookup. This is only called
+	// when the response cache is enabled.
+	RecordResponseCacheLookup(ctx context.Context, hit bool, extraAttrs ...attribute.KeyValue)
+}
+
+// HedgeMetrics is the optional interface of [ChatCompletionMetrics] recording the outcomes of the hedged requests.
+type HedgeMetrics interface {
+	// RecordHedge records the outcome of the request whose route rule has the hedging policy. This is only called
+	// for the requests eligible for hedging.
+	RecordHedge(ctx context.Context, result HedgeResult, extraAttrs ...attribute.KeyValue)
+}
+
+// MirrorMetrics is the optional interface of [ChatCompletionMetrics] recording the comparisons of the mirrored requests.
+type MirrorMetrics interface {
+	// RecordMirror records the comparison of the response of the shadow backend with the one of the primary backend.
+	// This is only called for the requests mirrored by the route rule with the mirror policy, after both responses
+	// have completed, which may be after the response to the client.
+	RecordMirror(ctx context.Context, comparison *MirrorComparison, extraAttrs ...attribute.KeyValue)
+}
+
+// CircuitBreakerMetrics is the optional interface of [ChatCompletionMetrics] recording the state changes of the
+// circuit breakers.
+type CircuitBreakerMetrics interface {
+	// RecordCircuitBreakerTransition records the change of the state of the circuit breaker of the backend. This is
+	// only called by the request that caused the change. The state belongs to the backend rather than the request,
+	// so the request attributes are not recorded.
+	RecordCircuitBreakerTransition(ctx context.Context, backend string, from, to CircuitBreakerState)
+}
+
+// HedgeResult is the outcome of the request whose route rule has the hedging policy.
+type HedgeResult string
+
+const (
+	// HedgeResultNotFired means the primary backend responded within the delay and the hedged request was not sent.
+	HedgeResultNotFired HedgeResult = "not_fired"
+	// HedgeResultPrimary means the hedged request was sent, and the primary backend responded first.
+	HedgeResultPrimary HedgeResult = "primary"
+	// HedgeResultHedge means the hedged request was sent, and the hedge backend responded first.
+	HedgeResultHedge HedgeResult = "hedge"
+	// HedgeResultFailed means neither backend responded successfully.
+	HedgeResultFailed HedgeResult = "failed"
+)
+
+// CircuitBreakerState is the state of the circuit breaker of a backend.
+type CircuitBreakerState string
+
+const (
+	// CircuitBreakerStateClosed means the backend is selected by the router as usual.
+	CircuitBreakerStateClosed CircuitBreakerState = "closed"
+	// CircuitBreakerStateOpen means the backend is excluded from the selection by the router.
+	CircuitBreakerStateOpen CircuitBreakerState = "open"
+	// CircuitBreakerStateHalfOpen means the limited number of the probe requests are sent to the backend.
+	CircuitBreakerStateHalfOpen CircuitBreakerState = "half_open"
+)
+
+// MirrorComparison is the comparison of the responses of the primary and shadow backends to a mirrored request.
+type MirrorComparison struct {
+	// ShadowBackend is the name of the shadow backend.
+	ShadowBackend string
+	// ShadowSucceeded is true if the shadow backend responded with a completion.
+	ShadowSucceeded bool
+	// PrimaryLatency and ShadowLatency are the durations from when the request was mirrored until the responses
+	// of the backends completed.
+	PrimaryLatency, ShadowLatency time.Duration
+	// ShadowInputTokens and ShadowOutputTokens are the token usage reported by the shadow backend.
+	ShadowInputTokens, ShadowOutputTokens uint32
+	// FinishReasonMatch is true if both backends responded with completions of the same finish reason.
+	FinishReasonMatch bool
+	// Score is the similarity score of the outputs. This is nil if the comparison is not configured or fails.
+	Score *float64
+}
+
+// NewCustomEmbeddingsMetrics is the function to create a custom embeddings AI Gateway metrics over
+// the default metrics. This is nil by default and can be set by the custom build of external processor.
+var NewCustomEmbeddingsMetrics NewCustomEmbeddingsMetricsFn
+
+// NewCustomEmbeddingsMetricsFn is the function to create a custom embeddings AI Gateway metrics.
+type NewCustomEmbeddingsMetricsFn func(meter metric.Meter) EmbeddingsMetrics
+
+// EmbeddingsMetrics is the interface for the embeddings AI Gateway metrics.
+type EmbeddingsMetrics interface {
+	// StartRequest initializes timing for a new request.
+	StartRequest(headers map[string]string)
+	// SetModel sets the model the request. This is usually called after parsing the request body .
+	SetModel(model string)
+	// SetBackend sets the selected backend when the routing decision has been made. This is usually called
+	// after parsing the request body to determine the model and invoke the routing logic.
+	SetBackend(backend *filterapi.Backend)
+
+	// RecordTokenUsage records token usage metrics. Embeddings only consume input tokens.
+	RecordTokenUsage(ctx context.Context, inputTokens, totalTokens uint32, extraAttrs ...attribute.KeyValue)
+	// RecordRequestCompletion records latency metrics for the entire request.
+	// When the request failed, extraAttrs usually contain the "error.type" attribute classifying the failure,
+	// e.g. "rate_limited" or "upstream_5xx".
+	RecordRequestCompletion(ctx context.Context, success bool, extraAttrs ...attribute.KeyValue)
+}
@@ -0,0 +1,126 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package aigwerrors classifies the failures of the requests processed by the AI Gateway.
+//
+// The classification is reported as the error.type attribute of the metrics, and determines the status and the
+// OpenAI error type of the response to the client.
+package aigwerrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Type is the classification of the failure of a request.
+type Type string
+
+const (
+	// ClientError is the invalid request from the client, e.g. the malformed body or the unknown model.
+	ClientError Type = "client_error"
+	// RateLimited is the request rejected due to the rate limit or the quota, by the gateway or the backend.
+	RateLimited Type = "rate_limited"
+	// UpstreamTimeout is the request that timed out waiting for the backend.
+	UpstreamTimeout Type = "upstream_timeout"
+	// Upstream5xx is the server error response from the backend.
+	Upstream5xx Type = "upstream_5xx"
+	// TranslationError is the failure to translate the request or the response between the API schemas.
+	TranslationError Type = "translation_error"
+	// AuthError is the failure to authenticate with the backend, either while signing the request or
+	// as reported by the backend.
+	AuthError Type = "auth_error"
+	// GuardrailBlocked is the request or the response blocked by the guardrails.
+	GuardrailBlocked Type = "guardrail_blocked"
+	// Other is any other failure. This is the fallback value of error.type in the OpenTelemetry semantic conventions.
+	Other Type = "_OTHER"
+)
+
+// Error is the error classified with the Type.
+type Error struct {
+	// Type is the classification of the error.
+	Type Type
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements [error.Error].
+func (e *Error) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Wrap classifies the error with the type unless it is already classified. This returns nil if err is nil.
+func Wrap(t Type, err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	return &Error{Type: t, Err: err}
+}
+
+// TypeOf returns the classification of the error, which is Other if the error is not classified.
+func TypeOf(err error) Type {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Type
+	}
+	return Other
+}
+
+// FromStatus returns the classification of the HTTP response status from the backend.
+// This returns an empty Type if the status is not an error.
+func FromStatus(status int) Type {
+	switch {
+	case status < http.StatusBadRequest:
+		return ""
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return AuthError
+	case status == http.StatusTooManyRequests:
+		return RateLimited
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return UpstreamTimeout
+	case status < http.StatusInternalServerError:
+		return ClientError
+	default:
+		return Upstream5xx
+	}
+}
+
+// Status returns the HTTP status of the response to the client for the failure detected by the gateway itself.
+func (t Type) Status() int {
+	switch t {
+	case ClientError, GuardrailBlocked:
+		return http.StatusBadRequest
+	case RateLimited:
+		return http.StatusTooManyRequests
+	case UpstreamTimeout:
+		return http.StatusGatewayTimeout
+	case Upstream5xx:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// OpenAIErrorType returns the "type" of the OpenAI error for the HTTP response status.
+func OpenAIErrorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == http.StatusForbidden:
+		return "permission_error"
+	case status == http.StatusNotFound:
+		return "not_found_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+		return "invalid_request_error"
+	default:
+		return "server_error"
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package aigwerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	require.NoError(t, Wrap(ClientError, nil))
+
+	base := errors.New("boom")
+	err := Wrap(AuthError, base)
+	require.ErrorIs(t, err, base)
+	require.Equal(t, "boom", err.Error())
+	require.Equal(t, AuthError, TypeOf(err))
+
+	// The classification is kept through the wrapping, and not overridden.
+	wrapped := Wrap(TranslationError, fmt.Errorf("failed to do auth request: %w", err))
+	require.Equal(t, AuthError, TypeOf(wrapped))
+	require.Equal(t, "failed to do auth request: boom", wrapped.Error())
+
+	require.Equal(t, Other, TypeOf(base))
+	require.Equal(t, Other, TypeOf(nil))
+}
+
+func TestFromStatus(t *testing.T) {
+	for status, exp := range map[int]Type{
+		200: "",
+		304: "",
+		400: ClientError,
+		401: AuthError,
+		403: AuthError,
+		404: ClientError,
+		408: UpstreamTimeout,
+		429: RateLimited,
+		500: Upstream5xx,
+		503: Upstream5xx,
+		504: UpstreamTimeout,
+	} {
+		require.Equal(t, exp, FromStatus(status), status)
+	}
+}
+
+func TestType_Status(t *testing.T) {
+	for typ, exp := range map[Type]int{
+		ClientError:      400,
+		GuardrailBlocked: 400,
+		RateLimited:      429,
+		UpstreamTimeout:  504,
+		Upstream5xx:      502,
+		TranslationError: 500,
+		AuthError:        500,
+		Other:            500,
+	} {
+		require.Equal(t, exp, typ.Status(), typ)
+	}
+}
+
+func TestOpenAIErrorType(t *testing.T) {
+	for status, exp := range map[int]string{
+		400: "invalid_request_error",
+		401: "authentication_error",
+		403: "permission_error",
+		404: "not_found_error",
+		422: "invalid_request_error",
+		429: "rate_limit_error",
+		500: "server_error",
+		504: "server_error",
+	} {
+		require.Equal(t, exp, OpenAIErrorType(status), status)
+	}
+}
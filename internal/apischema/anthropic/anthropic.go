@@ -0,0 +1,233 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package anthropic contains the Anthropic Messages API schema definitions.
+// https://docs.anthropic.com/en/api/messages
+package anthropic
+
+const (
+	// MessageRoleUser is the role of the user message.
+	MessageRoleUser = "user"
+	// MessageRoleAssistant is the role of the assistant message.
+	MessageRoleAssistant = "assistant"
+
+	// ContentBlockTypeText is the type of the text content block.
+	ContentBlockTypeText = "text"
+	// ContentBlockTypeImage is the type of the image content block.
+	ContentBlockTypeImage = "image"
+	// ContentBlockTypeToolUse is the type of the tool use content block.
+	ContentBlockTypeToolUse = "tool_use"
+	// ContentBlockTypeToolResult is the type of the tool result content block.
+	ContentBlockTypeToolResult = "tool_result"
+
+	// ImageSourceTypeBase64 is the type of the base64 encoded image source.
+	ImageSourceTypeBase64 = "base64"
+	// ImageSourceTypeURL is the type of the URL image source.
+	ImageSourceTypeURL = "url"
+
+	// StopReasonEndTurn is the stop reason when the model reached a natural stopping point.
+	StopReasonEndTurn = "end_turn"
+	// StopReasonMaxTokens is the stop reason when the model exceeded the requested max_tokens.
+	StopReasonMaxTokens = "max_tokens"
+	// StopReasonStopSequence is the stop reason when one of the custom stop_sequences was generated.
+	StopReasonStopSequence = "stop_sequence"
+	// StopReasonToolUse is the stop reason when the model invoked one or more tools.
+	StopReasonToolUse = "tool_use"
+
+	// ToolChoiceTypeAuto allows the model to decide whether to use the provided tools.
+	ToolChoiceTypeAuto = "auto"
+	// ToolChoiceTypeAny forces the model to use one of the provided tools.
+	ToolChoiceTypeAny = "any"
+	// ToolChoiceTypeTool forces the model to use the specified tool.
+	ToolChoiceTypeTool = "tool"
+	// ToolChoiceTypeNone prevents the model from using any tools.
+	ToolChoiceTypeNone = "none"
+)
+
+// MessagesRequest is the request body of the Messages API.
+// https://docs.anthropic.com/en/api/messages
+type MessagesRequest struct {
+	// Model is the model that will complete the prompt.
+	Model string `json:"model"`
+	// Messages is the list of input messages.
+	Messages []Message `json:"messages"`
+	// System is the system prompt.
+	System []ContentBlock `json:"system,omitempty"`
+	// MaxTokens is the maximum number of tokens to generate before stopping. This is required.
+	MaxTokens int64 `json:"max_tokens"`
+	// StopSequences are the custom text sequences that will cause the model to stop generating.
+	StopSequences []string `json:"stop_sequences,omitempty"`
+	// Stream is whether to incrementally stream the response using server-sent events.
+	Stream bool `json:"stream,omitempty"`
+	// Temperature is the amount of randomness injected into the response.
+	Temperature *float64 `json:"temperature,omitempty"`
+	// TopP is the nucleus sampling parameter.
+	TopP *float64 `json:"top_p,omitempty"`
+	// Tools are the definitions of tools that the model may use.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice is how the model should use the provided tools.
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+	// Metadata is an object describing metadata about the request.
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// Metadata is an object describing metadata about the request.
+type Metadata struct {
+	// UserID is an external identifier for the user who is associated with the request.
+	UserID string `json:"user_id,omitempty"`
+}
+
+// Message is a single input message of the Messages API.
+type Message struct {
+	// Role is either "user" or "assistant".
+	Role string `json:"role"`
+	// Content is the list of content blocks of the message.
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock is a union of the content blocks of the Messages API where Type specifies which fields are set.
+type ContentBlock struct {
+	// Type is the type of the content block.
+	Type string `json:"type"`
+	// Text is set when the Type is "text".
+	Text *string `json:"text,omitempty"`
+	// Source is set when the Type is "image".
+	Source *ImageSource `json:"source,omitempty"`
+	// ID is the ID of the tool use, set when the Type is "tool_use".
+	ID string `json:"id,omitempty"`
+	// Name is the name of the tool, set when the Type is "tool_use".
+	Name string `json:"name,omitempty"`
+	// Input is the input of the tool, set when the Type is "tool_use".
+	Input any `json:"input,omitempty"`
+	// ToolUseID is the ID of the tool use this is the result of, set when the Type is "tool_result".
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	// Content is the content of the tool result, set when the Type is "tool_result".
+	Content []ContentBlock `json:"content,omitempty"`
+}
+
+// ImageSource is the source of the image content block.
+type ImageSource struct {
+	// Type is either "base64" or "url".
+	Type string `json:"type"`
+	// MediaType is the media type of the base64 encoded image, e.g. "image/png".
+	MediaType string `json:"media_type,omitempty"`
+	// Data is the base64 encoded image.
+	Data string `json:"data,omitempty"`
+	// URL is the URL of the image.
+	URL string `json:"url,omitempty"`
+}
+
+// Tool is the definition of a tool that the model may use.
+type Tool struct {
+	// Name is the name of the tool.
+	Name string `json:"name"`
+	// Description is the description of what the tool does.
+	Description string `json:"description,omitempty"`
+	// InputSchema is the JSON schema of the tool input.
+	InputSchema any `json:"input_schema"`
+}
+
+// ToolChoice specifies how the model should use the provided tools.
+type ToolChoice struct {
+	// Type is one of "auto", "any", "tool" or "none".
+	Type string `json:"type"`
+	// Name is the name of the tool to use, set when the Type is "tool".
+	Name string `json:"name,omitempty"`
+	// DisableParallelToolUse is whether to disable parallel tool use.
+	DisableParallelToolUse *bool `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// MessagesResponse is the response body of the Messages API.
+type MessagesResponse struct {
+	// ID is the unique object identifier.
+	ID string `json:"id"`
+	// Type is always "message".
+	Type string `json:"type"`
+	// Role is always "assistant".
+	Role string `json:"role"`
+	// Content is the content generated by the model.
+	Content []ContentBlock `json:"content"`
+	// Model is the model that handled the request.
+	Model string `json:"model"`
+	// StopReason is the reason that the model stopped.
+	StopReason *string `json:"stop_reason,omitempty"`
+	// StopSequence is the custom stop sequence that was generated, if any.
+	StopSequence *string `json:"stop_sequence,omitempty"`
+	// Usage is the billing and rate-limit usage.
+	Usage Usage `json:"usage"`
+}
+
+// Usage is the billing and rate-limit usage of the Messages API.
+type Usage struct {
+	// InputTokens is the number of input tokens which were used.
+	InputTokens int `json:"input_tokens"`
+	// OutputTokens is the number of output tokens which were used.
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Stream event types of the Messages API.
+// https://docs.anthropic.com/en/api/messages-streaming
+const (
+	StreamEventTypeMessageStart      = "message_start"
+	StreamEventTypeMessageDelta      = "message_delta"
+	StreamEventTypeMessageStop       = "message_stop"
+	StreamEventTypeContentBlockStart = "content_block_start"
+	StreamEventTypeContentBlockDelta = "content_block_delta"
+	StreamEventTypeContentBlockStop  = "content_block_stop"
+	StreamEventTypePing              = "ping"
+	StreamEventTypeError             = "error"
+
+	// DeltaTypeText is the type of the text delta of the content_block_delta event.
+	DeltaTypeText = "text_delta"
+	// DeltaTypeInputJSON is the type of the partial tool input delta of the content_block_delta event.
+	DeltaTypeInputJSON = "input_json_delta"
+)
+
+// StreamEvent is a union of the server-sent events of the Messages API where Type specifies which fields are set.
+type StreamEvent struct {
+	// Type is the type of the event.
+	Type string `json:"type"`
+	// Message is set for the "message_start" event.
+	Message *MessagesResponse `json:"message,omitempty"`
+	// Index is the index of the content block, set for the "content_block_*" events.
+	Index int `json:"index"`
+	// ContentBlock is set for the "content_block_start" event.
+	ContentBlock *ContentBlock `json:"content_block,omitempty"`
+	// Delta is set for the "content_block_delta" and "message_delta" events.
+	Delta *StreamEventDelta `json:"delta,omitempty"`
+	// Usage is set for the "message_delta" event and is cumulative.
+	Usage *Usage `json:"usage,omitempty"`
+	// Error is set for the "error" event.
+	Error *Error `json:"error,omitempty"`
+}
+
+// StreamEventDelta is a union of the deltas of the "content_block_delta" and "message_delta" events.
+type StreamEventDelta struct {
+	// Type is the type of the content block delta. Empty for the "message_delta" event.
+	Type string `json:"type,omitempty"`
+	// Text is set when the Type is "text_delta".
+	Text string `json:"text,omitempty"`
+	// PartialJSON is set when the Type is "input_json_delta".
+	PartialJSON string `json:"partial_json,omitempty"`
+	// StopReason is set for the "message_delta" event.
+	StopReason *string `json:"stop_reason,omitempty"`
+}
+
+// ErrorResponse is the error response body of the Messages API.
+// https://docs.anthropic.com/en/api/errors
+type ErrorResponse struct {
+	// Type is always "error".
+	Type string `json:"type"`
+	// Error is the error detail.
+	Error Error `json:"error"`
+}
+
+// Error is the detail of the error.
+type Error struct {
+	// Type is the type of the error, e.g. "invalid_request_error", "overloaded_error".
+	Type string `json:"type"`
+	// Message is the human-readable error message.
+	Message string `json:"message"`
+}
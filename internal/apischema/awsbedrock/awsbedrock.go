@@ -508,3 +508,47 @@ type ToolSpecification struct {
 	// Name is a required field
 	Name *string `json:"name"`
 }
+
+// TitanEmbeddingRequest is the InvokeModel request body for Amazon Titan text embedding models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-titan-embed-text.html
+type TitanEmbeddingRequest struct {
+	// InputText is the text to convert to an embedding.
+	InputText string `json:"inputText"`
+	// Dimensions is the number of dimensions the output embedding should have.
+	// Only supported by Titan Text Embeddings V2.
+	Dimensions *int `json:"dimensions,omitempty"`
+}
+
+// TitanEmbeddingResponse is the InvokeModel response body for Amazon Titan text embedding models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-titan-embed-text.html
+type TitanEmbeddingResponse struct {
+	// Embedding is the embedding vector of the input text.
+	Embedding []float64 `json:"embedding"`
+	// InputTextTokenCount is the number of tokens in the input.
+	InputTextTokenCount int `json:"inputTextTokenCount"`
+}
+
+// CohereEmbeddingRequest is the InvokeModel request body for Cohere Embed models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-embed.html
+type CohereEmbeddingRequest struct {
+	// Texts is the array of strings for the model to embed.
+	Texts []string `json:"texts"`
+	// InputType prepends special tokens to differentiate each type from one another.
+	// One of "search_document", "search_query", "classification" or "clustering".
+	InputType string `json:"input_type"`
+	// Truncate specifies how the API handles inputs longer than the maximum token length.
+	Truncate *string `json:"truncate,omitempty"`
+}
+
+// CohereEmbeddingResponse is the InvokeModel response body for Cohere Embed models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-embed.html
+type CohereEmbeddingResponse struct {
+	// ID is an identifier for the response.
+	ID string `json:"id"`
+	// Embeddings is an array of embeddings, one per input text.
+	Embeddings [][]float64 `json:"embeddings"`
+	// Texts is the array of the input texts.
+	Texts []string `json:"texts"`
+	// ResponseType is the type of the response, which is "embeddings_floats" for the float embeddings.
+	ResponseType string `json:"response_type,omitempty"`
+}
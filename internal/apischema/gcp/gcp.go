@@ -0,0 +1,167 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package gcp contains the subset of the GCP Vertex AI Gemini API schema used by the ai-gateway.
+//
+// https://cloud.google.com/vertex-ai/docs/reference/rest/v1/projects.locations.publishers.models/generateContent
+package gcp
+
+const (
+	// RoleUser is the role of the content produced by the user.
+	RoleUser = "user"
+	// RoleModel is the role of the content produced by the model.
+	RoleModel = "model"
+
+	// FinishReasonStop is the finish reason when the model reached a natural stop point or a stop sequence.
+	FinishReasonStop = "STOP"
+	// FinishReasonMaxTokens is the finish reason when the maximum number of tokens was reached.
+	FinishReasonMaxTokens = "MAX_TOKENS"
+	// FinishReasonSafety is the finish reason when the response was flagged for safety reasons.
+	FinishReasonSafety = "SAFETY"
+
+	// FunctionCallingModeAuto lets the model decide whether to call a function.
+	FunctionCallingModeAuto = "AUTO"
+	// FunctionCallingModeAny forces the model to call a function.
+	FunctionCallingModeAny = "ANY"
+	// FunctionCallingModeNone prevents the model from calling a function.
+	FunctionCallingModeNone = "NONE"
+)
+
+// GenerateContentRequest is the request body of the generateContent and streamGenerateContent methods.
+type GenerateContentRequest struct {
+	// Contents is the content of the current conversation with the model.
+	Contents []Content `json:"contents"`
+	// SystemInstruction is the instruction given to the model to steer it towards better performance.
+	SystemInstruction *Content `json:"systemInstruction,omitempty"`
+	// Tools is the list of tools the model may use to generate the next response.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolConfig is the configuration for the tools specified in the request.
+	ToolConfig *ToolConfig `json:"toolConfig,omitempty"`
+	// GenerationConfig is the configuration for the generation.
+	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// Content is the multi-part content of a message.
+type Content struct {
+	// Role is the producer of the content, either "user" or "model".
+	Role string `json:"role,omitempty"`
+	// Parts is the ordered parts of the message.
+	Parts []Part `json:"parts"`
+}
+
+// Part is a datatype containing media that is part of a multi-part content message.
+// Exactly one of the fields must be set.
+type Part struct {
+	Text             *string           `json:"text,omitempty"`
+	InlineData       *Blob             `json:"inlineData,omitempty"`
+	FileData         *FileData         `json:"fileData,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// Blob is the raw media bytes.
+type Blob struct {
+	// MimeType is the IANA standard MIME type of the source data.
+	MimeType string `json:"mimeType"`
+	// Data is the base64 encoded raw bytes.
+	Data string `json:"data"`
+}
+
+// FileData is the URI based data.
+type FileData struct {
+	// MimeType is the IANA standard MIME type of the source data.
+	MimeType string `json:"mimeType"`
+	// FileURI is the URI of the file.
+	FileURI string `json:"fileUri"`
+}
+
+// FunctionCall is a predicted function call returned from the model.
+type FunctionCall struct {
+	// Name is the name of the function to call.
+	Name string `json:"name"`
+	// Args is the function parameters and values in JSON object format.
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// FunctionResponse is the result output of a FunctionCall.
+type FunctionResponse struct {
+	// Name is the name of the function that was called.
+	Name string `json:"name"`
+	// Response is the function response in JSON object format.
+	Response map[string]any `json:"response"`
+}
+
+// Tool is the tool that the model may use to generate the response.
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+// FunctionDeclaration is the structured representation of a function declaration.
+type FunctionDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the subset of the OpenAPI 3.0 schema object describing the parameters.
+	Parameters any `json:"parameters,omitempty"`
+}
+
+// ToolConfig is the configuration shared by all the tools in the request.
+type ToolConfig struct {
+	FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+// FunctionCallingConfig is the configuration of the function calling behavior.
+type FunctionCallingConfig struct {
+	// Mode is one of "AUTO", "ANY" or "NONE".
+	Mode string `json:"mode,omitempty"`
+	// AllowedFunctionNames is the set of functions the model is allowed to call when the mode is "ANY".
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// GenerationConfig is the configuration of the generation.
+type GenerationConfig struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	CandidateCount   *int     `json:"candidateCount,omitempty"`
+	MaxOutputTokens  *int64   `json:"maxOutputTokens,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	PresencePenalty  *float32 `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float32 `json:"frequencyPenalty,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+}
+
+// GenerateContentResponse is the response of the generateContent method, as well as
+// each event of the streamGenerateContent method.
+type GenerateContentResponse struct {
+	Candidates    []Candidate    `json:"candidates,omitempty"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string         `json:"modelVersion,omitempty"`
+}
+
+// Candidate is a response candidate generated from the model.
+type Candidate struct {
+	Index        int     `json:"index,omitempty"`
+	Content      Content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty"`
+}
+
+// UsageMetadata is the usage metadata of the request.
+type UsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount,omitempty"`
+	CandidatesTokenCount int `json:"candidatesTokenCount,omitempty"`
+	TotalTokenCount      int `json:"totalTokenCount,omitempty"`
+}
+
+// ErrorResponse is the body of the error response from the Vertex AI API.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error is the Google API error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
@@ -387,6 +387,9 @@ type ChatCompletionMessageToolCallFunctionParam struct {
 }
 
 type ChatCompletionMessageToolCallParam struct {
+	// Index is the index of the tool call in the list of tool calls. This is only set in the streaming chunks
+	// to identify which tool call the delta belongs to.
+	Index *int `json:"index,omitempty"`
 	// The ID of the tool call.
 	ID string `json:"id"`
 	// The function that the model called.
@@ -793,3 +796,116 @@ func (t *JSONUNIXTime) UnmarshalJSON(s []byte) error {
 	*(*time.Time)(t) = time.Unix(q, 0)
 	return nil
 }
+
+// EmbeddingRequest is described in the OpenAI API documentation
+// https://platform.openai.com/docs/api-reference/embeddings/create
+type EmbeddingRequest struct {
+	// Input is the text to embed, encoded as a string, an array of strings, an array of tokens,
+	// or an array of token arrays.
+	Input EmbeddingRequestInput `json:"input"`
+	// Model is the ID of the model to use.
+	Model string `json:"model"`
+	// EncodingFormat is the format to return the embeddings in. Can be either "float" or "base64".
+	EncodingFormat *string `json:"encoding_format,omitempty"`
+	// Dimensions is the number of dimensions the resulting output embeddings should have.
+	// Only supported in text-embedding-3 and later models.
+	Dimensions *int `json:"dimensions,omitempty"`
+	// User is a unique identifier representing the end-user.
+	User *string `json:"user,omitempty"`
+}
+
+// EmbeddingRequestInput is the union type of the `input` field of [EmbeddingRequest].
+// Value is one of string, []string, []int64 or [][]int64.
+type EmbeddingRequestInput struct {
+	Value interface{}
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (e *EmbeddingRequestInput) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		e.Value = str
+		return nil
+	}
+	var strs []string
+	if err := json.Unmarshal(data, &strs); err == nil {
+		e.Value = strs
+		return nil
+	}
+	var tokens []int64
+	if err := json.Unmarshal(data, &tokens); err == nil {
+		e.Value = tokens
+		return nil
+	}
+	var tokenArrays [][]int64
+	if err := json.Unmarshal(data, &tokenArrays); err == nil {
+		e.Value = tokenArrays
+		return nil
+	}
+	return errors.New("cannot unmarshal JSON data as string, array of strings, array of tokens or array of token arrays")
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (e EmbeddingRequestInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Value)
+}
+
+// EmbeddingResponse is described in the OpenAI API documentation
+// https://platform.openai.com/docs/api-reference/embeddings/object
+type EmbeddingResponse struct {
+	// Object is always "list".
+	Object string `json:"object"`
+	// Data is the list of embeddings generated by the model.
+	Data []Embedding `json:"data"`
+	// Model is the name of the model used to generate the embeddings.
+	Model string `json:"model"`
+	// Usage is the usage information for the request.
+	Usage EmbeddingUsage `json:"usage"`
+}
+
+// Embedding is described in the OpenAI API documentation
+// https://platform.openai.com/docs/api-reference/embeddings/object
+type Embedding struct {
+	// Object is always "embedding".
+	Object string `json:"object"`
+	// Embedding is the embedding vector, which is either a list of floats or a base64 encoded string
+	// depending on the encoding_format of the request.
+	Embedding EmbeddingUnion `json:"embedding"`
+	// Index is the index of the embedding in the list of embeddings.
+	Index int `json:"index"`
+}
+
+// EmbeddingUnion is the union type of the `embedding` field of [Embedding].
+// Value is either []float64 or string.
+type EmbeddingUnion struct {
+	Value interface{}
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (e *EmbeddingUnion) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		e.Value = str
+		return nil
+	}
+	var floats []float64
+	if err := json.Unmarshal(data, &floats); err == nil {
+		e.Value = floats
+		return nil
+	}
+	return errors.New("cannot unmarshal JSON data as array of floats or string")
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (e EmbeddingUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Value)
+}
+
+// EmbeddingUsage is described in the OpenAI API documentation
+// https://platform.openai.com/docs/api-reference/embeddings/object
+type EmbeddingUsage struct {
+	// PromptTokens is the number of tokens used by the input.
+	PromptTokens int `json:"prompt_tokens"`
+	// TotalTokens is the total number of tokens used by the request.
+	TotalTokens int `json:"total_tokens"`
+}
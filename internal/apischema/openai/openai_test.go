@@ -327,3 +327,55 @@ func TestModelListMarshal(t *testing.T) {
 	// Unmarshalling initializes other fields in time.Time we're not interested with. Just compare the actual time.
 	require.Equal(t, time.Time(model.Created).Unix(), time.Time(out.Data[0].Created).Unix())
 }
+
+func TestEmbeddingRequestInputUnmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		in     string
+		out    interface{}
+		expErr string
+	}{
+		{name: "string", in: `"hello"`, out: "hello"},
+		{name: "array of strings", in: `["hello", "world"]`, out: []string{"hello", "world"}},
+		{name: "array of tokens", in: `[1, 2, 3]`, out: []int64{1, 2, 3}},
+		{name: "array of token arrays", in: `[[1, 2], [3]]`, out: [][]int64{{1, 2}, {3}}},
+		{name: "invalid", in: `{"foo": "bar"}`, expErr: "cannot unmarshal JSON data"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var input EmbeddingRequestInput
+			err := json.Unmarshal([]byte(tc.in), &input)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.out, input.Value)
+
+			b, err := json.Marshal(input)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.in, string(b))
+		})
+	}
+}
+
+func TestEmbeddingResponseUnmarshal(t *testing.T) {
+	t.Run("float", func(t *testing.T) {
+		raw := `{"object":"list","data":[{"object":"embedding","embedding":[0.1,-0.2],"index":0}],"model":"text-embedding-3-small","usage":{"prompt_tokens":5,"total_tokens":5}}`
+		var resp EmbeddingResponse
+		require.NoError(t, json.Unmarshal([]byte(raw), &resp))
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, []float64{0.1, -0.2}, resp.Data[0].Embedding.Value)
+		require.Equal(t, 5, resp.Usage.PromptTokens)
+		require.Equal(t, 5, resp.Usage.TotalTokens)
+
+		b, err := json.Marshal(resp)
+		require.NoError(t, err)
+		require.JSONEq(t, raw, string(b))
+	})
+	t.Run("base64", func(t *testing.T) {
+		raw := `{"object":"list","data":[{"object":"embedding","embedding":"AAAA","index":0}],"model":"m","usage":{"prompt_tokens":1,"total_tokens":1}}`
+		var resp EmbeddingResponse
+		require.NoError(t, json.Unmarshal([]byte(raw), &resp))
+		require.Equal(t, "AAAA", resp.Data[0].Embedding.Value)
+	})
+}
@@ -0,0 +1,306 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ResponseRequest represents a request structure for the Responses API.
+// https://platform.openai.com/docs/api-reference/responses/create
+//
+// Only the fields used by the AI Gateway are defined. The request is passed through as-is to the OpenAI
+// compatible backends, so the other fields are preserved.
+type ResponseRequest struct {
+	// Model is the ID of the model to use.
+	Model string `json:"model"`
+	// Input is the text, image, or file inputs to the model, used to generate a response.
+	Input ResponseInput `json:"input"`
+	// Instructions is a system (or developer) message inserted into the model's context.
+	Instructions *string `json:"instructions,omitempty"`
+	// Stream is true if the response is streamed to the client as server-sent events.
+	Stream bool `json:"stream,omitempty"`
+	// MaxOutputTokens is an upper bound for the number of tokens that can be generated for a response,
+	// including visible output tokens and reasoning tokens.
+	MaxOutputTokens *int64 `json:"max_output_tokens,omitempty"` //nolint:tagliatelle //follow openai api
+	// Temperature is the sampling temperature to use, between 0 and 2.
+	Temperature *float64 `json:"temperature,omitempty"`
+	// TopP is the nucleus sampling probability mass.
+	TopP *float64 `json:"top_p,omitempty"` //nolint:tagliatelle //follow openai api
+	// Tools is the array of tools the model may call while generating a response.
+	Tools []ResponseTool `json:"tools,omitempty"`
+	// ToolChoice is how the model should select which tool to use, which is either a string
+	// ("none", "auto" or "required") or an object specifying the tool.
+	ToolChoice any `json:"tool_choice,omitempty"` //nolint:tagliatelle //follow openai api
+	// PreviousResponseID is the unique ID of the previous response to the model to create multi-turn conversations.
+	PreviousResponseID *string `json:"previous_response_id,omitempty"` //nolint:tagliatelle //follow openai api
+	// Store is whether to store the generated model response for later retrieval via API.
+	Store *bool `json:"store,omitempty"`
+	// User is a unique identifier representing the end-user.
+	User string `json:"user,omitempty"`
+}
+
+// ResponseInput is the union type of the `input` field of [ResponseRequest].
+// Value is either string or []ResponseItem.
+type ResponseInput struct {
+	Value interface{}
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (r *ResponseInput) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		r.Value = str
+		return nil
+	}
+	var items []ResponseItem
+	if err := json.Unmarshal(data, &items); err == nil {
+		r.Value = items
+		return nil
+	}
+	return errors.New("cannot unmarshal JSON data as string or array of input items")
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (r ResponseInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Value)
+}
+
+// ResponseItemType is the type of [ResponseItem].
+type ResponseItemType string
+
+const (
+	// ResponseItemTypeMessage is a message to or from the model. The type can be omitted in the input messages.
+	ResponseItemTypeMessage ResponseItemType = "message"
+	// ResponseItemTypeFunctionCall is a tool call to run a function.
+	ResponseItemTypeFunctionCall ResponseItemType = "function_call"
+	// ResponseItemTypeFunctionCallOutput is the output of a function tool call.
+	ResponseItemTypeFunctionCallOutput ResponseItemType = "function_call_output"
+	// ResponseItemTypeReasoning is the chain of thought used by a reasoning model while generating a response.
+	ResponseItemTypeReasoning ResponseItemType = "reasoning"
+	// ResponseItemTypeItemReference is a reference to an item by ID.
+	ResponseItemTypeItemReference ResponseItemType = "item_reference"
+)
+
+// ResponseItem is an input or output item of the Responses API. This is the union of the item types
+// distinguished by the Type field, and only the fields relevant to the type are set.
+// https://platform.openai.com/docs/api-reference/responses/object#responses/object-output
+type ResponseItem struct {
+	// Type is the type of the item. This is empty for the input messages without the type.
+	Type ResponseItemType `json:"type,omitempty"`
+	// ID is the unique ID of the item.
+	ID string `json:"id,omitempty"`
+	// Status is the status of the item, one of "in_progress", "completed", or "incomplete".
+	Status string `json:"status,omitempty"`
+
+	// Role is the role of the message, one of "user", "assistant", "system", or "developer".
+	Role string `json:"role,omitempty"`
+	// Content is the content of the message.
+	Content *ResponseMessageContent `json:"content,omitempty"`
+
+	// CallID is the unique ID of the function tool call generated by the model.
+	CallID string `json:"call_id,omitempty"` //nolint:tagliatelle //follow openai api
+	// Name is the name of the function to run.
+	Name string `json:"name,omitempty"`
+	// Arguments is a JSON string of the arguments to pass to the function.
+	Arguments string `json:"arguments,omitempty"`
+	// Output is a JSON string of the output of the function tool call.
+	Output string `json:"output,omitempty"`
+
+	// Summary is the reasoning summary content.
+	Summary []ResponseReasoningSummary `json:"summary,omitempty"`
+	// EncryptedContent is the encrypted content of the reasoning item.
+	EncryptedContent *string `json:"encrypted_content,omitempty"` //nolint:tagliatelle //follow openai api
+}
+
+// ResponseMessageContent is the union type of the `content` field of a message [ResponseItem].
+// Value is either string or []ResponseContentPart.
+type ResponseMessageContent struct {
+	Value interface{}
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (r *ResponseMessageContent) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		r.Value = str
+		return nil
+	}
+	var parts []ResponseContentPart
+	if err := json.Unmarshal(data, &parts); err == nil {
+		r.Value = parts
+		return nil
+	}
+	return errors.New("cannot unmarshal JSON data as string or array of content parts")
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (r ResponseMessageContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Value)
+}
+
+// ResponseContentPartType is the type of [ResponseContentPart].
+type ResponseContentPartType string
+
+const (
+	ResponseContentPartTypeInputText  ResponseContentPartType = "input_text"
+	ResponseContentPartTypeInputImage ResponseContentPartType = "input_image"
+	ResponseContentPartTypeInputFile  ResponseContentPartType = "input_file"
+	ResponseContentPartTypeOutputText ResponseContentPartType = "output_text"
+	ResponseContentPartTypeRefusal    ResponseContentPartType = "refusal"
+)
+
+// ResponseContentPart is a part of the message content of the Responses API.
+type ResponseContentPart struct {
+	// Type is the type of the content part.
+	Type ResponseContentPartType `json:"type"`
+	// Text is the text of the input_text and output_text parts.
+	Text string `json:"text,omitempty"`
+	// ImageURL is the URL of the image of the input_image part, which can be a data URL.
+	ImageURL *string `json:"image_url,omitempty"` //nolint:tagliatelle //follow openai api
+	// FileID is the ID of the file of the input_image and input_file parts.
+	FileID *string `json:"file_id,omitempty"` //nolint:tagliatelle //follow openai api
+	// Detail is the detail level of the image of the input_image part.
+	Detail string `json:"detail,omitempty"`
+	// Refusal is the refusal explanation of the refusal part.
+	Refusal string `json:"refusal,omitempty"`
+	// Annotations are the annotations of the output_text part.
+	Annotations []any `json:"annotations,omitempty"`
+}
+
+// ResponseReasoningSummary is a summary of the reasoning of the reasoning [ResponseItem].
+type ResponseReasoningSummary struct {
+	// Type is always "summary_text".
+	Type string `json:"type"`
+	// Text is a short summary of the reasoning used by the model.
+	Text string `json:"text"`
+}
+
+// ResponseToolType is the type of [ResponseTool].
+type ResponseToolType string
+
+// ResponseToolTypeFunction is the function tool defined by the client. The other types are the built-in tools
+// of OpenAI, such as "web_search_preview" and "file_search".
+const ResponseToolTypeFunction ResponseToolType = "function"
+
+// ResponseTool is a tool the model may call while generating a response.
+type ResponseTool struct {
+	// Type is the type of the tool.
+	Type ResponseToolType `json:"type"`
+	// Name is the name of the function tool.
+	Name string `json:"name,omitempty"`
+	// Description is the description of the function tool.
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema object describing the parameters of the function tool.
+	Parameters any `json:"parameters,omitempty"`
+	// Strict is whether to enforce strict parameter validation of the function tool.
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// ResponseStatus is the status of [Response].
+type ResponseStatus string
+
+const (
+	ResponseStatusCompleted  ResponseStatus = "completed"
+	ResponseStatusFailed     ResponseStatus = "failed"
+	ResponseStatusInProgress ResponseStatus = "in_progress"
+	ResponseStatusIncomplete ResponseStatus = "incomplete"
+)
+
+// Response represents a response from /v1/responses.
+// https://platform.openai.com/docs/api-reference/responses/object
+type Response struct {
+	// ID is the unique identifier for this response.
+	ID string `json:"id"`
+	// Object is always "response".
+	Object string `json:"object"`
+	// CreatedAt is the Unix timestamp (in seconds) of when this response was created.
+	CreatedAt int64 `json:"created_at"` //nolint:tagliatelle //follow openai api
+	// Model is the model ID used to generate the response.
+	Model string `json:"model"`
+	// Status is the status of the response generation.
+	Status ResponseStatus `json:"status"`
+	// Output is the array of content items generated by the model.
+	Output []ResponseItem `json:"output"`
+	// IncompleteDetails is the details about why the response is incomplete.
+	IncompleteDetails *ResponseIncompleteDetails `json:"incomplete_details,omitempty"` //nolint:tagliatelle //follow openai api
+	// Usage is the token usage details. This is not set until the response is completed.
+	Usage *ResponseUsage `json:"usage,omitempty"`
+}
+
+// ResponseIncompleteDetails is the details about why the response is incomplete.
+type ResponseIncompleteDetails struct {
+	// Reason is the reason why the response is incomplete, either "max_output_tokens" or "content_filter".
+	Reason string `json:"reason"`
+}
+
+// ResponseUsage is the token usage details of [Response].
+// https://platform.openai.com/docs/api-reference/responses/object#responses/object-usage
+type ResponseUsage struct {
+	InputTokens         int                               `json:"input_tokens"`                    //nolint:tagliatelle //follow openai api
+	InputTokensDetails  *ResponseUsageInputTokensDetails  `json:"input_tokens_details,omitempty"`  //nolint:tagliatelle //follow openai api
+	OutputTokens        int                               `json:"output_tokens"`                   //nolint:tagliatelle //follow openai api
+	OutputTokensDetails *ResponseUsageOutputTokensDetails `json:"output_tokens_details,omitempty"` //nolint:tagliatelle //follow openai api
+	TotalTokens         int                               `json:"total_tokens"`                    //nolint:tagliatelle //follow openai api
+}
+
+// ResponseUsageInputTokensDetails is the breakdown of the input tokens.
+type ResponseUsageInputTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"` //nolint:tagliatelle //follow openai api
+}
+
+// ResponseUsageOutputTokensDetails is the breakdown of the output tokens.
+type ResponseUsageOutputTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"` //nolint:tagliatelle //follow openai api
+}
+
+// The types of the server-sent events streamed from /v1/responses.
+// https://platform.openai.com/docs/api-reference/responses-streaming
+const (
+	ResponseStreamEventTypeCreated                    = "response.created"
+	ResponseStreamEventTypeInProgress                 = "response.in_progress"
+	ResponseStreamEventTypeCompleted                  = "response.completed"
+	ResponseStreamEventTypeIncomplete                 = "response.incomplete"
+	ResponseStreamEventTypeFailed                     = "response.failed"
+	ResponseStreamEventTypeOutputItemAdded            = "response.output_item.added"
+	ResponseStreamEventTypeOutputItemDone             = "response.output_item.done"
+	ResponseStreamEventTypeContentPartAdded           = "response.content_part.added"
+	ResponseStreamEventTypeContentPartDone            = "response.content_part.done"
+	ResponseStreamEventTypeOutputTextDelta            = "response.output_text.delta"
+	ResponseStreamEventTypeOutputTextDone             = "response.output_text.done"
+	ResponseStreamEventTypeFunctionCallArgumentsDelta = "response.function_call_arguments.delta"
+	ResponseStreamEventTypeFunctionCallArgumentsDone  = "response.function_call_arguments.done"
+	ResponseStreamEventTypeReasoningSummaryTextDelta  = "response.reasoning_summary_text.delta"
+)
+
+// ResponseStreamEvent is a server-sent event streamed from /v1/responses. This is the union of the event types
+// distinguished by the Type field, and only the fields relevant to the type are set.
+// https://platform.openai.com/docs/api-reference/responses-streaming
+type ResponseStreamEvent struct {
+	// Type is the type of the event.
+	Type string `json:"type"`
+	// SequenceNumber is the sequence number of the event.
+	SequenceNumber int `json:"sequence_number"` //nolint:tagliatelle //follow openai api
+	// Response is the response of the response.* lifecycle events.
+	Response *Response `json:"response,omitempty"`
+	// OutputIndex is the index of the output item that the event is associated with.
+	OutputIndex *int `json:"output_index,omitempty"` //nolint:tagliatelle //follow openai api
+	// ContentIndex is the index of the content part that the event is associated with.
+	ContentIndex *int `json:"content_index,omitempty"` //nolint:tagliatelle //follow openai api
+	// ItemID is the ID of the output item that the event is associated with.
+	ItemID string `json:"item_id,omitempty"` //nolint:tagliatelle //follow openai api
+	// Item is the output item of the output_item events.
+	Item *ResponseItem `json:"item,omitempty"`
+	// Part is the content part of the content_part events.
+	Part *ResponseContentPart `json:"part,omitempty"`
+	// Delta is the text or the function call arguments delta of the delta events.
+	Delta string `json:"delta,omitempty"`
+	// Text is the complete text of the output_text.done event.
+	Text string `json:"text,omitempty"`
+	// Arguments is the complete arguments of the function_call_arguments.done event.
+	Arguments string `json:"arguments,omitempty"`
+}
@@ -0,0 +1,89 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseInputUnmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		in     string
+		out    interface{}
+		expErr string
+	}{
+		{name: "string", in: `"hello"`, out: "hello"},
+		{
+			name: "items",
+			in: `[
+{"role": "user", "content": "what is the weather?"},
+{"type": "message", "role": "user", "content": [{"type": "input_text", "text": "in Tokyo"}]},
+{"type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Tokyo\"}"},
+{"type": "function_call_output", "call_id": "call_1", "output": "sunny"},
+{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "thinking"}]}
+]`,
+			out: []ResponseItem{
+				{Role: "user", Content: &ResponseMessageContent{Value: "what is the weather?"}},
+				{
+					Type: ResponseItemTypeMessage, Role: "user",
+					Content: &ResponseMessageContent{Value: []ResponseContentPart{{Type: ResponseContentPartTypeInputText, Text: "in Tokyo"}}},
+				},
+				{Type: ResponseItemTypeFunctionCall, CallID: "call_1", Name: "get_weather", Arguments: `{"city":"Tokyo"}`},
+				{Type: ResponseItemTypeFunctionCallOutput, CallID: "call_1", Output: "sunny"},
+				{Type: ResponseItemTypeReasoning, ID: "rs_1", Summary: []ResponseReasoningSummary{{Type: "summary_text", Text: "thinking"}}},
+			},
+		},
+		{name: "invalid", in: `{"foo": "bar"}`, expErr: "cannot unmarshal JSON data"},
+		{name: "invalid content", in: `[{"role": "user", "content": 1}]`, expErr: "cannot unmarshal JSON data"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var input ResponseInput
+			err := json.Unmarshal([]byte(tc.in), &input)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.out, input.Value)
+
+			b, err := json.Marshal(input)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.in, string(b))
+		})
+	}
+}
+
+func TestResponseUnmarshal(t *testing.T) {
+	raw := `{
+"id": "resp_1",
+"object": "response",
+"created_at": 1741476542,
+"model": "gpt-4o-2024-08-06",
+"status": "completed",
+"output": [
+  {"type": "message", "id": "msg_1", "status": "completed", "role": "assistant",
+   "content": [{"type": "output_text", "text": "Hello!", "annotations": []}]}
+],
+"usage": {"input_tokens": 10, "input_tokens_details": {"cached_tokens": 0}, "output_tokens": 5,
+  "output_tokens_details": {"reasoning_tokens": 0}, "total_tokens": 15}
+}`
+	var resp Response
+	require.NoError(t, json.Unmarshal([]byte(raw), &resp))
+	require.Equal(t, ResponseStatusCompleted, resp.Status)
+	require.Len(t, resp.Output, 1)
+	require.Equal(t, []ResponseContentPart{{Type: ResponseContentPartTypeOutputText, Text: "Hello!", Annotations: []any{}}},
+		resp.Output[0].Content.Value)
+	require.Equal(t, 15, resp.Usage.TotalTokens)
+
+	var event ResponseStreamEvent
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"response.completed","sequence_number":3,"response":`+raw+`}`), &event))
+	require.Equal(t, ResponseStreamEventTypeCompleted, event.Type)
+	require.Equal(t, 10, event.Response.Usage.InputTokens)
+}
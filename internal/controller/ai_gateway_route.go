@@ -8,8 +8,14 @@ package controller
 import (
 	"context"
 	"fmt"
+	"math"
+	"net"
 	"path"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
@@ -26,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gwaiev1a2 "sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
 	"sigs.k8s.io/yaml"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
@@ -51,6 +58,32 @@ const (
 	awsCredentialsKey = "credentials"
 	// azureAccessTokenKey is the key used to store Azure access token in Kubernetes secrets.
 	azureAccessTokenKey = "azureAccessToken"
+	// gcpAccessTokenKey is the key used to store GCP access token in Kubernetes secrets.
+	gcpAccessTokenKey = "gcpAccessToken"
+	// spendBudgetLocalStoreVolumeName is the name of the volume keeping the file of the Local spend budget store.
+	spendBudgetLocalStoreVolumeName = "spend-budget-store"
+	// spendBudgetLocalStoreMountPath is where the volume of the Local spend budget store is mounted on the external proc.
+	spendBudgetLocalStoreMountPath = "/var/lib/ai-gateway/spend-budget"
+	// spendBudgetRedisPasswordVolumeName is the name of the volume of the secret containing the Redis password.
+	spendBudgetRedisPasswordVolumeName = "spend-budget-redis-password" // #nosec G101
+	// spendBudgetRedisPasswordMountPath is where the secret containing the Redis password is mounted on the external proc.
+	spendBudgetRedisPasswordMountPath = "/etc/spend_budget/redis" // #nosec G101
+	// redisPasswordKey is the key used to store the Redis password in Kubernetes secrets.
+	redisPasswordKey = "password"
+	// inferencePoolCAMountPath is where the secrets containing the CA certificates of the InferencePools are mounted
+	// on the external proc. The secret is mounted on the subdirectory named after it.
+	inferencePoolCAMountPath = "/etc/inference_pool/ca"
+	// caCertificateKey is the key used to store the CA certificates in Kubernetes secrets.
+	caCertificateKey = "ca.crt"
+	// usageRecordFileSinkMountPath is where the volumes of the File usage record sinks are mounted on the external proc.
+	// The volume of the i-th sink is mounted on the subdirectory named i.
+	usageRecordFileSinkMountPath = "/var/lib/ai-gateway/usage-records"
+	// auditLogFileSinkMountPath is where the volumes of the File audit log sinks are mounted on the external proc.
+	// The volume of the i-th sink is mounted on the subdirectory named i.
+	auditLogFileSinkMountPath = "/var/lib/ai-gateway/audit-log"
+	// mirrorResultFileSinkMountPath is where the volumes of the File mirror result sinks are mounted on the external proc.
+	// The volume of the i-th sink is mounted on the subdirectory named i.
+	mirrorResultFileSinkMountPath = "/var/lib/ai-gateway/mirror-results"
 )
 
 // AIGatewayRouteController implements [reconcile.TypedReconciler].
@@ -292,7 +325,18 @@ func (c *AIGatewayRouteController) reconcileExtProcConfigMap(ctx context.Context
 			key := fmt.Sprintf("%s.%s", backendRef.Name, aiGatewayRoute.Namespace)
 			ecBackendConfig.Name = key
 			ecBackendConfig.Weight = backendRef.Weight
+			if backendRef.Priority != nil {
+				ecBackendConfig.Priority = int(*backendRef.Priority)
+			}
+			for _, m := range backendRef.ModelNameMappings {
+				ecBackendConfig.ModelNameMappings = append(ecBackendConfig.ModelNameMappings,
+					filterapi.ModelNameMapping{From: m.From, To: m.To})
+			}
 			if isInferencePoolRef(backendRef) {
+				// The filter cannot send the request directly to the InferencePool when failing over to it.
+				if rule.Fallback != nil {
+					return fmt.Errorf("fallback of rule %d is not supported with InferencePool %s", i, backendRef.Name)
+				}
 				var pool *gwaiev1a2.InferencePool
 				var referencedAIServiceBackends []aigv1a1.AIServiceBackend
 				pool, referencedAIServiceBackends, err = c.getPoolAndReferencedAIServiceBackends(ctx, aiGatewayRoute.Namespace, backendRef.Name)
@@ -303,6 +347,17 @@ func (c *AIGatewayRouteController) reconcileExtProcConfigMap(ctx context.Context
 				if err != nil {
 					return fmt.Errorf("failed to create dynamic load balancing: %w", err)
 				}
+				if err = setDynamicLoadBalancingPolicy(ecBackendConfig.DynamicLoadBalancing, aiGatewayRoute.Namespace, backendRef.LoadBalancing); err != nil {
+					return fmt.Errorf("invalid load balancing of InferencePool %s: %w", backendRef.Name, err)
+				}
+				// The extension server cannot create the cluster connecting with TLS without the CA certificates, so
+				// the missing ones are reported here instead of silently dropping the traffic to the InferencePool.
+				if err = c.validateInferencePoolCA(ctx, aiGatewayRoute.Namespace, backendRef.LoadBalancing); err != nil {
+					return fmt.Errorf("invalid load balancing of InferencePool %s: %w", backendRef.Name, err)
+				}
+				if err = validateSheddableModels(ecBackendConfig.DynamicLoadBalancing); err != nil {
+					return fmt.Errorf("invalid load balancing of InferencePool %s: %w", backendRef.Name, err)
+				}
 			} else {
 				var backendObj *aigv1a1.AIServiceBackend
 				backendObj, err = c.backend(ctx, aiGatewayRoute.Namespace, backendRef.Name)
@@ -311,6 +366,17 @@ func (c *AIGatewayRouteController) reconcileExtProcConfigMap(ctx context.Context
 				}
 				ecBackendConfig.Schema.Name = filterapi.APISchemaName(backendObj.Spec.APISchema.Name)
 				ecBackendConfig.Schema.Version = backendObj.Spec.APISchema.Version
+				if g := backendObj.Spec.AWSBedrockGuardrail; g != nil {
+					ecBackendConfig.AWSBedrockGuardrail = &filterapi.AWSBedrockGuardrail{
+						Identifier: g.Identifier, Version: g.Version, Trace: g.Trace,
+					}
+				}
+				if rule.Fallback != nil || rule.Hedging != nil {
+					ecBackendConfig.Endpoint, err = c.backendEndpoint(ctx, backendObj)
+					if err != nil {
+						return fmt.Errorf("failed to get endpoint of AIServiceBackend %s: %w", key, err)
+					}
+				}
 				if bspRef := backendObj.Spec.BackendSecurityPolicyRef; bspRef != nil {
 					volumeName := backendSecurityPolicyVolumeName(
 						i, j, string(backendObj.Spec.BackendSecurityPolicyRef.Name),
@@ -322,10 +388,65 @@ func (c *AIGatewayRouteController) reconcileExtProcConfigMap(ctx context.Context
 				}
 			}
 		}
-		ec.Rules[i].Headers = make([]filterapi.HeaderMatch, len(rule.Matches))
-		for j, match := range rule.Matches {
-			ec.Rules[i].Headers[j].Name = match.Headers[0].Name
-			ec.Rules[i].Headers[j].Value = match.Headers[0].Value
+		if fallback := rule.Fallback; fallback != nil {
+			ec.Rules[i].Fallback = &filterapi.FallbackPolicy{}
+			for _, code := range fallback.RetriableStatusCodes {
+				ec.Rules[i].Fallback.RetriableStatusCodes = append(ec.Rules[i].Fallback.RetriableStatusCodes, int(code))
+			}
+			if fallback.MaxAttempts != nil {
+				ec.Rules[i].Fallback.MaxAttempts = int(*fallback.MaxAttempts)
+			}
+			if fallback.Timeout != nil {
+				if ec.Rules[i].Fallback.Timeout, err = time.ParseDuration(string(*fallback.Timeout)); err != nil {
+					return fmt.Errorf("invalid fallback timeout of rule %d: %w", i, err)
+				}
+			}
+		}
+		if hedging := rule.Hedging; hedging != nil {
+			var delay time.Duration
+			if delay, err = time.ParseDuration(string(hedging.Delay)); err != nil {
+				return fmt.Errorf("invalid hedging delay of rule %d: %w", i, err)
+			}
+			ec.Rules[i].Hedging = &filterapi.HedgingPolicy{Delay: delay}
+			if hedging.Timeout != nil {
+				if ec.Rules[i].Hedging.Timeout, err = time.ParseDuration(string(*hedging.Timeout)); err != nil {
+					return fmt.Errorf("invalid hedging timeout of rule %d: %w", i, err)
+				}
+			}
+		}
+		if cb := rule.CircuitBreaker; cb != nil {
+			if ec.Rules[i].CircuitBreaker, err = circuitBreakerPolicy(cb); err != nil {
+				return fmt.Errorf("invalid circuit breaker of rule %d: %w", i, err)
+			}
+		}
+		if sticky := rule.StickyRouting; sticky != nil {
+			ec.Rules[i].StickyRouting = &filterapi.StickyRouting{HeaderName: strings.ToLower(sticky.HeaderName)}
+		}
+		if experiment := rule.Experiment; experiment != nil {
+			ec.Rules[i].Experiment = &filterapi.Experiment{Name: experiment.Name}
+			for _, arm := range experiment.Arms {
+				fa := filterapi.ExperimentArm{Name: arm.Name, Weight: int(ptr.Deref(arm.Weight, 1))}
+				for _, name := range arm.BackendRefs {
+					fa.Backends = append(fa.Backends, fmt.Sprintf("%s.%s", name, aiGatewayRoute.Namespace))
+				}
+				ec.Rules[i].Experiment.Arms = append(ec.Rules[i].Experiment.Arms, fa)
+			}
+		}
+		if mirror := rule.Mirror; mirror != nil {
+			if ec.Rules[i].Mirror, err = c.mirrorPolicy(ctx, aiGatewayRoute.Namespace, i, mirror); err != nil {
+				return fmt.Errorf("invalid mirror of rule %d: %w", i, err)
+			}
+		}
+		ec.Rules[i].Matches = make([]filterapi.RouteRuleMatch, len(rule.Matches))
+		for j := range rule.Matches {
+			if err = validateRouteRuleMatch(&rule.Matches[j]); err != nil {
+				return fmt.Errorf("invalid match %d of rule %d: %w", j, i, err)
+			}
+			ec.Rules[i].Matches[j] = filterapi.RouteRuleMatch{
+				Headers:     rule.Matches[j].Headers,
+				QueryParams: rule.Matches[j].QueryParams,
+				Path:        rule.Matches[j].Path,
+			}
 		}
 	}
 
@@ -339,6 +460,8 @@ func (c *AIGatewayRouteController) reconcileExtProcConfigMap(ctx context.Context
 			fc.Type = filterapi.LLMRequestCostTypeOutputToken
 		case aigv1a1.LLMRequestCostTypeTotalToken:
 			fc.Type = filterapi.LLMRequestCostTypeTotalToken
+		case aigv1a1.LLMRequestCostTypeEstimatedInputToken:
+			fc.Type = filterapi.LLMRequestCostTypeEstimatedInputToken
 		case aigv1a1.LLMRequestCostTypeCEL:
 			fc.Type = filterapi.LLMRequestCostTypeCEL
 			expr := *cost.CEL
@@ -354,6 +477,64 @@ func (c *AIGatewayRouteController) reconcileExtProcConfigMap(ctx context.Context
 		ec.LLMRequestCosts = append(ec.LLMRequestCosts, fc)
 	}
 
+	if rc := spec.ResponseCache; rc != nil {
+		ec.ResponseCache = &filterapi.ResponseCacheConfig{TTL: time.Hour, MaxEntries: 1024}
+		if rc.TTL != nil {
+			ec.ResponseCache.TTL, err = time.ParseDuration(string(*rc.TTL))
+			if err != nil {
+				return fmt.Errorf("invalid response cache TTL: %w", err)
+			}
+		}
+		if rc.MaxEntries != nil {
+			ec.ResponseCache.MaxEntries = int(*rc.MaxEntries)
+		}
+	}
+
+	if spec.Guardrails != nil {
+		ec.Guardrails, err = guardrailsConfig(spec.Guardrails)
+		if err != nil {
+			return fmt.Errorf("invalid guardrails: %w", err)
+		}
+	}
+
+	if spec.SpendBudgets != nil {
+		ec.SpendBudgets, err = spendBudgetsConfig(spec.SpendBudgets)
+		if err != nil {
+			return fmt.Errorf("invalid spend budgets: %w", err)
+		}
+	}
+
+	if spec.UsageRecords != nil {
+		ec.UsageRecords, err = usageRecordsConfig(spec.UsageRecords)
+		if err != nil {
+			return fmt.Errorf("invalid usage records: %w", err)
+		}
+	}
+
+	if spec.AuditLog != nil {
+		ec.AuditLog, err = auditLogConfig(spec.AuditLog)
+		if err != nil {
+			return fmt.Errorf("invalid audit log: %w", err)
+		}
+	}
+
+	if mr := spec.MirrorResults; mr != nil {
+		ec.MirrorResults = &filterapi.MirrorResultsConfig{}
+		ec.MirrorResults.Sinks, err = auditLogSinksConfig(mr.Sinks, mirrorResultFileSinkMountPath, "mirror")
+		if err != nil {
+			return fmt.Errorf("invalid mirror results: %w", err)
+		}
+	}
+
+	if m := spec.Metrics; m != nil && len(m.RequestHeaderAttributes) > 0 {
+		ec.Metrics = &filterapi.MetricsConfig{MaxAttributeValues: int(ptr.Deref(m.MaxAttributeValues, 100))}
+		for _, a := range m.RequestHeaderAttributes {
+			// The header names are lower-cased by Envoy.
+			ec.Metrics.RequestHeaderAttributes = append(ec.Metrics.RequestHeaderAttributes,
+				filterapi.MetricsRequestHeaderAttribute{Header: strings.ToLower(a.Header), Attribute: a.Attribute})
+		}
+	}
+
 	marshaled, err := yaml.Marshal(ec)
 	if err != nil {
 		return fmt.Errorf("failed to marshal extproc config: %w", err)
@@ -417,6 +598,17 @@ func (c *AIGatewayRouteController) bspToFilterAPIAuth(ctx context.Context, names
 				Filename: path.Join(backendSecurityMountPath(volumeName), azureAccessTokenKey),
 			},
 		}, nil
+	case aigv1a1.BackendSecurityPolicyTypeGCPCredentials:
+		if backendSecurityPolicy.Spec.GCPCredentials == nil {
+			return nil, fmt.Errorf("GCPCredentials type selected but not defined %s", backendSecurityPolicy.Name)
+		}
+		return &filterapi.BackendAuth{
+			GCPAuth: &filterapi.GCPAuth{
+				Filename:    path.Join(backendSecurityMountPath(volumeName), gcpAccessTokenKey),
+				ProjectName: backendSecurityPolicy.Spec.GCPCredentials.ProjectName,
+				Region:      backendSecurityPolicy.Spec.GCPCredentials.Region,
+			},
+		}, nil
 	default:
 		return nil, fmt.Errorf("invalid backend security type %s for policy %s", backendSecurityPolicy.Spec.Type,
 			backendSecurityPolicy.Name)
@@ -452,9 +644,13 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 				// When the target is InferencePool, we don't need the HTTPRoute level setting, but
 				// will route to ORIGINAL_DST, so we don't need to set the backendRefs.
 				//
-				// However, to properly route to the ORIGINAL_DST, we use the constant OriginalDstClusterName
-				// as the selected backend name.
-				dstName = extensionserver.OriginalDstClusterName
+				// However, to properly route to the ORIGINAL_DST, we use the name of the ORIGINAL_DST cluster
+				// as the selected backend name, which is shared by the InferencePools with the same TLS configuration.
+				var tls *aigv1a1.InferencePoolTLS
+				if br.LoadBalancing != nil {
+					tls = br.LoadBalancing.TLS
+				}
+				dstName = extensionserver.OriginalDstClusterNameOf(aiGatewayRoute.Namespace, tls)
 
 				// TODO: make the timeout configurable. One way is to move the timeout setting from
 				// 	AIServiceBackend to AIGatewayRouteBackendRef.
@@ -608,6 +804,11 @@ func (c *AIGatewayRouteController) syncExtProcDeployment(ctx context.Context, ai
 			updatedSpec, err = c.mountBackendSecurityPolicySecrets(ctx, &deployment.Spec.Template.Spec, aiGatewayRoute)
 			if err == nil {
 				deployment.Spec.Template.Spec = *updatedSpec
+				mountSpendBudgetVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+				mountUsageRecordVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+				mountAuditLogVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+				mountMirrorResultVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+				mountInferencePoolCAVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
 			}
 			c.applyExtProcDeploymentConfigUpdate(&deployment.Spec, aiGatewayRoute.Spec.FilterConfig)
 			_, err = c.kube.AppsV1().Deployments(aiGatewayRoute.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
@@ -623,6 +824,11 @@ func (c *AIGatewayRouteController) syncExtProcDeployment(ctx context.Context, ai
 		updatedSpec, err = c.mountBackendSecurityPolicySecrets(ctx, &deployment.Spec.Template.Spec, aiGatewayRoute)
 		if err == nil {
 			deployment.Spec.Template.Spec = *updatedSpec
+			mountSpendBudgetVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+			mountUsageRecordVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+			mountAuditLogVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+			mountMirrorResultVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
+			mountInferencePoolCAVolumes(&deployment.Spec.Template.Spec, aiGatewayRoute)
 		}
 		c.applyExtProcDeploymentConfigUpdate(&deployment.Spec, aiGatewayRoute.Spec.FilterConfig)
 		if _, err = c.kube.AppsV1().Deployments(aiGatewayRoute.Namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
@@ -706,10 +912,172 @@ func (c *AIGatewayRouteController) mountBackendSecurityPolicySecrets(ctx context
 				}
 			}
 		}
+		if mirror := rule.Mirror; mirror != nil {
+			// The embedding backend computing the similarity score comes second, if any.
+			backendNames := []string{mirror.Name}
+			if cmp := mirror.Comparison; cmp != nil && cmp.Type == aigv1a1.MirrorComparisonTypeEmbeddingSimilarity && cmp.EmbeddingSimilarity != nil {
+				backendNames = append(backendNames, cmp.EmbeddingSimilarity.BackendName)
+			}
+			for k, backendName := range backendNames {
+				embedding := k == 1
+				backend, err := c.backend(ctx, aiGatewayRoute.Namespace, backendName)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get backend %s: %w", backendName, err)
+				}
+				if backendSecurityPolicyRef := backend.Spec.BackendSecurityPolicyRef; backendSecurityPolicyRef != nil {
+					volumeName := mirrorBackendSecurityPolicyVolumePrefix(i, embedding) + "-" + string(backendSecurityPolicyRef.Name)
+					volume, volumeMount, err := c.backendSecurityPolicyVolumes(ctx, aiGatewayRoute.Namespace,
+						string(backendSecurityPolicyRef.Name), volumeName)
+					if err != nil {
+						return nil, fmt.Errorf("failed to populate backend security policy volume: %w", err)
+					}
+					spec.Volumes = append(spec.Volumes, volume)
+					container.VolumeMounts = append(container.VolumeMounts, volumeMount)
+				}
+			}
+		}
 	}
 	return spec, nil
 }
 
+// mountSpendBudgetVolumes mounts the volumes used by the spend budget store, if any.
+// This must be called after mountBackendSecurityPolicySecrets, which removes all the volumes but the config.
+func mountSpendBudgetVolumes(spec *corev1.PodSpec, aiGatewayRoute *aigv1a1.AIGatewayRoute) {
+	sb := aiGatewayRoute.Spec.SpendBudgets
+	if sb == nil {
+		return
+	}
+	container := &spec.Containers[0]
+	switch store := &sb.Store; store.Type {
+	case aigv1a1.SpendBudgetStoreTypeLocal:
+		source := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		if store.Local != nil && store.Local.PersistentVolumeClaimName != nil {
+			source = corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: *store.Local.PersistentVolumeClaimName,
+			}}
+		}
+		spec.Volumes = append(spec.Volumes, corev1.Volume{Name: spendBudgetLocalStoreVolumeName, VolumeSource: source})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      spendBudgetLocalStoreVolumeName,
+			MountPath: spendBudgetLocalStoreMountPath,
+		})
+	case aigv1a1.SpendBudgetStoreTypeRedis:
+		if store.Redis == nil || store.Redis.PasswordSecretRef == nil {
+			return
+		}
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: spendBudgetRedisPasswordVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: string(store.Redis.PasswordSecretRef.Name)},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      spendBudgetRedisPasswordVolumeName,
+			MountPath: spendBudgetRedisPasswordMountPath,
+			ReadOnly:  true,
+		})
+	}
+}
+
+// mountUsageRecordVolumes mounts the volumes keeping the files of the File usage record sinks, if any.
+// This must be called after mountBackendSecurityPolicySecrets, which removes all the volumes but the config.
+func mountUsageRecordVolumes(spec *corev1.PodSpec, aiGatewayRoute *aigv1a1.AIGatewayRoute) {
+	ur := aiGatewayRoute.Spec.UsageRecords
+	if ur == nil {
+		return
+	}
+	container := &spec.Containers[0]
+	for i := range ur.Sinks {
+		sink := &ur.Sinks[i]
+		if sink.Type != aigv1a1.UsageRecordSinkTypeFile {
+			continue
+		}
+		source := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		if sink.File != nil && sink.File.PersistentVolumeClaimName != nil {
+			source = corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: *sink.File.PersistentVolumeClaimName,
+			}}
+		}
+		name := fmt.Sprintf("usage-records-%d", i)
+		spec.Volumes = append(spec.Volumes, corev1.Volume{Name: name, VolumeSource: source})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(usageRecordFileSinkMountPath, strconv.Itoa(i)),
+		})
+	}
+}
+
+// mountAuditLogVolumes mounts the volumes keeping the files of the File audit log sinks, if any.
+// This must be called after mountBackendSecurityPolicySecrets, which removes all the volumes but the config.
+func mountAuditLogVolumes(spec *corev1.PodSpec, aiGatewayRoute *aigv1a1.AIGatewayRoute) {
+	if al := aiGatewayRoute.Spec.AuditLog; al != nil {
+		mountAuditLogSinkVolumes(spec, al.Sinks, "audit-log", auditLogFileSinkMountPath)
+	}
+}
+
+// mountMirrorResultVolumes mounts the volumes keeping the files of the File mirror result sinks, if any.
+// This must be called after mountBackendSecurityPolicySecrets, which removes all the volumes but the config.
+func mountMirrorResultVolumes(spec *corev1.PodSpec, aiGatewayRoute *aigv1a1.AIGatewayRoute) {
+	if mr := aiGatewayRoute.Spec.MirrorResults; mr != nil {
+		mountAuditLogSinkVolumes(spec, mr.Sinks, "mirror-results", mirrorResultFileSinkMountPath)
+	}
+}
+
+// mountInferencePoolCAVolumes mounts the secrets containing the CA certificates referenced by the TLS of the
+// InferencePools, if any, which are used to scrape the metrics of their endpoints.
+// This must be called after mountBackendSecurityPolicySecrets, which removes all the volumes but the config.
+func mountInferencePoolCAVolumes(spec *corev1.PodSpec, aiGatewayRoute *aigv1a1.AIGatewayRoute) {
+	container := &spec.Containers[0]
+	mounted := make(map[gwapiv1.ObjectName]struct{})
+	for i := range aiGatewayRoute.Spec.Rules {
+		for j := range aiGatewayRoute.Spec.Rules[i].BackendRefs {
+			lb := aiGatewayRoute.Spec.Rules[i].BackendRefs[j].LoadBalancing
+			if lb == nil || lb.TLS == nil || lb.TLS.CACertificateRef == nil {
+				continue
+			}
+			name := lb.TLS.CACertificateRef.Name
+			if _, ok := mounted[name]; ok {
+				continue
+			}
+			volumeName := fmt.Sprintf("inference-pool-ca-%d", len(mounted))
+			mounted[name] = struct{}{}
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name:         volumeName,
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: string(name)}},
+			})
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: path.Join(inferencePoolCAMountPath, string(name)),
+				ReadOnly:  true,
+			})
+		}
+	}
+}
+
+// mountAuditLogSinkVolumes mounts the volume of the i-th File sink named "<volumePrefix>-<i>" on the subdirectory i
+// of the mountPath.
+func mountAuditLogSinkVolumes(spec *corev1.PodSpec, sinks []aigv1a1.AuditLogSink, volumePrefix, mountPath string) {
+	container := &spec.Containers[0]
+	for i := range sinks {
+		sink := &sinks[i]
+		if sink.Type != aigv1a1.AuditLogSinkTypeFile {
+			continue
+		}
+		source := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		if sink.File != nil && sink.File.PersistentVolumeClaimName != nil {
+			source = corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: *sink.File.PersistentVolumeClaimName,
+			}}
+		}
+		name := fmt.Sprintf("%s-%d", volumePrefix, i)
+		spec.Volumes = append(spec.Volumes, corev1.Volume{Name: name, VolumeSource: source})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(mountPath, strconv.Itoa(i)),
+		})
+	}
+}
+
 func (c *AIGatewayRouteController) backendSecurityPolicyVolumes(ctx context.Context, bspNamespace, bspName, volumeName string) (
 	volume corev1.Volume, volumeMount corev1.VolumeMount, err error,
 ) {
@@ -729,7 +1097,7 @@ func (c *AIGatewayRouteController) backendSecurityPolicyVolumes(ctx context.Cont
 		} else {
 			secretName = rotators.GetBSPSecretName(backendSecurityPolicy.Name)
 		}
-	case aigv1a1.BackendSecurityPolicyTypeAzureCredentials:
+	case aigv1a1.BackendSecurityPolicyTypeAzureCredentials, aigv1a1.BackendSecurityPolicyTypeGCPCredentials:
 		secretName = rotators.GetBSPSecretName(backendSecurityPolicy.Name)
 	default:
 		err = fmt.Errorf("backend security policy %s is not supported", backendSecurityPolicy.Spec.Type)
@@ -758,6 +1126,450 @@ func (c *AIGatewayRouteController) backend(ctx context.Context, namespace, name
 	return backend, nil
 }
 
+// validateRouteRuleMatch sanity checks the regular expressions in the match, which would otherwise
+// fail the AI Gateway filter to load the configuration.
+func validateRouteRuleMatch(m *aigv1a1.AIGatewayRouteRuleMatch) error {
+	for _, h := range m.Headers {
+		if ptr.Deref(h.Type, gwapiv1.HeaderMatchExact) != gwapiv1.HeaderMatchRegularExpression {
+			continue
+		}
+		if _, err := regexp.Compile(h.Value); err != nil {
+			return fmt.Errorf("invalid regular expression for header %s: %w", h.Name, err)
+		}
+	}
+	for _, q := range m.QueryParams {
+		if ptr.Deref(q.Type, gwapiv1.QueryParamMatchExact) != gwapiv1.QueryParamMatchRegularExpression {
+			continue
+		}
+		if _, err := regexp.Compile(q.Value); err != nil {
+			return fmt.Errorf("invalid regular expression for query parameter %s: %w", q.Name, err)
+		}
+	}
+	if p := m.Path; p != nil && ptr.Deref(p.Type, gwapiv1.PathMatchPathPrefix) == gwapiv1.PathMatchRegularExpression {
+		if _, err := regexp.Compile(ptr.Deref(p.Value, "")); err != nil {
+			return fmt.Errorf("invalid regular expression for path: %w", err)
+		}
+	}
+	return nil
+}
+
+// guardrailsConfig converts the guardrails of AIGatewayRoute to the filter configuration.
+func guardrailsConfig(g *aigv1a1.Guardrails) (*filterapi.GuardrailsConfig, error) {
+	ret := &filterapi.GuardrailsConfig{}
+	for i := range g.Rules {
+		r := &g.Rules[i]
+		fr := filterapi.GuardrailRule{Name: r.Name, Action: filterapi.GuardrailAction(r.Action)}
+		switch r.Type {
+		case aigv1a1.GuardrailRuleTypeRegularExpression:
+			fr.RegularExpression = ptr.Deref(r.RegularExpression, "")
+			// Sanity check the regular expression.
+			if _, err := regexp.Compile(fr.RegularExpression); err != nil {
+				return nil, fmt.Errorf("invalid regular expression in rule %s: %w", r.Name, err)
+			}
+		case aigv1a1.GuardrailRuleTypePII:
+			for _, pii := range r.PII {
+				fr.PII = append(fr.PII, filterapi.GuardrailPIIType(pii))
+			}
+		default:
+			return nil, fmt.Errorf("unknown guardrail rule type: %s", r.Type)
+		}
+		for _, stage := range r.Stages {
+			fr.Stages = append(fr.Stages, filterapi.GuardrailStage(stage))
+		}
+		ret.Rules = append(ret.Rules, fr)
+	}
+	if m := g.Moderation; m != nil {
+		ret.Moderation = &filterapi.GuardrailModeration{
+			URL:      m.URL,
+			Action:   filterapi.GuardrailAction(m.Action),
+			Timeout:  5 * time.Second,
+			FailOpen: m.FailOpen,
+		}
+		if m.Timeout != nil {
+			timeout, err := time.ParseDuration(string(*m.Timeout))
+			if err != nil {
+				return nil, fmt.Errorf("invalid moderation timeout: %w", err)
+			}
+			ret.Moderation.Timeout = timeout
+		}
+		for _, stage := range m.Stages {
+			ret.Moderation.Stages = append(ret.Moderation.Stages, filterapi.GuardrailStage(stage))
+		}
+	}
+	return ret, nil
+}
+
+// spendBudgetsConfig converts the spend budgets of AIGatewayRoute to the filter configuration.
+func spendBudgetsConfig(sb *aigv1a1.SpendBudgets) (*filterapi.SpendBudgetsConfig, error) {
+	ret := &filterapi.SpendBudgetsConfig{
+		ClientIDHeader:   ptr.Deref(sb.ClientIdentifier.Header, ""),
+		ClientIDJWTClaim: ptr.Deref(sb.ClientIdentifier.JWTClaim, ""),
+		FailOpen:         ptr.Deref(sb.FailOpen, true),
+	}
+	var err error
+	for i := range sb.Pricing {
+		p := &sb.Pricing[i]
+		fp := filterapi.ModelPricing{Model: p.Model, Backend: ptr.Deref(p.Backend, "")}
+		if fp.InputPerMillionTokens, err = parseNanoUSD(p.InputPerMillionTokens); err != nil {
+			return nil, fmt.Errorf("invalid input price of model %s: %w", p.Model, err)
+		}
+		if fp.OutputPerMillionTokens, err = parseNanoUSD(p.OutputPerMillionTokens); err != nil {
+			return nil, fmt.Errorf("invalid output price of model %s: %w", p.Model, err)
+		}
+		ret.Pricing = append(ret.Pricing, fp)
+	}
+	for i := range sb.Clients {
+		c := &sb.Clients[i]
+		fc := filterapi.ClientSpendBudget{Client: c.Client}
+		if fc.MonthlyLimit, err = parseNanoUSD(c.MonthlyLimit); err != nil {
+			return nil, fmt.Errorf("invalid monthly limit of client %s: %w", c.Client, err)
+		}
+		ret.Clients = append(ret.Clients, fc)
+	}
+	if l := sb.DefaultMonthlyLimit; l != nil {
+		if ret.DefaultMonthlyLimit, err = parseNanoUSD(*l); err != nil {
+			return nil, fmt.Errorf("invalid default monthly limit: %w", err)
+		}
+	}
+	switch store := &sb.Store; store.Type {
+	case aigv1a1.SpendBudgetStoreTypeLocal:
+		ret.Store = filterapi.SpendBudgetStoreConfig{
+			Type: filterapi.SpendBudgetStoreTypeLocal,
+			Path: path.Join(spendBudgetLocalStoreMountPath, "spend.db"),
+		}
+	case aigv1a1.SpendBudgetStoreTypeRedis:
+		if store.Redis == nil {
+			return nil, fmt.Errorf("redis must be set for the Redis store")
+		}
+		ret.Store = filterapi.SpendBudgetStoreConfig{
+			Type:           filterapi.SpendBudgetStoreTypeRedis,
+			RedisAddress:   store.Redis.Address,
+			RedisTLS:       store.Redis.TLS,
+			RedisKeyPrefix: ptr.Deref(store.Redis.KeyPrefix, "ai-gateway:spend:"),
+		}
+		if store.Redis.PasswordSecretRef != nil {
+			ret.Store.RedisPasswordFile = path.Join(spendBudgetRedisPasswordMountPath, redisPasswordKey)
+		}
+	default:
+		return nil, fmt.Errorf("unknown spend budget store type: %s", store.Type)
+	}
+	return ret, nil
+}
+
+// usageRecordsConfig converts the usage records of AIGatewayRoute to the filter configuration.
+func usageRecordsConfig(ur *aigv1a1.UsageRecords) (*filterapi.UsageRecordsConfig, error) {
+	ret := &filterapi.UsageRecordsConfig{Headers: ur.Headers}
+	for i := range ur.Sinks {
+		s := &ur.Sinks[i]
+		fs := filterapi.UsageRecordSink{Type: filterapi.UsageRecordSinkType(s.Type)}
+		var batch *aigv1a1.UsageRecordBatch
+		switch s.Type {
+		case aigv1a1.UsageRecordSinkTypeFile:
+			fs.Path = path.Join(usageRecordFileSinkMountPath, strconv.Itoa(i), fmt.Sprintf("usage-%d.jsonl", i))
+			fs.MaxSizeMegabytes, fs.MaxBackups = 100, 10
+			if f := s.File; f != nil {
+				fs.MaxSizeMegabytes = int(ptr.Deref(f.MaxSizeMegabytes, 100))
+				fs.MaxBackups = int(ptr.Deref(f.MaxBackups, 10))
+			}
+		case aigv1a1.UsageRecordSinkTypeWebhook:
+			if s.Webhook == nil {
+				return nil, fmt.Errorf("webhook must be set for the Webhook sink %d", i)
+			}
+			fs.URL, batch = s.Webhook.URL, s.Webhook.Batch
+		case aigv1a1.UsageRecordSinkTypeOTLP:
+			if s.OTLP == nil {
+				return nil, fmt.Errorf("otlp must be set for the OTLP sink %d", i)
+			}
+			fs.OTLPEndpoint, fs.OTLPInsecure, batch = s.OTLP.Endpoint, s.OTLP.Insecure, s.OTLP.Batch
+		default:
+			return nil, fmt.Errorf("unknown usage record sink type: %s", s.Type)
+		}
+		var err error
+		if fs.BatchSize, fs.FlushInterval, fs.MaxRetries, err = exportBatchConfig(batch); err != nil {
+			return nil, fmt.Errorf("invalid flush interval of sink %d: %w", i, err)
+		}
+		ret.Sinks = append(ret.Sinks, fs)
+	}
+	return ret, nil
+}
+
+// circuitBreakerPolicy converts the circuit breaker of a rule to the filter configuration with the defaults applied.
+func circuitBreakerPolicy(cb *aigv1a1.AIGatewayRouteRuleCircuitBreaker) (*filterapi.CircuitBreakerPolicy, error) {
+	ret := &filterapi.CircuitBreakerPolicy{
+		ConsecutiveFailures: int(ptr.Deref(cb.ConsecutiveFailures, 5)),
+		ErrorRatePercentage: int(ptr.Deref(cb.ErrorRatePercentage, 0)),
+		MinimumRequests:     int(ptr.Deref(cb.MinimumRequests, 10)),
+		Interval:            10 * time.Second,
+		OpenDuration:        30 * time.Second,
+		HalfOpenRequests:    int(ptr.Deref(cb.HalfOpenRequests, 1)),
+	}
+	for _, d := range []struct {
+		name  string
+		value *gwapiv1.Duration
+		dst   *time.Duration
+	}{
+		{"interval", cb.Interval, &ret.Interval},
+		{"slow response threshold", cb.SlowResponseThreshold, &ret.SlowResponseThreshold},
+		{"open duration", cb.OpenDuration, &ret.OpenDuration},
+	} {
+		if d.value == nil {
+			continue
+		}
+		var err error
+		if *d.dst, err = time.ParseDuration(string(*d.value)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+	}
+	return ret, nil
+}
+
+// exportBatchConfig converts the batching of the exported usage records or audit log entries, which may be nil,
+// to the filter configuration with the defaults applied.
+func exportBatchConfig(batch *aigv1a1.UsageRecordBatch) (batchSize int, flushInterval time.Duration, maxRetries int, err error) {
+	batchSize, flushInterval, maxRetries = 100, 5*time.Second, 3
+	if batch == nil {
+		return
+	}
+	batchSize = int(ptr.Deref(batch.MaxSize, 100))
+	maxRetries = int(ptr.Deref(batch.MaxRetries, 3))
+	if batch.FlushInterval != nil {
+		flushInterval, err = time.ParseDuration(string(*batch.FlushInterval))
+	}
+	return
+}
+
+// auditLogConfig converts the audit log of AIGatewayRoute to the filter configuration.
+func auditLogConfig(al *aigv1a1.AuditLog) (*filterapi.AuditLogConfig, error) {
+	ret := &filterapi.AuditLogConfig{
+		SamplingPercentage: int(ptr.Deref(al.SamplingPercentage, 100)),
+		Headers:            al.Headers,
+	}
+	if r := al.Redaction; r != nil {
+		ret.Redaction = &filterapi.AuditLogRedaction{Patterns: r.Patterns, Fields: r.Fields, Headers: r.Headers}
+	}
+	var err error
+	if ret.Sinks, err = auditLogSinksConfig(al.Sinks, auditLogFileSinkMountPath, "audit"); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// auditLogSinksConfig converts the sinks of the audit log or the mirror results to the filter configuration.
+// The file of the i-th File sink is "<prefix>-<i>.jsonl" in the subdirectory i of the mountPath.
+func auditLogSinksConfig(sinks []aigv1a1.AuditLogSink, mountPath, prefix string) ([]filterapi.AuditLogSink, error) {
+	var ret []filterapi.AuditLogSink
+	for i := range sinks {
+		s := &sinks[i]
+		fs := filterapi.AuditLogSink{Type: filterapi.AuditLogSinkType(s.Type)}
+		var batch *aigv1a1.UsageRecordBatch
+		switch s.Type {
+		case aigv1a1.AuditLogSinkTypeFile:
+			fs.Path = path.Join(mountPath, strconv.Itoa(i), fmt.Sprintf("%s-%d.jsonl", prefix, i))
+			fs.MaxSizeMegabytes, fs.MaxBackups = 100, 10
+			if f := s.File; f != nil {
+				fs.MaxSizeMegabytes = int(ptr.Deref(f.MaxSizeMegabytes, 100))
+				fs.MaxBackups = int(ptr.Deref(f.MaxBackups, 10))
+			}
+		case aigv1a1.AuditLogSinkTypeHTTP:
+			if s.HTTP == nil {
+				return nil, fmt.Errorf("http must be set for the HTTP sink %d", i)
+			}
+			fs.URL, batch = s.HTTP.URL, s.HTTP.Batch
+		default:
+			return nil, fmt.Errorf("unknown audit log sink type: %s", s.Type)
+		}
+		var err error
+		if fs.BatchSize, fs.FlushInterval, fs.MaxRetries, err = exportBatchConfig(batch); err != nil {
+			return nil, fmt.Errorf("invalid flush interval of sink %d: %w", i, err)
+		}
+		ret = append(ret, fs)
+	}
+	return ret, nil
+}
+
+// mirrorPolicy converts the traffic mirroring of the ruleIndex-th rule to the filter configuration.
+func (c *AIGatewayRouteController) mirrorPolicy(ctx context.Context, namespace string, ruleIndex int,
+	mirror *aigv1a1.AIGatewayRouteRuleMirror,
+) (*filterapi.MirrorPolicy, error) {
+	backend, err := c.directBackend(ctx, namespace, mirror.Name, mirrorBackendSecurityPolicyVolumePrefix(ruleIndex, false))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mirror.ModelNameMappings {
+		backend.ModelNameMappings = append(backend.ModelNameMappings, filterapi.ModelNameMapping{From: m.From, To: m.To})
+	}
+	ret := &filterapi.MirrorPolicy{Backend: *backend, Percentage: int(ptr.Deref(mirror.Percentage, 100))}
+	if cmp := mirror.Comparison; cmp != nil {
+		ret.Comparison = &filterapi.MirrorComparison{Type: filterapi.MirrorComparisonType(cmp.Type)}
+		if cmp.Type == aigv1a1.MirrorComparisonTypeEmbeddingSimilarity {
+			es := cmp.EmbeddingSimilarity
+			if es == nil {
+				return nil, fmt.Errorf("embeddingSimilarity must be set for the EmbeddingSimilarity comparison")
+			}
+			ret.Comparison.EmbeddingBackend, err = c.directBackend(ctx, namespace, es.BackendName,
+				mirrorBackendSecurityPolicyVolumePrefix(ruleIndex, true))
+			if err != nil {
+				return nil, err
+			}
+			if ret.Comparison.EmbeddingBackend.Schema.Name != filterapi.APISchemaOpenAI {
+				return nil, fmt.Errorf("embedding backend %s must have the OpenAI schema", es.BackendName)
+			}
+			ret.Comparison.EmbeddingModel = es.Model
+		}
+	}
+	return ret, nil
+}
+
+// directBackend converts the AIServiceBackend to the backend the AI Gateway filter sends the requests to directly,
+// whose Endpoint is always set. The volume of its BackendSecurityPolicy, if any, is named with the volumeNamePrefix.
+func (c *AIGatewayRouteController) directBackend(ctx context.Context, namespace, name, volumeNamePrefix string) (*filterapi.Backend, error) {
+	key := fmt.Sprintf("%s.%s", name, namespace)
+	backendObj, err := c.backend(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AIServiceBackend %s: %w", key, err)
+	}
+	ret := &filterapi.Backend{
+		Name: key,
+		Schema: filterapi.VersionedAPISchema{
+			Name: filterapi.APISchemaName(backendObj.Spec.APISchema.Name), Version: backendObj.Spec.APISchema.Version,
+		},
+	}
+	if ret.Endpoint, err = c.backendEndpoint(ctx, backendObj); err != nil {
+		return nil, fmt.Errorf("failed to get endpoint of AIServiceBackend %s: %w", key, err)
+	}
+	if bspRef := backendObj.Spec.BackendSecurityPolicyRef; bspRef != nil {
+		ret.Auth, err = c.bspToFilterAPIAuth(ctx, namespace, string(bspRef.Name), volumeNamePrefix+"-"+string(bspRef.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create backend auth: %w", err)
+		}
+	}
+	return ret, nil
+}
+
+// parseNanoUSD parses the decimal amount in USD, e.g. "12.5", into nano USD.
+func parseNanoUSD(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 9 {
+		return 0, fmt.Errorf("more than 9 decimal places: %s", s)
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || w < 0 || w > math.MaxInt64/1_000_000_000-1 {
+		return 0, fmt.Errorf("invalid amount: %s", s)
+	}
+	var f int64
+	if frac != "" {
+		if f, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil || f < 0 {
+			return 0, fmt.Errorf("invalid amount: %s", s)
+		}
+	}
+	return w*1_000_000_000 + f, nil
+}
+
+// backendEndpoint returns the base URL of the given AIServiceBackend, which is used by the AI Gateway filter
+// to send the request directly to the backend when failing over from another backend.
+//
+// The Backend resource of Envoy Gateway must have a single endpoint since the filter does not load balance across
+// them. The scheme is https when a BackendTLSPolicy targets the Service or the Backend, and http otherwise.
+func (c *AIGatewayRouteController) backendEndpoint(ctx context.Context, backend *aigv1a1.AIServiceBackend) (string, error) {
+	ref := &backend.Spec.BackendRef
+	namespace := backend.Namespace
+	if ref.Namespace != nil {
+		namespace = string(*ref.Namespace)
+	}
+	var host string
+	var port int32
+	var group gwapiv1.Group
+	switch {
+	case ref.Kind == nil || *ref.Kind == "Service":
+		if ref.Port == nil {
+			return "", fmt.Errorf("port is not specified for Service %s", ref.Name)
+		}
+		host, port = fmt.Sprintf("%s.%s.svc.cluster.local", ref.Name, namespace), int32(*ref.Port)
+		group = corev1.GroupName
+	case *ref.Kind == "Backend":
+		var egBackend egv1a1.Backend
+		if err := c.client.Get(ctx, client.ObjectKey{Name: string(ref.Name), Namespace: namespace}, &egBackend); err != nil {
+			return "", fmt.Errorf("failed to get Backend %s: %w", ref.Name, err)
+		}
+		switch len(egBackend.Spec.Endpoints) {
+		case 0:
+			return "", fmt.Errorf("no endpoint is specified for Backend %s", ref.Name)
+		case 1:
+		default:
+			return "", fmt.Errorf("multiple endpoints of Backend %s are not supported", ref.Name)
+		}
+		switch ep := egBackend.Spec.Endpoints[0]; {
+		case ep.FQDN != nil:
+			host, port = ep.FQDN.Hostname, ep.FQDN.Port
+		case ep.IP != nil:
+			host, port = ep.IP.Address, ep.IP.Port
+		default:
+			return "", fmt.Errorf("unsupported endpoint type for Backend %s", ref.Name)
+		}
+		group = egv1a1.GroupName
+	default:
+		return "", fmt.Errorf("unsupported backend kind %s", *ref.Kind)
+	}
+	tls, err := c.backendTLS(ctx, namespace, group, ptr.Deref(ref.Kind, "Service"), ref.Name, host)
+	if err != nil {
+		return "", err
+	}
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(int(port)))), nil
+}
+
+// backendTLS returns true if a BackendTLSPolicy in the namespace targets the object of the given group, kind and
+// name, in which case Envoy connects to it with TLS.
+//
+// The filter verifies the certificate of the backend against the system trust store and the host of the endpoint,
+// so the policy must use the well-known system CA certificates and the same hostname.
+func (c *AIGatewayRouteController) backendTLS(ctx context.Context, namespace string,
+	group gwapiv1.Group, kind gwapiv1.Kind, name gwapiv1.ObjectName, host string,
+) (bool, error) {
+	var policies gwapiv1a3.BackendTLSPolicyList
+	if err := c.client.List(ctx, &policies, client.InNamespace(namespace)); err != nil {
+		return false, fmt.Errorf("failed to list BackendTLSPolicies: %w", err)
+	}
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		for _, target := range policy.Spec.TargetRefs {
+			if target.Group != group || target.Kind != kind || target.Name != name {
+				continue
+			}
+			validation := &policy.Spec.Validation
+			if len(validation.CACertificateRefs) > 0 {
+				return false, fmt.Errorf("caCertificateRefs of BackendTLSPolicy %s is not supported", policy.Name)
+			}
+			if string(validation.Hostname) != host {
+				return false, fmt.Errorf("hostname %s of BackendTLSPolicy %s does not match the endpoint %s",
+					validation.Hostname, policy.Name, host)
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// validateInferencePoolCA returns an error if the Secret of the CA certificates referenced by the TLS of the
+// InferencePool in the namespace does not exist or does not have the CA certificates.
+func (c *AIGatewayRouteController) validateInferencePoolCA(ctx context.Context, namespace string, lb *aigv1a1.InferencePoolLoadBalancing) error {
+	if lb == nil || lb.TLS == nil || lb.TLS.CACertificateRef == nil {
+		return nil
+	}
+	key := client.ObjectKey{Namespace: namespace, Name: string(lb.TLS.CACertificateRef.Name)}
+	var secret corev1.Secret
+	if err := c.client.Get(ctx, key, &secret); err != nil {
+		return fmt.Errorf("failed to get CA certificate Secret %s: %w", key, err)
+	}
+	if _, ok := secret.Data[caCertificateKey]; !ok {
+		return fmt.Errorf("CA certificate Secret %s does not have the %s key", key, caCertificateKey)
+	}
+	return nil
+}
+
 func (c *AIGatewayRouteController) backendSecurityPolicy(ctx context.Context, namespace, name string) (*aigv1a1.BackendSecurityPolicy, error) {
 	backendSecurityPolicy := &aigv1a1.BackendSecurityPolicy{}
 	if err := c.client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, backendSecurityPolicy); err != nil {
@@ -771,6 +1583,15 @@ func backendSecurityPolicyForInferencePoolVolumeName(ruleIndex, backendRefIndex,
 	return fmt.Sprintf("rule%d-backref%d-inpool%d-%s", ruleIndex, backendRefIndex, inPoolIndex, name)
 }
 
+// mirrorBackendSecurityPolicyVolumePrefix returns the prefix of the name of the volume of the BackendSecurityPolicy
+// of the shadow backend of the ruleIndex-th rule, or of its embedding backend if embedding is true.
+func mirrorBackendSecurityPolicyVolumePrefix(ruleIndex int, embedding bool) string {
+	if embedding {
+		return fmt.Sprintf("rule%d-mirror-embedding", ruleIndex)
+	}
+	return fmt.Sprintf("rule%d-mirror", ruleIndex)
+}
+
 func backendSecurityPolicyVolumeName(ruleIndex, backendRefIndex int, name string) string {
 	// Note: do not use "." as it's not allowed in the volume name.
 	return fmt.Sprintf("rule%d-backref%d-%s", ruleIndex, backendRefIndex, name)
@@ -911,19 +1732,82 @@ func (c *AIGatewayRouteController) createDynamicLoadBalancing(ctx context.Contex
 		return nil, fmt.Errorf("failed to list InferenceModels: %w", err)
 	}
 	for _, model := range models.Items {
-		ret.Models = append(ret.Models, filterapi.DynamicLoadBalancingModel{Name: model.Spec.ModelName})
+		m := filterapi.DynamicLoadBalancingModel{
+			Name:        model.Spec.ModelName,
+			Criticality: filterapi.DynamicLoadBalancingCriticalityStandard,
+		}
+		if model.Spec.Criticality != nil {
+			m.Criticality = filterapi.DynamicLoadBalancingCriticality(*model.Spec.Criticality)
+		}
+		// The weights are either set on all the target models or none of them, in which case they are equal.
 		for _, targetModel := range model.Spec.TargetModels {
-			var weight *int
-			if targetModel.Weight != nil {
-				_weight := int(*targetModel.Weight)
-				weight = &_weight
-			}
-			ret.Models = append(ret.Models, filterapi.DynamicLoadBalancingModel{Name: targetModel.Name, Weight: weight})
+			m.TargetModels = append(m.TargetModels, filterapi.DynamicLoadBalancingTargetModel{
+				Name: targetModel.Name, Weight: int(ptr.Deref(targetModel.Weight, 1)),
+			})
 		}
+		ret.Models = append(ret.Models, m)
 	}
 	return ret, nil
 }
 
+// setDynamicLoadBalancingPolicy sets the load balancing policy, the session affinity and the TLS of the InferencePool
+// referenced in the namespace to the dynamic load balancing configuration. The default is the least request policy
+// without the session affinity in plaintext.
+func setDynamicLoadBalancingPolicy(dyn *filterapi.DynamicLoadBalancing, namespace string, lb *aigv1a1.InferencePoolLoadBalancing) error {
+	dyn.Policy = filterapi.DynamicLoadBalancingPolicyLeastRequest
+	if lb == nil {
+		return nil
+	}
+	if lb.TLS != nil {
+		dyn.ClusterName = extensionserver.OriginalDstClusterNameOf(namespace, lb.TLS)
+		dyn.TLS = true
+		if ref := lb.TLS.CACertificateRef; ref != nil {
+			dyn.CACertificatesFile = path.Join(inferencePoolCAMountPath, string(ref.Name), caCertificateKey)
+		}
+	}
+	if lb.Policy != "" {
+		dyn.Policy = filterapi.DynamicLoadBalancingPolicy(lb.Policy)
+	}
+	if a := lb.SessionAffinity; a != nil {
+		dyn.SessionAffinity = &filterapi.DynamicLoadBalancingSessionAffinity{
+			HeaderName:        strings.ToLower(ptr.Deref(a.HeaderName, "")),
+			MaxLoadPercentage: int(ptr.Deref(a.MaxLoadPercentage, 125)),
+		}
+	}
+	if dyn.Policy != filterapi.DynamicLoadBalancingPolicyEndpointMetrics {
+		return nil
+	}
+	dyn.EndpointMetrics = &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/metrics", Interval: time.Second}
+	if m := lb.EndpointMetrics; m != nil {
+		if m.Path != nil {
+			dyn.EndpointMetrics.Path = *m.Path
+		}
+		if m.Interval != nil {
+			interval, err := time.ParseDuration(string(*m.Interval))
+			if err != nil {
+				return fmt.Errorf("invalid endpoint metrics interval: %w", err)
+			}
+			dyn.EndpointMetrics.Interval = interval
+		}
+	}
+	return nil
+}
+
+// validateSheddableModels returns an error if any of the models of the dynamic load balancing is sheddable while
+// its policy is not the endpoint metrics, since the saturation of the endpoints is only known from their metrics.
+func validateSheddableModels(dyn *filterapi.DynamicLoadBalancing) error {
+	if dyn.Policy == filterapi.DynamicLoadBalancingPolicyEndpointMetrics {
+		return nil
+	}
+	for i := range dyn.Models {
+		if dyn.Models[i].Criticality == filterapi.DynamicLoadBalancingCriticalitySheddable {
+			return fmt.Errorf("sheddable InferenceModel %s requires the %s policy", dyn.Models[i].Name,
+				aigv1a1.InferencePoolLoadBalancingPolicyEndpointMetrics)
+		}
+	}
+	return nil
+}
+
 // isInferencePoolRef returns true if AIGatewayRouteRuleBackendRef references an InferencePool reference.
 func isInferencePoolRef(ref *aigv1a1.AIGatewayRouteRuleBackendRef) bool {
 	return ref.Kind != nil && *ref.Kind == aigv1a1.AIGatewayRouteRuleBackendRefInferencePool
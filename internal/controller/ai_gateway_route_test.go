@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
@@ -30,6 +31,7 @@ import (
 	gwaiev1a2 "sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1a3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/filterapi"
@@ -343,6 +345,15 @@ func Test_newHTTPRoute(t *testing.T) {
 								Kind: ptr.To(aigv1a1.AIGatewayRouteRuleBackendRefInferencePool),
 							}},
 						},
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{
+								Name: "secure-pool",
+								Kind: ptr.To(aigv1a1.AIGatewayRouteRuleBackendRefInferencePool),
+								LoadBalancing: &aigv1a1.InferencePoolLoadBalancing{
+									TLS: &aigv1a1.InferencePoolTLS{CACertificateRef: &gwapiv1.SecretObjectReference{Name: "my-ca"}},
+								},
+							}},
+						},
 					},
 				},
 			}
@@ -422,11 +433,18 @@ func Test_newHTTPRoute(t *testing.T) {
 					},
 					Timeouts: inferencePoolDefaultTimeout,
 				},
+				{
+					// The InferencePool with TLS is routed to the ORIGINAL_DST cluster of its CA certificate Secret.
+					Matches: []gwapiv1.HTTPRouteMatch{
+						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: selectedBackendHeaderKey, Value: "original_destination_cluster_tls_" + ns + "_my-ca"}}},
+					},
+					Timeouts: inferencePoolDefaultTimeout,
+				},
 			}
-			require.Len(t, httpRoute.Spec.Rules, 6) // 5 backends + 1 for the default rule.
+			require.Len(t, httpRoute.Spec.Rules, 7) // 6 backends + 1 for the default rule.
 			for i, r := range httpRoute.Spec.Rules {
 				t.Run(fmt.Sprintf("rule-%d", i), func(t *testing.T) {
-					if i == 5 {
+					if i == 6 {
 						require.Empty(t, r.BackendRefs)
 						require.NotNil(t, r.Matches[0].Path)
 						require.Equal(t, "/", *r.Matches[0].Path.Value)
@@ -498,6 +516,17 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 				},
 			},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-backend-security-policy-5", Namespace: "ns"},
+			Spec: aigv1a1.BackendSecurityPolicySpec{
+				Type: aigv1a1.BackendSecurityPolicyTypeGCPCredentials,
+				GCPCredentials: &aigv1a1.BackendSecurityPolicyGCPCredentials{
+					ProjectName:          "some-project",
+					Region:               "us-central1",
+					ServiceAccountKeyRef: &gwapiv1.SecretObjectReference{Name: "some-gcp-secret"},
+				},
+			},
+		},
 	} {
 		err := fakeClient.Create(t.Context(), bsp, &client.CreateOptions{})
 		require.NoError(t, err)
@@ -552,10 +581,60 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 				BackendSecurityPolicyRef: &gwapiv1.LocalObjectReference{Name: "some-backend-security-policy-4"},
 			},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gemini", Namespace: "ns"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema:                aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaGCPVertexAI},
+				BackendRef:               gwapiv1.BackendObjectReference{Name: "some-backend7", Namespace: ptr.To[gwapiv1.Namespace]("ns")},
+				BackendSecurityPolicyRef: &gwapiv1.LocalObjectReference{Name: "some-backend-security-policy-5"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "fish", Namespace: "ns"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{Name: "some-service", Port: ptr.To[gwapiv1.PortNumber](8080)},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "bird", Namespace: "ns"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{
+					Name: "some-eg-backend", Kind: ptr.To[gwapiv1.Kind]("Backend"), Group: ptr.To[gwapiv1.Group]("gateway.envoyproxy.io"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "whale", Namespace: "ns"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema:           aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaAWSBedrock},
+				BackendRef:          gwapiv1.BackendObjectReference{Name: "some-backend8"},
+				AWSBedrockGuardrail: &aigv1a1.AWSBedrockGuardrail{Identifier: "some-guardrail", Version: "DRAFT", Trace: true},
+			},
+		},
 	} {
 		err := fakeClient.Create(t.Context(), b, &client.CreateOptions{})
 		require.NoError(t, err)
 	}
+	require.NoError(t, fakeClient.Create(t.Context(), &egv1a1.Backend{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-eg-backend", Namespace: "ns"},
+		Spec: egv1a1.BackendSpec{
+			Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}},
+		},
+	}))
+	require.NoError(t, fakeClient.Create(t.Context(), &gwapiv1a3.BackendTLSPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-eg-backend-tls", Namespace: "ns"},
+		Spec: gwapiv1a3.BackendTLSPolicySpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+					Group: "gateway.envoyproxy.io", Kind: "Backend", Name: "some-eg-backend",
+				},
+			}},
+			Validation: gwapiv1a3.BackendTLSPolicyValidation{
+				Hostname:                "api.openai.com",
+				WellKnownCACertificates: ptr.To(gwapiv1a3.WellKnownCACertificatesSystem),
+			},
+		},
+	}))
 	require.NotNil(t, s)
 
 	for _, tc := range []struct {
@@ -609,6 +688,14 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 								{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-4"}}},
 							},
 						},
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+								{Name: "gemini", Weight: 1},
+							},
+							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+								{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-5"}}},
+							},
+						},
 					},
 					LLMRequestCosts: []aigv1a1.LLMRequestCost{
 						{
@@ -623,6 +710,10 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 							Type:        aigv1a1.LLMRequestCostTypeTotalToken,
 							MetadataKey: "total-token",
 						},
+						{
+							Type:        aigv1a1.LLMRequestCostTypeEstimatedInputToken,
+							MetadataKey: "estimated-input-token",
+						},
 						{
 							Type:        aigv1a1.LLMRequestCostTypeCEL,
 							MetadataKey: "cel-token",
@@ -646,7 +737,7 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 								},
 							}}, {Name: "pineapple.ns", Weight: 2},
 						},
-						Headers: []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}},
+						Matches: []filterapi.RouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}}},
 					},
 					{
 						Backends: []filterapi.Backend{{Name: "cat.ns", Weight: 1, Auth: &filterapi.BackendAuth{
@@ -654,7 +745,7 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 								Filename: "/etc/backend_security_policy/rule1-backref0-some-backend-security-policy-1/apiKey",
 							},
 						}}},
-						Headers: []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}},
+						Matches: []filterapi.RouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}}}},
 					},
 					{
 						Backends: []filterapi.Backend{{Name: "pen.ns", Weight: 2, Auth: &filterapi.BackendAuth{
@@ -663,7 +754,7 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 								Region:             "us-east-1",
 							},
 						}}},
-						Headers: []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-2"}},
+						Matches: []filterapi.RouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-2"}}}},
 					},
 					{
 						Backends: []filterapi.Backend{{Name: "dog.ns", Weight: 1, Auth: &filterapi.BackendAuth{
@@ -672,7 +763,7 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 								Region:             "us-east-1",
 							},
 						}}},
-						Headers: []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-3"}},
+						Matches: []filterapi.RouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-3"}}}},
 					},
 					{
 						Backends: []filterapi.Backend{{
@@ -685,17 +776,405 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 							},
 							Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAzureOpenAI, Version: "version1"},
 						}},
-						Headers: []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-4"}},
+						Matches: []filterapi.RouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-4"}}}},
+					},
+					{
+						Backends: []filterapi.Backend{{
+							Name:   "gemini.ns",
+							Weight: 1,
+							Auth: &filterapi.BackendAuth{
+								GCPAuth: &filterapi.GCPAuth{
+									Filename:    "/etc/backend_security_policy/rule5-backref0-some-backend-security-policy-5/gcpAccessToken",
+									ProjectName: "some-project",
+									Region:      "us-central1",
+								},
+							},
+							Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaGCPVertexAI},
+						}},
+						Matches: []filterapi.RouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-5"}}}},
 					},
 				},
 				LLMRequestCosts: []filterapi.LLMRequestCost{
 					{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output-token"},
 					{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input-token"},
 					{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total-token"},
+					{Type: filterapi.LLMRequestCostTypeEstimatedInputToken, MetadataKey: "estimated-input-token"},
 					{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel-token", CEL: "model == 'cool_model' ?  input_tokens * output_tokens : total_tokens"},
 				},
 			},
 		},
+		{
+			name: "fallback",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-fallback", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+								{Name: "fish", Weight: 1},
+								{
+									Name: "bird", Weight: 1, Priority: ptr.To[uint32](1),
+									ModelNameMappings: []aigv1a1.ModelNameMapping{{From: "some-ai", To: "bird-ai"}},
+								},
+							},
+							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+								{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
+							},
+							Fallback: &aigv1a1.AIGatewayRouteRuleFallback{
+								RetriableStatusCodes: []gwapiv1.HTTPRouteRetryStatusCode{429, 503},
+								MaxAttempts:          ptr.To[int32](2),
+								Timeout:              ptr.To[gwapiv1.Duration]("30s"),
+							},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{
+							{Name: "fish.ns", Weight: 1, Endpoint: "http://some-service.ns.svc.cluster.local:8080"},
+							{
+								Name: "bird.ns", Weight: 1, Priority: 1, Endpoint: "https://api.openai.com:443",
+								ModelNameMappings: []filterapi.ModelNameMapping{{From: "some-ai", To: "bird-ai"}},
+							},
+						},
+						Matches:  []filterapi.RouteRuleMatch{{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}}},
+						Fallback: &filterapi.FallbackPolicy{RetriableStatusCodes: []int{429, 503}, MaxAttempts: 2, Timeout: 30 * time.Second},
+					},
+				},
+			},
+		},
+		{
+			name: "hedging",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-hedging", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+								{Name: "fish", Weight: 1},
+								{Name: "bird", Weight: 1, Priority: ptr.To[uint32](1)},
+							},
+							Hedging: &aigv1a1.AIGatewayRouteRuleHedging{Delay: "500ms", Timeout: ptr.To[gwapiv1.Duration]("30s")},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{
+							{Name: "fish.ns", Weight: 1, Endpoint: "http://some-service.ns.svc.cluster.local:8080"},
+							{Name: "bird.ns", Weight: 1, Priority: 1, Endpoint: "https://api.openai.com:443"},
+						},
+						Hedging: &filterapi.HedgingPolicy{Delay: 500 * time.Millisecond, Timeout: 30 * time.Second},
+					},
+				},
+			},
+		},
+		{
+			name: "circuit breaker",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-circuit-breaker", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "fish", Weight: 1}},
+							CircuitBreaker: &aigv1a1.AIGatewayRouteRuleCircuitBreaker{
+								ErrorRatePercentage:   ptr.To[int32](50),
+								SlowResponseThreshold: ptr.To[gwapiv1.Duration]("20s"),
+								OpenDuration:          ptr.To[gwapiv1.Duration]("1m"),
+							},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "fish.ns", Weight: 1}},
+						CircuitBreaker: &filterapi.CircuitBreakerPolicy{
+							ConsecutiveFailures:   5,
+							ErrorRatePercentage:   50,
+							MinimumRequests:       10,
+							Interval:              10 * time.Second,
+							SlowResponseThreshold: 20 * time.Second,
+							OpenDuration:          time.Minute,
+							HalfOpenRequests:      1,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "experiment",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-experiment", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs:   []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "fish", Weight: 1}, {Name: "bird", Weight: 1}},
+							StickyRouting: &aigv1a1.AIGatewayRouteRuleStickyRouting{HeaderName: "X-User-ID"},
+							Experiment: &aigv1a1.AIGatewayRouteRuleExperiment{
+								Name: "new-model",
+								Arms: []aigv1a1.AIGatewayRouteRuleExperimentArm{
+									{Name: "control", BackendRefs: []string{"fish"}},
+									{Name: "treatment", Weight: ptr.To[int32](3), BackendRefs: []string{"bird"}},
+								},
+							},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends:      []filterapi.Backend{{Name: "fish.ns", Weight: 1}, {Name: "bird.ns", Weight: 1}},
+						StickyRouting: &filterapi.StickyRouting{HeaderName: "x-user-id"},
+						Experiment: &filterapi.Experiment{
+							Name: "new-model",
+							Arms: []filterapi.ExperimentArm{
+								{Name: "control", Weight: 1, Backends: []string{"fish.ns"}},
+								{Name: "treatment", Weight: 3, Backends: []string{"bird.ns"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "mirror",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-mirror", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "fish", Weight: 1}},
+							Mirror: &aigv1a1.AIGatewayRouteRuleMirror{
+								Name:              "bird",
+								ModelNameMappings: []aigv1a1.ModelNameMapping{{From: "some-ai", To: "bird-ai"}},
+								Percentage:        ptr.To[int32](10),
+								Comparison:        &aigv1a1.MirrorComparison{Type: aigv1a1.MirrorComparisonTypeExactMatch},
+							},
+						},
+					},
+					MirrorResults: &aigv1a1.MirrorResults{Sinks: []aigv1a1.AuditLogSink{{Type: aigv1a1.AuditLogSinkTypeFile}}},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "fish.ns", Weight: 1}},
+						Mirror: &filterapi.MirrorPolicy{
+							Backend: filterapi.Backend{
+								Name: "bird.ns", Endpoint: "https://api.openai.com:443",
+								ModelNameMappings: []filterapi.ModelNameMapping{{From: "some-ai", To: "bird-ai"}},
+							},
+							Percentage: 10,
+							Comparison: &filterapi.MirrorComparison{Type: filterapi.MirrorComparisonTypeExactMatch},
+						},
+					},
+				},
+				MirrorResults: &filterapi.MirrorResultsConfig{Sinks: []filterapi.AuditLogSink{{
+					Type: filterapi.AuditLogSinkTypeFile, Path: "/var/lib/ai-gateway/mirror-results/0/mirror-0.jsonl",
+					MaxSizeMegabytes: 100, MaxBackups: 10, BatchSize: 100, FlushInterval: 5 * time.Second, MaxRetries: 3,
+				}}},
+			},
+		},
+		{
+			name: "response cache",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-cache", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "fish", Weight: 1}}},
+					},
+					ResponseCache: &aigv1a1.ResponseCache{TTL: ptr.To[gwapiv1.Duration]("10m")},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "fish.ns", Weight: 1}},
+					},
+				},
+				ResponseCache: &filterapi.ResponseCacheConfig{TTL: 10 * time.Minute, MaxEntries: 1024},
+			},
+		},
+		{
+			name: "metrics",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-metrics", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "fish", Weight: 1}}},
+					},
+					Metrics: &aigv1a1.Metrics{
+						RequestHeaderAttributes: []aigv1a1.MetricsRequestHeaderAttribute{
+							{Header: "X-Tenant-ID", Attribute: "tenant.id"},
+							{Header: "x-api-key-id", Attribute: "api_key.id"},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "fish.ns", Weight: 1}},
+					},
+				},
+				Metrics: &filterapi.MetricsConfig{
+					RequestHeaderAttributes: []filterapi.MetricsRequestHeaderAttribute{
+						{Header: "x-tenant-id", Attribute: "tenant.id"},
+						{Header: "x-api-key-id", Attribute: "api_key.id"},
+					},
+					MaxAttributeValues: 100,
+				},
+			},
+		},
+		{
+			name: "guardrails",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-guardrails", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "whale", Weight: 1}}},
+					},
+					Guardrails: &aigv1a1.Guardrails{
+						Rules: []aigv1a1.GuardrailRule{
+							{
+								Name: "secret", Type: aigv1a1.GuardrailRuleTypeRegularExpression, RegularExpression: ptr.To("secret"),
+								Action: aigv1a1.GuardrailActionBlock, Stages: []aigv1a1.GuardrailStage{aigv1a1.GuardrailStageRequest},
+							},
+							{
+								Name: "pii", Type: aigv1a1.GuardrailRuleTypePII, Action: aigv1a1.GuardrailActionRedact,
+								PII: []aigv1a1.GuardrailPIIType{aigv1a1.GuardrailPIITypeEmail},
+							},
+						},
+						Moderation: &aigv1a1.GuardrailModeration{
+							URL: "http://moderation/v1/moderations", Action: aigv1a1.GuardrailActionAnnotate, FailOpen: true,
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{
+							Name: "whale.ns", Weight: 1, Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+							AWSBedrockGuardrail: &filterapi.AWSBedrockGuardrail{Identifier: "some-guardrail", Version: "DRAFT", Trace: true},
+						}},
+					},
+				},
+				Guardrails: &filterapi.GuardrailsConfig{
+					Rules: []filterapi.GuardrailRule{
+						{
+							Name: "secret", RegularExpression: "secret", Action: filterapi.GuardrailActionBlock,
+							Stages: []filterapi.GuardrailStage{filterapi.GuardrailStageRequest},
+						},
+						{Name: "pii", PII: []filterapi.GuardrailPIIType{filterapi.GuardrailPIITypeEmail}, Action: filterapi.GuardrailActionRedact},
+					},
+					Moderation: &filterapi.GuardrailModeration{
+						URL: "http://moderation/v1/moderations", Action: filterapi.GuardrailActionAnnotate, Timeout: 5 * time.Second, FailOpen: true,
+					},
+				},
+			},
+		},
+		{
+			name: "regex, query and path matches",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-matches", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "fish", Weight: 1}},
+							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+								{
+									Headers: []gwapiv1.HTTPHeaderMatch{
+										{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: aigv1a1.AIModelHeaderKey, Value: "gpt-.*"},
+										{Name: "x-tenant", Value: "foo"},
+									},
+									QueryParams: []gwapiv1.HTTPQueryParamMatch{{Name: "tier", Value: "premium"}},
+									Path:        &gwapiv1.HTTPPathMatch{Type: ptr.To(gwapiv1.PathMatchPathPrefix), Value: ptr.To("/v1")},
+								},
+							},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				UUID:                     string(uuid2.NewUUID()),
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "fish.ns", Weight: 1}},
+						Matches: []filterapi.RouteRuleMatch{
+							{
+								Headers: []gwapiv1.HTTPHeaderMatch{
+									{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: aigv1a1.AIModelHeaderKey, Value: "gpt-.*"},
+									{Name: "x-tenant", Value: "foo"},
+								},
+								QueryParams: []gwapiv1.HTTPQueryParamMatch{{Name: "tier", Value: "premium"}},
+								Path:        &gwapiv1.HTTPPathMatch{Type: ptr.To(gwapiv1.PathMatchPathPrefix), Value: ptr.To("/v1")},
+							},
+						},
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			err := s.reconcileExtProcConfigMap(t.Context(), tc.route, tc.exp.UUID)
@@ -711,6 +1190,26 @@ func TestAIGatewayRouteController_reconcileExtProcConfigMap(t *testing.T) {
 			require.Equal(t, tc.exp, &actual)
 		})
 	}
+
+	t.Run("fallback with InferencePool", func(t *testing.T) {
+		route := &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "myroute-fallback-pool", Namespace: "ns"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{
+						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+							{Name: "fish", Weight: 1},
+							{Name: "some-pool", Kind: ptr.To(aigv1a1.AIGatewayRouteRuleBackendRefInferencePool), Priority: ptr.To[uint32](1)},
+						},
+						Fallback: &aigv1a1.AIGatewayRouteRuleFallback{},
+					},
+				},
+			},
+		}
+		err := s.reconcileExtProcConfigMap(t.Context(), route, "some-uuid")
+		require.ErrorContains(t, err, "fallback of rule 0 is not supported with InferencePool some-pool")
+	})
 }
 
 func TestAIGatewayRouteController_syncExtProcDeployment(t *testing.T) {
@@ -1433,10 +1932,11 @@ func TestAIGatewayRouteController_createDynamicLoadBalancing(t *testing.T) {
 		require.NoError(t, fakeClient.Create(t.Context(), &gwaiev1a2.InferenceModel{
 			ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"},
 			Spec: gwaiev1a2.InferenceModelSpec{
-				ModelName: "model2",
-				PoolRef:   gwaiev1a2.PoolObjectReference{Name: "mypool"},
+				ModelName:   "model2",
+				Criticality: ptr.To(gwaiev1a2.Sheddable),
+				PoolRef:     gwaiev1a2.PoolObjectReference{Name: "mypool"},
 				TargetModels: []gwaiev1a2.TargetModel{
-					{Name: "model3"},
+					{Name: "model3", Weight: ptr.To(int32(3))},
 					{Name: "model4", Weight: ptr.To(int32(1))},
 				},
 			},
@@ -1445,10 +1945,685 @@ func TestAIGatewayRouteController_createDynamicLoadBalancing(t *testing.T) {
 		dyn, err := s.createDynamicLoadBalancing(t.Context(), 0, 0, inferencePool, nil)
 		require.NoError(t, err)
 		require.ElementsMatch(t, []filterapi.DynamicLoadBalancingModel{
-			{Name: "model1"},
-			{Name: "model2"},
-			{Name: "model3"},
-			{Name: "model4", Weight: ptr.To(1)},
+			{Name: "model1", Criticality: filterapi.DynamicLoadBalancingCriticalityStandard},
+			{
+				Name:        "model2",
+				Criticality: filterapi.DynamicLoadBalancingCriticalitySheddable,
+				TargetModels: []filterapi.DynamicLoadBalancingTargetModel{
+					{Name: "model3", Weight: 3},
+					{Name: "model4", Weight: 1},
+				},
+			},
 		}, dyn.Models)
+		// As in the Inference Extension, the target models are not routable by their names.
+		for _, m := range dyn.Models {
+			require.NotContains(t, []string{"model3", "model4"}, m.Name)
+		}
 	})
 }
+
+func TestAIGatewayRouteController_backendEndpoint(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewAIGatewayRouteController(fakeClient, nil, logr.Discard(), uuid2.NewUUID, "defaultExtProcImage", "debug")
+	for _, b := range []*egv1a1.Backend{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ip", Namespace: "ns"},
+			Spec:       egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{IP: &egv1a1.IPEndpoint{Address: "::1", Port: 8080}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "unix", Namespace: "ns"},
+			Spec:       egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{Unix: &egv1a1.UnixSocket{Path: "/tmp/sock"}}}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "ns"}},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "multiple", Namespace: "ns"},
+			Spec: egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{
+				{IP: &egv1a1.IPEndpoint{Address: "1.1.1.1", Port: 8080}},
+				{IP: &egv1a1.IPEndpoint{Address: "2.2.2.2", Port: 8080}},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "ns"},
+			Spec:       egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 8443}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "plaintext", Namespace: "ns"},
+			Spec:       egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "custom-ca", Namespace: "ns"},
+			Spec:       egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-hostname", Namespace: "ns"},
+			Spec:       egv1a1.BackendSpec{Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}}},
+		},
+	} {
+		require.NoError(t, fakeClient.Create(t.Context(), b))
+	}
+	newBackendTLSPolicy := func(namespace, group, kind, name, hostname string, caRefs ...gwapiv1.LocalObjectReference) *gwapiv1a3.BackendTLSPolicy {
+		return &gwapiv1a3.BackendTLSPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-tls", Namespace: namespace},
+			Spec: gwapiv1a3.BackendTLSPolicySpec{
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+					LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+						Group: gwapiv1.Group(group), Kind: gwapiv1.Kind(kind), Name: gwapiv1.ObjectName(name),
+					},
+				}},
+				Validation: gwapiv1a3.BackendTLSPolicyValidation{
+					Hostname:                gwapiv1.PreciseHostname(hostname),
+					CACertificateRefs:       caRefs,
+					WellKnownCACertificates: ptr.To(gwapiv1a3.WellKnownCACertificatesSystem),
+				},
+			},
+		}
+	}
+	for _, p := range []*gwapiv1a3.BackendTLSPolicy{
+		newBackendTLSPolicy("other", "", "Service", "svc", "svc.other.svc.cluster.local"),
+		newBackendTLSPolicy("ns", "gateway.envoyproxy.io", "Backend", "tls", "api.openai.com"),
+		// The policy targeting the Service of the same name does not apply to the Backend.
+		newBackendTLSPolicy("ns", "", "Service", "plaintext", "api.openai.com"),
+		newBackendTLSPolicy("ns", "gateway.envoyproxy.io", "Backend", "custom-ca", "api.openai.com",
+			gwapiv1.LocalObjectReference{Kind: "ConfigMap", Name: "ca"}),
+		newBackendTLSPolicy("ns", "gateway.envoyproxy.io", "Backend", "other-hostname", "openai.example.com"),
+	} {
+		require.NoError(t, fakeClient.Create(t.Context(), p))
+	}
+
+	for _, tc := range []struct {
+		name   string
+		ref    gwapiv1.BackendObjectReference
+		exp    string
+		expErr string
+	}{
+		{
+			name: "service in another namespace",
+			ref:  gwapiv1.BackendObjectReference{Name: "svc", Namespace: ptr.To[gwapiv1.Namespace]("other"), Port: ptr.To[gwapiv1.PortNumber](443)},
+			exp:  "https://svc.other.svc.cluster.local:443",
+		},
+		{
+			name: "service without tls",
+			ref:  gwapiv1.BackendObjectReference{Name: "svc", Port: ptr.To[gwapiv1.PortNumber](443)},
+			exp:  "http://svc.ns.svc.cluster.local:443",
+		},
+		{
+			name: "tls backend",
+			ref:  gwapiv1.BackendObjectReference{Name: "tls", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			exp:  "https://api.openai.com:8443",
+		},
+		{
+			name: "plaintext backend",
+			ref:  gwapiv1.BackendObjectReference{Name: "plaintext", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			exp:  "http://api.openai.com:443",
+		},
+		{
+			name:   "backend tls with ca certificates",
+			ref:    gwapiv1.BackendObjectReference{Name: "custom-ca", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			expErr: "caCertificateRefs of BackendTLSPolicy custom-ca-tls is not supported",
+		},
+		{
+			name:   "backend tls with another hostname",
+			ref:    gwapiv1.BackendObjectReference{Name: "other-hostname", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			expErr: "hostname openai.example.com of BackendTLSPolicy other-hostname-tls does not match the endpoint api.openai.com",
+		},
+		{
+			name:   "multiple endpoints",
+			ref:    gwapiv1.BackendObjectReference{Name: "multiple", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			expErr: "multiple endpoints of Backend multiple are not supported",
+		},
+		{
+			name:   "service without port",
+			ref:    gwapiv1.BackendObjectReference{Name: "svc"},
+			expErr: "port is not specified for Service svc",
+		},
+		{
+			name: "ip backend",
+			ref:  gwapiv1.BackendObjectReference{Name: "ip", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			exp:  "http://[::1]:8080",
+		},
+		{
+			name:   "unix backend",
+			ref:    gwapiv1.BackendObjectReference{Name: "unix", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			expErr: "unsupported endpoint type for Backend unix",
+		},
+		{
+			name:   "empty backend",
+			ref:    gwapiv1.BackendObjectReference{Name: "empty", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			expErr: "no endpoint is specified for Backend empty",
+		},
+		{
+			name:   "missing backend",
+			ref:    gwapiv1.BackendObjectReference{Name: "missing", Kind: ptr.To[gwapiv1.Kind]("Backend")},
+			expErr: "failed to get Backend missing",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint, err := c.backendEndpoint(t.Context(), &aigv1a1.AIServiceBackend{
+				ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "ns"},
+				Spec:       aigv1a1.AIServiceBackendSpec{BackendRef: tc.ref},
+			})
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, endpoint)
+		})
+	}
+}
+
+func Test_guardrailsConfig(t *testing.T) {
+	_, err := guardrailsConfig(&aigv1a1.Guardrails{Rules: []aigv1a1.GuardrailRule{
+		{Name: "foo", Type: aigv1a1.GuardrailRuleTypeRegularExpression, RegularExpression: ptr.To("(")},
+	}})
+	require.ErrorContains(t, err, "invalid regular expression in rule foo")
+
+	_, err = guardrailsConfig(&aigv1a1.Guardrails{Rules: []aigv1a1.GuardrailRule{{Name: "foo", Type: "Unknown"}}})
+	require.ErrorContains(t, err, "unknown guardrail rule type: Unknown")
+
+	_, err = guardrailsConfig(&aigv1a1.Guardrails{Moderation: &aigv1a1.GuardrailModeration{Timeout: ptr.To[gwapiv1.Duration]("foo")}})
+	require.ErrorContains(t, err, "invalid moderation timeout")
+
+	c, err := guardrailsConfig(&aigv1a1.Guardrails{Moderation: &aigv1a1.GuardrailModeration{
+		URL: "http://foo", Action: aigv1a1.GuardrailActionBlock, Timeout: ptr.To[gwapiv1.Duration]("1s"),
+		Stages: []aigv1a1.GuardrailStage{aigv1a1.GuardrailStageResponse},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.GuardrailsConfig{Moderation: &filterapi.GuardrailModeration{
+		URL: "http://foo", Action: filterapi.GuardrailActionBlock, Timeout: time.Second,
+		Stages: []filterapi.GuardrailStage{filterapi.GuardrailStageResponse},
+	}}, c)
+}
+
+func Test_spendBudgetsConfig(t *testing.T) {
+	_, err := spendBudgetsConfig(&aigv1a1.SpendBudgets{Pricing: []aigv1a1.ModelPricing{
+		{Model: "foo", InputPerMillionTokens: "1.0000000001", OutputPerMillionTokens: "1"},
+	}})
+	require.ErrorContains(t, err, "invalid input price of model foo")
+	_, err = spendBudgetsConfig(&aigv1a1.SpendBudgets{Clients: []aigv1a1.ClientSpendBudget{{Client: "alice", MonthlyLimit: "-1"}}})
+	require.ErrorContains(t, err, "invalid monthly limit of client alice")
+	_, err = spendBudgetsConfig(&aigv1a1.SpendBudgets{Store: aigv1a1.SpendBudgetStore{Type: "Unknown"}})
+	require.ErrorContains(t, err, "unknown spend budget store type: Unknown")
+
+	c, err := spendBudgetsConfig(&aigv1a1.SpendBudgets{
+		ClientIdentifier: aigv1a1.SpendBudgetClientIdentifier{JWTClaim: ptr.To("sub")},
+		Pricing: []aigv1a1.ModelPricing{
+			{Model: "gpt-4o", InputPerMillionTokens: "2.5", OutputPerMillionTokens: "10"},
+			{Model: "gpt-4o", Backend: ptr.To("azure"), InputPerMillionTokens: "0.000000001", OutputPerMillionTokens: "0"},
+		},
+		Clients:             []aigv1a1.ClientSpendBudget{{Client: "alice", MonthlyLimit: "12.34"}},
+		DefaultMonthlyLimit: ptr.To("100"),
+		Store:               aigv1a1.SpendBudgetStore{Type: aigv1a1.SpendBudgetStoreTypeLocal},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.SpendBudgetsConfig{
+		ClientIDJWTClaim: "sub",
+		Pricing: []filterapi.ModelPricing{
+			{Model: "gpt-4o", InputPerMillionTokens: 2_500_000_000, OutputPerMillionTokens: 10_000_000_000},
+			{Model: "gpt-4o", Backend: "azure", InputPerMillionTokens: 1},
+		},
+		Clients:             []filterapi.ClientSpendBudget{{Client: "alice", MonthlyLimit: 12_340_000_000}},
+		DefaultMonthlyLimit: 100_000_000_000,
+		Store: filterapi.SpendBudgetStoreConfig{
+			Type: filterapi.SpendBudgetStoreTypeLocal, Path: "/var/lib/ai-gateway/spend-budget/spend.db",
+		},
+		// The budgets fail open by default.
+		FailOpen: true,
+	}, c)
+
+	c, err = spendBudgetsConfig(&aigv1a1.SpendBudgets{
+		ClientIdentifier: aigv1a1.SpendBudgetClientIdentifier{Header: ptr.To("x-user-id")},
+		Store: aigv1a1.SpendBudgetStore{Type: aigv1a1.SpendBudgetStoreTypeRedis, Redis: &aigv1a1.SpendBudgetRedisStore{
+			Address: "redis:6379", TLS: true, PasswordSecretRef: &gwapiv1.SecretObjectReference{Name: "redis"},
+		}},
+		FailOpen: ptr.To(false),
+	})
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.SpendBudgetsConfig{
+		ClientIDHeader: "x-user-id",
+		Store: filterapi.SpendBudgetStoreConfig{
+			Type: filterapi.SpendBudgetStoreTypeRedis, RedisAddress: "redis:6379", RedisTLS: true,
+			RedisPasswordFile: "/etc/spend_budget/redis/password", RedisKeyPrefix: "ai-gateway:spend:",
+		},
+	}, c)
+}
+
+func Test_parseNanoUSD(t *testing.T) {
+	for in, exp := range map[string]int64{
+		"0": 0, "1": 1_000_000_000, "0.5": 500_000_000, "12.345678901": 12_345_678_901, "9223372035": 9_223_372_035_000_000_000,
+	} {
+		v, err := parseNanoUSD(in)
+		require.NoError(t, err, in)
+		require.Equal(t, exp, v, in)
+	}
+	for _, in := range []string{"", "-1", "1.-5", "abc", "1.0000000001", "9223372037"} {
+		_, err := parseNanoUSD(in)
+		require.Error(t, err, in)
+	}
+}
+
+func Test_mountSpendBudgetVolumes(t *testing.T) {
+	newSpec := func() *corev1.PodSpec {
+		return &corev1.PodSpec{Containers: []corev1.Container{{}}}
+	}
+	route := &aigv1a1.AIGatewayRoute{}
+	spec := newSpec()
+	mountSpendBudgetVolumes(spec, route)
+	require.Empty(t, spec.Volumes)
+
+	route.Spec.SpendBudgets = &aigv1a1.SpendBudgets{Store: aigv1a1.SpendBudgetStore{Type: aigv1a1.SpendBudgetStoreTypeLocal}}
+	mountSpendBudgetVolumes(spec, route)
+	require.Equal(t, []corev1.Volume{
+		{Name: "spend-budget-store", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}, spec.Volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "spend-budget-store", MountPath: "/var/lib/ai-gateway/spend-budget"},
+	}, spec.Containers[0].VolumeMounts)
+
+	route.Spec.SpendBudgets.Store.Local = &aigv1a1.SpendBudgetLocalStore{PersistentVolumeClaimName: ptr.To("spend")}
+	spec = newSpec()
+	mountSpendBudgetVolumes(spec, route)
+	require.Equal(t, "spend", spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+
+	route.Spec.SpendBudgets.Store = aigv1a1.SpendBudgetStore{
+		Type: aigv1a1.SpendBudgetStoreTypeRedis, Redis: &aigv1a1.SpendBudgetRedisStore{Address: "redis:6379"},
+	}
+	spec = newSpec()
+	mountSpendBudgetVolumes(spec, route)
+	require.Empty(t, spec.Volumes)
+
+	route.Spec.SpendBudgets.Store.Redis.PasswordSecretRef = &gwapiv1.SecretObjectReference{Name: "redis"}
+	mountSpendBudgetVolumes(spec, route)
+	require.Equal(t, []corev1.Volume{
+		{Name: "spend-budget-redis-password", VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: "redis"},
+		}},
+	}, spec.Volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "spend-budget-redis-password", MountPath: "/etc/spend_budget/redis", ReadOnly: true},
+	}, spec.Containers[0].VolumeMounts)
+}
+
+func Test_usageRecordsConfig(t *testing.T) {
+	cfg, err := usageRecordsConfig(&aigv1a1.UsageRecords{
+		Headers: []string{"x-team"},
+		Sinks: []aigv1a1.UsageRecordSink{
+			{Type: aigv1a1.UsageRecordSinkTypeFile},
+			{Type: aigv1a1.UsageRecordSinkTypeFile, File: &aigv1a1.UsageRecordFileSink{
+				MaxSizeMegabytes: ptr.To[int32](5), MaxBackups: ptr.To[int32](0),
+			}},
+			{Type: aigv1a1.UsageRecordSinkTypeWebhook, Webhook: &aigv1a1.UsageRecordWebhookSink{URL: "http://chargeback/records"}},
+			{Type: aigv1a1.UsageRecordSinkTypeOTLP, OTLP: &aigv1a1.UsageRecordOTLPSink{
+				Endpoint: "otel-collector:4317", Insecure: true, Batch: &aigv1a1.UsageRecordBatch{
+					MaxSize: ptr.To[int32](10), FlushInterval: ptr.To[gwapiv1.Duration]("1s"), MaxRetries: ptr.To[int32](0),
+				},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.UsageRecordsConfig{
+		Headers: []string{"x-team"},
+		Sinks: []filterapi.UsageRecordSink{
+			{
+				Type: filterapi.UsageRecordSinkTypeFile, Path: "/var/lib/ai-gateway/usage-records/0/usage-0.jsonl",
+				MaxSizeMegabytes: 100, MaxBackups: 10, BatchSize: 100, FlushInterval: 5 * time.Second, MaxRetries: 3,
+			},
+			{
+				Type: filterapi.UsageRecordSinkTypeFile, Path: "/var/lib/ai-gateway/usage-records/1/usage-1.jsonl",
+				MaxSizeMegabytes: 5, MaxBackups: 0, BatchSize: 100, FlushInterval: 5 * time.Second, MaxRetries: 3,
+			},
+			{
+				Type: filterapi.UsageRecordSinkTypeWebhook, URL: "http://chargeback/records",
+				BatchSize: 100, FlushInterval: 5 * time.Second, MaxRetries: 3,
+			},
+			{
+				Type: filterapi.UsageRecordSinkTypeOTLP, OTLPEndpoint: "otel-collector:4317", OTLPInsecure: true,
+				BatchSize: 10, FlushInterval: time.Second, MaxRetries: 0,
+			},
+		},
+	}, cfg)
+
+	for _, tc := range []struct {
+		name   string
+		sink   aigv1a1.UsageRecordSink
+		expErr string
+	}{
+		{name: "no webhook", sink: aigv1a1.UsageRecordSink{Type: aigv1a1.UsageRecordSinkTypeWebhook}, expErr: "webhook must be set for the Webhook sink 0"},
+		{name: "no otlp", sink: aigv1a1.UsageRecordSink{Type: aigv1a1.UsageRecordSinkTypeOTLP}, expErr: "otlp must be set for the OTLP sink 0"},
+		{name: "unknown", sink: aigv1a1.UsageRecordSink{Type: "Kafka"}, expErr: "unknown usage record sink type: Kafka"},
+		{
+			name: "invalid flush interval",
+			sink: aigv1a1.UsageRecordSink{Type: aigv1a1.UsageRecordSinkTypeWebhook, Webhook: &aigv1a1.UsageRecordWebhookSink{
+				URL: "http://chargeback", Batch: &aigv1a1.UsageRecordBatch{FlushInterval: ptr.To[gwapiv1.Duration]("soon")},
+			}},
+			expErr: "invalid flush interval of sink 0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := usageRecordsConfig(&aigv1a1.UsageRecords{Sinks: []aigv1a1.UsageRecordSink{tc.sink}})
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func Test_mountUsageRecordVolumes(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{}}}
+	route := &aigv1a1.AIGatewayRoute{}
+	mountUsageRecordVolumes(spec, route)
+	require.Empty(t, spec.Volumes)
+
+	route.Spec.UsageRecords = &aigv1a1.UsageRecords{Sinks: []aigv1a1.UsageRecordSink{
+		{Type: aigv1a1.UsageRecordSinkTypeFile},
+		{Type: aigv1a1.UsageRecordSinkTypeWebhook, Webhook: &aigv1a1.UsageRecordWebhookSink{URL: "http://chargeback"}},
+		{Type: aigv1a1.UsageRecordSinkTypeFile, File: &aigv1a1.UsageRecordFileSink{PersistentVolumeClaimName: ptr.To("usage")}},
+	}}
+	mountUsageRecordVolumes(spec, route)
+	require.Equal(t, []corev1.Volume{
+		{Name: "usage-records-0", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: "usage-records-2", VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "usage"},
+		}},
+	}, spec.Volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "usage-records-0", MountPath: "/var/lib/ai-gateway/usage-records/0"},
+		{Name: "usage-records-2", MountPath: "/var/lib/ai-gateway/usage-records/2"},
+	}, spec.Containers[0].VolumeMounts)
+}
+
+func Test_auditLogConfig(t *testing.T) {
+	cfg, err := auditLogConfig(&aigv1a1.AuditLog{
+		Headers:   []string{"x-user-id"},
+		Redaction: &aigv1a1.AuditLogRedaction{Fields: []string{"request.user"}, Headers: []string{"x-user-id"}},
+		Sinks: []aigv1a1.AuditLogSink{
+			{Type: aigv1a1.AuditLogSinkTypeFile, File: &aigv1a1.AuditLogFileSink{MaxBackups: ptr.To[int32](1)}},
+			{Type: aigv1a1.AuditLogSinkTypeHTTP, HTTP: &aigv1a1.AuditLogHTTPSink{
+				URL: "http://compliance/audit", Batch: &aigv1a1.UsageRecordBatch{FlushInterval: ptr.To[gwapiv1.Duration]("1s")},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.AuditLogConfig{
+		SamplingPercentage: 100,
+		Headers:            []string{"x-user-id"},
+		Redaction:          &filterapi.AuditLogRedaction{Fields: []string{"request.user"}, Headers: []string{"x-user-id"}},
+		Sinks: []filterapi.AuditLogSink{
+			{
+				Type: filterapi.AuditLogSinkTypeFile, Path: "/var/lib/ai-gateway/audit-log/0/audit-0.jsonl",
+				MaxSizeMegabytes: 100, MaxBackups: 1, BatchSize: 100, FlushInterval: 5 * time.Second, MaxRetries: 3,
+			},
+			{
+				Type: filterapi.AuditLogSinkTypeHTTP, URL: "http://compliance/audit",
+				BatchSize: 100, FlushInterval: time.Second, MaxRetries: 3,
+			},
+		},
+	}, cfg)
+
+	cfg, err = auditLogConfig(&aigv1a1.AuditLog{SamplingPercentage: ptr.To[int32](5), Sinks: []aigv1a1.AuditLogSink{
+		{Type: aigv1a1.AuditLogSinkTypeFile},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 5, cfg.SamplingPercentage)
+	require.Nil(t, cfg.Redaction)
+
+	for _, tc := range []struct {
+		name   string
+		sink   aigv1a1.AuditLogSink
+		expErr string
+	}{
+		{name: "no http", sink: aigv1a1.AuditLogSink{Type: aigv1a1.AuditLogSinkTypeHTTP}, expErr: "http must be set for the HTTP sink 0"},
+		{name: "unknown", sink: aigv1a1.AuditLogSink{Type: "Kafka"}, expErr: "unknown audit log sink type: Kafka"},
+		{
+			name: "invalid flush interval",
+			sink: aigv1a1.AuditLogSink{Type: aigv1a1.AuditLogSinkTypeHTTP, HTTP: &aigv1a1.AuditLogHTTPSink{
+				URL: "http://compliance", Batch: &aigv1a1.UsageRecordBatch{FlushInterval: ptr.To[gwapiv1.Duration]("soon")},
+			}},
+			expErr: "invalid flush interval of sink 0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := auditLogConfig(&aigv1a1.AuditLog{Sinks: []aigv1a1.AuditLogSink{tc.sink}})
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func Test_mountAuditLogVolumes(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{}}}
+	route := &aigv1a1.AIGatewayRoute{}
+	mountAuditLogVolumes(spec, route)
+	require.Empty(t, spec.Volumes)
+
+	route.Spec.AuditLog = &aigv1a1.AuditLog{Sinks: []aigv1a1.AuditLogSink{
+		{Type: aigv1a1.AuditLogSinkTypeHTTP, HTTP: &aigv1a1.AuditLogHTTPSink{URL: "http://compliance"}},
+		{Type: aigv1a1.AuditLogSinkTypeFile, File: &aigv1a1.AuditLogFileSink{PersistentVolumeClaimName: ptr.To("audit")}},
+	}}
+	mountAuditLogVolumes(spec, route)
+	require.Equal(t, []corev1.Volume{
+		{Name: "audit-log-1", VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "audit"},
+		}},
+	}, spec.Volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "audit-log-1", MountPath: "/var/lib/ai-gateway/audit-log/1"},
+	}, spec.Containers[0].VolumeMounts)
+}
+
+func Test_mountMirrorResultVolumes(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{}}}
+	route := &aigv1a1.AIGatewayRoute{}
+	mountMirrorResultVolumes(spec, route)
+	require.Empty(t, spec.Volumes)
+
+	route.Spec.MirrorResults = &aigv1a1.MirrorResults{Sinks: []aigv1a1.AuditLogSink{
+		{Type: aigv1a1.AuditLogSinkTypeFile},
+	}}
+	mountMirrorResultVolumes(spec, route)
+	require.Equal(t, []corev1.Volume{
+		{Name: "mirror-results-0", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}, spec.Volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "mirror-results-0", MountPath: "/var/lib/ai-gateway/mirror-results/0"},
+	}, spec.Containers[0].VolumeMounts)
+}
+
+func Test_mountInferencePoolCAVolumes(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{}}}
+	route := &aigv1a1.AIGatewayRoute{Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{
+		{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "backend"}}},
+	}}}
+	mountInferencePoolCAVolumes(spec, route)
+	require.Empty(t, spec.Volumes)
+
+	ca := func(name string) *aigv1a1.InferencePoolLoadBalancing {
+		return &aigv1a1.InferencePoolLoadBalancing{TLS: &aigv1a1.InferencePoolTLS{
+			CACertificateRef: &gwapiv1.SecretObjectReference{Name: gwapiv1.ObjectName(name)},
+		}}
+	}
+	route.Spec.Rules = append(route.Spec.Rules,
+		aigv1a1.AIGatewayRouteRule{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+			{Name: "pool1", LoadBalancing: ca("ca1")},
+			{Name: "pool2", LoadBalancing: &aigv1a1.InferencePoolLoadBalancing{TLS: &aigv1a1.InferencePoolTLS{}}},
+		}},
+		aigv1a1.AIGatewayRouteRule{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+			{Name: "pool3", LoadBalancing: ca("ca1")},
+			{Name: "pool4", LoadBalancing: ca("ca2")},
+		}},
+	)
+	mountInferencePoolCAVolumes(spec, route)
+	require.Equal(t, []corev1.Volume{
+		{Name: "inference-pool-ca-0", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "ca1"}}},
+		{Name: "inference-pool-ca-1", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "ca2"}}},
+	}, spec.Volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "inference-pool-ca-0", MountPath: "/etc/inference_pool/ca/ca1", ReadOnly: true},
+		{Name: "inference-pool-ca-1", MountPath: "/etc/inference_pool/ca/ca2", ReadOnly: true},
+	}, spec.Containers[0].VolumeMounts)
+}
+
+func Test_setDynamicLoadBalancingPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		lb     *aigv1a1.InferencePoolLoadBalancing
+		exp    *filterapi.DynamicLoadBalancing
+		expErr string
+	}{
+		{
+			name: "default",
+			exp:  &filterapi.DynamicLoadBalancing{Policy: filterapi.DynamicLoadBalancingPolicyLeastRequest},
+		},
+		{
+			name: "random",
+			lb:   &aigv1a1.InferencePoolLoadBalancing{Policy: aigv1a1.InferencePoolLoadBalancingPolicyRandom},
+			exp:  &filterapi.DynamicLoadBalancing{Policy: filterapi.DynamicLoadBalancingPolicyRandom},
+		},
+		{
+			name: "session affinity with defaults",
+			lb:   &aigv1a1.InferencePoolLoadBalancing{SessionAffinity: &aigv1a1.InferencePoolSessionAffinity{}},
+			exp: &filterapi.DynamicLoadBalancing{
+				Policy:          filterapi.DynamicLoadBalancingPolicyLeastRequest,
+				SessionAffinity: &filterapi.DynamicLoadBalancingSessionAffinity{MaxLoadPercentage: 125},
+			},
+		},
+		{
+			name: "session affinity",
+			lb: &aigv1a1.InferencePoolLoadBalancing{
+				Policy: aigv1a1.InferencePoolLoadBalancingPolicyRandom,
+				SessionAffinity: &aigv1a1.InferencePoolSessionAffinity{
+					HeaderName: ptr.To("X-Session-ID"), MaxLoadPercentage: ptr.To[int32](150),
+				},
+			},
+			exp: &filterapi.DynamicLoadBalancing{
+				Policy:          filterapi.DynamicLoadBalancingPolicyRandom,
+				SessionAffinity: &filterapi.DynamicLoadBalancingSessionAffinity{HeaderName: "x-session-id", MaxLoadPercentage: 150},
+			},
+		},
+		{
+			name: "endpoint metrics with defaults",
+			lb:   &aigv1a1.InferencePoolLoadBalancing{Policy: aigv1a1.InferencePoolLoadBalancingPolicyEndpointMetrics},
+			exp: &filterapi.DynamicLoadBalancing{
+				Policy:          filterapi.DynamicLoadBalancingPolicyEndpointMetrics,
+				EndpointMetrics: &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/metrics", Interval: time.Second},
+			},
+		},
+		{
+			name: "endpoint metrics",
+			lb: &aigv1a1.InferencePoolLoadBalancing{
+				Policy: aigv1a1.InferencePoolLoadBalancingPolicyEndpointMetrics,
+				EndpointMetrics: &aigv1a1.InferencePoolEndpointMetrics{
+					Path: ptr.To("/stats"), Interval: ptr.To(gwapiv1.Duration("500ms")),
+				},
+			},
+			exp: &filterapi.DynamicLoadBalancing{
+				Policy:          filterapi.DynamicLoadBalancingPolicyEndpointMetrics,
+				EndpointMetrics: &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/stats", Interval: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "tls with system CA",
+			lb:   &aigv1a1.InferencePoolLoadBalancing{TLS: &aigv1a1.InferencePoolTLS{}},
+			exp: &filterapi.DynamicLoadBalancing{
+				Policy:      filterapi.DynamicLoadBalancingPolicyLeastRequest,
+				ClusterName: "original_destination_cluster_tls",
+				TLS:         true,
+			},
+		},
+		{
+			name: "tls with CA secret",
+			lb: &aigv1a1.InferencePoolLoadBalancing{TLS: &aigv1a1.InferencePoolTLS{
+				CACertificateRef: &gwapiv1.SecretObjectReference{Name: "my-ca"},
+			}},
+			exp: &filterapi.DynamicLoadBalancing{
+				Policy:             filterapi.DynamicLoadBalancingPolicyLeastRequest,
+				ClusterName:        "original_destination_cluster_tls_ns_my-ca",
+				TLS:                true,
+				CACertificatesFile: "/etc/inference_pool/ca/my-ca/ca.crt",
+			},
+		},
+		{
+			name: "invalid interval",
+			lb: &aigv1a1.InferencePoolLoadBalancing{
+				Policy:          aigv1a1.InferencePoolLoadBalancingPolicyEndpointMetrics,
+				EndpointMetrics: &aigv1a1.InferencePoolEndpointMetrics{Interval: ptr.To(gwapiv1.Duration("foo"))},
+			},
+			expErr: "invalid endpoint metrics interval",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dyn := &filterapi.DynamicLoadBalancing{}
+			err := setDynamicLoadBalancingPolicy(dyn, "ns", tc.lb)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, dyn)
+		})
+	}
+}
+
+func TestAIGatewayRouteController_validateInferencePoolCA(t *testing.T) {
+	c := requireNewFakeClientWithIndexes(t)
+	s := NewAIGatewayRouteController(c, nil, logr.Discard(), uuid2.NewUUID, "defaultExtProcImage", "debug")
+	require.NoError(t, c.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "ns"},
+		Data:       map[string][]byte{"ca.crt": []byte("some-ca")},
+	}))
+	require.NoError(t, c.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "no-ca", Namespace: "ns"},
+		Data:       map[string][]byte{"tls.crt": []byte("some-cert")},
+	}))
+	lbWithCA := func(name string) *aigv1a1.InferencePoolLoadBalancing {
+		return &aigv1a1.InferencePoolLoadBalancing{TLS: &aigv1a1.InferencePoolTLS{
+			CACertificateRef: &gwapiv1.SecretObjectReference{Name: gwapiv1.ObjectName(name)},
+		}}
+	}
+
+	for _, lb := range []*aigv1a1.InferencePoolLoadBalancing{
+		nil, {}, {TLS: &aigv1a1.InferencePoolTLS{}}, lbWithCA("ca"),
+	} {
+		require.NoError(t, s.validateInferencePoolCA(t.Context(), "ns", lb))
+	}
+	require.ErrorContains(t, s.validateInferencePoolCA(t.Context(), "ns", lbWithCA("missing")),
+		"failed to get CA certificate Secret ns/missing")
+	require.EqualError(t, s.validateInferencePoolCA(t.Context(), "ns", lbWithCA("no-ca")),
+		"CA certificate Secret ns/no-ca does not have the ca.crt key")
+}
+
+func Test_validateSheddableModels(t *testing.T) {
+	dyn := &filterapi.DynamicLoadBalancing{
+		Policy: filterapi.DynamicLoadBalancingPolicyEndpointMetrics,
+		Models: []filterapi.DynamicLoadBalancingModel{
+			{Name: "foo", Criticality: filterapi.DynamicLoadBalancingCriticalityCritical},
+			{Name: "bar", Criticality: filterapi.DynamicLoadBalancingCriticalitySheddable},
+		},
+	}
+	require.NoError(t, validateSheddableModels(dyn))
+
+	for _, policy := range []filterapi.DynamicLoadBalancingPolicy{
+		filterapi.DynamicLoadBalancingPolicyLeastRequest, filterapi.DynamicLoadBalancingPolicyRandom,
+	} {
+		dyn.Policy = policy
+		require.EqualError(t, validateSheddableModels(dyn), "sheddable InferenceModel bar requires the EndpointMetrics policy")
+	}
+
+	dyn.Models = dyn.Models[:1]
+	require.NoError(t, validateSheddableModels(dyn))
+}
+
+func Test_validateRouteRuleMatch(t *testing.T) {
+	require.NoError(t, validateRouteRuleMatch(&aigv1a1.AIGatewayRouteRuleMatch{
+		Headers: []gwapiv1.HTTPHeaderMatch{
+			{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-foo", Value: "gpt-.*"},
+			{Name: "x-bar", Value: "("},
+		},
+	}))
+	require.ErrorContains(t, validateRouteRuleMatch(&aigv1a1.AIGatewayRouteRuleMatch{
+		Headers: []gwapiv1.HTTPHeaderMatch{{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-foo", Value: "("}},
+	}), "invalid regular expression for header x-foo")
+	require.ErrorContains(t, validateRouteRuleMatch(&aigv1a1.AIGatewayRouteRuleMatch{
+		QueryParams: []gwapiv1.HTTPQueryParamMatch{{Type: ptr.To(gwapiv1.QueryParamMatchRegularExpression), Name: "tier", Value: "["}},
+	}), "invalid regular expression for query parameter tier")
+	require.ErrorContains(t, validateRouteRuleMatch(&aigv1a1.AIGatewayRouteRuleMatch{
+		Path: &gwapiv1.HTTPPathMatch{Type: ptr.To(gwapiv1.PathMatchRegularExpression), Value: ptr.To("*")},
+	}), "invalid regular expression for path")
+}
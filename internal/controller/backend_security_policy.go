@@ -17,8 +17,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
@@ -29,6 +31,9 @@ const (
 	// clientSecretKey is key used to store Azure and OIDC client secret in Kubernetes secrets.
 	clientSecretKey = "client-secret"
 
+	// gcpServiceAccountKey is the key used to store the GCP service account key in Kubernetes secrets.
+	gcpServiceAccountKey = "service_account.json"
+
 	// azureScopeURL specifies Microsoft Azure OAuth 2.0 scope to authenticate and authorize when accessing Azure OpenAI.
 	azureScopeURL = "https://cognitiveservices.azure.com/.default"
 
@@ -141,6 +146,16 @@ func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context,
 		if err != nil {
 			return ctrl.Result{}, err
 		}
+	case aigv1a1.BackendSecurityPolicyTypeGCPCredentials:
+		var provider tokenprovider.TokenProvider
+		provider, err = c.gcpTokenProvider(ctx, bsp)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		rotator, err = rotators.NewGCPTokenRotator(c.client, c.kube, c.logger, bsp.Namespace, bsp.Name, preRotationWindow, provider)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 	default:
 		err = fmt.Errorf("backend security type %s does not support OIDC token exchange", bsp.Spec.Type)
 		c.logger.Error(err, "namespace", bsp.Namespace, "name", bsp.Name)
@@ -149,6 +164,47 @@ func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context,
 	return c.executeRotation(ctx, rotator, bsp)
 }
 
+// gcpTokenProvider returns the token provider for the GCP access token based on the GCPCredentials of the policy.
+func (c *BackendSecurityPolicyController) gcpTokenProvider(ctx context.Context, bsp *aigv1a1.BackendSecurityPolicy) (tokenprovider.TokenProvider, error) {
+	gcpCred := bsp.Spec.GCPCredentials
+	if gcpCred == nil {
+		return nil, fmt.Errorf("gcp credentials are nil, namespace %s name %s", bsp.Namespace, bsp.Name)
+	}
+	switch {
+	case gcpCred.ServiceAccountKeyRef != nil:
+		secretNamespace := bsp.Namespace
+		if gcpCred.ServiceAccountKeyRef.Namespace != nil {
+			secretNamespace = string(*gcpCred.ServiceAccountKeyRef.Namespace)
+		}
+		secretName := string(gcpCred.ServiceAccountKeyRef.Name)
+		secret, err := rotators.LookupSecret(ctx, c.client, secretNamespace, secretName)
+		if err != nil {
+			c.logger.Error(err, "failed to lookup gcp service account key secret", "namespace", secretNamespace, "name", secretName)
+			return nil, err
+		}
+		key, exists := secret.Data[gcpServiceAccountKey]
+		if !exists {
+			return nil, fmt.Errorf("missing gcp service account key %s", gcpServiceAccountKey)
+		}
+		return tokenprovider.NewGCPServiceAccountTokenProvider(key)
+	case gcpCred.WorkloadIdentityFederation != nil:
+		wif := gcpCred.WorkloadIdentityFederation
+		oidc := wif.OIDC.DeepCopy()
+		if oidc.ClientSecret.Namespace == nil {
+			oidc.ClientSecret.Namespace = ptr.To(gwapiv1.Namespace(bsp.Namespace))
+		}
+		oidcProvider, err := tokenprovider.NewOidcTokenProvider(ctx, c.client, oidc)
+		if err != nil {
+			return nil, err
+		}
+		return tokenprovider.NewGCPWorkloadIdentityTokenProvider(oidcProvider, wif.ProjectNumber,
+			wif.WorkloadIdentityPoolName, wif.WorkloadIdentityProviderName, wif.ServiceAccountEmail), nil
+	default:
+		return nil, fmt.Errorf("gcp credentials must specify either service account key or workload identity federation, namespace %s name %s",
+			bsp.Namespace, bsp.Name)
+	}
+}
+
 func (c *BackendSecurityPolicyController) executeRotation(ctx context.Context, rotator rotators.Rotator, bsp *aigv1a1.BackendSecurityPolicy) (res ctrl.Result, err error) {
 	requeue := time.Minute
 	var rotationTime time.Time
@@ -191,6 +247,10 @@ func getBackendSecurityPolicyAuthOIDC(spec aigv1a1.BackendSecurityPolicySpec) *e
 		}
 	case aigv1a1.BackendSecurityPolicyTypeAzureCredentials:
 		return nil
+	case aigv1a1.BackendSecurityPolicyTypeGCPCredentials:
+		if spec.GCPCredentials != nil && spec.GCPCredentials.WorkloadIdentityFederation != nil {
+			return &spec.GCPCredentials.WorkloadIdentityFederation.OIDC
+		}
 	}
 	return nil
 }
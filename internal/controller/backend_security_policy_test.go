@@ -7,7 +7,11 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -360,6 +364,26 @@ func TestBackendSecurityPolicyController_GetBackendSecurityPolicyAuthOIDC(t *tes
 	})
 	require.NotNil(t, oidc)
 	require.Equal(t, "some-client-id", oidc.ClientID)
+
+	// GCP type with the service account key does not use OIDC.
+	require.Nil(t, getBackendSecurityPolicyAuthOIDC(aigv1a1.BackendSecurityPolicySpec{
+		Type: aigv1a1.BackendSecurityPolicyTypeGCPCredentials,
+		GCPCredentials: &aigv1a1.BackendSecurityPolicyGCPCredentials{
+			ServiceAccountKeyRef: &gwapiv1.SecretObjectReference{Name: "some-secret"},
+		},
+	}))
+
+	// GCP type with the workload identity federation.
+	oidc = getBackendSecurityPolicyAuthOIDC(aigv1a1.BackendSecurityPolicySpec{
+		Type: aigv1a1.BackendSecurityPolicyTypeGCPCredentials,
+		GCPCredentials: &aigv1a1.BackendSecurityPolicyGCPCredentials{
+			WorkloadIdentityFederation: &aigv1a1.GCPWorkloadIdentityFederation{
+				OIDC: egv1a1.OIDC{ClientID: "some-gcp-client-id"},
+			},
+		},
+	})
+	require.NotNil(t, oidc)
+	require.Equal(t, "some-gcp-client-id", oidc.ClientID)
 }
 
 func TestNewBackendSecurityPolicyController_ReconcileAzureMissingSecret(t *testing.T) {
@@ -605,3 +629,76 @@ func TestBackendSecurityPolicyController_ExecutionRotation(t *testing.T) {
 	require.NoError(t, err)
 	require.Less(t, res.RequeueAfter, time.Hour)
 }
+
+func TestNewBackendSecurityPolicyController_ReconcileGCPMissingSecretData(t *testing.T) {
+	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]()
+	cl := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, syncFn.Sync)
+	bspName := "my-gcp-backend-security-policy"
+
+	require.NoError(t, cl.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-gcp-secret", Namespace: "default"},
+	}))
+	bsp := &aigv1a1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: bspName, Namespace: "default"},
+		Spec: aigv1a1.BackendSecurityPolicySpec{
+			Type: aigv1a1.BackendSecurityPolicyTypeGCPCredentials,
+			GCPCredentials: &aigv1a1.BackendSecurityPolicyGCPCredentials{
+				ProjectName:          "some-project",
+				Region:               "us-central1",
+				ServiceAccountKeyRef: &gwapiv1.SecretObjectReference{Name: "some-gcp-secret"},
+			},
+		},
+	}
+	require.NoError(t, cl.Create(t.Context(), bsp))
+	res, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: bspName}})
+	require.Error(t, err)
+	require.Equal(t, "missing gcp service account key service_account.json", err.Error())
+	require.Equal(t, time.Duration(0), res.RequeueAfter)
+}
+
+func TestNewBackendSecurityPolicyController_RotateCredentialGCPServiceAccountKey(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"some-gcp-access-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "sa@some-project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})),
+		"token_uri":    tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]()
+	cl := fake.NewClientBuilder().WithScheme(Scheme).Build()
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, syncFn.Sync)
+	require.NoError(t, cl.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-gcp-secret", Namespace: "default"},
+		Data:       map[string][]byte{gcpServiceAccountKey: key},
+	}))
+	bsp := &aigv1a1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-gcp-policy", Namespace: "default"},
+		Spec: aigv1a1.BackendSecurityPolicySpec{
+			Type: aigv1a1.BackendSecurityPolicyTypeGCPCredentials,
+			GCPCredentials: &aigv1a1.BackendSecurityPolicyGCPCredentials{
+				ProjectName:          "some-project",
+				Region:               "us-central1",
+				ServiceAccountKeyRef: &gwapiv1.SecretObjectReference{Name: "some-gcp-secret"},
+			},
+		},
+	}
+	require.NoError(t, cl.Create(t.Context(), bsp))
+
+	res, err := c.rotateCredential(t.Context(), bsp)
+	require.NoError(t, err)
+	require.Greater(t, res.RequeueAfter, 50*time.Minute)
+
+	secret, err := rotators.LookupSecret(t.Context(), cl, "default", rotators.GetBSPSecretName("some-gcp-policy"))
+	require.NoError(t, err)
+	require.Equal(t, "some-gcp-access-token", string(secret.Data[gcpAccessTokenKey]))
+}
@@ -26,6 +26,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	gwaiev1a2 "sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
@@ -37,6 +38,7 @@ func init() {
 	utilruntime.Must(apiextensionsv1.AddToScheme(Scheme))
 	utilruntime.Must(egv1a1.AddToScheme(Scheme))
 	utilruntime.Must(gwapiv1.Install(Scheme))
+	utilruntime.Must(gwapiv1a3.Install(Scheme))
 	utilruntime.Must(gwapiv1b1.Install(Scheme))
 	utilruntime.Must(gwaiev1a2.AddToScheme(Scheme))
 }
@@ -227,6 +229,12 @@ func aiGatewayRouteIndexFunc(o client.Object) []string {
 			key := fmt.Sprintf("%s.%s", backend.Name, aiGatewayRoute.Namespace)
 			ret = append(ret, key)
 		}
+		if mirror := rule.Mirror; mirror != nil {
+			ret = append(ret, fmt.Sprintf("%s.%s", mirror.Name, aiGatewayRoute.Namespace))
+			if cmp := mirror.Comparison; cmp != nil && cmp.EmbeddingSimilarity != nil {
+				ret = append(ret, fmt.Sprintf("%s.%s", cmp.EmbeddingSimilarity.BackendName, aiGatewayRoute.Namespace))
+			}
+		}
 	}
 	return ret
 }
@@ -48,6 +48,13 @@ func Test_aiGatewayRouteIndexFunc(t *testing.T) {
 						{Name: "inference-pool1", Weight: 1, Kind: ptr.To(aigv1a1.AIGatewayRouteRuleBackendRefInferencePool)},
 						{Name: "inference-pool2", Weight: 1, Kind: ptr.To(aigv1a1.AIGatewayRouteRuleBackendRefInferencePool)},
 					},
+					Mirror: &aigv1a1.AIGatewayRouteRuleMirror{
+						Name: "shadow",
+						Comparison: &aigv1a1.MirrorComparison{
+							Type:                aigv1a1.MirrorComparisonTypeEmbeddingSimilarity,
+							EmbeddingSimilarity: &aigv1a1.MirrorEmbeddingSimilarity{BackendName: "embedding", Model: "some-model"},
+						},
+					},
 				},
 			},
 		},
@@ -67,6 +74,14 @@ func Test_aiGatewayRouteIndexFunc(t *testing.T) {
 	require.Len(t, aiGatewayRoutes.Items, 1)
 	require.Equal(t, aiGatewayRoute.Name, aiGatewayRoutes.Items[0].Name)
 
+	// The shadow backend and the embedding backend of the mirror are referenced as well.
+	for _, key := range []string{"shadow.default", "embedding.default"} {
+		err = c.List(t.Context(), &aiGatewayRoutes,
+			client.MatchingFields{k8sClientIndexBackendToReferencingAIGatewayRoute: key})
+		require.NoError(t, err)
+		require.Len(t, aiGatewayRoutes.Items, 1, key)
+	}
+
 	err = c.List(t.Context(), &aiGatewayRoutes,
 		client.MatchingFields{k8sClientIndexInferencePoolToReferencingAIGatewayRoute: "inference-pool1.default"})
 	require.NoError(t, err)
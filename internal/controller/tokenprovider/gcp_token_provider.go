@@ -0,0 +1,176 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// gcpCloudPlatformScope is the OAuth 2.0 scope to access Vertex AI.
+	gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	// gcpDefaultTokenURL is the token endpoint used when the service account key does not specify the token_uri.
+	gcpDefaultTokenURL = "https://oauth2.googleapis.com/token"
+	// gcpSTSTokenURL is the endpoint of the GCP Security Token Service to exchange the OIDC token.
+	gcpSTSTokenURL = "https://sts.googleapis.com/v1/token"
+	// gcpIAMCredentialsURL is the endpoint of the IAM Service Account Credentials API.
+	gcpIAMCredentialsURL = "https://iamcredentials.googleapis.com"
+)
+
+// gcpServiceAccountKey is the subset of the service account key file in JSON format.
+type gcpServiceAccountKey struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// gcpServiceAccountTokenProvider is a provider implements TokenProvider interface for GCP access tokens
+// obtained with a service account key.
+type gcpServiceAccountTokenProvider struct {
+	config *jwt.Config
+}
+
+// NewGCPServiceAccountTokenProvider creates a new TokenProvider with the given service account key in JSON format.
+func NewGCPServiceAccountTokenProvider(serviceAccountKey []byte) (TokenProvider, error) {
+	var key gcpServiceAccountKey
+	if err := json.Unmarshal(serviceAccountKey, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse gcp service account key: %w", err)
+	}
+	if key.Type != "service_account" {
+		return nil, fmt.Errorf("unsupported gcp credentials type %q, expected service_account", key.Type)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = gcpDefaultTokenURL
+	}
+	return &gcpServiceAccountTokenProvider{config: &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{gcpCloudPlatformScope},
+		TokenURL:     key.TokenURI,
+	}}, nil
+}
+
+// GetToken implements TokenProvider.GetToken method to retrieve a GCP access token and its expiration time.
+func (g *gcpServiceAccountTokenProvider) GetToken(ctx context.Context) (TokenExpiry, error) {
+	// Underlying token call will apply http client timeout.
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: time.Minute})
+	token, err := g.config.TokenSource(ctx).Token()
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to get gcp access token: %w", err)
+	}
+	return TokenExpiry{Token: token.AccessToken, ExpiresAt: token.Expiry}, nil
+}
+
+// gcpWorkloadIdentityTokenProvider is a provider implements TokenProvider interface for GCP access tokens
+// obtained by exchanging an OIDC token via the Workload Identity Federation.
+type gcpWorkloadIdentityTokenProvider struct {
+	oidcProvider TokenProvider
+	// audience is the full resource name of the workload identity pool provider.
+	audience string
+	// serviceAccountEmail is the service account to impersonate, and can be empty.
+	serviceAccountEmail string
+	stsURL              string
+	iamCredentialsURL   string
+	httpClient          *http.Client
+}
+
+// NewGCPWorkloadIdentityTokenProvider creates a new TokenProvider which exchanges the token obtained
+// from the given oidcProvider for a GCP access token.
+func NewGCPWorkloadIdentityTokenProvider(oidcProvider TokenProvider, projectNumber, poolName, providerName, serviceAccountEmail string) TokenProvider {
+	return &gcpWorkloadIdentityTokenProvider{
+		oidcProvider: oidcProvider,
+		audience: fmt.Sprintf("//iam.googleapis.com/projects/%s/locations/global/workloadIdentityPools/%s/providers/%s",
+			projectNumber, poolName, providerName),
+		serviceAccountEmail: serviceAccountEmail,
+		stsURL:              gcpSTSTokenURL,
+		iamCredentialsURL:   gcpIAMCredentialsURL,
+		httpClient:          &http.Client{Timeout: time.Minute},
+	}
+}
+
+// GetToken implements TokenProvider.GetToken method to retrieve a GCP access token and its expiration time.
+func (g *gcpWorkloadIdentityTokenProvider) GetToken(ctx context.Context) (TokenExpiry, error) {
+	oidcToken, err := g.oidcProvider.GetToken(ctx)
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to get oidc token: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"audience":             {g.audience},
+		"scope":                {gcpCloudPlatformScope},
+		"requested_token_type": {"urn:ietf:params:oauth:token-type:access_token"},
+		"subject_token":        {oidcToken.Token},
+		"subject_token_type":   {"urn:ietf:params:oauth:token-type:jwt"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.stsURL, bytes.NewBufferString(form.Encode()))
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to create sts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var stsResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = g.do(req, &stsResp); err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to exchange oidc token via sts: %w", err)
+	}
+	federated := TokenExpiry{Token: stsResp.AccessToken, ExpiresAt: time.Now().Add(time.Duration(stsResp.ExpiresIn) * time.Second)}
+	if g.serviceAccountEmail == "" {
+		return federated, nil
+	}
+
+	body, err := json.Marshal(map[string]any{"scope": []string{gcpCloudPlatformScope}})
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to marshal impersonation request: %w", err)
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/v1/projects/-/serviceAccounts/%s:generateAccessToken", g.iamCredentialsURL, g.serviceAccountEmail),
+		bytes.NewReader(body))
+	if err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to create impersonation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+federated.Token)
+	var iamResp struct {
+		AccessToken string    `json:"accessToken"`
+		ExpireTime  time.Time `json:"expireTime"`
+	}
+	if err = g.do(req, &iamResp); err != nil {
+		return TokenExpiry{}, fmt.Errorf("failed to impersonate service account %s: %w", g.serviceAccountEmail, err)
+	}
+	return TokenExpiry{Token: iamResp.AccessToken, ExpiresAt: iamResp.ExpireTime}, nil
+}
+
+// do sends the request and decodes the JSON response into out.
+func (g *gcpWorkloadIdentityTokenProvider) do(req *http.Request, out any) error {
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
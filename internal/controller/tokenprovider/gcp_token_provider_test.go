@@ -0,0 +1,116 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGCPServiceAccountTokenProvider(t *testing.T) {
+	_, err := NewGCPServiceAccountTokenProvider([]byte("not-json"))
+	require.ErrorContains(t, err, "failed to parse gcp service account key")
+
+	_, err = NewGCPServiceAccountTokenProvider([]byte(`{"type":"authorized_user"}`))
+	require.ErrorContains(t, err, `unsupported gcp credentials type "authorized_user"`)
+
+	p, err := NewGCPServiceAccountTokenProvider([]byte(`{"type":"service_account","client_email":"sa@example.com"}`))
+	require.NoError(t, err)
+	require.Equal(t, gcpDefaultTokenURL, p.(*gcpServiceAccountTokenProvider).config.TokenURL)
+	require.Equal(t, "sa@example.com", p.(*gcpServiceAccountTokenProvider).config.Email)
+}
+
+func TestGCPServiceAccountTokenProvider_GetToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+		require.NotEmpty(t, r.Form.Get("assertion"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"some-access-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	key, err := json.Marshal(gcpServiceAccountKey{
+		Type: "service_account", ClientEmail: "sa@example.com", PrivateKeyID: "id", PrivateKey: string(keyPEM), TokenURI: server.URL,
+	})
+	require.NoError(t, err)
+
+	p, err := NewGCPServiceAccountTokenProvider(key)
+	require.NoError(t, err)
+	token, err := p.GetToken(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "some-access-token", token.Token)
+	require.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+}
+
+func TestGCPWorkloadIdentityTokenProvider_GetToken(t *testing.T) {
+	expireTime := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/token":
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			form, err := url.ParseQuery(string(body))
+			require.NoError(t, err)
+			require.Equal(t, "oidc-token", form.Get("subject_token"))
+			require.Equal(t, "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider",
+				form.Get("audience"))
+			_, _ = w.Write([]byte(`{"access_token":"federated-token","expires_in":3600}`))
+		case "/v1/projects/-/serviceAccounts/sa@example.com:generateAccessToken":
+			require.Equal(t, "Bearer federated-token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"accessToken":"impersonated-token","expireTime":"` + expireTime.Format(time.RFC3339) + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	newProvider := func(oidcProvider TokenProvider, sa string) TokenProvider {
+		p := NewGCPWorkloadIdentityTokenProvider(oidcProvider, "123", "pool", "provider", sa).(*gcpWorkloadIdentityTokenProvider)
+		p.stsURL = server.URL + "/v1/token"
+		p.iamCredentialsURL = server.URL
+		return p
+	}
+	oidcProvider := NewMockTokenProvider("oidc-token", time.Now().Add(time.Hour), nil)
+
+	t.Run("federated token", func(t *testing.T) {
+		token, err := newProvider(oidcProvider, "").GetToken(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "federated-token", token.Token)
+		require.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+	})
+	t.Run("impersonated token", func(t *testing.T) {
+		token, err := newProvider(oidcProvider, "sa@example.com").GetToken(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "impersonated-token", token.Token)
+		require.True(t, expireTime.Equal(token.ExpiresAt))
+	})
+	t.Run("oidc error", func(t *testing.T) {
+		p := newProvider(NewMockTokenProvider("", time.Time{}, errors.New("oidc failure")), "")
+		_, err := p.GetToken(context.Background())
+		require.ErrorContains(t, err, "oidc failure")
+	})
+	t.Run("unknown service account", func(t *testing.T) {
+		_, err := newProvider(oidcProvider, "unknown@example.com").GetToken(t.Context())
+		require.ErrorContains(t, err, "failed to impersonate service account unknown@example.com: unexpected status code 404")
+	})
+}
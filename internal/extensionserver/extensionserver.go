@@ -7,27 +7,40 @@ package extensionserver
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	egextension "github.com/envoyproxy/gateway/proto/extension"
 	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	"github.com/go-logr/logr"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 )
 
 // Server is the implementation of the EnvoyGatewayExtensionServer interface.
 type Server struct {
 	egextension.UnimplementedEnvoyGatewayExtensionServer
 	log logr.Logger
+	// client is used to read the AIGatewayRoutes and the Secrets to configure the TLS of the ORIGINAL_DST clusters.
+	client client.Client
 }
 
 // New creates a new instance of the extension server that implements the EnvoyGatewayExtensionServer interface.
-func New(logger logr.Logger) *Server {
+func New(logger logr.Logger, k8sClient client.Client) *Server {
 	logger = logger.WithName("envoy-gateway-extension-server")
-	return &Server{log: logger}
+	return &Server{log: logger, client: k8sClient}
 }
 
 // Check implements [grpc_health_v1.HealthServer].
@@ -43,31 +56,85 @@ func (s *Server) Watch(*grpc_health_v1.HealthCheckRequest, grpc_health_v1.Health
 const (
 	// originalDstHeaderName is the header name that will be used to pass the original destination endpoint in the form of "ip:port".
 	originalDstHeaderName = "x-ai-eg-original-dst"
+	// originalHostHeaderName is the header name that will be used to pass the host of the original destination endpoint.
+	// The Host header is rewritten to its value by the route, as the external processor cannot mutate the Host header.
+	originalHostHeaderName = "x-ai-eg-original-host"
 	// OriginalDstClusterName is the global name of the original destination cluster.
 	OriginalDstClusterName = "original_destination_cluster"
+	// originalDstTLSClusterName is the name of the original destination cluster connecting with TLS, which is
+	// suffixed with the namespace and the name of the Secret of the CA certificates if any.
+	originalDstTLSClusterName = OriginalDstClusterName + "_tls"
+	// caCertificateKey is the key of the CA certificates in the Secret referenced by the InferencePoolTLS.
+	caCertificateKey = "ca.crt"
+	// systemCACertificatesPath is the path of the system trust store in the Envoy image.
+	systemCACertificatesPath = "/etc/ssl/certs/ca-certificates.crt"
 )
 
+// OriginalDstClusterNameOf returns the name of the original destination cluster of the InferencePool with the
+// given TLS configuration in the namespace, which is OriginalDstClusterName if the TLS is not configured.
+func OriginalDstClusterNameOf(namespace string, tls *aigv1a1.InferencePoolTLS) string {
+	switch {
+	case tls == nil:
+		return OriginalDstClusterName
+	case tls.CACertificateRef == nil:
+		return originalDstTLSClusterName
+	default:
+		// The names of the Kubernetes objects cannot contain underscores, so this never collides.
+		return fmt.Sprintf("%s_%s_%s", originalDstTLSClusterName, namespace, tls.CACertificateRef.Name)
+	}
+}
+
+// isOriginalDstClusterName returns true if the name is of one of the original destination clusters.
+func isOriginalDstClusterName(name string) bool {
+	return name == OriginalDstClusterName || strings.HasPrefix(name, originalDstTLSClusterName)
+}
+
 // PostTranslateModify allows an extension to modify the clusters and secrets in the xDS config.
 //
-// Currently, this adds an ORIGINAL_DST cluster to the list of clusters unconditionally.
-func (s *Server) PostTranslateModify(_ context.Context, req *egextension.PostTranslateModifyRequest) (*egextension.PostTranslateModifyResponse, error) {
+// Currently, this adds an ORIGINAL_DST cluster to the list of clusters unconditionally, and another one connecting
+// with TLS for each CA certificate Secret referenced by the InferencePoolTLS of the AIGatewayRoutes.
+func (s *Server) PostTranslateModify(ctx context.Context, req *egextension.PostTranslateModifyRequest) (*egextension.PostTranslateModifyResponse, error) {
+	existing := make(map[string]struct{}, len(req.Clusters))
 	for _, cluster := range req.Clusters {
-		if cluster.Name == OriginalDstClusterName {
+		existing[cluster.Name] = struct{}{}
+	}
+	clusters, err := s.originalDstTLSClusters(ctx)
+	if err != nil {
+		// The other clusters are still added so that only the InferencePools with the broken TLS are affected.
+		// The missing CA certificates are also reported on the status of the AIGatewayRoutes by the controller.
+		s.log.Error(err, "failed to create some of the original_dst clusters with TLS")
+	}
+	clusters = append([]*clusterv3.Cluster{originalDstCluster(OriginalDstClusterName)}, clusters...)
+
+	added := false
+	for _, cluster := range clusters {
+		if _, ok := existing[cluster.Name]; ok {
 			// The cluster already exists, no need to add it again.
-			s.log.Info("original_dst cluster already exists in the list of clusters")
-			return nil, nil
+			continue
 		}
+		req.Clusters = append(req.Clusters, cluster)
+		added = true
+		s.log.Info("Added original_dst cluster to the list of clusters", "cluster", cluster.Name)
 	}
-	// Append the following cluster to the list of clusters:
-	//   name: original_destination_cluster
-	//   connectTimeout: 60s
-	//   lbPolicy: CLUSTER_PROVIDED
-	//   originalDstLbConfig:
-	//     httpHeaderName: x-ai-eg-original-dst
-	//     useHttpHeader: true
-	//   type: ORIGINAL_DST
-	req.Clusters = append(req.Clusters, &clusterv3.Cluster{
-		Name:                 OriginalDstClusterName,
+	if !added {
+		s.log.Info("original_dst clusters already exist in the list of clusters")
+		return nil, nil
+	}
+	return &egextension.PostTranslateModifyResponse{Clusters: req.Clusters, Secrets: req.Secrets}, nil
+}
+
+// originalDstCluster returns the following cluster with the given name:
+//
+//	name: original_destination_cluster
+//	connectTimeout: 60s
+//	lbPolicy: CLUSTER_PROVIDED
+//	originalDstLbConfig:
+//	  httpHeaderName: x-ai-eg-original-dst
+//	  useHttpHeader: true
+//	type: ORIGINAL_DST
+func originalDstCluster(name string) *clusterv3.Cluster {
+	return &clusterv3.Cluster{
+		Name:                 name,
 		ClusterDiscoveryType: &clusterv3.Cluster_Type{Type: clusterv3.Cluster_ORIGINAL_DST},
 		LbPolicy:             clusterv3.Cluster_CLUSTER_PROVIDED,
 		LbConfig: &clusterv3.Cluster_OriginalDstLbConfig_{
@@ -76,15 +143,113 @@ func (s *Server) PostTranslateModify(_ context.Context, req *egextension.PostTra
 			},
 		},
 		ConnectTimeout: &durationpb.Duration{Seconds: 60},
+	}
+}
+
+// originalDstTLSClusters returns the original destination clusters connecting with TLS for the InferencePoolTLS of
+// all the AIGatewayRoutes. The clusters whose CA certificates cannot be read are skipped and reported in the error.
+func (s *Server) originalDstTLSClusters(ctx context.Context) ([]*clusterv3.Cluster, error) {
+	var routes aigv1a1.AIGatewayRouteList
+	if err := s.client.List(ctx, &routes); err != nil {
+		return nil, fmt.Errorf("failed to list AIGatewayRoutes: %w", err)
+	}
+	var (
+		clusters []*clusterv3.Cluster
+		errs     []error
+		seen     = make(map[string]struct{})
+	)
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		for j := range route.Spec.Rules {
+			for k := range route.Spec.Rules[j].BackendRefs {
+				lb := route.Spec.Rules[j].BackendRefs[k].LoadBalancing
+				if lb == nil || lb.TLS == nil {
+					continue
+				}
+				name := OriginalDstClusterNameOf(route.Namespace, lb.TLS)
+				if _, ok := seen[name]; ok {
+					continue
+				}
+				seen[name] = struct{}{}
+				trustedCA, err := s.trustedCA(ctx, route.Namespace, lb.TLS)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				cluster, err := originalDstTLSCluster(name, trustedCA)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				clusters = append(clusters, cluster)
+			}
+		}
+	}
+	return clusters, errors.Join(errs...)
+}
+
+// trustedCA returns the CA certificates to verify the certificates of the endpoints, which are read from the
+// referenced Secret in the namespace, or the system trust store if not referenced.
+func (s *Server) trustedCA(ctx context.Context, namespace string, tls *aigv1a1.InferencePoolTLS) (*corev3.DataSource, error) {
+	if tls.CACertificateRef == nil {
+		return &corev3.DataSource{Specifier: &corev3.DataSource_Filename{Filename: systemCACertificatesPath}}, nil
+	}
+	key := client.ObjectKey{Namespace: namespace, Name: string(tls.CACertificateRef.Name)}
+	var secret corev1.Secret
+	if err := s.client.Get(ctx, key, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get CA certificate Secret %s: %w", key, err)
+	}
+	ca, ok := secret.Data[caCertificateKey]
+	if !ok {
+		return nil, fmt.Errorf("CA certificate Secret %s does not have the %s key", key, caCertificateKey)
+	}
+	return &corev3.DataSource{Specifier: &corev3.DataSource_InlineBytes{InlineBytes: ca}}, nil
+}
+
+// originalDstTLSCluster returns the original destination cluster with the given name connecting with TLS, which
+// verifies the certificates of the endpoints with the given CA certificates.
+//
+// The SNI and the verified SAN are taken from the Host header, which is rewritten to the hostname of the selected
+// endpoint by the route.
+func originalDstTLSCluster(name string, trustedCA *corev3.DataSource) (*clusterv3.Cluster, error) {
+	tlsContext, err := anypb.New(&tlsv3.UpstreamTlsContext{
+		CommonTlsContext: &tlsv3.CommonTlsContext{
+			ValidationContextType: &tlsv3.CommonTlsContext_ValidationContext{
+				ValidationContext: &tlsv3.CertificateValidationContext{TrustedCa: trustedCA},
+			},
+		},
 	})
-	response := &egextension.PostTranslateModifyResponse{Clusters: req.Clusters, Secrets: req.Secrets}
-	s.log.Info("Added original_dst cluster to the list of clusters")
-	return response, nil
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the TLS context of cluster %s: %w", name, err)
+	}
+	protocolOptions, err := anypb.New(&httpv3.HttpProtocolOptions{
+		UpstreamHttpProtocolOptions: &corev3.UpstreamHttpProtocolOptions{AutoSni: true, AutoSanValidation: true},
+		UpstreamProtocolOptions: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+					HttpProtocolOptions: &corev3.Http1ProtocolOptions{},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the protocol options of cluster %s: %w", name, err)
+	}
+	cluster := originalDstCluster(name)
+	cluster.TransportSocket = &corev3.TransportSocket{
+		Name:       "envoy.transport_sockets.tls",
+		ConfigType: &corev3.TransportSocket_TypedConfig{TypedConfig: tlsContext},
+	}
+	cluster.TypedExtensionProtocolOptions = map[string]*anypb.Any{
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protocolOptions,
+	}
+	return cluster, nil
 }
 
 // PostVirtualHostModify allows an extension to modify the virtual hosts in the xDS config.
 //
-// Currently, this replaces the route that has "x-ai-eg-selected-backend" pointing to "original_destination_cluster" to route to the original destination cluster.
+// Currently, this replaces the route that has "x-ai-eg-selected-backend" pointing to one of the original destination clusters
+// to route to that cluster with the Host header rewritten to the host of the selected endpoint.
 func (s *Server) PostVirtualHostModify(_ context.Context, req *egextension.PostVirtualHostModifyRequest) (*egextension.PostVirtualHostModifyResponse, error) {
 	if req.VirtualHost == nil || len(req.VirtualHost.Routes) == 0 {
 		return nil, nil
@@ -95,12 +260,15 @@ func (s *Server) PostVirtualHostModify(_ context.Context, req *egextension.PostV
 				continue
 			}
 			matcher, ok := h.HeaderMatchSpecifier.(*routev3.HeaderMatcher_StringMatch)
-			if !ok || matcher.StringMatch.GetExact() != OriginalDstClusterName {
+			if !ok || !isOriginalDstClusterName(matcher.StringMatch.GetExact()) {
 				s.log.Info("unexpected header value", "header", h)
 				continue
 			}
 			route.Action = &routev3.Route_Route{
-				Route: &routev3.RouteAction{ClusterSpecifier: &routev3.RouteAction_Cluster{Cluster: OriginalDstClusterName}},
+				Route: &routev3.RouteAction{
+					ClusterSpecifier:     &routev3.RouteAction_Cluster{Cluster: matcher.StringMatch.GetExact()},
+					HostRewriteSpecifier: &routev3.RouteAction_HostRewriteHeader{HostRewriteHeader: originalHostHeaderName},
+				},
 			}
 		}
 	}
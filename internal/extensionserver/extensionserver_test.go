@@ -10,28 +10,49 @@ import (
 
 	egextension "github.com/envoyproxy/gateway/proto/extension"
 	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 )
 
+// newFakeClient returns a fake client with the given objects.
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, aigv1a1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
 func TestNew(t *testing.T) {
 	logger := logr.Discard()
-	s := New(logger)
+	s := New(logger, newFakeClient(t))
 	require.NotNil(t, s)
 }
 
 func TestCheck(t *testing.T) {
 	logger := logr.Discard()
-	s := New(logger)
+	s := New(logger, newFakeClient(t))
 	_, err := s.Check(t.Context(), nil)
 	require.NoError(t, err)
 }
 
 func TestWatch(t *testing.T) {
 	logger := logr.Discard()
-	s := New(logger)
+	s := New(logger, newFakeClient(t))
 	err := s.Watch(nil, nil)
 	require.Error(t, err)
 	require.Equal(t, "rpc error: code = Unimplemented desc = Watch is not implemented", err.Error())
@@ -39,7 +60,7 @@ func TestWatch(t *testing.T) {
 
 func TestServerPostTranslateModify(t *testing.T) {
 	t.Run("existing", func(t *testing.T) {
-		s := New(logr.Discard())
+		s := New(logr.Discard(), newFakeClient(t))
 		res, err := s.PostTranslateModify(t.Context(), &egextension.PostTranslateModifyRequest{
 			Clusters: []*clusterv3.Cluster{
 				{Name: OriginalDstClusterName},
@@ -49,7 +70,7 @@ func TestServerPostTranslateModify(t *testing.T) {
 		require.NoError(t, err)
 	})
 	t.Run("not existing", func(t *testing.T) {
-		s := New(logr.Discard())
+		s := New(logr.Discard(), newFakeClient(t))
 		res, err := s.PostTranslateModify(t.Context(), &egextension.PostTranslateModifyRequest{
 			Clusters: []*clusterv3.Cluster{
 				{Name: "foo"},
@@ -60,18 +81,94 @@ func TestServerPostTranslateModify(t *testing.T) {
 		require.Len(t, res.Clusters, 2)
 		require.Equal(t, "foo", res.Clusters[0].Name)
 		require.Equal(t, OriginalDstClusterName, res.Clusters[1].Name)
+		require.Nil(t, res.Clusters[1].TransportSocket)
+	})
+	t.Run("tls", func(t *testing.T) {
+		poolRef := func(name string, tls *aigv1a1.InferencePoolTLS) aigv1a1.AIGatewayRouteRuleBackendRef {
+			return aigv1a1.AIGatewayRouteRuleBackendRef{
+				Name:          name,
+				Kind:          ptr.To(aigv1a1.AIGatewayRouteRuleBackendRefInferencePool),
+				LoadBalancing: &aigv1a1.InferencePoolLoadBalancing{TLS: tls},
+			}
+		}
+		caRef := &gwapiv1.SecretObjectReference{Name: "my-ca"}
+		s := New(logr.Discard(), newFakeClient(t,
+			&aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "route1", Namespace: "ns1"},
+				Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+						{Name: "backend"},
+						poolRef("plaintext-pool", nil),
+						poolRef("system-ca-pool", &aigv1a1.InferencePoolTLS{}),
+						poolRef("pool", &aigv1a1.InferencePoolTLS{CACertificateRef: caRef}),
+					}},
+					// The pools sharing the same CA certificates share the cluster.
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+						poolRef("another-pool", &aigv1a1.InferencePoolTLS{CACertificateRef: caRef}),
+					}},
+				}},
+			},
+			&aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "route2", Namespace: "ns2"},
+				Spec: aigv1a1.AIGatewayRouteSpec{Rules: []aigv1a1.AIGatewayRouteRule{
+					// The Secret does not exist in this namespace, so the cluster is skipped.
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
+						poolRef("pool", &aigv1a1.InferencePoolTLS{CACertificateRef: caRef}),
+					}},
+				}},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-ca", Namespace: "ns1"},
+				Data:       map[string][]byte{"ca.crt": []byte("some-ca")},
+			},
+		))
+		res, err := s.PostTranslateModify(t.Context(), &egextension.PostTranslateModifyRequest{
+			Clusters: []*clusterv3.Cluster{{Name: "foo"}},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		require.Len(t, res.Clusters, 4)
+		require.Equal(t, "foo", res.Clusters[0].Name)
+		require.Equal(t, OriginalDstClusterName, res.Clusters[1].Name)
+
+		requireTLSCluster := func(cluster *clusterv3.Cluster, name string, trustedCA *corev3.DataSource) {
+			require.Equal(t, name, cluster.Name)
+			require.Equal(t, clusterv3.Cluster_ORIGINAL_DST, cluster.GetType())
+			require.Equal(t, originalDstHeaderName, cluster.GetOriginalDstLbConfig().HttpHeaderName)
+			require.Equal(t, "envoy.transport_sockets.tls", cluster.TransportSocket.Name)
+			var tlsContext tlsv3.UpstreamTlsContext
+			require.NoError(t, cluster.TransportSocket.GetTypedConfig().UnmarshalTo(&tlsContext))
+			require.Equal(t, trustedCA.String(), tlsContext.CommonTlsContext.GetValidationContext().TrustedCa.String())
+			var protocolOptions httpv3.HttpProtocolOptions
+			require.NoError(t, cluster.TypedExtensionProtocolOptions["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"].
+				UnmarshalTo(&protocolOptions))
+			require.True(t, protocolOptions.UpstreamHttpProtocolOptions.AutoSni)
+			require.True(t, protocolOptions.UpstreamHttpProtocolOptions.AutoSanValidation)
+		}
+		requireTLSCluster(res.Clusters[2], "original_destination_cluster_tls",
+			&corev3.DataSource{Specifier: &corev3.DataSource_Filename{Filename: "/etc/ssl/certs/ca-certificates.crt"}})
+		requireTLSCluster(res.Clusters[3], "original_destination_cluster_tls_ns1_my-ca",
+			&corev3.DataSource{Specifier: &corev3.DataSource_InlineBytes{InlineBytes: []byte("some-ca")}})
 	})
 }
 
+func TestOriginalDstClusterNameOf(t *testing.T) {
+	require.Equal(t, "original_destination_cluster", OriginalDstClusterNameOf("ns", nil))
+	require.Equal(t, "original_destination_cluster_tls", OriginalDstClusterNameOf("ns", &aigv1a1.InferencePoolTLS{}))
+	require.Equal(t, "original_destination_cluster_tls_ns_my-ca", OriginalDstClusterNameOf("ns", &aigv1a1.InferencePoolTLS{
+		CACertificateRef: &gwapiv1.SecretObjectReference{Name: "my-ca"},
+	}))
+}
+
 func TestServerPostVirtualHostModify(t *testing.T) {
 	t.Run("nil virtual host", func(t *testing.T) {
-		s := New(logr.Discard())
+		s := New(logr.Discard(), newFakeClient(t))
 		res, err := s.PostVirtualHostModify(t.Context(), &egextension.PostVirtualHostModifyRequest{})
 		require.Nil(t, res)
 		require.NoError(t, err)
 	})
 	t.Run("zero routes", func(t *testing.T) {
-		s := New(logr.Discard())
+		s := New(logr.Discard(), newFakeClient(t))
 		res, err := s.PostVirtualHostModify(t.Context(), &egextension.PostVirtualHostModifyRequest{
 			VirtualHost: &routev3.VirtualHost{},
 		})
@@ -79,7 +176,7 @@ func TestServerPostVirtualHostModify(t *testing.T) {
 		require.NoError(t, err)
 	})
 	t.Run("route", func(t *testing.T) {
-		s := New(logr.Discard())
+		s := New(logr.Discard(), newFakeClient(t))
 		res, err := s.PostVirtualHostModify(t.Context(), &egextension.PostVirtualHostModifyRequest{
 			VirtualHost: &routev3.VirtualHost{
 				Routes: []*routev3.Route{
@@ -107,5 +204,30 @@ func TestServerPostVirtualHostModify(t *testing.T) {
 		// Ensure that the action has been updated.
 		require.Equal(t, OriginalDstClusterName, res.VirtualHost.Routes[0].Action.(*routev3.Route_Route).
 			Route.ClusterSpecifier.(*routev3.RouteAction_Cluster).Cluster)
+		require.Equal(t, originalHostHeaderName, res.VirtualHost.Routes[0].GetRoute().GetHostRewriteHeader())
+	})
+	t.Run("tls route", func(t *testing.T) {
+		s := New(logr.Discard(), newFakeClient(t))
+		newRoute := func(name, cluster string) *routev3.Route {
+			return &routev3.Route{Name: name, Match: &routev3.RouteMatch{
+				Headers: []*routev3.HeaderMatcher{{
+					Name: "x-ai-eg-selected-backend",
+					HeaderMatchSpecifier: &routev3.HeaderMatcher_StringMatch{
+						StringMatch: &matcherv3.StringMatcher{MatchPattern: &matcherv3.StringMatcher_Exact{Exact: cluster}},
+					},
+				}},
+			}}
+		}
+		res, err := s.PostVirtualHostModify(t.Context(), &egextension.PostVirtualHostModifyRequest{
+			VirtualHost: &routev3.VirtualHost{Routes: []*routev3.Route{
+				newRoute("tls", "original_destination_cluster_tls_ns1_my-ca"),
+				newRoute("backend", "apple.ns1"),
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "original_destination_cluster_tls_ns1_my-ca", res.VirtualHost.Routes[0].GetRoute().GetCluster())
+		require.Equal(t, originalHostHeaderName, res.VirtualHost.Routes[0].GetRoute().GetHostRewriteHeader())
+		// The routes of the other backends are kept as is.
+		require.Nil(t, res.VirtualHost.Routes[1].Action)
 	})
 }
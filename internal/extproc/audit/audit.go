@@ -0,0 +1,138 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package audit implements the audit log of the chat completion requests sent to the backends and their completions.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/export"
+)
+
+// Entry is the audit log entry of a request.
+type Entry struct {
+	// Timestamp is when the request was received.
+	Timestamp time.Time `json:"timestamp"`
+	// RequestID is the value of the x-request-id header.
+	RequestID string `json:"requestID,omitempty"`
+	// Headers are the configured request headers, whose sensitive values are redacted.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Model is the model requested by the client.
+	Model string `json:"model"`
+	// Backend is the name of the backend the request was sent to.
+	Backend string `json:"backend,omitempty"`
+	// BackendModel is the model sent to the backend, which differs from Model when the backend maps the model name.
+	BackendModel string `json:"backendModel,omitempty"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Stream is true if the response was streamed.
+	Stream bool `json:"stream,omitempty"`
+	// Request is the body of the chat completion request in the OpenAI format as sent by the client, or as redacted
+	// by the guardrails.
+	Request any `json:"request"`
+	// Completion is the chat completion in the OpenAI format. The chunks of the streaming response are assembled
+	// into a single completion. This is omitted when the response is not a successful completion.
+	Completion any `json:"completion,omitempty"`
+}
+
+// Sink exports the batches of the entries.
+type Sink = export.Sink[*Entry]
+
+// NewSink creates a new sink for the configuration.
+func NewSink(config *filterapi.AuditLogSink) (Sink, error) {
+	switch config.Type {
+	case filterapi.AuditLogSinkTypeFile:
+		return export.NewFileSink[*Entry](config.Path, config.MaxSizeMegabytes, config.MaxBackups), nil
+	case filterapi.AuditLogSinkTypeHTTP:
+		return export.NewWebhookSink[*Entry](config.URL), nil
+	default:
+		return nil, fmt.Errorf("unknown audit log sink type: %s", config.Type)
+	}
+}
+
+// Logger redacts the entries and exports them to the sinks in the background.
+type Logger struct {
+	pipeline           *export.Pipeline[*Entry]
+	samplingPercentage int
+	headers            []string
+	redactor           *redactor
+	logger             *slog.Logger
+}
+
+// NewLogger creates a new Logger for the configuration and starts exporting to the sinks.
+func NewLogger(config *filterapi.AuditLogConfig, logger *slog.Logger) (*Logger, error) {
+	if config.SamplingPercentage < 0 || config.SamplingPercentage > 100 {
+		return nil, fmt.Errorf("invalid sampling percentage: %d", config.SamplingPercentage)
+	}
+	r, err := newRedactor(config.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction: %w", err)
+	}
+	l := &Logger{
+		pipeline:           export.NewPipeline[*Entry]("audit log entry", logger),
+		samplingPercentage: config.SamplingPercentage,
+		headers:            config.Headers,
+		redactor:           r,
+		logger:             logger,
+	}
+	for i := range config.Sinks {
+		sc := &config.Sinks[i]
+		if sc.BatchSize <= 0 || sc.FlushInterval <= 0 {
+			return nil, errors.Join(fmt.Errorf("invalid batch config of sink %d: batchSize=%d, flushInterval=%s",
+				i, sc.BatchSize, sc.FlushInterval), l.Close())
+		}
+		sink, err := NewSink(sc)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot create sink %d: %w", i, err), l.Close())
+		}
+		l.pipeline.AddSink(fmt.Sprintf("%d-%s", i, sc.Type), sink, sc.BatchSize, sc.FlushInterval, sc.MaxRetries)
+	}
+	return l, nil
+}
+
+// Sample decides whether the request is recorded based on the sampling percentage.
+func (l *Logger) Sample() bool {
+	return rand.IntN(100) < l.samplingPercentage // #nosec G404
+}
+
+// Record redacts the entry with the request headers, the JSON body of the request and the completion, and queues
+// it to be exported.
+//
+// This never blocks, and the entry is dropped for the sinks whose queue is full.
+func (l *Logger) Record(e *Entry, requestHeaders map[string]string, requestBody []byte, completion *Completion) {
+	if len(l.headers) > 0 {
+		e.Headers = make(map[string]string, len(l.headers))
+		for _, h := range l.headers {
+			if v, ok := requestHeaders[h]; ok {
+				e.Headers[h] = v
+			}
+		}
+		l.redactor.redactHeaders(e.Headers)
+	}
+	if err := json.Unmarshal(requestBody, &e.Request); err != nil {
+		l.logger.Error("dropping audit log entry with the invalid request body",
+			"requestID", e.RequestID, "error", err)
+		return
+	}
+	e.Request = l.redactor.redactValue(fieldRootRequest, e.Request)
+	if completion != nil {
+		if v := completion.value(); v != nil {
+			e.Completion = l.redactor.redactValue(fieldRootCompletion, v)
+		}
+	}
+	l.pipeline.Emit(e)
+}
+
+// Close exports the queued entries and closes the sinks. This blocks until all of them are done.
+func (l *Logger) Close() error {
+	return l.pipeline.Close()
+}
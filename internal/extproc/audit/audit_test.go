@@ -0,0 +1,121 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package audit
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestLogger_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewLogger(&filterapi.AuditLogConfig{
+		SamplingPercentage: 100,
+		Headers:            []string{"authorization", "x-team"},
+		Redaction:          &filterapi.AuditLogRedaction{Fields: []string{"request.user", "completion.choices[*].message.content"}},
+		Sinks: []filterapi.AuditLogSink{
+			{Type: filterapi.AuditLogSinkTypeFile, Path: path, MaxSizeMegabytes: 1, BatchSize: 10, FlushInterval: time.Hour},
+		},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.True(t, l.Sample())
+
+	var c Completion
+	c.Observe([]byte(`{"choices":[{"message":{"content":"secret"}}]}`), false)
+	l.Record(&Entry{
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), RequestID: "req-1", Model: "gpt-4o",
+		Backend: "openai", BackendModel: "gpt-4o-2024-08-06", Status: 200,
+	}, map[string]string{"authorization": "Bearer foo", "x-team": "search", "x-other": "bar"},
+		[]byte(`{"model":"gpt-4o","user":"alice"}`), &c)
+	// The entry with the invalid request body is dropped.
+	l.Record(&Entry{RequestID: "req-2"}, nil, []byte("not json"), nil)
+	require.NoError(t, l.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 1)
+	require.JSONEq(t, `{"timestamp":"2025-01-02T03:04:05Z","requestID":"req-1",
+"headers":{"authorization":"[REDACTED]","x-team":"search"},"model":"gpt-4o","backend":"openai",
+"backendModel":"gpt-4o-2024-08-06","status":200,"request":{"model":"gpt-4o","user":"[REDACTED]"},
+"completion":{"choices":[{"message":{"content":"[REDACTED]"}}]}}`, lines[0])
+}
+
+func TestLogger_Sample(t *testing.T) {
+	l := &Logger{samplingPercentage: 0}
+	for range 100 {
+		require.False(t, l.Sample())
+	}
+	l.samplingPercentage = 50
+	var sampled int
+	for range 1000 {
+		if l.Sample() {
+			sampled++
+		}
+	}
+	require.InDelta(t, 500, sampled, 150)
+}
+
+func TestNewLogger(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Run("ok", func(t *testing.T) {
+		l, err := NewLogger(&filterapi.AuditLogConfig{
+			SamplingPercentage: 10,
+			Sinks: []filterapi.AuditLogSink{
+				{Type: filterapi.AuditLogSinkTypeFile, Path: t.TempDir() + "/audit.jsonl", MaxSizeMegabytes: 1, BatchSize: 1, FlushInterval: time.Second},
+				{Type: filterapi.AuditLogSinkTypeHTTP, URL: "http://localhost:1", BatchSize: 1, FlushInterval: time.Second},
+			},
+		}, logger)
+		require.NoError(t, err)
+		require.Equal(t, 2, l.pipeline.Sinks())
+		require.NoError(t, l.Close())
+	})
+	for _, tc := range []struct {
+		name   string
+		config *filterapi.AuditLogConfig
+		expErr string
+	}{
+		{
+			name:   "invalid sampling percentage",
+			config: &filterapi.AuditLogConfig{SamplingPercentage: 101},
+			expErr: "invalid sampling percentage: 101",
+		},
+		{
+			name:   "invalid redaction",
+			config: &filterapi.AuditLogConfig{Redaction: &filterapi.AuditLogRedaction{Patterns: []string{"["}}},
+			expErr: "invalid redaction: invalid pattern",
+		},
+		{
+			name: "invalid batch",
+			config: &filterapi.AuditLogConfig{Sinks: []filterapi.AuditLogSink{
+				{Type: filterapi.AuditLogSinkTypeHTTP, URL: "http://localhost:1"},
+			}},
+			expErr: "invalid batch config of sink 0",
+		},
+		{
+			name: "unknown type",
+			config: &filterapi.AuditLogConfig{Sinks: []filterapi.AuditLogSink{
+				{Type: filterapi.AuditLogSinkTypeHTTP, URL: "http://localhost:1", BatchSize: 1, FlushInterval: time.Second},
+				{Type: "Kafka", BatchSize: 1, FlushInterval: time.Second},
+			}},
+			expErr: "cannot create sink 1: unknown audit log sink type: Kafka",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewLogger(tc.config, logger)
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
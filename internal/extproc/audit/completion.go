@@ -0,0 +1,203 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+)
+
+// Completion assembles the chat completion from the body of the response in the OpenAI format. The chunks of the
+// streaming response are stitched into a single completion as if it was not streamed.
+type Completion struct {
+	// body is the body of the non-streaming response.
+	body []byte
+	// pending is the incomplete line of the streaming response carried over to the next chunk.
+	pending []byte
+	// streamed is the completion assembled from the chunks, which is nil until the first chunk is observed.
+	streamed *assembledCompletion
+}
+
+// assembledCompletion is the chat completion assembled from the chunks of the streaming response.
+type assembledCompletion struct {
+	ID      string             `json:"id,omitempty"`
+	Object  string             `json:"object"`
+	Created int64              `json:"created,omitempty"`
+	Model   string             `json:"model,omitempty"`
+	Choices []*assembledChoice `json:"choices"`
+	Usage   json.RawMessage    `json:"usage,omitempty"`
+}
+
+type assembledChoice struct {
+	Index        int              `json:"index"`
+	Message      assembledMessage `json:"message"`
+	FinishReason string           `json:"finish_reason,omitempty"`
+}
+
+type assembledMessage struct {
+	Role      string               `json:"role,omitempty"`
+	Content   string               `json:"content"`
+	Refusal   string               `json:"refusal,omitempty"`
+	ToolCalls []*assembledToolCall `json:"tool_calls,omitempty"`
+}
+
+type assembledToolCall struct {
+	index    int
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// completionChunk is the subset of the chunk of the streaming response used to assemble the completion.
+type completionChunk struct {
+	ID      string `json:"id"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Role      string `json:"role"`
+			Content   string `json:"content"`
+			Refusal   string `json:"refusal"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage json.RawMessage `json:"usage"`
+}
+
+// Observe accumulates the body of the response, which is a chunk of the server-sent events if stream is true.
+func (c *Completion) Observe(body []byte, stream bool) {
+	if !stream {
+		c.body = append(c.body, body...)
+		return
+	}
+	data := append(c.pending, body...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSpace(data[:i])
+		data = data[i+1:]
+		if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			if payload = bytes.TrimSpace(payload); !bytes.Equal(payload, []byte("[DONE]")) {
+				c.observeChunk(payload)
+			}
+		}
+	}
+	c.pending = append([]byte(nil), data...)
+}
+
+func (c *Completion) observeChunk(payload []byte) {
+	var chunk completionChunk
+	if err := json.Unmarshal(payload, &chunk); err != nil {
+		return
+	}
+	if c.streamed == nil {
+		c.streamed = &assembledCompletion{Object: "chat.completion"}
+	}
+	s := c.streamed
+	if chunk.ID != "" {
+		s.ID = chunk.ID
+	}
+	if chunk.Created != 0 {
+		s.Created = chunk.Created
+	}
+	if chunk.Model != "" {
+		s.Model = chunk.Model
+	}
+	if len(chunk.Usage) > 0 && !bytes.Equal(chunk.Usage, []byte("null")) {
+		s.Usage = chunk.Usage
+	}
+	for i := range chunk.Choices {
+		delta := &chunk.Choices[i]
+		idx := slices.IndexFunc(s.Choices, func(c *assembledChoice) bool { return c.Index == delta.Index })
+		if idx < 0 {
+			s.Choices = append(s.Choices, &assembledChoice{Index: delta.Index})
+			idx = len(s.Choices) - 1
+		}
+		choice := s.Choices[idx]
+		if delta.Delta.Role != "" {
+			choice.Message.Role = delta.Delta.Role
+		}
+		choice.Message.Content += delta.Delta.Content
+		choice.Message.Refusal += delta.Delta.Refusal
+		if delta.FinishReason != "" {
+			choice.FinishReason = delta.FinishReason
+		}
+		for j := range delta.Delta.ToolCalls {
+			tc := &delta.Delta.ToolCalls[j]
+			k := slices.IndexFunc(choice.Message.ToolCalls, func(c *assembledToolCall) bool { return c.index == tc.Index })
+			if k < 0 {
+				choice.Message.ToolCalls = append(choice.Message.ToolCalls, &assembledToolCall{index: tc.Index})
+				k = len(choice.Message.ToolCalls) - 1
+			}
+			call := choice.Message.ToolCalls[k]
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			if tc.Type != "" {
+				call.Type = tc.Type
+			}
+			if tc.Function.Name != "" {
+				call.Function.Name = tc.Function.Name
+			}
+			call.Function.Arguments += tc.Function.Arguments
+		}
+	}
+}
+
+// FirstChoice returns the content of the message and the finish reason of the first choice of the completion.
+// Both are empty if nothing has been observed or the body is not a valid completion.
+func (c *Completion) FirstChoice() (content, finishReason string) {
+	if c.streamed != nil {
+		for _, choice := range c.streamed.Choices {
+			if choice.Index == 0 {
+				return choice.Message.Content, choice.FinishReason
+			}
+		}
+		return "", ""
+	}
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if json.Unmarshal(c.body, &completion) != nil || len(completion.Choices) == 0 {
+		return "", ""
+	}
+	return completion.Choices[0].Message.Content, completion.Choices[0].FinishReason
+}
+
+// value returns the completion as the decoded JSON value, or nil if nothing has been observed
+// or the body is not a valid JSON.
+func (c *Completion) value() any {
+	body := c.body
+	if c.streamed != nil {
+		body, _ = json.Marshal(c.streamed)
+	}
+	var v any
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletion(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		var c Completion
+		c.Observe([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,`), false)
+		c.Observe([]byte(`"message":{"role":"assistant","content":"hi"}}]}`), false)
+		requireCompletion(t, `{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"}}]}`, &c)
+		content, finishReason := c.FirstChoice()
+		require.Equal(t, "hi", content)
+		require.Empty(t, finishReason)
+	})
+	t.Run("streaming", func(t *testing.T) {
+		var c Completion
+		c.Observe([]byte(`data: {"id":"chatcmpl-1","created":1,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}
+
+data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"lo"}},{"index":1,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"ci"}}]}}]}
+
+data: {"id":"chatcmpl-1","choices":[{"index":1,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ty\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}
+
+data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":null}
+
+data: {"id":"chatcmpl-1","choi`), true)
+		c.Observe([]byte(`ces":[],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}
+
+data: [DONE]
+
+`), true)
+		requireCompletion(t, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o","choices":[
+{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"},
+{"index":1,"message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}
+],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`, &c)
+		content, finishReason := c.FirstChoice()
+		require.Equal(t, "Hello", content)
+		require.Equal(t, "stop", finishReason)
+	})
+	t.Run("empty", func(t *testing.T) {
+		var c Completion
+		require.Nil(t, c.value())
+		c.Observe([]byte("not json"), false)
+		require.Nil(t, c.value())
+		content, finishReason := c.FirstChoice()
+		require.Empty(t, content)
+		require.Empty(t, finishReason)
+	})
+}
+
+func requireCompletion(t *testing.T, expected string, c *Completion) {
+	raw, err := json.Marshal(c.value())
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(raw))
+}
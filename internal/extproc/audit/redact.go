@@ -0,0 +1,186 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// RedactedValue replaces the redacted values.
+const RedactedValue = "[REDACTED]"
+
+// SensitiveHeaderKeys are the names of the request headers that are always redacted,
+// both in the audit log and in the debug logs.
+var SensitiveHeaderKeys = []string{"authorization"}
+
+// Roots of the field paths.
+const (
+	fieldRootRequest    = "request"
+	fieldRootCompletion = "completion"
+)
+
+// redactor removes the sensitive values from the entries.
+type redactor struct {
+	patterns []*regexp.Regexp
+	// fields are the parsed field paths keyed by their root.
+	fields  map[string][]fieldPath
+	headers []string
+}
+
+// newRedactor creates a new redactor for the configuration, which may be nil.
+func newRedactor(config *filterapi.AuditLogRedaction) (*redactor, error) {
+	r := &redactor{fields: map[string][]fieldPath{}, headers: slices.Clone(SensitiveHeaderKeys)}
+	if config == nil {
+		return r, nil
+	}
+	for _, p := range config.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, f := range config.Fields {
+		root, path, err := parseFieldPath(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", f, err)
+		}
+		r.fields[root] = append(r.fields[root], path)
+	}
+	for _, h := range config.Headers {
+		r.headers = append(r.headers, strings.ToLower(h))
+	}
+	return r, nil
+}
+
+// redactHeaders redacts the values of the sensitive headers in place.
+func (r *redactor) redactHeaders(headers map[string]string) {
+	for k := range headers {
+		if slices.Contains(r.headers, strings.ToLower(k)) {
+			headers[k] = RedactedValue
+		}
+	}
+}
+
+// redactValue redacts the decoded JSON value under the root, i.e. "request" or "completion". The fields are
+// redacted first so that the patterns don't need to be applied to them.
+func (r *redactor) redactValue(root string, v any) any {
+	for _, path := range r.fields[root] {
+		v = path.redact(v)
+	}
+	if len(r.patterns) > 0 {
+		v = r.redactPatterns(v)
+	}
+	return v
+}
+
+// redactPatterns replaces the matches of the patterns in all the string values.
+func (r *redactor) redactPatterns(v any) any {
+	switch v := v.(type) {
+	case string:
+		for _, re := range r.patterns {
+			v = re.ReplaceAllLiteralString(v, RedactedValue)
+		}
+		return v
+	case map[string]any:
+		for k, e := range v {
+			v[k] = r.redactPatterns(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = r.redactPatterns(e)
+		}
+	}
+	return v
+}
+
+// fieldPath is the parsed path of the field such as "messages[*].content" under the root.
+type fieldPath []fieldPathSegment
+
+// fieldPathSegment is either the key of the object, or the index of the array. The wildcard "*" matches
+// all the keys or all the elements.
+type fieldPathSegment struct {
+	key   string
+	index int
+	// array is true if this is an array index.
+	array bool
+}
+
+// wildcardIndex is the index of the wildcard "[*]".
+const wildcardIndex = -1
+
+// parseFieldPath parses the field path such as "request.messages[*].content" into its root and the rest.
+func parseFieldPath(s string) (root string, path fieldPath, err error) {
+	for _, part := range strings.Split(s, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" {
+			return "", nil, fmt.Errorf("empty key")
+		}
+		if root == "" {
+			if key != fieldRootRequest && key != fieldRootCompletion {
+				return "", nil, fmt.Errorf("must start with %q or %q", fieldRootRequest, fieldRootCompletion)
+			}
+			root = key
+		} else {
+			path = append(path, fieldPathSegment{key: key})
+		}
+		for rest != "" {
+			var index string
+			var ok bool
+			if index, rest, ok = strings.Cut(rest, "]"); !ok {
+				return "", nil, fmt.Errorf("unclosed bracket")
+			}
+			seg := fieldPathSegment{array: true, index: wildcardIndex}
+			if index != "*" {
+				if seg.index, err = strconv.Atoi(index); err != nil || seg.index < 0 {
+					return "", nil, fmt.Errorf("invalid index %q", index)
+				}
+			}
+			path = append(path, seg)
+			if rest != "" {
+				if rest, ok = strings.CutPrefix(rest, "["); !ok {
+					return "", nil, fmt.Errorf("unexpected %q after bracket", rest)
+				}
+			}
+		}
+	}
+	return root, path, nil
+}
+
+// redact replaces the values at the path in v, returning the updated v.
+func (p fieldPath) redact(v any) any {
+	if len(p) == 0 {
+		return RedactedValue
+	}
+	seg, rest := p[0], p[1:]
+	switch v := v.(type) {
+	case map[string]any:
+		if seg.array {
+			return v
+		}
+		for k, e := range v {
+			if seg.key == "*" || seg.key == k {
+				v[k] = rest.redact(e)
+			}
+		}
+	case []any:
+		if !seg.array {
+			return v
+		}
+		for i, e := range v {
+			if seg.index == wildcardIndex || seg.index == i {
+				v[i] = rest.redact(e)
+			}
+		}
+	}
+	return v
+}
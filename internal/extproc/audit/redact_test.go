@@ -0,0 +1,97 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		expRoot string
+		expPath fieldPath
+		expErr  string
+	}{
+		{in: "request.user", expRoot: "request", expPath: fieldPath{{key: "user"}}},
+		{in: "request", expRoot: "request"},
+		{
+			in: "request.messages[*].content", expRoot: "request",
+			expPath: fieldPath{{key: "messages"}, {array: true, index: wildcardIndex}, {key: "content"}},
+		},
+		{
+			in: "completion.choices[0].message.tool_calls[*][1]", expRoot: "completion",
+			expPath: fieldPath{
+				{key: "choices"}, {array: true, index: 0}, {key: "message"}, {key: "tool_calls"},
+				{array: true, index: wildcardIndex}, {array: true, index: 1},
+			},
+		},
+		{in: "request.metadata.*", expRoot: "request", expPath: fieldPath{{key: "metadata"}, {key: "*"}}},
+		{in: "response.id", expErr: `must start with "request" or "completion"`},
+		{in: "request..user", expErr: "empty key"},
+		{in: "request.messages[0", expErr: "unclosed bracket"},
+		{in: "request.messages[-1]", expErr: `invalid index "-1"`},
+		{in: "request.messages[0]x", expErr: `unexpected "x" after bracket`},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			root, path, err := parseFieldPath(tc.in)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expRoot, root)
+			require.Equal(t, tc.expPath, path)
+		})
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r, err := newRedactor(&filterapi.AuditLogRedaction{
+		Patterns: []string{`\b\d{3}-\d{2}-\d{4}\b`, `sk-[a-zA-Z0-9]+`},
+		Fields:   []string{"request.user", "request.messages[0].content", "completion.choices[*].message.tool_calls"},
+		Headers:  []string{"X-API-Key"},
+	})
+	require.NoError(t, err)
+
+	var request any
+	require.NoError(t, json.Unmarshal([]byte(`{"model":"gpt-4o","user":"alice","messages":[
+{"role":"system","content":"you are helpful"},
+{"role":"user","content":[{"type":"text","text":"my SSN is 123-45-6789 and key sk-abc123"}]}]}`), &request))
+	request = r.redactValue(fieldRootRequest, request)
+	raw, err := json.Marshal(request)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"model":"gpt-4o","user":"[REDACTED]","messages":[
+{"role":"system","content":"[REDACTED]"},
+{"role":"user","content":[{"type":"text","text":"my SSN is [REDACTED] and key [REDACTED]"}]}]}`, string(raw))
+
+	var completion any
+	require.NoError(t, json.Unmarshal([]byte(`{"choices":[{"message":{"content":"ok","tool_calls":[{"id":"1"}]}}]}`), &completion))
+	completion = r.redactValue(fieldRootCompletion, completion)
+	raw, err = json.Marshal(completion)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"choices":[{"message":{"content":"ok","tool_calls":"[REDACTED]"}}]}`, string(raw))
+
+	headers := map[string]string{"authorization": "Bearer foo", "x-api-key": "bar", "x-team": "search"}
+	r.redactHeaders(headers)
+	require.Equal(t, map[string]string{"authorization": RedactedValue, "x-api-key": RedactedValue, "x-team": "search"}, headers)
+}
+
+func TestNewRedactor(t *testing.T) {
+	r, err := newRedactor(nil)
+	require.NoError(t, err)
+	require.Equal(t, SensitiveHeaderKeys, r.headers)
+
+	_, err = newRedactor(&filterapi.AuditLogRedaction{Patterns: []string{"("}})
+	require.ErrorContains(t, err, `invalid pattern "("`)
+	_, err = newRedactor(&filterapi.AuditLogRedaction{Fields: []string{"foo"}})
+	require.ErrorContains(t, err, `invalid field "foo"`)
+}
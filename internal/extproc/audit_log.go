@@ -0,0 +1,68 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/extproc/audit"
+)
+
+// auditRecorder records the audit log entry of a sampled chat completion request once its response completes.
+type auditRecorder struct {
+	// start is when the request was received.
+	start time.Time
+	// requestBody is the raw body of the request after the guardrails. This is nil if the request is not sampled.
+	requestBody []byte
+	// completion assembles the completion from the successful response.
+	completion audit.Completion
+	// emitted is true once the entry is emitted so that a request is recorded only once.
+	emitted bool
+}
+
+// newAuditRecorder creates a new auditRecorder for the request received now.
+func newAuditRecorder() auditRecorder {
+	return auditRecorder{start: time.Now()}
+}
+
+// sample decides whether the request is recorded, and keeps its body if so.
+// This is a no-op if the audit log is not configured.
+func (a *auditRecorder) sample(config *processorConfig, requestBody []byte) {
+	if config.auditLog != nil && config.auditLog.Sample() {
+		a.requestBody = requestBody
+	}
+}
+
+// sampled returns true if the request is recorded.
+func (a *auditRecorder) sampled() bool {
+	return a.requestBody != nil
+}
+
+// observe accumulates the body of the successful response in the OpenAI format.
+func (a *auditRecorder) observe(body []byte, stream bool) {
+	a.completion.Observe(body, stream)
+}
+
+// emit emits the audit log entry of the request sent to the upstream. This is a no-op if the request is not sampled.
+func (a *auditRecorder) emit(config *processorConfig, requestHeaders, responseHeaders map[string]string,
+	up upstream, stream bool,
+) {
+	if !a.sampled() || a.emitted {
+		return
+	}
+	a.emitted = true
+	status, _ := strconv.Atoi(responseHeaders[":status"])
+	config.auditLog.Record(&audit.Entry{
+		Timestamp:    a.start,
+		RequestID:    requestHeaders["x-request-id"],
+		Model:        requestHeaders[config.modelNameHeaderKey],
+		Backend:      up.backend,
+		BackendModel: up.model,
+		Status:       status,
+		Stream:       stream,
+	}, requestHeaders, a.requestBody, &a.completion)
+}
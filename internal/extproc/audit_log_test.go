@@ -0,0 +1,186 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/audit"
+)
+
+// newTestAuditLog creates the audit logger writing to the file at the returned path.
+func newTestAuditLog(t *testing.T, samplingPercentage int) (*audit.Logger, string) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := audit.NewLogger(&filterapi.AuditLogConfig{
+		SamplingPercentage: samplingPercentage,
+		Headers:            []string{"authorization", "x-team"},
+		Redaction:          &filterapi.AuditLogRedaction{Patterns: []string{`\d{3}-\d{2}-\d{4}`}},
+		Sinks: []filterapi.AuditLogSink{{
+			Type: filterapi.AuditLogSinkTypeFile, Path: path, MaxSizeMegabytes: 1, BatchSize: 100, FlushInterval: time.Hour,
+		}},
+	}, slog.Default())
+	require.NoError(t, err)
+	return l, path
+}
+
+// readAuditLog closes the logger and returns the lines written to the file at the path.
+func readAuditLog(t *testing.T, l *audit.Logger, path string) []string {
+	require.NoError(t, l.Close())
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+func TestChatCompletion_auditLog(t *testing.T) {
+	const body = `{"model":"some-model","stream":true,"messages":[{"role":"user","content":"my SSN is 123-45-6789"}]}`
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+
+	for _, tc := range []struct {
+		name               string
+		samplingPercentage int
+		status             string
+		expLine            string
+	}{
+		{
+			name:               "sampled",
+			samplingPercentage: 100,
+			status:             "200",
+			expLine: `{"requestID":"req-1","headers":{"authorization":"[REDACTED]","x-team":"search"},
+"model":"some-model","backend":"some-backend","backendModel":"some-model","status":200,"stream":true,
+"request":{"model":"some-model","stream":true,"messages":[{"role":"user","content":"my SSN is [REDACTED]"}]},
+"completion":{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"content":"Hello"},"finish_reason":"stop"}]}}`,
+		},
+		{
+			name:               "error response",
+			samplingPercentage: 100,
+			status:             "500",
+			expLine: `{"requestID":"req-1","headers":{"authorization":"[REDACTED]","x-team":"search"},
+"model":"some-model","backend":"some-backend","backendModel":"some-model","status":500,"stream":true,
+"request":{"model":"some-model","stream":true,"messages":[{"role":"user","content":"my SSN is [REDACTED]"}]}}`,
+		},
+		{name: "not sampled", samplingPercentage: 0, status: "200"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			auditLog, path := newTestAuditLog(t, tc.samplingPercentage)
+			headers := map[string]string{
+				":path": "/v1/chat/completions", "x-request-id": "req-1", "x-team": "search", "authorization": "Bearer secret",
+			}
+			p := &chatCompletionProcessor{
+				config: &processorConfig{
+					modelNameHeaderKey:       "x-model-name",
+					selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+					router:                   mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"},
+					auditLog:                 auditLog,
+				},
+				requestHeaders:  headers,
+				responseHeaders: map[string]string{":status": tc.status},
+				logger:          slog.Default(),
+				metrics:         &mockChatCompletionMetrics{},
+				translator:      &mockTranslator{t: t, expRequestBody: &expBody},
+				audit:           newAuditRecorder(),
+			}
+			_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+			require.NoError(t, err)
+			_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+				Body: []byte("data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\ndata: {\"choices\":[{\"index\":0,"),
+			})
+			require.NoError(t, err)
+			_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+				Body:        []byte("\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n"),
+				EndOfStream: true,
+			})
+			require.NoError(t, err)
+
+			lines := readAuditLog(t, auditLog, path)
+			if tc.expLine == "" {
+				require.Empty(t, lines)
+				return
+			}
+			require.Len(t, lines, 1)
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+			require.NotEmpty(t, entry["timestamp"])
+			delete(entry, "timestamp")
+			actual, err := json.Marshal(entry)
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expLine, string(actual))
+		})
+	}
+}
+
+func TestAuditRecorder_emit_disabled(t *testing.T) {
+	a := newAuditRecorder()
+	a.sample(&processorConfig{}, []byte(`{}`))
+	require.False(t, a.sampled())
+	a.emit(&processorConfig{}, map[string]string{}, map[string]string{}, upstream{}, true)
+	require.False(t, a.emitted)
+}
+
+func TestServer_LoadConfig_auditLog(t *testing.T) {
+	s, err := NewServer(slog.Default(), nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	newConfig := func() *filterapi.AuditLogConfig {
+		return &filterapi.AuditLogConfig{
+			SamplingPercentage: 100,
+			Sinks: []filterapi.AuditLogSink{{
+				Type: filterapi.AuditLogSinkTypeFile, Path: filepath.Join(dir, "audit.jsonl"), BatchSize: 1, FlushInterval: time.Second,
+			}},
+		}
+	}
+	config := &filterapi.Config{AuditLog: newConfig()}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	auditLog := s.config.auditLog
+	require.NotNil(t, auditLog)
+
+	// The logger is reused as long as its configuration is unchanged.
+	config.AuditLog = newConfig()
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Same(t, auditLog, s.config.auditLog)
+
+	config.AuditLog.SamplingPercentage = 50
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.NotSame(t, auditLog, s.config.auditLog)
+
+	config.AuditLog = nil
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Nil(t, s.config.auditLog)
+
+	config.AuditLog = &filterapi.AuditLogConfig{Redaction: &filterapi.AuditLogRedaction{Fields: []string{"response.id"}}}
+	require.ErrorContains(t, s.LoadConfig(t.Context(), config), "cannot create audit log: invalid redaction: invalid field")
+
+	// The reload failing after the new logger is created keeps the current one running.
+	config = &filterapi.Config{AuditLog: newConfig()}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	auditLog = s.config.auditLog
+	config.AuditLog = newConfig()
+	config.AuditLog.SamplingPercentage = 50
+	config.MirrorResults = &filterapi.MirrorResultsConfig{Sinks: []filterapi.AuditLogSink{{Type: "Kafka", BatchSize: 1, FlushInterval: time.Second}}}
+	require.ErrorContains(t, s.LoadConfig(t.Context(), config), "cannot create mirror results")
+	require.Same(t, auditLog, s.config.auditLog)
+	// The key of the current logger is kept as well, so reloading its configuration still reuses it.
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{AuditLog: newConfig()}))
+	require.Same(t, auditLog, s.config.auditLog)
+	auditLog.Record(&audit.Entry{RequestID: "after-failed-reload"}, nil, []byte(`{}`), nil)
+	lines := readAuditLog(t, auditLog, filepath.Join(dir, "audit.jsonl"))
+	require.Contains(t, lines[len(lines)-1], `"requestID":"after-failed-reload"`)
+}
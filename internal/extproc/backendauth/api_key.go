@@ -17,23 +17,39 @@ import (
 	"github.com/envoyproxy/ai-gateway/filterapi"
 )
 
+// anthropicAPIKeyHeaderName is the header used by the Anthropic API to carry the api key instead of the Authorization header.
+const anthropicAPIKeyHeaderName = "x-api-key"
+
 // apiKeyHandler implements [Handler] for api key authz.
 type apiKeyHandler struct {
 	apiKey string
+	// anthropic is true if the api key is sent as the x-api-key header as required by the Anthropic API.
+	anthropic bool
 }
 
-func newAPIKeyHandler(auth *filterapi.APIKeyAuth) (Handler, error) {
+func newAPIKeyHandler(auth *filterapi.APIKeyAuth, schema filterapi.APISchemaName) (Handler, error) {
 	secret, err := os.ReadFile(auth.Filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read api key file: %w", err)
 	}
-	return &apiKeyHandler{apiKey: strings.TrimSpace(string(secret))}, nil
+	return &apiKeyHandler{
+		apiKey:    strings.TrimSpace(string(secret)),
+		anthropic: schema == filterapi.APISchemaAnthropic,
+	}, nil
 }
 
 // Do implements [Handler.Do].
 //
-// Extracts the api key from the local file and set it as an authorization header.
+// Extracts the api key from the local file and set it as an authorization header,
+// or as the x-api-key header for the Anthropic backends.
 func (a *apiKeyHandler) Do(_ context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, _ *extprocv3.BodyMutation) error {
+	if a.anthropic {
+		requestHeaders[anthropicAPIKeyHeaderName] = a.apiKey
+		headerMut.SetHeaders = append(headerMut.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: anthropicAPIKeyHeaderName, RawValue: []byte(a.apiKey)},
+		})
+		return nil
+	}
 	requestHeaders["Authorization"] = fmt.Sprintf("Bearer %s", a.apiKey)
 	headerMut.SetHeaders = append(headerMut.SetHeaders, &corev3.HeaderValueOption{
 		Header: &corev3.HeaderValue{Key: "Authorization", RawValue: []byte(requestHeaders["Authorization"])},
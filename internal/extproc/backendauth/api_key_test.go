@@ -27,7 +27,7 @@ func TestNewAPIKeyHandler(t *testing.T) {
 	require.NoError(t, f.Sync())
 
 	auth := filterapi.APIKeyAuth{Filename: apiKeyFile}
-	handler, err := newAPIKeyHandler(&auth)
+	handler, err := newAPIKeyHandler(&auth, filterapi.APISchemaOpenAI)
 	require.NoError(t, err)
 	require.NotNil(t, handler)
 	// apiKey should be trimmed.
@@ -45,7 +45,7 @@ func TestApiKeyHandler_Do(t *testing.T) {
 	require.NoError(t, f.Sync())
 
 	auth := filterapi.APIKeyAuth{Filename: apiKeyFile}
-	handler, err := newAPIKeyHandler(&auth)
+	handler, err := newAPIKeyHandler(&auth, filterapi.APISchemaOpenAI)
 	require.NoError(t, err)
 	require.NotNil(t, handler)
 
@@ -78,3 +78,22 @@ func TestApiKeyHandler_Do(t *testing.T) {
 	require.Equal(t, "Authorization", headerMut.SetHeaders[1].Header.Key)
 	require.Equal(t, []byte("Bearer test"), headerMut.SetHeaders[1].Header.GetRawValue())
 }
+
+func TestApiKeyHandler_Do_Anthropic(t *testing.T) {
+	apiKeyFile := t.TempDir() + "/test"
+	require.NoError(t, os.WriteFile(apiKeyFile, []byte("test\n"), 0o600))
+
+	handler, err := newAPIKeyHandler(&filterapi.APIKeyAuth{Filename: apiKeyFile}, filterapi.APISchemaAnthropic)
+	require.NoError(t, err)
+
+	requestHeaders := map[string]string{":method": "POST"}
+	headerMut := &extprocv3.HeaderMutation{}
+	require.NoError(t, handler.Do(t.Context(), requestHeaders, headerMut, nil))
+
+	require.Equal(t, "test", requestHeaders["x-api-key"])
+	_, ok := requestHeaders["Authorization"]
+	require.False(t, ok)
+	require.Len(t, headerMut.SetHeaders, 1)
+	require.Equal(t, "x-api-key", headerMut.SetHeaders[0].Header.Key)
+	require.Equal(t, []byte("test"), headerMut.SetHeaders[0].Header.GetRawValue())
+}
@@ -19,18 +19,22 @@ import (
 // TODO: maybe this can be just "post-transformation" handler, as it is not really only about auth.
 type Handler interface {
 	// Do performs the backend auth, and make changes to the request headers and body mutations.
+	// The returned error is classified as aigwerrors.AuthError.
 	Do(ctx context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, bodyMut *extprocv3.BodyMutation) error
 }
 
 // NewHandler returns a new implementation of [Handler] based on the configuration.
-func NewHandler(ctx context.Context, config *filterapi.BackendAuth) (Handler, error) {
+// The schema is the API schema of the backend, which decides how some credentials are attached to the request.
+func NewHandler(ctx context.Context, config *filterapi.BackendAuth, schema filterapi.APISchemaName) (Handler, error) {
 	switch {
 	case config.AWSAuth != nil:
 		return newAWSHandler(ctx, config.AWSAuth)
 	case config.APIKey != nil:
-		return newAPIKeyHandler(config.APIKey)
+		return newAPIKeyHandler(config.APIKey, schema)
 	case config.AzureAuth != nil:
 		return newAzureHandler(config.AzureAuth)
+	case config.GCPAuth != nil:
+		return newGCPHandler(config.GCPAuth)
 	default:
 		return nil, errors.New("no backend auth handler found")
 	}
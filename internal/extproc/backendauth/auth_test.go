@@ -53,9 +53,15 @@ aws_secret_access_key = test
 				AzureAuth: &filterapi.AzureAuth{Filename: azureFile},
 			},
 		},
+		{
+			name: "GCPAuth",
+			config: &filterapi.BackendAuth{
+				GCPAuth: &filterapi.GCPAuth{Filename: azureFile, ProjectName: "project", Region: "us-central1"},
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := NewHandler(t.Context(), tt.config)
+			_, err := NewHandler(t.Context(), tt.config, filterapi.APISchemaOpenAI)
 			require.NoError(t, err)
 		})
 	}
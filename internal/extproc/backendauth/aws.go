@@ -23,6 +23,7 @@ import (
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
 )
 
 // awsHandler implements [Handler] for AWS Bedrock authz.
@@ -92,13 +93,13 @@ func (a *awsHandler) Do(ctx context.Context, requestHeaders map[string]string, h
 		fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com%s", a.region, path),
 		bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("cannot create request: %w", err)
+		return aigwerrors.Wrap(aigwerrors.AuthError, fmt.Errorf("cannot create request: %w", err))
 	}
 
 	err = a.signer.SignHTTP(ctx, a.credentials, req,
 		hex.EncodeToString(payloadHash[:]), "bedrock", a.region, time.Now())
 	if err != nil {
-		return fmt.Errorf("cannot sign request: %w", err)
+		return aigwerrors.Wrap(aigwerrors.AuthError, fmt.Errorf("cannot sign request: %w", err))
 	}
 
 	for key, hdr := range req.Header {
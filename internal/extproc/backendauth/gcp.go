@@ -0,0 +1,55 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+type gcpHandler struct {
+	gcpAccessToken string
+	projectName    string
+	region         string
+}
+
+func newGCPHandler(auth *filterapi.GCPAuth) (Handler, error) {
+	secret, err := os.ReadFile(auth.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gcp access token file: %w", err)
+	}
+	return &gcpHandler{
+		gcpAccessToken: strings.TrimSpace(string(secret)),
+		projectName:    auth.ProjectName,
+		region:         auth.Region,
+	}, nil
+}
+
+// Do implements [Handler.Do].
+//
+// Extracts the gcp access token from the local file and set it as an authorization header.
+// The path set by the translator is relative to the project and location, so it is also prefixed here.
+func (g *gcpHandler) Do(_ context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, _ *extprocv3.BodyMutation) error {
+	requestHeaders["Authorization"] = fmt.Sprintf("Bearer %s", g.gcpAccessToken)
+	headerMut.SetHeaders = append(headerMut.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "Authorization", RawValue: []byte(requestHeaders["Authorization"])},
+	})
+	for _, h := range headerMut.SetHeaders {
+		if h.Header.Key == ":path" {
+			p := fmt.Sprintf("/v1/projects/%s/locations/%s/%s", g.projectName, g.region, string(h.Header.RawValue))
+			h.Header.RawValue = []byte(p)
+			requestHeaders[":path"] = p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"os"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNewGCPHandler_MissingConfigFile(t *testing.T) {
+	handler, err := newGCPHandler(&filterapi.GCPAuth{})
+	require.ErrorContains(t, err, "failed to read gcp access token file")
+	require.Nil(t, handler)
+}
+
+func TestGCPHandler_Do(t *testing.T) {
+	gcpTokenFile := t.TempDir() + "/gcpAccessToken"
+	require.NoError(t, os.WriteFile(gcpTokenFile, []byte(" some-access-token \n"), 0o600))
+
+	handler, err := newGCPHandler(&filterapi.GCPAuth{Filename: gcpTokenFile, ProjectName: "my-project", Region: "us-central1"})
+	require.NoError(t, err)
+	require.Equal(t, "some-access-token", handler.(*gcpHandler).gcpAccessToken)
+
+	requestHeaders := map[string]string{":method": "POST"}
+	headerMut := &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte("publishers/google/models/gemini-2.0-flash:generateContent")}},
+		},
+	}
+	require.NoError(t, handler.Do(t.Context(), requestHeaders, headerMut, nil))
+
+	require.Equal(t, "Bearer some-access-token", requestHeaders["Authorization"])
+	const expPath = "/v1/projects/my-project/locations/us-central1/publishers/google/models/gemini-2.0-flash:generateContent"
+	require.Equal(t, expPath, requestHeaders[":path"])
+	require.Len(t, headerMut.SetHeaders, 2)
+	require.Equal(t, expPath, string(headerMut.SetHeaders[0].Header.RawValue))
+	require.Equal(t, "Authorization", headerMut.SetHeaders[1].Header.Key)
+	require.Equal(t, "Bearer some-access-token", string(headerMut.SetHeaders[1].Header.RawValue))
+}
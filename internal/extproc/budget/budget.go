@@ -0,0 +1,181 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package budget implements the monthly spend budgets of the clients.
+package budget
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// Store is the ledger of the spend of the clients. The amounts are in nano USD.
+type Store interface {
+	// Spent returns the spend of the client in the period.
+	Spent(ctx context.Context, client, period string) (int64, error)
+	// Add adds the cost to the spend of the client in the period, and returns the updated spend.
+	Add(ctx context.Context, client, period string, cost int64) (int64, error)
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// NewStore creates a new store for the configuration.
+func NewStore(config *filterapi.SpendBudgetStoreConfig) (Store, error) {
+	switch config.Type {
+	case filterapi.SpendBudgetStoreTypeLocal:
+		return NewFileStore(config.Path)
+	case filterapi.SpendBudgetStoreTypeRedis:
+		return NewRedisStore(config)
+	default:
+		return nil, fmt.Errorf("unknown spend budget store type: %s", config.Type)
+	}
+}
+
+// Manager checks and records the spend of the clients against their budgets.
+type Manager struct {
+	config *filterapi.SpendBudgetsConfig
+	store  Store
+	limits map[string]int64
+	// now is the current time, which can be replaced in tests.
+	now func() time.Time
+}
+
+// NewManager creates a new manager for the configuration. The store is not closed by the manager
+// since it can be shared across the configuration reloads.
+func NewManager(config *filterapi.SpendBudgetsConfig, store Store) (*Manager, error) {
+	if (config.ClientIDHeader == "") == (config.ClientIDJWTClaim == "") {
+		return nil, fmt.Errorf("exactly one of the client ID header or JWT claim must be set")
+	}
+	limits := make(map[string]int64, len(config.Clients))
+	for _, c := range config.Clients {
+		limits[c.Client] = c.MonthlyLimit
+	}
+	return &Manager{config: config, store: store, limits: limits, now: time.Now}, nil
+}
+
+// FailOpen returns true if the requests are let through when the spend cannot be read from the store.
+func (m *Manager) FailOpen() bool {
+	return m.config.FailOpen
+}
+
+// ClientID returns the identifier of the client of the request from its headers, or empty if not found.
+func (m *Manager) ClientID(headers map[string]string) string {
+	if m.config.ClientIDHeader != "" {
+		return headers[strings.ToLower(m.config.ClientIDHeader)]
+	}
+	return jwtClaim(headers["authorization"], m.config.ClientIDJWTClaim)
+}
+
+// Exceeded returns true if the spend of the client in the current month has reached its budget.
+func (m *Manager) Exceeded(ctx context.Context, client string) (bool, error) {
+	limit := m.limit(client)
+	if limit <= 0 {
+		return false, nil
+	}
+	spent, err := m.store.Spent(ctx, client, m.period())
+	if err != nil {
+		return false, err
+	}
+	return spent >= limit, nil
+}
+
+// Record charges the cost of the token usage of the model on the backend to the client,
+// and returns the charged cost in nano USD.
+func (m *Manager) Record(ctx context.Context, client, backend, model string, usage translator.LLMTokenUsage) (int64, error) {
+	cost := m.Cost(backend, model, usage)
+	if cost == 0 {
+		return 0, nil
+	}
+	if _, err := m.store.Add(ctx, client, m.period(), cost); err != nil {
+		return 0, err
+	}
+	return cost, nil
+}
+
+// Cost returns the cost of the token usage of the model on the backend in nano USD rounded up.
+// This returns zero if the model has no price.
+func (m *Manager) Cost(backend, model string, usage translator.LLMTokenUsage) int64 {
+	p := m.pricing(backend, model)
+	if p == nil {
+		return 0
+	}
+	// The prices are per million tokens, so the product can overflow int64 for the expensive models.
+	cost := new(big.Int).Mul(big.NewInt(int64(usage.InputTokens)), big.NewInt(p.InputPerMillionTokens))
+	cost.Add(cost, new(big.Int).Mul(big.NewInt(int64(usage.OutputTokens)), big.NewInt(p.OutputPerMillionTokens)))
+	cost.Add(cost, big.NewInt(999_999))
+	cost.Quo(cost, big.NewInt(1_000_000))
+	if !cost.IsInt64() {
+		return 0
+	}
+	return cost.Int64()
+}
+
+// pricing returns the price of the model on the backend, preferring the one specific to the backend.
+func (m *Manager) pricing(backend, model string) *filterapi.ModelPricing {
+	var ret *filterapi.ModelPricing
+	for i := range m.config.Pricing {
+		p := &m.config.Pricing[i]
+		if p.Model != model {
+			continue
+		}
+		if p.Backend == backend {
+			return p
+		} else if p.Backend == "" && ret == nil {
+			ret = p
+		}
+	}
+	return ret
+}
+
+// limit returns the monthly budget of the client, or zero if unlimited.
+func (m *Manager) limit(client string) int64 {
+	if limit, ok := m.limits[client]; ok {
+		return limit
+	}
+	return m.config.DefaultMonthlyLimit
+}
+
+// period returns the current calendar month in UTC, which is the period of the budgets.
+func (m *Manager) period() string {
+	return m.now().UTC().Format("2006-01")
+}
+
+// jwtClaim returns the claim of the JWT bearer token in the authorization header without verifying it,
+// or empty if not found.
+func jwtClaim(authorization, claim string) string {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims map[string]any
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	switch v := claims[claim].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
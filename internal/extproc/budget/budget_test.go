@@ -0,0 +1,130 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package budget
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func newTestManager(t *testing.T, config *filterapi.SpendBudgetsConfig) *Manager {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "spend.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	m, err := NewManager(config, store)
+	require.NoError(t, err)
+	return m
+}
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(&filterapi.SpendBudgetsConfig{}, nil)
+	require.ErrorContains(t, err, "exactly one of the client ID header or JWT claim must be set")
+	_, err = NewManager(&filterapi.SpendBudgetsConfig{ClientIDHeader: "x-user-id", ClientIDJWTClaim: "sub"}, nil)
+	require.Error(t, err)
+}
+
+func TestManager_ClientID(t *testing.T) {
+	m := &Manager{config: &filterapi.SpendBudgetsConfig{ClientIDHeader: "X-User-ID"}}
+	require.Equal(t, "alice", m.ClientID(map[string]string{"x-user-id": "alice"}))
+	require.Empty(t, m.ClientID(map[string]string{}))
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"bob","org":42,"groups":["a"]}`))
+	headers := map[string]string{"authorization": "Bearer header." + payload + ".signature"}
+	for _, tc := range []struct {
+		claim, headerValue, exp string
+	}{
+		{claim: "sub", exp: "bob"},
+		{claim: "org", exp: "42"},
+		{claim: "groups"},
+		{claim: "missing"},
+		{claim: "sub", headerValue: "Basic dXNlcjpwYXNz"},
+		{claim: "sub", headerValue: "Bearer not-a-jwt"},
+		{claim: "sub", headerValue: "Bearer a.!!!.c"},
+	} {
+		t.Run(tc.claim+tc.headerValue, func(t *testing.T) {
+			m := &Manager{config: &filterapi.SpendBudgetsConfig{ClientIDJWTClaim: tc.claim}}
+			h := headers
+			if tc.headerValue != "" {
+				h = map[string]string{"authorization": tc.headerValue}
+			}
+			require.Equal(t, tc.exp, m.ClientID(h))
+		})
+	}
+}
+
+func TestManager_Cost(t *testing.T) {
+	m := &Manager{config: &filterapi.SpendBudgetsConfig{Pricing: []filterapi.ModelPricing{
+		{Model: "gpt-4o", InputPerMillionTokens: 2_500_000_000, OutputPerMillionTokens: 10_000_000_000},
+		{Model: "gpt-4o", Backend: "azure", InputPerMillionTokens: 5_000_000_000, OutputPerMillionTokens: 15_000_000_000},
+		{Model: "cheap", InputPerMillionTokens: 1, OutputPerMillionTokens: 1},
+		{Model: "expensive", InputPerMillionTokens: 1 << 60, OutputPerMillionTokens: 1 << 60},
+	}}}
+	usage := translator.LLMTokenUsage{InputTokens: 1000, OutputTokens: 200, TotalTokens: 1200}
+	// 1000 * 2.5 / 1e6 + 200 * 10 / 1e6 USD = 0.0045 USD.
+	require.Equal(t, int64(4_500_000), m.Cost("openai", "gpt-4o", usage))
+	// 1000 * 5 / 1e6 + 200 * 15 / 1e6 USD = 0.008 USD.
+	require.Equal(t, int64(8_000_000), m.Cost("azure", "gpt-4o", usage))
+	// Rounded up to 1 nano USD.
+	require.Equal(t, int64(1), m.Cost("openai", "cheap", usage))
+	require.Zero(t, m.Cost("openai", "cheap", translator.LLMTokenUsage{}))
+	require.Zero(t, m.Cost("openai", "unknown", usage))
+	require.Equal(t, int64(1<<60)/1_000_000+1, m.Cost("openai", "expensive", translator.LLMTokenUsage{InputTokens: 1}))
+	// Too large to be represented.
+	require.Zero(t, m.Cost("openai", "expensive", translator.LLMTokenUsage{InputTokens: 1 << 31, OutputTokens: 1 << 31}))
+}
+
+func TestManager_ExceededAndRecord(t *testing.T) {
+	m := newTestManager(t, &filterapi.SpendBudgetsConfig{
+		ClientIDHeader: "x-user-id",
+		Pricing: []filterapi.ModelPricing{
+			{Model: "gpt-4o", InputPerMillionTokens: 1_000_000_000, OutputPerMillionTokens: 2_000_000_000},
+		},
+		Clients:             []filterapi.ClientSpendBudget{{Client: "alice", MonthlyLimit: 3_000}, {Client: "unlimited"}},
+		DefaultMonthlyLimit: 1_000,
+	})
+	now := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	ctx := t.Context()
+	usage := translator.LLMTokenUsage{InputTokens: 1, OutputTokens: 1}
+
+	for _, client := range []string{"alice", "bob", "unlimited"} {
+		exceeded, err := m.Exceeded(ctx, client)
+		require.NoError(t, err)
+		require.False(t, exceeded, client)
+	}
+
+	cost, err := m.Record(ctx, "alice", "openai", "gpt-4o", usage)
+	require.NoError(t, err)
+	require.Equal(t, int64(3_000), cost)
+	cost, err = m.Record(ctx, "bob", "openai", "gpt-4o", usage)
+	require.NoError(t, err)
+	require.Equal(t, int64(3_000), cost)
+	cost, err = m.Record(ctx, "unlimited", "openai", "gpt-4o", usage)
+	require.NoError(t, err)
+	require.Equal(t, int64(3_000), cost)
+	cost, err = m.Record(ctx, "alice", "openai", "unknown", usage)
+	require.NoError(t, err)
+	require.Zero(t, cost)
+
+	for client, exp := range map[string]bool{"alice": true, "bob": true, "unlimited": false, "carol": false} {
+		exceeded, err := m.Exceeded(ctx, client)
+		require.NoError(t, err)
+		require.Equal(t, exp, exceeded, client)
+	}
+
+	// The budgets are reset in the next month.
+	now = now.Add(2 * time.Hour)
+	exceeded, err := m.Exceeded(ctx, "alice")
+	require.NoError(t, err)
+	require.False(t, exceeded)
+}
@@ -0,0 +1,73 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package budget
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// fileStore is the Store persisting the spend in a BoltDB file.
+// The spend of each period is kept in its own bucket keyed by the client.
+type fileStore struct {
+	db *bolt.DB
+}
+
+// NewFileStore opens or creates the BoltDB file at the path as a Store.
+func NewFileStore(path string) (Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of %s: %w", path, err)
+	}
+	// The timeout prevents from blocking forever when the file is locked by another process.
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &fileStore{db: db}, nil
+}
+
+// Spent implements [Store.Spent].
+func (s *fileStore) Spent(_ context.Context, client, period string) (spent int64, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(period)); b != nil {
+			spent = decodeSpend(b.Get([]byte(client)))
+		}
+		return nil
+	})
+	return
+}
+
+// Add implements [Store.Add].
+func (s *fileStore) Add(_ context.Context, client, period string, cost int64) (spent int64, err error) {
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(period))
+		if err != nil {
+			return err
+		}
+		spent = decodeSpend(b.Get([]byte(client))) + cost
+		return b.Put([]byte(client), binary.BigEndian.AppendUint64(nil, uint64(spent))) //nolint:gosec
+	})
+	return
+}
+
+// Close implements [Store.Close].
+func (s *fileStore) Close() error {
+	return s.db.Close()
+}
+
+// decodeSpend decodes the spend stored as the big-endian 64-bit integer.
+func decodeSpend(v []byte) int64 {
+	if len(v) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(v)) //nolint:gosec
+}
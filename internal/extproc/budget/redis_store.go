@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package budget
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// redisKeyTTL is the expiration of the keys, which is long enough to outlive the month of the period.
+const redisKeyTTL = 62 * 24 * time.Hour
+
+// redisStore is the Store persisting the spend in Redis so that it is shared by the replicas.
+// The spend is kept at the key "<prefix><period>:<client>".
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a new Store backed by the Redis server of the configuration.
+func NewRedisStore(config *filterapi.SpendBudgetStoreConfig) (Store, error) {
+	opts := &redis.Options{Addr: config.RedisAddress}
+	if config.RedisPasswordFile != "" {
+		password, err := os.ReadFile(config.RedisPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the Redis password file: %w", err)
+		}
+		opts.Password = strings.TrimSpace(string(password))
+	}
+	if config.RedisTLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return &redisStore{client: redis.NewClient(opts), prefix: config.RedisKeyPrefix}, nil
+}
+
+// Spent implements [Store.Spent].
+func (s *redisStore) Spent(ctx context.Context, client, period string) (int64, error) {
+	spent, err := s.client.Get(ctx, s.key(client, period)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return spent, err
+}
+
+// Add implements [Store.Add].
+func (s *redisStore) Add(ctx context.Context, client, period string, cost int64) (int64, error) {
+	key := s.key(client, period)
+	pipe := s.client.TxPipeline()
+	incr := pipe.IncrBy(ctx, key, cost)
+	pipe.Expire(ctx, key, redisKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// Close implements [Store.Close].
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *redisStore) key(client, period string) string {
+	return s.prefix + period + ":" + client
+}
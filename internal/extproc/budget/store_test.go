@@ -0,0 +1,92 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore(&filterapi.SpendBudgetStoreConfig{Type: "unknown"})
+	require.ErrorContains(t, err, "unknown spend budget store type: unknown")
+	_, err = NewStore(&filterapi.SpendBudgetStoreConfig{
+		Type: filterapi.SpendBudgetStoreTypeRedis, RedisPasswordFile: filepath.Join(t.TempDir(), "missing"),
+	})
+	require.ErrorContains(t, err, "failed to read the Redis password file")
+}
+
+func testStore(t *testing.T, s Store) {
+	ctx := t.Context()
+	spent, err := s.Spent(ctx, "alice", "2025-01")
+	require.NoError(t, err)
+	require.Zero(t, spent)
+
+	spent, err = s.Add(ctx, "alice", "2025-01", 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), spent)
+	spent, err = s.Add(ctx, "alice", "2025-01", 50)
+	require.NoError(t, err)
+	require.Equal(t, int64(150), spent)
+	_, err = s.Add(ctx, "alice", "2025-02", 10)
+	require.NoError(t, err)
+	_, err = s.Add(ctx, "bob", "2025-01", 1)
+	require.NoError(t, err)
+
+	spent, err = s.Spent(ctx, "alice", "2025-01")
+	require.NoError(t, err)
+	require.Equal(t, int64(150), spent)
+	spent, err = s.Spent(ctx, "alice", "2025-02")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), spent)
+	spent, err = s.Spent(ctx, "bob", "2025-01")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), spent)
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "spend.db")
+	s, err := NewStore(&filterapi.SpendBudgetStoreConfig{Type: filterapi.SpendBudgetStoreTypeLocal, Path: path})
+	require.NoError(t, err)
+	testStore(t, s)
+	require.NoError(t, s.Close())
+
+	// The spend is persisted across the restarts.
+	s, err = NewFileStore(path)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+	spent, err := s.Spent(t.Context(), "alice", "2025-01")
+	require.NoError(t, err)
+	require.Equal(t, int64(150), spent)
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireAuth("secret")
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0o600))
+
+	s, err := NewStore(&filterapi.SpendBudgetStoreConfig{
+		Type:              filterapi.SpendBudgetStoreTypeRedis,
+		RedisAddress:      mr.Addr(),
+		RedisPasswordFile: passwordFile,
+		RedisKeyPrefix:    "spend:",
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+	testStore(t, s)
+
+	v, err := mr.Get("spend:2025-01:alice")
+	require.NoError(t, err)
+	require.Equal(t, "150", v)
+	require.Equal(t, redisKeyTTL, mr.TTL("spend:2025-01:alice"))
+}
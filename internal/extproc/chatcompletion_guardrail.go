@@ -0,0 +1,182 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/guardrail"
+)
+
+// guardrailFlaggedHeaderKey is the header key set to the names of the matched guardrails when the action is Annotate.
+// This is set on the request to the backend at the request stage, and on the response to the client at the response stage.
+const guardrailFlaggedHeaderKey = "x-ai-eg-guardrail-flagged"
+
+// guardrailTexts holds the texts checked by the guardrails in the decoded JSON body, as well as
+// the functions to write them back to the body.
+type guardrailTexts struct {
+	texts   []string
+	setters []func(string)
+}
+
+// collectContent collects the texts in the "content" field of the message which is either a string or
+// an array of the content parts.
+func (g *guardrailTexts) collectContent(message map[string]any) {
+	switch content := message["content"].(type) {
+	case string:
+		g.texts = append(g.texts, content)
+		g.setters = append(g.setters, func(s string) { message["content"] = s })
+	case []any:
+		for _, p := range content {
+			part, ok := p.(map[string]any)
+			if !ok || part["type"] != "text" {
+				continue
+			}
+			if text, ok := part["text"].(string); ok {
+				g.texts = append(g.texts, text)
+				g.setters = append(g.setters, func(s string) { part["text"] = s })
+			}
+		}
+	}
+}
+
+// writeBack writes the redacted texts back to the body.
+func (g *guardrailTexts) writeBack() {
+	for i, set := range g.setters {
+		set(g.texts[i])
+	}
+}
+
+// decodeGuardrailBody decodes the JSON body into the generic form, keeping the numbers as-is.
+func decodeGuardrailBody(raw []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var body map[string]any
+	if err := decoder.Decode(&body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// guardRequest checks the request with the guardrails before it is translated for the backend.
+//
+// This returns the immediate response when the request is blocked. When the request is redacted, this returns
+// the redacted request both parsed and raw. Otherwise, the given request is returned as-is.
+func (c *chatCompletionProcessor) guardRequest(ctx context.Context, body *openai.ChatCompletionRequest, raw []byte) (
+	res *extprocv3.ProcessingResponse, newBody *openai.ChatCompletionRequest, newRaw []byte, err error,
+) {
+	decoded, err := decodeGuardrailBody(raw)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to decode request body: %w", err)
+	}
+	var texts guardrailTexts
+	messages, _ := decoded["messages"].([]any)
+	for _, m := range messages {
+		if message, ok := m.(map[string]any); ok {
+			texts.collectContent(message)
+		}
+	}
+
+	verdict, err := c.config.guardrail.Check(ctx, filterapi.GuardrailStageRequest, texts.texts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to check request: %w", err)
+	}
+	switch verdict.Action {
+	case filterapi.GuardrailActionBlock:
+		c.logger.Info("request blocked by guardrails", "reasons", verdict.Reasons)
+		return guardrailBlockedResponse(verdict), nil, nil, nil
+	case filterapi.GuardrailActionRedact:
+		c.logger.Info("request redacted by guardrails", "reasons", verdict.Reasons)
+		texts.writeBack()
+		if newRaw, err = json.Marshal(decoded); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to marshal redacted request body: %w", err)
+		}
+		if _, newBody, err = parseOpenAIChatCompletionBody(&extprocv3.HttpBody{Body: newRaw}); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse redacted request body: %w", err)
+		}
+		c.guardrailRedactedRequest = newRaw
+		return nil, newBody, newRaw, nil
+	case filterapi.GuardrailActionAnnotate:
+		c.logger.Info("request annotated by guardrails", "reasons", verdict.Reasons)
+		c.guardrailRequestFlagged = strings.Join(verdict.Reasons, ",")
+	}
+	return nil, body, raw, nil
+}
+
+// guardResponse checks the non-streaming response translated back to the OpenAI schema with the guardrails.
+//
+// This returns the immediate response when the response is blocked. Otherwise, this returns the mutations
+// which are either the given ones or the ones replacing the body with the redacted one.
+func (c *chatCompletionProcessor) guardResponse(ctx context.Context, translated []byte,
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation,
+) (*extprocv3.ProcessingResponse, *extprocv3.HeaderMutation, *extprocv3.BodyMutation, error) {
+	decoded, err := decodeGuardrailBody(translated)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	var texts guardrailTexts
+	choices, _ := decoded["choices"].([]any)
+	for _, ch := range choices {
+		choice, _ := ch.(map[string]any)
+		if message, ok := choice["message"].(map[string]any); ok {
+			texts.collectContent(message)
+		}
+	}
+
+	verdict, err := c.config.guardrail.Check(ctx, filterapi.GuardrailStageResponse, texts.texts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to check response: %w", err)
+	}
+	if verdict.Action == "" {
+		return nil, headerMutation, bodyMutation, nil
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	}
+	switch verdict.Action {
+	case filterapi.GuardrailActionBlock:
+		c.logger.Info("response blocked by guardrails", "reasons", verdict.Reasons)
+		return guardrailBlockedResponse(verdict), nil, nil, nil
+	case filterapi.GuardrailActionRedact:
+		c.logger.Info("response redacted by guardrails", "reasons", verdict.Reasons)
+		texts.writeBack()
+		redacted, err := json.Marshal(decoded)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to marshal redacted response body: %w", err)
+		}
+		bodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: redacted}}
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: "content-length", RawValue: []byte(strconv.Itoa(len(redacted)))},
+		})
+		if c.responseEncoding != "" {
+			// The redacted body is not encoded anymore.
+			headerMutation.RemoveHeaders = append(headerMutation.RemoveHeaders, "content-encoding")
+		}
+	case filterapi.GuardrailActionAnnotate:
+		c.logger.Info("response annotated by guardrails", "reasons", verdict.Reasons)
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: guardrailFlaggedHeaderKey, RawValue: []byte(strings.Join(verdict.Reasons, ","))},
+		})
+	}
+	return nil, headerMutation, bodyMutation, nil
+}
+
+// guardrailBlockedResponse builds the immediate response in the OpenAI error format for the blocked content.
+func guardrailBlockedResponse(verdict guardrail.Verdict) *extprocv3.ProcessingResponse {
+	return errorResponse(http.StatusBadRequest, "invalid_request_error", "content_policy_violation",
+		"content blocked by guardrails: "+strings.Join(verdict.Reasons, ","))
+}
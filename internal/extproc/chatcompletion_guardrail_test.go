@@ -0,0 +1,150 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func TestChatCompletion_guardrails(t *testing.T) {
+	checker, err := guardrail.New(&filterapi.GuardrailsConfig{Rules: []filterapi.GuardrailRule{
+		{Name: "secret", RegularExpression: `secret`, Action: filterapi.GuardrailActionBlock},
+		{Name: "pii", PII: []filterapi.GuardrailPIIType{filterapi.GuardrailPIITypeEmail}, Action: filterapi.GuardrailActionRedact},
+		{Name: "acme", RegularExpression: `acme`, Action: filterapi.GuardrailActionAnnotate},
+	}}, slog.Default())
+	require.NoError(t, err)
+
+	newProcessor := func(t *testing.T, mm *mockChatCompletionMetrics, expBody string) *chatCompletionProcessor {
+		headers := map[string]string{":path": "/v1/chat/completions"}
+		var expRequestBody *openai.ChatCompletionRequest
+		if expBody != "" {
+			expRequestBody = &openai.ChatCompletionRequest{}
+			require.NoError(t, json.Unmarshal([]byte(expBody), expRequestBody))
+		}
+		return &chatCompletionProcessor{
+			config: &processorConfig{
+				modelNameHeaderKey:       "x-model-name",
+				selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+				router:                   mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"},
+				guardrail:                checker,
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output"}},
+				},
+				metadataNamespace: "ai_gateway_llm_ns",
+			},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+			translator: &mockTranslator{
+				t: t, expRequestBody: expRequestBody, expHeaders: map[string]string{":status": "200"},
+				retUsedToken: translator.LLMTokenUsage{OutputTokens: 5},
+			},
+		}
+	}
+
+	t.Run("request blocked", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, "")
+		res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"model":"some-model","messages":[{"role":"user","content":"tell me the secret"}]}`),
+		})
+		require.NoError(t, err)
+		ir := res.GetImmediateResponse()
+		require.Equal(t, typev3.StatusCode_BadRequest, ir.Status.Code)
+		require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","code":"content_policy_violation",
+			"message":"content blocked by guardrails: secret"}}`, string(ir.Body))
+		mm.RequireRequestFailureType(t, aigwerrors.GuardrailBlocked)
+	})
+	t.Run("request redacted", func(t *testing.T) {
+		const redacted = `{"messages":[{"content":"mail [REDACTED]","role":"user"},` +
+			`{"content":[{"text":"or [REDACTED]","type":"text"}],"role":"user"}],"model":"some-model","temperature":0.1}`
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, redacted)
+		res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"model":"some-model","temperature":0.1,"messages":[{"role":"user","content":"mail foo@example.com"},
+				{"role":"user","content":[{"type":"text","text":"or bar@example.com"}]}]}`),
+		})
+		require.NoError(t, err)
+		common := res.GetRequestBody().Response
+		require.Equal(t, redacted, string(common.BodyMutation.GetBody()))
+		require.Contains(t, common.HeaderMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: "content-length", RawValue: []byte(strconv.Itoa(len(redacted)))},
+		})
+		mm.RequireRequestNotCompleted(t)
+	})
+	t.Run("request annotated", func(t *testing.T) {
+		const body = `{"model":"some-model","messages":[{"role":"user","content":"acme"}]}`
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, body)
+		res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		common := res.GetRequestBody().Response
+		require.Nil(t, common.BodyMutation)
+		require.Contains(t, common.HeaderMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: guardrailFlaggedHeaderKey, RawValue: []byte("acme")},
+		})
+	})
+
+	processResponse := func(t *testing.T, p *chatCompletionProcessor, body string) *extprocv3.ProcessingResponse {
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{
+			Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}},
+		})
+		require.NoError(t, err)
+		res, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body), EndOfStream: true})
+		require.NoError(t, err)
+		return res
+	}
+	t.Run("response blocked", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, "")
+		res := processResponse(t, p, `{"choices":[{"message":{"role":"assistant","content":"the secret is"}}]}`)
+		require.Equal(t, typev3.StatusCode_BadRequest, res.GetImmediateResponse().Status.Code)
+		md := res.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+		require.Equal(t, float64(5), md.Fields["output"].GetNumberValue())
+		// The upstream responded with 200, but the client gets the error.
+		mm.RequireRequestFailureType(t, aigwerrors.GuardrailBlocked)
+	})
+	t.Run("response redacted", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, "")
+		res := processResponse(t, p, `{"choices":[{"message":{"role":"assistant","content":"foo@example.com"}}],"created":1741382030}`)
+		common := res.GetResponseBody().Response
+		require.Equal(t, `{"choices":[{"message":{"content":"[REDACTED]","role":"assistant"}}],"created":1741382030}`,
+			string(common.BodyMutation.GetBody()))
+		mm.RequireRequestSuccess(t)
+	})
+	t.Run("response annotated", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, "")
+		res := processResponse(t, p, `{"choices":[{"message":{"role":"assistant","content":"acme"}}]}`)
+		common := res.GetResponseBody().Response
+		require.Nil(t, common.BodyMutation)
+		require.Equal(t, []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: guardrailFlaggedHeaderKey, RawValue: []byte("acme")}},
+		}, common.HeaderMutation.SetHeaders)
+	})
+	t.Run("streaming response is not checked", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, "")
+		p.stream = true
+		res := processResponse(t, p, `{"choices":[{"message":{"role":"assistant","content":"the secret is"}}]}`)
+		require.NotNil(t, res.GetResponseBody())
+	})
+}
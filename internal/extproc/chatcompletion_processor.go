@@ -7,6 +7,7 @@ package extproc
 
 import (
 	"bytes"
+	"cmp"
 	"compress/gzip"
 	"context"
 	"encoding/json"
@@ -14,18 +15,25 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
-	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/utils/ptr"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/tokenizer"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
 )
 
 // ChatCompletionProcessorFactory returns a factory method to instantiate the chat completion processor.
@@ -39,6 +47,9 @@ func ChatCompletionProcessorFactory(ccm x.ChatCompletionMetrics) ProcessorFactor
 			requestHeaders: requestHeaders,
 			logger:         logger,
 			metrics:        ccm,
+			metricAttrs:    config.metricAttributes.Attributes(requestHeaders),
+			usage:          newUsageRecorder(usageRecordOperationChat),
+			audit:          newAuditRecorder(),
 		}, nil
 	}
 }
@@ -53,33 +64,92 @@ type chatCompletionProcessor struct {
 	translator       translator.OpenAIChatCompletionTranslator
 	// cost is the cost of the request that is accumulated during the processing of the response.
 	costs translator.LLMTokenUsage
+	// estimatedInputTokens is the number of input tokens estimated before the request is sent to the backend.
+	// This is only calculated when a request cost uses it.
+	estimatedInputTokens uint32
 	// metrics tracking.
 	metrics x.ChatCompletionMetrics
+	// metricAttrs are the extra attributes of the metrics derived from the request headers.
+	metricAttrs []attribute.KeyValue
 	// stream is set to true if the request is a streaming request.
 	stream bool
+	// originalRequestBody and originalRequestBodyRaw are kept to translate the request again on failover.
+	originalRequestBody    *openai.ChatCompletionRequest
+	originalRequestBodyRaw []byte
+	// fallback is not nil if the originally selected backend belongs to a rule with the fallback policy.
+	fallback *processorConfigFallback
+	// responseCacheKey is not empty if the response should be stored in the response cache after the cache miss.
+	responseCacheKey string
+	// responseCacheBuffer accumulates the response body to be stored in the response cache.
+	responseCacheBuffer []byte
+	// guardrailRedactedRequest is the raw request body redacted by the guardrails, if any.
+	guardrailRedactedRequest []byte
+	// guardrailRequestFlagged is the comma-separated names of the guardrails annotating the request, if any.
+	guardrailRequestFlagged string
 	// dynamicLB is not nil if the originally selected backend has dynamic load balancing.
 	// TODO: this is not currently used but can be used to do a failover to the whole another backend as per the
 	// the comment in https://github.com/envoyproxy/ai-gateway/issues/34#issuecomment-2743810926.
 	dynamicLB *filterapi.DynamicLoadBalancing
+	// upstream is where the request is sent, which the cost and the usage of the request are attributed to.
+	upstream upstream
+	// spend charges the cost of the request to the spend budget of the client.
+	spend spendBudgetTracker
+	// usage emits the usage record of the request.
+	usage usageRecorder
+	// audit records the audit log entry of the request if it is sampled.
+	audit auditRecorder
+	// mirror compares the response with the one of the shadow backend if the request is mirrored.
+	mirror mirrorRecorder
+	// circuitBreaker is not nil if the request is sent via Envoy to the backend with the circuit breaker.
+	circuitBreaker *circuitBreakerCall
+	// experimentArm is not nil if the request is assigned to an arm of the experiment.
+	experimentArm *experimentArm
+	// upstreamSpan is the span of the request sent to the upstream, which ends when its response completes.
+	upstreamSpan trace.Span
+	// completion accumulates the attributes of the response recorded on the span of the request.
+	completion completionTrace
 }
 
-// selectTranslator selects the translator based on the output schema.
-func (c *chatCompletionProcessor) selectTranslator(out filterapi.VersionedAPISchema) error {
+// selectTranslator selects the translator based on the output schema of the backend.
+func (c *chatCompletionProcessor) selectTranslator(b *filterapi.Backend) (err error) {
 	if c.translator != nil { // Prevents re-selection and allows translator injection in tests.
 		return nil
 	}
+	c.translator, err = newChatCompletionTranslator(b)
+	return
+}
+
+// newChatCompletionTranslator creates a new translator for the output schema of the given backend.
+func newChatCompletionTranslator(b *filterapi.Backend) (translator.OpenAIChatCompletionTranslator, error) {
+	out := b.Schema
 	// TODO: currently, we ignore the LLMAPISchema."Version" field.
 	switch out.Name {
 	case filterapi.APISchemaOpenAI:
-		c.translator = translator.NewChatCompletionOpenAIToOpenAITranslator()
+		return translator.NewChatCompletionOpenAIToOpenAITranslator(), nil
 	case filterapi.APISchemaAWSBedrock:
-		c.translator = translator.NewChatCompletionOpenAIToAWSBedrockTranslator()
+		return translator.NewChatCompletionOpenAIToAWSBedrockTranslator(awsBedrockGuardrailConfiguration(b)), nil
 	case filterapi.APISchemaAzureOpenAI:
-		c.translator = translator.NewChatCompletionOpenAIToAzureOpenAITranslator(out.Version)
+		return translator.NewChatCompletionOpenAIToAzureOpenAITranslator(out.Version), nil
+	case filterapi.APISchemaAnthropic:
+		return translator.NewChatCompletionOpenAIToAnthropicTranslator(out.Version), nil
+	case filterapi.APISchemaGCPVertexAI:
+		return translator.NewChatCompletionOpenAIToGCPVertexAITranslator(), nil
 	default:
-		return fmt.Errorf("unsupported API schema: backend=%s", out)
+		return nil, fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+}
+
+// awsBedrockGuardrailConfiguration returns the Bedrock guardrail configuration of the backend, or nil if not configured.
+func awsBedrockGuardrailConfiguration(b *filterapi.Backend) *awsbedrock.GuardrailConfiguration {
+	g := b.AWSBedrockGuardrail
+	if g == nil {
+		return nil
 	}
-	return nil
+	guardrail := &awsbedrock.GuardrailConfiguration{GuardrailIdentifier: &g.Identifier, GuardrailVersion: &g.Version}
+	if g.Trace {
+		guardrail.Trace = ptr.To("enabled")
+	}
+	return guardrail
 }
 
 // ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
@@ -97,34 +167,81 @@ func (c *chatCompletionProcessor) ProcessRequestHeaders(_ context.Context, _ *co
 func (c *chatCompletionProcessor) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
 	defer func() {
 		if err != nil {
-			c.metrics.RecordRequestCompletion(ctx, false)
+			c.metrics.RecordRequestCompletion(ctx, false, failureAttrs(c.metricAttrs, aigwerrors.TypeOf(err))...)
 		}
 	}()
 	model, body, err := parseOpenAIChatCompletionBody(rawBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse request body: %w", err)
+		return nil, aigwerrors.Wrap(aigwerrors.ClientError, fmt.Errorf("failed to parse request body: %w", err))
 	}
 	c.logger.Info("processing request body", "path", c.requestHeaders[":path"], "model", model)
+	setRequestSpanAttributes(trace.SpanFromContext(ctx), usageRecordOperationChat, model)
+	recordPrompt(ctx, c.config, rawBody.Body)
 
 	c.metrics.SetModel(model)
+	if rejected, t := c.spend.check(ctx, c.config, c.requestHeaders, c.logger); rejected != nil {
+		c.metrics.RecordRequestCompletion(ctx, false, failureAttrs(c.metricAttrs, t)...)
+		return rejected, nil
+	}
+	raw := rawBody.Body
+	if c.config.guardrail != nil {
+		var blocked *extprocv3.ProcessingResponse
+		if blocked, body, raw, err = c.guardRequest(ctx, body, raw); err != nil {
+			return nil, err
+		} else if blocked != nil {
+			c.metrics.RecordRequestCompletion(ctx, false, failureAttrs(c.metricAttrs, aigwerrors.GuardrailBlocked)...)
+			return blocked, nil
+		}
+	}
 	c.requestHeaders[c.config.modelNameHeaderKey] = model
+	routeCtx, routeSpan := c.config.tracing.Start(ctx, "route", trace.SpanKindInternal)
 	b, err := c.config.router.Calculate(c.requestHeaders)
 	if err != nil {
+		tracing.End(routeSpan, err)
 		if errors.Is(err, x.ErrNoMatchingRule) {
-			c.metrics.RecordRequestCompletion(ctx, false)
-			return &extprocv3.ProcessingResponse{
-				Response: &extprocv3.ProcessingResponse_ImmediateResponse{
-					ImmediateResponse: &extprocv3.ImmediateResponse{
-						Status: &typev3.HttpStatus{Code: typev3.StatusCode_NotFound},
-						Body:   []byte(err.Error()),
-					},
-				},
-			}, nil
+			c.metrics.RecordRequestCompletion(ctx, false, failureAttrs(c.metricAttrs, aigwerrors.ClientError)...)
+			return noMatchingRuleResponse(err), nil
 		}
 		return nil, fmt.Errorf("failed to calculate route: %w", err)
 	}
+	c.experimentArm = c.config.experimentArms[b]
+	c.metricAttrs = c.experimentArm.metricAttrs(c.metricAttrs)
+	// The cached responses are scoped to the rule and the backend selected by the router.
+	if c.config.responseCache != nil {
+		if cached := c.lookupResponseCache(ctx, b, body); cached != nil {
+			routeSpan.End()
+			return cached, nil
+		}
+	}
+	c.audit.sample(c.config, raw)
+
+	if m := c.config.mirrors[b]; m != nil && m.sample() {
+		c.mirror.mirror(ctx, c.config, m, c.requestHeaders, raw, c.logger)
+	}
+	if f := c.config.fallbacks[b]; f != nil {
+		c.fallback = f
+		c.originalRequestBody, c.originalRequestBodyRaw = body, raw
+	}
+	// The hedged requests are sent directly by this filter, and their responses are returned as the immediate response.
+	if h := c.config.hedges[b]; h != nil && body.Stream {
+		// The hedged responses are buffered entirely, so the streaming requests are routed to the selected backend as usual.
+		c.logger.Info("streaming request is not hedged", "backend", b.Name)
+	} else if h != nil {
+		c.logger.Info("selected backend with hedging", "backend", b.Name, "schema", b.Schema, "hedge", h.backend.Name)
+		routeSpan.SetAttributes(aigwBackend.String(b.Name))
+		routeSpan.End()
+		c.originalRequestBody, c.originalRequestBodyRaw = body, raw
+		if c.config.estimateInputTokens {
+			c.estimatedInputTokens = tokenizer.ChatCompletionInputTokens(tokenizer.ForModel(model), body)
+		}
+		return c.hedge(ctx, b, h)
+	}
+
+	c.circuitBreaker = admitCircuitBreaker(ctx, c.config, b, c.metrics, c.logger)
 
 	var headers []*corev3.HeaderValueOption
+	// The model name mappings of the routed backend also apply to the endpoints selected by the dynamic load balancer.
+	modelNameMappings := b.ModelNameMappings
 	c.dynamicLB = b.DynamicLoadBalancing
 	selectedBackendHeaderValue := b.Name
 	if c.dynamicLB != nil {
@@ -133,32 +250,71 @@ func (c *chatCompletionProcessor) ProcessRequestBody(ctx context.Context, rawBod
 			// If it's not found, that should be a BUG.
 			panic("BUG: failed to find dynamic load balancer")
 		}
-		b, headers, err = lb.SelectChatCompletionsEndpoint(model, c.metrics)
+		var targetModel string
+		b, targetModel, headers, err = lb.SelectChatCompletionsEndpoint(routeCtx, model, c.requestHeaders, body, c.metrics)
 		if err != nil {
+			tracing.End(routeSpan, err)
 			return nil, fmt.Errorf("failed to select endpoint: %w", err)
 		}
-		// The selected backend is the dynamic load balancer name.
-		// TODO: we should make this constant as a part of the filterapi package.
+		// The target model selected by the load balancer, e.g. a LoRA adapter, takes precedence over the mappings.
+		if targetModel != model {
+			modelNameMappings = append([]filterapi.ModelNameMapping{{From: model, To: targetModel}}, modelNameMappings...)
+		}
+		// The selected backend is the ORIGINAL_DST cluster of the dynamic load balancer.
+		// TODO: we should make the default constant as a part of the filterapi package.
 		//  However, that will likely to change after https://github.com/envoyproxy/envoy/pull/38757
 		// 	so for now, we keep it as an inline string.
-		selectedBackendHeaderValue = "original_destination_cluster"
+		selectedBackendHeaderValue = cmp.Or(c.dynamicLB.ClusterName, "original_destination_cluster")
 	}
 
 	c.logger.Info("selected backend", "backend", b.Name, "schema", b.Schema)
 	c.metrics.SetBackend(b)
+	routeSpan.SetAttributes(aigwBackend.String(b.Name))
+	routeSpan.End()
 
-	if err = c.selectTranslator(b.Schema); err != nil {
+	if err = c.selectTranslator(b); err != nil {
 		return nil, fmt.Errorf("failed to select translator: %w", err)
 	}
 
+	_, translateSpan := c.config.tracing.Start(ctx, "translate request", trace.SpanKindInternal,
+		attribute.String("aigw.backend.schema", string(b.Schema.Name)))
+
+	// The redacted request by the guardrails, if any, needs to replace the original one passed through by the translator.
+	replacedBody := c.guardrailRedactedRequest
+	backendModel, mappedRaw, err := mapModelName(modelNameMappings, model, raw)
+	if err != nil {
+		tracing.End(translateSpan, err)
+		return nil, fmt.Errorf("failed to map model name: %w", err)
+	}
+	if mappedRaw != nil {
+		mapped := *body
+		mapped.Model = backendModel
+		body, replacedBody = &mapped, mappedRaw
+		c.metrics.SetModel(backendModel)
+	}
+	c.upstream = upstream{backend: b.Name, model: backendModel}
+
+	if c.config.estimateInputTokens {
+		c.estimatedInputTokens = tokenizer.ChatCompletionInputTokens(tokenizer.ForModel(backendModel), body)
+	}
+
 	headerMutation, bodyMutation, err := c.translator.RequestBody(body)
+	tracing.End(translateSpan, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to transform request: %w", err)
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform request: %w", err))
 	}
 
 	if headerMutation == nil {
 		headerMutation = &extprocv3.HeaderMutation{}
 	}
+	if replacedBody != nil && bodyMutation == nil {
+		bodyMutation = replaceRequestBody(headerMutation, replacedBody)
+	}
+	if c.guardrailRequestFlagged != "" {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: guardrailFlaggedHeaderKey, RawValue: []byte(c.guardrailRequestFlagged)},
+		})
+	}
 	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
 		// Set the model name to the request header with the key `x-ai-eg-model`.
 		Header: &corev3.HeaderValue{Key: c.config.modelNameHeaderKey, RawValue: []byte(model)},
@@ -169,10 +325,16 @@ func (c *chatCompletionProcessor) ProcessRequestBody(ctx context.Context, rawBod
 	headerMutation.SetHeaders = append(headerMutation.SetHeaders, headers...)
 	// The cluster-based routing is only used when the selected backend is not using dynamic load balancing.
 	if authHandler, ok := c.config.backendAuthHandlers[b.Name]; ok {
-		if err = authHandler.Do(ctx, c.requestHeaders, headerMutation, bodyMutation); err != nil {
-			return nil, fmt.Errorf("failed to do auth request: %w", err)
+		authCtx, authSpan := c.config.tracing.Start(ctx, "auth", trace.SpanKindInternal)
+		err = authHandler.Do(authCtx, c.requestHeaders, headerMutation, bodyMutation)
+		tracing.End(authSpan, err)
+		if err != nil {
+			return nil, aigwerrors.Wrap(aigwerrors.AuthError, fmt.Errorf("failed to do auth request: %w", err))
 		}
 	}
+	var upstreamCtx context.Context
+	upstreamCtx, c.upstreamSpan = startUpstreamSpan(ctx, c.config, b, c.upstream)
+	injectTraceContext(upstreamCtx, c.config, headerMutation)
 
 	resp := &extprocv3.ProcessingResponse{
 		Response: &extprocv3.ProcessingResponse_RequestBody{
@@ -184,6 +346,8 @@ func (c *chatCompletionProcessor) ProcessRequestBody(ctx context.Context, rawBod
 				},
 			},
 		},
+		DynamicMetadata: c.experimentArm.addDynamicMetadata(c.config,
+			buildEstimatedCostDynamicMetadata(c.config, c.estimatedInputTokens, c.logger)),
 	}
 	c.stream = body.Stream
 	return resp, nil
@@ -193,17 +357,31 @@ func (c *chatCompletionProcessor) ProcessRequestBody(ctx context.Context, rawBod
 func (c *chatCompletionProcessor) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
 	defer func() {
 		if err != nil {
-			c.metrics.RecordRequestCompletion(ctx, false)
+			c.metrics.RecordRequestCompletion(ctx, false, failureAttrs(c.metricAttrs, aigwerrors.TypeOf(err))...)
 		}
 	}()
-	// TODO: check the status code and use the dynamic load balancing to retry the request per the comment in
-	// 	https://github.com/envoyproxy/ai-gateway/issues/34#issuecomment-2743810926
-	_ = c.dynamicLB
-
 	c.responseHeaders = headersToMap(headers)
+	setResponseStatusSpanAttributes(c.upstreamSpan, c.responseHeaders[":status"])
+	status, _ := strconv.Atoi(c.responseHeaders[":status"])
+	c.circuitBreaker.done(ctx, status, nil)
+	c.circuitBreaker = nil
+	if c.fallback != nil {
+		if c.fallback.isRetriable(status) {
+			var resp *extprocv3.ProcessingResponse
+			if resp, err = c.failover(ctx); err != nil {
+				return nil, fmt.Errorf("failed to fail over: %w", err)
+			} else if resp != nil {
+				return resp, nil
+			}
+		}
+	}
 	if enc := c.responseHeaders["content-encoding"]; enc != "" {
 		c.responseEncoding = enc
 	}
+	if c.responseCacheKey != "" && (c.responseHeaders[":status"] != "200" || c.responseEncoding != "") {
+		// Only the successful responses are cached. The encoded ones are not since the body might be passed through as-is.
+		c.responseCacheKey = ""
+	}
 	// The translator can be nil as there could be response event generated by previous ext proc without
 	// getting the request event.
 	if c.translator == nil {
@@ -213,8 +391,9 @@ func (c *chatCompletionProcessor) ProcessResponseHeaders(ctx context.Context, he
 	}
 	headerMutation, err := c.translator.ResponseHeaders(c.responseHeaders)
 	if err != nil {
-		return nil, fmt.Errorf("failed to transform response headers: %w", err)
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response headers: %w", err))
 	}
+	headerMutation = c.experimentArm.setResponseHeaders(headerMutation)
 	var mode *extprocv3http.ProcessingMode
 	if c.stream && c.responseHeaders[":status"] == "200" {
 		// We only stream the response if the status code is 200 and the response is a stream.
@@ -229,8 +408,14 @@ func (c *chatCompletionProcessor) ProcessResponseHeaders(ctx context.Context, he
 
 // ProcessResponseBody implements [Processor.ProcessResponseBody].
 func (c *chatCompletionProcessor) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	// blocked is the immediate response replacing the response blocked by the guardrails, if any.
+	var blocked *extprocv3.ProcessingResponse
 	defer func() {
-		c.metrics.RecordRequestCompletion(ctx, err == nil)
+		if blocked != nil && err == nil {
+			c.metrics.RecordRequestCompletion(ctx, false, failureAttrs(c.metricAttrs, aigwerrors.GuardrailBlocked)...)
+			return
+		}
+		recordResponseCompletion(ctx, c.metrics, c.metricAttrs, c.responseHeaders, err)
 	}()
 	var br io.Reader
 	switch c.responseEncoding {
@@ -248,9 +433,45 @@ func (c *chatCompletionProcessor) ProcessResponseBody(ctx context.Context, body
 		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{}}, nil
 	}
 
+	// The guardrails only check the complete and successful non-streaming responses.
+	guardResponse := body.EndOfStream && !c.stream && c.responseHeaders[":status"] == "200" && c.config.guardrail != nil
+	traceResponse := trace.SpanFromContext(ctx).IsRecording() && c.responseHeaders[":status"] == "200"
+	auditResponse := c.audit.sampled() && c.responseHeaders[":status"] == "200"
+	mirrorResponse := c.mirror.mirrored() && c.responseHeaders[":status"] == "200"
+	var decodedBody []byte
+	if guardResponse || traceResponse || auditResponse || mirrorResponse {
+		// Keep the decoded body since the translator may pass it through as-is.
+		if decodedBody, err = io.ReadAll(br); err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		br = bytes.NewReader(decodedBody)
+	}
+
 	headerMutation, bodyMutation, tokenUsage, err := c.translator.ResponseBody(c.responseHeaders, br, body.EndOfStream)
 	if err != nil {
-		return nil, fmt.Errorf("failed to transform response: %w", err)
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response: %w", err))
+	}
+
+	translated := decodedBody
+	if m, ok := bodyMutation.GetMutation().(*extprocv3.BodyMutation_Body); ok {
+		translated = m.Body
+	}
+	if traceResponse {
+		c.completion.observe(translated, c.stream)
+	}
+	if auditResponse {
+		c.audit.observe(translated, c.stream)
+	}
+	if mirrorResponse {
+		c.mirror.observe(translated, c.stream)
+	}
+
+	if guardResponse {
+		if blocked, headerMutation, bodyMutation, err = c.guardResponse(ctx, translated, headerMutation, bodyMutation); err != nil {
+			return nil, err
+		} else if blocked != nil {
+			c.responseCacheKey = ""
+		}
 	}
 
 	resp := &extprocv3.ProcessingResponse{
@@ -264,26 +485,44 @@ func (c *chatCompletionProcessor) ProcessResponseBody(ctx context.Context, body
 		},
 	}
 
+	if c.responseCacheKey != "" {
+		c.bufferResponseCache(ctx, body, bodyMutation)
+	}
+
 	// TODO: we need to investigate if we need to accumulate the token usage for streaming responses.
 	c.costs.InputTokens += tokenUsage.InputTokens
 	c.costs.OutputTokens += tokenUsage.OutputTokens
 	c.costs.TotalTokens += tokenUsage.TotalTokens
 
 	// Update metrics with token usage.
-	c.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens)
+	c.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, c.metricAttrs...)
 	if c.stream {
 		// Token latency is only recorded for streaming responses, otherwise it doesn't make sense since
 		// these metrics are defined as a difference between the two output events.
-		c.metrics.RecordTokenLatency(ctx, tokenUsage.OutputTokens)
+		c.metrics.RecordTokenLatency(ctx, tokenUsage.OutputTokens, c.metricAttrs...)
 	}
 
+	if body.EndOfStream {
+		endUpstreamSpan(ctx, c.upstreamSpan, c.costs)
+		c.upstreamSpan = nil
+		c.completion.record(trace.SpanFromContext(ctx), c.config.tracing.RecordContent())
+		c.spend.record(ctx, c.config, c.upstream, c.costs, c.logger)
+		c.usage.emit(c.config, c.requestHeaders, c.responseHeaders, c.upstream, c.costs, c.stream)
+		c.audit.emit(c.config, c.requestHeaders, c.responseHeaders, c.upstream, c.stream)
+		c.mirror.emit(ctx, c.config, c.metrics, c.metricAttrs, c.requestHeaders, c.responseHeaders, c.upstream, c.costs, c.logger)
+	}
 	if body.EndOfStream && len(c.config.requestCosts) > 0 {
-		resp.DynamicMetadata, err = c.maybeBuildDynamicMetadata()
+		resp.DynamicMetadata, err = buildDynamicMetadata(c.config, &c.costs, c.estimatedInputTokens, c.requestHeaders, c.logger)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
 		}
 	}
 
+	if blocked != nil {
+		// The tokens have been consumed anyway, so the costs are still reported.
+		blocked.DynamicMetadata = resp.DynamicMetadata
+		return blocked, nil
+	}
 	return resp, nil
 }
 
@@ -295,26 +534,33 @@ func parseOpenAIChatCompletionBody(body *extprocv3.HttpBody) (modelName string,
 	return openAIReq.Model, &openAIReq, nil
 }
 
-func (c *chatCompletionProcessor) maybeBuildDynamicMetadata() (*structpb.Struct, error) {
-	metadata := make(map[string]*structpb.Value, len(c.config.requestCosts))
-	for i := range c.config.requestCosts {
-		rc := &c.config.requestCosts[i]
+// buildDynamicMetadata builds the dynamic metadata carrying the request costs calculated from the token usage.
+// This returns nil if there is no request cost configured.
+func buildDynamicMetadata(config *processorConfig, costs *translator.LLMTokenUsage, estimatedInputTokens uint32,
+	requestHeaders map[string]string, logger *slog.Logger,
+) (*structpb.Struct, error) {
+	metadata := make(map[string]*structpb.Value, len(config.requestCosts))
+	for i := range config.requestCosts {
+		rc := &config.requestCosts[i]
 		var cost uint32
 		switch rc.Type {
 		case filterapi.LLMRequestCostTypeInputToken:
-			cost = c.costs.InputTokens
+			cost = costs.InputTokens
 		case filterapi.LLMRequestCostTypeOutputToken:
-			cost = c.costs.OutputTokens
+			cost = costs.OutputTokens
 		case filterapi.LLMRequestCostTypeTotalToken:
-			cost = c.costs.TotalTokens
+			cost = costs.TotalTokens
+		case filterapi.LLMRequestCostTypeEstimatedInputToken:
+			cost = estimatedInputTokens
 		case filterapi.LLMRequestCostTypeCEL:
 			costU64, err := llmcostcel.EvaluateProgram(
 				rc.celProg,
-				c.requestHeaders[c.config.modelNameHeaderKey],
-				c.requestHeaders[c.config.selectedBackendHeaderKey],
-				c.costs.InputTokens,
-				c.costs.OutputTokens,
-				c.costs.TotalTokens,
+				requestHeaders[config.modelNameHeaderKey],
+				requestHeaders[config.selectedBackendHeaderKey],
+				costs.InputTokens,
+				costs.OutputTokens,
+				costs.TotalTokens,
+				estimatedInputTokens,
 			)
 			if err != nil {
 				return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
@@ -323,19 +569,38 @@ func (c *chatCompletionProcessor) maybeBuildDynamicMetadata() (*structpb.Struct,
 		default:
 			return nil, fmt.Errorf("unknown request cost kind: %s", rc.Type)
 		}
-		c.logger.Info("Setting request cost metadata", "type", rc.Type, "cost", cost, "metadataKey", rc.MetadataKey)
+		logger.Info("Setting request cost metadata", "type", rc.Type, "cost", cost, "metadataKey", rc.MetadataKey)
 		metadata[rc.MetadataKey] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(cost)}}
 	}
+	return newDynamicMetadata(config, metadata), nil
+}
+
+// buildEstimatedCostDynamicMetadata builds the dynamic metadata carrying the request costs of the estimated input
+// tokens, which is set at the request path so that the rate limit can be applied before the upstream call.
+// This returns nil if there is no such request cost configured.
+func buildEstimatedCostDynamicMetadata(config *processorConfig, estimatedInputTokens uint32, logger *slog.Logger) *structpb.Struct {
+	metadata := make(map[string]*structpb.Value)
+	for i := range config.requestCosts {
+		if rc := &config.requestCosts[i]; rc.Type == filterapi.LLMRequestCostTypeEstimatedInputToken {
+			logger.Info("Setting request cost metadata", "type", rc.Type, "cost", estimatedInputTokens, "metadataKey", rc.MetadataKey)
+			metadata[rc.MetadataKey] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(estimatedInputTokens)}}
+		}
+	}
+	return newDynamicMetadata(config, metadata)
+}
+
+// newDynamicMetadata returns the dynamic metadata with the given fields in the metadata namespace, or nil if it's empty.
+func newDynamicMetadata(config *processorConfig, metadata map[string]*structpb.Value) *structpb.Struct {
 	if len(metadata) == 0 {
-		return nil, nil
+		return nil
 	}
 	return &structpb.Struct{
 		Fields: map[string]*structpb.Value{
-			c.config.metadataNamespace: {
+			config.metadataNamespace: {
 				Kind: &structpb.Value_StructValue{
 					StructValue: &structpb.Struct{Fields: metadata},
 				},
 			},
 		},
-	}, nil
+	}
 }
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
 	"testing"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -18,13 +19,16 @@ import (
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/dynlb"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
 )
 
 func TestChatCompletion_Schema(t *testing.T) {
@@ -38,26 +42,48 @@ func TestChatCompletion_Schema(t *testing.T) {
 		_, err := ChatCompletionProcessorFactory(nil)(cfg, nil, nil)
 		require.NoError(t, err)
 	})
+	t.Run("metric attributes", func(t *testing.T) {
+		cfg := &processorConfig{
+			schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			metricAttributes: metrics.NewRequestHeaderAttributes(&filterapi.MetricsConfig{
+				RequestHeaderAttributes: []filterapi.MetricsRequestHeaderAttribute{{Header: "x-tenant-id", Attribute: "tenant.id"}},
+				MaxAttributeValues:      10,
+			}),
+		}
+		p, err := ChatCompletionProcessorFactory(nil)(cfg, map[string]string{"x-tenant-id": "a"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "a")}, p.(*chatCompletionProcessor).metricAttrs)
+	})
 }
 
 func TestChatCompletion_SelectTranslator(t *testing.T) {
 	c := &chatCompletionProcessor{}
 	t.Run("unsupported", func(t *testing.T) {
-		err := c.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"}})
 		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
 	})
 	t.Run("supported openai", func(t *testing.T) {
-		err := c.selectTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI})
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
 		require.NoError(t, err)
 		require.NotNil(t, c.translator)
 	})
 	t.Run("supported aws bedrock", func(t *testing.T) {
-		err := c.selectTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock})
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}})
 		require.NoError(t, err)
 		require.NotNil(t, c.translator)
 	})
 	t.Run("supported azure openai", func(t *testing.T) {
-		err := c.selectTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaAzureOpenAI})
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAzureOpenAI}})
+		require.NoError(t, err)
+		require.NotNil(t, c.translator)
+	})
+	t.Run("supported anthropic", func(t *testing.T) {
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic}})
+		require.NoError(t, err)
+		require.NotNil(t, c.translator)
+	})
+	t.Run("supported gcp vertex ai", func(t *testing.T) {
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaGCPVertexAI}})
 		require.NoError(t, err)
 		require.NotNil(t, c.translator)
 	})
@@ -89,7 +115,7 @@ func TestChatCompletion_ProcessResponseHeaders(t *testing.T) {
 		mt.retErr = errors.New("test error")
 		_, err := p.ProcessResponseHeaders(t.Context(), nil)
 		require.ErrorContains(t, err, "test error")
-		mm.RequireRequestFailure(t)
+		mm.RequireRequestFailureType(t, aigwerrors.TranslationError)
 	})
 	t.Run("ok/non-streaming", func(t *testing.T) {
 		inHeaders := &corev3.HeaderMap{
@@ -150,7 +176,7 @@ func TestChatCompletion_ProcessResponseBody(t *testing.T) {
 		mt.retErr = errors.New("test error")
 		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
 		require.ErrorContains(t, err, "test error")
-		mm.RequireRequestFailure(t)
+		mm.RequireRequestFailureType(t, aigwerrors.TranslationError)
 		mm.RequireTokensRecorded(t, 0)
 	})
 	t.Run("ok", func(t *testing.T) {
@@ -168,11 +194,14 @@ func TestChatCompletion_ProcessResponseBody(t *testing.T) {
 		require.NoError(t, err)
 		celProgUint, err := llmcostcel.NewProgram("uint(9999)")
 		require.NoError(t, err)
+		celProgEstimated, err := llmcostcel.NewProgram("estimated_input_tokens + output_tokens")
+		require.NoError(t, err)
 		p := &chatCompletionProcessor{
-			translator: mt,
-			logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
-			metrics:    mm,
-			stream:     true,
+			translator:           mt,
+			logger:               slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:              mm,
+			stream:               true,
+			estimatedInputTokens: 7,
 			config: &processorConfig{
 				metadataNamespace: "ai_gateway_llm_ns",
 				requestCosts: []processorConfigRequestCost{
@@ -186,6 +215,11 @@ func TestChatCompletion_ProcessResponseBody(t *testing.T) {
 						celProg:        celProgUint,
 						LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel_uint"},
 					},
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeEstimatedInputToken, MetadataKey: "estimated"}},
+					{
+						celProg:        celProgEstimated,
+						LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel_estimated"},
+					},
 				},
 			},
 		}
@@ -207,6 +241,10 @@ func TestChatCompletion_ProcessResponseBody(t *testing.T) {
 			GetStructValue().Fields["cel_int"].GetNumberValue())
 		require.Equal(t, float64(9999), md.Fields["ai_gateway_llm_ns"].
 			GetStructValue().Fields["cel_uint"].GetNumberValue())
+		require.Equal(t, float64(7), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["estimated"].GetNumberValue())
+		require.Equal(t, float64(130), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["cel_estimated"].GetNumberValue())
 	})
 }
 
@@ -228,7 +266,7 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 				}
 				_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
 				require.ErrorContains(t, err, "invalid character 'o' in literal null")
-				mm.RequireRequestFailure(t)
+				mm.RequireRequestFailureType(t, aigwerrors.ClientError)
 				mm.RequireTokensRecorded(t, 0)
 				mm.RequireSelected(t, "", "")
 				require.False(t, p.stream) // On error, stream should be false regardless of the input.
@@ -245,7 +283,7 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 				}
 				_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: bodyFromModel(t, "some-model")})
 				require.ErrorContains(t, err, "failed to calculate route: test error")
-				mm.RequireRequestFailure(t)
+				mm.RequireRequestFailureType(t, aigwerrors.Other)
 				mm.RequireTokensRecorded(t, 0)
 				mm.RequireSelected(t, "some-model", "")
 				require.False(t, p.stream) // On error, stream should be false regardless of the input.
@@ -266,8 +304,9 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 				ir := resp.GetImmediateResponse()
 				require.NotNil(t, ir)
 				require.Equal(t, typev3.StatusCode_NotFound, ir.GetStatus().GetCode())
-				require.Equal(t, x.ErrNoMatchingRule.Error(), string(ir.GetBody()))
-				mm.RequireRequestFailure(t)
+				require.JSONEq(t, `{"type":"error","error":{"type":"not_found_error","code":"model_not_found","message":"no matching rule found"}}`,
+					string(ir.GetBody()))
+				mm.RequireRequestFailureType(t, aigwerrors.ClientError)
 				mm.RequireTokensRecorded(t, 0)
 				mm.RequireSelected(t, "some-model", "")
 				require.False(t, p.stream) // On error, stream should be false regardless of the input.
@@ -312,7 +351,7 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 				}
 				_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
 				require.ErrorContains(t, err, "failed to transform request: test error")
-				mm.RequireRequestFailure(t)
+				mm.RequireRequestFailureType(t, aigwerrors.TranslationError)
 				mm.RequireTokensRecorded(t, 0)
 				mm.RequireSelected(t, "some-model", "some-backend")
 				require.False(t, p.stream) // On error, stream should be false regardless of the input.
@@ -321,17 +360,25 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 				for _, tc := range []struct {
 					name  string
 					dynlb *mockDynamicLB
+					// clusterName is the ORIGINAL_DST cluster name of the dynamic load balancing.
+					clusterName string
+					// expSelectedBackend is the expected selected backend header value.
+					expSelectedBackend string
 				}{
-					{name: "no-dynlb"},
+					{name: "no-dynlb", expSelectedBackend: "some-backend"},
 					{name: "dynlb", dynlb: &mockDynamicLB{
 						backedName: "some-backend",
 						headers:    []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "foo", Value: "bar"}}},
-					}},
+					}, expSelectedBackend: "original_destination_cluster"},
+					{name: "dynlb with tls", dynlb: &mockDynamicLB{
+						backedName: "some-backend",
+						headers:    []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "foo", Value: "bar"}}},
+					}, clusterName: "original_destination_cluster_tls", expSelectedBackend: "original_destination_cluster_tls"},
 				} {
 					t.Run(tc.name, func(t *testing.T) {
 						someBody := bodyFromModel(t, "some-model")
 						headers := map[string]string{":path": "/foo"}
-						dynLb := &filterapi.DynamicLoadBalancing{}
+						dynLb := &filterapi.DynamicLoadBalancing{ClusterName: tc.clusterName}
 						rt := mockRouter{
 							t: t, expHeaders: headers, retBackendName: "some-backend",
 							retVersionedAPISchema: filterapi.VersionedAPISchema{Name: "some-schema", Version: "v10.0"},
@@ -378,7 +425,7 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 							require.Equal(t, "x-ai-gateway-model-key", hdrs[0].Header.Key)
 							require.Equal(t, "some-model", string(hdrs[0].Header.RawValue))
 							require.Equal(t, "x-ai-gateway-backend-key", hdrs[1].Header.Key)
-							require.Equal(t, "original_destination_cluster", string(hdrs[1].Header.RawValue))
+							require.Equal(t, tc.expSelectedBackend, string(hdrs[1].Header.RawValue))
 							require.Equal(t, "foo", hdrs[2].Header.Key)
 							require.Equal(t, "bar", hdrs[2].Header.Value)
 						} else {
@@ -386,12 +433,145 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 							require.Equal(t, "x-ai-gateway-model-key", hdrs[0].Header.Key)
 							require.Equal(t, "some-model", string(hdrs[0].Header.RawValue))
 							require.Equal(t, "x-ai-gateway-backend-key", hdrs[1].Header.Key)
-							require.Equal(t, "some-backend", string(hdrs[1].Header.RawValue))
+							require.Equal(t, tc.expSelectedBackend, string(hdrs[1].Header.RawValue))
 						}
 						require.Equal(t, stream, p.stream)
 					})
 				}
 			})
+			t.Run("estimated input tokens", func(t *testing.T) {
+				someBody := []byte(`{"model":"some-model","messages":[{"role":"user","content":"hello world"}]}`)
+				headers := map[string]string{":path": "/foo"}
+				var expBody openai.ChatCompletionRequest
+				require.NoError(t, json.Unmarshal(someBody, &expBody))
+				p := &chatCompletionProcessor{
+					config: &processorConfig{
+						router: mockRouter{
+							t: t, expHeaders: headers, retBackendName: "some-backend",
+							retVersionedAPISchema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+						},
+						metadataNamespace: "ai_gateway_llm_ns",
+						requestCosts: []processorConfigRequestCost{
+							{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input"}},
+							{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeEstimatedInputToken, MetadataKey: "estimated"}},
+						},
+						estimateInputTokens: true,
+					},
+					requestHeaders: headers,
+					logger:         slog.Default(),
+					metrics:        &mockChatCompletionMetrics{},
+					translator:     mockTranslator{t: t, expRequestBody: &expBody},
+				}
+				resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+				require.NoError(t, err)
+				// The approximated tokens of "hello world" plus the overheads of the message and the reply.
+				require.Equal(t, uint32(2+2+3+3), p.estimatedInputTokens)
+				// Only the estimated cost is set at the request path.
+				md := resp.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+				require.Len(t, md.Fields, 1)
+				require.Equal(t, float64(10), md.Fields["estimated"].GetNumberValue())
+			})
+			t.Run("model name mapping", func(t *testing.T) {
+				someBody := bodyFromModel(t, "alias")
+				headers := map[string]string{":path": "/foo"}
+				var expBody openai.ChatCompletionRequest
+				require.NoError(t, json.Unmarshal(someBody, &expBody))
+				expBody.Model = "backend-model"
+				headerMut := &extprocv3.HeaderMutation{}
+				mt := mockTranslator{t: t, expRequestBody: &expBody, retHeaderMutation: headerMut}
+				mm := &mockChatCompletionMetrics{}
+				p := &chatCompletionProcessor{
+					config: &processorConfig{
+						router: mockRouter{
+							t: t, expHeaders: headers, retBackendName: "some-backend",
+							retVersionedAPISchema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+							retModelNameMappings:  []filterapi.ModelNameMapping{{From: "alias", To: "backend-model"}},
+						},
+						selectedBackendHeaderKey: "x-ai-gateway-backend-key",
+						modelNameHeaderKey:       "x-ai-gateway-model-key",
+					},
+					requestHeaders: headers,
+					logger:         slog.Default(),
+					metrics:        mm,
+					translator:     mt,
+				}
+				resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+				require.NoError(t, err)
+				commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestBody).RequestBody.Response
+				// The translator doesn't mutate the body, so the processor replaces it with the mapped one.
+				var sent openai.ChatCompletionRequest
+				require.NoError(t, json.Unmarshal(commonRes.BodyMutation.GetBody(), &sent))
+				require.Equal(t, "backend-model", sent.Model)
+				mm.RequireSelected(t, "backend-model", "some-backend")
+
+				hdrs := headerMut.SetHeaders
+				require.Len(t, hdrs, 3)
+				require.Equal(t, "content-length", hdrs[0].Header.Key)
+				require.Equal(t, strconv.Itoa(len(commonRes.BodyMutation.GetBody())), string(hdrs[0].Header.RawValue))
+				// The model name header still carries the name in the client request as the routing is based on it.
+				require.Equal(t, "x-ai-gateway-model-key", hdrs[1].Header.Key)
+				require.Equal(t, "alias", string(hdrs[1].Header.RawValue))
+			})
+			t.Run("dynlb target model", func(t *testing.T) {
+				someBody := bodyFromModel(t, "alias")
+				headers := map[string]string{":path": "/foo"}
+				var expBody openai.ChatCompletionRequest
+				require.NoError(t, json.Unmarshal(someBody, &expBody))
+				expBody.Model = "lora-v2"
+				dynLb := &filterapi.DynamicLoadBalancing{}
+				mm := &mockChatCompletionMetrics{}
+				p := &chatCompletionProcessor{
+					config: &processorConfig{
+						router: mockRouter{
+							t: t, expHeaders: headers, retBackendName: "some-backend",
+							retVersionedAPISchema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+							// The target model selected by the load balancer takes precedence over the mappings.
+							retModelNameMappings: []filterapi.ModelNameMapping{{From: "alias", To: "backend-model"}},
+							retBackendDynamicLB:  dynLb,
+						},
+						modelNameHeaderKey: "x-ai-gateway-model-key",
+						dynamicLoadBalancers: map[*filterapi.DynamicLoadBalancing]dynlb.DynamicLoadBalancer{
+							dynLb: &mockDynamicLB{backedName: "some-backend", targetModel: "lora-v2"},
+						},
+					},
+					requestHeaders: headers,
+					logger:         slog.Default(),
+					metrics:        mm,
+					translator:     mockTranslator{t: t, expRequestBody: &expBody},
+				}
+				resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+				require.NoError(t, err)
+				commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestBody).RequestBody.Response
+				var sent openai.ChatCompletionRequest
+				require.NoError(t, json.Unmarshal(commonRes.BodyMutation.GetBody(), &sent))
+				require.Equal(t, "lora-v2", sent.Model)
+				mm.RequireSelected(t, "lora-v2", "some-backend")
+			})
+			t.Run("dynlb saturated", func(t *testing.T) {
+				headers := map[string]string{":path": "/foo"}
+				dynLb := &filterapi.DynamicLoadBalancing{}
+				mm := &mockChatCompletionMetrics{}
+				p := &chatCompletionProcessor{
+					config: &processorConfig{
+						router: mockRouter{
+							t: t, expHeaders: headers, retBackendName: "some-backend",
+							retVersionedAPISchema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+							retBackendDynamicLB:   dynLb,
+						},
+						dynamicLoadBalancers: map[*filterapi.DynamicLoadBalancing]dynlb.DynamicLoadBalancer{
+							dynLb: &mockDynamicLB{err: aigwerrors.Wrap(aigwerrors.RateLimited, dynlb.ErrSaturated)},
+						},
+					},
+					requestHeaders: headers,
+					logger:         slog.Default(),
+					metrics:        mm,
+				}
+				_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: bodyFromModel(t, "some-model")})
+				require.ErrorIs(t, err, dynlb.ErrSaturated)
+				// The sheddable request is rejected with 429 to the client.
+				require.Equal(t, aigwerrors.RateLimited, aigwerrors.TypeOf(err))
+				mm.RequireRequestFailureType(t, aigwerrors.RateLimited)
+			})
 		})
 	}
 }
@@ -0,0 +1,68 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/circuitbreaker"
+)
+
+// circuitBreakerCall is a request admitted by the circuit breaker of the backend it is sent to.
+type circuitBreakerCall struct {
+	backend string
+	breaker *circuitbreaker.Breaker
+	ticket  circuitbreaker.Ticket
+	// metrics records the transitions of the breaker. This can be nil, in which case they are only logged.
+	metrics x.CircuitBreakerMetrics
+	logger  *slog.Logger
+}
+
+// admitCircuitBreaker admits the request to the backend selected from the rules of the config. This returns nil if
+// the backend has no circuit breaker, and the result of the returned call must be passed to its done method.
+// The transitions are recorded to the metrics if it implements [x.CircuitBreakerMetrics].
+func admitCircuitBreaker(ctx context.Context, config *processorConfig, b *filterapi.Backend,
+	metrics any, logger *slog.Logger,
+) *circuitBreakerCall {
+	breaker := config.circuitBreakers[b]
+	if breaker == nil {
+		return nil
+	}
+	c := &circuitBreakerCall{backend: b.Name, breaker: breaker, logger: logger}
+	c.metrics, _ = metrics.(x.CircuitBreakerMetrics)
+	var t *circuitbreaker.Transition
+	c.ticket, t = breaker.Admit()
+	c.record(ctx, t)
+	return c
+}
+
+// done records the result of the call, where err is the error of the request sent without response. The 429 and
+// 5xx responses count as failures, and the cancelled request is not counted. This is a no-op on the nil call.
+func (c *circuitBreakerCall) done(ctx context.Context, status int, err error) {
+	if c == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		c.breaker.Cancel(c.ticket)
+		return
+	}
+	c.record(ctx, c.breaker.Done(c.ticket, err == nil && status != 429 && status < 500))
+}
+
+// record logs and records the transition of the breaker if any.
+func (c *circuitBreakerCall) record(ctx context.Context, t *circuitbreaker.Transition) {
+	if t == nil {
+		return
+	}
+	c.logger.Info("circuit breaker state changed", "backend", c.backend, "from", t.From, "to", t.To)
+	if c.metrics != nil {
+		c.metrics.RecordCircuitBreakerTransition(ctx, c.backend, t.From, t.To)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package circuitbreaker implements the circuit breakers of the backends, which exclude the failing backends
+// from the routing for a while.
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+// Breaker is the circuit breaker of a backend. This is goroutine-safe.
+//
+// The circuit is closed initially, and opens when the backend fails consecutively or its error rate exceeds
+// the threshold. Once the open duration elapses, the circuit becomes half-open and the limited number of the
+// probe requests are admitted. The circuit closes when all the probes succeed, and opens again when any fails.
+type Breaker struct {
+	policy filterapi.CircuitBreakerPolicy
+	// now returns the current time, which is replaced in the tests.
+	now func() time.Time
+
+	mu    sync.Mutex
+	state x.CircuitBreakerState
+	// generation is incremented on every change of the state so that the responses to the requests admitted
+	// in the previous state are ignored.
+	generation uint64
+	// changedAt is when the state changed last.
+	changedAt time.Time
+	// consecutiveFailures is the number of the consecutive failures while closed.
+	consecutiveFailures int
+	// windowStart, windowRequests and windowFailures are the tumbling window of the error rate while closed.
+	windowStart                    time.Time
+	windowRequests, windowFailures int
+	// probes and probeSuccesses are the number of the probe requests admitted and succeeded while half-open.
+	probes, probeSuccesses int
+}
+
+// Ticket is issued to a request admitted to the backend, and is passed back to [Breaker.Done] with the result.
+type Ticket struct {
+	generation uint64
+	start      time.Time
+	// probe is true if the request is a probe of the half-open circuit.
+	probe bool
+}
+
+// Transition is the change of the state of a circuit.
+type Transition struct {
+	From, To x.CircuitBreakerState
+}
+
+// New creates a new closed circuit breaker for the policy.
+func New(policy *filterapi.CircuitBreakerPolicy) *Breaker {
+	b := &Breaker{policy: *policy, now: time.Now, state: x.CircuitBreakerStateClosed}
+	b.changedAt = b.now()
+	b.windowStart = b.changedAt
+	return b
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() x.CircuitBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Available returns true if a request can be admitted to the backend without waiting for the circuit, i.e.
+// the circuit is closed, the open duration has elapsed, or a probe can be admitted while half-open.
+// This does not change the state, so the router can check all the backends of a rule.
+func (b *Breaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case x.CircuitBreakerStateOpen:
+		return b.now().Sub(b.changedAt) >= b.policy.OpenDuration
+	case x.CircuitBreakerStateHalfOpen:
+		return b.probes < b.policy.HalfOpenRequests || b.stuck()
+	default:
+		return true
+	}
+}
+
+// stuck returns true if the half-open circuit has not completed the probes within the open duration, e.g. because
+// their responses never arrived. Such probes are abandoned. This must be called with the lock held.
+func (b *Breaker) stuck() bool {
+	return b.now().Sub(b.changedAt) >= b.policy.OpenDuration
+}
+
+// Admit admits a request to the backend, and returns the ticket to be passed to [Breaker.Done] with the result.
+// When the open duration has elapsed, the circuit becomes half-open and the transition is returned. Otherwise,
+// the returned transition is nil.
+//
+// The request can be admitted even when the circuit is open since the router selects the backend regardless of
+// the circuits if all of them are open, but the result of such a request is ignored.
+func (b *Breaker) Admit() (Ticket, *Transition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var transition *Transition
+	switch b.state {
+	case x.CircuitBreakerStateOpen:
+		if b.now().Sub(b.changedAt) < b.policy.OpenDuration {
+			break
+		}
+		transition = b.transition(x.CircuitBreakerStateHalfOpen)
+		fallthrough
+	case x.CircuitBreakerStateHalfOpen:
+		if b.probes >= b.policy.HalfOpenRequests {
+			if !b.stuck() {
+				break
+			}
+			// Restart the probes in the new generation so that the abandoned ones are ignored.
+			b.generation++
+			b.changedAt = b.now()
+			b.probes, b.probeSuccesses = 0, 0
+		}
+		b.probes++
+		return Ticket{generation: b.generation, start: b.now(), probe: true}, transition
+	}
+	return Ticket{generation: b.generation, start: b.now()}, transition
+}
+
+// Done records the result of the request admitted with the ticket. The response slower than the threshold of the
+// policy counts as a failure regardless of success. The transition is returned if the state has changed.
+func (b *Breaker) Done(t Ticket, success bool) *Transition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.generation != b.generation {
+		return nil
+	}
+	now := b.now()
+	if threshold := b.policy.SlowResponseThreshold; threshold > 0 && now.Sub(t.start) >= threshold {
+		success = false
+	}
+	switch b.state {
+	case x.CircuitBreakerStateClosed:
+		if now.Sub(b.windowStart) >= b.policy.Interval {
+			b.windowStart, b.windowRequests, b.windowFailures = now, 0, 0
+		}
+		b.windowRequests++
+		if success {
+			b.consecutiveFailures = 0
+			return nil
+		}
+		b.consecutiveFailures++
+		b.windowFailures++
+		if (b.policy.ConsecutiveFailures > 0 && b.consecutiveFailures >= b.policy.ConsecutiveFailures) ||
+			(b.policy.ErrorRatePercentage > 0 && b.windowRequests >= b.policy.MinimumRequests &&
+				b.windowFailures*100 >= b.policy.ErrorRatePercentage*b.windowRequests) {
+			return b.transition(x.CircuitBreakerStateOpen)
+		}
+	case x.CircuitBreakerStateHalfOpen:
+		if !t.probe {
+			return nil
+		}
+		if !success {
+			return b.transition(x.CircuitBreakerStateOpen)
+		}
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.policy.HalfOpenRequests {
+			return b.transition(x.CircuitBreakerStateClosed)
+		}
+	}
+	return nil
+}
+
+// Cancel releases the ticket of the request cancelled before its result is known, e.g. the loser of the hedging,
+// so that it neither counts as a success nor a failure.
+func (b *Breaker) Cancel(t Ticket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.generation == b.generation && t.probe && b.state == x.CircuitBreakerStateHalfOpen {
+		b.probes--
+	}
+}
+
+// transition changes the state and resets the counters. This must be called with the lock held.
+func (b *Breaker) transition(to x.CircuitBreakerState) *Transition {
+	t := &Transition{From: b.state, To: to}
+	b.state = to
+	b.generation++
+	b.changedAt = b.now()
+	b.consecutiveFailures = 0
+	b.windowStart, b.windowRequests, b.windowFailures = b.changedAt, 0, 0
+	b.probes, b.probeSuccesses = 0, 0
+	return t
+}
@@ -0,0 +1,171 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+// newTestBreaker creates a breaker whose clock is advanced by the returned function.
+func newTestBreaker(policy filterapi.CircuitBreakerPolicy) (*Breaker, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := New(&policy)
+	b.now = func() time.Time { return now }
+	b.changedAt, b.windowStart = now, now
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+// call admits a request and records its result after the latency.
+func call(b *Breaker, advance func(time.Duration), latency time.Duration, success bool) (admitted, done *Transition) {
+	t, admitted := b.Admit()
+	advance(latency)
+	return admitted, b.Done(t, success)
+}
+
+var defaultPolicy = filterapi.CircuitBreakerPolicy{
+	ConsecutiveFailures: 3,
+	MinimumRequests:     10,
+	Interval:            10 * time.Second,
+	OpenDuration:        30 * time.Second,
+	HalfOpenRequests:    2,
+}
+
+func TestBreaker_consecutiveFailures(t *testing.T) {
+	b, advance := newTestBreaker(defaultPolicy)
+	for range 2 {
+		_, tr := call(b, advance, 0, false)
+		require.Nil(t, tr)
+	}
+	// A success resets the consecutive failures.
+	_, tr := call(b, advance, 0, true)
+	require.Nil(t, tr)
+	for range 2 {
+		_, tr = call(b, advance, 0, false)
+		require.Nil(t, tr)
+	}
+	require.True(t, b.Available())
+	_, tr = call(b, advance, 0, false)
+	require.Equal(t, &Transition{From: x.CircuitBreakerStateClosed, To: x.CircuitBreakerStateOpen}, tr)
+	require.Equal(t, x.CircuitBreakerStateOpen, b.State())
+	require.False(t, b.Available())
+}
+
+func TestBreaker_errorRate(t *testing.T) {
+	policy := defaultPolicy
+	policy.ConsecutiveFailures = 0
+	policy.ErrorRatePercentage = 50
+	b, advance := newTestBreaker(policy)
+
+	// The failures in the previous window are not counted.
+	for range 9 {
+		_, tr := call(b, advance, 0, false)
+		require.Nil(t, tr)
+	}
+	advance(10 * time.Second)
+	for range 5 {
+		_, tr := call(b, advance, 0, true)
+		require.Nil(t, tr)
+	}
+	for range 4 {
+		_, tr := call(b, advance, 0, false)
+		require.Nil(t, tr)
+	}
+	// The 10th request reaches the minimum with 50% of the failures.
+	_, tr := call(b, advance, 0, false)
+	require.Equal(t, &Transition{From: x.CircuitBreakerStateClosed, To: x.CircuitBreakerStateOpen}, tr)
+}
+
+func TestBreaker_slowResponse(t *testing.T) {
+	policy := defaultPolicy
+	policy.ConsecutiveFailures = 1
+	policy.SlowResponseThreshold = time.Second
+	b, advance := newTestBreaker(policy)
+
+	_, tr := call(b, advance, 999*time.Millisecond, true)
+	require.Nil(t, tr)
+	_, tr = call(b, advance, time.Second, true)
+	require.Equal(t, &Transition{From: x.CircuitBreakerStateClosed, To: x.CircuitBreakerStateOpen}, tr)
+}
+
+func TestBreaker_halfOpen(t *testing.T) {
+	open := func(t *testing.T) (*Breaker, func(time.Duration)) {
+		b, advance := newTestBreaker(defaultPolicy)
+		for range 3 {
+			call(b, advance, 0, false)
+		}
+		require.Equal(t, x.CircuitBreakerStateOpen, b.State())
+		return b, advance
+	}
+
+	t.Run("close", func(t *testing.T) {
+		b, advance := open(t)
+		// The requests admitted while open, e.g. when all the backends are open, are ignored.
+		ticket, tr := b.Admit()
+		require.Nil(t, tr)
+		require.Nil(t, b.Done(ticket, true))
+
+		advance(30 * time.Second)
+		require.True(t, b.Available())
+		probe1, tr := b.Admit()
+		require.Equal(t, &Transition{From: x.CircuitBreakerStateOpen, To: x.CircuitBreakerStateHalfOpen}, tr)
+		require.True(t, b.Available())
+		probe2, tr := b.Admit()
+		require.Nil(t, tr)
+		// No more probes are admitted until they complete.
+		require.False(t, b.Available())
+		ticket, _ = b.Admit()
+		require.Nil(t, b.Done(ticket, false))
+
+		require.Nil(t, b.Done(probe1, true))
+		require.Equal(t, &Transition{From: x.CircuitBreakerStateHalfOpen, To: x.CircuitBreakerStateClosed}, b.Done(probe2, true))
+		require.True(t, b.Available())
+	})
+	t.Run("reopen", func(t *testing.T) {
+		b, advance := open(t)
+		advance(30 * time.Second)
+		probe1, _ := b.Admit()
+		probe2, _ := b.Admit()
+		require.Equal(t, &Transition{From: x.CircuitBreakerStateHalfOpen, To: x.CircuitBreakerStateOpen}, b.Done(probe1, false))
+		require.False(t, b.Available())
+		// The result of the probe of the previous half-open state is ignored.
+		require.Nil(t, b.Done(probe2, true))
+		require.Equal(t, x.CircuitBreakerStateOpen, b.State())
+	})
+	t.Run("cancel", func(t *testing.T) {
+		b, advance := open(t)
+		advance(30 * time.Second)
+		probe1, _ := b.Admit()
+		probe2, _ := b.Admit()
+		require.False(t, b.Available())
+		b.Cancel(probe1)
+		require.True(t, b.Available())
+		probe3, _ := b.Admit()
+		require.Nil(t, b.Done(probe2, true))
+		require.Equal(t, &Transition{From: x.CircuitBreakerStateHalfOpen, To: x.CircuitBreakerStateClosed}, b.Done(probe3, true))
+	})
+	t.Run("stuck", func(t *testing.T) {
+		b, advance := open(t)
+		advance(30 * time.Second)
+		abandoned, _ := b.Admit()
+		b.Admit()
+		require.False(t, b.Available())
+		// The probes that never complete are abandoned after the open duration.
+		advance(30 * time.Second)
+		require.True(t, b.Available())
+		probe1, tr := b.Admit()
+		require.Nil(t, tr)
+		probe2, _ := b.Admit()
+		require.Nil(t, b.Done(abandoned, false))
+		require.Nil(t, b.Done(probe1, true))
+		require.Equal(t, &Transition{From: x.CircuitBreakerStateHalfOpen, To: x.CircuitBreakerStateClosed}, b.Done(probe2, true))
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package dynlb
+
+import (
+	"cmp"
+	"encoding/json"
+	"hash"
+	"hash/fnv"
+	"slices"
+	"strconv"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/hashing"
+)
+
+const (
+	// hashRingReplicas is the number of the points of each endpoint in the hash ring. The more points spread the
+	// sessions more evenly across the endpoints.
+	hashRingReplicas = 100
+	// minSessionCapacity is the number of the outstanding requests below which an endpoint is never regarded as
+	// overloaded. The model servers process many requests concurrently in a batch, so the bound relative to the
+	// average would break the affinity needlessly while the load is low.
+	minSessionCapacity = 8
+)
+
+// hashRing is the consistent hash ring of the endpoints used by the session affinity.
+type hashRing struct {
+	// points are the points of the endpoints sorted by the hash.
+	points []hashRingPoint
+	// endpoints are the distinct endpoints in the ring.
+	endpoints []*endpoint
+}
+
+// hashRingPoint is a point of an endpoint in the hash ring.
+type hashRingPoint struct {
+	hash     uint64
+	endpoint *endpoint
+}
+
+// newHashRing creates the hash ring of the endpoints. The points only depend on the endpoints themselves, so the
+// rings of all the filter replicas map a session to the same endpoint, and adding or removing an endpoint only moves
+// the sessions of the points next to its points.
+func newHashRing(endpoints []endpoint) *hashRing {
+	r := &hashRing{
+		points:    make([]hashRingPoint, 0, len(endpoints)*hashRingReplicas),
+		endpoints: make([]*endpoint, len(endpoints)),
+	}
+	for i := range endpoints {
+		ep := &endpoints[i]
+		r.endpoints[i] = ep
+		for j := range hashRingReplicas {
+			h := fnv.New64a()
+			_, _ = h.Write(ep.ipPort)
+			_, _ = h.Write([]byte{0})
+			_, _ = h.Write([]byte(ep.backend.Name))
+			_, _ = h.Write([]byte{0})
+			_, _ = h.Write(strconv.AppendInt(nil, int64(j), 10))
+			r.points = append(r.points, hashRingPoint{hash: hashing.Mix64(h.Sum64()), endpoint: ep})
+		}
+	}
+	slices.SortFunc(r.points, func(a, b hashRingPoint) int { return cmp.Compare(a.hash, b.hash) })
+	return r
+}
+
+// selectEndpoint selects the endpoint of the session by the consistent hashing with bounded loads: the first
+// endpoint clockwise from the point of the session whose outstanding requests are below the capacity, which is
+// maxLoadPercentage of the average including this request but at least minSessionCapacity. Since the average is
+// below the capacity, such an endpoint always exists.
+//
+// See: https://arxiv.org/abs/1608.01350
+func (r *hashRing) selectEndpoint(session uint64, maxLoadPercentage int) *endpoint {
+	if len(r.points) == 0 {
+		return nil
+	}
+	var total int64
+	for _, ep := range r.endpoints {
+		total += ep.stats.outstanding.Load()
+	}
+	// capacity = ceil(maxLoadPercentage/100 * (total+1) / len(endpoints)).
+	denominator := int64(100 * len(r.endpoints))
+	capacity := max((int64(maxLoadPercentage)*(total+1)+denominator-1)/denominator, minSessionCapacity)
+
+	start, _ := slices.BinarySearchFunc(r.points, session, func(p hashRingPoint, h uint64) int { return cmp.Compare(p.hash, h) })
+	var fallback *endpoint
+	for i := range r.points {
+		ep := r.points[(start+i)%len(r.points)].endpoint
+		if ep.stats.outstanding.Load() < capacity {
+			return ep
+		}
+		if fallback == nil {
+			fallback = ep
+		}
+	}
+	// The loads have changed concurrently while walking the ring.
+	return fallback
+}
+
+// sessionHash returns the hash identifying the session of the chat completion request, which is the value of the
+// session header if present, or the model and the leading messages up to and including the first user message.
+// This returns false if the session cannot be identified, i.e. the request has neither of them.
+func sessionHash(affinity *filterapi.DynamicLoadBalancingSessionAffinity, model string, headers map[string]string,
+	body *openai.ChatCompletionRequest,
+) (uint64, bool) {
+	h := fnv.New64a()
+	writeString(h, model)
+	if name := affinity.HeaderName; name != "" {
+		if v := headers[name]; v != "" {
+			writeString(h, "header")
+			writeString(h, v)
+			return hashing.Mix64(h.Sum64()), true
+		}
+	}
+	if body == nil {
+		return 0, false
+	}
+	writeString(h, "messages")
+	for i := range body.Messages {
+		m := &body.Messages[i]
+		raw, err := json.Marshal(m.Value)
+		if err != nil {
+			return 0, false
+		}
+		writeString(h, m.Type)
+		_, _ = h.Write(raw)
+		if m.Type == openai.ChatMessageRoleUser {
+			return hashing.Mix64(h.Sum64()), true
+		}
+	}
+	return 0, false
+}
+
+// writeString writes the string followed by the separator to the hash.
+func writeString(h hash.Hash64, s string) {
+	_, _ = h.Write([]byte(s))
+	_, _ = h.Write([]byte{0})
+}
@@ -0,0 +1,181 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package dynlb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/hashing"
+)
+
+// newChatRequest creates a chat completion request with the messages in the JSON.
+func newChatRequest(t *testing.T, messages string) *openai.ChatCompletionRequest {
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"model":"foo","messages":`+messages+`}`), &req))
+	return &req
+}
+
+// newTestEndpoints creates n endpoints with distinct addresses.
+func newTestEndpoints(n int) []endpoint {
+	b := &filterapi.Backend{Name: "foo"}
+	endpoints := make([]endpoint, n)
+	for i := range endpoints {
+		endpoints[i] = endpoint{ipPort: fmt.Appendf(nil, "10.0.0.%d:8080", i), backend: b, stats: &endpointStats{}}
+	}
+	return endpoints
+}
+
+func Test_sessionHash(t *testing.T) {
+	affinity := &filterapi.DynamicLoadBalancingSessionAffinity{HeaderName: "x-session-id", MaxLoadPercentage: 125}
+	first := newChatRequest(t, `[{"role":"system","content":"be nice"},{"role":"user","content":"hi"}]`)
+	h, ok := sessionHash(affinity, "foo", nil, first)
+	require.True(t, ok)
+
+	// The following turns of the conversation are in the same session.
+	next := newChatRequest(t, `[{"role":"system","content":"be nice"},{"role":"user","content":"hi"},
+		{"role":"assistant","content":"hello"},{"role":"user","content":"how are you?"}]`)
+	nextHash, ok := sessionHash(affinity, "foo", nil, next)
+	require.True(t, ok)
+	require.Equal(t, h, nextHash)
+
+	// The different system prompt, first user message or model is a different session.
+	for _, tc := range []struct {
+		model, messages string
+	}{
+		{"foo", `[{"role":"system","content":"be rude"},{"role":"user","content":"hi"}]`},
+		{"foo", `[{"role":"system","content":"be nice"},{"role":"user","content":"hey"}]`},
+		{"bar", `[{"role":"system","content":"be nice"},{"role":"user","content":"hi"}]`},
+	} {
+		other, ok := sessionHash(affinity, tc.model, nil, newChatRequest(t, tc.messages))
+		require.True(t, ok)
+		require.NotEqual(t, h, other)
+	}
+
+	// The session header takes precedence over the messages.
+	h1, ok := sessionHash(affinity, "foo", map[string]string{"x-session-id": "abc"}, first)
+	require.True(t, ok)
+	h2, ok := sessionHash(affinity, "foo", map[string]string{"x-session-id": "abc"}, next)
+	require.True(t, ok)
+	require.Equal(t, h1, h2)
+	require.NotEqual(t, h, h1)
+	h3, ok := sessionHash(affinity, "foo", map[string]string{"x-session-id": "def"}, first)
+	require.True(t, ok)
+	require.NotEqual(t, h1, h3)
+
+	// The session cannot be identified without any user message.
+	_, ok = sessionHash(affinity, "foo", nil, newChatRequest(t, `[{"role":"system","content":"be nice"}]`))
+	require.False(t, ok)
+	_, ok = sessionHash(affinity, "foo", nil, nil)
+	require.False(t, ok)
+}
+
+func Test_hashRing(t *testing.T) {
+	endpoints := newTestEndpoints(5)
+	r := newHashRing(endpoints)
+	require.Len(t, r.points, 5*hashRingReplicas)
+
+	counts := make(map[*endpoint]int)
+	assigned := make(map[uint64]string)
+	for i := range 5000 {
+		session := hashing.Mix64(uint64(i)) // nolint:gosec
+		ep := r.selectEndpoint(session, 125)
+		counts[ep]++
+		assigned[session] = string(ep.ipPort)
+		// The same session is mapped to the same endpoint.
+		require.Same(t, ep, r.selectEndpoint(session, 125))
+	}
+	// The sessions are spread over the endpoints.
+	require.Len(t, counts, 5)
+	for _, c := range counts {
+		require.InDelta(t, 1000, c, 300)
+	}
+
+	// Removing an endpoint only moves its sessions, and the new ring of the same endpoints is the same.
+	r = newHashRing(newTestEndpoints(5)[:4])
+	var moved int
+	for session, ipPort := range assigned {
+		if got := string(r.selectEndpoint(session, 125).ipPort); got != ipPort {
+			require.Equal(t, "10.0.0.4:8080", ipPort)
+			moved++
+		}
+	}
+	require.Equal(t, counts[&endpoints[4]], moved)
+}
+
+func Test_hashRing_boundedLoad(t *testing.T) {
+	endpoints := newTestEndpoints(4)
+	r := newHashRing(endpoints)
+	session := hashing.Mix64(42)
+	target := r.selectEndpoint(session, 125)
+
+	// The endpoint with fewer outstanding requests than the minimum capacity is never overloaded.
+	target.stats.outstanding.Store(minSessionCapacity - 1)
+	require.Same(t, target, r.selectEndpoint(session, 125))
+
+	// The overloaded target is skipped, and the next endpoint in the ring takes the session.
+	target.stats.outstanding.Store(minSessionCapacity)
+	next := r.selectEndpoint(session, 125)
+	require.NotSame(t, target, next)
+	require.Same(t, next, r.selectEndpoint(session, 125))
+	// The session goes back once the target is no longer overloaded.
+	target.stats.outstanding.Store(0)
+	require.Same(t, target, r.selectEndpoint(session, 125))
+
+	// Under the high load, the capacity is relative to the average: ceil(1.25 * (20*3+24+1) / 4) = 27.
+	for i := range endpoints {
+		endpoints[i].stats.outstanding.Store(20)
+	}
+	target.stats.outstanding.Store(24)
+	require.Same(t, target, r.selectEndpoint(session, 125))
+	// ceil(1.25 * (20*3+27+1) / 4) = 28.
+	target.stats.outstanding.Store(27)
+	require.Same(t, target, r.selectEndpoint(session, 125))
+	// ceil(1.25 * (20*3+40+1) / 4) = 32.
+	target.stats.outstanding.Store(40)
+	require.NotSame(t, target, r.selectEndpoint(session, 125))
+
+	require.Nil(t, newHashRing(nil).selectEndpoint(session, 125))
+}
+
+func TestDynamicLoadBalancingSelectChatCompletionsEndpoint_sessionAffinity(t *testing.T) {
+	dlb := &dynamicLoadBalancer{
+		logger:   slog.Default(),
+		policy:   filterapi.DynamicLoadBalancingPolicyLeastRequest,
+		models:   map[string]filterapi.DynamicLoadBalancingModel{"foo": {}},
+		affinity: &filterapi.DynamicLoadBalancingSessionAffinity{MaxLoadPercentage: 200},
+	}
+	dlb.swapEndpoints(newTestEndpoints(8))
+	require.NotNil(t, dlb.ring.Load())
+	selectEndpoint := func(ctx context.Context, messages string) string {
+		_, _, headers, err := dlb.SelectChatCompletionsEndpoint(ctx, "foo", nil, newChatRequest(t, messages), nil)
+		require.NoError(t, err)
+		return string(headers[0].Header.RawValue)
+	}
+
+	// The turns of the same conversation go to the same endpoint even while the previous ones are outstanding,
+	// which the least request policy would avoid.
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	first := selectEndpoint(ctx, `[{"role":"user","content":"hi"}]`)
+	for _, messages := range []string{
+		`[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"bye"}]`,
+		`[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"wait"}]`,
+	} {
+		require.Equal(t, first, selectEndpoint(ctx, messages))
+	}
+
+	// The swapped endpoints carry the ring of them.
+	dlb.swapEndpoints(newTestEndpoints(2))
+	require.Len(t, dlb.ring.Load().endpoints, 2)
+}
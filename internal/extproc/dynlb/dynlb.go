@@ -9,22 +9,34 @@ package dynlb
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"math/rand"
+	"net"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	"github.com/miekg/dns"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 )
 
-// originalDstHeaderName is the header name that will be used to pass the original destination endpoint in the form of "ip:port".
-const originalDstHeaderName = "x-ai-eg-original-dst"
+const (
+	// originalDstHeaderName is the header name that will be used to pass the original destination endpoint in the form of "ip:port".
+	originalDstHeaderName = "x-ai-eg-original-dst"
+	// originalHostHeaderName is the header name that will be used to pass the host of the original destination endpoint.
+	// The route to the ORIGINAL_DST cluster rewrites the Host header to its value, which is also used as the SNI with TLS.
+	originalHostHeaderName = "x-ai-eg-original-host"
+)
 
 var dnsServerEndpoint = func() string {
 	if v := os.Getenv("DNS_SERVER_ADDR"); v != "" {
@@ -50,15 +62,31 @@ type DynamicLoadBalancer interface {
 	// The selection result is reflected in the headers to be added to the request, returned as a slice of HeaderValueOption.
 	//
 	// This also returns the selected backend filterapi.Backend to perform per-Backend level operations such rate limiting.
-	SelectChatCompletionsEndpoint(model string, _ x.ChatCompletionMetrics) (
-		selected *filterapi.Backend, headers []*corev3.HeaderValueOption, err error,
-	)
+	//
+	// The selected endpoint is regarded as serving the request until the given context is done, which is
+	// the lifetime of the external processing stream of the request.
+	//
+	// The request headers and body are used to identify the session of the request when the session affinity is
+	// configured. The body can be nil.
+	//
+	// The returned targetModel is the model name that the request must be sent with, which is selected from the
+	// target models of the requested model, e.g. a LoRA adapter. This is the requested model if it has none.
+	//
+	// The sheddable requests are rejected with the error classified as [aigwerrors.RateLimited] wrapping
+	// [ErrSaturated] when none of the endpoints has the capacity.
+	SelectChatCompletionsEndpoint(ctx context.Context, model string, requestHeaders map[string]string,
+		body *openai.ChatCompletionRequest, _ x.ChatCompletionMetrics,
+	) (selected *filterapi.Backend, targetModel string, headers []*corev3.HeaderValueOption, err error)
 }
 
+// ErrSaturated is the error returned when the sheddable request is rejected because all the endpoints are saturated.
+var ErrSaturated = errors.New("all the endpoints are saturated")
+
 // NewDynamicLoadBalancer returns a new implementation of the DynamicLoadBalancer interface.
 //
 // This is called asynchronously by the config watcher, not on the hot path. The returned DynamicLoadBalancer
-// will be reused for multiple requests/goroutines.
+// will be reused for multiple requests/goroutines. The endpoints are resolved and their metrics are scraped in the
+// background until the given context is done.
 func NewDynamicLoadBalancer(ctx context.Context, logger *slog.Logger, dyn *filterapi.DynamicLoadBalancing) (DynamicLoadBalancer, error) {
 	return newDynamicLoadBalancer(ctx, logger, dyn, dnsServerEndpoint)
 }
@@ -66,66 +94,94 @@ func NewDynamicLoadBalancer(ctx context.Context, logger *slog.Logger, dyn *filte
 // dynamicLoadBalancer implements NewDynamicLoadBalancer but decoupled for testing.
 func newDynamicLoadBalancer(ctx context.Context, logger *slog.Logger, dyn *filterapi.DynamicLoadBalancing, dnsServerAddr string) (DynamicLoadBalancer, error) {
 	ret := &dynamicLoadBalancer{
-		logger: logger,
-		models: make(map[string]filterapi.DynamicLoadBalancingModel, len(dyn.Models)),
+		logger:        logger,
+		policy:        dyn.Policy,
+		models:        make(map[string]filterapi.DynamicLoadBalancingModel, len(dyn.Models)),
+		backends:      dyn.Backends,
+		dnsServerAddr: dnsServerAddr,
+		affinity:      dyn.SessionAffinity,
+		tls:           dyn.TLS,
+	}
+	switch dyn.Policy {
+	case "", filterapi.DynamicLoadBalancingPolicyLeastRequest, filterapi.DynamicLoadBalancingPolicyRandom:
+	case filterapi.DynamicLoadBalancingPolicyEndpointMetrics:
+		if dyn.EndpointMetrics == nil {
+			return nil, fmt.Errorf("endpoint metrics must be configured for the %s policy", dyn.Policy)
+		}
+		var tlsConfig *tls.Config
+		if dyn.TLS {
+			var err error
+			if tlsConfig, err = newTLSConfig(dyn.CACertificatesFile); err != nil {
+				return nil, err
+			}
+		}
+		ret.scraper = newMetricsScraper(logger, dyn.EndpointMetrics, tlsConfig)
+	default:
+		return nil, fmt.Errorf("unknown load balancing policy: %s", dyn.Policy)
 	}
 
-	// TODO: maybe reuse the client for multiple queries.
-	client := dns.Client{}
-	conn, err := client.Dial(dnsServerAddr)
+	endpoints, ttl, err := ret.resolve(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to dial DNS server: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
+	ret.swapEndpoints(endpoints)
 	for _, b := range dyn.Backends {
-		for _, ip := range b.IPs {
-			ret.endpoints = append(ret.endpoints, endpoint{
-				ipPort:  []byte(fmt.Sprintf("%s:%d", ip, b.Port)),
-				backend: &b.Backend,
-			})
-		}
-		logger.Info("resolving hostnames to IP addresses", slog.String("hostnames", strings.Join(b.Hostnames, ",")))
-		// Resolves all hostnames to IP addresses.
-		for _, hostname := range b.Hostnames {
-			// Append a dot if the hostname is not fully qualified.
-			fqdn := hostname
-			if !strings.HasSuffix(fqdn, ".") {
-				fqdn += "."
-			}
-			msg := new(dns.Msg)
-			// TODO: add support for TypeAAAA for IPv6.
-			msg.SetQuestion(fqdn, dns.TypeA)
-			response, _, err := client.ExchangeWithConnContext(ctx, msg, conn)
-			if err != nil {
-				return nil, fmt.Errorf("failed to query DNS server: %w", err)
-			}
-			if response.Rcode != dns.RcodeSuccess {
-				return nil, fmt.Errorf("DNS query failed: %s", dns.RcodeToString[response.Rcode])
-			}
-
-			for _, answer := range response.Answer {
-				if aRecord, ok := answer.(*dns.A); ok {
-					logger.Info("resolved IP address", slog.String("hostname", hostname), slog.String("ip", aRecord.A.String()))
-					ret.endpoints = append(ret.endpoints, endpoint{
-						ipPort:   []byte(fmt.Sprintf("%s:%d", aRecord.A.String(), b.Port)),
-						backend:  &b.Backend,
-						hostname: hostname,
-					})
-				}
-			}
+		if len(b.Hostnames) > 0 {
+			go ret.runResolver(ctx, ttl)
+			break
 		}
 	}
 	for _, m := range dyn.Models {
+		// The saturation is only known from the endpoint metrics, which is also checked by the controller.
+		if m.Criticality == filterapi.DynamicLoadBalancingCriticalitySheddable && ret.scraper == nil {
+			return nil, fmt.Errorf("sheddable model %s requires the %s policy", m.Name, filterapi.DynamicLoadBalancingPolicyEndpointMetrics)
+		}
 		ret.models[m.Name] = m
 	}
+	if ret.scraper != nil {
+		go ret.scraper.run(ctx, func() []endpoint { return *ret.endpoints.Load() })
+	}
 	return ret, nil
 }
 
+// newTLSConfig returns the TLS config to verify the certificates of the endpoints with the CA certificates in the
+// file, or the system trust store if the file is empty.
+func newTLSConfig(caCertificatesFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caCertificatesFile == "" {
+		return config, nil
+	}
+	ca, err := os.ReadFile(caCertificatesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no CA certificate is found in %s", caCertificatesFile)
+	}
+	return config, nil
+}
+
 // dynamicLoadBalancer implements DynamicLoadBalancer.
 type dynamicLoadBalancer struct {
-	logger    *slog.Logger
-	models    map[string]filterapi.DynamicLoadBalancingModel
-	endpoints []endpoint
+	logger *slog.Logger
+	policy filterapi.DynamicLoadBalancingPolicy
+	models map[string]filterapi.DynamicLoadBalancingModel
+	// backends are the backends whose IPs and hostnames are resolved to the endpoints.
+	backends []filterapi.DynamicLoadBalancingBackend
+	// dnsServerAddr is the address of the DNS server used to resolve the hostnames.
+	dnsServerAddr string
+	// endpoints is the current set of the endpoints, which is swapped by the resolver running in the background.
+	endpoints atomic.Pointer[[]endpoint]
+	// scraper is the scraper of the endpoint metrics, which is only set for the EndpointMetrics policy.
+	scraper *metricsScraper
+	// affinity is the configuration of the session affinity. This is nil if the session affinity is disabled.
+	affinity *filterapi.DynamicLoadBalancingSessionAffinity
+	// ring is the hash ring of the current endpoints, which is swapped together with them. This is only set when
+	// the session affinity is enabled.
+	ring atomic.Pointer[hashRing]
+	// tls is true if the endpoints are connected with TLS, which changes the default port omitted from the Host header.
+	tls bool
 }
 
 // endpoint represents an endpoint, a pair of IP and port, which belongs to a backend.
@@ -135,37 +191,146 @@ type endpoint struct {
 	hostname string
 	// backend is the backend that this ip:port pair belongs to.
 	backend *filterapi.Backend
+	// stats is the load of this endpoint used by the load balancing policies.
+	stats *endpointStats
+}
+
+// endpointStats is the load of an endpoint shared by all the requests.
+type endpointStats struct {
+	// outstanding is the number of the requests sent to the endpoint by this filter which have not finished yet.
+	outstanding atomic.Int64
+	// metrics is the latest metrics scraped from the endpoint. This is nil if not scraped successfully yet.
+	metrics atomic.Pointer[endpointMetrics]
 }
 
 // SelectChatCompletionsEndpoint implements [DynamicLoadBalancer.SelectChatCompletionsEndpoint].
 //
-// TODO: expand x.ChatCompletionMetrics to add getter methods to be able to make a decision based on the metrics.
+// When the session affinity is enabled, the endpoint of the session is selected from the hash ring, and the policy
+// is only used for the requests without the session.
+//
+// The returned headers carry the ip:port pair of the selected endpoint and its host, to which the route to the
+// ORIGINAL_DST cluster rewrites the Host header.
+//
 // TODO: this might need to return dynamic metadata instead of headers.
-func (dlb *dynamicLoadBalancer) SelectChatCompletionsEndpoint(model string, _ x.ChatCompletionMetrics) (
-	selected *filterapi.Backend, headers []*corev3.HeaderValueOption, err error,
-) {
+func (dlb *dynamicLoadBalancer) SelectChatCompletionsEndpoint(ctx context.Context, model string, requestHeaders map[string]string,
+	body *openai.ChatCompletionRequest, _ x.ChatCompletionMetrics,
+) (selected *filterapi.Backend, targetModel string, headers []*corev3.HeaderValueOption, err error) {
 	m, ok := dlb.models[model]
 	if !ok {
 		err = fmt.Errorf("model %s is not found in the dynamic load balancer", model)
 		return
 	}
+	endpoints := *dlb.endpoints.Load()
+	if len(endpoints) == 0 {
+		err = fmt.Errorf("no endpoint is available in the dynamic load balancer")
+		return
+	}
+	targetModel = selectTargetModel(model, &m)
 
-	// TODO: use the filterapi.DynamicLoadBalancingModel to make a decision.
-	_ = m
-	// Pick random backend for now. TODO: use the metrics to make a decision as commented above.
-	// TODO: Use non blocking rand (if it's really random).
-	ep := dlb.endpoints[rand.Intn(len(dlb.endpoints))] // nolint:gosec
-	dlb.logger.Info("selected endpoint", slog.String("endpoint", string(ep.ipPort)))
+	// The sheddable models are only allowed with the endpoint metrics, from which the saturation is known.
+	sheddable := m.Criticality == filterapi.DynamicLoadBalancingCriticalitySheddable && dlb.scraper != nil
+	if sheddable {
+		now := dlb.scraper.now()
+		if endpoints = filterEndpointsStrict(endpoints, func(ep *endpoint) bool {
+			return dlb.hasCapacityForSheddable(ep, now)
+		}); len(endpoints) == 0 {
+			err = aigwerrors.Wrap(aigwerrors.RateLimited, fmt.Errorf("sheddable model %s is rejected: %w", model, ErrSaturated))
+			return
+		}
+	}
+
+	var ep *endpoint
+	if dlb.affinity != nil {
+		if session, ok := sessionHash(dlb.affinity, model, requestHeaders, body); ok {
+			ep = dlb.ring.Load().selectEndpoint(session, dlb.affinity.MaxLoadPercentage)
+		}
+		// The endpoint of the session without the capacity is not used for the sheddable requests.
+		if ep != nil && sheddable && !dlb.hasCapacityForSheddable(ep, dlb.scraper.now()) {
+			ep = nil
+		}
+	}
+	if ep != nil {
+		dlb.logger.Info("selected endpoint of session", slog.String("endpoint", string(ep.ipPort)))
+	} else {
+		switch dlb.policy {
+		case filterapi.DynamicLoadBalancingPolicyRandom:
+			ep = &endpoints[rand.Intn(len(endpoints))] // nolint:gosec
+		case filterapi.DynamicLoadBalancingPolicyEndpointMetrics:
+			ep = dlb.selectByMetrics(endpoints, targetModel, dlb.scraper.now())
+		default:
+			ep = leastRequest(endpoints)
+		}
+		dlb.logger.Info("selected endpoint", slog.String("endpoint", string(ep.ipPort)))
+	}
+
+	ep.stats.outstanding.Add(1)
+	context.AfterFunc(ctx, func() { ep.stats.outstanding.Add(-1) })
 
 	selected = ep.backend
+	// The host is always set so that the one in the client request is never used to rewrite the Host header.
 	headers = []*corev3.HeaderValueOption{
 		{Header: &corev3.HeaderValue{Key: originalDstHeaderName, RawValue: ep.ipPort}},
-	}
-	if hn := ep.hostname; hn != "" {
-		// TODO: Set host header if the IP is resolved from a hostname. Without this, it is likely that we cannot
-		// 	route the requests to external services that reject requests with the mismatching host header.
-		// 	Currently, EG API doesn't support allow us to set mutation_rules.
-		_ = hn
+		{Header: &corev3.HeaderValue{Key: originalHostHeaderName, RawValue: ep.authority(dlb.tls)}},
 	}
 	return
 }
+
+// authority returns the value of the Host header of the requests to the endpoint, which is the hostname of the
+// endpoint if resolved from it, or the ip:port pair otherwise. The port is omitted if it is the default one.
+func (ep *endpoint) authority(tls bool) []byte {
+	if ep.hostname == "" {
+		return ep.ipPort
+	}
+	hostname := strings.TrimSuffix(ep.hostname, ".")
+	_, port, err := net.SplitHostPort(string(ep.ipPort))
+	if err != nil || (tls && port == "443") || (!tls && port == "80") {
+		return []byte(hostname)
+	}
+	return []byte(net.JoinHostPort(hostname, port))
+}
+
+// selectTargetModel selects one of the target models of the model randomly by their weights, or returns the name
+// of the model if it has no target model. The target models are selected equally if all the weights are zero.
+func selectTargetModel(model string, m *filterapi.DynamicLoadBalancingModel) string {
+	if len(m.TargetModels) == 0 {
+		return model
+	}
+	total := 0
+	for i := range m.TargetModels {
+		total += max(m.TargetModels[i].Weight, 0)
+	}
+	if total == 0 {
+		return m.TargetModels[rand.Intn(len(m.TargetModels))].Name // nolint:gosec
+	}
+	selected := rand.Intn(total) // nolint:gosec
+	for i := range m.TargetModels {
+		if w := max(m.TargetModels[i].Weight, 0); selected < w {
+			return m.TargetModels[i].Name
+		} else {
+			selected -= w
+		}
+	}
+	return m.TargetModels[len(m.TargetModels)-1].Name
+}
+
+// leastRequest selects the endpoint with the least outstanding requests among the candidates.
+func leastRequest(candidates []endpoint) *endpoint {
+	return minScore(candidates, func(ep *endpoint) float64 { return float64(ep.stats.outstanding.Load()) })
+}
+
+// minScore returns the endpoint with the smallest score among the candidates.
+// The ties are broken randomly by starting the scan at a random offset.
+func minScore(candidates []endpoint, score func(*endpoint) float64) *endpoint {
+	offset := rand.Intn(len(candidates)) // nolint:gosec
+	var (
+		selected  *endpoint
+		bestScore float64
+	)
+	for i := range candidates {
+		ep := &candidates[(offset+i)%len(candidates)]
+		if s := score(ep); selected == nil || s < bestScore {
+			selected, bestScore = ep, s
+		}
+	}
+	return selected
+}
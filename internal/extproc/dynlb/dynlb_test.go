@@ -6,13 +6,18 @@
 package dynlb
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"strconv"
 	"testing"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
 	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
 )
 
@@ -48,48 +53,54 @@ func Test_newDynamicLoadBalancer(t *testing.T) {
 		{
 			ipPort:  []byte("1.2.3.4:8080"),
 			backend: &f.Backends[0].Backend,
+			stats:   &endpointStats{},
 		},
 		{
 			ipPort:   []byte("1.1.1.1:9999"),
 			hostname: "foo.io",
 			backend:  &f.Backends[1].Backend,
+			stats:    &endpointStats{},
 		},
 		{
 			ipPort:   []byte("2.2.2.2:9999"),
 			hostname: "example.com",
 			backend:  &f.Backends[1].Backend,
+			stats:    &endpointStats{},
 		},
 		{
 			ipPort:   []byte("3.3.3.3:4444"),
 			hostname: "something.io",
 			backend:  &f.Backends[2].Backend,
+			stats:    &endpointStats{},
 		},
 		{
 			ipPort:   []byte("4.4.4.4:4444"),
 			hostname: "something.io",
 			backend:  &f.Backends[2].Backend,
+			stats:    &endpointStats{},
 		},
-	}, dlb.endpoints)
+	}, *dlb.endpoints.Load())
 }
 
 func TestDynamicLoadBalancingSelectChatCompletionsEndpoint(t *testing.T) {
 	// TODO: currently this is mostly for test coverage, need to add more tests as we add more features.
 	dlb := &dynamicLoadBalancer{
 		logger: slog.Default(),
-		endpoints: []endpoint{
-			{ipPort: []byte("1.1.1.1:8080"), backend: &filterapi.Backend{Name: "foo"}, hostname: "foo.io"},
-		},
 		models: map[string]filterapi.DynamicLoadBalancingModel{"foo": {}},
 	}
+	dlb.swapEndpoints([]endpoint{
+		{ipPort: []byte("1.1.1.1:8080"), backend: &filterapi.Backend{Name: "foo"}, hostname: "foo.io"},
+	})
 	t.Run("model name not found", func(t *testing.T) {
-		_, _, err := dlb.SelectChatCompletionsEndpoint("aaaaaaaaaaaaa", nil)
+		_, _, _, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "aaaaaaaaaaaaa", nil, nil, nil)
 		require.ErrorContains(t, err, "model aaaaaaaaaaaaa is not found in the dynamic load balancer")
 	})
 	t.Run("ok", func(t *testing.T) {
-		backend, headers, err := dlb.SelectChatCompletionsEndpoint("foo", nil)
+		backend, targetModel, headers, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
 		require.NoError(t, err)
 		require.Equal(t, &filterapi.Backend{Name: "foo"}, backend)
-		require.Len(t, headers, 1)
+		require.Equal(t, "foo", targetModel)
+		require.Len(t, headers, 2)
 		for _, h := range []*corev3.HeaderValueOption{
 			{
 				Header: &corev3.HeaderValue{
@@ -97,8 +108,242 @@ func TestDynamicLoadBalancingSelectChatCompletionsEndpoint(t *testing.T) {
 					RawValue: []byte("1.1.1.1:8080"),
 				},
 			},
+			{
+				Header: &corev3.HeaderValue{
+					Key:      originalHostHeaderName,
+					RawValue: []byte("foo.io:8080"),
+				},
+			},
 		} {
 			require.Contains(t, headers, h)
 		}
 	})
 }
+
+func TestEndpoint_authority(t *testing.T) {
+	for _, tc := range []struct {
+		ipPort, hostname string
+		tls              bool
+		exp              string
+	}{
+		{ipPort: "1.1.1.1:8080", exp: "1.1.1.1:8080"},
+		{ipPort: "[2001:db8::1]:443", tls: true, exp: "[2001:db8::1]:443"},
+		{ipPort: "1.1.1.1:8080", hostname: "foo.io", exp: "foo.io:8080"},
+		{ipPort: "1.1.1.1:80", hostname: "foo.io", exp: "foo.io"},
+		{ipPort: "1.1.1.1:443", hostname: "foo.io", exp: "foo.io:443"},
+		{ipPort: "1.1.1.1:443", hostname: "foo.io.", tls: true, exp: "foo.io"},
+		{ipPort: "1.1.1.1:80", hostname: "foo.io", tls: true, exp: "foo.io:80"},
+		{ipPort: "[2001:db8::1]:8443", hostname: "foo.io", tls: true, exp: "foo.io:8443"},
+	} {
+		t.Run(tc.exp, func(t *testing.T) {
+			ep := &endpoint{ipPort: []byte(tc.ipPort), hostname: tc.hostname}
+			require.Equal(t, tc.exp, string(ep.authority(tc.tls)))
+		})
+	}
+}
+
+func Test_newDynamicLoadBalancer_policy(t *testing.T) {
+	addr := internaltesting.RequireNewTestDNSServer(t)
+	_, err := newDynamicLoadBalancer(t.Context(), slog.Default(), &filterapi.DynamicLoadBalancing{
+		Policy: filterapi.DynamicLoadBalancingPolicyEndpointMetrics,
+	}, addr)
+	require.ErrorContains(t, err, "endpoint metrics must be configured for the EndpointMetrics policy")
+
+	_, err = newDynamicLoadBalancer(t.Context(), slog.Default(), &filterapi.DynamicLoadBalancing{Policy: "Foo"}, addr)
+	require.ErrorContains(t, err, "unknown load balancing policy: Foo")
+
+	_dlb, err := newDynamicLoadBalancer(t.Context(), slog.Default(), &filterapi.DynamicLoadBalancing{
+		Policy:          filterapi.DynamicLoadBalancingPolicyEndpointMetrics,
+		EndpointMetrics: &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/metrics", Interval: time.Second},
+	}, addr)
+	require.NoError(t, err)
+	dlb := _dlb.(*dynamicLoadBalancer)
+	require.Equal(t, "/metrics", dlb.scraper.path)
+	require.Equal(t, time.Second, dlb.scraper.interval)
+}
+
+func TestDynamicLoadBalancingSelectChatCompletionsEndpoint_leastRequest(t *testing.T) {
+	dlb := &dynamicLoadBalancer{
+		logger: slog.Default(),
+		policy: filterapi.DynamicLoadBalancingPolicyLeastRequest,
+		models: map[string]filterapi.DynamicLoadBalancingModel{"foo": {}},
+	}
+	dlb.swapEndpoints([]endpoint{
+		{ipPort: []byte("1.1.1.1:8080"), backend: &filterapi.Backend{Name: "foo"}},
+		{ipPort: []byte("2.2.2.2:8080"), backend: &filterapi.Backend{Name: "foo"}},
+	})
+	selectEndpoint := func(ctx context.Context) string {
+		_, _, headers, err := dlb.SelectChatCompletionsEndpoint(ctx, "foo", nil, nil, nil)
+		require.NoError(t, err)
+		return string(headers[0].Header.RawValue)
+	}
+
+	ctx1, cancel1 := context.WithCancel(t.Context())
+	first := selectEndpoint(ctx1)
+	// The other endpoint is selected while the first request is outstanding.
+	ctx2, cancel2 := context.WithCancel(t.Context())
+	second := selectEndpoint(ctx2)
+	require.NotEqual(t, first, second)
+
+	// The endpoint is released when the request finishes.
+	cancel1()
+	endpoints := *dlb.endpoints.Load()
+	require.Eventually(t, func() bool {
+		return endpoints[0].stats.outstanding.Load()+endpoints[1].stats.outstanding.Load() == 1
+	}, time.Second, time.Millisecond)
+	ctx3, cancel3 := context.WithCancel(t.Context())
+	defer cancel3()
+	require.Equal(t, first, selectEndpoint(ctx3))
+	cancel2()
+}
+
+func TestDynamicLoadBalancingSelectChatCompletionsEndpoint_random(t *testing.T) {
+	dlb := &dynamicLoadBalancer{
+		logger: slog.Default(),
+		policy: filterapi.DynamicLoadBalancingPolicyRandom,
+		models: map[string]filterapi.DynamicLoadBalancingModel{"foo": {}},
+	}
+	dlb.swapEndpoints([]endpoint{
+		{ipPort: []byte("1.1.1.1:8080"), backend: &filterapi.Backend{Name: "foo"}},
+	})
+	backend, targetModel, headers, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.Backend{Name: "foo"}, backend)
+	require.Equal(t, "foo", targetModel)
+	require.Equal(t, []byte("1.1.1.1:8080"), headers[0].Header.RawValue)
+}
+
+func TestDynamicLoadBalancingSelectChatCompletionsEndpoint_targetModels(t *testing.T) {
+	dlb := &dynamicLoadBalancer{
+		logger: slog.Default(),
+		models: map[string]filterapi.DynamicLoadBalancingModel{
+			"foo": {Name: "foo", TargetModels: []filterapi.DynamicLoadBalancingTargetModel{
+				{Name: "foo-v1", Weight: 3},
+				{Name: "foo-v2", Weight: 1},
+				{Name: "foo-v3", Weight: 0},
+			}},
+		},
+	}
+	dlb.swapEndpoints([]endpoint{
+		{ipPort: []byte("1.1.1.1:8080"), backend: &filterapi.Backend{Name: "foo"}},
+	})
+	const n = 4000
+	counts := map[string]int{}
+	for range n {
+		_, targetModel, _, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
+		require.NoError(t, err)
+		counts[targetModel]++
+	}
+	require.Len(t, counts, 2)
+	require.InDelta(t, n*3/4, counts["foo-v1"], n/10)
+	require.InDelta(t, n/4, counts["foo-v2"], n/10)
+
+	// The target models are not routable by themselves as in the Inference Extension, where the requests are
+	// only matched by the model names of the InferenceModels.
+	_, _, _, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo-v1", nil, nil, nil)
+	require.ErrorContains(t, err, "model foo-v1 is not found in the dynamic load balancer")
+}
+
+func Test_selectTargetModel(t *testing.T) {
+	require.Equal(t, "foo", selectTargetModel("foo", &filterapi.DynamicLoadBalancingModel{Name: "foo"}))
+	// The target models are selected equally if all the weights are zero.
+	selected := map[string]struct{}{}
+	for range 100 {
+		selected[selectTargetModel("foo", &filterapi.DynamicLoadBalancingModel{
+			Name:         "foo",
+			TargetModels: []filterapi.DynamicLoadBalancingTargetModel{{Name: "a"}, {Name: "b"}},
+		})] = struct{}{}
+	}
+	require.Len(t, selected, 2)
+}
+
+func TestDynamicLoadBalancingSelectChatCompletionsEndpoint_criticality(t *testing.T) {
+	now := time.Now()
+	newDLB := func(policy filterapi.DynamicLoadBalancingPolicy, criticality filterapi.DynamicLoadBalancingCriticality, metrics ...*endpointMetrics) *dynamicLoadBalancer {
+		dlb := &dynamicLoadBalancer{
+			logger: slog.Default(),
+			policy: policy,
+			models: map[string]filterapi.DynamicLoadBalancingModel{"foo": {Name: "foo", Criticality: criticality}},
+		}
+		if policy == filterapi.DynamicLoadBalancingPolicyEndpointMetrics {
+			dlb.scraper = &metricsScraper{interval: time.Minute, now: func() time.Time { return now }}
+		}
+		backend := &filterapi.Backend{Name: "foo"}
+		endpoints := make([]endpoint, len(metrics))
+		for i := range endpoints {
+			endpoints[i] = endpoint{ipPort: []byte(fmt.Sprintf("%d.%d.%d.%d:8080", i+1, i+1, i+1, i+1)), backend: backend}
+		}
+		dlb.swapEndpoints(endpoints)
+		for i, m := range metrics {
+			if m != nil {
+				m.scrapedAt = now
+				endpoints[i].stats.metrics.Store(m)
+			}
+		}
+		return dlb
+	}
+	saturated := func() *endpointMetrics { return &endpointMetrics{waitingRequests: 10, kvCacheUsage: 0.5} }
+
+	t.Run("sheddable is rejected when saturated", func(t *testing.T) {
+		dlb := newDLB(filterapi.DynamicLoadBalancingPolicyEndpointMetrics, filterapi.DynamicLoadBalancingCriticalitySheddable,
+			saturated(), &endpointMetrics{waitingRequests: 0, kvCacheUsage: 0.9})
+		_, _, _, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
+		require.ErrorIs(t, err, ErrSaturated)
+		require.Equal(t, aigwerrors.RateLimited, aigwerrors.TypeOf(err))
+	})
+	t.Run("sheddable goes to the endpoint with capacity", func(t *testing.T) {
+		dlb := newDLB(filterapi.DynamicLoadBalancingPolicyEndpointMetrics, filterapi.DynamicLoadBalancingCriticalitySheddable,
+			saturated(), &endpointMetrics{waitingRequests: 4, kvCacheUsage: 0.5}, saturated())
+		for range 10 {
+			_, _, headers, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
+			require.NoError(t, err)
+			require.Equal(t, "2.2.2.2:8080", string(headers[0].Header.RawValue))
+		}
+	})
+	t.Run("sheddable does not go to the endpoint without fresh metrics", func(t *testing.T) {
+		dlb := newDLB(filterapi.DynamicLoadBalancingPolicyEndpointMetrics, filterapi.DynamicLoadBalancingCriticalitySheddable,
+			&endpointMetrics{}, nil)
+		for range 10 {
+			_, _, headers, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
+			require.NoError(t, err)
+			require.Equal(t, "1.1.1.1:8080", string(headers[0].Header.RawValue))
+		}
+	})
+	t.Run("sheddable is rejected without fresh metrics", func(t *testing.T) {
+		dlb := newDLB(filterapi.DynamicLoadBalancingPolicyEndpointMetrics, filterapi.DynamicLoadBalancingCriticalitySheddable,
+			saturated(), nil)
+		_, _, _, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
+		require.ErrorIs(t, err, ErrSaturated)
+	})
+	t.Run("sheddable session goes to the endpoint with capacity", func(t *testing.T) {
+		dlb := newDLB(filterapi.DynamicLoadBalancingPolicyEndpointMetrics, filterapi.DynamicLoadBalancingCriticalitySheddable,
+			saturated(), &endpointMetrics{}, saturated())
+		dlb.affinity = &filterapi.DynamicLoadBalancingSessionAffinity{HeaderName: "x-session-id", MaxLoadPercentage: 125}
+		// Swapping the same endpoints builds the ring of them while keeping their metrics.
+		dlb.swapEndpoints(*dlb.endpoints.Load())
+		for i := range 10 {
+			_, _, headers, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo",
+				map[string]string{"x-session-id": strconv.Itoa(i)}, nil, nil)
+			require.NoError(t, err)
+			require.Equal(t, "2.2.2.2:8080", string(headers[0].Header.RawValue))
+		}
+	})
+	for _, criticality := range []filterapi.DynamicLoadBalancingCriticality{
+		filterapi.DynamicLoadBalancingCriticalityCritical,
+		filterapi.DynamicLoadBalancingCriticalityStandard,
+	} {
+		t.Run(string(criticality)+" is not rejected", func(t *testing.T) {
+			dlb := newDLB(filterapi.DynamicLoadBalancingPolicyEndpointMetrics, criticality, saturated(), saturated())
+			_, _, _, err := dlb.SelectChatCompletionsEndpoint(t.Context(), "foo", nil, nil, nil)
+			require.NoError(t, err)
+		})
+	}
+	t.Run("sheddable requires endpoint metrics", func(t *testing.T) {
+		addr := internaltesting.RequireNewTestDNSServer(t)
+		_, err := newDynamicLoadBalancer(t.Context(), slog.Default(), &filterapi.DynamicLoadBalancing{
+			Policy: filterapi.DynamicLoadBalancingPolicyLeastRequest,
+			Models: []filterapi.DynamicLoadBalancingModel{{Name: "foo", Criticality: filterapi.DynamicLoadBalancingCriticalitySheddable}},
+		}, addr)
+		require.EqualError(t, err, "sheddable model foo requires the EndpointMetrics policy")
+	})
+}
@@ -0,0 +1,297 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package dynlb
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// The names of the metrics exposed by the vLLM compatible model servers.
+const (
+	metricNameWaitingRequests = "vllm:num_requests_waiting"
+	metricNameRunningRequests = "vllm:num_requests_running"
+	metricNameKVCacheUsage    = "vllm:gpu_cache_usage_perc"
+	metricNameLoRARequests    = "vllm:lora_requests_info"
+
+	labelRunningLoRAAdapters = "running_lora_adapters"
+	labelWaitingLoRAAdapters = "waiting_lora_adapters"
+)
+
+const (
+	// kvCacheUsageThreshold is the KV cache utilization above which the endpoint is avoided if possible,
+	// since the new requests likely cause the preemption of the running ones.
+	kvCacheUsageThreshold = 0.8
+	// loraAffinityQueueThreshold is the number of the waiting requests below which the endpoint that has already
+	// loaded the requested model is preferred over the others.
+	loraAffinityQueueThreshold = 50
+	// staleMetricsIntervals is the number of the scrape intervals after which the scraped metrics are regarded as stale.
+	staleMetricsIntervals = 10
+	// sheddableQueueThreshold is the number of the waiting requests at or above which the endpoint has no capacity
+	// for the sheddable requests.
+	sheddableQueueThreshold = 5
+)
+
+// endpointMetrics is the metrics scraped from an endpoint.
+type endpointMetrics struct {
+	// waitingRequests is the number of the requests waiting in the queue of the model server.
+	waitingRequests float64
+	// runningRequests is the number of the requests being processed by the model server.
+	runningRequests float64
+	// kvCacheUsage is the utilization of the KV cache in the range of [0, 1].
+	kvCacheUsage float64
+	// loadedModels is the set of the LoRA adapters that are running or waiting on the model server.
+	loadedModels map[string]struct{}
+	// scrapedAt is the time when the metrics were scraped.
+	scrapedAt time.Time
+}
+
+// selectByMetrics selects the endpoint based on the scraped metrics in the same way as the
+// Gateway API Inference Extension:
+//
+//  1. The endpoints whose KV cache utilization is below the threshold are preferred.
+//  2. The endpoints which have already loaded the requested model with the short queue are preferred.
+//  3. The endpoint with the least waiting and outstanding requests is selected.
+//
+// Each filter is skipped when none of the candidates passes it. The endpoints without the fresh metrics
+// are scored by the outstanding requests only.
+func (dlb *dynamicLoadBalancer) selectByMetrics(endpoints []endpoint, model string, now time.Time) *endpoint {
+	metrics := make(map[*endpointStats]*endpointMetrics, len(endpoints))
+	for i := range endpoints {
+		if m := dlb.freshMetrics(endpoints[i].stats, now); m != nil {
+			metrics[endpoints[i].stats] = m
+		}
+	}
+
+	candidates := filterEndpoints(endpoints, func(ep *endpoint) bool {
+		m, ok := metrics[ep.stats]
+		return !ok || m.kvCacheUsage < kvCacheUsageThreshold
+	})
+	candidates = filterEndpoints(candidates, func(ep *endpoint) bool {
+		m, ok := metrics[ep.stats]
+		if !ok || m.waitingRequests >= loraAffinityQueueThreshold {
+			return false
+		}
+		_, loaded := m.loadedModels[model]
+		return loaded
+	})
+	return minScore(candidates, func(ep *endpoint) float64 {
+		score := float64(ep.stats.outstanding.Load())
+		if m, ok := metrics[ep.stats]; ok {
+			score += m.waitingRequests
+		}
+		return score
+	})
+}
+
+// freshMetrics returns the metrics scraped from the endpoint unless they are stale, or nil if there is none.
+func (dlb *dynamicLoadBalancer) freshMetrics(stats *endpointStats, now time.Time) *endpointMetrics {
+	if m := stats.metrics.Load(); m != nil && now.Sub(m.scrapedAt) < staleMetricsIntervals*dlb.scraper.interval {
+		return m
+	}
+	return nil
+}
+
+// hasCapacityForSheddable returns true if the endpoint can accept the sheddable requests, i.e. its queue is short
+// and its KV cache is not saturated, as in the Gateway API Inference Extension. The endpoint without the fresh
+// metrics is regarded as having no capacity since its load is unknown, e.g. when its metrics cannot be scraped
+// because it is overloaded.
+func (dlb *dynamicLoadBalancer) hasCapacityForSheddable(ep *endpoint, now time.Time) bool {
+	m := dlb.freshMetrics(ep.stats, now)
+	return m != nil && m.waitingRequests < sheddableQueueThreshold && m.kvCacheUsage < kvCacheUsageThreshold
+}
+
+// filterEndpoints returns the endpoints that pass the filter, or all the endpoints if none of them passes.
+func filterEndpoints(endpoints []endpoint, filter func(*endpoint) bool) []endpoint {
+	if ret := filterEndpointsStrict(endpoints, filter); len(ret) > 0 {
+		return ret
+	}
+	return endpoints
+}
+
+// filterEndpointsStrict returns the endpoints that pass the filter, which can be empty.
+func filterEndpointsStrict(endpoints []endpoint, filter func(*endpoint) bool) []endpoint {
+	var ret []endpoint
+	for i := range endpoints {
+		if filter(&endpoints[i]) {
+			ret = append(ret, endpoints[i])
+		}
+	}
+	return ret
+}
+
+// metricsScraper scrapes the metrics from the endpoints in the background.
+//
+// The endpoints are scraped every interval regardless of the requests so that the metrics are fresh even after
+// the load balancer has been idle, e.g. for the first sheddable request. The scraping stops when the context of
+// the load balancer is done, i.e. when the configuration using it is replaced.
+type metricsScraper struct {
+	logger   *slog.Logger
+	path     string
+	interval time.Duration
+	client   *http.Client
+	// tls is true if the endpoints are scraped with TLS.
+	tls bool
+	now func() time.Time
+}
+
+// newMetricsScraper creates a new metricsScraper from the configuration. The endpoints are scraped with TLS using
+// the given config if not nil, in which case the SNI is set to the hostname of each endpoint in the same way as the
+// requests routed to it.
+func newMetricsScraper(logger *slog.Logger, config *filterapi.DynamicLoadBalancingEndpointMetrics, tlsConfig *tls.Config) *metricsScraper {
+	s := &metricsScraper{
+		logger:   logger,
+		path:     config.Path,
+		interval: config.Interval,
+		client:   &http.Client{Timeout: config.Interval},
+		now:      time.Now,
+	}
+	if tlsConfig != nil {
+		s.tls = true
+		s.client.Transport = &http.Transport{
+			// The connections to the same ip:port are pooled regardless of the SNI, while the endpoints resolved from
+			// different hostnames can share the ip:port.
+			DisableKeepAlives: true,
+			DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				config := tlsConfig.Clone()
+				config.ServerName, _ = ctx.Value(serverNameKey{}).(string)
+				dialer := &tls.Dialer{Config: config}
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}
+	}
+	return s
+}
+
+// serverNameKey is the context key of the SNI of the scrape request, which is empty for the endpoints specified by
+// the IP addresses.
+type serverNameKey struct{}
+
+// run scrapes the endpoints right away and then every interval until the context is done. The endpoints are
+// returned by the given function at each scrape since they are swapped by the resolver.
+func (s *metricsScraper) run(ctx context.Context, endpoints func() []endpoint) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.scrapeAll(ctx, endpoints())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrapeAll scrapes all the endpoints concurrently and stores the results.
+func (s *metricsScraper) scrapeAll(ctx context.Context, endpoints []endpoint) {
+	var wg sync.WaitGroup
+	for i := range endpoints {
+		ep := &endpoints[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := s.scrape(ctx, ep)
+			if ctx.Err() != nil {
+				// The load balancer is no longer used, so the metrics are kept as they are.
+				return
+			}
+			if err != nil {
+				s.logger.Warn("failed to scrape endpoint metrics",
+					slog.String("endpoint", string(ep.ipPort)), slog.String("error", err.Error()))
+				ep.stats.metrics.Store(nil)
+				return
+			}
+			ep.stats.metrics.Store(m)
+		}()
+	}
+	wg.Wait()
+}
+
+// scrape scrapes the metrics from the endpoint.
+func (s *metricsScraper) scrape(ctx context.Context, ep *endpoint) (*endpointMetrics, error) {
+	scrapedAt := s.now()
+	scheme := "http"
+	if s.tls {
+		scheme = "https"
+	}
+	ctx = context.WithValue(ctx, serverNameKey{}, strings.TrimSuffix(ep.hostname, "."))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+string(ep.ipPort)+s.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = string(ep.authority(s.tls))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	m, err := parseEndpointMetrics(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+	m.scrapedAt = scrapedAt
+	return m, nil
+}
+
+// parseEndpointMetrics parses the metrics in the Prometheus text format exposed by the vLLM compatible model servers.
+func parseEndpointMetrics(r io.Reader) (*endpointMetrics, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+	m := &endpointMetrics{
+		waitingRequests: sumGauges(families[metricNameWaitingRequests]),
+		runningRequests: sumGauges(families[metricNameRunningRequests]),
+		kvCacheUsage:    sumGauges(families[metricNameKVCacheUsage]),
+		loadedModels:    make(map[string]struct{}),
+	}
+	// The LoRA requests info is reported as a series per state change whose value is the timestamp,
+	// so the latest one reflects the current state.
+	var latest *dto.Metric
+	if f := families[metricNameLoRARequests]; f != nil {
+		for _, metric := range f.GetMetric() {
+			if latest == nil || metric.GetGauge().GetValue() > latest.GetGauge().GetValue() {
+				latest = metric
+			}
+		}
+	}
+	for _, label := range latest.GetLabel() {
+		if label.GetName() != labelRunningLoRAAdapters && label.GetName() != labelWaitingLoRAAdapters {
+			continue
+		}
+		for _, adapter := range strings.Split(label.GetValue(), ",") {
+			if adapter = strings.TrimSpace(adapter); adapter != "" {
+				m.loadedModels[adapter] = struct{}{}
+			}
+		}
+	}
+	return m, nil
+}
+
+// sumGauges returns the sum of the values of the gauges in the family. This returns zero if the family is nil.
+func sumGauges(f *dto.MetricFamily) (sum float64) {
+	for _, metric := range f.GetMetric() {
+		sum += metric.GetGauge().GetValue()
+	}
+	return
+}
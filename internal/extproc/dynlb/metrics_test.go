@@ -0,0 +1,273 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package dynlb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const testVLLMMetrics = `# HELP vllm:num_requests_running Number of requests currently running on GPU.
+# TYPE vllm:num_requests_running gauge
+vllm:num_requests_running{model_name="meta-llama/Llama-3.1-8B-Instruct"} 3.0
+# HELP vllm:num_requests_waiting Number of requests waiting to be processed.
+# TYPE vllm:num_requests_waiting gauge
+vllm:num_requests_waiting{model_name="meta-llama/Llama-3.1-8B-Instruct"} 7.0
+# HELP vllm:gpu_cache_usage_perc GPU KV-cache usage. 1 means 100 percent usage.
+# TYPE vllm:gpu_cache_usage_perc gauge
+vllm:gpu_cache_usage_perc{model_name="meta-llama/Llama-3.1-8B-Instruct"} 0.42
+# HELP vllm:lora_requests_info Running stats on lora requests.
+# TYPE vllm:lora_requests_info gauge
+vllm:lora_requests_info{max_lora="4",running_lora_adapters="sql-lora",waiting_lora_adapters=""} 1.7e+09
+vllm:lora_requests_info{max_lora="4",running_lora_adapters="sql-lora,tweet-lora",waiting_lora_adapters="chat-lora"} 1.8e+09
+`
+
+func Test_parseEndpointMetrics(t *testing.T) {
+	m, err := parseEndpointMetrics(strings.NewReader(testVLLMMetrics))
+	require.NoError(t, err)
+	require.Equal(t, &endpointMetrics{
+		waitingRequests: 7,
+		runningRequests: 3,
+		kvCacheUsage:    0.42,
+		loadedModels:    map[string]struct{}{"sql-lora": {}, "tweet-lora": {}, "chat-lora": {}},
+	}, m)
+
+	m, err = parseEndpointMetrics(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Equal(t, &endpointMetrics{loadedModels: map[string]struct{}{}}, m)
+
+	_, err = parseEndpointMetrics(strings.NewReader("vllm:num_requests_waiting{"))
+	require.Error(t, err)
+}
+
+func TestDynamicLoadBalancer_selectByMetrics(t *testing.T) {
+	now := time.Now()
+	newEndpoint := func(ipPort string, outstanding int64, m *endpointMetrics) endpoint {
+		ep := endpoint{ipPort: []byte(ipPort), backend: &filterapi.Backend{Name: "foo"}, stats: &endpointStats{}}
+		ep.stats.outstanding.Store(outstanding)
+		if m != nil {
+			if m.scrapedAt.IsZero() {
+				m.scrapedAt = now
+			}
+			ep.stats.metrics.Store(m)
+		}
+		return ep
+	}
+	for _, tc := range []struct {
+		name      string
+		endpoints []endpoint
+		model     string
+		exp       string
+	}{
+		{
+			name: "least waiting requests",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 0, &endpointMetrics{waitingRequests: 10}),
+				newEndpoint("2.2.2.2:8080", 0, &endpointMetrics{waitingRequests: 2}),
+				newEndpoint("3.3.3.3:8080", 0, &endpointMetrics{waitingRequests: 5}),
+			},
+			exp: "2.2.2.2:8080",
+		},
+		{
+			name: "outstanding requests are added to the queue",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 0, &endpointMetrics{waitingRequests: 3}),
+				newEndpoint("2.2.2.2:8080", 5, &endpointMetrics{waitingRequests: 0}),
+			},
+			exp: "1.1.1.1:8080",
+		},
+		{
+			name: "kv cache saturated",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 0, &endpointMetrics{waitingRequests: 0, kvCacheUsage: 0.95}),
+				newEndpoint("2.2.2.2:8080", 0, &endpointMetrics{waitingRequests: 4, kvCacheUsage: 0.5}),
+			},
+			exp: "2.2.2.2:8080",
+		},
+		{
+			name: "all kv cache saturated",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 0, &endpointMetrics{waitingRequests: 1, kvCacheUsage: 0.95}),
+				newEndpoint("2.2.2.2:8080", 0, &endpointMetrics{waitingRequests: 4, kvCacheUsage: 0.9}),
+			},
+			exp: "1.1.1.1:8080",
+		},
+		{
+			name:  "model loaded",
+			model: "sql-lora",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 0, &endpointMetrics{waitingRequests: 0}),
+				newEndpoint("2.2.2.2:8080", 0, &endpointMetrics{waitingRequests: 8, loadedModels: map[string]struct{}{"sql-lora": {}}}),
+			},
+			exp: "2.2.2.2:8080",
+		},
+		{
+			name:  "model loaded but queue too long",
+			model: "sql-lora",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 0, &endpointMetrics{waitingRequests: 0}),
+				newEndpoint("2.2.2.2:8080", 0, &endpointMetrics{waitingRequests: 80, loadedModels: map[string]struct{}{"sql-lora": {}}}),
+			},
+			exp: "1.1.1.1:8080",
+		},
+		{
+			name: "stale metrics are ignored",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 1, &endpointMetrics{waitingRequests: 0, scrapedAt: now.Add(-time.Minute)}),
+				newEndpoint("2.2.2.2:8080", 0, &endpointMetrics{waitingRequests: 3}),
+			},
+			exp: "1.1.1.1:8080",
+		},
+		{
+			name: "without metrics",
+			endpoints: []endpoint{
+				newEndpoint("1.1.1.1:8080", 2, nil),
+				newEndpoint("2.2.2.2:8080", 1, nil),
+			},
+			exp: "2.2.2.2:8080",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dlb := &dynamicLoadBalancer{scraper: &metricsScraper{interval: time.Second}}
+			require.Equal(t, tc.exp, string(dlb.selectByMetrics(tc.endpoints, tc.model, now).ipPort))
+		})
+	}
+}
+
+func TestMetricsScraper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(testVLLMMetrics))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	s := newMetricsScraper(slog.Default(), &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/metrics", Interval: time.Minute}, nil)
+	s.now = func() time.Time { return now }
+	endpoints := []endpoint{
+		{ipPort: []byte(strings.TrimPrefix(srv.URL, "http://")), stats: &endpointStats{}},
+		{ipPort: []byte("127.0.0.1:1"), stats: &endpointStats{}},
+	}
+	endpoints[1].stats.metrics.Store(&endpointMetrics{})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(ctx, func() []endpoint { return endpoints })
+	}()
+	require.Eventually(t, func() bool {
+		m := endpoints[0].stats.metrics.Load()
+		return m != nil && endpoints[1].stats.metrics.Load() == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	m := endpoints[0].stats.metrics.Load()
+	require.Equal(t, float64(7), m.waitingRequests)
+	require.Equal(t, now, m.scrapedAt)
+
+	// The scraping stops when the context is done.
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scraper did not stop")
+	}
+}
+
+func TestMetricsScraper_interval(t *testing.T) {
+	var scrapes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		scrapes.Add(1)
+		_, _ = w.Write([]byte(testVLLMMetrics))
+	}))
+	defer srv.Close()
+
+	// The endpoints are scraped every interval without any request.
+	s := newMetricsScraper(slog.Default(), &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/metrics", Interval: 10 * time.Millisecond}, nil)
+	endpoints := []endpoint{{ipPort: []byte(strings.TrimPrefix(srv.URL, "http://")), stats: &endpointStats{}}}
+	ctx, cancel := context.WithCancel(t.Context())
+	go s.run(ctx, func() []endpoint { return endpoints })
+	require.Eventually(t, func() bool { return scrapes.Load() >= 3 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+}
+
+func TestMetricsScraper_tls(t *testing.T) {
+	var serverName, host string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverName, host = r.TLS.ServerName, r.Host
+		_, _ = w.Write([]byte(testVLLMMetrics))
+	}))
+	defer srv.Close()
+	ipPort := strings.TrimPrefix(srv.URL, "https://")
+	_, port, err := net.SplitHostPort(ipPort)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+	tlsConfig, err := newTLSConfig(caFile)
+	require.NoError(t, err)
+	s := newMetricsScraper(slog.Default(), &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/metrics", Interval: time.Minute}, tlsConfig)
+
+	t.Run("hostname", func(t *testing.T) {
+		// The certificate of the test server is valid for example.com.
+		m, err := s.scrape(t.Context(), &endpoint{ipPort: []byte(ipPort), hostname: "example.com.", stats: &endpointStats{}})
+		require.NoError(t, err)
+		require.Equal(t, float64(7), m.waitingRequests)
+		require.Equal(t, "example.com", serverName)
+		require.Equal(t, "example.com:"+port, host)
+	})
+	t.Run("ip", func(t *testing.T) {
+		// The certificate of the test server is also valid for 127.0.0.1, which is verified without the SNI.
+		_, err := s.scrape(t.Context(), &endpoint{ipPort: []byte(ipPort), stats: &endpointStats{}})
+		require.NoError(t, err)
+		require.Empty(t, serverName)
+		require.Equal(t, ipPort, host)
+	})
+	t.Run("hostname mismatch", func(t *testing.T) {
+		_, err := s.scrape(t.Context(), &endpoint{ipPort: []byte(ipPort), hostname: "foo.io", stats: &endpointStats{}})
+		var hostnameErr x509.HostnameError
+		require.ErrorAs(t, err, &hostnameErr)
+	})
+	t.Run("untrusted", func(t *testing.T) {
+		s := newMetricsScraper(slog.Default(), &filterapi.DynamicLoadBalancingEndpointMetrics{Path: "/metrics", Interval: time.Minute},
+			&tls.Config{MinVersion: tls.VersionTLS12, RootCAs: x509.NewCertPool()})
+		_, err := s.scrape(t.Context(), &endpoint{ipPort: []byte(ipPort), hostname: "example.com", stats: &endpointStats{}})
+		var unknownAuthorityErr x509.UnknownAuthorityError
+		require.ErrorAs(t, err, &unknownAuthorityErr)
+	})
+}
+
+func Test_newTLSConfig(t *testing.T) {
+	config, err := newTLSConfig("")
+	require.NoError(t, err)
+	require.Nil(t, config.RootCAs)
+
+	_, err = newTLSConfig(filepath.Join(t.TempDir(), "nonexistent"))
+	require.ErrorContains(t, err, "failed to read CA certificates")
+
+	invalid := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(invalid, []byte("foo"), 0o600))
+	_, err = newTLSConfig(invalid)
+	require.EqualError(t, err, "no CA certificate is found in "+invalid)
+}
@@ -0,0 +1,248 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package dynlb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// minResolveInterval and maxResolveInterval bound the interval of the re-resolution derived from the record TTLs.
+	minResolveInterval = time.Second
+	maxResolveInterval = 5 * time.Minute
+	// retryResolveInterval is the interval of the re-resolution when the resolution fails or a hostname has no records.
+	retryResolveInterval = 5 * time.Second
+)
+
+// runResolver re-resolves the hostnames when the shortest TTL of the records expires until the context is done.
+func (dlb *dynamicLoadBalancer) runResolver(ctx context.Context, ttl time.Duration) {
+	timer := time.NewTimer(ttl)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		endpoints, next, err := dlb.resolve(ctx)
+		if err != nil {
+			// Keep the current endpoints since the failure is likely to be transient.
+			dlb.logger.Error("failed to re-resolve hostnames", slog.String("error", err.Error()))
+			next = retryResolveInterval
+		} else {
+			dlb.swapEndpoints(endpoints)
+		}
+		timer.Reset(next)
+	}
+}
+
+// swapEndpoints replaces the current endpoints with the given ones. The load of the endpoints that
+// exist in both is carried over to the new ones.
+func (dlb *dynamicLoadBalancer) swapEndpoints(endpoints []endpoint) {
+	type key struct {
+		ipPort  string
+		backend *filterapi.Backend
+	}
+	current := make(map[key]*endpointStats)
+	if prev := dlb.endpoints.Load(); prev != nil {
+		for _, ep := range *prev {
+			current[key{string(ep.ipPort), ep.backend}] = ep.stats
+		}
+	}
+	for i := range endpoints {
+		ep := &endpoints[i]
+		if stats, ok := current[key{string(ep.ipPort), ep.backend}]; ok {
+			ep.stats = stats
+		} else {
+			ep.stats = &endpointStats{}
+		}
+	}
+	dlb.endpoints.Store(&endpoints)
+	if dlb.affinity != nil {
+		dlb.ring.Store(newHashRing(endpoints))
+	}
+}
+
+// resolve resolves the IPs and the hostnames of the backends to the endpoints. This also returns the interval
+// until the next resolution which is the shortest TTL of the records.
+//
+// The hostnames starting with an underscore, e.g. "_http._tcp.vllm.default.svc.cluster.local", are resolved
+// as SRV records whose targets and ports are used as the endpoints. Otherwise, both A and AAAA records are resolved
+// with the port of the backend.
+func (dlb *dynamicLoadBalancer) resolve(ctx context.Context) (endpoints []endpoint, ttl time.Duration, err error) {
+	ttl = maxResolveInterval
+	var r *resolver
+	for i := range dlb.backends {
+		b := &dlb.backends[i]
+		port := strconv.Itoa(int(b.Port))
+		for _, ip := range b.IPs {
+			endpoints = append(endpoints, endpoint{
+				ipPort:  []byte(net.JoinHostPort(ip, port)),
+				backend: &b.Backend,
+			})
+		}
+		if len(b.Hostnames) == 0 {
+			continue
+		}
+		if r == nil {
+			if r, err = newResolver(dlb.dnsServerAddr); err != nil {
+				return nil, 0, err
+			}
+			defer r.close()
+		}
+		dlb.logger.Info("resolving hostnames to IP addresses", slog.String("hostnames", strings.Join(b.Hostnames, ",")))
+		for _, hostname := range b.Hostnames {
+			var addrs []resolvedAddress
+			var hostTTL time.Duration
+			if strings.HasPrefix(hostname, "_") {
+				addrs, hostTTL, err = r.resolveSRV(ctx, hostname)
+			} else {
+				addrs, hostTTL, err = r.resolveIPs(ctx, hostname, port)
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(addrs) == 0 {
+				dlb.logger.Warn("no records found", slog.String("hostname", hostname))
+				hostTTL = retryResolveInterval
+			}
+			ttl = min(ttl, hostTTL)
+			for _, addr := range addrs {
+				dlb.logger.Info("resolved IP address", slog.String("hostname", hostname), slog.String("endpoint", addr.ipPort))
+				endpoints = append(endpoints, endpoint{
+					ipPort:   []byte(addr.ipPort),
+					backend:  &b.Backend,
+					hostname: addr.hostname,
+				})
+			}
+		}
+	}
+	return endpoints, max(ttl, minResolveInterval), nil
+}
+
+// resolvedAddress is an ip:port pair resolved from a hostname.
+type resolvedAddress struct {
+	ipPort string
+	// hostname is the hostname which the IP address belongs to, which is the target for the SRV records.
+	hostname string
+}
+
+// resolver resolves the hostnames with a single connection to the DNS server.
+type resolver struct {
+	client dns.Client
+	conn   *dns.Conn
+}
+
+// newResolver dials the DNS server.
+func newResolver(dnsServerAddr string) (*resolver, error) {
+	r := &resolver{}
+	conn, err := r.client.Dial(dnsServerAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial DNS server: %w", err)
+	}
+	r.conn = conn
+	return r, nil
+}
+
+// close closes the connection to the DNS server.
+func (r *resolver) close() {
+	_ = r.conn.Close()
+}
+
+// query queries the DNS server for the records of the given type. The non-existent domain results in no records.
+func (r *resolver) query(ctx context.Context, name string, qtype uint16) ([]dns.RR, []dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	response, _, err := r.client.ExchangeWithConnContext(ctx, msg, r.conn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query DNS server: %w", err)
+	}
+	switch response.Rcode {
+	case dns.RcodeSuccess:
+		return response.Answer, response.Extra, nil
+	case dns.RcodeNameError:
+		return nil, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("DNS query failed: %s", dns.RcodeToString[response.Rcode])
+	}
+}
+
+// resolveIPs resolves the hostname to both IPv4 and IPv6 addresses.
+func (r *resolver) resolveIPs(ctx context.Context, hostname, port string) (addrs []resolvedAddress, ttl time.Duration, err error) {
+	ttl = maxResolveInterval
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		var answers []dns.RR
+		if answers, _, err = r.query(ctx, hostname, qtype); err != nil {
+			return nil, 0, err
+		}
+		for _, rr := range answers {
+			if ip := ipOf(rr); ip != "" {
+				addrs = append(addrs, resolvedAddress{ipPort: net.JoinHostPort(ip, port), hostname: hostname})
+				ttl = min(ttl, time.Duration(rr.Header().Ttl)*time.Second)
+			}
+		}
+	}
+	return
+}
+
+// resolveSRV resolves the SRV records of the name to the addresses of the targets. The addresses in the additional
+// section of the response are used if available, otherwise the targets are resolved separately.
+func (r *resolver) resolveSRV(ctx context.Context, name string) (addrs []resolvedAddress, ttl time.Duration, err error) {
+	ttl = maxResolveInterval
+	answers, extra, err := r.query(ctx, name, dns.TypeSRV)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, rr := range answers {
+		srv, ok := rr.(*dns.SRV)
+		if !ok {
+			continue
+		}
+		ttl = min(ttl, time.Duration(srv.Hdr.Ttl)*time.Second)
+		target := strings.TrimSuffix(srv.Target, ".")
+		port := strconv.Itoa(int(srv.Port))
+		found := false
+		for _, e := range extra {
+			if ip := ipOf(e); ip != "" && strings.EqualFold(e.Header().Name, srv.Target) {
+				addrs = append(addrs, resolvedAddress{ipPort: net.JoinHostPort(ip, port), hostname: target})
+				ttl = min(ttl, time.Duration(e.Header().Ttl)*time.Second)
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		targetAddrs, targetTTL, err := r.resolveIPs(ctx, target, port)
+		if err != nil {
+			return nil, 0, err
+		}
+		addrs = append(addrs, targetAddrs...)
+		ttl = min(ttl, targetTTL)
+	}
+	return
+}
+
+// ipOf returns the IP address of the A or AAAA record, or empty string for the other records.
+func ipOf(rr dns.RR) string {
+	switch rr := rr.(type) {
+	case *dns.A:
+		return rr.A.String()
+	case *dns.AAAA:
+		return rr.AAAA.String()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package dynlb
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
+)
+
+func TestDynamicLoadBalancer_resolve(t *testing.T) {
+	dlb := &dynamicLoadBalancer{
+		logger:        slog.Default(),
+		dnsServerAddr: internaltesting.RequireNewTestDNSServer(t),
+		backends: []filterapi.DynamicLoadBalancingBackend{
+			{IPs: []string{"2001:db8::10"}, Port: 8080},
+			{Hostnames: []string{"ipv6.io", "dualstack.io", "nxdomain.io"}, Port: 9999},
+			{Hostnames: []string{"_http._tcp.srv.io", "_http._tcp.noextra.io"}},
+		},
+	}
+	endpoints, ttl, err := dlb.resolve(t.Context())
+	require.NoError(t, err)
+	// The non-existent domain makes the resolution retried sooner.
+	require.Equal(t, retryResolveInterval, ttl)
+	require.ElementsMatch(t, []endpoint{
+		{ipPort: []byte("[2001:db8::10]:8080"), backend: &dlb.backends[0].Backend},
+		{ipPort: []byte("[2001:db8::1]:9999"), hostname: "ipv6.io", backend: &dlb.backends[1].Backend},
+		{ipPort: []byte("5.5.5.5:9999"), hostname: "dualstack.io", backend: &dlb.backends[1].Backend},
+		{ipPort: []byte("[2001:db8::2]:9999"), hostname: "dualstack.io", backend: &dlb.backends[1].Backend},
+		{ipPort: []byte("6.6.6.6:8000"), hostname: "pod-a.srv.io", backend: &dlb.backends[2].Backend},
+		{ipPort: []byte("[2001:db8::3]:8001"), hostname: "pod-b.srv.io", backend: &dlb.backends[2].Backend},
+		{ipPort: []byte("2.2.2.2:8000"), hostname: "example.com", backend: &dlb.backends[2].Backend},
+	}, endpoints)
+}
+
+func TestDynamicLoadBalancer_runResolver(t *testing.T) {
+	// The IP address of the hostname changes from 1.1.1.1 to 2.2.2.2 after the first query.
+	var queried atomic.Int32
+	addr := internaltesting.RequireNewTestDNSServerWithRecords(t, func(name string, qtype uint16) (answer, extra []string, rcode int) {
+		if qtype != dns.TypeA || name != "pods.io." {
+			return
+		}
+		if queried.Add(1) == 1 {
+			return []string{name + " 1 A 1.1.1.1", name + " 1 A 3.3.3.3"}, nil, dns.RcodeSuccess
+		}
+		return []string{name + " 1 A 2.2.2.2", name + " 1 A 3.3.3.3"}, nil, dns.RcodeSuccess
+	})
+
+	_dlb, err := newDynamicLoadBalancer(t.Context(), slog.Default(), &filterapi.DynamicLoadBalancing{
+		Backends: []filterapi.DynamicLoadBalancingBackend{{Hostnames: []string{"pods.io"}, Port: 8080}},
+		Models:   []filterapi.DynamicLoadBalancingModel{{Name: "foo"}},
+	}, addr)
+	require.NoError(t, err)
+	dlb := _dlb.(*dynamicLoadBalancer)
+	endpoints := *dlb.endpoints.Load()
+	require.Len(t, endpoints, 2)
+	stats := endpoints[1].stats
+	require.Equal(t, "3.3.3.3:8080", string(endpoints[1].ipPort))
+
+	// The endpoints are swapped after the TTL expires, and the load of the remaining endpoint is carried over.
+	require.Eventually(t, func() bool {
+		endpoints = *dlb.endpoints.Load()
+		return string(endpoints[0].ipPort) == "2.2.2.2:8080"
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Len(t, endpoints, 2)
+	require.Same(t, stats, endpoints[1].stats)
+}
+
+func TestDynamicLoadBalancer_swapEndpoints(t *testing.T) {
+	backend := &filterapi.Backend{Name: "foo"}
+	dlb := &dynamicLoadBalancer{}
+	dlb.swapEndpoints([]endpoint{
+		{ipPort: []byte("1.1.1.1:8080"), backend: backend},
+		{ipPort: []byte("2.2.2.2:8080"), backend: backend},
+	})
+	prev := *dlb.endpoints.Load()
+	prev[1].stats.outstanding.Store(3)
+
+	dlb.swapEndpoints([]endpoint{
+		{ipPort: []byte("2.2.2.2:8080"), backend: backend},
+		{ipPort: []byte("3.3.3.3:8080"), backend: backend},
+		{ipPort: []byte("2.2.2.2:8080"), backend: &filterapi.Backend{Name: "bar"}},
+	})
+	current := *dlb.endpoints.Load()
+	require.Same(t, prev[1].stats, current[0].stats)
+	require.Equal(t, int64(0), current[1].stats.outstanding.Load())
+	require.NotSame(t, prev[1].stats, current[2].stats)
+}
@@ -0,0 +1,322 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/tokenizer"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
+)
+
+// EmbeddingsProcessorFactory returns a factory method to instantiate the embeddings processor.
+func EmbeddingsProcessorFactory(em x.EmbeddingsMetrics) ProcessorFactory {
+	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger) (Processor, error) {
+		if config.schema.Name != filterapi.APISchemaOpenAI {
+			return nil, fmt.Errorf("unsupported API schema: %s", config.schema.Name)
+		}
+		return &embeddingsProcessor{
+			config:         config,
+			requestHeaders: requestHeaders,
+			logger:         logger,
+			metrics:        em,
+			metricAttrs:    config.metricAttributes.Attributes(requestHeaders),
+			usage:          newUsageRecorder(usageRecordOperationEmbeddings),
+		}, nil
+	}
+}
+
+// embeddingsProcessor handles the processing of the request and response messages for a single stream
+// of the /v1/embeddings endpoint.
+type embeddingsProcessor struct {
+	logger           *slog.Logger
+	config           *processorConfig
+	requestHeaders   map[string]string
+	responseHeaders  map[string]string
+	responseEncoding string
+	translator       translator.OpenAIEmbeddingTranslator
+	// costs is the cost of the request that is accumulated during the processing of the response.
+	costs translator.LLMTokenUsage
+	// estimatedInputTokens is the number of input tokens estimated before the request is sent to the backend.
+	// This is only calculated when a request cost uses it.
+	estimatedInputTokens uint32
+	// metrics tracking.
+	metrics x.EmbeddingsMetrics
+	// metricAttrs are the extra attributes of the metrics derived from the request headers.
+	metricAttrs []attribute.KeyValue
+	// upstream is where the request is sent, which the cost and the usage of the request are attributed to.
+	upstream upstream
+	// spend charges the cost of the request to the spend budget of the client.
+	spend spendBudgetTracker
+	// usage emits the usage record of the request.
+	usage usageRecorder
+	// upstreamSpan is the span of the request sent to the upstream, which ends when its response completes.
+	upstreamSpan trace.Span
+	// circuitBreaker is not nil if the request is sent to the backend with the circuit breaker.
+	circuitBreaker *circuitBreakerCall
+	// experimentArm is not nil if the request is assigned to an arm of the experiment.
+	experimentArm *experimentArm
+}
+
+// selectTranslator selects the translator based on the output schema.
+func (e *embeddingsProcessor) selectTranslator(out filterapi.VersionedAPISchema) error {
+	if e.translator != nil { // Prevents re-selection and allows translator injection in tests.
+		return nil
+	}
+	switch out.Name {
+	case filterapi.APISchemaOpenAI:
+		e.translator = translator.NewEmbeddingOpenAIToOpenAITranslator()
+	case filterapi.APISchemaAWSBedrock:
+		e.translator = translator.NewEmbeddingOpenAIToAWSBedrockTranslator()
+	case filterapi.APISchemaAzureOpenAI:
+		e.translator = translator.NewEmbeddingOpenAIToAzureOpenAITranslator(out.Version)
+	default:
+		return fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+	return nil
+}
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+func (e *embeddingsProcessor) ProcessRequestHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	// Start tracking metrics for this request.
+	e.metrics.StartRequest(e.requestHeaders)
+
+	// The request headers have already been at the time the processor was created.
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+		RequestHeaders: &extprocv3.HeadersResponse{},
+	}}, nil
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (e *embeddingsProcessor) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			e.metrics.RecordRequestCompletion(ctx, false, failureAttrs(e.metricAttrs, aigwerrors.TypeOf(err))...)
+		}
+	}()
+	model, body, err := parseOpenAIEmbeddingBody(rawBody)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.ClientError, fmt.Errorf("failed to parse request body: %w", err))
+	}
+	e.logger.Info("processing request body", "path", e.requestHeaders[":path"], "model", model)
+	setRequestSpanAttributes(trace.SpanFromContext(ctx), usageRecordOperationEmbeddings, model)
+	recordPrompt(ctx, e.config, rawBody.Body)
+
+	e.metrics.SetModel(model)
+	if rejected, t := e.spend.check(ctx, e.config, e.requestHeaders, e.logger); rejected != nil {
+		e.metrics.RecordRequestCompletion(ctx, false, failureAttrs(e.metricAttrs, t)...)
+		return rejected, nil
+	}
+	e.requestHeaders[e.config.modelNameHeaderKey] = model
+	_, routeSpan := e.config.tracing.Start(ctx, "route", trace.SpanKindInternal)
+	b, err := e.config.router.Calculate(e.requestHeaders)
+	if err != nil {
+		tracing.End(routeSpan, err)
+		if errors.Is(err, x.ErrNoMatchingRule) {
+			e.metrics.RecordRequestCompletion(ctx, false, failureAttrs(e.metricAttrs, aigwerrors.ClientError)...)
+			return noMatchingRuleResponse(err), nil
+		}
+		return nil, fmt.Errorf("failed to calculate route: %w", err)
+	}
+	e.experimentArm = e.config.experimentArms[b]
+	e.metricAttrs = e.experimentArm.metricAttrs(e.metricAttrs)
+	routeSpan.SetAttributes(aigwBackend.String(b.Name))
+	routeSpan.End()
+	if b.DynamicLoadBalancing != nil {
+		// TODO: the dynamic load balancer only knows how to select endpoints for chat completions for now.
+		return nil, fmt.Errorf("dynamic load balancing is not supported for embeddings: backend=%s", b.Name)
+	}
+	// The embeddings metrics do not record the transitions of the circuit breakers, so they are only logged.
+	e.circuitBreaker = admitCircuitBreaker(ctx, e.config, b, nil, e.logger)
+
+	e.logger.Info("selected backend", "backend", b.Name, "schema", b.Schema)
+	e.metrics.SetBackend(b)
+
+	if err = e.selectTranslator(b.Schema); err != nil {
+		return nil, fmt.Errorf("failed to select translator: %w", err)
+	}
+
+	_, translateSpan := e.config.tracing.Start(ctx, "translate request", trace.SpanKindInternal,
+		attribute.String("aigw.backend.schema", string(b.Schema.Name)))
+	backendModel, mappedRaw, err := mapModelName(b.ModelNameMappings, model, rawBody.Body)
+	if err != nil {
+		tracing.End(translateSpan, err)
+		return nil, fmt.Errorf("failed to map model name: %w", err)
+	}
+	if mappedRaw != nil {
+		mapped := *body
+		mapped.Model = backendModel
+		body = &mapped
+		e.metrics.SetModel(backendModel)
+	}
+	e.upstream = upstream{backend: b.Name, model: backendModel}
+
+	if e.config.estimateInputTokens {
+		e.estimatedInputTokens = tokenizer.EmbeddingInputTokens(tokenizer.ForModel(backendModel), body)
+	}
+
+	headerMutation, bodyMutation, err := e.translator.RequestBody(body)
+	tracing.End(translateSpan, err)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform request: %w", err))
+	}
+
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	}
+	if mappedRaw != nil && bodyMutation == nil {
+		// The translator passes the original request through, so the one with the mapped model name needs to replace it.
+		bodyMutation = replaceRequestBody(headerMutation, mappedRaw)
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		// Set the model name to the request header with the key `x-ai-eg-model`.
+		Header: &corev3.HeaderValue{Key: e.config.modelNameHeaderKey, RawValue: []byte(model)},
+	}, &corev3.HeaderValueOption{
+		// Also set the selected backend to the request header with the key `x-ai-eg-selected-backend`.
+		Header: &corev3.HeaderValue{Key: e.config.selectedBackendHeaderKey, RawValue: []byte(b.Name)},
+	})
+	if authHandler, ok := e.config.backendAuthHandlers[b.Name]; ok {
+		authCtx, authSpan := e.config.tracing.Start(ctx, "auth", trace.SpanKindInternal)
+		err = authHandler.Do(authCtx, e.requestHeaders, headerMutation, bodyMutation)
+		tracing.End(authSpan, err)
+		if err != nil {
+			return nil, aigwerrors.Wrap(aigwerrors.AuthError, fmt.Errorf("failed to do auth request: %w", err))
+		}
+	}
+	var upstreamCtx context.Context
+	upstreamCtx, e.upstreamSpan = startUpstreamSpan(ctx, e.config, b, e.upstream)
+	injectTraceContext(upstreamCtx, e.config, headerMutation)
+
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation:  headerMutation,
+					BodyMutation:    bodyMutation,
+					ClearRouteCache: true,
+				},
+			},
+		},
+		DynamicMetadata: e.experimentArm.addDynamicMetadata(e.config,
+			buildEstimatedCostDynamicMetadata(e.config, e.estimatedInputTokens, e.logger)),
+	}, nil
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (e *embeddingsProcessor) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			e.metrics.RecordRequestCompletion(ctx, false, failureAttrs(e.metricAttrs, aigwerrors.TypeOf(err))...)
+		}
+	}()
+	e.responseHeaders = headersToMap(headers)
+	setResponseStatusSpanAttributes(e.upstreamSpan, e.responseHeaders[":status"])
+	status, _ := strconv.Atoi(e.responseHeaders[":status"])
+	e.circuitBreaker.done(ctx, status, nil)
+	e.circuitBreaker = nil
+	if enc := e.responseHeaders["content-encoding"]; enc != "" {
+		e.responseEncoding = enc
+	}
+	// The translator can be nil as there could be response event generated by previous ext proc without
+	// getting the request event.
+	if e.translator == nil {
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+			ResponseHeaders: &extprocv3.HeadersResponse{},
+		}}, nil
+	}
+	headerMutation, err := e.translator.ResponseHeaders(e.responseHeaders)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response headers: %w", err))
+	}
+	headerMutation = e.experimentArm.setResponseHeaders(headerMutation)
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HeadersResponse{
+			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
+		},
+	}}, nil
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (e *embeddingsProcessor) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		recordResponseCompletion(ctx, e.metrics, e.metricAttrs, e.responseHeaders, err)
+	}()
+	var br io.Reader
+	switch e.responseEncoding {
+	case "gzip":
+		br, err = gzip.NewReader(bytes.NewReader(body.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+	default:
+		br = bytes.NewReader(body.Body)
+	}
+	// The translator can be nil as there could be response event generated by previous ext proc without
+	// getting the request event.
+	if e.translator == nil {
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{}}, nil
+	}
+
+	headerMutation, bodyMutation, tokenUsage, err := e.translator.ResponseBody(e.responseHeaders, br, body.EndOfStream)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response: %w", err))
+	}
+
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation,
+					BodyMutation:   bodyMutation,
+				},
+			},
+		},
+	}
+
+	e.costs.InputTokens += tokenUsage.InputTokens
+	e.costs.TotalTokens += tokenUsage.TotalTokens
+	e.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.TotalTokens, e.metricAttrs...)
+
+	if body.EndOfStream {
+		endUpstreamSpan(ctx, e.upstreamSpan, e.costs)
+		e.upstreamSpan = nil
+		e.spend.record(ctx, e.config, e.upstream, e.costs, e.logger)
+		e.usage.emit(e.config, e.requestHeaders, e.responseHeaders, e.upstream, e.costs, false)
+	}
+	if body.EndOfStream && len(e.config.requestCosts) > 0 {
+		resp.DynamicMetadata, err = buildDynamicMetadata(e.config, &e.costs, e.estimatedInputTokens, e.requestHeaders, e.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+func parseOpenAIEmbeddingBody(body *extprocv3.HttpBody) (modelName string, rb *openai.EmbeddingRequest, err error) {
+	var openAIReq openai.EmbeddingRequest
+	if err := json.Unmarshal(body.Body, &openAIReq); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	return openAIReq.Model, &openAIReq, nil
+}
@@ -0,0 +1,180 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func TestEmbeddings_Schema(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		cfg := &processorConfig{schema: filterapi.VersionedAPISchema{Name: "Foo", Version: "v123"}}
+		_, err := EmbeddingsProcessorFactory(nil)(cfg, nil, nil)
+		require.ErrorContains(t, err, "unsupported API schema: Foo")
+	})
+	t.Run("supported openai", func(t *testing.T) {
+		cfg := &processorConfig{schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}}
+		_, err := EmbeddingsProcessorFactory(nil)(cfg, nil, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestEmbeddings_SelectTranslator(t *testing.T) {
+	for _, schema := range []filterapi.APISchemaName{filterapi.APISchemaOpenAI, filterapi.APISchemaAWSBedrock, filterapi.APISchemaAzureOpenAI} {
+		t.Run(string(schema), func(t *testing.T) {
+			e := &embeddingsProcessor{}
+			require.NoError(t, e.selectTranslator(filterapi.VersionedAPISchema{Name: schema}))
+			require.NotNil(t, e.translator)
+		})
+	}
+	t.Run("unsupported", func(t *testing.T) {
+		e := &embeddingsProcessor{}
+		err := e.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
+		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
+	})
+}
+
+func TestEmbeddings_ProcessRequestBody(t *testing.T) {
+	someBody := []byte(`{"model":"some-model","input":"hello"}`)
+	var expBody openai.EmbeddingRequest
+	require.NoError(t, json.Unmarshal(someBody, &expBody))
+
+	t.Run("body parser error", func(t *testing.T) {
+		mm := &mockEmbeddingsMetrics{}
+		e := &embeddingsProcessor{metrics: mm}
+		_, err := e.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
+		require.ErrorContains(t, err, "failed to parse request body")
+		require.Equal(t, 1, mm.requestErrorCount)
+	})
+	t.Run("router error 404", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/embeddings"}
+		mm := &mockEmbeddingsMetrics{}
+		e := &embeddingsProcessor{
+			config:         &processorConfig{router: mockRouter{t: t, expHeaders: headers, retErr: x.ErrNoMatchingRule}},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		resp, err := e.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_NotFound, resp.GetImmediateResponse().GetStatus().GetCode())
+		require.Equal(t, 1, mm.requestErrorCount)
+	})
+	t.Run("dynamic load balancing", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/embeddings"}
+		mm := &mockEmbeddingsMetrics{}
+		e := &embeddingsProcessor{
+			config: &processorConfig{router: mockRouter{
+				t: t, expHeaders: headers, retBackendName: "pool", retBackendDynamicLB: &filterapi.DynamicLoadBalancing{},
+			}},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		_, err := e.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+		require.ErrorContains(t, err, "dynamic load balancing is not supported for embeddings: backend=pool")
+		require.Equal(t, 1, mm.requestErrorCount)
+	})
+	t.Run("ok", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/embeddings"}
+		headerMut := &extprocv3.HeaderMutation{}
+		bodyMut := &extprocv3.BodyMutation{}
+		mt := mockEmbeddingTranslator{t: t, expRequestBody: &expBody, retHeaderMutation: headerMut, retBodyMutation: bodyMut}
+		mm := &mockEmbeddingsMetrics{}
+		e := &embeddingsProcessor{
+			config: &processorConfig{
+				router: mockRouter{
+					t: t, expHeaders: headers, retBackendName: "some-backend",
+					retVersionedAPISchema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				},
+				selectedBackendHeaderKey: "x-ai-gateway-backend-key",
+				modelNameHeaderKey:       "x-ai-gateway-model-key",
+			},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+			translator:     mt,
+		}
+		resp, err := e.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+		require.NoError(t, err)
+		commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestBody).RequestBody.Response
+		require.Equal(t, headerMut, commonRes.HeaderMutation)
+		require.Equal(t, bodyMut, commonRes.BodyMutation)
+		require.Equal(t, "some-model", mm.model)
+		require.Equal(t, "some-backend", mm.backend)
+		require.Zero(t, mm.requestErrorCount+mm.requestSuccessCount)
+
+		hdrs := headerMut.SetHeaders
+		require.Len(t, hdrs, 2)
+		require.Equal(t, "x-ai-gateway-model-key", hdrs[0].Header.Key)
+		require.Equal(t, "some-model", string(hdrs[0].Header.RawValue))
+		require.Equal(t, "x-ai-gateway-backend-key", hdrs[1].Header.Key)
+		require.Equal(t, "some-backend", string(hdrs[1].Header.RawValue))
+	})
+}
+
+func TestEmbeddings_ProcessResponseHeaders(t *testing.T) {
+	inHeaders := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}}
+	mm := &mockEmbeddingsMetrics{}
+	mt := &mockEmbeddingTranslator{t: t, expHeaders: map[string]string{":status": "200"}}
+	e := &embeddingsProcessor{translator: mt, metrics: mm}
+	_, err := e.ProcessResponseHeaders(t.Context(), inHeaders)
+	require.NoError(t, err)
+
+	mt.retErr = errors.New("test error")
+	_, err = e.ProcessResponseHeaders(t.Context(), inHeaders)
+	require.ErrorContains(t, err, "test error")
+	require.Equal(t, 1, mm.requestErrorCount)
+}
+
+func TestEmbeddings_ProcessResponseBody(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockEmbeddingsMetrics{}
+		e := &embeddingsProcessor{translator: &mockEmbeddingTranslator{t: t, retErr: errors.New("test error")}, metrics: mm}
+		_, err := e.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.ErrorContains(t, err, "test error")
+		require.Equal(t, 1, mm.requestErrorCount)
+		require.Equal(t, 0, mm.tokenUsageCount)
+	})
+	t.Run("ok", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		mm := &mockEmbeddingsMetrics{}
+		mt := &mockEmbeddingTranslator{
+			t: t, expResponseBody: inBody, retUsedToken: translator.LLMTokenUsage{InputTokens: 7, TotalTokens: 7},
+		}
+		e := &embeddingsProcessor{
+			translator: mt,
+			logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:    mm,
+			config: &processorConfig{
+				metadataNamespace: "ai_gateway_llm_ns",
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input_token_usage"}},
+				},
+			},
+		}
+		res, err := e.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		require.Equal(t, 1, mm.requestSuccessCount)
+		require.Equal(t, 1, mm.tokenUsageCount)
+		require.Equal(t, float64(7), res.DynamicMetadata.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["input_token_usage"].GetNumberValue())
+	})
+}
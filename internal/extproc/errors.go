@@ -0,0 +1,91 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+// errorResponse builds the immediate response in the OpenAI error format.
+func errorResponse(status int, errType, code, message string) *extprocv3.ProcessingResponse {
+	body, _ := json.Marshal(openai.Error{
+		Type:  "error",
+		Error: openai.ErrorType{Type: errType, Code: &code, Message: message},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode(status)}, //nolint:gosec
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{
+						{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}},
+					},
+				},
+				Body: body,
+			},
+		},
+	}
+}
+
+// classifiedErrorResponse builds the immediate response for the failure of the request processing based on
+// its classification. The error message is only exposed to the client when it is caused by the request itself
+// since other ones may contain the details of the backends.
+func classifiedErrorResponse(err error) *extprocv3.ProcessingResponse {
+	t := aigwerrors.TypeOf(err)
+	status := t.Status()
+	message := http.StatusText(status)
+	if t == aigwerrors.ClientError {
+		message = err.Error()
+	}
+	return errorResponse(status, aigwerrors.OpenAIErrorType(status), string(t), message)
+}
+
+// noMatchingRuleResponse builds the immediate response for the request that does not match any route rule,
+// which is usually because of the unknown model.
+func noMatchingRuleResponse(err error) *extprocv3.ProcessingResponse {
+	return errorResponse(http.StatusNotFound, aigwerrors.OpenAIErrorType(http.StatusNotFound), "model_not_found", err.Error())
+}
+
+// failureAttrs returns the metric attributes of the failed request classified as the given type.
+// The given attributes are not modified as they are shared across the metrics of the request.
+func failureAttrs(attrs []attribute.KeyValue, t aigwerrors.Type) []attribute.KeyValue {
+	return append(attrs[:len(attrs):len(attrs)], metrics.ErrorTypeAttribute(t))
+}
+
+// requestCompletionRecorder is the metrics recording the completion of the request, which is common to
+// the chat completion and embeddings metrics.
+type requestCompletionRecorder interface {
+	RecordRequestCompletion(ctx context.Context, success bool, extraAttrs ...attribute.KeyValue)
+}
+
+// recordResponseCompletion records the completion of the request after processing its response. The request is
+// failed if either the processing failed or the upstream responded with the error status.
+func recordResponseCompletion(ctx context.Context, m requestCompletionRecorder, attrs []attribute.KeyValue,
+	responseHeaders map[string]string, err error,
+) {
+	if err != nil {
+		m.RecordRequestCompletion(ctx, false, failureAttrs(attrs, aigwerrors.TypeOf(err))...)
+		return
+	}
+	status, _ := strconv.Atoi(responseHeaders[":status"])
+	if t := aigwerrors.FromStatus(status); t != "" {
+		m.RecordRequestCompletion(ctx, false, failureAttrs(attrs, t)...)
+		return
+	}
+	m.RecordRequestCompletion(ctx, true, attrs...)
+}
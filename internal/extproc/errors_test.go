@@ -0,0 +1,83 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+)
+
+func TestClassifiedErrorResponse(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		err       error
+		expStatus typev3.StatusCode
+		expBody   string
+	}{
+		{
+			name:      "client error",
+			err:       aigwerrors.Wrap(aigwerrors.ClientError, errors.New("failed to parse request body")),
+			expStatus: typev3.StatusCode_BadRequest,
+			expBody:   `{"type":"error","error":{"type":"invalid_request_error","code":"client_error","message":"failed to parse request body"}}`,
+		},
+		{
+			name:      "wrapped translation error",
+			err:       fmt.Errorf("cannot process request body: %w", aigwerrors.Wrap(aigwerrors.TranslationError, errors.New("secret"))),
+			expStatus: typev3.StatusCode_InternalServerError,
+			expBody:   `{"type":"error","error":{"type":"server_error","code":"translation_error","message":"Internal Server Error"}}`,
+		},
+		{
+			name:      "unclassified",
+			err:       errors.New("secret"),
+			expStatus: typev3.StatusCode_InternalServerError,
+			expBody:   `{"type":"error","error":{"type":"server_error","code":"_OTHER","message":"Internal Server Error"}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := classifiedErrorResponse(tc.err).GetImmediateResponse()
+			require.Equal(t, tc.expStatus, ir.Status.Code)
+			require.Equal(t, "application/json", string(ir.Headers.SetHeaders[0].Header.RawValue))
+			require.JSONEq(t, tc.expBody, string(ir.Body))
+		})
+	}
+}
+
+func TestRecordResponseCompletion(t *testing.T) {
+	attrs := []attribute.KeyValue{attribute.String("tenant", "a")}
+	for _, tc := range []struct {
+		name       string
+		status     string
+		err        error
+		expSuccess bool
+		expType    aigwerrors.Type
+	}{
+		{name: "ok", status: "200", expSuccess: true},
+		{name: "rate limited", status: "429", expType: aigwerrors.RateLimited},
+		{name: "upstream 5xx", status: "503", expType: aigwerrors.Upstream5xx},
+		{name: "upstream timeout", status: "504", expType: aigwerrors.UpstreamTimeout},
+		{name: "auth", status: "401", expType: aigwerrors.AuthError},
+		{name: "processing error", status: "200", err: aigwerrors.Wrap(aigwerrors.TranslationError, errors.New("bad")), expType: aigwerrors.TranslationError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mm := &mockChatCompletionMetrics{}
+			recordResponseCompletion(t.Context(), mm, attrs, map[string]string{":status": tc.status}, tc.err)
+			if tc.expSuccess {
+				mm.RequireRequestSuccess(t)
+			} else {
+				mm.RequireRequestFailureType(t, tc.expType)
+			}
+			// The shared attributes must not be modified.
+			require.Len(t, attrs, 1)
+		})
+	}
+}
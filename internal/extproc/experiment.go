@@ -0,0 +1,109 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"slices"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+)
+
+const (
+	// experimentHeaderKey is the response header carrying the name of the experiment the request is assigned to.
+	experimentHeaderKey = "x-ai-eg-experiment"
+	// experimentArmHeaderKey is the response header carrying the name of the arm the request is assigned to.
+	experimentArmHeaderKey = "x-ai-eg-experiment-arm"
+	// experimentMetadataKey and experimentArmMetadataKey are the fields of the dynamic metadata carrying the names
+	// of the experiment and the arm.
+	experimentMetadataKey    = "experiment"
+	experimentArmMetadataKey = "experiment_arm"
+)
+
+// experimentArm is the arm of the experiment a backend serves.
+type experimentArm struct {
+	experiment, arm string
+}
+
+// newExperimentArms maps each backend in the rule with the experiment to the arm it serves.
+func newExperimentArms(rule *filterapi.RouteRule, arms map[*filterapi.Backend]*experimentArm) {
+	if rule.Experiment == nil {
+		return
+	}
+	for i := range rule.Experiment.Arms {
+		a := &rule.Experiment.Arms[i]
+		arm := &experimentArm{experiment: rule.Experiment.Name, arm: a.Name}
+		for j := range rule.Backends {
+			if b := &rule.Backends[j]; slices.Contains(a.Backends, b.Name) {
+				arms[b] = arm
+			}
+		}
+	}
+}
+
+// sameExperimentArm returns true if the two backends of the rule serve the same arm of its experiment, which is
+// always the case if the rule has no experiment. The fallback and the hedged requests stay within the arm so that
+// the requests assigned to an arm are not served by the backends of the others.
+func sameExperimentArm(rule *filterapi.RouteRule, a, b *filterapi.Backend) bool {
+	if rule.Experiment == nil {
+		return true
+	}
+	for i := range rule.Experiment.Arms {
+		if backends := rule.Experiment.Arms[i].Backends; slices.Contains(backends, a.Name) {
+			return slices.Contains(backends, b.Name)
+		}
+	}
+	return false
+}
+
+// metricAttrs returns the attributes of the metrics with the experiment and the arm. This returns attrs as-is if
+// a is nil.
+func (a *experimentArm) metricAttrs(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if a == nil {
+		return attrs
+	}
+	return append(attrs[:len(attrs):len(attrs)], metrics.ExperimentAttributes(a.experiment, a.arm)...)
+}
+
+// setResponseHeaders returns the header mutation of the response with the headers of the experiment and the arm,
+// which is created if nil. This returns headerMutation as-is if a is nil.
+func (a *experimentArm) setResponseHeaders(headerMutation *extprocv3.HeaderMutation) *extprocv3.HeaderMutation {
+	if a == nil {
+		return headerMutation
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders,
+		&corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: experimentHeaderKey, RawValue: []byte(a.experiment)}},
+		&corev3.HeaderValueOption{Header: &corev3.HeaderValue{Key: experimentArmHeaderKey, RawValue: []byte(a.arm)}},
+	)
+	return headerMutation
+}
+
+// addDynamicMetadata adds the fields of the experiment and the arm to the dynamic metadata, which can be nil,
+// in the metadata namespace. This returns metadata as-is if a is nil.
+func (a *experimentArm) addDynamicMetadata(config *processorConfig, metadata *structpb.Struct) *structpb.Struct {
+	if a == nil {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	ns := metadata.Fields[config.metadataNamespace].GetStructValue()
+	if ns == nil {
+		ns = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+		metadata.Fields[config.metadataNamespace] = structpb.NewStructValue(ns)
+	}
+	ns.Fields[experimentMetadataKey] = structpb.NewStringValue(a.experiment)
+	ns.Fields[experimentArmMetadataKey] = structpb.NewStringValue(a.arm)
+	return metadata
+}
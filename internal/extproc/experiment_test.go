@@ -0,0 +1,146 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/router"
+)
+
+func newExperimentRule() *filterapi.RouteRule {
+	return &filterapi.RouteRule{
+		Backends: []filterapi.Backend{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		Experiment: &filterapi.Experiment{Name: "exp", Arms: []filterapi.ExperimentArm{
+			{Name: "control", Weight: 1, Backends: []string{"a", "b"}},
+			{Name: "treatment", Weight: 1, Backends: []string{"c"}},
+		}},
+	}
+}
+
+func Test_newExperimentArms(t *testing.T) {
+	arms := make(map[*filterapi.Backend]*experimentArm)
+	newExperimentArms(&filterapi.RouteRule{Backends: []filterapi.Backend{{Name: "a"}}}, arms)
+	require.Empty(t, arms)
+
+	rule := newExperimentRule()
+	newExperimentArms(rule, arms)
+	require.Len(t, arms, 3)
+	require.Equal(t, &experimentArm{experiment: "exp", arm: "control"}, arms[&rule.Backends[0]])
+	require.Same(t, arms[&rule.Backends[0]], arms[&rule.Backends[1]])
+	require.Equal(t, &experimentArm{experiment: "exp", arm: "treatment"}, arms[&rule.Backends[2]])
+}
+
+func Test_sameExperimentArm(t *testing.T) {
+	rule := newExperimentRule()
+	require.True(t, sameExperimentArm(rule, &rule.Backends[0], &rule.Backends[1]))
+	require.False(t, sameExperimentArm(rule, &rule.Backends[0], &rule.Backends[2]))
+	require.False(t, sameExperimentArm(rule, &rule.Backends[2], &rule.Backends[1]))
+	rule.Experiment = nil
+	require.True(t, sameExperimentArm(rule, &rule.Backends[0], &rule.Backends[2]))
+}
+
+func Test_experimentArm(t *testing.T) {
+	config := &processorConfig{metadataNamespace: "ns"}
+	t.Run("nil", func(t *testing.T) {
+		var a *experimentArm
+		attrs := []attribute.KeyValue{attribute.String("foo", "bar")}
+		require.Equal(t, attrs, a.metricAttrs(attrs))
+		require.Nil(t, a.setResponseHeaders(nil))
+		require.Nil(t, a.addDynamicMetadata(config, nil))
+	})
+
+	a := &experimentArm{experiment: "exp", arm: "control"}
+	t.Run("metric attributes", func(t *testing.T) {
+		attrs := make([]attribute.KeyValue, 1, 10)
+		attrs[0] = attribute.String("foo", "bar")
+		require.Equal(t, []attribute.KeyValue{
+			attribute.String("foo", "bar"),
+			attribute.String("aigw.experiment", "exp"),
+			attribute.String("aigw.experiment.arm", "control"),
+		}, a.metricAttrs(attrs))
+		// The spare capacity of the original attributes is not overwritten.
+		require.Equal(t, attribute.KeyValue{}, attrs[:2][1])
+	})
+	t.Run("response headers", func(t *testing.T) {
+		hm := a.setResponseHeaders(nil)
+		require.Equal(t, []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: "x-ai-eg-experiment", RawValue: []byte("exp")}},
+			{Header: &corev3.HeaderValue{Key: "x-ai-eg-experiment-arm", RawValue: []byte("control")}},
+		}, hm.SetHeaders)
+		hm = &extprocv3.HeaderMutation{RemoveHeaders: []string{"foo"}}
+		require.Same(t, hm, a.setResponseHeaders(hm))
+		require.Len(t, hm.SetHeaders, 2)
+	})
+	t.Run("dynamic metadata", func(t *testing.T) {
+		md := a.addDynamicMetadata(config, nil)
+		require.Equal(t, "exp", md.Fields["ns"].GetStructValue().Fields["experiment"].GetStringValue())
+		require.Equal(t, "control", md.Fields["ns"].GetStructValue().Fields["experiment_arm"].GetStringValue())
+
+		md = newDynamicMetadata(config, map[string]*structpb.Value{"cost": structpb.NewNumberValue(10)})
+		require.Same(t, md, a.addDynamicMetadata(config, md))
+		fields := md.Fields["ns"].GetStructValue().Fields
+		require.Len(t, fields, 3)
+		require.Equal(t, float64(10), fields["cost"].GetNumberValue())
+		require.Equal(t, "control", fields["experiment_arm"].GetStringValue())
+	})
+}
+
+func TestChatCompletion_experiment(t *testing.T) {
+	rule := newExperimentRule()
+	rule.Headers = []filterapi.HeaderMatch{{Name: "x-model", Value: "some-model"}}
+	rule.StickyRouting = &filterapi.StickyRouting{HeaderName: "x-user-id"}
+	config := &filterapi.Config{Rules: []filterapi.RouteRule{*rule}}
+	rt, err := router.New(config, nil, nil)
+	require.NoError(t, err)
+	arms := make(map[*filterapi.Backend]*experimentArm)
+	newExperimentArms(&config.Rules[0], arms)
+
+	body := []byte(`{"model":"some-model","messages":[{"role":"user","content":"hello"}]}`)
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal(body, &expBody))
+	chosen := make(map[string]int)
+	for _, user := range []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi"} {
+		mm := &mockChatCompletionMetrics{}
+		p := &chatCompletionProcessor{
+			config: &processorConfig{
+				router:             rt,
+				experimentArms:     arms,
+				metadataNamespace:  "ns",
+				modelNameHeaderKey: "x-model",
+			},
+			requestHeaders: map[string]string{"x-user-id": user},
+			logger:         slog.Default(),
+			metrics:        mm,
+			translator:     mockTranslator{t: t, expRequestBody: &expBody},
+		}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: body})
+		require.NoError(t, err)
+		arm := resp.DynamicMetadata.Fields["ns"].GetStructValue().Fields["experiment_arm"].GetStringValue()
+		require.Contains(t, []string{"control", "treatment"}, arm)
+		require.Contains(t, p.metricAttrs, attribute.String("aigw.experiment.arm", arm))
+		chosen[arm]++
+
+		p.translator = mockTranslator{t: t, expHeaders: map[string]string{":status": "200"}}
+		resp, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		hdrs := resp.GetResponseHeaders().Response.HeaderMutation.SetHeaders
+		require.Len(t, hdrs, 2)
+		require.Equal(t, "exp", string(hdrs[0].Header.RawValue))
+		require.Equal(t, arm, string(hdrs[1].Header.RawValue))
+	}
+	require.Len(t, chosen, 2)
+}
@@ -0,0 +1,179 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package export implements the background export of the items produced by the requests, such as the usage records
+// and the audit log entries, to the sinks in batches.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// queueSize is the number of the items buffered for each sink. The items are dropped when it is full.
+	queueSize = 4096
+	// exportTimeout is the timeout of each export to a sink.
+	exportTimeout = 10 * time.Second
+	// initialBackoff is the wait before the first retry of a failed export, which doubles on each retry.
+	initialBackoff = 100 * time.Millisecond
+)
+
+// Sink exports the batches of the items.
+type Sink[T any] interface {
+	// Export exports the items.
+	Export(ctx context.Context, items []T) error
+	// Close releases the resources of the sink.
+	Close() error
+}
+
+// Pipeline exports the items to the sinks in the background.
+type Pipeline[T any] struct {
+	// kind is the human-readable name of the items used in the logs, e.g. "usage record".
+	kind    string
+	workers []*worker[T]
+	logger  *slog.Logger
+	// mux protects closed so that no item is emitted to the closed queues.
+	mux    sync.RWMutex
+	closed bool
+}
+
+// NewPipeline creates a new pipeline without any sink. The kind is the human-readable name of the items used in
+// the logs, e.g. "usage record".
+func NewPipeline[T any](kind string, logger *slog.Logger) *Pipeline[T] {
+	return &Pipeline[T]{kind: kind, logger: logger}
+}
+
+// AddSink starts exporting to the sink in batches of at most batchSize items, which are flushed at least every
+// flushInterval. A failed export is retried up to maxRetries times. The pipeline takes the ownership of the sink.
+//
+// This must be called before the first Emit.
+func (p *Pipeline[T]) AddSink(name string, sink Sink[T], batchSize int, flushInterval time.Duration, maxRetries int) {
+	w := &worker[T]{
+		name:          name,
+		kind:          p.kind,
+		sink:          sink,
+		queue:         make(chan T, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxRetries:    maxRetries,
+		backoff:       initialBackoff,
+		logger:        p.logger,
+		done:          make(chan struct{}),
+	}
+	p.workers = append(p.workers, w)
+	go w.run()
+}
+
+// Sinks returns the number of the sinks.
+func (p *Pipeline[T]) Sinks() int {
+	return len(p.workers)
+}
+
+// Emit queues the item to be exported to all the sinks. This never blocks, and the item is dropped for the sinks
+// whose queue is full.
+func (p *Pipeline[T]) Emit(item T) {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	if p.closed {
+		p.logger.Warn(fmt.Sprintf("dropping %s emitted after the pipeline is closed", p.kind))
+		return
+	}
+	for _, w := range p.workers {
+		select {
+		case w.queue <- item:
+		default:
+			p.logger.Warn(fmt.Sprintf("dropping %s since the queue is full", p.kind), "sink", w.name)
+		}
+	}
+}
+
+// Close exports the queued items and closes the sinks. This blocks until all of them are done.
+func (p *Pipeline[T]) Close() error {
+	p.mux.Lock()
+	if p.closed {
+		p.mux.Unlock()
+		return nil
+	}
+	p.closed = true
+	for _, w := range p.workers {
+		close(w.queue)
+	}
+	p.mux.Unlock()
+	var errs []error
+	for _, w := range p.workers {
+		<-w.done
+		if err := w.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close sink %s: %w", w.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// worker exports the items in its queue to the sink in batches.
+type worker[T any] struct {
+	name          string
+	kind          string
+	sink          Sink[T]
+	queue         chan T
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	backoff       time.Duration
+	logger        *slog.Logger
+	// done is closed when the worker has exported all the items after the queue is closed.
+	done chan struct{}
+}
+
+func (w *worker[T]) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+	batch := make([]T, 0, w.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			w.export(batch)
+			batch = make([]T, 0, w.batchSize)
+		}
+	}
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			if batch = append(batch, item); len(batch) >= w.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// export exports the batch to the sink, retrying with the exponential backoff on failure.
+func (w *worker[T]) export(batch []T) {
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		err := w.sink.Export(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= w.maxRetries {
+			w.logger.Error(fmt.Sprintf("dropping %ss after failing to export", w.kind), "sink", w.name,
+				"items", len(batch), "attempts", attempt+1, "error", err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink records the exported batches, failing the first failures exports.
+type recordingSink struct {
+	mux      sync.Mutex
+	batches  [][]string
+	failures int
+	attempts int
+	closed   bool
+}
+
+func (s *recordingSink) Export(_ context.Context, records []string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.attempts++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("export failed")
+	}
+	s.batches = append(s.batches, records)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *recordingSink) exported() (batches [][]string, attempts int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.batches, s.attempts
+}
+
+func newTestPipeline(sink Sink[string], batchSize int, flushInterval time.Duration, maxRetries int) *Pipeline[string] {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	w := &worker[string]{
+		name: "test", kind: "item", sink: sink, queue: make(chan string, 2), batchSize: batchSize, flushInterval: flushInterval,
+		maxRetries: maxRetries, backoff: time.Millisecond, logger: logger, done: make(chan struct{}),
+	}
+	go w.run()
+	return &Pipeline[string]{kind: "item", workers: []*worker[string]{w}, logger: logger}
+}
+
+func TestPipeline_batchSize(t *testing.T) {
+	sink := &recordingSink{}
+	p := newTestPipeline(sink, 2, time.Hour, 0)
+	p.Emit("1")
+	p.Emit("2")
+	require.Eventually(t, func() bool {
+		batches, _ := sink.exported()
+		return len(batches) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	batches, _ := sink.exported()
+	require.Len(t, batches[0], 2)
+	require.NoError(t, p.Close())
+	require.True(t, sink.closed)
+}
+
+func TestPipeline_flushInterval(t *testing.T) {
+	sink := &recordingSink{}
+	p := newTestPipeline(sink, 100, 10*time.Millisecond, 0)
+	defer func() { require.NoError(t, p.Close()) }()
+	p.Emit("1")
+	require.Eventually(t, func() bool {
+		batches, _ := sink.exported()
+		return len(batches) == 1 && len(batches[0]) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPipeline_Close(t *testing.T) {
+	sink := &recordingSink{}
+	p := newTestPipeline(sink, 100, time.Hour, 0)
+	p.Emit("1")
+	require.NoError(t, p.Close())
+	batches, _ := sink.exported()
+	require.Len(t, batches, 1)
+	require.True(t, sink.closed)
+
+	// Emitting and closing after the pipeline is closed are no-ops.
+	p.Emit("2")
+	require.NoError(t, p.Close())
+	batches, _ = sink.exported()
+	require.Len(t, batches, 1)
+}
+
+func TestPipeline_retry(t *testing.T) {
+	t.Run("succeeds after retries", func(t *testing.T) {
+		sink := &recordingSink{failures: 2}
+		p := newTestPipeline(sink, 1, time.Hour, 2)
+		p.Emit("1")
+		require.NoError(t, p.Close())
+		batches, attempts := sink.exported()
+		require.Len(t, batches, 1)
+		require.Equal(t, 3, attempts)
+	})
+	t.Run("dropped after max retries", func(t *testing.T) {
+		sink := &recordingSink{failures: 10}
+		p := newTestPipeline(sink, 1, time.Hour, 2)
+		p.Emit("1")
+		require.NoError(t, p.Close())
+		batches, attempts := sink.exported()
+		require.Empty(t, batches)
+		require.Equal(t, 3, attempts)
+	})
+}
+
+func TestPipeline_Emit_queueFull(t *testing.T) {
+	// The sink blocks until released so that the queue fills up.
+	release := make(chan struct{})
+	sink := &blockingSink{release: release}
+	p := newTestPipeline(sink, 1, time.Hour, 0)
+	for i := 0; i < 10; i++ {
+		p.Emit("")
+	}
+	close(release)
+	require.NoError(t, p.Close())
+	// One record is being exported when the queue of size 2 fills up, so the rest are dropped.
+	require.LessOrEqual(t, sink.count(), 3)
+}
+
+type blockingSink struct {
+	release chan struct{}
+	mux     sync.Mutex
+	n       int
+}
+
+func (s *blockingSink) Export(_ context.Context, records []string) error {
+	<-s.release
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.n += len(records)
+	return nil
+}
+
+func (s *blockingSink) Close() error { return nil }
+
+func (s *blockingSink) count() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.n
+}
+
+func TestPipeline_AddSink(t *testing.T) {
+	p := NewPipeline[string]("item", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	sinks := []*recordingSink{{}, {}}
+	for i, s := range sinks {
+		p.AddSink(strconv.Itoa(i), s, 10, time.Hour, 0)
+	}
+	require.Equal(t, 2, p.Sinks())
+	p.Emit("1")
+	require.NoError(t, p.Close())
+	for _, s := range sinks {
+		batches, _ := s.exported()
+		require.Equal(t, [][]string{{"1"}}, batches)
+		require.True(t, s.closed)
+	}
+}
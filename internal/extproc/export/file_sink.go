@@ -0,0 +1,49 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// fileSink is the Sink writing the items as JSON lines to the file rotated by size.
+type fileSink[T any] struct {
+	w *lumberjack.Logger
+}
+
+// NewFileSink creates a new Sink writing to the file at the path. The file is rotated when it reaches maxSizeMegabytes,
+// and at most maxBackups rotated files are kept.
+func NewFileSink[T any](path string, maxSizeMegabytes, maxBackups int) Sink[T] {
+	return &fileSink[T]{w: &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSizeMegabytes,
+		MaxBackups: maxBackups,
+	}}
+}
+
+// Export implements [Sink.Export].
+func (s *fileSink[T]) Export(_ context.Context, items []T) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return fmt.Errorf("failed to encode item: %w", err)
+		}
+	}
+	// The batch is written at once so that an item is never split across the files.
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// Close implements [Sink.Close].
+func (s *fileSink[T]) Close() error {
+	return s.w.Close()
+}
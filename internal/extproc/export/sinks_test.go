@@ -0,0 +1,60 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string `json:"name"`
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.jsonl")
+	s := NewFileSink[testItem](path, 1, 1)
+	require.NoError(t, s.Export(context.Background(), []testItem{{Name: "a"}, {Name: "b"}}))
+	require.NoError(t, s.Export(context.Background(), []testItem{{Name: "c"}}))
+	require.NoError(t, s.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "{\"name\":\"a\"}\n{\"name\":\"b\"}\n{\"name\":\"c\"}\n", string(content))
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []testItem
+	statusCode := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("content-type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte("some error"))
+	}))
+	defer srv.Close()
+
+	s := NewWebhookSink[testItem](srv.URL)
+	defer func() { require.NoError(t, s.Close()) }()
+	require.NoError(t, s.Export(context.Background(), []testItem{{Name: "a"}, {Name: "b"}}))
+	require.Len(t, received, 2)
+	require.Equal(t, testItem{Name: "a"}, received[0])
+
+	statusCode = http.StatusServiceUnavailable
+	err := s.Export(context.Background(), []testItem{{Name: "a"}})
+	require.EqualError(t, err, "webhook responded with status 503: some error")
+}
@@ -0,0 +1,56 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// webhookSink is the Sink sending the items as a JSON array with an HTTP POST request.
+type webhookSink[T any] struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a new Sink sending the items to the URL.
+func NewWebhookSink[T any](url string) Sink[T] {
+	return &webhookSink[T]{url: url, client: &http.Client{}}
+}
+
+// Export implements [Sink.Export].
+func (s *webhookSink[T]) Export(ctx context.Context, items []T) error {
+	body, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal items: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("webhook responded with status %d: %s", res.StatusCode, msg)
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+// Close implements [Sink.Close].
+func (s *webhookSink[T]) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,311 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
+)
+
+// defaultFailoverTimeout is the timeout of each request to the fallback backend when the fallback policy has none.
+const defaultFailoverTimeout = 5 * time.Minute
+
+// failoverHTTPClient is the HTTP client used to send the request directly to the fallback backends.
+// The timeout of each request is set by its context.
+var failoverHTTPClient = &http.Client{}
+
+// failoverSkippedHeaders are the headers that are not copied between the Envoy stream and the fallback request/response.
+var failoverSkippedHeaders = map[string]struct{}{
+	"host":              {},
+	"connection":        {},
+	"content-length":    {},
+	"transfer-encoding": {},
+	// The accept-encoding header is not copied so that the HTTP client transparently decompresses the response.
+	"accept-encoding":  {},
+	"content-encoding": {},
+}
+
+// failover sends the request to the fallback backends of the originally selected backend in order, and returns
+// the immediate response built from the first response that is not retriable.
+//
+// Since Envoy has already received the response headers from the original backend, the request to the fallback
+// backend is sent directly from this filter instead of Envoy, and the response is buffered entirely.
+//
+// This returns nil when there's no fallback backend available or all of them fail. In that case, the original
+// response is returned to the client as-is.
+func (c *chatCompletionProcessor) failover(ctx context.Context) (*extprocv3.ProcessingResponse, error) {
+	if c.upstreamSpan != nil {
+		c.upstreamSpan.SetStatus(codes.Error, "failing over")
+		c.upstreamSpan.End()
+		c.upstreamSpan = nil
+	}
+	attempts := 0
+	for _, b := range c.fallback.backends {
+		if attempts >= c.fallback.maxFallbacks {
+			break
+		}
+		if cb := c.config.circuitBreakers[b]; cb != nil && !cb.Available() {
+			c.logger.Info("skipping fallback backend with open circuit", "backend", b.Name)
+			continue
+		}
+		attempts++
+
+		c.logger.Info("failing over to the fallback backend", "backend", b.Name, "schema", b.Schema)
+		model, mapped := lookupModelNameMapping(b.ModelNameMappings, c.originalRequestBody.Model)
+		if !mapped {
+			model = c.originalRequestBody.Model
+		}
+		spanCtx, span := startUpstreamSpan(ctx, c.config, b, upstream{backend: b.Name, model: model})
+		cb := admitCircuitBreaker(ctx, c.config, b, c.metrics, c.logger)
+		timeoutCtx, cancel := context.WithTimeout(spanCtx, c.fallback.timeout)
+		tr, responseHeaders, responseBody, err := c.sendToFallbackBackend(timeoutCtx, b)
+		cancel()
+		status, _ := strconv.Atoi(responseHeaders[":status"])
+		cb.done(ctx, status, err)
+		if err != nil {
+			c.logger.Error("failed to send request to the fallback backend", "backend", b.Name, "error", err)
+			tracing.End(span, err)
+			continue
+		}
+		setResponseStatusSpanAttributes(span, responseHeaders[":status"])
+		if c.fallback.isRetriable(status) {
+			c.logger.Info("fallback backend responded with retriable status", "backend", b.Name, "status", status)
+			span.SetStatus(codes.Error, "failing over")
+			span.End()
+			continue
+		}
+		c.upstreamSpan = span
+		return c.directResponse(ctx, b, tr, responseHeaders, responseBody, 0)
+	}
+	return nil, nil
+}
+
+// sendToFallbackBackend translates the original request for the given backend, applies the backend auth, and sends it.
+// This returns the translator used for the request together with the response headers and the whole response body.
+func (c *chatCompletionProcessor) sendToFallbackBackend(ctx context.Context, b *filterapi.Backend) (
+	tr translator.OpenAIChatCompletionTranslator, responseHeaders map[string]string, responseBody []byte, err error,
+) {
+	return sendDirect(ctx, c.config, b, c.requestHeaders, c.originalRequestBody, c.originalRequestBodyRaw)
+}
+
+// sendDirect translates the request for the given backend, applies the backend auth, and sends it directly
+// instead of Envoy. The request headers are not modified, and the request body may be modified by the translator.
+func sendDirect(ctx context.Context, config *processorConfig, b *filterapi.Backend, requestHeaders map[string]string,
+	reqBody *openai.ChatCompletionRequest, body []byte,
+) (tr translator.OpenAIChatCompletionTranslator, responseHeaders map[string]string, responseBody []byte, err error) {
+	tr, err = newChatCompletionTranslator(b)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	backendModel, mappedRaw, err := mapModelName(b.ModelNameMappings, reqBody.Model, body)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to map model name: %w", err)
+	}
+	if mappedRaw != nil {
+		mapped := *reqBody
+		mapped.Model = backendModel
+		reqBody, body = &mapped, mappedRaw
+	}
+	headerMutation, bodyMutation, err := tr.RequestBody(reqBody)
+	if err != nil {
+		return nil, nil, nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform request: %w", err))
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	}
+	requestHeaders = maps.Clone(requestHeaders)
+	if authHandler, ok := config.backendAuthHandlers[b.Name]; ok {
+		if err = authHandler.Do(ctx, requestHeaders, headerMutation, bodyMutation); err != nil {
+			return nil, nil, nil, aigwerrors.Wrap(aigwerrors.AuthError, fmt.Errorf("failed to do auth request: %w", err))
+		}
+	}
+	applyHeaderMutation(requestHeaders, headerMutation)
+	maps.Copy(requestHeaders, config.tracing.Inject(ctx))
+
+	if mutated := bodyMutation.GetBody(); len(mutated) > 0 {
+		body = mutated
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.Endpoint+requestHeaders[":path"], bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range requestHeaders {
+		if _, skipped := failoverSkippedHeaders[strings.ToLower(k)]; skipped || strings.HasPrefix(k, ":") {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	res, err := failoverHTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	responseBody, err = io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	responseHeaders = map[string]string{":status": strconv.Itoa(res.StatusCode)}
+	for k := range res.Header {
+		if _, skipped := failoverSkippedHeaders[strings.ToLower(k)]; !skipped {
+			responseHeaders[strings.ToLower(k)] = res.Header.Get(k)
+		}
+	}
+	return tr, responseHeaders, responseBody, nil
+}
+
+// directResponse translates the response from the backend the request was sent to directly, i.e. the fallback
+// or the hedged backend, and builds the immediate response returned to the client instead of the one from Envoy.
+//
+// cancelledCalls is the number of the other requests cancelled in flight. The backends bill their prompts anyway,
+// so the input tokens of this response are counted once more for each of them. otherUsages are the token usages of
+// the other requests that have been responded, which are counted as they are.
+func (c *chatCompletionProcessor) directResponse(ctx context.Context, b *filterapi.Backend,
+	tr translator.OpenAIChatCompletionTranslator, responseHeaders map[string]string, responseBody []byte, cancelledCalls int,
+	otherUsages ...translator.LLMTokenUsage,
+) (*extprocv3.ProcessingResponse, error) {
+	c.logger.Info("backend responded", "backend", b.Name, "status", responseHeaders[":status"])
+	c.metrics.SetBackend(b)
+	model, mapped := lookupModelNameMapping(b.ModelNameMappings, c.originalRequestBody.Model)
+	if mapped {
+		c.metrics.SetModel(model)
+	} else {
+		model = c.originalRequestBody.Model
+	}
+	c.upstream = upstream{backend: b.Name, model: model}
+	c.requestHeaders[c.config.selectedBackendHeaderKey] = b.Name
+
+	headerMutation, err := tr.ResponseHeaders(responseHeaders)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response headers: %w", err))
+	}
+	applyHeaderMutation(responseHeaders, headerMutation)
+	bodyHeaderMutation, bodyMutation, tokenUsage, err := tr.ResponseBody(responseHeaders, bytes.NewReader(responseBody), true)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response: %w", err))
+	}
+	applyHeaderMutation(responseHeaders, bodyHeaderMutation)
+	if mutated := bodyMutation.GetBody(); len(mutated) > 0 {
+		responseBody = mutated
+	}
+
+	c.costs.InputTokens += tokenUsage.InputTokens
+	c.costs.OutputTokens += tokenUsage.OutputTokens
+	c.costs.TotalTokens += tokenUsage.TotalTokens
+	c.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, c.metricAttrs...)
+	for range cancelledCalls {
+		c.costs.InputTokens += tokenUsage.InputTokens
+		c.costs.TotalTokens += tokenUsage.InputTokens
+		c.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, 0, tokenUsage.InputTokens, c.metricAttrs...)
+	}
+	for _, u := range otherUsages {
+		c.costs.InputTokens += u.InputTokens
+		c.costs.OutputTokens += u.OutputTokens
+		c.costs.TotalTokens += u.TotalTokens
+		c.metrics.RecordTokenUsage(ctx, u.InputTokens, u.OutputTokens, u.TotalTokens, c.metricAttrs...)
+	}
+	endUpstreamSpan(ctx, c.upstreamSpan, c.costs)
+	c.upstreamSpan = nil
+	if trace.SpanFromContext(ctx).IsRecording() {
+		c.completion.observe(responseBody, false)
+		c.completion.record(trace.SpanFromContext(ctx), c.config.tracing.RecordContent())
+	}
+	c.spend.record(ctx, c.config, c.upstream, c.costs, c.logger)
+	c.usage.emit(c.config, c.requestHeaders, responseHeaders, c.upstream, c.costs, c.stream)
+	if c.audit.sampled() && responseHeaders[":status"] == "200" {
+		c.audit.observe(responseBody, c.stream)
+	}
+	c.audit.emit(c.config, c.requestHeaders, responseHeaders, c.upstream, c.stream)
+	if c.mirror.mirrored() && responseHeaders[":status"] == "200" {
+		c.mirror.observe(responseBody, c.stream)
+	}
+	// The comparison uses the token usage of this response excluding the prompts of the cancelled requests.
+	c.mirror.emit(ctx, c.config, c.metrics, c.metricAttrs, c.requestHeaders, responseHeaders, c.upstream, tokenUsage, c.logger)
+
+	// The guardrails check the successful non-streaming responses as in the response body phase.
+	var blocked *extprocv3.ProcessingResponse
+	if c.config.guardrail != nil && !c.stream && responseHeaders[":status"] == "200" {
+		var guardedMutation *extprocv3.BodyMutation
+		if blocked, _, guardedMutation, err = c.guardResponse(ctx, responseBody, nil, nil); err != nil {
+			return nil, err
+		}
+		if redacted := guardedMutation.GetBody(); len(redacted) > 0 {
+			responseBody = redacted
+		}
+	}
+
+	status, _ := strconv.Atoi(responseHeaders[":status"])
+	immediateHeaders := &extprocv3.HeaderMutation{}
+	for k, v := range responseHeaders {
+		if _, skipped := failoverSkippedHeaders[k]; skipped || strings.HasPrefix(k, ":") {
+			continue
+		}
+		immediateHeaders.SetHeaders = append(immediateHeaders.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: k, RawValue: []byte(v)},
+		})
+	}
+	immediateHeaders = c.experimentArm.setResponseHeaders(immediateHeaders)
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode(status)}, //nolint:gosec
+				Headers: immediateHeaders,
+				Body:    responseBody,
+			},
+		},
+	}
+	if len(c.config.requestCosts) > 0 {
+		resp.DynamicMetadata, err = buildDynamicMetadata(c.config, &c.costs, c.estimatedInputTokens, c.requestHeaders, c.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
+		}
+	}
+	// The immediate response of the hedged request replaces the request path, so the arm is carried here as well.
+	resp.DynamicMetadata = c.experimentArm.addDynamicMetadata(c.config, resp.DynamicMetadata)
+	// The response body phase will not happen after the immediate response, so the request completes here.
+	if blocked != nil {
+		c.metrics.RecordRequestCompletion(ctx, false, failureAttrs(c.metricAttrs, aigwerrors.GuardrailBlocked)...)
+		// The tokens have been consumed anyway, so the costs are still reported.
+		blocked.DynamicMetadata = resp.DynamicMetadata
+		return blocked, nil
+	}
+	recordResponseCompletion(ctx, c.metrics, c.metricAttrs, responseHeaders, nil)
+	return resp, nil
+}
+
+// applyHeaderMutation applies the given header mutation to the headers map whose keys are lower-cased as in Envoy.
+func applyHeaderMutation(headers map[string]string, mutation *extprocv3.HeaderMutation) {
+	for _, h := range mutation.GetRemoveHeaders() {
+		delete(headers, strings.ToLower(h))
+	}
+	for _, h := range mutation.GetSetHeaders() {
+		key := strings.ToLower(h.Header.Key)
+		if len(h.Header.Value) > 0 {
+			headers[key] = h.Header.Value
+		} else {
+			headers[key] = string(h.Header.RawValue)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/circuitbreaker"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/router"
+)
+
+func TestChatCompletion_failover(t *testing.T) {
+	const (
+		requestBody = `{"model":"some-model","messages":[{"role":"user","content":"hi"}]}`
+		// The "ok" backend maps the model name, so the request body sent to it differs from the original one.
+		mappedRequestBody = `{"model":"fallback-model","messages":[{"role":"user","content":"hi"}]}`
+	)
+	var requests int
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/v1/chat/completions", r.URL.Path)
+		require.Equal(t, "Bearer fallback-key", r.Header.Get("Authorization"))
+		require.Equal(t, "some-value", r.Header.Get("x-some-header"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, mappedRequestBody, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`))
+	}))
+	defer ok.Close()
+
+	apiKeyFile := path.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(apiKeyFile, []byte("fallback-key"), 0o600))
+	authHandler, err := backendauth.NewHandler(t.Context(), &filterapi.BackendAuth{
+		APIKey: &filterapi.APIKeyAuth{Filename: apiKeyFile},
+	}, filterapi.APISchemaOpenAI)
+	require.NoError(t, err)
+
+	openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	rule := &filterapi.RouteRule{
+		Backends: []filterapi.Backend{
+			{Name: "primary", Schema: openAISchema, Endpoint: unavailable.URL},
+			{Name: "unavailable", Schema: openAISchema, Priority: 1, Endpoint: unavailable.URL},
+			{
+				Name: "ok", Schema: openAISchema, Priority: 2, Endpoint: ok.URL,
+				ModelNameMappings: []filterapi.ModelNameMapping{{From: "some-model", To: "fallback-model"}},
+			},
+		},
+		Fallback: &filterapi.FallbackPolicy{},
+	}
+	newProcessor := func(mm *mockChatCompletionMetrics, mt *mockTranslator) *chatCompletionProcessor {
+		fallback, err := newProcessorConfigFallback(rule, 0)
+		require.NoError(t, err)
+		var body openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(requestBody), &body))
+		return &chatCompletionProcessor{
+			config: &processorConfig{
+				selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+				backendAuthHandlers:      map[string]backendauth.Handler{"ok": authHandler},
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total"}},
+				},
+				metadataNamespace: "ai_gateway_llm_ns",
+			},
+			requestHeaders: map[string]string{
+				":path": "/v1/chat/completions", ":method": "POST", "authorization": "Bearer client-key",
+				"x-some-header": "some-value", "x-ai-eg-selected-backend": "primary",
+			},
+			logger:                 slog.Default(),
+			metrics:                mm,
+			translator:             mt,
+			fallback:               fallback,
+			originalRequestBody:    &body,
+			originalRequestBodyRaw: []byte(requestBody),
+		}
+	}
+	inHeaders := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "429"}}}
+
+	t.Run("ok", func(t *testing.T) {
+		requests = 0
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(mm, &mockTranslator{t: t})
+		res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		require.Equal(t, 2, requests)
+
+		ir := res.Response.(*extprocv3.ProcessingResponse_ImmediateResponse).ImmediateResponse
+		require.Equal(t, typev3.StatusCode_OK, ir.Status.Code)
+		require.JSONEq(t, `{"choices":[{"index":0,"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`, string(ir.Body))
+		require.Contains(t, ir.Headers.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")},
+		})
+		md := res.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+		require.Equal(t, float64(3), md.Fields["total"].GetNumberValue())
+
+		mm.RequireSelected(t, "fallback-model", "ok")
+		require.Equal(t, 1, mm.tokenUsageCount)
+		mm.RequireRequestSuccess(t)
+		require.Equal(t, "ok", p.requestHeaders["x-ai-eg-selected-backend"])
+	})
+	t.Run("guardrail blocked", func(t *testing.T) {
+		checker, err := guardrail.New(&filterapi.GuardrailsConfig{Rules: []filterapi.GuardrailRule{
+			{Name: "greeting", RegularExpression: `hello`, Action: filterapi.GuardrailActionBlock},
+		}}, slog.Default())
+		require.NoError(t, err)
+		requests = 0
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(mm, &mockTranslator{t: t})
+		p.config.guardrail = checker
+		res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_BadRequest, res.GetImmediateResponse().Status.Code)
+		// The tokens of the blocked response are still reported.
+		md := res.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+		require.Equal(t, float64(3), md.Fields["total"].GetNumberValue())
+		mm.RequireRequestFailureType(t, aigwerrors.GuardrailBlocked)
+	})
+	t.Run("max attempts", func(t *testing.T) {
+		requests = 0
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockTranslator{t: t, expHeaders: map[string]string{":status": "429"}}
+		p := newProcessor(mm, mt)
+		p.fallback.maxFallbacks = 1
+		res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		require.Equal(t, 1, requests)
+
+		// The original response is returned as-is when all the fallback backends fail.
+		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseHeaders).ResponseHeaders.Response
+		require.Equal(t, mt.retHeaderMutation, commonRes.HeaderMutation)
+		mm.RequireRequestNotCompleted(t)
+	})
+	t.Run("timeout", func(t *testing.T) {
+		requests = 0
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockTranslator{t: t, expHeaders: map[string]string{":status": "429"}}
+		p := newProcessor(mm, mt)
+		p.fallback.timeout = time.Nanosecond
+		res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		// The original response is returned as-is when all the requests to the fallback backends time out.
+		require.NotNil(t, res.GetResponseHeaders())
+		mm.RequireRequestNotCompleted(t)
+	})
+	t.Run("not retriable", func(t *testing.T) {
+		requests = 0
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockTranslator{t: t, expHeaders: map[string]string{":status": "400"}}
+		p := newProcessor(mm, mt)
+		res, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{
+			Headers: []*corev3.HeaderValue{{Key: ":status", Value: "400"}},
+		})
+		require.NoError(t, err)
+		require.Zero(t, requests)
+		require.NotNil(t, res.GetResponseHeaders())
+	})
+	t.Run("circuit breaker", func(t *testing.T) {
+		policy := &filterapi.CircuitBreakerPolicy{ConsecutiveFailures: 1, OpenDuration: time.Hour, HalfOpenRequests: 1}
+		breakers := map[*filterapi.Backend]*circuitbreaker.Breaker{
+			&rule.Backends[0]: circuitbreaker.New(policy),
+			&rule.Backends[1]: circuitbreaker.New(policy),
+		}
+		newCircuitBreakerProcessor := func(mm *mockChatCompletionMetrics) *chatCompletionProcessor {
+			p := newProcessor(mm, &mockTranslator{t: t})
+			p.config.circuitBreakers = breakers
+			p.circuitBreaker = admitCircuitBreaker(t.Context(), p.config, &rule.Backends[0], mm, p.logger)
+			return p
+		}
+
+		requests = 0
+		mm := &mockChatCompletionMetrics{}
+		_, err := newCircuitBreakerProcessor(mm).ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		require.Equal(t, 2, requests)
+		// Both the original backend responding with 429 and the fallback one responding with 503 fail.
+		require.Equal(t, map[string][]x.CircuitBreakerState{
+			"primary":     {x.CircuitBreakerStateOpen},
+			"unavailable": {x.CircuitBreakerStateOpen},
+		}, mm.circuitBreakerStates)
+
+		// The fallback backend with the open circuit is skipped.
+		requests = 0
+		mm = &mockChatCompletionMetrics{}
+		res, err := newCircuitBreakerProcessor(mm).ProcessResponseHeaders(t.Context(), inHeaders)
+		require.NoError(t, err)
+		require.Equal(t, 1, requests)
+		require.Equal(t, typev3.StatusCode_OK, res.GetImmediateResponse().Status.Code)
+		require.Empty(t, mm.circuitBreakerStates)
+	})
+}
+
+func TestChatCompletion_failover_streaming(t *testing.T) {
+	const (
+		requestBody    = `{"model":"some-model","messages":[{"role":"user","content":"hi"}],"stream":true}`
+		streamResponse = "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hello\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":2,\"total_tokens\":3}}\n\n" +
+			"data: [DONE]\n\n"
+	)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, requestBody, string(body))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(streamResponse))
+	}))
+	defer ok.Close()
+
+	openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	config := &filterapi.Config{Rules: []filterapi.RouteRule{{
+		Headers: []filterapi.HeaderMatch{{Name: "x-model", Value: "some-model"}},
+		Backends: []filterapi.Backend{
+			{Name: "primary", Schema: openAISchema, Endpoint: "http://127.0.0.1:1"},
+			{Name: "fallback", Schema: openAISchema, Priority: 1, Endpoint: ok.URL},
+		},
+		Fallback: &filterapi.FallbackPolicy{},
+	}}}
+	rt, err := router.New(config, nil, nil)
+	require.NoError(t, err)
+	rule := &config.Rules[0]
+	fallback, err := newProcessorConfigFallback(rule, 0)
+	require.NoError(t, err)
+
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(requestBody), &expBody))
+	mm := &mockChatCompletionMetrics{}
+	p := &chatCompletionProcessor{
+		config: &processorConfig{
+			router:                   rt,
+			modelNameHeaderKey:       "x-model",
+			selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+			fallbacks:                map[*filterapi.Backend]*processorConfigFallback{&rule.Backends[0]: fallback},
+		},
+		requestHeaders: map[string]string{":path": "/v1/chat/completions", ":method": "POST"},
+		logger:         slog.Default(),
+		metrics:        mm,
+		translator:     mockTranslator{t: t, expRequestBody: &expBody},
+	}
+	_, err = p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(requestBody)})
+	require.NoError(t, err)
+	require.True(t, p.stream)
+	require.NotNil(t, p.fallback)
+
+	// The retriable status code of the streaming response arrives in the headers before any event is streamed.
+	res, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{
+		Headers: []*corev3.HeaderValue{{Key: ":status", Value: "429"}},
+	})
+	require.NoError(t, err)
+	ir := res.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, typev3.StatusCode_OK, ir.Status.Code)
+	// All the events of the fallback backend are sent at once.
+	require.Equal(t, streamResponse, string(ir.Body))
+	require.Contains(t, ir.Headers.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("text/event-stream")},
+	})
+	require.Equal(t, "fallback", p.requestHeaders["x-ai-eg-selected-backend"])
+	require.Equal(t, uint32(3), p.costs.TotalTokens)
+	mm.RequireRequestSuccess(t)
+}
+
+func Test_applyHeaderMutation(t *testing.T) {
+	headers := map[string]string{"foo": "bar", "authorization": "client", "dog": "cat"}
+	applyHeaderMutation(headers, &extprocv3.HeaderMutation{
+		RemoveHeaders: []string{"Dog"},
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: "Authorization", RawValue: []byte("backend")}},
+			{Header: &corev3.HeaderValue{Key: ":path", Value: "/path"}},
+		},
+	})
+	require.Equal(t, map[string]string{"foo": "bar", "authorization": "backend", ":path": "/path"}, headers)
+}
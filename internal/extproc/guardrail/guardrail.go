@@ -0,0 +1,268 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package guardrail provides the content checks of the chat completion requests and responses.
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// RedactedText is the replacement of the content redacted by the rules.
+const RedactedText = "[REDACTED]"
+
+// piiPatterns are the regular expressions detecting each kind of the personally identifiable information.
+var piiPatterns = map[filterapi.GuardrailPIIType]string{
+	filterapi.GuardrailPIITypeEmail:                  `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`,
+	filterapi.GuardrailPIITypePhoneNumber:            `(?:\+\d{1,3}[\s.\-]?)?\(?\b\d{3}\)?[\s.\-]?\d{3}[\s.\-]\d{4}\b`,
+	filterapi.GuardrailPIITypeCreditCardNumber:       `\b(?:\d{4}[ \-]?){3}\d{1,4}\b`,
+	filterapi.GuardrailPIITypeUSSocialSecurityNumber: `\b\d{3}-\d{2}-\d{4}\b`,
+	filterapi.GuardrailPIITypeIPAddress:              `\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`,
+}
+
+// Verdict is the result of the check.
+type Verdict struct {
+	// Action is the most restrictive action among the matched checks. This is empty if nothing matched.
+	Action filterapi.GuardrailAction
+	// Reasons are the names of the matched rules or the categories flagged by the moderation service.
+	Reasons []string
+}
+
+// merge merges the other verdict into this one.
+func (v *Verdict) merge(other Verdict) {
+	if severity(other.Action) > severity(v.Action) {
+		v.Action = other.Action
+	}
+	v.Reasons = append(v.Reasons, other.Reasons...)
+}
+
+// severity returns the order of the action where the more restrictive one is larger.
+func severity(action filterapi.GuardrailAction) int {
+	switch action {
+	case filterapi.GuardrailActionBlock:
+		return 3
+	case filterapi.GuardrailActionRedact:
+		return 2
+	case filterapi.GuardrailActionAnnotate:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Checker checks the texts in the chat completion requests and responses.
+type Checker interface {
+	// Check checks the texts at the given stage. When any of the matched checks redacts,
+	// the matched parts of the texts are replaced in place.
+	Check(ctx context.Context, stage filterapi.GuardrailStage, texts []string) (Verdict, error)
+}
+
+// New creates a new [Checker] from the configuration. The rules are evaluated before the moderation service is called.
+func New(config *filterapi.GuardrailsConfig, logger *slog.Logger) (Checker, error) {
+	var c chain
+	if len(config.Rules) > 0 {
+		rc := &rulesChecker{}
+		for i := range config.Rules {
+			r := &config.Rules[i]
+			expr := r.RegularExpression
+			if expr == "" {
+				patterns := make([]string, 0, len(r.PII))
+				for _, pii := range r.PII {
+					pattern, ok := piiPatterns[pii]
+					if !ok {
+						return nil, fmt.Errorf("unknown PII type %q in rule %s", pii, r.Name)
+					}
+					patterns = append(patterns, "(?:"+pattern+")")
+				}
+				expr = strings.Join(patterns, "|")
+			}
+			if expr == "" {
+				return nil, fmt.Errorf("neither regular expression nor PII is specified in rule %s", r.Name)
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression in rule %s: %w", r.Name, err)
+			}
+			rc.rules = append(rc.rules, rule{name: r.Name, re: re, action: r.Action, stages: r.Stages})
+		}
+		c = append(c, rc)
+	}
+	if m := config.Moderation; m != nil {
+		if m.Action == filterapi.GuardrailActionRedact {
+			return nil, fmt.Errorf("redact action is not supported by the moderation service")
+		}
+		c = append(c, &moderationChecker{
+			url:      m.URL,
+			action:   m.Action,
+			stages:   m.Stages,
+			failOpen: m.FailOpen,
+			client:   &http.Client{Timeout: m.Timeout},
+			logger:   logger,
+		})
+	}
+	return c, nil
+}
+
+// appliesTo returns true if the check configured with the stages applies to the stage.
+func appliesTo(stages []filterapi.GuardrailStage, stage filterapi.GuardrailStage) bool {
+	return len(stages) == 0 || slices.Contains(stages, stage)
+}
+
+// chain implements [Checker] by running the checkers in order.
+type chain []Checker
+
+// Check implements [Checker.Check].
+func (c chain) Check(ctx context.Context, stage filterapi.GuardrailStage, texts []string) (v Verdict, err error) {
+	for _, checker := range c {
+		var cv Verdict
+		if cv, err = checker.Check(ctx, stage, texts); err != nil {
+			return Verdict{}, err
+		}
+		v.merge(cv)
+		if v.Action == filterapi.GuardrailActionBlock {
+			// No need to check further since the content is blocked anyway.
+			break
+		}
+	}
+	return
+}
+
+// rule is a compiled [filterapi.GuardrailRule].
+type rule struct {
+	name   string
+	re     *regexp.Regexp
+	action filterapi.GuardrailAction
+	stages []filterapi.GuardrailStage
+}
+
+// rulesChecker implements [Checker] with the built-in rules.
+type rulesChecker struct{ rules []rule }
+
+// Check implements [Checker.Check].
+func (r *rulesChecker) Check(_ context.Context, stage filterapi.GuardrailStage, texts []string) (v Verdict, _ error) {
+	for i := range r.rules {
+		rl := &r.rules[i]
+		if !appliesTo(rl.stages, stage) {
+			continue
+		}
+		matched := false
+		for j, text := range texts {
+			if !rl.re.MatchString(text) {
+				continue
+			}
+			matched = true
+			if rl.action == filterapi.GuardrailActionRedact {
+				texts[j] = rl.re.ReplaceAllLiteralString(text, RedactedText)
+			}
+		}
+		if matched {
+			v.merge(Verdict{Action: rl.action, Reasons: []string{rl.name}})
+		}
+	}
+	return
+}
+
+// moderationChecker implements [Checker] with the external moderation service implementing the OpenAI moderation API.
+type moderationChecker struct {
+	url      string
+	action   filterapi.GuardrailAction
+	stages   []filterapi.GuardrailStage
+	failOpen bool
+	client   *http.Client
+	logger   *slog.Logger
+}
+
+// moderationRequest is the request body of the OpenAI moderation API.
+type moderationRequest struct {
+	Input []string `json:"input"`
+}
+
+// moderationResponse is the response body of the OpenAI moderation API.
+type moderationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// Check implements [Checker.Check].
+func (m *moderationChecker) Check(ctx context.Context, stage filterapi.GuardrailStage, texts []string) (Verdict, error) {
+	if !appliesTo(m.stages, stage) || len(texts) == 0 {
+		return Verdict{}, nil
+	}
+	res, err := m.moderate(ctx, texts)
+	if err != nil {
+		if m.failOpen {
+			m.logger.Error("failed to call moderation service, letting the content through", "error", err)
+			return Verdict{}, nil
+		}
+		return Verdict{}, fmt.Errorf("failed to call moderation service: %w", err)
+	}
+
+	flagged := false
+	categories := make(map[string]struct{})
+	for _, r := range res.Results {
+		if !r.Flagged {
+			continue
+		}
+		flagged = true
+		for category, ok := range r.Categories {
+			if ok {
+				categories[category] = struct{}{}
+			}
+		}
+	}
+	if !flagged {
+		return Verdict{}, nil
+	}
+	v := Verdict{Action: m.action}
+	for category := range categories {
+		v.Reasons = append(v.Reasons, "moderation:"+category)
+	}
+	sort.Strings(v.Reasons)
+	if len(v.Reasons) == 0 {
+		v.Reasons = []string{"moderation"}
+	}
+	return v, nil
+}
+
+// moderate sends the texts to the moderation service.
+func (m *moderationChecker) moderate(ctx context.Context, texts []string) (*moderationResponse, error) {
+	body, err := json.Marshal(moderationRequest{Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, b)
+	}
+	var res moderationResponse
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &res, nil
+}
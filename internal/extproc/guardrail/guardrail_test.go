@@ -0,0 +1,210 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package guardrail
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config *filterapi.GuardrailsConfig
+		expErr string
+	}{
+		{
+			name: "unknown pii",
+			config: &filterapi.GuardrailsConfig{Rules: []filterapi.GuardrailRule{
+				{Name: "foo", PII: []filterapi.GuardrailPIIType{"Unknown"}},
+			}},
+			expErr: `unknown PII type "Unknown" in rule foo`,
+		},
+		{
+			name:   "empty rule",
+			config: &filterapi.GuardrailsConfig{Rules: []filterapi.GuardrailRule{{Name: "foo"}}},
+			expErr: "neither regular expression nor PII is specified in rule foo",
+		},
+		{
+			name:   "invalid regex",
+			config: &filterapi.GuardrailsConfig{Rules: []filterapi.GuardrailRule{{Name: "foo", RegularExpression: "("}}},
+			expErr: "invalid regular expression in rule foo",
+		},
+		{
+			name: "redact moderation",
+			config: &filterapi.GuardrailsConfig{Moderation: &filterapi.GuardrailModeration{
+				URL: "http://localhost", Action: filterapi.GuardrailActionRedact,
+			}},
+			expErr: "redact action is not supported by the moderation service",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.config, slog.Default())
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestRulesChecker(t *testing.T) {
+	c, err := New(&filterapi.GuardrailsConfig{Rules: []filterapi.GuardrailRule{
+		{Name: "secret", RegularExpression: `(?i)top\s+secret`, Action: filterapi.GuardrailActionBlock},
+		{
+			Name: "pii", Action: filterapi.GuardrailActionRedact,
+			PII: []filterapi.GuardrailPIIType{
+				filterapi.GuardrailPIITypeEmail, filterapi.GuardrailPIITypePhoneNumber, filterapi.GuardrailPIITypeCreditCardNumber,
+				filterapi.GuardrailPIITypeUSSocialSecurityNumber, filterapi.GuardrailPIITypeIPAddress,
+			},
+		},
+		{
+			Name: "competitor", RegularExpression: `acme`, Action: filterapi.GuardrailActionAnnotate,
+			Stages: []filterapi.GuardrailStage{filterapi.GuardrailStageResponse},
+		},
+	}}, slog.Default())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		stage    filterapi.GuardrailStage
+		texts    []string
+		exp      Verdict
+		expTexts []string
+	}{
+		{
+			name:     "no match",
+			stage:    filterapi.GuardrailStageRequest,
+			texts:    []string{"hello", "world"},
+			expTexts: []string{"hello", "world"},
+		},
+		{
+			name:     "block",
+			stage:    filterapi.GuardrailStageRequest,
+			texts:    []string{"this is Top  Secret"},
+			exp:      Verdict{Action: filterapi.GuardrailActionBlock, Reasons: []string{"secret"}},
+			expTexts: []string{"this is Top  Secret"},
+		},
+		{
+			name:  "redact",
+			stage: filterapi.GuardrailStageRequest,
+			texts: []string{
+				"mail foo.bar@example.com or call +1 415-555-0100",
+				"card 4111 1111 1111 1111, ssn 123-45-6789 from 10.0.0.1",
+			},
+			exp: Verdict{Action: filterapi.GuardrailActionRedact, Reasons: []string{"pii"}},
+			expTexts: []string{
+				"mail [REDACTED] or call [REDACTED]",
+				"card [REDACTED], ssn [REDACTED] from [REDACTED]",
+			},
+		},
+		{
+			name:     "annotate only on response",
+			stage:    filterapi.GuardrailStageRequest,
+			texts:    []string{"acme"},
+			expTexts: []string{"acme"},
+		},
+		{
+			name:     "most restrictive action wins",
+			stage:    filterapi.GuardrailStageResponse,
+			texts:    []string{"acme", "foo@example.com"},
+			exp:      Verdict{Action: filterapi.GuardrailActionRedact, Reasons: []string{"pii", "competitor"}},
+			expTexts: []string{"acme", "[REDACTED]"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := c.Check(t.Context(), tc.stage, tc.texts)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, v)
+			require.Equal(t, tc.expTexts, tc.texts)
+		})
+	}
+}
+
+func TestModerationChecker(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var req moderationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.WriteHeader(status)
+		res := `{"results":[`
+		for i, input := range req.Input {
+			if i > 0 {
+				res += ","
+			}
+			switch input {
+			case "hate":
+				res += `{"flagged":true,"categories":{"hate":true,"violence":false}}`
+			case "violence":
+				res += `{"flagged":true,"categories":{"violence":true}}`
+			case "unknown":
+				res += `{"flagged":true}`
+			default:
+				res += `{"flagged":false,"categories":{"hate":false}}`
+			}
+		}
+		_, _ = w.Write([]byte(res + "]}"))
+	}))
+	defer srv.Close()
+
+	newChecker := func(t *testing.T, failOpen bool) Checker {
+		c, err := New(&filterapi.GuardrailsConfig{
+			Rules: []filterapi.GuardrailRule{
+				{Name: "pii", PII: []filterapi.GuardrailPIIType{filterapi.GuardrailPIITypeEmail}, Action: filterapi.GuardrailActionRedact},
+			},
+			Moderation: &filterapi.GuardrailModeration{
+				URL: srv.URL, Action: filterapi.GuardrailActionBlock, Timeout: time.Second, FailOpen: failOpen,
+				Stages: []filterapi.GuardrailStage{filterapi.GuardrailStageRequest},
+			},
+		}, slog.Default())
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("flagged", func(t *testing.T) {
+		status = http.StatusOK
+		v, err := newChecker(t, false).Check(t.Context(), filterapi.GuardrailStageRequest, []string{"hate", "ok", "violence"})
+		require.NoError(t, err)
+		require.Equal(t, Verdict{Action: filterapi.GuardrailActionBlock, Reasons: []string{"moderation:hate", "moderation:violence"}}, v)
+	})
+	t.Run("flagged without categories", func(t *testing.T) {
+		status = http.StatusOK
+		v, err := newChecker(t, false).Check(t.Context(), filterapi.GuardrailStageRequest, []string{"unknown"})
+		require.NoError(t, err)
+		require.Equal(t, Verdict{Action: filterapi.GuardrailActionBlock, Reasons: []string{"moderation"}}, v)
+	})
+	t.Run("not flagged after redaction", func(t *testing.T) {
+		status = http.StatusOK
+		texts := []string{"ok foo@example.com"}
+		v, err := newChecker(t, false).Check(t.Context(), filterapi.GuardrailStageRequest, texts)
+		require.NoError(t, err)
+		require.Equal(t, Verdict{Action: filterapi.GuardrailActionRedact, Reasons: []string{"pii"}}, v)
+		require.Equal(t, []string{"ok [REDACTED]"}, texts)
+	})
+	t.Run("not applied to response", func(t *testing.T) {
+		status = http.StatusOK
+		v, err := newChecker(t, false).Check(t.Context(), filterapi.GuardrailStageResponse, []string{"hate"})
+		require.NoError(t, err)
+		require.Equal(t, Verdict{}, v)
+	})
+	t.Run("error", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		_, err := newChecker(t, false).Check(t.Context(), filterapi.GuardrailStageRequest, []string{"hate"})
+		require.ErrorContains(t, err, "failed to call moderation service: unexpected status 500")
+	})
+	t.Run("fail open", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		v, err := newChecker(t, true).Check(t.Context(), filterapi.GuardrailStageRequest, []string{"hate"})
+		require.NoError(t, err)
+		require.Equal(t, Verdict{}, v)
+	})
+}
@@ -0,0 +1,197 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
+)
+
+// defaultHedgeTimeout is the timeout of the requests sent while hedging when the hedging policy has none.
+const defaultHedgeTimeout = 5 * time.Minute
+
+// hedgeAttempt is the request sent to a backend while hedging and its result.
+type hedgeAttempt struct {
+	backend *filterapi.Backend
+	// hedged is true if this is the duplicate request sent to the hedge backend.
+	hedged          bool
+	span            trace.Span
+	tr              translator.OpenAIChatCompletionTranslator
+	responseHeaders map[string]string
+	responseBody    []byte
+	err             error
+	// circuitBreaker is not nil if the backend has the circuit breaker.
+	circuitBreaker *circuitBreakerCall
+}
+
+// succeeded returns true if the backend responded with the status that is returned to the client even when the
+// other request is still in flight.
+func (a *hedgeAttempt) succeeded() bool {
+	if a.err != nil {
+		return false
+	}
+	status, _ := strconv.Atoi(a.responseHeaders[":status"])
+	return status != 429 && status < 500
+}
+
+// billedUsage returns the token usage billed by the backend for the attempt that lost the race. The usage is taken
+// from the response when the backend has responded, and known is false when the request was cancelled in flight.
+func (a *hedgeAttempt) billedUsage() (usage translator.LLMTokenUsage, known bool) {
+	if a.err != nil {
+		return usage, false
+	}
+	if a.responseHeaders[":status"] != "200" {
+		return usage, true
+	}
+	headers := maps.Clone(a.responseHeaders)
+	headerMutation, err := a.tr.ResponseHeaders(headers)
+	if err != nil {
+		return usage, false
+	}
+	applyHeaderMutation(headers, headerMutation)
+	if _, _, usage, err = a.tr.ResponseBody(headers, bytes.NewReader(a.responseBody), true); err != nil {
+		return translator.LLMTokenUsage{}, false
+	}
+	return usage, true
+}
+
+// done records the result of the attempt to the circuit breaker of the backend.
+func (a *hedgeAttempt) done(ctx context.Context) {
+	status, _ := strconv.Atoi(a.responseHeaders[":status"])
+	a.circuitBreaker.done(ctx, status, a.err)
+}
+
+// hedge sends the request to the selected backend directly, and also to the hedge backend when the selected one has
+// not responded successfully within the delay. This returns the immediate response built from the first successful
+// response, and cancels the other request.
+//
+// When neither backend responds successfully, the last response received is returned to the client as-is.
+func (c *chatCompletionProcessor) hedge(ctx context.Context, selected *filterapi.Backend, h *processorConfigHedge) (
+	*extprocv3.ProcessingResponse, error,
+) {
+	hedgeCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	results := make(chan *hedgeAttempt, 2)
+	send := func(b *filterapi.Backend, hedged bool) {
+		model, mapped := lookupModelNameMapping(b.ModelNameMappings, c.originalRequestBody.Model)
+		if !mapped {
+			model = c.originalRequestBody.Model
+		}
+		a := &hedgeAttempt{backend: b, hedged: hedged, circuitBreaker: admitCircuitBreaker(ctx, c.config, b, c.metrics, c.logger)}
+		var spanCtx context.Context
+		spanCtx, a.span = startUpstreamSpan(hedgeCtx, c.config, b, upstream{backend: b.Name, model: model})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.tr, a.responseHeaders, a.responseBody, a.err = c.sendToFallbackBackend(spanCtx, b)
+			results <- a
+		}()
+	}
+
+	send(selected, false)
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+	inFlight, hedged := 1, false
+	sendHedge := func() {
+		c.logger.Info("sending hedged request", "backend", h.backend.Name, "schema", h.backend.Schema)
+		hedged = true
+		inFlight++
+		send(h.backend, true)
+	}
+
+	var winner, last *hedgeAttempt
+	for winner == nil && inFlight > 0 {
+		select {
+		case <-timer.C:
+			if !hedged {
+				sendHedge()
+			}
+		case a := <-results:
+			inFlight--
+			a.done(ctx)
+			if a.succeeded() {
+				winner = a
+				continue
+			}
+			if a.err != nil {
+				c.logger.Error("failed to send request to the backend", "backend", a.backend.Name, "error", a.err)
+				tracing.End(a.span, a.err)
+			} else {
+				c.logger.Info("backend responded with retriable status", "backend", a.backend.Name,
+					"status", a.responseHeaders[":status"])
+				setResponseStatusSpanAttributes(a.span, a.responseHeaders[":status"])
+				a.span.SetStatus(codes.Error, "hedging")
+				// The span of the last response ends when it is returned to the client.
+				if last != nil {
+					last.span.End()
+				}
+				last = a
+			}
+			// The hedged request is sent right away without waiting for the delay if the selected backend fails.
+			if !hedged {
+				sendHedge()
+			}
+		}
+	}
+
+	// The loser is cancelled and waited for so that it no longer reads the state of this processor.
+	cancel()
+	wg.Wait()
+	close(results)
+	// The requests in flight have reached the backends, which bill their prompts even if they are cancelled.
+	// The usage of the loser that has responded in the meantime is billed as it is.
+	cancelledCalls := 0
+	var loserUsages []translator.LLMTokenUsage
+	for a := range results {
+		a.done(ctx)
+		if usage, known := a.billedUsage(); known {
+			loserUsages = append(loserUsages, usage)
+		} else if errors.Is(a.err, context.Canceled) {
+			cancelledCalls++
+		}
+		a.span.SetStatus(codes.Error, "cancelled by hedging")
+		a.span.End()
+	}
+
+	result := x.HedgeResultNotFired
+	switch {
+	case winner == nil:
+		result = x.HedgeResultFailed
+		winner = last
+	case winner.hedged:
+		result = x.HedgeResultHedge
+	case hedged:
+		result = x.HedgeResultPrimary
+	}
+	if m, _ := c.metrics.(x.HedgeMetrics); m != nil {
+		m.RecordHedge(ctx, result, c.metricAttrs...)
+	}
+	if last != nil && last != winner {
+		last.span.End()
+	}
+	if winner == nil {
+		return nil, aigwerrors.Wrap(aigwerrors.Upstream5xx, errors.New("failed to send request to any of the backends"))
+	}
+	setResponseStatusSpanAttributes(winner.span, winner.responseHeaders[":status"])
+	c.upstreamSpan = winner.span
+	return c.directResponse(ctx, winner.backend, winner.tr, winner.responseHeaders, winner.responseBody, cancelledCalls, loserUsages...)
+}
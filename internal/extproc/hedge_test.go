@@ -0,0 +1,340 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/router"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func TestChatCompletion_hedge(t *testing.T) {
+	const (
+		requestBody  = `{"model":"some-model","messages":[{"role":"user","content":"hi"}]}`
+		responseBody = `{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`
+	)
+	// backend is the test server responding with the status after the delay, or until the request is cancelled.
+	type backend struct {
+		status    int
+		delay     time.Duration
+		requests  atomic.Int32
+		cancelled atomic.Int32
+	}
+	newServer := func(b *backend) *httptest.Server {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b.requests.Add(1)
+			// The body is read so that the server notices the cancellation of the request.
+			_, _ = io.ReadAll(r.Body)
+			select {
+			case <-time.After(b.delay):
+			case <-r.Context().Done():
+				b.cancelled.Add(1)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(b.status)
+			_, _ = w.Write([]byte(responseBody))
+		}))
+		t.Cleanup(s.Close)
+		return s
+	}
+
+	for _, tc := range []struct {
+		name                string
+		primary, hedge      *backend
+		delay               time.Duration
+		expResult           x.HedgeResult
+		expStatus           typev3.StatusCode
+		expBackend          string
+		expHedgeRequests    int32
+		expCancelled        int32
+		expInputTokens      float64
+		expRequestSucceeded bool
+	}{
+		{
+			name:                "not fired",
+			primary:             &backend{status: http.StatusOK},
+			hedge:               &backend{status: http.StatusOK},
+			delay:               time.Minute,
+			expResult:           x.HedgeResultNotFired,
+			expStatus:           typev3.StatusCode_OK,
+			expBackend:          "primary",
+			expInputTokens:      5,
+			expRequestSucceeded: true,
+		},
+		{
+			name:             "hedge wins",
+			primary:          &backend{status: http.StatusOK, delay: time.Minute},
+			hedge:            &backend{status: http.StatusOK},
+			delay:            10 * time.Millisecond,
+			expResult:        x.HedgeResultHedge,
+			expStatus:        typev3.StatusCode_OK,
+			expBackend:       "hedge",
+			expHedgeRequests: 1,
+			expCancelled:     1,
+			// The prompt of the cancelled request to the primary backend is counted as well.
+			expInputTokens:      10,
+			expRequestSucceeded: true,
+		},
+		{
+			name:                "primary wins",
+			primary:             &backend{status: http.StatusOK, delay: 100 * time.Millisecond},
+			hedge:               &backend{status: http.StatusOK, delay: time.Minute},
+			delay:               10 * time.Millisecond,
+			expResult:           x.HedgeResultPrimary,
+			expStatus:           typev3.StatusCode_OK,
+			expBackend:          "primary",
+			expHedgeRequests:    1,
+			expCancelled:        1,
+			expInputTokens:      10,
+			expRequestSucceeded: true,
+		},
+		{
+			name:                "primary fails",
+			primary:             &backend{status: http.StatusServiceUnavailable},
+			hedge:               &backend{status: http.StatusOK},
+			delay:               time.Minute,
+			expResult:           x.HedgeResultHedge,
+			expStatus:           typev3.StatusCode_OK,
+			expBackend:          "hedge",
+			expHedgeRequests:    1,
+			expInputTokens:      5,
+			expRequestSucceeded: true,
+		},
+		{
+			name:             "both fail",
+			primary:          &backend{status: http.StatusServiceUnavailable},
+			hedge:            &backend{status: http.StatusTooManyRequests, delay: 10 * time.Millisecond},
+			delay:            time.Minute,
+			expResult:        x.HedgeResultFailed,
+			expStatus:        typev3.StatusCode_TooManyRequests,
+			expBackend:       "hedge",
+			expHedgeRequests: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+			rule := &filterapi.RouteRule{
+				Backends: []filterapi.Backend{
+					{Name: "primary", Schema: openAISchema, Endpoint: newServer(tc.primary).URL},
+					{Name: "hedge", Schema: openAISchema, Priority: 1, Endpoint: newServer(tc.hedge).URL},
+				},
+				Hedging: &filterapi.HedgingPolicy{Delay: tc.delay},
+			}
+			var body openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(requestBody), &body))
+			mm := &mockChatCompletionMetrics{}
+			p := &chatCompletionProcessor{
+				config: &processorConfig{
+					selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+					requestCosts: []processorConfigRequestCost{
+						{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input"}},
+					},
+					metadataNamespace: "ai_gateway_llm_ns",
+				},
+				requestHeaders:         map[string]string{":path": "/v1/chat/completions", ":method": "POST"},
+				logger:                 slog.Default(),
+				metrics:                mm,
+				originalRequestBody:    &body,
+				originalRequestBodyRaw: []byte(requestBody),
+			}
+
+			res, err := p.hedge(t.Context(), &rule.Backends[0], newProcessorConfigHedge(rule, 0))
+			require.NoError(t, err)
+			ir := res.Response.(*extprocv3.ProcessingResponse_ImmediateResponse).ImmediateResponse
+			require.Equal(t, tc.expStatus, ir.Status.Code)
+			require.JSONEq(t, responseBody, string(ir.Body))
+			require.Equal(t, tc.expBackend, p.requestHeaders["x-ai-eg-selected-backend"])
+			require.Equal(t, []x.HedgeResult{tc.expResult}, mm.hedgeResults)
+			if tc.expRequestSucceeded {
+				mm.RequireRequestSuccess(t)
+				md := res.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue()
+				require.Equal(t, tc.expInputTokens, md.Fields["input"].GetNumberValue())
+			} else {
+				mm.RequireRequestFailure(t)
+			}
+
+			require.Equal(t, int32(1), tc.primary.requests.Load())
+			require.Equal(t, tc.expHedgeRequests, tc.hedge.requests.Load())
+			// The loser is cancelled before the response is returned.
+			require.Eventually(t, func() bool {
+				return tc.primary.cancelled.Load()+tc.hedge.cancelled.Load() == tc.expCancelled
+			}, time.Second, 10*time.Millisecond)
+		})
+	}
+
+	t.Run("no response", func(t *testing.T) {
+		var body openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(requestBody), &body))
+		openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+		rule := &filterapi.RouteRule{
+			Backends: []filterapi.Backend{
+				{Name: "primary", Schema: openAISchema, Endpoint: "http://127.0.0.1:1"},
+				{Name: "hedge", Schema: openAISchema, Priority: 1, Endpoint: "http://127.0.0.1:1"},
+			},
+			Hedging: &filterapi.HedgingPolicy{Delay: time.Minute},
+		}
+		mm := &mockChatCompletionMetrics{}
+		p := &chatCompletionProcessor{
+			config:                 &processorConfig{},
+			requestHeaders:         map[string]string{":path": "/v1/chat/completions"},
+			logger:                 slog.Default(),
+			metrics:                mm,
+			originalRequestBody:    &body,
+			originalRequestBodyRaw: []byte(requestBody),
+		}
+		_, err := p.hedge(t.Context(), &rule.Backends[0], newProcessorConfigHedge(rule, 0))
+		require.Error(t, err)
+		require.Equal(t, aigwerrors.Upstream5xx, aigwerrors.TypeOf(err))
+		require.Equal(t, []x.HedgeResult{x.HedgeResultFailed}, mm.hedgeResults)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var body openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(requestBody), &body))
+		primary, hedge := &backend{status: http.StatusOK, delay: time.Minute}, &backend{status: http.StatusOK, delay: time.Minute}
+		openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+		rule := &filterapi.RouteRule{
+			Backends: []filterapi.Backend{
+				{Name: "primary", Schema: openAISchema, Endpoint: newServer(primary).URL},
+				{Name: "hedge", Schema: openAISchema, Priority: 1, Endpoint: newServer(hedge).URL},
+			},
+			Hedging: &filterapi.HedgingPolicy{Delay: 10 * time.Millisecond, Timeout: 100 * time.Millisecond},
+		}
+		mm := &mockChatCompletionMetrics{}
+		p := &chatCompletionProcessor{
+			config:                 &processorConfig{},
+			requestHeaders:         map[string]string{":path": "/v1/chat/completions"},
+			logger:                 slog.Default(),
+			metrics:                mm,
+			originalRequestBody:    &body,
+			originalRequestBodyRaw: []byte(requestBody),
+		}
+		_, err := p.hedge(t.Context(), &rule.Backends[0], newProcessorConfigHedge(rule, 0))
+		// Both requests are cancelled by the timeout instead of waiting for the responses.
+		require.Equal(t, aigwerrors.Upstream5xx, aigwerrors.TypeOf(err))
+		require.Equal(t, []x.HedgeResult{x.HedgeResultFailed}, mm.hedgeResults)
+		require.Equal(t, int32(1), hedge.requests.Load())
+		require.Eventually(t, func() bool {
+			return primary.cancelled.Load()+hedge.cancelled.Load() == 2
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestChatCompletion_hedge_basicMetrics(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[]}`))
+	}))
+	t.Cleanup(s.Close)
+	openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	rule := &filterapi.RouteRule{
+		Backends: []filterapi.Backend{
+			{Name: "primary", Schema: openAISchema, Endpoint: s.URL},
+			{Name: "hedge", Schema: openAISchema, Priority: 1, Endpoint: s.URL},
+		},
+		Hedging: &filterapi.HedgingPolicy{Delay: time.Minute},
+	}
+	var body openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"model":"some-model","messages":[]}`), &body))
+	mm := &mockChatCompletionMetrics{}
+	p := &chatCompletionProcessor{
+		config:                 &processorConfig{},
+		requestHeaders:         map[string]string{":path": "/v1/chat/completions"},
+		logger:                 slog.Default(),
+		metrics:                basicChatCompletionMetrics{mm},
+		originalRequestBody:    &body,
+		originalRequestBodyRaw: []byte(`{"model":"some-model","messages":[]}`),
+	}
+	// The outcome is not recorded by the metrics without [x.HedgeMetrics].
+	res, err := p.hedge(t.Context(), &rule.Backends[0], newProcessorConfigHedge(rule, 0))
+	require.NoError(t, err)
+	require.Equal(t, typev3.StatusCode_OK, res.GetImmediateResponse().Status.Code)
+	require.Empty(t, mm.hedgeResults)
+	mm.RequireRequestSuccess(t)
+}
+
+func TestChatCompletion_hedge_streaming(t *testing.T) {
+	openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	config := &filterapi.Config{Rules: []filterapi.RouteRule{{
+		Headers: []filterapi.HeaderMatch{{Name: "x-model", Value: "some-model"}},
+		Backends: []filterapi.Backend{
+			{Name: "primary", Schema: openAISchema, Endpoint: "http://127.0.0.1:1"},
+			{Name: "hedge", Schema: openAISchema, Priority: 1, Endpoint: "http://127.0.0.1:1"},
+		},
+		Hedging: &filterapi.HedgingPolicy{Delay: time.Second},
+	}}}
+	rt, err := router.New(config, nil, nil)
+	require.NoError(t, err)
+	rule := &config.Rules[0]
+	const body = `{"model":"some-model","messages":[{"role":"user","content":"hi"}],"stream":true}`
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+	mm := &mockChatCompletionMetrics{}
+	p := &chatCompletionProcessor{
+		config: &processorConfig{
+			router:                   rt,
+			modelNameHeaderKey:       "x-model",
+			selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+			hedges:                   map[*filterapi.Backend]*processorConfigHedge{&rule.Backends[0]: newProcessorConfigHedge(rule, 0)},
+		},
+		requestHeaders: map[string]string{},
+		logger:         slog.Default(),
+		metrics:        mm,
+		translator:     mockTranslator{t: t, expRequestBody: &expBody},
+	}
+	res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+	require.NoError(t, err)
+	// The streaming request is routed to the selected backend by Envoy as usual without hedging.
+	require.Nil(t, res.GetImmediateResponse())
+	require.Contains(t, res.GetRequestBody().Response.HeaderMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "x-ai-eg-selected-backend", RawValue: []byte("primary")},
+	})
+	require.Empty(t, mm.hedgeResults)
+	mm.RequireRequestNotCompleted(t)
+}
+
+func TestHedgeAttempt_billedUsage(t *testing.T) {
+	tr, err := newChatCompletionTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
+	require.NoError(t, err)
+	a := &hedgeAttempt{
+		tr:              tr,
+		responseHeaders: map[string]string{":status": "200", "content-type": "application/json"},
+		responseBody:    []byte(`{"choices":[],"usage":{"prompt_tokens":6,"completion_tokens":3,"total_tokens":9}}`),
+	}
+	usage, known := a.billedUsage()
+	require.True(t, known)
+	require.Equal(t, translator.LLMTokenUsage{InputTokens: 6, OutputTokens: 3, TotalTokens: 9}, usage)
+
+	// The error response is not billed.
+	a.responseHeaders[":status"] = "503"
+	usage, known = a.billedUsage()
+	require.True(t, known)
+	require.Zero(t, usage)
+
+	// The usage of the request cancelled in flight is unknown.
+	a.err = context.Canceled
+	_, known = a.billedUsage()
+	require.False(t, known)
+}
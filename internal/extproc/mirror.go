@@ -0,0 +1,182 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/audit"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/mirror"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// mirrorTimeout is the timeout of the request sent to the shadow backend.
+const mirrorTimeout = 5 * time.Minute
+
+// mirrorRecorder mirrors a sampled chat completion request to the shadow backend, and compares the responses
+// once both of them complete.
+type mirrorRecorder struct {
+	// start is when the request was mirrored.
+	start time.Time
+	// policy is the mirroring configuration of the rule. This is nil if the request is not mirrored.
+	policy *processorConfigMirror
+	// shadow receives the response of the shadow backend once it completes.
+	shadow chan *mirror.Response
+	// completion assembles the completion from the successful response of the primary backend.
+	completion audit.Completion
+	// emitted is true once the comparison is started so that a request is compared only once.
+	emitted bool
+}
+
+// mirror sends the copy of the request to the shadow backend in the background. The request is sent regardless of
+// the primary one, and is not cancelled when the client goes away.
+func (m *mirrorRecorder) mirror(ctx context.Context, config *processorConfig, policy *processorConfigMirror,
+	requestHeaders map[string]string, body []byte, logger *slog.Logger,
+) {
+	m.start = time.Now()
+	m.policy = policy
+	m.shadow = make(chan *mirror.Response, 1)
+	b := &policy.Backend
+	logger.Info("mirroring request to the shadow backend", "backend", b.Name, "schema", b.Schema)
+
+	// The request is parsed again since the translator may modify it while the processor still reads it.
+	var reqBody openai.ChatCompletionRequest
+	if err := json.Unmarshal(body, &reqBody); err != nil {
+		m.shadow <- &mirror.Response{Backend: b.Name, Error: err.Error()}
+		return
+	}
+	res := &mirror.Response{Backend: b.Name, Model: reqBody.Model}
+	if model, mapped := lookupModelNameMapping(b.ModelNameMappings, reqBody.Model); mapped {
+		res.Model = model
+	}
+	// The headers are cloned since the processor keeps modifying them while the request is in flight.
+	requestHeaders = maps.Clone(requestHeaders)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mirrorTimeout)
+	go func() {
+		defer cancel()
+		tr, responseHeaders, responseBody, err := sendDirect(ctx, config, b, requestHeaders, &reqBody, body)
+		res.LatencyMs = time.Since(m.start).Milliseconds()
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Status, _ = strconv.Atoi(responseHeaders[":status"])
+		}
+		if res.Succeeded() {
+			if err = observeShadowResponse(res, tr, responseHeaders, responseBody, reqBody.Stream); err != nil {
+				res.Error = err.Error()
+			}
+		}
+		m.shadow <- res
+	}()
+}
+
+// observeShadowResponse translates the whole successful response of the shadow backend, and fills the token usage,
+// the finish reason and the content of the response.
+func observeShadowResponse(res *mirror.Response, tr translator.OpenAIChatCompletionTranslator,
+	responseHeaders map[string]string, responseBody []byte, stream bool,
+) error {
+	headerMutation, err := tr.ResponseHeaders(responseHeaders)
+	if err != nil {
+		return err
+	}
+	applyHeaderMutation(responseHeaders, headerMutation)
+	_, bodyMutation, tokenUsage, err := tr.ResponseBody(responseHeaders, bytes.NewReader(responseBody), true)
+	if err != nil {
+		return err
+	}
+	if mutated := bodyMutation.GetBody(); len(mutated) > 0 {
+		responseBody = mutated
+	}
+	res.InputTokens, res.OutputTokens = tokenUsage.InputTokens, tokenUsage.OutputTokens
+	var completion audit.Completion
+	completion.Observe(responseBody, stream)
+	res.Content, res.FinishReason = completion.FirstChoice()
+	return nil
+}
+
+// mirrored returns true if the request is mirrored.
+func (m *mirrorRecorder) mirrored() bool {
+	return m.policy != nil
+}
+
+// observe accumulates the body of the successful response of the primary backend in the OpenAI format.
+func (m *mirrorRecorder) observe(body []byte, stream bool) {
+	m.completion.Observe(body, stream)
+}
+
+// emit completes the primary side of the comparison with the response of the upstream, and compares it with the
+// shadow response in the background once it completes. This is a no-op if the request is not mirrored.
+func (m *mirrorRecorder) emit(ctx context.Context, config *processorConfig, metrics x.ChatCompletionMetrics,
+	metricAttrs []attribute.KeyValue, requestHeaders, responseHeaders map[string]string, up upstream,
+	tokenUsage translator.LLMTokenUsage, logger *slog.Logger,
+) {
+	if !m.mirrored() || m.emitted {
+		return
+	}
+	m.emitted = true
+	r := &mirror.Result{
+		Timestamp: m.start,
+		RequestID: requestHeaders["x-request-id"],
+		Model:     requestHeaders[config.modelNameHeaderKey],
+		Primary: mirror.Response{
+			Backend:      up.backend,
+			Model:        up.model,
+			LatencyMs:    time.Since(m.start).Milliseconds(),
+			InputTokens:  tokenUsage.InputTokens,
+			OutputTokens: tokenUsage.OutputTokens,
+		},
+	}
+	r.Primary.Status, _ = strconv.Atoi(responseHeaders[":status"])
+	if r.Primary.Succeeded() {
+		r.Primary.Content, r.Primary.FinishReason = m.completion.FirstChoice()
+	}
+	mirrorMetrics, _ := metrics.(x.MirrorMetrics)
+	go compareMirror(context.WithoutCancel(ctx), r, m.shadow, m.policy.comparator, config.mirrorResults,
+		mirrorMetrics, metricAttrs, logger)
+}
+
+// compareMirror waits for the shadow response, compares it with the primary one in the result, and records the
+// result to the metrics and the pipeline. The comparator, the pipeline and the metrics can be nil.
+func compareMirror(ctx context.Context, r *mirror.Result, shadow <-chan *mirror.Response, comparator mirror.Comparator,
+	pipeline *mirror.Pipeline, metrics x.MirrorMetrics, metricAttrs []attribute.KeyValue, logger *slog.Logger,
+) {
+	r.Shadow = *<-shadow
+	if r.Primary.Succeeded() && r.Shadow.Succeeded() {
+		r.FinishReasonMatch = r.Primary.FinishReason == r.Shadow.FinishReason
+		if comparator != nil {
+			if score, err := comparator.Score(ctx, r.Primary.Content, r.Shadow.Content); err != nil {
+				logger.Error("failed to compare the mirrored responses", "requestID", r.RequestID, "error", err)
+			} else {
+				r.Score = &score
+			}
+		}
+	}
+	if metrics != nil {
+		metrics.RecordMirror(ctx, &x.MirrorComparison{
+			ShadowBackend:      r.Shadow.Backend,
+			ShadowSucceeded:    r.Shadow.Succeeded(),
+			PrimaryLatency:     time.Duration(r.Primary.LatencyMs) * time.Millisecond,
+			ShadowLatency:      time.Duration(r.Shadow.LatencyMs) * time.Millisecond,
+			ShadowInputTokens:  r.Shadow.InputTokens,
+			ShadowOutputTokens: r.Shadow.OutputTokens,
+			FinishReasonMatch:  r.FinishReasonMatch,
+			Score:              r.Score,
+		}, metricAttrs...)
+	}
+	if pipeline != nil {
+		pipeline.Emit(r)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+)
+
+// embeddingHTTPClient is the HTTP client used to request the embeddings of the outputs.
+var embeddingHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// Comparator scores the similarity of the outputs of the primary and shadow backends.
+type Comparator interface {
+	// Score returns the similarity score of the outputs.
+	Score(ctx context.Context, primary, shadow string) (float64, error)
+}
+
+// NewComparator creates a new Comparator for the configuration. The authHandler is the backend auth of the
+// embedding backend, which can be nil if the backend requires no auth.
+func NewComparator(config *filterapi.MirrorComparison, authHandler backendauth.Handler) (Comparator, error) {
+	switch config.Type {
+	case filterapi.MirrorComparisonTypeExactMatch:
+		return exactMatch{}, nil
+	case filterapi.MirrorComparisonTypeEmbeddingSimilarity:
+		if config.EmbeddingBackend == nil || config.EmbeddingBackend.Endpoint == "" || config.EmbeddingModel == "" {
+			return nil, errors.New("embedding similarity requires the embedding backend with endpoint and the model")
+		}
+		return &embeddingSimilarity{
+			endpoint:    config.EmbeddingBackend.Endpoint,
+			model:       config.EmbeddingModel,
+			authHandler: authHandler,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown mirror comparison type: %s", config.Type)
+	}
+}
+
+// exactMatch implements [Comparator] scoring 1 if the outputs are the same ignoring the leading and trailing
+// whitespaces, and 0 otherwise.
+type exactMatch struct{}
+
+// Score implements [Comparator.Score].
+func (exactMatch) Score(_ context.Context, primary, shadow string) (float64, error) {
+	if strings.TrimSpace(primary) == strings.TrimSpace(shadow) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// embeddingSimilarity implements [Comparator] scoring the cosine similarity of the embeddings of the outputs.
+type embeddingSimilarity struct {
+	// endpoint is the base URL of the embedding backend serving the OpenAI embeddings API.
+	endpoint    string
+	model       string
+	authHandler backendauth.Handler
+}
+
+// embeddingResponse is the subset of the response of the OpenAI embeddings API.
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Score implements [Comparator.Score].
+func (e *embeddingSimilarity) Score(ctx context.Context, primary, shadow string) (float64, error) {
+	body, err := json.Marshal(map[string]any{"model": e.model, "input": []string{primary, shadow}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+	headers := map[string]string{":method": http.MethodPost, ":path": "/v1/embeddings", "content-type": "application/json"}
+	headerMut := &extprocv3.HeaderMutation{}
+	if e.authHandler != nil {
+		bodyMut := &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: body}}
+		if err = e.authHandler.Do(ctx, headers, headerMut, bodyMut); err != nil {
+			return 0, fmt.Errorf("failed to do auth request: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+headers[":path"], bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	for _, h := range headerMut.SetHeaders {
+		v := h.Header.Value
+		if len(h.Header.RawValue) > 0 {
+			v = string(h.Header.RawValue)
+		}
+		req.Header.Set(h.Header.Key, v)
+	}
+
+	res, err := embeddingHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return 0, fmt.Errorf("embedding backend responded with status %d: %s", res.StatusCode, msg)
+	}
+	var er embeddingResponse
+	if err = json.NewDecoder(res.Body).Decode(&er); err != nil {
+		return 0, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	embeddings := make([][]float64, 2)
+	for _, d := range er.Data {
+		if d.Index >= 0 && d.Index < len(embeddings) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+	return CosineSimilarity(embeddings[0], embeddings[1])
+}
+
+// CosineSimilarity returns the cosine similarity of the vectors, from -1 to 1.
+func CosineSimilarity(a, b []float64) (float64, error) {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0, fmt.Errorf("invalid embedding dimensions: %d and %d", len(a), len(b))
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0, errors.New("zero embedding vector")
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
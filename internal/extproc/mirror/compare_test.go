@@ -0,0 +1,142 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNewComparator(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config *filterapi.MirrorComparison
+		expErr string
+	}{
+		{name: "exact match", config: &filterapi.MirrorComparison{Type: filterapi.MirrorComparisonTypeExactMatch}},
+		{
+			name: "embedding similarity",
+			config: &filterapi.MirrorComparison{
+				Type:             filterapi.MirrorComparisonTypeEmbeddingSimilarity,
+				EmbeddingBackend: &filterapi.Backend{Name: "embedding", Endpoint: "http://localhost:1"},
+				EmbeddingModel:   "text-embedding-3-small",
+			},
+		},
+		{
+			name:   "embedding similarity without backend",
+			config: &filterapi.MirrorComparison{Type: filterapi.MirrorComparisonTypeEmbeddingSimilarity, EmbeddingModel: "foo"},
+			expErr: "embedding similarity requires the embedding backend with endpoint and the model",
+		},
+		{
+			name:   "unknown",
+			config: &filterapi.MirrorComparison{Type: "Judge"},
+			expErr: "unknown mirror comparison type: Judge",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewComparator(tc.config, nil)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, c)
+		})
+	}
+}
+
+func TestExactMatch(t *testing.T) {
+	score, err := exactMatch{}.Score(t.Context(), " Paris.\n", "Paris.")
+	require.NoError(t, err)
+	require.Equal(t, 1.0, score)
+	score, err = exactMatch{}.Score(t.Context(), "Paris.", "paris.")
+	require.NoError(t, err)
+	require.Equal(t, 0.0, score)
+}
+
+// fakeAuthHandler implements [backendauth.Handler] setting the authorization header.
+type fakeAuthHandler struct{}
+
+func (fakeAuthHandler) Do(_ context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, _ *extprocv3.BodyMutation) error {
+	requestHeaders["Authorization"] = "Bearer token"
+	headerMut.SetHeaders = append(headerMut.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "Authorization", RawValue: []byte("Bearer token")},
+	})
+	return nil
+}
+
+func TestEmbeddingSimilarity(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/embeddings", r.URL.Path)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "text-embedding-3-small", req.Model)
+		require.Equal(t, []string{"a", "b"}, req.Input)
+		w.WriteHeader(status)
+		// The data are in the reverse order to check that they are matched by the index.
+		_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[1,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewComparator(&filterapi.MirrorComparison{
+		Type:             filterapi.MirrorComparisonTypeEmbeddingSimilarity,
+		EmbeddingBackend: &filterapi.Backend{Name: "embedding", Endpoint: srv.URL},
+		EmbeddingModel:   "text-embedding-3-small",
+	}, fakeAuthHandler{})
+	require.NoError(t, err)
+
+	t.Run("ok", func(t *testing.T) {
+		status = http.StatusOK
+		score, err := c.Score(t.Context(), "a", "b")
+		require.NoError(t, err)
+		require.InDelta(t, 0.7071, score, 0.0001)
+	})
+	t.Run("error status", func(t *testing.T) {
+		status = http.StatusTooManyRequests
+		_, err := c.Score(t.Context(), "a", "b")
+		require.ErrorContains(t, err, "embedding backend responded with status 429")
+	})
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		a, b   []float64
+		exp    float64
+		expErr string
+	}{
+		{name: "same", a: []float64{1, 2, 3}, b: []float64{2, 4, 6}, exp: 1},
+		{name: "orthogonal", a: []float64{1, 0}, b: []float64{0, 1}, exp: 0},
+		{name: "opposite", a: []float64{1, 1}, b: []float64{-1, -1}, exp: -1},
+		{name: "dimension mismatch", a: []float64{1}, b: []float64{1, 2}, expErr: "invalid embedding dimensions: 1 and 2"},
+		{name: "empty", expErr: "invalid embedding dimensions: 0 and 0"},
+		{name: "zero", a: []float64{0, 0}, b: []float64{1, 1}, expErr: "zero embedding vector"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			score, err := CosineSimilarity(tc.a, tc.b)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.InDelta(t, tc.exp, score, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package mirror implements the comparison of the responses of the requests mirrored to the shadow backends with the
+// ones of the primary backends, and the export of the results.
+package mirror
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/export"
+)
+
+// Result is the comparison of the responses of a mirrored request.
+type Result struct {
+	// Timestamp is when the request was mirrored.
+	Timestamp time.Time `json:"timestamp"`
+	// RequestID is the value of the x-request-id header.
+	RequestID string `json:"requestID,omitempty"`
+	// Model is the model requested by the client.
+	Model string `json:"model"`
+	// Primary is the response of the backend selected by the router, which is returned to the client.
+	Primary Response `json:"primary"`
+	// Shadow is the response of the shadow backend, which is discarded.
+	Shadow Response `json:"shadow"`
+	// FinishReasonMatch is true if both responses are successful and their finish reasons are the same.
+	FinishReasonMatch bool `json:"finishReasonMatch"`
+	// Score is the similarity score of the outputs. This is omitted when the comparison is not configured,
+	// either response is not successful, or the score cannot be computed.
+	Score *float64 `json:"score,omitempty"`
+}
+
+// Response is the summary of the response of a backend to a mirrored request.
+type Response struct {
+	// Backend is the name of the backend.
+	Backend string `json:"backend"`
+	// Model is the model sent to the backend.
+	Model string `json:"model,omitempty"`
+	// Status is the HTTP status code of the response. This is zero if the request failed without response.
+	Status int `json:"status,omitempty"`
+	// Error is the error of the request failed without response, or of the successful response that cannot be read.
+	Error string `json:"error,omitempty"`
+	// LatencyMs is the time in milliseconds from when the request was mirrored until the response completed.
+	LatencyMs int64 `json:"latencyMs"`
+	// InputTokens and OutputTokens are the token usage reported by the backend.
+	InputTokens  uint32 `json:"inputTokens"`
+	OutputTokens uint32 `json:"outputTokens"`
+	// FinishReason is the finish reason of the first choice of the completion.
+	FinishReason string `json:"finishReason,omitempty"`
+	// Content is the content of the message of the first choice of the completion, which is compared but not exported.
+	Content string `json:"-"`
+}
+
+// Succeeded returns true if the backend responded with a completion.
+func (r *Response) Succeeded() bool {
+	return r.Status == 200 && r.Error == ""
+}
+
+// Sink exports the batches of the results.
+type Sink = export.Sink[*Result]
+
+// NewSink creates a new sink for the configuration.
+func NewSink(config *filterapi.AuditLogSink) (Sink, error) {
+	switch config.Type {
+	case filterapi.AuditLogSinkTypeFile:
+		return export.NewFileSink[*Result](config.Path, config.MaxSizeMegabytes, config.MaxBackups), nil
+	case filterapi.AuditLogSinkTypeHTTP:
+		return export.NewWebhookSink[*Result](config.URL), nil
+	default:
+		return nil, fmt.Errorf("unknown mirror result sink type: %s", config.Type)
+	}
+}
+
+// Pipeline exports the results to the sinks in the background.
+type Pipeline struct {
+	*export.Pipeline[*Result]
+}
+
+// NewPipeline creates a new pipeline for the configuration and starts exporting to the sinks.
+func NewPipeline(config *filterapi.MirrorResultsConfig, logger *slog.Logger) (*Pipeline, error) {
+	p := &Pipeline{Pipeline: export.NewPipeline[*Result]("mirror result", logger)}
+	for i := range config.Sinks {
+		sc := &config.Sinks[i]
+		if sc.BatchSize <= 0 || sc.FlushInterval <= 0 {
+			return nil, errors.Join(fmt.Errorf("invalid batch config of sink %d: batchSize=%d, flushInterval=%s",
+				i, sc.BatchSize, sc.FlushInterval), p.Close())
+		}
+		sink, err := NewSink(sc)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot create sink %d: %w", i, err), p.Close())
+		}
+		p.AddSink(fmt.Sprintf("%d-%s", i, sc.Type), sink, sc.BatchSize, sc.FlushInterval, sc.MaxRetries)
+	}
+	return p, nil
+}
@@ -0,0 +1,70 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package mirror
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNewPipeline(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Run("ok", func(t *testing.T) {
+		path := t.TempDir() + "/mirror.jsonl"
+		p, err := NewPipeline(&filterapi.MirrorResultsConfig{Sinks: []filterapi.AuditLogSink{
+			{Type: filterapi.AuditLogSinkTypeFile, Path: path, MaxSizeMegabytes: 1, BatchSize: 1, FlushInterval: time.Second},
+			{Type: filterapi.AuditLogSinkTypeHTTP, URL: "http://localhost:1", BatchSize: 1, FlushInterval: time.Second},
+		}}, logger)
+		require.NoError(t, err)
+		require.Equal(t, 2, p.Sinks())
+
+		score := 0.5
+		p.Emit(&Result{
+			Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			RequestID: "req-1",
+			Model:     "gpt-4o",
+			Primary: Response{
+				Backend: "openai", Model: "gpt-4o", Status: 200, LatencyMs: 100,
+				InputTokens: 10, OutputTokens: 20, FinishReason: "stop", Content: "secret",
+			},
+			Shadow: Response{Backend: "shadow.default", Status: 0, Error: "connection refused", LatencyMs: 5},
+			Score:  &score,
+		})
+		// The export to the unreachable webhook fails without retries, which does not affect the file.
+		require.NoError(t, p.Close())
+
+		raw, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"timestamp": "2025-01-02T03:04:05Z",
+			"requestID": "req-1",
+			"model": "gpt-4o",
+			"primary": {"backend": "openai", "model": "gpt-4o", "status": 200, "latencyMs": 100, "inputTokens": 10, "outputTokens": 20, "finishReason": "stop"},
+			"shadow": {"backend": "shadow.default", "error": "connection refused", "latencyMs": 5, "inputTokens": 0, "outputTokens": 0},
+			"finishReasonMatch": false,
+			"score": 0.5
+		}`, string(raw))
+	})
+	t.Run("invalid batch", func(t *testing.T) {
+		_, err := NewPipeline(&filterapi.MirrorResultsConfig{Sinks: []filterapi.AuditLogSink{
+			{Type: filterapi.AuditLogSinkTypeHTTP, URL: "http://localhost:1"},
+		}}, logger)
+		require.ErrorContains(t, err, "invalid batch config of sink 0")
+	})
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := NewPipeline(&filterapi.MirrorResultsConfig{Sinks: []filterapi.AuditLogSink{
+			{Type: "Kafka", BatchSize: 1, FlushInterval: time.Second},
+		}}, logger)
+		require.ErrorContains(t, err, "cannot create sink 0: unknown mirror result sink type: Kafka")
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/mirror"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func TestMirrorRecorder_mirror(t *testing.T) {
+	const requestBody = `{"model":"some-model","messages":[{"role":"user","content":"capital of France?"}]}`
+	var receivedModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/chat/completions", r.URL.Path)
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		receivedModel = body.Model
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Paris"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`))
+	}))
+	defer srv.Close()
+
+	openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	for _, tc := range []struct {
+		name     string
+		endpoint string
+		exp      mirror.Response
+	}{
+		{
+			name:     "ok",
+			endpoint: srv.URL,
+			exp: mirror.Response{
+				Backend: "shadow", Model: "shadow-model", Status: 200,
+				InputTokens: 5, OutputTokens: 2, FinishReason: "stop", Content: "Paris",
+			},
+		},
+		{
+			name:     "unreachable",
+			endpoint: "http://127.0.0.1:1",
+			exp:      mirror.Response{Backend: "shadow", Model: "shadow-model"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := &processorConfigMirror{MirrorPolicy: &filterapi.MirrorPolicy{
+				Backend: filterapi.Backend{
+					Name: "shadow", Schema: openAISchema, Endpoint: tc.endpoint,
+					ModelNameMappings: []filterapi.ModelNameMapping{{From: "some-model", To: "shadow-model"}},
+				},
+				Percentage: 100,
+			}}
+			headers := map[string]string{":path": "/v1/chat/completions", ":method": "POST"}
+			var m mirrorRecorder
+			m.mirror(t.Context(), &processorConfig{}, policy, headers, []byte(requestBody), slog.Default())
+			require.True(t, m.mirrored())
+			// The processor can keep modifying the headers while the request is in flight.
+			headers["x-ai-eg-selected-backend"] = "primary"
+
+			var res *mirror.Response
+			select {
+			case res = <-m.shadow:
+			case <-time.After(10 * time.Second):
+				t.Fatal("timed out waiting for the shadow response")
+			}
+			if tc.exp.Status == 0 {
+				require.NotEmpty(t, res.Error)
+				res.Error = ""
+			} else {
+				require.Equal(t, "shadow-model", receivedModel)
+			}
+			res.LatencyMs = 0
+			require.Equal(t, tc.exp, *res)
+		})
+	}
+}
+
+func TestMirrorRecorder_emit(t *testing.T) {
+	path := t.TempDir() + "/mirror.jsonl"
+	pipeline, err := mirror.NewPipeline(&filterapi.MirrorResultsConfig{Sinks: []filterapi.AuditLogSink{
+		{Type: filterapi.AuditLogSinkTypeFile, Path: path, MaxSizeMegabytes: 1, BatchSize: 1, FlushInterval: time.Millisecond},
+	}}, slog.Default())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, pipeline.Close()) }()
+	config := &processorConfig{modelNameHeaderKey: "x-ai-eg-model", mirrorResults: pipeline}
+	comparator, err := mirror.NewComparator(&filterapi.MirrorComparison{Type: filterapi.MirrorComparisonTypeExactMatch}, nil)
+	require.NoError(t, err)
+
+	m := mirrorRecorder{
+		start:  time.Now(),
+		policy: &processorConfigMirror{MirrorPolicy: &filterapi.MirrorPolicy{}, comparator: comparator},
+		shadow: make(chan *mirror.Response, 1),
+	}
+	m.shadow <- &mirror.Response{Backend: "shadow", Status: 200, InputTokens: 4, OutputTokens: 1, FinishReason: "stop", Content: "Paris"}
+	m.observe([]byte(`{"choices":[{"index":0,"message":{"content":"Paris\n"},"finish_reason":"stop"}]}`), false)
+	m.emit(t.Context(), config, &mockChatCompletionMetrics{}, nil,
+		map[string]string{"x-request-id": "req-1", "x-ai-eg-model": "some-model"}, map[string]string{":status": "200"},
+		upstream{backend: "primary", model: "some-model"}, translator.LLMTokenUsage{InputTokens: 5, OutputTokens: 2}, slog.Default())
+	// The comparison is made only once.
+	m.emit(t.Context(), config, &mockChatCompletionMetrics{}, nil, nil, nil, upstream{}, translator.LLMTokenUsage{}, slog.Default())
+
+	var raw []byte
+	require.Eventually(t, func() bool {
+		raw, _ = os.ReadFile(path)
+		return len(raw) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	var r mirror.Result
+	require.NoError(t, json.Unmarshal(raw, &r))
+	require.Equal(t, "req-1", r.RequestID)
+	require.Equal(t, "some-model", r.Model)
+	require.Equal(t, mirror.Response{Backend: "primary", Model: "some-model", Status: 200, LatencyMs: r.Primary.LatencyMs,
+		InputTokens: 5, OutputTokens: 2, FinishReason: "stop"}, r.Primary)
+	require.Equal(t, mirror.Response{Backend: "shadow", Status: 200, InputTokens: 4, OutputTokens: 1, FinishReason: "stop"}, r.Shadow)
+	require.True(t, r.FinishReasonMatch)
+	require.NotNil(t, r.Score)
+	require.Equal(t, 1.0, *r.Score)
+}
+
+func Test_compareMirror(t *testing.T) {
+	comparator, err := mirror.NewComparator(&filterapi.MirrorComparison{Type: filterapi.MirrorComparisonTypeExactMatch}, nil)
+	require.NoError(t, err)
+	newShadow := func(res *mirror.Response) <-chan *mirror.Response {
+		ch := make(chan *mirror.Response, 1)
+		ch <- res
+		return ch
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("both succeeded", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		r := &mirror.Result{Primary: mirror.Response{Status: 200, LatencyMs: 100, FinishReason: "stop", Content: "a"}}
+		compareMirror(t.Context(), r, newShadow(&mirror.Response{
+			Backend: "shadow", Status: 200, LatencyMs: 200, InputTokens: 3, OutputTokens: 4, FinishReason: "length", Content: "b",
+		}), comparator, nil, mm, nil, logger)
+		require.False(t, r.FinishReasonMatch)
+		require.NotNil(t, r.Score)
+		require.Equal(t, 0.0, *r.Score)
+		score := 0.0
+		require.Equal(t, []*x.MirrorComparison{{
+			ShadowBackend:      "shadow",
+			ShadowSucceeded:    true,
+			PrimaryLatency:     100 * time.Millisecond,
+			ShadowLatency:      200 * time.Millisecond,
+			ShadowInputTokens:  3,
+			ShadowOutputTokens: 4,
+			Score:              &score,
+		}}, mm.mirrorComparisons)
+	})
+	t.Run("shadow failed", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		r := &mirror.Result{Primary: mirror.Response{Status: 200, FinishReason: "stop", Content: "a"}}
+		compareMirror(t.Context(), r, newShadow(&mirror.Response{Backend: "shadow", Status: 503}), comparator, nil, mm, nil, logger)
+		require.False(t, r.FinishReasonMatch)
+		require.Nil(t, r.Score)
+		require.Len(t, mm.mirrorComparisons, 1)
+		require.False(t, mm.mirrorComparisons[0].ShadowSucceeded)
+	})
+}
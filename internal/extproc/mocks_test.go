@@ -20,6 +20,7 @@ import (
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 )
@@ -27,6 +28,7 @@ import (
 var (
 	_ Processor                                 = &mockProcessor{}
 	_ translator.OpenAIChatCompletionTranslator = &mockTranslator{}
+	_ translator.OpenAIEmbeddingTranslator      = &mockEmbeddingTranslator{}
 	_ x.Router                                  = &mockRouter{}
 )
 
@@ -111,6 +113,74 @@ func (m mockTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool
 	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
 }
 
+// mockEmbeddingTranslator implements [translator.OpenAIEmbeddingTranslator] for testing.
+type mockEmbeddingTranslator struct {
+	t                 *testing.T
+	expHeaders        map[string]string
+	expRequestBody    *openai.EmbeddingRequest
+	expResponseBody   *extprocv3.HttpBody
+	retHeaderMutation *extprocv3.HeaderMutation
+	retBodyMutation   *extprocv3.BodyMutation
+	retUsedToken      translator.LLMTokenUsage
+	retErr            error
+}
+
+// RequestBody implements [translator.OpenAIEmbeddingTranslator].
+func (m mockEmbeddingTranslator) RequestBody(body *openai.EmbeddingRequest) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.OpenAIEmbeddingTranslator].
+func (m mockEmbeddingTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.OpenAIEmbeddingTranslator].
+func (m mockEmbeddingTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
+// mockResponsesTranslator implements [translator.OpenAIResponsesTranslator] for testing.
+type mockResponsesTranslator struct {
+	t                 *testing.T
+	expHeaders        map[string]string
+	expRequestBody    *openai.ResponseRequest
+	expResponseBody   *extprocv3.HttpBody
+	retHeaderMutation *extprocv3.HeaderMutation
+	retBodyMutation   *extprocv3.BodyMutation
+	retUsedToken      translator.LLMTokenUsage
+	retErr            error
+}
+
+// RequestBody implements [translator.OpenAIResponsesTranslator].
+func (m mockResponsesTranslator) RequestBody(body *openai.ResponseRequest) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error) {
+	require.Equal(m.t, m.expRequestBody, body)
+	return m.retHeaderMutation, m.retBodyMutation, m.retErr
+}
+
+// ResponseHeaders implements [translator.OpenAIResponsesTranslator].
+func (m mockResponsesTranslator) ResponseHeaders(headers map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	require.Equal(m.t, m.expHeaders, headers)
+	return m.retHeaderMutation, m.retErr
+}
+
+// ResponseBody implements [translator.OpenAIResponsesTranslator].
+func (m mockResponsesTranslator) ResponseBody(_ map[string]string, body io.Reader, _ bool) (headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error) {
+	if m.expResponseBody != nil {
+		buf, err := io.ReadAll(body)
+		require.NoError(m.t, err)
+		require.Equal(m.t, m.expResponseBody.Body, buf)
+	}
+	return m.retHeaderMutation, m.retBodyMutation, m.retUsedToken, m.retErr
+}
+
 // mockRouter implements [router.Router] for testing.
 type mockRouter struct {
 	t                     *testing.T
@@ -118,13 +188,17 @@ type mockRouter struct {
 	retBackendName        string
 	retVersionedAPISchema filterapi.VersionedAPISchema
 	retBackendDynamicLB   *filterapi.DynamicLoadBalancing
+	retModelNameMappings  []filterapi.ModelNameMapping
 	retErr                error
 }
 
 // Calculate implements [router.Router.Calculate].
 func (m mockRouter) Calculate(headers map[string]string) (*filterapi.Backend, error) {
 	require.Equal(m.t, m.expHeaders, headers)
-	b := &filterapi.Backend{Name: m.retBackendName, Schema: m.retVersionedAPISchema, DynamicLoadBalancing: m.retBackendDynamicLB}
+	b := &filterapi.Backend{
+		Name: m.retBackendName, Schema: m.retVersionedAPISchema, DynamicLoadBalancing: m.retBackendDynamicLB,
+		ModelNameMappings: m.retModelNameMappings,
+	}
 	return b, m.retErr
 }
 
@@ -177,8 +251,15 @@ type mockChatCompletionMetrics struct {
 	backend             string
 	requestSuccessCount int
 	requestErrorCount   int
+	errorType           string
 	tokenUsageCount     int
 	tokenLatencyCount   int
+	cacheHitCount       int
+	cacheMissCount      int
+	hedgeResults        []x.HedgeResult
+	mirrorComparisons   []*x.MirrorComparison
+	// circuitBreakerStates are the new states of the circuit breakers keyed by the backend.
+	circuitBreakerStates map[string][]x.CircuitBreakerState
 }
 
 // StartRequest implements [metrics.ChatCompletion].
@@ -201,12 +282,40 @@ func (m *mockChatCompletionMetrics) RecordTokenLatency(_ context.Context, _ uint
 }
 
 // RecordRequestCompletion implements [metrics.ChatCompletion].
-func (m *mockChatCompletionMetrics) RecordRequestCompletion(_ context.Context, success bool, _ ...attribute.KeyValue) {
+func (m *mockChatCompletionMetrics) RecordRequestCompletion(_ context.Context, success bool, extraAttrs ...attribute.KeyValue) {
 	if success {
 		m.requestSuccessCount++
 	} else {
 		m.requestErrorCount++
+		m.errorType = errorTypeOf(extraAttrs)
+	}
+}
+
+// RecordResponseCacheLookup implements [metrics.ChatCompletion].
+func (m *mockChatCompletionMetrics) RecordResponseCacheLookup(_ context.Context, hit bool, _ ...attribute.KeyValue) {
+	if hit {
+		m.cacheHitCount++
+	} else {
+		m.cacheMissCount++
+	}
+}
+
+// RecordHedge implements [metrics.ChatCompletion].
+func (m *mockChatCompletionMetrics) RecordHedge(_ context.Context, result x.HedgeResult, _ ...attribute.KeyValue) {
+	m.hedgeResults = append(m.hedgeResults, result)
+}
+
+// RecordMirror implements [metrics.ChatCompletion].
+func (m *mockChatCompletionMetrics) RecordMirror(_ context.Context, comparison *x.MirrorComparison, _ ...attribute.KeyValue) {
+	m.mirrorComparisons = append(m.mirrorComparisons, comparison)
+}
+
+// RecordCircuitBreakerTransition implements [metrics.ChatCompletion].
+func (m *mockChatCompletionMetrics) RecordCircuitBreakerTransition(_ context.Context, backend string, _, to x.CircuitBreakerState) {
+	if m.circuitBreakerStates == nil {
+		m.circuitBreakerStates = make(map[string][]x.CircuitBreakerState)
 	}
+	m.circuitBreakerStates[backend] = append(m.circuitBreakerStates[backend], to)
 }
 
 // RequireModelAndBackendSet asserts the model and backend set on the metrics.
@@ -221,6 +330,22 @@ func (m *mockChatCompletionMetrics) RequireRequestFailure(t *testing.T) {
 	require.Equal(t, 1, m.requestErrorCount)
 }
 
+// RequireRequestFailureType asserts the request was a failure classified as the given type.
+func (m *mockChatCompletionMetrics) RequireRequestFailureType(t *testing.T, errType aigwerrors.Type) {
+	m.RequireRequestFailure(t)
+	require.Equal(t, string(errType), m.errorType)
+}
+
+// errorTypeOf returns the value of the error.type attribute, if any.
+func errorTypeOf(attrs []attribute.KeyValue) string {
+	for _, attr := range attrs {
+		if attr.Key == "error.type" {
+			return attr.Value.AsString()
+		}
+	}
+	return ""
+}
+
 // RequireRequestNotCompleted asserts the request was not completed.
 func (m *mockChatCompletionMetrics) RequireRequestNotCompleted(t *testing.T) {
 	require.Equal(t, 0, m.requestSuccessCount)
@@ -239,17 +364,74 @@ func (m *mockChatCompletionMetrics) RequireTokensRecorded(t *testing.T, count in
 	require.Equal(t, count, m.tokenLatencyCount)
 }
 
-var _ x.ChatCompletionMetrics = &mockChatCompletionMetrics{}
+// basicChatCompletionMetrics hides the optional interfaces implemented by the wrapped metrics, which mimics
+// the custom metrics only implementing [x.ChatCompletionMetrics].
+type basicChatCompletionMetrics struct {
+	x.ChatCompletionMetrics
+}
+
+var (
+	_ x.ChatCompletionMetrics = &mockChatCompletionMetrics{}
+	_ x.ResponseCacheMetrics  = &mockChatCompletionMetrics{}
+	_ x.HedgeMetrics          = &mockChatCompletionMetrics{}
+	_ x.MirrorMetrics         = &mockChatCompletionMetrics{}
+	_ x.CircuitBreakerMetrics = &mockChatCompletionMetrics{}
+)
+
+// mockEmbeddingsMetrics implements [x.EmbeddingsMetrics] for testing.
+type mockEmbeddingsMetrics struct {
+	requestStart        time.Time
+	model               string
+	backend             string
+	requestSuccessCount int
+	requestErrorCount   int
+	tokenUsageCount     int
+}
+
+// StartRequest implements [x.EmbeddingsMetrics].
+func (m *mockEmbeddingsMetrics) StartRequest(_ map[string]string) { m.requestStart = time.Now() }
+
+// SetModel implements [x.EmbeddingsMetrics].
+func (m *mockEmbeddingsMetrics) SetModel(model string) { m.model = model }
+
+// SetBackend implements [x.EmbeddingsMetrics].
+func (m *mockEmbeddingsMetrics) SetBackend(backend *filterapi.Backend) { m.backend = backend.Name }
+
+// RecordTokenUsage implements [x.EmbeddingsMetrics].
+func (m *mockEmbeddingsMetrics) RecordTokenUsage(_ context.Context, _, _ uint32, _ ...attribute.KeyValue) {
+	m.tokenUsageCount++
+}
+
+// RecordRequestCompletion implements [x.EmbeddingsMetrics].
+func (m *mockEmbeddingsMetrics) RecordRequestCompletion(_ context.Context, success bool, _ ...attribute.KeyValue) {
+	if success {
+		m.requestSuccessCount++
+	} else {
+		m.requestErrorCount++
+	}
+}
+
+var _ x.EmbeddingsMetrics = &mockEmbeddingsMetrics{}
 
 // mockDynamicLB implements dynlb.DynamicLoadBalancer for testing.
 type mockDynamicLB struct {
 	backedName string
-	headers    []*corev3.HeaderValueOption
+	// targetModel is the target model to return. The requested model is returned if empty.
+	targetModel string
+	headers     []*corev3.HeaderValueOption
+	err         error
 }
 
 // SelectChatCompletionsEndpoint implements dynlb.DynamicLoadBalancer.
-func (m *mockDynamicLB) SelectChatCompletionsEndpoint(string, x.ChatCompletionMetrics) (
-	selected *filterapi.Backend, headers []*corev3.HeaderValueOption, err error,
+func (m *mockDynamicLB) SelectChatCompletionsEndpoint(_ context.Context, model string, _ map[string]string, _ *openai.ChatCompletionRequest, _ x.ChatCompletionMetrics) (
+	selected *filterapi.Backend, targetModel string, headers []*corev3.HeaderValueOption, err error,
 ) {
-	return &filterapi.Backend{Name: m.backedName}, m.headers, nil
+	if m.err != nil {
+		return nil, "", nil, m.err
+	}
+	targetModel = model
+	if m.targetModel != "" {
+		targetModel = m.targetModel
+	}
+	return &filterapi.Backend{Name: m.backedName}, targetModel, m.headers, nil
 }
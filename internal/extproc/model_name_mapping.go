@@ -0,0 +1,57 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// mapModelName returns the model name sent to the backend for the model name in the request, and the raw request body
+// with the model name rewritten to it. The returned body is nil when the model name is not mapped by the mappings.
+func mapModelName(mappings []filterapi.ModelNameMapping, model string, raw []byte) (string, []byte, error) {
+	to, ok := lookupModelNameMapping(mappings, model)
+	if !ok {
+		return model, nil, nil
+	}
+	// The body is rewritten via the generic map so that the fields unknown to the request types are preserved.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	fields["model"], _ = json.Marshal(to)
+	mapped, err := json.Marshal(fields)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	return to, mapped, nil
+}
+
+// lookupModelNameMapping returns the model name that the given model name is mapped to, and whether it is mapped
+// to a different name.
+func lookupModelNameMapping(mappings []filterapi.ModelNameMapping, model string) (string, bool) {
+	for i := range mappings {
+		if m := &mappings[i]; m.From == model && m.To != model {
+			return m.To, true
+		}
+	}
+	return model, false
+}
+
+// replaceRequestBody returns the body mutation that replaces the request body, and sets the content-length header accordingly.
+// This is used when the translator passes the request through as-is but the filter changed the body.
+func replaceRequestBody(headerMutation *extprocv3.HeaderMutation, body []byte) *extprocv3.BodyMutation {
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "content-length", RawValue: []byte(strconv.Itoa(len(body)))},
+	})
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: body}}
+}
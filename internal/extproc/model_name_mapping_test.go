@@ -0,0 +1,63 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestMapModelName(t *testing.T) {
+	mappings := []filterapi.ModelNameMapping{
+		{From: "alias", To: "backend-model"},
+		{From: "same", To: "same"},
+	}
+	for _, tc := range []struct {
+		name     string
+		model    string
+		raw      string
+		expModel string
+		expBody  string
+	}{
+		{name: "not mapped", model: "other", raw: `{"model":"other"}`, expModel: "other"},
+		{name: "mapped to itself", model: "same", raw: `{"model":"same"}`, expModel: "same"},
+		{
+			name:     "mapped",
+			model:    "alias",
+			raw:      `{"model":"alias","messages":[],"unknown_field":{"foo":1}}`,
+			expModel: "backend-model",
+			expBody:  `{"model":"backend-model","messages":[],"unknown_field":{"foo":1}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			model, body, err := mapModelName(mappings, tc.model, []byte(tc.raw))
+			require.NoError(t, err)
+			require.Equal(t, tc.expModel, model)
+			if tc.expBody == "" {
+				require.Nil(t, body)
+			} else {
+				require.JSONEq(t, tc.expBody, string(body))
+			}
+		})
+	}
+	t.Run("invalid body", func(t *testing.T) {
+		_, _, err := mapModelName(mappings, "alias", []byte("nonjson"))
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+}
+
+func TestReplaceRequestBody(t *testing.T) {
+	headerMutation := &extprocv3.HeaderMutation{}
+	bodyMutation := replaceRequestBody(headerMutation, []byte(`{"model":"foo"}`))
+	require.Equal(t, []byte(`{"model":"foo"}`), bodyMutation.GetBody())
+	require.Len(t, headerMutation.SetHeaders, 1)
+	require.Equal(t, "content-length", headerMutation.SetHeaders[0].Header.Key)
+	require.Equal(t, "15", string(headerMutation.SetHeaders[0].Header.RawValue))
+}
@@ -6,8 +6,13 @@
 package extproc
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
@@ -15,8 +20,17 @@ import (
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/audit"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/budget"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/circuitbreaker"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/dynlb"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/mirror"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/responsecache"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/usage"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
 )
 
 // processorConfig is the configuration for the processor.
@@ -31,6 +45,170 @@ type processorConfig struct {
 	requestCosts                                 []processorConfigRequestCost
 	declaredModels                               []string
 	dynamicLoadBalancers                         map[*filterapi.DynamicLoadBalancing]dynlb.DynamicLoadBalancer
+	// runningDynamicLBs are the dynamic load balancers in dynamicLoadBalancers keyed by their JSON encoded
+	// configuration. They are reused across the reloads as long as the configuration is unchanged since they keep
+	// resolving the endpoints, scraping their metrics, and tracking their load in the background.
+	runningDynamicLBs map[string]*runningDynamicLB
+	// estimateInputTokens is true if any of the request costs uses the estimated input tokens, in which case
+	// the processors count the input tokens before sending the request to the backend.
+	estimateInputTokens bool
+	// fallbacks maps each backend in the rules with the fallback policy to its failover configuration.
+	fallbacks map[*filterapi.Backend]*processorConfigFallback
+	// hedges maps each backend in the rules with the hedging policy to its hedging configuration.
+	hedges map[*filterapi.Backend]*processorConfigHedge
+	// mirrors maps each backend in the rules with the mirror policy to the mirroring configuration of the rule.
+	mirrors map[*filterapi.Backend]*processorConfigMirror
+	// mirrorResults exports the results of the mirrored requests. This is nil if the export is not configured.
+	mirrorResults *mirror.Pipeline
+	// circuitBreakers maps each backend in the rules with the circuit breaker policy to its circuit breaker.
+	circuitBreakers map[*filterapi.Backend]*circuitbreaker.Breaker
+	// experimentArms maps each backend in the rules with the experiment to the arm it serves.
+	experimentArms map[*filterapi.Backend]*experimentArm
+	// responseCache is the store of the cached responses. This is nil if the response cache is disabled.
+	responseCache responsecache.Store
+	// responseCacheScopes maps each backend in the rules to the scope of its cached responses, which identifies the rule
+	// and the backend so that the cached responses are neither shared across them nor served after they are changed.
+	responseCacheScopes map[*filterapi.Backend]string
+	// guardrail checks the chat completion requests and responses. This is nil if the guardrails are not configured.
+	guardrail guardrail.Checker
+	// spendBudget enforces the spend budgets of the clients. This is nil if the budgets are not configured.
+	spendBudget *budget.Manager
+	// usageRecords exports the usage records of the requests. This is nil if the usage records are not configured.
+	usageRecords *usage.Pipeline
+	// auditLog records the chat completion requests and their completions. This is nil if the audit log is not configured.
+	auditLog *audit.Logger
+	// tracing creates the child spans of the requests. This is nil if the tracing is disabled.
+	tracing *tracing.Tracing
+	// metricAttributes derives the extra attributes of the metrics from the request headers.
+	// This is nil if no attribute is configured.
+	metricAttributes *metrics.RequestHeaderAttributes
+}
+
+// processorConfigFallback is the failover configuration for a backend selected by the router.
+type processorConfigFallback struct {
+	// backends are the other backends in the same rule in the order of the failover. When the rule has the
+	// experiment, only the backends serving the same arm are included.
+	backends             []*filterapi.Backend
+	retriableStatusCodes []int
+	// maxFallbacks is the maximum number of attempts to the fallback backends.
+	maxFallbacks int
+	// timeout is the timeout of each request to the fallback backends.
+	timeout time.Duration
+}
+
+// newProcessorConfigFallback creates a new failover configuration for the selected-th backend in the rule.
+// This returns an error if any of the backends in the rule cannot be reached directly without the endpoint.
+func newProcessorConfigFallback(rule *filterapi.RouteRule, selected int) (*processorConfigFallback, error) {
+	f := &processorConfigFallback{retriableStatusCodes: rule.Fallback.RetriableStatusCodes, timeout: rule.Fallback.Timeout}
+	if f.timeout == 0 {
+		f.timeout = defaultFailoverTimeout
+	}
+	for i := range rule.Backends {
+		if rule.Backends[i].Endpoint == "" {
+			return nil, fmt.Errorf("backend %s has no endpoint", rule.Backends[i].Name)
+		}
+		if i != selected && sameExperimentArm(rule, &rule.Backends[selected], &rule.Backends[i]) {
+			f.backends = append(f.backends, &rule.Backends[i])
+		}
+	}
+	slices.SortStableFunc(f.backends, func(a, b *filterapi.Backend) int { return cmp.Compare(a.Priority, b.Priority) })
+	f.maxFallbacks = len(f.backends)
+	if maxAttempts := rule.Fallback.MaxAttempts; maxAttempts > 0 {
+		f.maxFallbacks = min(maxAttempts-1, f.maxFallbacks)
+	}
+	return f, nil
+}
+
+// isRetriable returns true if the response with the given status code should fail over to the next backend.
+func (f *processorConfigFallback) isRetriable(status int) bool {
+	if len(f.retriableStatusCodes) == 0 {
+		return status == 429 || status >= 500
+	}
+	return slices.Contains(f.retriableStatusCodes, status)
+}
+
+// processorConfigHedge is the hedging configuration for a backend selected by the router.
+type processorConfigHedge struct {
+	// backend is the backend in the same rule the hedged request is sent to.
+	backend *filterapi.Backend
+	// delay is the duration to wait for the response from the selected backend before sending the hedged request.
+	delay time.Duration
+	// timeout is the timeout of the requests sent while hedging.
+	timeout time.Duration
+}
+
+// newProcessorConfigHedge creates a new hedging configuration for the selected-th backend in the rule.
+//
+// The hedged request is sent to the backend with the lowest priority among the other backends serving the same arm
+// of the experiment, if any. This returns nil
+// if the selected backend or all the other backends cannot be reached directly without the endpoint.
+func newProcessorConfigHedge(rule *filterapi.RouteRule, selected int) *processorConfigHedge {
+	if rule.Backends[selected].Endpoint == "" {
+		return nil
+	}
+	var hedge *filterapi.Backend
+	for i := range rule.Backends {
+		b := &rule.Backends[i]
+		if i == selected || b.Endpoint == "" || !sameExperimentArm(rule, &rule.Backends[selected], b) {
+			continue
+		}
+		if hedge == nil || b.Priority < hedge.Priority {
+			hedge = b
+		}
+	}
+	if hedge == nil {
+		return nil
+	}
+	return &processorConfigHedge{backend: hedge, delay: rule.Hedging.Delay, timeout: cmp.Or(rule.Hedging.Timeout, defaultHedgeTimeout)}
+}
+
+// processorConfigMirror is the mirroring configuration of a rule.
+type processorConfigMirror struct {
+	*filterapi.MirrorPolicy
+	// comparator scores the similarity of the outputs. This is nil if the comparison is not configured.
+	comparator mirror.Comparator
+}
+
+// newProcessorConfigMirror creates a new mirroring configuration of a rule. The auth handler of the shadow backend
+// is added to authHandlers unless the backend of the same name already has one.
+func newProcessorConfigMirror(ctx context.Context, policy *filterapi.MirrorPolicy,
+	authHandlers map[string]backendauth.Handler,
+) (*processorConfigMirror, error) {
+	m := &processorConfigMirror{MirrorPolicy: policy}
+	if b := &policy.Backend; b.Auth != nil {
+		if _, ok := authHandlers[b.Name]; !ok {
+			h, err := backendauth.NewHandler(ctx, b.Auth, b.Schema.Name)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create backend auth handler of shadow backend: %w", err)
+			}
+			authHandlers[b.Name] = h
+		}
+	}
+	if c := policy.Comparison; c != nil {
+		var (
+			embeddingAuth backendauth.Handler
+			err           error
+		)
+		if b := c.EmbeddingBackend; b != nil && b.Auth != nil {
+			if embeddingAuth, err = backendauth.NewHandler(ctx, b.Auth, b.Schema.Name); err != nil {
+				return nil, fmt.Errorf("cannot create backend auth handler of embedding backend: %w", err)
+			}
+		}
+		if m.comparator, err = mirror.NewComparator(c, embeddingAuth); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// sample decides whether the request is mirrored based on the percentage.
+func (m *processorConfigMirror) sample() bool {
+	return rand.IntN(100) < m.Percentage // #nosec G404
+}
+
+// upstream is the backend a request is sent to and the model name in the request sent to it.
+type upstream struct {
+	backend, model string
 }
 
 // processorConfigRequestCost is the configuration for the request cost.
@@ -6,10 +6,15 @@
 package extproc
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
 )
 
 func Test_passThroughProcessor(t *testing.T) { // This is mostly for coverage.
@@ -38,3 +43,140 @@ func Test_passThroughProcessor(t *testing.T) { // This is mostly for coverage.
 	_, ok = resp.Response.(*extprocv3.ProcessingResponse_ResponseBody)
 	require.True(t, ok)
 }
+
+func Test_newProcessorConfigFallback(t *testing.T) {
+	rule := &filterapi.RouteRule{
+		Backends: []filterapi.Backend{
+			{Name: "a", Priority: 0, Endpoint: "http://a"},
+			{Name: "b", Priority: 2, Endpoint: "http://b"},
+			{Name: "c", Priority: 1, Endpoint: "http://c"},
+			{Name: "d", Priority: 1, Endpoint: "http://d"},
+		},
+		Fallback: &filterapi.FallbackPolicy{},
+	}
+	f, err := newProcessorConfigFallback(rule, 0)
+	require.NoError(t, err)
+	require.Len(t, f.backends, 3)
+	require.Equal(t, "c", f.backends[0].Name)
+	require.Equal(t, "d", f.backends[1].Name)
+	require.Equal(t, "b", f.backends[2].Name)
+	require.Equal(t, 3, f.maxFallbacks)
+	require.Equal(t, defaultFailoverTimeout, f.timeout)
+	// The pointers must point to the backends in the rule.
+	require.Same(t, &rule.Backends[2], f.backends[0])
+
+	rule.Fallback.MaxAttempts = 2
+	rule.Fallback.Timeout = time.Second
+	f, err = newProcessorConfigFallback(rule, 2)
+	require.NoError(t, err)
+	require.Equal(t, []*filterapi.Backend{&rule.Backends[0], &rule.Backends[3], &rule.Backends[1]}, f.backends)
+	require.Equal(t, 1, f.maxFallbacks)
+	require.Equal(t, time.Second, f.timeout)
+
+	// All the backends must be reachable directly.
+	rule.Backends[1].Endpoint = ""
+	_, err = newProcessorConfigFallback(rule, 0)
+	require.EqualError(t, err, "backend b has no endpoint")
+}
+
+func Test_newProcessorConfigHedge(t *testing.T) {
+	rule := &filterapi.RouteRule{
+		Backends: []filterapi.Backend{
+			{Name: "a", Endpoint: "http://a"},
+			{Name: "b", Priority: 2, Endpoint: "http://b"},
+			{Name: "c", Priority: 1},
+			{Name: "d", Priority: 1, Endpoint: "http://d"},
+		},
+		Hedging: &filterapi.HedgingPolicy{Delay: time.Second},
+	}
+	h := newProcessorConfigHedge(rule, 0)
+	// The backend without endpoint is skipped even though it has the lowest priority.
+	require.Same(t, &rule.Backends[3], h.backend)
+	require.Equal(t, time.Second, h.delay)
+	require.Equal(t, defaultHedgeTimeout, h.timeout)
+	rule.Hedging.Timeout = time.Minute
+	require.Equal(t, time.Minute, newProcessorConfigHedge(rule, 0).timeout)
+	require.Same(t, &rule.Backends[0], newProcessorConfigHedge(rule, 3).backend)
+	// The selected backend without endpoint cannot be hedged.
+	require.Nil(t, newProcessorConfigHedge(rule, 2))
+
+	rule.Backends = rule.Backends[:1]
+	require.Nil(t, newProcessorConfigHedge(rule, 0))
+}
+
+func Test_newProcessorConfigMirror(t *testing.T) {
+	apiKeyFile := t.TempDir() + "/api-key"
+	require.NoError(t, os.WriteFile(apiKeyFile, []byte("key"), 0o600))
+	auth := &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Filename: apiKeyFile}}
+
+	t.Run("ok", func(t *testing.T) {
+		existing, err := backendauth.NewHandler(t.Context(), auth, filterapi.APISchemaOpenAI)
+		require.NoError(t, err)
+		handlers := map[string]backendauth.Handler{"primary": existing}
+		m, err := newProcessorConfigMirror(t.Context(), &filterapi.MirrorPolicy{
+			Backend:    filterapi.Backend{Name: "shadow", Endpoint: "http://shadow", Auth: auth},
+			Percentage: 100,
+			Comparison: &filterapi.MirrorComparison{
+				Type:             filterapi.MirrorComparisonTypeEmbeddingSimilarity,
+				EmbeddingBackend: &filterapi.Backend{Name: "embedding", Endpoint: "http://embedding", Auth: auth},
+				EmbeddingModel:   "text-embedding-3-small",
+			},
+		}, handlers)
+		require.NoError(t, err)
+		require.NotNil(t, m.comparator)
+		require.True(t, m.sample())
+		require.Len(t, handlers, 2)
+		require.NotNil(t, handlers["shadow"])
+		require.Same(t, existing, handlers["primary"])
+	})
+	t.Run("invalid comparison", func(t *testing.T) {
+		_, err := newProcessorConfigMirror(t.Context(), &filterapi.MirrorPolicy{
+			Backend:    filterapi.Backend{Name: "shadow", Endpoint: "http://shadow"},
+			Comparison: &filterapi.MirrorComparison{Type: "Judge"},
+		}, map[string]backendauth.Handler{})
+		require.EqualError(t, err, "unknown mirror comparison type: Judge")
+	})
+	t.Run("never sampled", func(t *testing.T) {
+		m, err := newProcessorConfigMirror(t.Context(), &filterapi.MirrorPolicy{Percentage: 0}, map[string]backendauth.Handler{})
+		require.NoError(t, err)
+		require.Nil(t, m.comparator)
+		require.False(t, m.sample())
+	})
+}
+
+func Test_processorConfigFallback_isRetriable(t *testing.T) {
+	f := &processorConfigFallback{}
+	for status, exp := range map[int]bool{200: false, 400: false, 404: false, 429: true, 500: true, 503: true} {
+		require.Equal(t, exp, f.isRetriable(status), status)
+	}
+	f.retriableStatusCodes = []int{503}
+	for status, exp := range map[int]bool{200: false, 429: false, 500: false, 503: true} {
+		require.Equal(t, exp, f.isRetriable(status), status)
+	}
+}
+
+func Test_newProcessorConfigFallback_experiment(t *testing.T) {
+	rule := newExperimentRule()
+	rule.Fallback = &filterapi.FallbackPolicy{}
+	for i := range rule.Backends {
+		rule.Backends[i].Endpoint = "http://" + rule.Backends[i].Name
+	}
+	// The fallback stays within the arm.
+	f, err := newProcessorConfigFallback(rule, 0)
+	require.NoError(t, err)
+	require.Equal(t, []*filterapi.Backend{&rule.Backends[1]}, f.backends)
+	f, err = newProcessorConfigFallback(rule, 2)
+	require.NoError(t, err)
+	require.Empty(t, f.backends)
+}
+
+func Test_newProcessorConfigHedge_experiment(t *testing.T) {
+	rule := newExperimentRule()
+	for i := range rule.Backends {
+		rule.Backends[i].Endpoint = "http://" + rule.Backends[i].Name
+	}
+	rule.Hedging = &filterapi.HedgingPolicy{Delay: time.Second}
+	// The hedged request stays within the arm.
+	require.Same(t, &rule.Backends[0], newProcessorConfigHedge(rule, 1).backend)
+	require.Nil(t, newProcessorConfigHedge(rule, 2))
+}
@@ -0,0 +1,131 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// responseCacheHeaderKey is the response header key set to "hit" when the response is served from the response cache.
+const responseCacheHeaderKey = "x-ai-eg-response-cache"
+
+// newResponseCacheScopes adds the scopes of the cached responses of the backends in the rule to scopes.
+//
+// The scope is the hash of the rule followed by the backend name, so the cached responses of a rule are no longer
+// served once the rule is changed or removed, or the backend is removed from it.
+func newResponseCacheScopes(rule *filterapi.RouteRule, scopes map[*filterapi.Backend]string) error {
+	raw, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rule: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	for i := range rule.Backends {
+		b := &rule.Backends[i]
+		scopes[b] = hex.EncodeToString(sum[:8]) + "/" + b.Name
+	}
+	return nil
+}
+
+// chatCompletionCacheKey returns the key of the response cache for the request in the scope.
+//
+// The key is computed from the re-encoded request so that the differences in the formatting of the original
+// request body do not matter. The fields which do not affect the response, such as "user", are ignored.
+func chatCompletionCacheKey(scope string, body *openai.ChatCompletionRequest) (string, error) {
+	normalized := *body
+	normalized.User = ""
+	encoded, err := json.Marshal(&normalized)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write(encoded)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lookupResponseCache returns the immediate response serving the cached response for the request routed to
+// the backend if exists. Otherwise, this returns nil and remembers the cache key so that the response is stored
+// when it completes.
+//
+// The response cache is best-effort, so any error is logged and treated as a cache miss.
+func (c *chatCompletionProcessor) lookupResponseCache(ctx context.Context, b *filterapi.Backend,
+	body *openai.ChatCompletionRequest,
+) *extprocv3.ProcessingResponse {
+	scope, ok := c.config.responseCacheScopes[b]
+	if !ok {
+		scope = b.Name
+	}
+	key, err := chatCompletionCacheKey(scope, body)
+	if err != nil {
+		c.logger.Error("failed to compute response cache key", "error", err)
+		return nil
+	}
+	cached, ok, err := c.config.responseCache.Get(ctx, key)
+	if err != nil {
+		c.logger.Error("failed to get cached response", "error", err)
+	}
+	if m, _ := c.metrics.(x.ResponseCacheMetrics); m != nil {
+		m.RecordResponseCacheLookup(ctx, ok, c.metricAttrs...)
+	}
+	if !ok {
+		c.responseCacheKey = key
+		return nil
+	}
+
+	c.logger.Info("serving cached response", "model", body.Model, "stream", body.Stream)
+	contentType := "application/json"
+	if body.Stream {
+		// The cached response of the streaming request is the whole server-sent events, so they are replayed at once.
+		contentType = "text/event-stream"
+	}
+	c.metrics.RecordRequestCompletion(ctx, true, c.metricAttrs...)
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_OK},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{
+						{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte(contentType)}},
+						{Header: &corev3.HeaderValue{Key: responseCacheHeaderKey, RawValue: []byte("hit")}},
+					},
+				},
+				Body: cached,
+			},
+		},
+	}
+}
+
+// bufferResponseCache buffers the response body sent to the client, and stores it in the response cache
+// at the end of the stream.
+func (c *chatCompletionProcessor) bufferResponseCache(ctx context.Context, body *extprocv3.HttpBody, mutation *extprocv3.BodyMutation) {
+	switch m := mutation.GetMutation().(type) {
+	case *extprocv3.BodyMutation_Body:
+		c.responseCacheBuffer = append(c.responseCacheBuffer, m.Body...)
+	case *extprocv3.BodyMutation_ClearBody:
+	default:
+		c.responseCacheBuffer = append(c.responseCacheBuffer, body.Body...)
+	}
+	if !body.EndOfStream {
+		return
+	}
+	if err := c.config.responseCache.Set(ctx, c.responseCacheKey, c.responseCacheBuffer); err != nil {
+		c.logger.Error("failed to store response in cache", "error", err)
+	}
+	c.responseCacheKey, c.responseCacheBuffer = "", nil
+}
@@ -0,0 +1,230 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/responsecache"
+)
+
+func Test_chatCompletionCacheKey(t *testing.T) {
+	keyOf := func(t *testing.T, body string) string {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &req))
+		key, err := chatCompletionCacheKey("rule/backend", &req)
+		require.NoError(t, err)
+		return key
+	}
+
+	key := keyOf(t, `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0.5}`)
+	require.Len(t, key, 64)
+	// The formatting, the order of the fields as well as the user field do not matter.
+	require.Equal(t, key, keyOf(t, `{"temperature": 0.5, "user": "foo",
+		"messages": [{"content": "hi", "role": "user"}], "model": "gpt-4o"}`))
+
+	for _, body := range []string{
+		`{"model":"gpt-4o-mini","messages":[{"role":"user","content":"hi"}],"temperature":0.5}`,
+		`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}],"temperature":0.5}`,
+		`{"model":"gpt-4o","messages":[{"role":"system","content":"hi"}],"temperature":0.5}`,
+		`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":1}`,
+		`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0.5,"stream":true}`,
+		`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0.5,"tools":[{"type":"function","function":{"name":"foo"}}]}`,
+	} {
+		require.NotEqual(t, key, keyOf(t, body), body)
+	}
+
+	// The same request in a different scope has a different key.
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0.5}`), &req))
+	other, err := chatCompletionCacheKey("rule/other-backend", &req)
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+}
+
+func Test_newResponseCacheScopes(t *testing.T) {
+	newRule := func(model string) *filterapi.RouteRule {
+		return &filterapi.RouteRule{
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: model}},
+			Backends: []filterapi.Backend{{Name: "foo"}, {Name: "bar"}},
+		}
+	}
+	scopes := make(map[*filterapi.Backend]string)
+	rule := newRule("gpt-4o")
+	require.NoError(t, newResponseCacheScopes(rule, scopes))
+	foo, bar := scopes[&rule.Backends[0]], scopes[&rule.Backends[1]]
+	require.Regexp(t, `^[0-9a-f]{16}/foo$`, foo)
+	require.Regexp(t, `^[0-9a-f]{16}/bar$`, bar)
+
+	// The scope is stable across the reloads of the same rule, and changes with the rule.
+	same, changed := newRule("gpt-4o"), newRule("gpt-4o-mini")
+	require.NoError(t, newResponseCacheScopes(same, scopes))
+	require.NoError(t, newResponseCacheScopes(changed, scopes))
+	require.Equal(t, foo, scopes[&same.Backends[0]])
+	require.NotEqual(t, foo, scopes[&changed.Backends[0]])
+}
+
+func TestChatCompletion_responseCache(t *testing.T) {
+	const (
+		requestBody          = `{"model":"some-model","messages":[{"role":"user","content":"hi"}]}`
+		equivalentBody       = `{"messages": [{"content": "hi", "role": "user"}], "model": "some-model"}`
+		streamingRequestBody = `{"model":"some-model","messages":[{"role":"user","content":"hi"}],"stream":true}`
+	)
+	config := &processorConfig{
+		modelNameHeaderKey:       "x-model-name",
+		selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+		responseCache:            responsecache.NewMemoryStore(10, time.Minute),
+	}
+	newProcessor := func(t *testing.T, mm *mockChatCompletionMetrics, body string) *chatCompletionProcessor {
+		var expBody openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+		headers := map[string]string{":path": "/v1/chat/completions"}
+		c := *config
+		c.router = mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"}
+		return &chatCompletionProcessor{
+			config:         &c,
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+			translator: &mockTranslator{
+				t: t, expRequestBody: &expBody, expHeaders: map[string]string{":status": "200"},
+				retBodyMutation: &extprocv3.BodyMutation{},
+			},
+		}
+	}
+	requireCacheHit := func(t *testing.T, body, contentType, expBody string) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, body)
+		res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		ir := res.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, typev3.StatusCode_OK, ir.Status.Code)
+		require.Equal(t, expBody, string(ir.Body))
+		require.Equal(t, []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte(contentType)}},
+			{Header: &corev3.HeaderValue{Key: responseCacheHeaderKey, RawValue: []byte("hit")}},
+		}, ir.Headers.SetHeaders)
+		require.Equal(t, 1, mm.cacheHitCount)
+		mm.RequireRequestSuccess(t)
+		mm.RequireSelected(t, "some-model", "")
+	}
+	// newProcessorWithBackend creates the processor whose router selects the given backend.
+	newProcessorWithBackend := func(t *testing.T, mm *mockChatCompletionMetrics, body, backend string) *chatCompletionProcessor {
+		p := newProcessor(t, mm, body)
+		p.config.router = mockRouter{t: t, expHeaders: p.requestHeaders, retBackendName: backend}
+		return p
+	}
+	requireCacheMiss := func(t *testing.T, mm *mockChatCompletionMetrics, p *chatCompletionProcessor, body string) {
+		res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		require.NotNil(t, res.GetRequestBody())
+		require.Equal(t, 1, mm.cacheMissCount)
+		require.NotEmpty(t, p.responseCacheKey)
+	}
+
+	t.Run("miss", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, requestBody)
+		requireCacheMiss(t, mm, p, requestBody)
+
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{
+			Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}},
+		})
+		require.NoError(t, err)
+		_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"choices":`), EndOfStream: false})
+		require.NoError(t, err)
+		_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`[]}`), EndOfStream: true})
+		require.NoError(t, err)
+		require.Empty(t, p.responseCacheKey)
+	})
+	t.Run("hit", func(t *testing.T) {
+		requireCacheHit(t, requestBody, "application/json", `{"choices":[]}`)
+		requireCacheHit(t, equivalentBody, "application/json", `{"choices":[]}`)
+	})
+	t.Run("other backend", func(t *testing.T) {
+		// The cached response of another backend is not served.
+		mm := &mockChatCompletionMetrics{}
+		requireCacheMiss(t, mm, newProcessorWithBackend(t, mm, requestBody, "other-backend"), requestBody)
+	})
+	t.Run("streaming", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, streamingRequestBody)
+		p.translator.(*mockTranslator).retBodyMutation = &extprocv3.BodyMutation{
+			Mutation: &extprocv3.BodyMutation_Body{Body: []byte("data: translated\n\n")},
+		}
+		requireCacheMiss(t, mm, p, streamingRequestBody)
+
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{
+			Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}},
+		})
+		require.NoError(t, err)
+		for _, eos := range []bool{false, false, true} {
+			_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: original\n\n"), EndOfStream: eos})
+			require.NoError(t, err)
+		}
+		requireCacheHit(t, streamingRequestBody, "text/event-stream", "data: translated\n\ndata: translated\n\ndata: translated\n\n")
+	})
+	t.Run("error response is not cached", func(t *testing.T) {
+		const body = `{"model":"some-model","messages":[{"role":"user","content":"error"}]}`
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, body)
+		requireCacheMiss(t, mm, p, body)
+
+		p.translator.(*mockTranslator).expHeaders = map[string]string{":status": "500"}
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{
+			Headers: []*corev3.HeaderValue{{Key: ":status", Value: "500"}},
+		})
+		require.NoError(t, err)
+		require.Empty(t, p.responseCacheKey)
+		_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"error":{}}`), EndOfStream: true})
+		require.NoError(t, err)
+
+		mm = &mockChatCompletionMetrics{}
+		requireCacheMiss(t, mm, newProcessor(t, mm, body), body)
+	})
+}
+
+func TestServer_LoadConfig_responseCache(t *testing.T) {
+	s, err := NewServer(slog.Default(), nil)
+	require.NoError(t, err)
+
+	config := &filterapi.Config{ResponseCache: &filterapi.ResponseCacheConfig{TTL: time.Minute, MaxEntries: 10}}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	store := s.config.responseCache
+	require.NotNil(t, store)
+
+	// The store is reused as long as the configuration is unchanged.
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Same(t, store, s.config.responseCache)
+
+	config.ResponseCache.MaxEntries = 20
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.NotSame(t, store, s.config.responseCache)
+
+	// The cached responses are scoped to each backend of the rules.
+	config.Rules = []filterapi.RouteRule{{Backends: []filterapi.Backend{{Name: "foo"}, {Name: "bar"}}}}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Len(t, s.config.responseCacheScopes, 2)
+
+	config.ResponseCache = nil
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Nil(t, s.config.responseCache)
+	require.Empty(t, s.config.responseCacheScopes)
+
+	config.ResponseCache = &filterapi.ResponseCacheConfig{TTL: time.Minute}
+	require.ErrorContains(t, s.LoadConfig(t.Context(), config), "invalid response cache config: maxEntries=0, ttl=1m0s")
+}
@@ -0,0 +1,94 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package responsecache provides the stores of the cached responses served by the external processor.
+package responsecache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store is the interface for the response cache store.
+//
+// The values are opaque bytes so that the implementation can be backed by an external key-value store
+// such as Redis in addition to the in-memory one.
+type Store interface {
+	// Get returns the value stored for the key. This returns false if the key is not found or has expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores the value for the key.
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// NewMemoryStore creates a new in-memory [Store] holding at most maxEntries values, each of which expires
+// after the ttl. When the store is full, the least recently used value is evicted.
+func NewMemoryStore(maxEntries int, ttl time.Duration) Store {
+	return &memoryStore{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element, maxEntries),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// memoryStore implements [Store] with a LRU cache.
+type memoryStore struct {
+	maxEntries int
+	ttl        time.Duration
+	mux        sync.Mutex
+	entries    map[string]*list.Element
+	// lru holds *memoryStoreEntry where the front is the most recently used.
+	lru *list.List
+	// now is the function to get the current time, which can be replaced in tests.
+	now func() time.Time
+}
+
+// memoryStoreEntry is the element of memoryStore.lru.
+type memoryStoreEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// Get implements [Store.Get].
+func (m *memoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryStoreEntry)
+	if !m.now().Before(entry.expiresAt) {
+		m.lru.Remove(elem)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set implements [Store.Set].
+func (m *memoryStore) Set(_ context.Context, key string, value []byte) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	expiresAt := m.now().Add(m.ttl)
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryStoreEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		m.lru.MoveToFront(elem)
+		return nil
+	}
+	m.entries[key] = m.lru.PushFront(&memoryStoreEntry{key: key, value: value, expiresAt: expiresAt})
+	for m.lru.Len() > m.maxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryStoreEntry).key)
+	}
+	return nil
+}
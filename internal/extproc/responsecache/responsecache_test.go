@@ -0,0 +1,78 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package responsecache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore(2, time.Minute).(*memoryStore)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	requireGet := func(t *testing.T, key string, exp string) {
+		v, ok, err := s.Get(t.Context(), key)
+		require.NoError(t, err)
+		if exp == "" {
+			require.False(t, ok)
+			require.Nil(t, v)
+			return
+		}
+		require.True(t, ok)
+		require.Equal(t, exp, string(v))
+	}
+
+	t.Run("get and set", func(t *testing.T) {
+		requireGet(t, "foo", "")
+		require.NoError(t, s.Set(t.Context(), "foo", []byte("foo-value")))
+		requireGet(t, "foo", "foo-value")
+		require.NoError(t, s.Set(t.Context(), "foo", []byte("foo-value2")))
+		requireGet(t, "foo", "foo-value2")
+		require.Equal(t, 1, s.lru.Len())
+	})
+	t.Run("least recently used is evicted", func(t *testing.T) {
+		require.NoError(t, s.Set(t.Context(), "bar", []byte("bar-value")))
+		// Access foo so that bar becomes the least recently used.
+		requireGet(t, "foo", "foo-value2")
+		require.NoError(t, s.Set(t.Context(), "baz", []byte("baz-value")))
+		requireGet(t, "bar", "")
+		requireGet(t, "foo", "foo-value2")
+		requireGet(t, "baz", "baz-value")
+		require.Len(t, s.entries, 2)
+	})
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		requireGet(t, "foo", "")
+		requireGet(t, "baz", "")
+		require.Empty(t, s.entries)
+		require.Zero(t, s.lru.Len())
+	})
+}
+
+func TestMemoryStore_concurrent(t *testing.T) {
+	s := NewMemoryStore(10, time.Minute)
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := strconv.Itoa(i % 20)
+			require.NoError(t, s.Set(t.Context(), key, []byte(key)))
+			if v, ok, err := s.Get(t.Context(), key); ok {
+				require.NoError(t, err)
+				require.Equal(t, key, string(v))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, s.(*memoryStore).entries, 10)
+}
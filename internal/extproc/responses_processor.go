@@ -0,0 +1,338 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/tokenizer"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
+)
+
+// ResponsesProcessorFactory returns a factory method to instantiate the responses processor.
+func ResponsesProcessorFactory(rm x.ChatCompletionMetrics) ProcessorFactory {
+	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger) (Processor, error) {
+		if config.schema.Name != filterapi.APISchemaOpenAI {
+			return nil, fmt.Errorf("unsupported API schema: %s", config.schema.Name)
+		}
+		return &responsesProcessor{
+			config:         config,
+			requestHeaders: requestHeaders,
+			logger:         logger,
+			metrics:        rm,
+			metricAttrs:    config.metricAttributes.Attributes(requestHeaders),
+			usage:          newUsageRecorder(usageRecordOperationResponses),
+		}, nil
+	}
+}
+
+// responsesProcessor handles the processing of the request and response messages for a single stream
+// of the /v1/responses endpoint.
+type responsesProcessor struct {
+	logger           *slog.Logger
+	config           *processorConfig
+	requestHeaders   map[string]string
+	responseHeaders  map[string]string
+	responseEncoding string
+	translator       translator.OpenAIResponsesTranslator
+	// stream is set to true if the request is a streaming request.
+	stream bool
+	// costs is the cost of the request that is accumulated during the processing of the response.
+	costs translator.LLMTokenUsage
+	// estimatedInputTokens is the number of input tokens estimated before the request is sent to the backend.
+	// This is only calculated when a request cost uses it.
+	estimatedInputTokens uint32
+	// metrics tracking. The responses share the metric definitions with the chat completions.
+	metrics x.ChatCompletionMetrics
+	// metricAttrs are the extra attributes of the metrics derived from the request headers.
+	metricAttrs []attribute.KeyValue
+	// upstream is where the request is sent, which the cost and the usage of the request are attributed to.
+	upstream upstream
+	// spend charges the cost of the request to the spend budget of the client.
+	spend spendBudgetTracker
+	// usage emits the usage record of the request.
+	usage usageRecorder
+	// upstreamSpan is the span of the request sent to the upstream, which ends when its response completes.
+	upstreamSpan trace.Span
+	// circuitBreaker is not nil if the request is sent to the backend with the circuit breaker.
+	circuitBreaker *circuitBreakerCall
+	// experimentArm is not nil if the request is assigned to an arm of the experiment.
+	experimentArm *experimentArm
+}
+
+// selectTranslator selects the translator based on the output schema of the backend.
+func (r *responsesProcessor) selectTranslator(b *filterapi.Backend) error {
+	if r.translator != nil { // Prevents re-selection and allows translator injection in tests.
+		return nil
+	}
+	switch b.Schema.Name {
+	case filterapi.APISchemaOpenAI:
+		r.translator = translator.NewResponsesOpenAIToOpenAITranslator()
+	case filterapi.APISchemaAWSBedrock:
+		r.translator = translator.NewResponsesOpenAIToAWSBedrockTranslator(awsBedrockGuardrailConfiguration(b))
+	case filterapi.APISchemaAzureOpenAI:
+		r.translator = translator.NewResponsesOpenAIToAzureOpenAITranslator(b.Schema.Version)
+	default:
+		return fmt.Errorf("unsupported API schema: backend=%s", b.Schema)
+	}
+	return nil
+}
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+func (r *responsesProcessor) ProcessRequestHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	// Start tracking metrics for this request.
+	r.metrics.StartRequest(r.requestHeaders)
+
+	// The request headers have already been at the time the processor was created.
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+		RequestHeaders: &extprocv3.HeadersResponse{},
+	}}, nil
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (r *responsesProcessor) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			r.metrics.RecordRequestCompletion(ctx, false, failureAttrs(r.metricAttrs, aigwerrors.TypeOf(err))...)
+		}
+	}()
+	model, body, err := parseOpenAIResponseBody(rawBody)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.ClientError, fmt.Errorf("failed to parse request body: %w", err))
+	}
+	r.logger.Info("processing request body", "path", r.requestHeaders[":path"], "model", model)
+	// The responses are the chat operation in terms of the GenAI semantic conventions.
+	setRequestSpanAttributes(trace.SpanFromContext(ctx), usageRecordOperationChat, model)
+	recordPrompt(ctx, r.config, rawBody.Body)
+
+	r.metrics.SetModel(model)
+	if rejected, t := r.spend.check(ctx, r.config, r.requestHeaders, r.logger); rejected != nil {
+		r.metrics.RecordRequestCompletion(ctx, false, failureAttrs(r.metricAttrs, t)...)
+		return rejected, nil
+	}
+	r.requestHeaders[r.config.modelNameHeaderKey] = model
+	_, routeSpan := r.config.tracing.Start(ctx, "route", trace.SpanKindInternal)
+	b, err := r.config.router.Calculate(r.requestHeaders)
+	if err != nil {
+		tracing.End(routeSpan, err)
+		if errors.Is(err, x.ErrNoMatchingRule) {
+			r.metrics.RecordRequestCompletion(ctx, false, failureAttrs(r.metricAttrs, aigwerrors.ClientError)...)
+			return noMatchingRuleResponse(err), nil
+		}
+		return nil, fmt.Errorf("failed to calculate route: %w", err)
+	}
+	r.experimentArm = r.config.experimentArms[b]
+	r.metricAttrs = r.experimentArm.metricAttrs(r.metricAttrs)
+	routeSpan.SetAttributes(aigwBackend.String(b.Name))
+	routeSpan.End()
+	if b.DynamicLoadBalancing != nil {
+		// TODO: the dynamic load balancer only knows how to select endpoints for chat completions for now.
+		return nil, fmt.Errorf("dynamic load balancing is not supported for responses: backend=%s", b.Name)
+	}
+	r.circuitBreaker = admitCircuitBreaker(ctx, r.config, b, r.metrics, r.logger)
+
+	r.logger.Info("selected backend", "backend", b.Name, "schema", b.Schema)
+	r.metrics.SetBackend(b)
+
+	if err = r.selectTranslator(b); err != nil {
+		return nil, fmt.Errorf("failed to select translator: %w", err)
+	}
+
+	_, translateSpan := r.config.tracing.Start(ctx, "translate request", trace.SpanKindInternal,
+		attribute.String("aigw.backend.schema", string(b.Schema.Name)))
+	backendModel, mappedRaw, err := mapModelName(b.ModelNameMappings, model, rawBody.Body)
+	if err != nil {
+		tracing.End(translateSpan, err)
+		return nil, fmt.Errorf("failed to map model name: %w", err)
+	}
+	if mappedRaw != nil {
+		mapped := *body
+		mapped.Model = backendModel
+		body = &mapped
+		r.metrics.SetModel(backendModel)
+	}
+	r.upstream = upstream{backend: b.Name, model: backendModel}
+
+	if r.config.estimateInputTokens {
+		r.estimatedInputTokens = tokenizer.ResponseInputTokens(tokenizer.ForModel(backendModel), body)
+	}
+
+	headerMutation, bodyMutation, err := r.translator.RequestBody(body)
+	tracing.End(translateSpan, err)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform request: %w", err))
+	}
+
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	}
+	if mappedRaw != nil && bodyMutation == nil {
+		// The translator passes the original request through, so the one with the mapped model name needs to replace it.
+		bodyMutation = replaceRequestBody(headerMutation, mappedRaw)
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		// Set the model name to the request header with the key `x-ai-eg-model`.
+		Header: &corev3.HeaderValue{Key: r.config.modelNameHeaderKey, RawValue: []byte(model)},
+	}, &corev3.HeaderValueOption{
+		// Also set the selected backend to the request header with the key `x-ai-eg-selected-backend`.
+		Header: &corev3.HeaderValue{Key: r.config.selectedBackendHeaderKey, RawValue: []byte(b.Name)},
+	})
+	if authHandler, ok := r.config.backendAuthHandlers[b.Name]; ok {
+		authCtx, authSpan := r.config.tracing.Start(ctx, "auth", trace.SpanKindInternal)
+		err = authHandler.Do(authCtx, r.requestHeaders, headerMutation, bodyMutation)
+		tracing.End(authSpan, err)
+		if err != nil {
+			return nil, aigwerrors.Wrap(aigwerrors.AuthError, fmt.Errorf("failed to do auth request: %w", err))
+		}
+	}
+	var upstreamCtx context.Context
+	upstreamCtx, r.upstreamSpan = startUpstreamSpan(ctx, r.config, b, r.upstream)
+	injectTraceContext(upstreamCtx, r.config, headerMutation)
+
+	r.stream = body.Stream
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation:  headerMutation,
+					BodyMutation:    bodyMutation,
+					ClearRouteCache: true,
+				},
+			},
+		},
+		DynamicMetadata: r.experimentArm.addDynamicMetadata(r.config,
+			buildEstimatedCostDynamicMetadata(r.config, r.estimatedInputTokens, r.logger)),
+	}, nil
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (r *responsesProcessor) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		if err != nil {
+			r.metrics.RecordRequestCompletion(ctx, false, failureAttrs(r.metricAttrs, aigwerrors.TypeOf(err))...)
+		}
+	}()
+	r.responseHeaders = headersToMap(headers)
+	setResponseStatusSpanAttributes(r.upstreamSpan, r.responseHeaders[":status"])
+	status, _ := strconv.Atoi(r.responseHeaders[":status"])
+	r.circuitBreaker.done(ctx, status, nil)
+	r.circuitBreaker = nil
+	if enc := r.responseHeaders["content-encoding"]; enc != "" {
+		r.responseEncoding = enc
+	}
+	// The translator can be nil as there could be response event generated by previous ext proc without
+	// getting the request event.
+	if r.translator == nil {
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+			ResponseHeaders: &extprocv3.HeadersResponse{},
+		}}, nil
+	}
+	headerMutation, err := r.translator.ResponseHeaders(r.responseHeaders)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response headers: %w", err))
+	}
+	headerMutation = r.experimentArm.setResponseHeaders(headerMutation)
+	var mode *extprocv3http.ProcessingMode
+	if r.stream && r.responseHeaders[":status"] == "200" {
+		// We only stream the response if the status code is 200 and the response is a stream.
+		mode = &extprocv3http.ProcessingMode{ResponseBodyMode: extprocv3http.ProcessingMode_STREAMED}
+	}
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HeadersResponse{
+			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
+		},
+	}, ModeOverride: mode}, nil
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (r *responsesProcessor) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		recordResponseCompletion(ctx, r.metrics, r.metricAttrs, r.responseHeaders, err)
+	}()
+	var br io.Reader
+	switch r.responseEncoding {
+	case "gzip":
+		br, err = gzip.NewReader(bytes.NewReader(body.Body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip: %w", err)
+		}
+	default:
+		br = bytes.NewReader(body.Body)
+	}
+	// The translator can be nil as there could be response event generated by previous ext proc without
+	// getting the request event.
+	if r.translator == nil {
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{}}, nil
+	}
+
+	headerMutation, bodyMutation, tokenUsage, err := r.translator.ResponseBody(r.responseHeaders, br, body.EndOfStream)
+	if err != nil {
+		return nil, aigwerrors.Wrap(aigwerrors.TranslationError, fmt.Errorf("failed to transform response: %w", err))
+	}
+
+	resp := &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation: headerMutation,
+					BodyMutation:   bodyMutation,
+				},
+			},
+		},
+	}
+
+	r.costs.InputTokens += tokenUsage.InputTokens
+	r.costs.OutputTokens += tokenUsage.OutputTokens
+	r.costs.TotalTokens += tokenUsage.TotalTokens
+
+	r.metrics.RecordTokenUsage(ctx, tokenUsage.InputTokens, tokenUsage.OutputTokens, tokenUsage.TotalTokens, r.metricAttrs...)
+	if r.stream {
+		// Token latency is only recorded for streaming responses, otherwise it doesn't make sense since
+		// these metrics are defined as a difference between the two output events.
+		r.metrics.RecordTokenLatency(ctx, tokenUsage.OutputTokens, r.metricAttrs...)
+	}
+
+	if body.EndOfStream {
+		endUpstreamSpan(ctx, r.upstreamSpan, r.costs)
+		r.upstreamSpan = nil
+		r.spend.record(ctx, r.config, r.upstream, r.costs, r.logger)
+		r.usage.emit(r.config, r.requestHeaders, r.responseHeaders, r.upstream, r.costs, r.stream)
+	}
+	if body.EndOfStream && len(r.config.requestCosts) > 0 {
+		resp.DynamicMetadata, err = buildDynamicMetadata(r.config, &r.costs, r.estimatedInputTokens, r.requestHeaders, r.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+func parseOpenAIResponseBody(body *extprocv3.HttpBody) (modelName string, rb *openai.ResponseRequest, err error) {
+	var openAIReq openai.ResponseRequest
+	if err := json.Unmarshal(body.Body, &openAIReq); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	return openAIReq.Model, &openAIReq, nil
+}
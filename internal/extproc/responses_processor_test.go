@@ -0,0 +1,218 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func TestResponses_Schema(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		cfg := &processorConfig{schema: filterapi.VersionedAPISchema{Name: "Foo", Version: "v123"}}
+		_, err := ResponsesProcessorFactory(nil)(cfg, nil, nil)
+		require.ErrorContains(t, err, "unsupported API schema: Foo")
+	})
+	t.Run("supported openai", func(t *testing.T) {
+		cfg := &processorConfig{schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}}
+		_, err := ResponsesProcessorFactory(nil)(cfg, nil, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestResponses_SelectTranslator(t *testing.T) {
+	for _, schema := range []filterapi.APISchemaName{filterapi.APISchemaOpenAI, filterapi.APISchemaAWSBedrock, filterapi.APISchemaAzureOpenAI} {
+		t.Run(string(schema), func(t *testing.T) {
+			r := &responsesProcessor{}
+			require.NoError(t, r.selectTranslator(&filterapi.Backend{
+				Schema:              filterapi.VersionedAPISchema{Name: schema},
+				AWSBedrockGuardrail: &filterapi.AWSBedrockGuardrail{Identifier: "gr", Version: "1"},
+			}))
+			require.NotNil(t, r.translator)
+		})
+	}
+	t.Run("unsupported", func(t *testing.T) {
+		r := &responsesProcessor{}
+		err := r.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAnthropic, Version: "v1"}})
+		require.ErrorContains(t, err, "unsupported API schema: backend={Anthropic v1}")
+	})
+}
+
+func TestResponses_ProcessRequestBody(t *testing.T) {
+	someBody := []byte(`{"model":"some-model","input":"hello","stream":true}`)
+	var expBody openai.ResponseRequest
+	require.NoError(t, json.Unmarshal(someBody, &expBody))
+
+	t.Run("body parser error", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		r := &responsesProcessor{metrics: mm}
+		_, err := r.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
+		require.ErrorContains(t, err, "failed to parse request body")
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("router error 404", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/responses"}
+		mm := &mockChatCompletionMetrics{}
+		r := &responsesProcessor{
+			config:         &processorConfig{router: mockRouter{t: t, expHeaders: headers, retErr: x.ErrNoMatchingRule}},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		resp, err := r.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_NotFound, resp.GetImmediateResponse().GetStatus().GetCode())
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("dynamic load balancing", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/responses"}
+		mm := &mockChatCompletionMetrics{}
+		r := &responsesProcessor{
+			config: &processorConfig{router: mockRouter{
+				t: t, expHeaders: headers, retBackendName: "pool", retBackendDynamicLB: &filterapi.DynamicLoadBalancing{},
+			}},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+		}
+		_, err := r.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+		require.ErrorContains(t, err, "dynamic load balancing is not supported for responses: backend=pool")
+		mm.RequireRequestFailure(t)
+	})
+	t.Run("ok", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/responses"}
+		headerMut := &extprocv3.HeaderMutation{}
+		bodyMut := &extprocv3.BodyMutation{}
+		mt := mockResponsesTranslator{t: t, expRequestBody: &expBody, retHeaderMutation: headerMut, retBodyMutation: bodyMut}
+		mm := &mockChatCompletionMetrics{}
+		r := &responsesProcessor{
+			config: &processorConfig{
+				router: mockRouter{
+					t: t, expHeaders: headers, retBackendName: "some-backend",
+					retVersionedAPISchema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				},
+				selectedBackendHeaderKey: "x-ai-gateway-backend-key",
+				modelNameHeaderKey:       "x-ai-gateway-model-key",
+			},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+			translator:     mt,
+		}
+		resp, err := r.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+		require.NoError(t, err)
+		commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestBody).RequestBody.Response
+		require.Equal(t, headerMut, commonRes.HeaderMutation)
+		require.Equal(t, bodyMut, commonRes.BodyMutation)
+		mm.RequireSelected(t, "some-model", "some-backend")
+		mm.RequireRequestNotCompleted(t)
+		require.True(t, r.stream)
+
+		hdrs := headerMut.SetHeaders
+		require.Len(t, hdrs, 2)
+		require.Equal(t, "x-ai-gateway-model-key", hdrs[0].Header.Key)
+		require.Equal(t, "some-model", string(hdrs[0].Header.RawValue))
+		require.Equal(t, "x-ai-gateway-backend-key", hdrs[1].Header.Key)
+		require.Equal(t, "some-backend", string(hdrs[1].Header.RawValue))
+	})
+	t.Run("model name mapping", func(t *testing.T) {
+		headers := map[string]string{":path": "/v1/responses"}
+		mappedBody := expBody
+		mappedBody.Model = "backend-model"
+		mt := mockResponsesTranslator{t: t, expRequestBody: &mappedBody}
+		mm := &mockChatCompletionMetrics{}
+		r := &responsesProcessor{
+			config: &processorConfig{
+				router: mockRouter{
+					t: t, expHeaders: headers, retBackendName: "some-backend",
+					retVersionedAPISchema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+					retModelNameMappings:  []filterapi.ModelNameMapping{{From: "some-model", To: "backend-model"}},
+				},
+				modelNameHeaderKey: "x-ai-gateway-model-key",
+			},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+			translator:     mt,
+		}
+		resp, err := r.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: someBody})
+		require.NoError(t, err)
+		commonRes := resp.Response.(*extprocv3.ProcessingResponse_RequestBody).RequestBody.Response
+		require.JSONEq(t, `{"model":"backend-model","input":"hello","stream":true}`, string(commonRes.BodyMutation.GetBody()))
+		mm.RequireSelected(t, "backend-model", "some-backend")
+	})
+}
+
+func TestResponses_ProcessResponseHeaders(t *testing.T) {
+	inHeaders := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}}
+	mm := &mockChatCompletionMetrics{}
+	mt := &mockResponsesTranslator{t: t, expHeaders: map[string]string{":status": "200"}}
+	r := &responsesProcessor{translator: mt, metrics: mm}
+	res, err := r.ProcessResponseHeaders(t.Context(), inHeaders)
+	require.NoError(t, err)
+	require.Nil(t, res.ModeOverride)
+
+	r.stream = true
+	res, err = r.ProcessResponseHeaders(t.Context(), inHeaders)
+	require.NoError(t, err)
+	require.Equal(t, extprocv3http.ProcessingMode_STREAMED, res.ModeOverride.ResponseBodyMode)
+
+	mt.retErr = errors.New("test error")
+	_, err = r.ProcessResponseHeaders(t.Context(), inHeaders)
+	require.ErrorContains(t, err, "test error")
+	mm.RequireRequestFailure(t)
+}
+
+func TestResponses_ProcessResponseBody(t *testing.T) {
+	t.Run("error translation", func(t *testing.T) {
+		mm := &mockChatCompletionMetrics{}
+		r := &responsesProcessor{translator: &mockResponsesTranslator{t: t, retErr: errors.New("test error")}, metrics: mm}
+		_, err := r.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.ErrorContains(t, err, "test error")
+		mm.RequireRequestFailure(t)
+		mm.RequireTokensRecorded(t, 0)
+	})
+	t.Run("ok", func(t *testing.T) {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
+		mm := &mockChatCompletionMetrics{}
+		mt := &mockResponsesTranslator{
+			t: t, expResponseBody: inBody, retUsedToken: translator.LLMTokenUsage{InputTokens: 7, OutputTokens: 3, TotalTokens: 10},
+		}
+		r := &responsesProcessor{
+			translator: mt,
+			logger:     slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			metrics:    mm,
+			stream:     true,
+			config: &processorConfig{
+				metadataNamespace: "ai_gateway_llm_ns",
+				requestCosts: []processorConfigRequestCost{
+					{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output_token_usage"}},
+				},
+			},
+		}
+		res, err := r.ProcessResponseBody(t.Context(), inBody)
+		require.NoError(t, err)
+		mm.RequireRequestSuccess(t)
+		mm.RequireTokensRecorded(t, 1)
+		require.Equal(t, 1, mm.tokenLatencyCount)
+		require.Equal(t, float64(3), res.DynamicMetadata.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["output_token_usage"].GetNumberValue())
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package router
+
+import (
+	"fmt"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// arm is the compiled arm of an experiment.
+type arm struct {
+	*filterapi.ExperimentArm
+	// backends are the backends of the rule serving this arm.
+	backends []*filterapi.Backend
+}
+
+// compileExperiment resolves the backends of the arms of the experiment of the rule. This returns nil if the rule
+// has no experiment.
+func compileExperiment(rule *filterapi.RouteRule) ([]arm, error) {
+	if rule.Experiment == nil {
+		return nil, nil
+	}
+	if len(rule.Experiment.Arms) == 0 {
+		return nil, fmt.Errorf("experiment %s has no arm", rule.Experiment.Name)
+	}
+	arms := make([]arm, len(rule.Experiment.Arms))
+	armOf := make(map[string]string, len(rule.Backends))
+	for i := range rule.Experiment.Arms {
+		a := &rule.Experiment.Arms[i]
+		arms[i].ExperimentArm = a
+		for _, name := range a.Backends {
+			if other, ok := armOf[name]; ok {
+				return nil, fmt.Errorf("backend %s belongs to both arms %s and %s", name, other, a.Name)
+			}
+			armOf[name] = a.Name
+			var found bool
+			for j := range rule.Backends {
+				if b := &rule.Backends[j]; b.Name == name {
+					arms[i].backends = append(arms[i].backends, b)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("backend %s of arm %s is not in the rule", name, a.Name)
+			}
+		}
+		if len(arms[i].backends) == 0 {
+			return nil, fmt.Errorf("arm %s has no backend", a.Name)
+		}
+	}
+	for j := range rule.Backends {
+		if _, ok := armOf[rule.Backends[j].Name]; !ok {
+			return nil, fmt.Errorf("backend %s belongs to no arm", rule.Backends[j].Name)
+		}
+	}
+	return arms, nil
+}
+
+// selectArm selects an arm of the experiment by the weights of the arms. When the key is not empty, the arm is
+// selected by the consistent hashing of the key salted with the name of the experiment, so that the assignments
+// of the clients are independent among the experiments.
+func selectArm(experiment string, arms []arm, key string) *arm {
+	if key != "" {
+		key = experiment + "\x00" + key
+	}
+	return &arms[selectWeighted(key, len(arms), func(i int) (string, int) {
+		return arms[i].Name, arms[i].Weight
+	})]
+}
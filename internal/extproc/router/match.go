@@ -0,0 +1,192 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// request is the view of the request headers used by the matches. The path and the query are parsed lazily.
+type request struct {
+	headers map[string]string
+	parsed  bool
+	path    string
+	query   url.Values
+}
+
+// pathAndQuery returns the path and the query parsed from the :path pseudo header.
+func (r *request) pathAndQuery() (string, url.Values) {
+	if !r.parsed {
+		r.parsed = true
+		raw := r.headers[":path"]
+		r.path, r.query = raw, url.Values{}
+		if i := strings.IndexByte(raw, '?'); i >= 0 {
+			r.path = raw[:i]
+			r.query, _ = url.ParseQuery(raw[i+1:])
+		}
+	}
+	return r.path, r.query
+}
+
+// condition is a compiled condition of a match.
+type condition func(r *request) bool
+
+// match is a compiled [filterapi.RouteRuleMatch], which matches if all the conditions are satisfied.
+type match []condition
+
+// matches returns true if all the conditions are satisfied.
+func (m match) matches(r *request) bool {
+	for _, c := range m {
+		if !c(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// compileRule compiles the matches of the rule. The legacy headers are compiled into a separate match each.
+func compileRule(rule *filterapi.RouteRule) ([]match, error) {
+	ret := make([]match, 0, len(rule.Headers)+len(rule.Matches))
+	for i := range rule.Headers {
+		c, err := compileHeaderMatch(&rule.Headers[i])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, match{c})
+	}
+	for i := range rule.Matches {
+		m, err := compileMatch(&rule.Matches[i])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
+// compileMatch compiles the [filterapi.RouteRuleMatch].
+func compileMatch(m *filterapi.RouteRuleMatch) (match, error) {
+	var ret match
+	for i := range m.Headers {
+		c, err := compileHeaderMatch(&m.Headers[i])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, c)
+	}
+	for i := range m.QueryParams {
+		c, err := compileQueryParamMatch(&m.QueryParams[i])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, c)
+	}
+	if m.Path != nil {
+		c, err := compilePathMatch(m.Path)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+// compileHeaderMatch compiles the header match. The header name is looked up as is first since the headers
+// set by the filter itself, e.g. the model name header, are not normalized, and then in lower case as Envoy does.
+func compileHeaderMatch(h *filterapi.HeaderMatch) (condition, error) {
+	name, lowerName := string(h.Name), strings.ToLower(string(h.Name))
+	matchValue, err := compileValueMatch(h.Type == nil || *h.Type == gwapiv1.HeaderMatchExact,
+		h.Type != nil && *h.Type == gwapiv1.HeaderMatchRegularExpression, h.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header match %s: %w", h.Name, err)
+	}
+	return func(r *request) bool {
+		v, ok := r.headers[name]
+		if !ok && name != lowerName {
+			v, ok = r.headers[lowerName]
+		}
+		return ok && matchValue(v)
+	}, nil
+}
+
+// compileQueryParamMatch compiles the query parameter match. The first value is used if the parameter is repeated.
+func compileQueryParamMatch(q *filterapi.QueryParamMatch) (condition, error) {
+	name := string(q.Name)
+	matchValue, err := compileValueMatch(q.Type == nil || *q.Type == gwapiv1.QueryParamMatchExact,
+		q.Type != nil && *q.Type == gwapiv1.QueryParamMatchRegularExpression, q.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query parameter match %s: %w", q.Name, err)
+	}
+	return func(r *request) bool {
+		_, query := r.pathAndQuery()
+		values, ok := query[name]
+		return ok && matchValue(values[0])
+	}, nil
+}
+
+// compileValueMatch compiles the exact or the regular expression match of the value.
+func compileValueMatch(exact, regex bool, value string) (func(string) bool, error) {
+	switch {
+	case exact:
+		return func(v string) bool { return v == value }, nil
+	case regex:
+		re, err := compileFullMatchRegexp(value)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	default:
+		return nil, fmt.Errorf("unsupported match type")
+	}
+}
+
+// compilePathMatch compiles the path match. The prefix match is done on the path elements in the same way as
+// the Gateway API, e.g. "/foo" matches "/foo" and "/foo/bar" but not "/foobar".
+func compilePathMatch(p *filterapi.PathMatch) (condition, error) {
+	value := "/"
+	if p.Value != nil {
+		value = *p.Value
+	}
+	t := gwapiv1.PathMatchPathPrefix
+	if p.Type != nil {
+		t = *p.Type
+	}
+	var matchPath func(string) bool
+	switch t {
+	case gwapiv1.PathMatchExact:
+		matchPath = func(path string) bool { return path == value }
+	case gwapiv1.PathMatchPathPrefix:
+		prefix := strings.TrimSuffix(value, "/")
+		matchPath = func(path string) bool {
+			return strings.HasPrefix(path, prefix) && (len(path) == len(prefix) || path[len(prefix)] == '/')
+		}
+	case gwapiv1.PathMatchRegularExpression:
+		re, err := compileFullMatchRegexp(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path match: %w", err)
+		}
+		matchPath = re.MatchString
+	default:
+		return nil, fmt.Errorf("invalid path match: unsupported match type %s", t)
+	}
+	return func(r *request) bool {
+		path, _ := r.pathAndQuery()
+		return matchPath(path)
+	}, nil
+}
+
+// compileFullMatchRegexp compiles the RE2 regular expression that must match the whole value
+// in the same way as the regular expression matches of Envoy.
+func compileFullMatchRegexp(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
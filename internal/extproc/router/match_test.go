@@ -0,0 +1,150 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func Test_compileMatch(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		match   filterapi.RouteRuleMatch
+		headers map[string]string
+		exp     bool
+	}{
+		{
+			name:    "empty match",
+			headers: map[string]string{},
+			exp:     true,
+		},
+		{
+			name: "regex header",
+			match: filterapi.RouteRuleMatch{Headers: []filterapi.HeaderMatch{
+				{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-model-name", Value: "gpt-4o.*"},
+			}},
+			headers: map[string]string{"x-model-name": "gpt-4o-mini"},
+			exp:     true,
+		},
+		{
+			name: "regex header must match the whole value",
+			match: filterapi.RouteRuleMatch{Headers: []filterapi.HeaderMatch{
+				{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-model-name", Value: "gpt-4o"},
+			}},
+			headers: map[string]string{"x-model-name": "gpt-4o-mini"},
+			exp:     false,
+		},
+		{
+			name: "header name is case-insensitive",
+			match: filterapi.RouteRuleMatch{Headers: []filterapi.HeaderMatch{
+				{Name: "X-Tenant", Value: "foo"},
+			}},
+			headers: map[string]string{"x-tenant": "foo"},
+			exp:     true,
+		},
+		{
+			name: "all headers must match",
+			match: filterapi.RouteRuleMatch{Headers: []filterapi.HeaderMatch{
+				{Name: "x-model-name", Value: "gpt-4o"},
+				{Name: "x-tenant", Value: "foo"},
+			}},
+			headers: map[string]string{"x-model-name": "gpt-4o", "x-tenant": "bar"},
+			exp:     false,
+		},
+		{
+			name: "missing header",
+			match: filterapi.RouteRuleMatch{Headers: []filterapi.HeaderMatch{
+				{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-tenant", Value: ".*"},
+			}},
+			headers: map[string]string{},
+			exp:     false,
+		},
+		{
+			name: "query params",
+			match: filterapi.RouteRuleMatch{QueryParams: []filterapi.QueryParamMatch{
+				{Name: "tier", Value: "premium"},
+				{Type: ptr.To(gwapiv1.QueryParamMatchRegularExpression), Name: "region", Value: "us-.+"},
+			}},
+			headers: map[string]string{":path": "/v1/chat/completions?tier=premium&region=us-east-1"},
+			exp:     true,
+		},
+		{
+			name: "query param not found",
+			match: filterapi.RouteRuleMatch{QueryParams: []filterapi.QueryParamMatch{
+				{Name: "tier", Value: "premium"},
+			}},
+			headers: map[string]string{":path": "/v1/chat/completions"},
+			exp:     false,
+		},
+		{
+			name:    "path prefix",
+			match:   filterapi.RouteRuleMatch{Path: &filterapi.PathMatch{Value: ptr.To("/v1/chat/")}},
+			headers: map[string]string{":path": "/v1/chat/completions?foo=bar"},
+			exp:     true,
+		},
+		{
+			name:    "path prefix matches only whole elements",
+			match:   filterapi.RouteRuleMatch{Path: &filterapi.PathMatch{Value: ptr.To("/v1/chat")}},
+			headers: map[string]string{":path": "/v1/chatbot"},
+			exp:     false,
+		},
+		{
+			name: "exact path",
+			match: filterapi.RouteRuleMatch{Path: &filterapi.PathMatch{
+				Type: ptr.To(gwapiv1.PathMatchExact), Value: ptr.To("/v1/chat/completions"),
+			}},
+			headers: map[string]string{":path": "/v1/chat/completions?foo=bar"},
+			exp:     true,
+		},
+		{
+			name: "regex path",
+			match: filterapi.RouteRuleMatch{Path: &filterapi.PathMatch{
+				Type: ptr.To(gwapiv1.PathMatchRegularExpression), Value: ptr.To("/tenants/[^/]+/v1/.*"),
+			}},
+			headers: map[string]string{":path": "/tenants/foo/v1/chat/completions"},
+			exp:     true,
+		},
+		{
+			name: "header, query and path",
+			match: filterapi.RouteRuleMatch{
+				Headers:     []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o"}},
+				QueryParams: []filterapi.QueryParamMatch{{Name: "tier", Value: "premium"}},
+				Path:        &filterapi.PathMatch{Value: ptr.To("/v1")},
+			},
+			headers: map[string]string{"x-model-name": "gpt-4o", ":path": "/v2/chat/completions?tier=premium"},
+			exp:     false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := compileMatch(&tc.match)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, m.matches(&request{headers: tc.headers}))
+		})
+	}
+}
+
+func Test_compileMatch_invalid(t *testing.T) {
+	_, err := compileMatch(&filterapi.RouteRuleMatch{Headers: []filterapi.HeaderMatch{
+		{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-model-name", Value: "gpt-4o("},
+	}})
+	require.ErrorContains(t, err, "invalid header match x-model-name")
+
+	_, err = compileMatch(&filterapi.RouteRuleMatch{QueryParams: []filterapi.QueryParamMatch{
+		{Type: ptr.To(gwapiv1.QueryParamMatchRegularExpression), Name: "tier", Value: "["},
+	}})
+	require.ErrorContains(t, err, "invalid query parameter match tier")
+
+	_, err = compileMatch(&filterapi.RouteRuleMatch{Path: &filterapi.PathMatch{
+		Type: ptr.To(gwapiv1.PathMatchRegularExpression), Value: ptr.To("*"),
+	}})
+	require.ErrorContains(t, err, "invalid path match")
+}
@@ -6,22 +6,47 @@
 package router
 
 import (
-	"time"
-
-	"golang.org/x/exp/rand"
+	"fmt"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/circuitbreaker"
 )
 
 // router implements [x.Router].
 type router struct {
 	rules []filterapi.RouteRule
+	// matches are the compiled matches of the rules indexed by the rule index.
+	matches [][]match
+	// arms are the compiled arms of the experiments of the rules indexed by the rule index, which is nil for the
+	// rules without experiment.
+	arms [][]arm
+	// circuitBreakers are the circuit breakers of the backends in the rules keyed by the pointer to the backend.
+	circuitBreakers map[*filterapi.Backend]*circuitbreaker.Breaker
 }
 
-// New creates a new [x.Router] implementation for the given config.
-func New(config *filterapi.Config, newCustomFn x.NewCustomRouterFn) (x.Router, error) {
-	r := &router{rules: config.Rules}
+// New creates a new [x.Router] implementation for the given config. The backends whose circuit breakers are
+// unavailable are excluded from the selection. The circuitBreakers can be nil.
+func New(config *filterapi.Config, newCustomFn x.NewCustomRouterFn,
+	circuitBreakers map[*filterapi.Backend]*circuitbreaker.Breaker,
+) (x.Router, error) {
+	r := &router{
+		rules:           config.Rules,
+		matches:         make([][]match, len(config.Rules)),
+		arms:            make([][]arm, len(config.Rules)),
+		circuitBreakers: circuitBreakers,
+	}
+	for i := range config.Rules {
+		m, err := compileRule(&config.Rules[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile rule %d: %w", i, err)
+		}
+		r.matches[i] = m
+		if r.arms[i], err = compileExperiment(&config.Rules[i]); err != nil {
+			return nil, fmt.Errorf("failed to compile experiment of rule %d: %w", i, err)
+		}
+	}
 	if newCustomFn != nil {
 		customRouter := newCustomFn(r, config)
 		return customRouter, nil
@@ -31,52 +56,74 @@ func New(config *filterapi.Config, newCustomFn x.NewCustomRouterFn) (x.Router, e
 
 // Calculate implements [x.Router.Calculate].
 func (r *router) Calculate(headers map[string]string) (backend *filterapi.Backend, err error) {
-	var rule *filterapi.RouteRule
+	ruleIndex := -1
+	req := &request{headers: headers}
 outer:
 	for i := range r.rules {
-		_rule := &r.rules[i]
-		for j := range _rule.Headers {
-			hdr := &_rule.Headers[j]
-			v, ok := headers[string(hdr.Name)]
-			// Currently, we only do the exact matching.
-			if ok && v == hdr.Value {
-				rule = _rule
+		// The rule matches if any of its matches is satisfied.
+		for _, m := range r.matches[i] {
+			if m.matches(req) {
+				ruleIndex = i
 				break outer
 			}
 		}
 	}
-	if rule == nil || len(rule.Backends) == 0 {
-		return nil, x.ErrNoMatchingRule
+	if ruleIndex < 0 || len(r.rules[ruleIndex].Backends) == 0 {
+		return nil, aigwerrors.Wrap(aigwerrors.ClientError, x.ErrNoMatchingRule)
+	}
+	rule := &r.rules[ruleIndex]
+	var key string
+	if rule.StickyRouting != nil {
+		key = headers[rule.StickyRouting.HeaderName]
 	}
-	return r.selectBackendFromRule(rule), nil
+	return r.selectBackendFromRule(rule, r.arms[ruleIndex], key), nil
 }
 
 // selectBackendFromRule selects a backend from the given rule. Precondition: len(rule.Backends) > 0.
-func (r *router) selectBackendFromRule(rule *filterapi.RouteRule) (backend *filterapi.Backend) {
-	if len(rule.Backends) == 1 {
-		return &rule.Backends[0]
+//
+// When the rule has the experiment, an arm is selected by the weights of the arms first, and the backend is selected
+// among the backends of the arm. Only the backends with the lowest priority are the candidates, and the others are
+// reserved for the fallback. The backends whose circuits are open are excluded unless all of them are.
+//
+// When the key is not empty, the arm and the backend are selected by the consistent hashing of the key.
+// Otherwise, they are selected randomly.
+func (r *router) selectBackendFromRule(rule *filterapi.RouteRule, arms []arm, key string) (backend *filterapi.Backend) {
+	backends := make([]*filterapi.Backend, 0, len(rule.Backends))
+	if len(arms) > 0 {
+		backends = append(backends, selectArm(rule.Experiment.Name, arms, key).backends...)
+	} else {
+		for i := range rule.Backends {
+			backends = append(backends, &rule.Backends[i])
+		}
 	}
-
-	// Each backend has a weight, so we randomly select depending on the weight.
-	// This is a pretty naive implementation and can be buggy, so fix it later.
-	totalWeight := 0
-	for _, b := range rule.Backends {
-		totalWeight += b.Weight
+	if len(backends) == 1 {
+		return backends[0]
 	}
 
-	rng := rand.New(rand.NewSource(uint64(time.Now().UnixNano()))) // nolint:gosec
-	// Pick a random backend if none of them have a weight.
-	if totalWeight <= 0 {
-		return &rule.Backends[rng.Intn(len(rule.Backends))]
+	available := make([]*filterapi.Backend, 0, len(backends))
+	for _, b := range backends {
+		if cb := r.circuitBreakers[b]; cb == nil || cb.Available() {
+			available = append(available, b)
+		}
+	}
+	if len(available) == 0 {
+		available = backends
 	}
 
-	selected := rng.Intn(totalWeight)
-	for i := range rule.Backends {
-		b := &rule.Backends[i]
-		if selected < b.Weight {
-			return b
+	minPriority := available[0].Priority
+	for _, b := range available {
+		minPriority = min(minPriority, b.Priority)
+	}
+	candidates := make([]*filterapi.Backend, 0, len(available))
+	for _, b := range available {
+		if b.Priority == minPriority {
+			candidates = append(candidates, b)
 		}
-		selected -= b.Weight
 	}
-	return &rule.Backends[0]
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return candidates[selectWeighted(key, len(candidates), func(i int) (string, int) {
+		return candidates[i].Name, candidates[i].Weight
+	})]
 }
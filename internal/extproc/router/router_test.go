@@ -6,14 +6,22 @@
 package router
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/circuitbreaker"
 )
 
 // dummyCustomRouter implements [filterapi.Router].
@@ -30,7 +38,7 @@ func TestRouter_NewRouter_Custom(t *testing.T) {
 		_, ok := defaultRouter.(*router)
 		require.True(t, ok) // Checking if the default router is correctly passed.
 		return &dummyCustomRouter{}
-	})
+	}, nil)
 	require.NoError(t, err)
 	_, ok := r.(*dummyCustomRouter)
 	require.True(t, ok)
@@ -79,7 +87,7 @@ func TestRouter_Calculate(t *testing.T) {
 				},
 			},
 		},
-	}, nil)
+	}, nil, nil)
 	require.NoError(t, err)
 	r, ok := _r.(*router)
 	require.True(t, ok)
@@ -155,8 +163,71 @@ func TestRouter_Calculate(t *testing.T) {
 	})
 }
 
+func TestRouter_Calculate_matches(t *testing.T) {
+	outSchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	_r, err := New(&filterapi.Config{
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "premium", Schema: outSchema}},
+				Matches: []filterapi.RouteRuleMatch{
+					{
+						Headers: []filterapi.HeaderMatch{
+							{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-model-name", Value: "gpt-4o.*"},
+							{Name: "x-tier", Value: "premium"},
+						},
+					},
+				},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: outSchema}},
+				Matches: []filterapi.RouteRuleMatch{
+					{Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "o1"}}},
+					{Headers: []filterapi.HeaderMatch{
+						{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-model-name", Value: "gpt-.*"},
+					}},
+				},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "tenant", Schema: outSchema}},
+				Matches: []filterapi.RouteRuleMatch{
+					{Path: &filterapi.PathMatch{Value: ptr.To("/tenants/foo")}},
+				},
+			},
+		},
+	}, nil, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		headers map[string]string
+		exp     string
+	}{
+		{headers: map[string]string{"x-model-name": "gpt-4o-mini", "x-tier": "premium"}, exp: "premium"},
+		{headers: map[string]string{"x-model-name": "gpt-4o-mini", "x-tier": "free"}, exp: "openai"},
+		{headers: map[string]string{"x-model-name": "o1"}, exp: "openai"},
+		{headers: map[string]string{"x-model-name": "llama", ":path": "/tenants/foo/v1/chat/completions"}, exp: "tenant"},
+	} {
+		b, err := _r.Calculate(tc.headers)
+		require.NoError(t, err)
+		require.Equal(t, tc.exp, b.Name)
+	}
+	_, err = _r.Calculate(map[string]string{"x-model-name": "llama", ":path": "/v1/chat/completions"})
+	require.ErrorIs(t, err, x.ErrNoMatchingRule)
+	require.Equal(t, aigwerrors.ClientError, aigwerrors.TypeOf(err))
+}
+
+func TestRouter_New_invalidMatch(t *testing.T) {
+	_, err := New(&filterapi.Config{
+		Rules: []filterapi.RouteRule{
+			{Matches: []filterapi.RouteRuleMatch{{Headers: []filterapi.HeaderMatch{
+				{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-model-name", Value: "("},
+			}}}},
+		},
+	}, nil, nil)
+	require.ErrorContains(t, err, "failed to compile rule 0: invalid header match x-model-name")
+}
+
 func TestRouter_selectBackendFromRule(t *testing.T) {
-	_r, err := New(&filterapi.Config{}, nil)
+	_r, err := New(&filterapi.Config{}, nil, nil)
 	require.NoError(t, err)
 	r, ok := _r.(*router)
 	require.True(t, ok)
@@ -168,8 +239,10 @@ func TestRouter_selectBackendFromRule(t *testing.T) {
 	}
 
 	tests := []struct {
-		name                           string
-		rule                           *filterapi.RouteRule
+		name string
+		rule *filterapi.RouteRule
+		// keyed selects with a different sticky key for each request if true.
+		keyed                          bool
 		expectedSelectionsWithMaxDelta expectedSelectionsWithMaxDelta
 	}{
 		{
@@ -232,13 +305,86 @@ func TestRouter_selectBackendFromRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "backends with zero weights should never be chosen",
+			rule: &filterapi.RouteRule{
+				Backends: []filterapi.Backend{
+					{Name: "foo", Schema: outSchema, Weight: 0},
+					{Name: "bar", Schema: outSchema, Weight: 1},
+					{Name: "baz", Schema: outSchema, Weight: 0},
+				},
+			},
+			expectedSelectionsWithMaxDelta: expectedSelectionsWithMaxDelta{
+				"foo": {expectedSelections: 0, maxDelta: 0},
+				"bar": {expectedSelections: 1000, maxDelta: 0},
+				"baz": {expectedSelections: 0, maxDelta: 0},
+			},
+		},
+		{
+			name: "backends with zero weights should never be chosen by the key",
+			rule: &filterapi.RouteRule{
+				Backends: []filterapi.Backend{
+					{Name: "foo", Schema: outSchema, Weight: 0},
+					{Name: "bar", Schema: outSchema, Weight: 1},
+					{Name: "baz", Schema: outSchema, Weight: 0},
+				},
+			},
+			keyed: true,
+			expectedSelectionsWithMaxDelta: expectedSelectionsWithMaxDelta{
+				"foo": {expectedSelections: 0, maxDelta: 0},
+				"bar": {expectedSelections: 1000, maxDelta: 0},
+				"baz": {expectedSelections: 0, maxDelta: 0},
+			},
+		},
+		{
+			name: "multiple backends without weights should be chosen by the key evenly",
+			rule: &filterapi.RouteRule{
+				Backends: []filterapi.Backend{
+					{Name: "foo", Schema: outSchema},
+					{Name: "bar", Schema: outSchema},
+				},
+			},
+			keyed: true,
+			expectedSelectionsWithMaxDelta: expectedSelectionsWithMaxDelta{
+				"foo": {expectedSelections: 500, maxDelta: 100},
+				"bar": {expectedSelections: 500, maxDelta: 100},
+			},
+		},
+		{
+			name: "only the backends with the lowest priority should be chosen",
+			rule: &filterapi.RouteRule{
+				Backends: []filterapi.Backend{
+					{Name: "foo", Schema: outSchema, Weight: 100, Priority: 1},
+					{Name: "bar", Schema: outSchema, Weight: 1},
+					{Name: "baz", Schema: outSchema, Weight: 1},
+				},
+			},
+			expectedSelectionsWithMaxDelta: expectedSelectionsWithMaxDelta{
+				"foo": {
+					expectedSelections: 0,
+					maxDelta:           0,
+				},
+				"bar": {
+					expectedSelections: 500,
+					maxDelta:           100,
+				},
+				"baz": {
+					expectedSelections: 500,
+					maxDelta:           100,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			chosenNames := make(map[string]int)
 			for i := 0; i < 1000; i++ {
-				b := r.selectBackendFromRule(test.rule)
+				var key string
+				if test.keyed {
+					key = fmt.Sprintf("key-%d", i)
+				}
+				b := r.selectBackendFromRule(test.rule, nil, key)
 				chosenNames[b.Name]++
 			}
 
@@ -250,7 +396,7 @@ func TestRouter_selectBackendFromRule(t *testing.T) {
 }
 
 func TestRouter_selectBackendFromRule_negativeWeight(t *testing.T) {
-	_r, err := New(&filterapi.Config{}, nil)
+	_r, err := New(&filterapi.Config{}, nil, nil)
 	require.NoError(t, err)
 	r, ok := _r.(*router)
 	require.True(t, ok)
@@ -262,6 +408,170 @@ func TestRouter_selectBackendFromRule_negativeWeight(t *testing.T) {
 			{Name: "foo", Schema: outSchema, Weight: -1},
 		},
 	}
-	b := r.selectBackendFromRule(rule)
+	b := r.selectBackendFromRule(rule, nil, "")
 	require.Equal(t, "foo", b.Name)
 }
+
+func TestRouter_selectBackendFromRule_circuitBreaker(t *testing.T) {
+	rule := &filterapi.RouteRule{
+		Backends: []filterapi.Backend{
+			{Name: "foo", Weight: 1},
+			{Name: "bar", Weight: 1},
+			{Name: "baz", Weight: 1, Priority: 1},
+		},
+	}
+	policy := &filterapi.CircuitBreakerPolicy{ConsecutiveFailures: 1, OpenDuration: time.Hour, HalfOpenRequests: 1}
+	breakers := map[*filterapi.Backend]*circuitbreaker.Breaker{}
+	for i := range rule.Backends {
+		breakers[&rule.Backends[i]] = circuitbreaker.New(policy)
+	}
+	open := func(name string) {
+		for b, cb := range breakers {
+			if b.Name == name {
+				ticket, _ := cb.Admit()
+				require.NotNil(t, cb.Done(ticket, false))
+			}
+		}
+	}
+	_r, err := New(&filterapi.Config{}, nil, breakers)
+	require.NoError(t, err)
+	r, ok := _r.(*router)
+	require.True(t, ok)
+
+	chosenNames := func() map[string]int {
+		chosen := make(map[string]int)
+		for i := 0; i < 100; i++ {
+			chosen[r.selectBackendFromRule(rule, nil, "").Name]++
+		}
+		return chosen
+	}
+	require.ElementsMatch(t, []string{"foo", "bar"}, slices.Collect(maps.Keys(chosenNames())))
+	// The open backend is excluded, and the other one of the same priority is selected.
+	open("foo")
+	require.Equal(t, map[string]int{"bar": 100}, chosenNames())
+	// When all the backends of the lowest priority are open, the next priority is selected.
+	open("bar")
+	require.Equal(t, map[string]int{"baz": 100}, chosenNames())
+	// When all the backends are open, they are selected as if there were no circuit breakers.
+	open("baz")
+	require.ElementsMatch(t, []string{"foo", "bar"}, slices.Collect(maps.Keys(chosenNames())))
+}
+
+func TestRouter_Calculate_sticky(t *testing.T) {
+	backends := []filterapi.Backend{{Name: "foo", Weight: 1}, {Name: "bar", Weight: 1}, {Name: "baz", Weight: 2}}
+	newRouter := func(t *testing.T, backends []filterapi.Backend) x.Router {
+		r, err := New(&filterapi.Config{Rules: []filterapi.RouteRule{{
+			Headers:       []filterapi.HeaderMatch{{Name: "x-model-name", Value: "llama3.3333"}},
+			Backends:      backends,
+			StickyRouting: &filterapi.StickyRouting{HeaderName: "x-user-id"},
+		}}}, nil, nil)
+		require.NoError(t, err)
+		return r
+	}
+	r := newRouter(t, backends)
+
+	chosen := make(map[string]string)
+	counts := make(map[string]int)
+	for i := range 4000 {
+		user := fmt.Sprintf("user-%d", i)
+		b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333", "x-user-id": user})
+		require.NoError(t, err)
+		chosen[user] = b.Name
+		counts[b.Name]++
+		// The same key always selects the same backend.
+		for range 3 {
+			again, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333", "x-user-id": user})
+			require.NoError(t, err)
+			require.Equal(t, b.Name, again.Name)
+		}
+	}
+	// The keys are distributed by the weights.
+	require.InDelta(t, 1000, counts["foo"], 150)
+	require.InDelta(t, 1000, counts["bar"], 150)
+	require.InDelta(t, 2000, counts["baz"], 150)
+
+	// Removing a backend only moves the keys of that backend.
+	r = newRouter(t, backends[1:])
+	for user, name := range chosen {
+		b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333", "x-user-id": user})
+		require.NoError(t, err)
+		if name != "foo" {
+			require.Equal(t, name, b.Name)
+		}
+	}
+}
+
+func TestRouter_Calculate_experiment(t *testing.T) {
+	r, err := New(&filterapi.Config{Rules: []filterapi.RouteRule{{
+		Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "llama3.3333"}},
+		Backends: []filterapi.Backend{
+			{Name: "control-a", Weight: 1},
+			{Name: "control-b", Weight: 1},
+			{Name: "treatment", Weight: 1},
+		},
+		StickyRouting: &filterapi.StickyRouting{HeaderName: "x-user-id"},
+		Experiment: &filterapi.Experiment{Name: "new-model", Arms: []filterapi.ExperimentArm{
+			{Name: "control", Weight: 9, Backends: []string{"control-a", "control-b"}},
+			{Name: "treatment", Weight: 1, Backends: []string{"treatment"}},
+		}},
+	}}}, nil, nil)
+	require.NoError(t, err)
+
+	counts := make(map[string]int)
+	for i := range 5000 {
+		headers := map[string]string{"x-model-name": "llama3.3333", "x-user-id": fmt.Sprintf("user-%d", i)}
+		b, err := r.Calculate(headers)
+		require.NoError(t, err)
+		counts[b.Name]++
+		again, err := r.Calculate(headers)
+		require.NoError(t, err)
+		require.Equal(t, b.Name, again.Name)
+	}
+	// The arms are selected by their weights, and the backends by theirs within the arm.
+	require.InDelta(t, 500, counts["treatment"], 100)
+	require.InDelta(t, 2250, counts["control-a"], 200)
+	require.InDelta(t, 2250, counts["control-b"], 200)
+
+	// Without the key, the arms are selected randomly by their weights.
+	counts = make(map[string]int)
+	for range 5000 {
+		b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333"})
+		require.NoError(t, err)
+		counts[b.Name]++
+	}
+	require.InDelta(t, 500, counts["treatment"], 150)
+	require.InDelta(t, 4500, counts["control-a"]+counts["control-b"], 150)
+}
+
+func TestRouter_New_invalidExperiment(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		arms   []filterapi.ExperimentArm
+		expErr string
+	}{
+		{name: "no arm", expErr: "experiment exp has no arm"},
+		{
+			name:   "unknown backend",
+			arms:   []filterapi.ExperimentArm{{Name: "a", Backends: []string{"foo", "bar", "qux"}}},
+			expErr: "backend qux of arm a is not in the rule",
+		},
+		{
+			name:   "duplicated backend",
+			arms:   []filterapi.ExperimentArm{{Name: "a", Backends: []string{"foo", "bar"}}, {Name: "b", Backends: []string{"foo"}}},
+			expErr: "backend foo belongs to both arms a and b",
+		},
+		{
+			name:   "unassigned backend",
+			arms:   []filterapi.ExperimentArm{{Name: "a", Backends: []string{"foo"}}},
+			expErr: "backend bar belongs to no arm",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(&filterapi.Config{Rules: []filterapi.RouteRule{{
+				Backends:   []filterapi.Backend{{Name: "foo"}, {Name: "bar"}},
+				Experiment: &filterapi.Experiment{Name: "exp", Arms: tc.arms},
+			}}}, nil, nil)
+			require.ErrorContains(t, err, "failed to compile experiment of rule 0: "+tc.expErr)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package router
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+
+	"github.com/envoyproxy/ai-gateway/internal/hashing"
+)
+
+// selectWeighted selects the index of one of the n items by their weights, where item returns the name and the
+// weight of the i-th item. The items with non-positive weights are never selected unless all the weights are
+// non-positive, in which case all the items are selected equally.
+//
+// When the key is not empty, the item is selected deterministically by the weighted rendezvous hashing of the key
+// and the names, so that the same key keeps selecting the same item, and adding or removing an item only moves the
+// keys of that item. Otherwise, the item is selected randomly.
+func selectWeighted(key string, n int, item func(i int) (name string, weight int)) int {
+	totalWeight := 0
+	for i := range n {
+		_, w := item(i)
+		totalWeight += max(w, 0)
+	}
+	weight := func(i int) int {
+		if totalWeight <= 0 {
+			return 1
+		}
+		_, w := item(i)
+		return max(w, 0)
+	}
+
+	if key == "" {
+		if totalWeight <= 0 {
+			return rand.IntN(n) // nolint:gosec
+		}
+		selected := rand.IntN(totalWeight) // nolint:gosec
+		for i := range n {
+			if selected < weight(i) {
+				return i
+			}
+			selected -= weight(i)
+		}
+		return 0
+	}
+
+	selected, maxScore := 0, math.Inf(-1)
+	for i := range n {
+		w := weight(i)
+		if w == 0 {
+			continue
+		}
+		name, _ := item(i)
+		// The score is -w/ln(u) for the uniform u in (0, 1) derived from the key and the name, whose maximum is
+		// selected with the probability proportional to the weight over the keys.
+		if score := -float64(w) / math.Log(hashUnit(key, name)); score > maxScore {
+			selected, maxScore = i, score
+		}
+	}
+	return selected
+}
+
+// hashUnit hashes the key and the name into a float64 uniformly distributed in (0, 1).
+func hashUnit(key, name string) float64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(name))
+	v := hashing.Mix64(h.Sum64())
+	// The higher 53 bits are used as the mantissa, and 0.5 is added to exclude both 0 and 1.
+	return (float64(v>>11) + 0.5) / (1 << 53)
+}
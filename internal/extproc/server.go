@@ -7,17 +7,23 @@ package extproc
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"slices"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/google/cel-go/cel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
@@ -25,15 +31,26 @@ import (
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/audit"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/budget"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/circuitbreaker"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/dynlb"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/guardrail"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/mirror"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/responsecache"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/router"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/tokenizer"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/usage"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+	"github.com/envoyproxy/ai-gateway/internal/metrics"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
 )
 
 var (
-	sensitiveHeaderRedactedValue = []byte("[REDACTED]")
-	sensitiveHeaderKeys          = []string{"authorization"}
+	sensitiveHeaderRedactedValue = []byte(audit.RedactedValue)
+	sensitiveHeaderKeys          = audit.SensitiveHeaderKeys
 )
 
 // Server implements the external processor server.
@@ -41,49 +58,141 @@ type Server struct {
 	logger     *slog.Logger
 	config     *processorConfig
 	processors map[string]ProcessorFactory
+	// tracing creates the spans of the requests. This is nil if the tracing is disabled.
+	tracing *tracing.Tracing
+	// responseCacheConfig is the configuration of the response cache store in config, if any.
+	responseCacheConfig filterapi.ResponseCacheConfig
+	// spendBudgetStore is the store of the spend budgets in config, if any, and spendBudgetStoreConfig is its configuration.
+	// The store is reused across the reloads as long as the configuration is unchanged since the Local store
+	// locks the file while it is open.
+	spendBudgetStore       budget.Store
+	spendBudgetStoreConfig filterapi.SpendBudgetStoreConfig
+	// usageRecordsKey is the JSON encoded configuration of the usage record pipeline in config, if any. The pipeline
+	// is reused across the reloads as long as the configuration is unchanged so that the queued records are kept.
+	usageRecordsKey string
+	// auditLogKey is the JSON encoded configuration of the audit log in config, if any. The audit logger
+	// is reused across the reloads as long as the configuration is unchanged so that the queued entries are kept.
+	auditLogKey string
+	// mirrorResultsKey is the JSON encoded configuration of the mirror result pipeline in config, if any. The pipeline
+	// is reused across the reloads as long as the configuration is unchanged so that the queued results are kept.
+	mirrorResultsKey string
+	// metricsKey is the JSON encoded configuration of the metric attributes in config, if any. They are reused across
+	// the reloads as long as the configuration is unchanged so that the cap of their distinct values holds.
+	metricsKey string
+	// circuitBreakers are the circuit breakers of the backends in config keyed by the backend name and the JSON
+	// encoded policy. They are reused across the reloads as long as both are unchanged so that the states are kept.
+	circuitBreakers map[string]*circuitbreaker.Breaker
 }
 
-// NewServer creates a new external processor server.
-func NewServer(logger *slog.Logger) (*Server, error) {
+// runningDynamicLB is a dynamic load balancer running in the background until cancel is called.
+type runningDynamicLB struct {
+	lb     dynlb.DynamicLoadBalancer
+	cancel context.CancelFunc
+}
+
+// NewServer creates a new external processor server. The tracing can be nil to disable the tracing.
+func NewServer(logger *slog.Logger, tracing *tracing.Tracing) (*Server, error) {
 	srv := &Server{
 		logger:     logger,
 		processors: make(map[string]ProcessorFactory),
+		tracing:    tracing,
 	}
 	return srv, nil
 }
 
 // LoadConfig updates the configuration of the external processor.
-func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error {
-	rt, err := router.New(config, x.NewCustomRouter)
-	if err != nil {
-		return fmt.Errorf("cannot create router: %w", err)
-	}
-
+func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) (err error) {
 	var (
-		backendAuthHandlers = make(map[string]backendauth.Handler)
-		declaredModels      []string
-		dynamicLBs          = make(map[*filterapi.DynamicLoadBalancing]dynlb.DynamicLoadBalancer)
+		backendAuthHandlers  = make(map[string]backendauth.Handler)
+		declaredModels       []string
+		dynamicLBs           = make(map[*filterapi.DynamicLoadBalancing]dynlb.DynamicLoadBalancer)
+		runningDynamicLBs    = make(map[string]*runningDynamicLB)
+		fallbacks            = make(map[*filterapi.Backend]*processorConfigFallback)
+		hedges               = make(map[*filterapi.Backend]*processorConfigHedge)
+		mirrors              = make(map[*filterapi.Backend]*processorConfigMirror)
+		circuitBreakers      = make(map[*filterapi.Backend]*circuitbreaker.Breaker)
+		circuitBreakersByKey = make(map[string]*circuitbreaker.Breaker)
+		experimentArms       = make(map[*filterapi.Backend]*experimentArm)
+		responseCacheScopes  = make(map[*filterapi.Backend]string)
+		usageRecords         *usage.Pipeline
+		usageRecordsKey      string
+		auditLog             *audit.Logger
+		auditLogKey          string
+		mirrorResults        *mirror.Pipeline
+		mirrorResultsKey     string
+		spendBudgetStore     budget.Store
 	)
-	for _, r := range config.Rules {
-		for _, b := range r.Backends {
+	defer func() {
+		// Close the resources created for the new configuration if the load fails. The ones reused
+		// from the current configuration are kept since it is still in use.
+		if err != nil {
+			s.closeReplaced(&processorConfig{
+				usageRecords: usageRecords, auditLog: auditLog, mirrorResults: mirrorResults, runningDynamicLBs: runningDynamicLBs,
+			}, s.config)
+			if spendBudgetStore != nil && spendBudgetStore != s.spendBudgetStore {
+				s.closeSpendBudgetStore(spendBudgetStore)
+			}
+		}
+	}()
+	for i := range config.Rules {
+		r := &config.Rules[i]
+		var m *processorConfigMirror
+		if r.Mirror != nil {
+			if m, err = newProcessorConfigMirror(ctx, r.Mirror, backendAuthHandlers); err != nil {
+				return fmt.Errorf("cannot create mirror: %w", err)
+			}
+		}
+		newExperimentArms(r, experimentArms)
+		if config.ResponseCache != nil {
+			if err = newResponseCacheScopes(r, responseCacheScopes); err != nil {
+				return fmt.Errorf("cannot create response cache scopes: %w", err)
+			}
+		}
+		for j := range r.Backends {
+			b := &r.Backends[j]
 			if b.Auth != nil {
-				backendAuthHandlers[b.Name], err = backendauth.NewHandler(ctx, b.Auth)
+				backendAuthHandlers[b.Name], err = backendauth.NewHandler(ctx, b.Auth, b.Schema.Name)
 				if err != nil {
 					return fmt.Errorf("cannot create backend auth handler: %w", err)
 				}
 			}
 			if b.DynamicLoadBalancing != nil {
-				dynamicLBs[b.DynamicLoadBalancing], err = dynlb.NewDynamicLoadBalancer(ctx, s.logger, b.DynamicLoadBalancing)
-				if err != nil {
+				var r *runningDynamicLB
+				if r, err = s.dynamicLoadBalancer(ctx, b.DynamicLoadBalancing, runningDynamicLBs); err != nil {
 					return fmt.Errorf("cannot create dynamic load balancer: %w", err)
 				}
+				dynamicLBs[b.DynamicLoadBalancing] = r.lb
+			}
+			// The router returns the pointer to the backend in the config, so we can use it as the key.
+			if r.Fallback != nil {
+				if fallbacks[b], err = newProcessorConfigFallback(r, j); err != nil {
+					return fmt.Errorf("cannot create fallback: %w", err)
+				}
+			}
+			if r.Hedging != nil {
+				if h := newProcessorConfigHedge(r, j); h != nil {
+					hedges[b] = h
+				} else {
+					s.logger.Warn("hedging is disabled for the backend without endpoint or alternative backend", "backend", b.Name)
+				}
+			}
+			if m != nil {
+				mirrors[b] = m
+			}
+			if r.CircuitBreaker != nil {
+				if circuitBreakers[b], err = s.circuitBreaker(b.Name, r.CircuitBreaker, circuitBreakersByKey); err != nil {
+					return fmt.Errorf("cannot create circuit breaker: %w", err)
+				}
 			}
 		}
 		// Collect declared models from configured header routes. These will be used to
 		// serve requests to the /v1/models endpoint.
-		// TODO(nacx): note that currently we only support exact matching in the headers. When
-		// header matching is extended, this will need to be updated.
-		for _, h := range r.Headers {
+		// Only the exact matches can be declared as models, so the regular expression matches are skipped.
+		headers := append([]filterapi.HeaderMatch{}, r.Headers...)
+		for j := range r.Matches {
+			headers = append(headers, r.Matches[j].Headers...)
+		}
+		for _, h := range headers {
 			// If explicitly set to something that is not an exact match, skip.
 			// If not set, we assume it's an exact match.
 			//
@@ -93,9 +202,23 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 			}
 			declaredModels = append(declaredModels, h.Value)
 		}
+		// The client-facing model names mapped by the backends are also declared since they are the ones clients use.
+		for j := range r.Backends {
+			for _, m := range r.Backends[j].ModelNameMappings {
+				if !slices.Contains(declaredModels, m.From) {
+					declaredModels = append(declaredModels, m.From)
+				}
+			}
+		}
+	}
+
+	rt, err := router.New(config, x.NewCustomRouter, circuitBreakers)
+	if err != nil {
+		return fmt.Errorf("cannot create router: %w", err)
 	}
 
 	costs := make([]processorConfigRequestCost, 0, len(config.LLMRequestCosts))
+	var estimateInputTokens bool
 	for i := range config.LLMRequestCosts {
 		c := &config.LLMRequestCosts[i]
 		var prog cel.Program
@@ -105,8 +228,107 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 				return fmt.Errorf("cannot create CEL program for cost: %w", err)
 			}
 		}
+		if c.Type == filterapi.LLMRequestCostTypeEstimatedInputToken || llmcostcel.UsesEstimatedInputTokens(c.CEL) {
+			estimateInputTokens = true
+		}
 		costs = append(costs, processorConfigRequestCost{LLMRequestCost: c, celProg: prog})
 	}
+	if estimateInputTokens {
+		if err = tokenizer.Load(); err != nil {
+			return fmt.Errorf("cannot load tokenizer: %w", err)
+		}
+	}
+
+	var responseCache responsecache.Store
+	if rc := config.ResponseCache; rc != nil {
+		if rc.MaxEntries <= 0 || rc.TTL <= 0 {
+			return fmt.Errorf("invalid response cache config: maxEntries=%d, ttl=%s", rc.MaxEntries, rc.TTL)
+		}
+		// Reuse the current store if the configuration is unchanged so that the cached responses survive the reload.
+		if s.config != nil && s.config.responseCache != nil && s.responseCacheConfig == *rc {
+			responseCache = s.config.responseCache
+		} else {
+			responseCache = responsecache.NewMemoryStore(rc.MaxEntries, rc.TTL)
+		}
+	}
+
+	var guardrailChecker guardrail.Checker
+	if config.Guardrails != nil {
+		if guardrailChecker, err = guardrail.New(config.Guardrails, s.logger); err != nil {
+			return fmt.Errorf("cannot create guardrails: %w", err)
+		}
+	}
+
+	var (
+		spendBudget            *budget.Manager
+		spendBudgetStoreConfig filterapi.SpendBudgetStoreConfig
+	)
+	if sb := config.SpendBudgets; sb != nil {
+		// Reuse the current store if its configuration is unchanged since the Local store locks the file while it is open.
+		spendBudgetStoreConfig = sb.Store
+		if s.spendBudgetStore != nil && s.spendBudgetStoreConfig == sb.Store {
+			spendBudgetStore = s.spendBudgetStore
+		} else if spendBudgetStore, err = budget.NewStore(&sb.Store); err != nil {
+			return fmt.Errorf("cannot create spend budgets: cannot create store: %w", err)
+		}
+		if spendBudget, err = budget.NewManager(sb, spendBudgetStore); err != nil {
+			return fmt.Errorf("cannot create spend budgets: %w", err)
+		}
+	}
+
+	if ur := config.UsageRecords; ur != nil {
+		var raw []byte
+		if raw, err = json.Marshal(ur); err != nil {
+			return fmt.Errorf("failed to marshal usage records: %w", err)
+		}
+		usageRecordsKey = string(raw)
+		if s.config != nil && s.config.usageRecords != nil && s.usageRecordsKey == usageRecordsKey {
+			usageRecords = s.config.usageRecords
+		} else if usageRecords, err = usage.NewPipeline(ur, s.logger); err != nil {
+			return fmt.Errorf("cannot create usage records: %w", err)
+		}
+	}
+	if al := config.AuditLog; al != nil {
+		var raw []byte
+		if raw, err = json.Marshal(al); err != nil {
+			return fmt.Errorf("failed to marshal audit log: %w", err)
+		}
+		auditLogKey = string(raw)
+		if s.config != nil && s.config.auditLog != nil && s.auditLogKey == auditLogKey {
+			auditLog = s.config.auditLog
+		} else if auditLog, err = audit.NewLogger(al, s.logger); err != nil {
+			return fmt.Errorf("cannot create audit log: %w", err)
+		}
+	}
+	if mr := config.MirrorResults; mr != nil {
+		var raw []byte
+		if raw, err = json.Marshal(mr); err != nil {
+			return fmt.Errorf("failed to marshal mirror results: %w", err)
+		}
+		mirrorResultsKey = string(raw)
+		if s.config != nil && s.config.mirrorResults != nil && s.mirrorResultsKey == mirrorResultsKey {
+			mirrorResults = s.config.mirrorResults
+		} else if mirrorResults, err = mirror.NewPipeline(mr, s.logger); err != nil {
+			return fmt.Errorf("cannot create mirror results: %w", err)
+		}
+	}
+	var (
+		metricAttributes *metrics.RequestHeaderAttributes
+		metricsKey       string
+	)
+	if m := config.Metrics; m != nil {
+		var raw []byte
+		if raw, err = json.Marshal(m); err != nil {
+			return fmt.Errorf("failed to marshal metrics: %w", err)
+		}
+		metricsKey = string(raw)
+		if s.config != nil && s.config.metricAttributes != nil && s.metricsKey == metricsKey {
+			metricAttributes = s.config.metricAttributes
+		} else {
+			metricAttributes = metrics.NewRequestHeaderAttributes(m)
+		}
+	}
+	s.metricsKey = metricsKey
 
 	newConfig := &processorConfig{
 		uuid:                     config.UUID,
@@ -117,22 +339,153 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 		backendAuthHandlers:      backendAuthHandlers,
 		metadataNamespace:        config.MetadataNamespace,
 		requestCosts:             costs,
+		estimateInputTokens:      estimateInputTokens,
 		declaredModels:           declaredModels,
 		dynamicLoadBalancers:     dynamicLBs,
+		runningDynamicLBs:        runningDynamicLBs,
+		fallbacks:                fallbacks,
+		hedges:                   hedges,
+		mirrors:                  mirrors,
+		mirrorResults:            mirrorResults,
+		circuitBreakers:          circuitBreakers,
+		experimentArms:           experimentArms,
+		responseCache:            responseCache,
+		responseCacheScopes:      responseCacheScopes,
+		guardrail:                guardrailChecker,
+		spendBudget:              spendBudget,
+		usageRecords:             usageRecords,
+		auditLog:                 auditLog,
+		tracing:                  s.tracing,
+		metricAttributes:         metricAttributes,
+	}
+	s.circuitBreakers = circuitBreakersByKey
+	if config.ResponseCache != nil {
+		s.responseCacheConfig = *config.ResponseCache
 	}
+	s.usageRecordsKey = usageRecordsKey
+	s.auditLogKey = auditLogKey
+	s.mirrorResultsKey = mirrorResultsKey
+	oldConfig, oldSpendBudgetStore := s.config, s.spendBudgetStore
+	s.spendBudgetStore, s.spendBudgetStoreConfig = spendBudgetStore, spendBudgetStoreConfig
 	s.config = newConfig // This is racey, but we don't care.
+	s.closeReplaced(oldConfig, newConfig)
+	if oldSpendBudgetStore != nil && oldSpendBudgetStore != spendBudgetStore {
+		// The requests still in flight with the old configuration fail to check or record the spend.
+		s.closeSpendBudgetStore(oldSpendBudgetStore)
+	}
 	return nil
 }
 
+// closeReplaced closes the resources of the stale configuration that are not used by the next one.
+// Either can be nil.
+func (s *Server) closeReplaced(stale, next *processorConfig) {
+	if stale == nil {
+		return
+	}
+	if next == nil {
+		next = &processorConfig{}
+	}
+	if stale.usageRecords != nil && stale.usageRecords != next.usageRecords {
+		// Closing flushes the queued records, which can take a while, so it is done in the background.
+		// The records of the requests still in flight with the old configuration are dropped.
+		go func(p *usage.Pipeline) {
+			if err := p.Close(); err != nil {
+				s.logger.Error("failed to close the usage record pipeline", "error", err)
+			}
+		}(stale.usageRecords)
+	}
+	if stale.auditLog != nil && stale.auditLog != next.auditLog {
+		// Same as the usage records, the entries of the requests still in flight are dropped.
+		go func(l *audit.Logger) {
+			if err := l.Close(); err != nil {
+				s.logger.Error("failed to close the audit logger", "error", err)
+			}
+		}(stale.auditLog)
+	}
+	if stale.mirrorResults != nil && stale.mirrorResults != next.mirrorResults {
+		// Same as the usage records, the results of the requests still in flight are dropped.
+		go func(p *mirror.Pipeline) {
+			if err := p.Close(); err != nil {
+				s.logger.Error("failed to close the mirror result pipeline", "error", err)
+			}
+		}(stale.mirrorResults)
+	}
+	for key, r := range stale.runningDynamicLBs {
+		// Stopping the dynamic load balancers stops resolving and scraping their endpoints in the background.
+		// The requests still in flight keep using the endpoints selected already.
+		if next.runningDynamicLBs[key] != r {
+			r.cancel()
+		}
+	}
+}
+
+// closeSpendBudgetStore closes the spend budget store.
+func (s *Server) closeSpendBudgetStore(store budget.Store) {
+	if err := store.Close(); err != nil {
+		s.logger.Error("failed to close the spend budget store", "error", err)
+	}
+}
+
+// dynamicLoadBalancer returns the dynamic load balancer for the configuration, reusing the current one if any.
+// The returned one is added to running keyed by the configuration.
+func (s *Server) dynamicLoadBalancer(ctx context.Context, dyn *filterapi.DynamicLoadBalancing,
+	running map[string]*runningDynamicLB,
+) (*runningDynamicLB, error) {
+	raw, err := json.Marshal(dyn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dynamic load balancing: %w", err)
+	}
+	key := string(raw)
+	r, ok := running[key]
+	if !ok && s.config != nil {
+		r, ok = s.config.runningDynamicLBs[key]
+	}
+	if !ok {
+		// The given context is only valid during the loading, so the background resolution
+		// runs with its own context until the load balancer is no longer used.
+		lbCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		var lb dynlb.DynamicLoadBalancer
+		if lb, err = dynlb.NewDynamicLoadBalancer(lbCtx, s.logger, dyn); err != nil {
+			cancel()
+			return nil, err
+		}
+		r = &runningDynamicLB{lb: lb, cancel: cancel}
+	}
+	running[key] = r
+	return r, nil
+}
+
+// circuitBreaker returns the circuit breaker of the backend for the policy, reusing the current one if any.
+// The returned one is added to next keyed by the backend name and the policy.
+func (s *Server) circuitBreaker(backend string, policy *filterapi.CircuitBreakerPolicy,
+	next map[string]*circuitbreaker.Breaker,
+) (*circuitbreaker.Breaker, error) {
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal circuit breaker: %w", err)
+	}
+	key := backend + "/" + string(raw)
+	cb, ok := next[key]
+	if !ok {
+		cb, ok = s.circuitBreakers[key]
+	}
+	if !ok {
+		cb = circuitbreaker.New(policy)
+	}
+	next[key] = cb
+	return cb, nil
+}
+
 // Register a new processor for the given request path.
 func (s *Server) Register(path string, newProcessor ProcessorFactory) {
 	s.processors[path] = newProcessor
 }
 
 // processorForPath returns the processor for the given path.
-// Only exact path matching is supported currently
+// Only exact path matching is supported currently, and the query string is ignored for the matching.
+// The processor still receives the full path including the query string so that the router can match on it.
 func (s *Server) processorForPath(requestHeaders map[string]string) (Processor, error) {
-	path := requestHeaders[":path"]
+	path, _, _ := strings.Cut(requestHeaders[":path"], "?")
 	newProcessor, ok := s.processors[path]
 	if !ok {
 		return nil, fmt.Errorf("no processor defined for path: %v", path)
@@ -153,6 +506,9 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 	// the request by sending an immediate response. In this case, we will use the passThroughProcessor
 	// to pass the request through without any processing as there would be nothing to process from AI Gateway's perspective.
 	var p Processor = passThroughProcessor{}
+	// span is the span of the request, which is started when the request headers are received.
+	var span trace.Span = noop.Span{}
+	defer func() { span.End() }()
 
 	for {
 		select {
@@ -175,9 +531,17 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 		// of type `ProcessingRequest_RequestHeaders`, so this will be executed only once per
 		// request, and the processor will be instantiated only once.
 		if headers := req.GetRequestHeaders().GetHeaders(); headers != nil {
-			p, err = s.processorForPath(headersToMap(headers))
+			requestHeaders := headersToMap(headers)
+			// The span is named after the operation and the model by the processor once the request body is parsed.
+			ctx, span = s.tracing.Start(s.tracing.Extract(ctx, requestHeaders),
+				requestHeaders[":method"]+" "+requestHeaders[":path"], trace.SpanKindServer,
+				attribute.String("http.request.method", requestHeaders[":method"]),
+				attribute.String("url.path", requestHeaders[":path"]),
+			)
+			p, err = s.processorForPath(requestHeaders)
 			if err != nil {
 				s.logger.Error("cannot get processor", slog.String("error", err.Error()))
+				span.SetStatus(otelcodes.Error, err.Error())
 				return status.Error(codes.NotFound, err.Error())
 			}
 		}
@@ -187,7 +551,20 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 		resp, err := s.processMsg(ctx, p, req)
 		if err != nil {
 			s.logger.Error("error processing request message", slog.String("error", err.Error()))
-			return status.Errorf(codes.Unknown, "error processing request message: %v", err)
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+			span.SetAttributes(attribute.String("error.type", string(aigwerrors.TypeOf(err))))
+			if req.GetResponseBody() != nil {
+				// The response headers have already been sent to the client, so the stream can only be aborted.
+				return status.Errorf(codes.Unknown, "error processing request message: %v", err)
+			}
+			// Otherwise, the client receives the error in the OpenAI format regardless of the backend.
+			resp = classifiedErrorResponse(err)
+		}
+		if headers := req.GetResponseHeaders().GetHeaders(); headers != nil {
+			setResponseStatusSpanAttributes(span, headersToMap(headers)[":status"])
+		} else if ir := resp.GetImmediateResponse(); ir != nil {
+			setResponseStatusSpanAttributes(span, strconv.Itoa(int(ir.GetStatus().GetCode())))
 		}
 		if err := stream.Send(resp); err != nil {
 			s.logger.Error("cannot send response", slog.String("error", err.Error()))
@@ -246,6 +623,19 @@ func (s *Server) processMsg(ctx context.Context, p Processor, req *extprocv3.Pro
 	}
 }
 
+// setResponseStatusSpanAttributes sets the status code of the response to the span, if any,
+// which is marked as an error if it is a server error.
+func setResponseStatusSpanAttributes(span trace.Span, statusCode string) {
+	code, err := strconv.Atoi(statusCode)
+	if span == nil || err != nil {
+		return
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", code))
+	if code >= 500 {
+		span.SetStatus(otelcodes.Error, "")
+	}
+}
+
 // Check implements [grpc_health_v1.HealthServer].
 func (s *Server) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
 	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
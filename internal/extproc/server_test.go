@@ -16,16 +16,21 @@ import (
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/dynlb"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 )
 
 func requireNewServerWithMockProcessor(t *testing.T) (*Server, *mockProcessor) {
-	s, err := NewServer(slog.Default())
+	s, err := NewServer(slog.Default(), nil)
 	require.NoError(t, err)
 	require.NotNil(t, s)
 	s.config = &processorConfig{}
@@ -50,8 +55,8 @@ func TestServer_LoadConfig(t *testing.T) {
 			Rules: []filterapi.RouteRule{
 				{
 					Backends: []filterapi.Backend{
-						{Name: "kserve", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
-						{Name: "awsbedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}},
+						{Name: "kserve", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Endpoint: "http://kserve"},
+						{Name: "awsbedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Endpoint: "https://awsbedrock"},
 					},
 					Headers: []filterapi.HeaderMatch{
 						{
@@ -59,6 +64,7 @@ func TestServer_LoadConfig(t *testing.T) {
 							Value: "llama3.3333",
 						},
 					},
+					Fallback: &filterapi.FallbackPolicy{},
 				},
 				{
 					Backends: []filterapi.Backend{
@@ -75,6 +81,22 @@ func TestServer_LoadConfig(t *testing.T) {
 						},
 					},
 				},
+				{
+					Backends: []filterapi.Backend{
+						{
+							Name: "vllm", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+							ModelNameMappings: []filterapi.ModelNameMapping{
+								{From: "qwen3", To: "Qwen/Qwen3-8B"}, {From: "qwen-large", To: "Qwen/Qwen3-32B"},
+							},
+						},
+					},
+					Matches: []filterapi.RouteRuleMatch{
+						{Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "qwen3"}}},
+						{Headers: []filterapi.HeaderMatch{
+							{Type: ptr.To(gwapiv1.HeaderMatchRegularExpression), Name: "x-model-name", Value: "qwen.*"},
+						}},
+					},
+				},
 			},
 		}
 		s, _ := requireNewServerWithMockProcessor(t)
@@ -95,10 +117,208 @@ func TestServer_LoadConfig(t *testing.T) {
 		require.Equal(t, "1 + 1", s.config.requestCosts[1].CEL)
 		prog := s.config.requestCosts[1].celProg
 		require.NotNil(t, prog)
-		val, err := llmcostcel.EvaluateProgram(prog, "", "", 1, 1, 1)
+		val, err := llmcostcel.EvaluateProgram(prog, "", "", 1, 1, 1, 0)
 		require.NoError(t, err)
 		require.Equal(t, uint64(2), val)
-		require.Equal(t, []string{"llama3.3333", "gpt4.4444"}, s.config.declaredModels)
+		// None of the costs uses the estimated input tokens.
+		require.False(t, s.config.estimateInputTokens)
+		require.Equal(t, []string{"llama3.3333", "gpt4.4444", "qwen3", "qwen-large"}, s.config.declaredModels)
+
+		require.Len(t, s.config.fallbacks, 2)
+		kserve, awsbedrock := &config.Rules[0].Backends[0], &config.Rules[0].Backends[1]
+		require.Equal(t, []*filterapi.Backend{awsbedrock}, s.config.fallbacks[kserve].backends)
+		require.Equal(t, []*filterapi.Backend{kserve}, s.config.fallbacks[awsbedrock].backends)
+
+		// The fallback requires the endpoints of all the backends.
+		config.Rules[0].Backends[1].Endpoint = ""
+		require.ErrorContains(t, s.LoadConfig(t.Context(), config), "cannot create fallback: backend awsbedrock has no endpoint")
+	})
+	t.Run("estimated input tokens", func(t *testing.T) {
+		for _, cost := range []filterapi.LLMRequestCost{
+			{MetadataKey: "key", Type: filterapi.LLMRequestCostTypeEstimatedInputToken},
+			{MetadataKey: "key", Type: filterapi.LLMRequestCostTypeCEL, CEL: "estimated_input_tokens + output_tokens"},
+		} {
+			s, _ := requireNewServerWithMockProcessor(t)
+			require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{LLMRequestCosts: []filterapi.LLMRequestCost{cost}}))
+			require.True(t, s.config.estimateInputTokens)
+		}
+	})
+	t.Run("dynamic load balancers are reused", func(t *testing.T) {
+		newConfig := func(port int32) *filterapi.Config {
+			return &filterapi.Config{Rules: []filterapi.RouteRule{{Backends: []filterapi.Backend{{
+				Name: "pool",
+				DynamicLoadBalancing: &filterapi.DynamicLoadBalancing{
+					Backends: []filterapi.DynamicLoadBalancingBackend{{IPs: []string{"1.1.1.1"}, Port: port}},
+				},
+			}}}}}
+		}
+		lbOf := func(s *Server, config *filterapi.Config) dynlb.DynamicLoadBalancer {
+			lb := s.config.dynamicLoadBalancers[config.Rules[0].Backends[0].DynamicLoadBalancing]
+			require.NotNil(t, lb)
+			return lb
+		}
+
+		s, _ := requireNewServerWithMockProcessor(t)
+		config := newConfig(8080)
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		lb := lbOf(s, config)
+		running := s.config.runningDynamicLBs
+
+		// The same configuration reloaded from the file reuses the running load balancer.
+		config = newConfig(8080)
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.Same(t, lb, lbOf(s, config))
+
+		// The changed configuration replaces the load balancer and stops the old one.
+		config = newConfig(9090)
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.NotSame(t, lb, lbOf(s, config))
+		require.Len(t, s.config.runningDynamicLBs, 1)
+		for key := range running {
+			require.NotContains(t, s.config.runningDynamicLBs, key)
+		}
+
+		// The failed reload keeps the running load balancers.
+		lb = lbOf(s, config)
+		config.Rules[0].Backends[0].DynamicLoadBalancing.Policy = "Foo"
+		require.ErrorContains(t, s.LoadConfig(t.Context(), config), "unknown load balancing policy: Foo")
+		require.Len(t, s.config.runningDynamicLBs, 1)
+		for _, r := range s.config.runningDynamicLBs {
+			require.Same(t, lb, r.lb)
+		}
+	})
+	t.Run("metric attributes are reused", func(t *testing.T) {
+		newConfig := func(maxValues int) *filterapi.Config {
+			return &filterapi.Config{Metrics: &filterapi.MetricsConfig{
+				RequestHeaderAttributes: []filterapi.MetricsRequestHeaderAttribute{{Header: "x-tenant-id", Attribute: "tenant.id"}},
+				MaxAttributeValues:      maxValues,
+			}}
+		}
+		s, _ := requireNewServerWithMockProcessor(t)
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(1)))
+		attrs := s.config.metricAttributes
+		require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "a")},
+			attrs.Attributes(map[string]string{"x-tenant-id": "a"}))
+
+		// The values seen are kept across the reloads of the same configuration, so the cap still holds.
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(1)))
+		require.Same(t, attrs, s.config.metricAttributes)
+		require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "_OTHER")},
+			s.config.metricAttributes.Attributes(map[string]string{"x-tenant-id": "b"}))
+
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(2)))
+		require.NotSame(t, attrs, s.config.metricAttributes)
+
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.metricAttributes)
+	})
+	t.Run("mirror", func(t *testing.T) {
+		dir := t.TempDir()
+		newConfig := func(path string) *filterapi.Config {
+			return &filterapi.Config{
+				Rules: []filterapi.RouteRule{{
+					Backends: []filterapi.Backend{{Name: "primary"}, {Name: "secondary"}},
+					Mirror: &filterapi.MirrorPolicy{
+						Backend:    filterapi.Backend{Name: "shadow", Endpoint: "http://shadow"},
+						Percentage: 10,
+						Comparison: &filterapi.MirrorComparison{Type: filterapi.MirrorComparisonTypeExactMatch},
+					},
+				}},
+				MirrorResults: &filterapi.MirrorResultsConfig{Sinks: []filterapi.AuditLogSink{
+					{Type: filterapi.AuditLogSinkTypeFile, Path: path, BatchSize: 1, FlushInterval: time.Second},
+				}},
+			}
+		}
+		s, _ := requireNewServerWithMockProcessor(t)
+		config := newConfig(dir + "/a.jsonl")
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.Len(t, s.config.mirrors, 2)
+		m := s.config.mirrors[&config.Rules[0].Backends[0]]
+		require.Same(t, m, s.config.mirrors[&config.Rules[0].Backends[1]])
+		require.Equal(t, 10, m.Percentage)
+		require.NotNil(t, m.comparator)
+		pipeline := s.config.mirrorResults
+		require.NotNil(t, pipeline)
+
+		// The pipeline is kept across the reloads of the same configuration so that the queued results are kept.
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(dir+"/a.jsonl")))
+		require.Same(t, pipeline, s.config.mirrorResults)
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(dir+"/b.jsonl")))
+		require.NotSame(t, pipeline, s.config.mirrorResults)
+
+		// The failed reload keeps the current pipeline and its key.
+		pipeline = s.config.mirrorResults
+		config = newConfig(dir + "/c.jsonl")
+		config.Rules[0].Mirror.Comparison.Type = "Foo"
+		require.Error(t, s.LoadConfig(t.Context(), config))
+		require.Same(t, pipeline, s.config.mirrorResults)
+		require.NoError(t, s.LoadConfig(t.Context(), newConfig(dir+"/b.jsonl")))
+		require.Same(t, pipeline, s.config.mirrorResults)
+
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.mirrorResults)
+		require.Empty(t, s.config.mirrors)
+	})
+	t.Run("circuit breaker", func(t *testing.T) {
+		s, err := NewServer(slog.Default(), nil)
+		require.NoError(t, err)
+		newConfig := func(consecutiveFailures int) *filterapi.Config {
+			policy := &filterapi.CircuitBreakerPolicy{
+				ConsecutiveFailures: consecutiveFailures, MinimumRequests: 10, Interval: 10 * time.Second,
+				OpenDuration: 30 * time.Second, HalfOpenRequests: 1,
+			}
+			return &filterapi.Config{Rules: []filterapi.RouteRule{
+				{Backends: []filterapi.Backend{{Name: "foo"}, {Name: "bar"}}, CircuitBreaker: policy},
+				{Backends: []filterapi.Backend{{Name: "baz"}}},
+			}}
+		}
+		config := newConfig(5)
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.Len(t, s.config.circuitBreakers, 2)
+		foo := s.config.circuitBreakers[&config.Rules[0].Backends[0]]
+		require.NotNil(t, foo)
+		require.NotSame(t, foo, s.config.circuitBreakers[&config.Rules[0].Backends[1]])
+
+		// The breakers are kept across the reloads of the same configuration so that the states are kept.
+		config = newConfig(5)
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.Same(t, foo, s.config.circuitBreakers[&config.Rules[0].Backends[0]])
+		config = newConfig(3)
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.NotSame(t, foo, s.config.circuitBreakers[&config.Rules[0].Backends[0]])
+
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Empty(t, s.config.circuitBreakers)
+		require.Empty(t, s.circuitBreakers)
+	})
+	t.Run("experiment", func(t *testing.T) {
+		s, err := NewServer(slog.Default(), nil)
+		require.NoError(t, err)
+		config := &filterapi.Config{Rules: []filterapi.RouteRule{*newExperimentRule(), {Backends: []filterapi.Backend{{Name: "d"}}}}}
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		require.Len(t, s.config.experimentArms, 3)
+		require.Equal(t, "treatment", s.config.experimentArms[&config.Rules[0].Backends[2]].arm)
+
+		// The experiment referring to a backend not in the rule is rejected by the router.
+		config.Rules[0].Experiment.Arms[1].Backends = []string{"c", "d"}
+		require.ErrorContains(t, s.LoadConfig(t.Context(), config), "backend d of arm treatment is not in the rule")
+	})
+}
+
+func TestServer_closeReplaced(t *testing.T) {
+	t.Run("dynamic load balancers", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		staleCtx, staleCancel := context.WithCancel(t.Context())
+		keptCtx, keptCancel := context.WithCancel(t.Context())
+		defer keptCancel()
+		kept := &runningDynamicLB{cancel: keptCancel}
+		s.closeReplaced(&processorConfig{runningDynamicLBs: map[string]*runningDynamicLB{
+			"stale": {cancel: staleCancel},
+			"kept":  kept,
+		}}, &processorConfig{runningDynamicLBs: map[string]*runningDynamicLB{"kept": kept}})
+		// The background resolution and scraping of the stale one stop with its context.
+		require.ErrorIs(t, staleCtx.Err(), context.Canceled)
+		require.NoError(t, keptCtx.Err())
 	})
 }
 
@@ -255,6 +475,25 @@ func TestServer_Process(t *testing.T) {
 		err := s.Process(ms)
 		require.ErrorContains(t, err, "context deadline exceeded")
 	})
+	t.Run("processing error", func(t *testing.T) {
+		s, p := requireNewServerWithMockProcessor(t)
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+
+		hm := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/"}}}
+		p.t = t
+		p.expHeaderMap = hm
+		p.retErr = aigwerrors.Wrap(aigwerrors.AuthError, errors.New("cannot sign request"))
+
+		req := &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{Headers: hm}},
+		}
+		// The client receives the classified error without its details.
+		expResponse := errorResponse(500, "server_error", "auth_error", "Internal Server Error")
+		ms := &mockExternalProcessingStream{t: t, ctx: ctx, retRecv: req, expResponseOnSend: expResponse}
+		err := s.Process(ms)
+		require.ErrorContains(t, err, "context deadline exceeded")
+	})
 	t.Run("without going through request headers phase", func(t *testing.T) {
 		// This is a regression test as in #419.
 		s, _ := requireNewServerWithMockProcessor(t)
@@ -271,7 +510,7 @@ func TestServer_Process(t *testing.T) {
 }
 
 func TestServer_ProcessorSelection(t *testing.T) {
-	s, err := NewServer(slog.Default())
+	s, err := NewServer(slog.Default(), nil)
 	require.NoError(t, err)
 	require.NotNil(t, s)
 
@@ -324,6 +563,36 @@ func TestServer_ProcessorSelection(t *testing.T) {
 		err = s.Process(ms)
 		require.ErrorContains(t, err, "context deadline exceeded")
 	})
+
+	t.Run("known path with query string", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+
+		const path = "/three?tier=premium&region=us-east-1"
+		var gotHeaders map[string]string
+		s.Register("/three", func(_ *processorConfig, requestHeaders map[string]string, _ *slog.Logger) (Processor, error) {
+			gotHeaders = requestHeaders
+			return &mockProcessor{
+				t:                     t,
+				expHeaderMap:          &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: path}}},
+				retProcessingResponse: &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{}},
+			}, nil
+		})
+		req := &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_RequestHeaders{
+				RequestHeaders: &extprocv3.HttpHeaders{
+					Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: path}}},
+				},
+			},
+		}
+		expResponse := &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{}}
+		ms := &mockExternalProcessingStream{t: t, ctx: ctx, retRecv: req, expResponseOnSend: expResponse}
+
+		err = s.Process(ms)
+		require.ErrorContains(t, err, "context deadline exceeded")
+		// The processor, hence the router, receives the full path including the query string.
+		require.Equal(t, path, gotHeaders[":path"])
+	})
 }
 
 func Test_filterSensitiveHeadersForLogging(t *testing.T) {
@@ -0,0 +1,88 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// spendBudgetTracker charges the cost of a request to the spend budget of its client.
+type spendBudgetTracker struct {
+	// client is the identifier of the client of the request. This is empty if the budgets are not enforced.
+	client string
+}
+
+// check identifies the client of the request and returns the immediate response rejecting the request
+// if the client has exhausted the budget, together with the classification of the rejection.
+// This returns nil if the request can proceed.
+//
+// When the store fails, the request is let through if the budgets fail open so that the outage of the store does not
+// stop the traffic. Otherwise, it is rejected.
+func (s *spendBudgetTracker) check(ctx context.Context, config *processorConfig, headers map[string]string,
+	logger *slog.Logger,
+) (*extprocv3.ProcessingResponse, aigwerrors.Type) {
+	if config.spendBudget == nil {
+		return nil, ""
+	}
+	if s.client = config.spendBudget.ClientID(headers); s.client == "" {
+		return nil, ""
+	}
+	exceeded, err := config.spendBudget.Exceeded(ctx, s.client)
+	if err != nil {
+		if config.spendBudget.FailOpen() {
+			logger.Error("failed to check the spend budget, letting the request through", "client", s.client, "error", err)
+			return nil, ""
+		}
+		logger.Error("failed to check the spend budget, rejecting the request", "client", s.client, "error", err)
+		s.client = ""
+		return spendBudgetUnavailableResponse(), aigwerrors.Other
+	}
+	if !exceeded {
+		return nil, ""
+	}
+	logger.Info("spend budget exhausted", "client", s.client)
+	s.client = ""
+	return spendBudgetExceededResponse(), aigwerrors.RateLimited
+}
+
+// record charges the cost of the token usage on the upstream to the client.
+// This is a no-op if the client is not identified.
+func (s *spendBudgetTracker) record(ctx context.Context, config *processorConfig, up upstream,
+	usage translator.LLMTokenUsage, logger *slog.Logger,
+) {
+	if config.spendBudget == nil || s.client == "" {
+		return
+	}
+	cost, err := config.spendBudget.Record(ctx, s.client, up.backend, up.model, usage)
+	if err != nil {
+		logger.Error("failed to record the spend", "client", s.client, "error", err)
+		return
+	}
+	logger.Debug("recorded the spend", "client", s.client, "backend", up.backend, "model", up.model, "nanoUSD", cost)
+	// The request is charged only once even if the response processing is repeated.
+	s.client = ""
+}
+
+// spendBudgetExceededResponse builds the immediate response in the OpenAI error format for the client
+// that has exhausted the spend budget.
+func spendBudgetExceededResponse() *extprocv3.ProcessingResponse {
+	return errorResponse(http.StatusTooManyRequests, "insufficient_quota", "insufficient_quota",
+		"You exceeded your current spend budget for this month.")
+}
+
+// spendBudgetUnavailableResponse builds the immediate response in the OpenAI error format for the request
+// rejected because the spend budget cannot be checked.
+func spendBudgetUnavailableResponse() *extprocv3.ProcessingResponse {
+	return errorResponse(http.StatusServiceUnavailable, aigwerrors.OpenAIErrorType(http.StatusServiceUnavailable),
+		"spend_budget_unavailable", "The spend budget cannot be checked at the moment. Please retry later.")
+}
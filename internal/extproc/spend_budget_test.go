@@ -0,0 +1,259 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/budget"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+const spendBudgetExceededBody = `{"type":"error","error":{"type":"insufficient_quota","code":"insufficient_quota",
+	"message":"You exceeded your current spend budget for this month."}}`
+
+// newTestSpendBudget creates the spend budget manager where alice has a budget of 1000 nano USD and has spent spent,
+// and the other clients are unlimited. The price of some-model is 1 nano USD per input or output token.
+func newTestSpendBudget(t *testing.T, spent int64) (*budget.Manager, budget.Store) {
+	store, err := budget.NewFileStore(filepath.Join(t.TempDir(), "spend.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	if spent > 0 {
+		_, err = store.Add(t.Context(), "alice", time.Now().UTC().Format("2006-01"), spent)
+		require.NoError(t, err)
+	}
+	m, err := budget.NewManager(&filterapi.SpendBudgetsConfig{
+		ClientIDHeader: "x-user-id",
+		Pricing: []filterapi.ModelPricing{
+			{Model: "some-model", InputPerMillionTokens: 1_000_000, OutputPerMillionTokens: 1_000_000},
+		},
+		Clients: []filterapi.ClientSpendBudget{{Client: "alice", MonthlyLimit: 1000}},
+	}, store)
+	require.NoError(t, err)
+	return m, store
+}
+
+func requireSpent(t *testing.T, store budget.Store, client string, exp int64) {
+	spent, err := store.Spent(t.Context(), client, time.Now().UTC().Format("2006-01"))
+	require.NoError(t, err)
+	require.Equal(t, exp, spent)
+}
+
+func TestChatCompletion_spendBudget(t *testing.T) {
+	const body = `{"model":"some-model","messages":[{"role":"user","content":"hello"}]}`
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+
+	newProcessor := func(t *testing.T, mm *mockChatCompletionMetrics, m *budget.Manager, client string) *chatCompletionProcessor {
+		headers := map[string]string{":path": "/v1/chat/completions"}
+		if client != "" {
+			headers["x-user-id"] = client
+		}
+		return &chatCompletionProcessor{
+			config: &processorConfig{
+				modelNameHeaderKey:       "x-model-name",
+				selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+				router:                   mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"},
+				spendBudget:              m,
+			},
+			requestHeaders: headers,
+			logger:         slog.Default(),
+			metrics:        mm,
+			translator: &mockTranslator{
+				t: t, expRequestBody: &expBody, retUsedToken: translator.LLMTokenUsage{InputTokens: 300, OutputTokens: 200},
+			},
+		}
+	}
+
+	t.Run("exhausted", func(t *testing.T) {
+		m, _ := newTestSpendBudget(t, 1000)
+		mm := &mockChatCompletionMetrics{}
+		p := newProcessor(t, mm, m, "alice")
+		res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		ir := res.GetImmediateResponse()
+		require.Equal(t, typev3.StatusCode_TooManyRequests, ir.Status.Code)
+		require.JSONEq(t, spendBudgetExceededBody, string(ir.Body))
+		mm.RequireRequestFailureType(t, aigwerrors.RateLimited)
+	})
+	for _, client := range []string{"alice", "bob", ""} {
+		t.Run("charged "+client, func(t *testing.T) {
+			m, store := newTestSpendBudget(t, 100)
+			mm := &mockChatCompletionMetrics{}
+			p := newProcessor(t, mm, m, client)
+			res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+			require.NoError(t, err)
+			require.NotNil(t, res.GetRequestBody())
+
+			_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("part")})
+			require.NoError(t, err)
+			requireSpent(t, store, "alice", 100)
+			_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("end"), EndOfStream: true})
+			require.NoError(t, err)
+
+			// (300 + 200) * 2 tokens at 1 nano USD per token.
+			switch client {
+			case "alice":
+				requireSpent(t, store, "alice", 1100)
+			case "bob":
+				requireSpent(t, store, "alice", 100)
+				requireSpent(t, store, "bob", 1000)
+			default:
+				requireSpent(t, store, "alice", 100)
+			}
+		})
+	}
+}
+
+func TestEmbeddings_spendBudget(t *testing.T) {
+	m, store := newTestSpendBudget(t, 0)
+	headers := map[string]string{":path": "/v1/embeddings", "x-user-id": "alice"}
+	const body = `{"model":"some-model","input":"hello"}`
+	var expBody openai.EmbeddingRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+	p := &embeddingsProcessor{
+		config: &processorConfig{
+			modelNameHeaderKey:       "x-model-name",
+			selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+			router:                   mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"},
+			spendBudget:              m,
+		},
+		requestHeaders: headers,
+		logger:         slog.Default(),
+		metrics:        &mockEmbeddingsMetrics{},
+		translator: &mockEmbeddingTranslator{
+			t: t, expRequestBody: &expBody, retUsedToken: translator.LLMTokenUsage{InputTokens: 1000},
+		},
+	}
+	_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{EndOfStream: true})
+	require.NoError(t, err)
+	requireSpent(t, store, "alice", 1000)
+
+	// The budget is exhausted now.
+	p.spend = spendBudgetTracker{}
+	res, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+	require.NoError(t, err)
+	require.Equal(t, typev3.StatusCode_TooManyRequests, res.GetImmediateResponse().Status.Code)
+	require.JSONEq(t, spendBudgetExceededBody, string(res.GetImmediateResponse().Body))
+}
+
+func TestResponses_spendBudget(t *testing.T) {
+	m, _ := newTestSpendBudget(t, 1000)
+	mm := &mockChatCompletionMetrics{}
+	r := &responsesProcessor{
+		config:         &processorConfig{spendBudget: m},
+		requestHeaders: map[string]string{":path": "/v1/responses", "x-user-id": "alice"},
+		logger:         slog.Default(),
+		metrics:        mm,
+	}
+	res, err := r.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"some-model","input":"hello"}`)})
+	require.NoError(t, err)
+	require.Equal(t, typev3.StatusCode_TooManyRequests, res.GetImmediateResponse().Status.Code)
+	require.JSONEq(t, spendBudgetExceededBody, string(res.GetImmediateResponse().Body))
+	mm.RequireRequestFailureType(t, aigwerrors.RateLimited)
+}
+
+func TestServer_LoadConfig_spendBudget(t *testing.T) {
+	s, err := NewServer(slog.Default(), nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	config := &filterapi.Config{SpendBudgets: &filterapi.SpendBudgetsConfig{
+		ClientIDHeader: "x-user-id",
+		Store:          filterapi.SpendBudgetStoreConfig{Type: filterapi.SpendBudgetStoreTypeLocal, Path: filepath.Join(dir, "a.db")},
+	}}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.NotNil(t, s.config.spendBudget)
+	store := s.spendBudgetStore
+	require.NotNil(t, store)
+
+	// The store is reused as long as its configuration is unchanged. Otherwise, this would block on the file lock.
+	config.SpendBudgets.DefaultMonthlyLimit = 100
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Same(t, store, s.spendBudgetStore)
+
+	config.SpendBudgets.Store.Path = filepath.Join(dir, "b.db")
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.NotSame(t, store, s.spendBudgetStore)
+	// The previous store is closed.
+	_, err = store.Spent(t.Context(), "alice", "2025-01")
+	require.Error(t, err)
+
+	config.SpendBudgets = nil
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Nil(t, s.config.spendBudget)
+	require.Nil(t, s.spendBudgetStore)
+
+	config.SpendBudgets = &filterapi.SpendBudgetsConfig{Store: filterapi.SpendBudgetStoreConfig{Type: "Unknown"}}
+	require.ErrorContains(t, s.LoadConfig(t.Context(), config), "cannot create spend budgets: cannot create store: unknown spend budget store type: Unknown")
+	config.SpendBudgets.Store = filterapi.SpendBudgetStoreConfig{Type: filterapi.SpendBudgetStoreTypeLocal, Path: filepath.Join(dir, "c.db")}
+	require.ErrorContains(t, s.LoadConfig(t.Context(), config), "exactly one of the client ID header or JWT claim must be set")
+	// The store created for the failed reload is closed. Otherwise, this would block on the file lock.
+	c, err := budget.NewFileStore(filepath.Join(dir, "c.db"))
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	// The failed reload keeps the current store open whether the budgets are changed or removed.
+	config = &filterapi.Config{SpendBudgets: &filterapi.SpendBudgetsConfig{
+		ClientIDHeader: "x-user-id",
+		Store:          filterapi.SpendBudgetStoreConfig{Type: filterapi.SpendBudgetStoreTypeLocal, Path: filepath.Join(dir, "a.db")},
+	}}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	store = s.spendBudgetStore
+	invalidUsageRecords := &filterapi.UsageRecordsConfig{Sinks: []filterapi.UsageRecordSink{{Type: "Kafka", BatchSize: 1, FlushInterval: time.Second}}}
+	for _, sb := range []*filterapi.SpendBudgetsConfig{
+		{ClientIDHeader: "x-user-id", Store: filterapi.SpendBudgetStoreConfig{Type: filterapi.SpendBudgetStoreTypeLocal, Path: filepath.Join(dir, "d.db")}},
+		nil,
+	} {
+		require.ErrorContains(t, s.LoadConfig(t.Context(), &filterapi.Config{SpendBudgets: sb, UsageRecords: invalidUsageRecords}),
+			"cannot create usage records")
+		require.Same(t, store, s.spendBudgetStore)
+		_, err = store.Spent(t.Context(), "alice", "2025-01")
+		require.NoError(t, err)
+	}
+}
+
+func TestSpendBudgetTracker_check_storeFailure(t *testing.T) {
+	store, err := budget.NewFileStore(filepath.Join(t.TempDir(), "spend.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+	headers := map[string]string{"x-user-id": "alice"}
+	for _, failOpen := range []bool{true, false} {
+		t.Run(fmt.Sprintf("failOpen=%t", failOpen), func(t *testing.T) {
+			m, err := budget.NewManager(&filterapi.SpendBudgetsConfig{
+				ClientIDHeader: "x-user-id",
+				Clients:        []filterapi.ClientSpendBudget{{Client: "alice", MonthlyLimit: 1000}},
+				FailOpen:       failOpen,
+			}, store)
+			require.NoError(t, err)
+			var s spendBudgetTracker
+			res, errType := s.check(t.Context(), &processorConfig{spendBudget: m}, headers, slog.Default())
+			if failOpen {
+				require.Nil(t, res)
+				require.Equal(t, "alice", s.client)
+				return
+			}
+			require.Equal(t, typev3.StatusCode_ServiceUnavailable, res.GetImmediateResponse().Status.Code)
+			require.Equal(t, aigwerrors.Other, errType)
+			// The rejected request is not charged.
+			require.Empty(t, s.client)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// approximateCharsPerToken is the average number of the ASCII characters per token in the English texts.
+const approximateCharsPerToken = 4
+
+// approximateCounter implements [Counter] by approximating the number of tokens without the vocabulary.
+//
+// The text is split into the pieces in the same way as cl100k_base, and each piece is counted as follows:
+//   - The ASCII piece is one token per approximateCharsPerToken characters, rounded up.
+//   - The CJK characters are one token each.
+//   - The other characters are one token per two characters, rounded up.
+//
+// This tends to overestimate rather than underestimate, which is preferable for the rate limiting.
+type approximateCounter struct{}
+
+// Count implements [Counter.Count].
+func (approximateCounter) Count(text string) (count int) {
+	splitCL100K(text, func(piece string) {
+		var ascii, cjk, others int
+		for _, r := range piece {
+			switch {
+			case r < utf8.RuneSelf:
+				ascii++
+			case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+				cjk++
+			default:
+				others++
+			}
+		}
+		count += ceilDiv(ascii, approximateCharsPerToken) + cjk + ceilDiv(others, 2)
+	})
+	return
+}
+
+// ceilDiv returns a / b rounded up.
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,102 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// bpeMaxPieceLength is the maximum length of a piece merged at once. The merge is quadratic to the length of
+// the piece, so the longer pieces, e.g. base64-encoded data, are split into the chunks of this length. This makes
+// the count slightly larger than the exact one for such pieces.
+const bpeMaxPieceLength = 256
+
+// bpe implements [Counter] with the tiktoken-compatible byte pair encoding.
+type bpe struct {
+	// ranks maps the tokens to their ranks. The lower rank is merged first.
+	ranks map[string]int
+	split splitter
+}
+
+// newBPE creates a new byte pair encoder with the given ranks and the pre-tokenizer.
+func newBPE(ranks map[string]int, split splitter) *bpe {
+	return &bpe{ranks: ranks, split: split}
+}
+
+// Count implements [Counter.Count].
+func (b *bpe) Count(text string) (count int) {
+	b.split(text, func(piece string) {
+		for len(piece) > bpeMaxPieceLength {
+			count += b.countPiece(piece[:bpeMaxPieceLength])
+			piece = piece[bpeMaxPieceLength:]
+		}
+		count += b.countPiece(piece)
+	})
+	return
+}
+
+// countPiece returns the number of tokens of the piece after merging the byte pairs in the order of the ranks.
+func (b *bpe) countPiece(piece string) int {
+	if len(piece) <= 1 {
+		return len(piece)
+	}
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+	// bounds are the start offsets of the parts, plus the end of the piece.
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		minRank, minIndex := math.MaxInt, -1
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < minRank {
+				minRank, minIndex = rank, i
+			}
+		}
+		if minIndex < 0 {
+			break
+		}
+		bounds = append(bounds[:minIndex+1], bounds[minIndex+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// parseTiktokenRanks parses the tiktoken rank table which consists of the lines of a base64-encoded token and its rank.
+func parseTiktokenRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		encoded, rankStr, ok := bytes.Cut(text, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("invalid line %d: missing rank", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid token at line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(string(rankStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank at line %d: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ranks: %w", err)
+	}
+	return ranks, nil
+}
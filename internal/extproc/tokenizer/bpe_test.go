@@ -0,0 +1,61 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTiktokenRanks(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		ranks, err := parseTiktokenRanks(strings.NewReader("YQ== 0\nYg== 1\n\nYWI= 2\n"))
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"a": 0, "b": 1, "ab": 2}, ranks)
+	})
+	for _, tc := range []struct {
+		name, table, expErr string
+	}{
+		{name: "missing rank", table: "YQ==\n", expErr: "invalid line 1: missing rank"},
+		{name: "invalid token", table: "YQ== 0\n!!! 1\n", expErr: "invalid token at line 2"},
+		{name: "invalid rank", table: "YQ== a\n", expErr: "invalid rank at line 1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseTiktokenRanks(strings.NewReader(tc.table))
+			require.ErrorContains(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestBPE_Count(t *testing.T) {
+	b := newBPE(map[string]int{
+		"a": 0, "b": 1, "c": 2, " ": 3,
+		"ab": 4, "abc": 5, " abc": 6,
+	}, splitCL100K)
+	for _, tc := range []struct {
+		text string
+		exp  int
+	}{
+		{text: "", exp: 0},
+		{text: "a", exp: 1},
+		{text: "abc", exp: 1},
+		{text: "abab", exp: 2},
+		{text: "abcabc", exp: 2},
+		{text: "cba", exp: 3},
+		{text: "abc abc", exp: 2},
+		{text: "abc abcab", exp: 3},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			require.Equal(t, tc.exp, b.Count(tc.text))
+		})
+	}
+	t.Run("long piece", func(t *testing.T) {
+		// The pieces longer than bpeMaxPieceLength are merged in chunks.
+		require.Equal(t, bpeMaxPieceLength, b.Count(strings.Repeat("ab", bpeMaxPieceLength)))
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// The overheads of the chat format added to the tokens of the contents, following the OpenAI cookbook
+// https://cookbook.openai.com/examples/how_to_count_tokens_with_tiktoken.
+const (
+	// tokensPerMessage is the overhead of each message for the role and the delimiters.
+	tokensPerMessage = 3
+	// tokensPerName is the overhead of the optional name of the message author.
+	tokensPerName = 1
+	// tokensPerReply is the overhead priming the reply of the assistant.
+	tokensPerReply = 3
+	// tokensPerImage is the number of tokens of an image in the low detail. The actual number depends on
+	// the size of the image, which is not known without fetching and decoding the image.
+	tokensPerImage = 85
+)
+
+// ChatCompletionInputTokens estimates the number of input tokens of the chat completion request.
+func ChatCompletionInputTokens(c Counter, req *openai.ChatCompletionRequest) uint32 {
+	count := tokensPerReply
+	for i := range req.Messages {
+		count += tokensPerMessage
+		switch m := req.Messages[i].Value.(type) {
+		case openai.ChatCompletionUserMessageParam:
+			count += countName(c, m.Name)
+			switch content := m.Content.Value.(type) {
+			case string:
+				count += c.Count(content)
+			case []openai.ChatCompletionContentPartUserUnionParam:
+				for j := range content {
+					switch part := &content[j]; {
+					case part.TextContent != nil:
+						count += c.Count(part.TextContent.Text)
+					case part.ImageContent != nil:
+						count += tokensPerImage
+					}
+				}
+			}
+		case openai.ChatCompletionSystemMessageParam:
+			count += countName(c, m.Name) + countStringOrArray(c, &m.Content)
+		case openai.ChatCompletionDeveloperMessageParam:
+			count += countName(c, m.Name) + countStringOrArray(c, &m.Content)
+		case openai.ChatCompletionToolMessageParam:
+			count += countStringOrArray(c, &m.Content)
+		case openai.ChatCompletionAssistantMessageParam:
+			count += countName(c, m.Name)
+			switch content := m.Content.Value.(type) {
+			case string:
+				count += c.Count(content)
+			case openai.ChatCompletionAssistantMessageParamContent:
+				if content.Text != nil {
+					count += c.Count(*content.Text)
+				}
+			}
+			for j := range m.ToolCalls {
+				count += c.Count(m.ToolCalls[j].Function.Name) + c.Count(m.ToolCalls[j].Function.Arguments)
+			}
+		}
+	}
+	if len(req.Tools) > 0 {
+		count += countJSON(c, req.Tools)
+	}
+	return clampUint32(count)
+}
+
+// EmbeddingInputTokens estimates the number of input tokens of the embedding request.
+func EmbeddingInputTokens(c Counter, req *openai.EmbeddingRequest) uint32 {
+	var count int
+	switch input := req.Input.Value.(type) {
+	case string:
+		count = c.Count(input)
+	case []string:
+		for _, s := range input {
+			count += c.Count(s)
+		}
+	case []int64:
+		// The input is already tokenized.
+		count = len(input)
+	case [][]int64:
+		for _, tokens := range input {
+			count += len(tokens)
+		}
+	}
+	return clampUint32(count)
+}
+
+// ResponseInputTokens estimates the number of input tokens of the responses request.
+func ResponseInputTokens(c Counter, req *openai.ResponseRequest) uint32 {
+	count := tokensPerReply
+	if req.Instructions != nil {
+		count += tokensPerMessage + c.Count(*req.Instructions)
+	}
+	switch input := req.Input.Value.(type) {
+	case string:
+		count += tokensPerMessage + c.Count(input)
+	case []openai.ResponseItem:
+		for i := range input {
+			item := &input[i]
+			count += tokensPerMessage
+			switch item.Type {
+			case openai.ResponseItemTypeFunctionCall:
+				count += c.Count(item.Name) + c.Count(item.Arguments)
+			case openai.ResponseItemTypeFunctionCallOutput:
+				count += c.Count(item.Output)
+			case openai.ResponseItemTypeReasoning:
+				for _, s := range item.Summary {
+					count += c.Count(s.Text)
+				}
+			default:
+				count += countResponseMessageContent(c, item.Content)
+			}
+		}
+	}
+	if len(req.Tools) > 0 {
+		count += countJSON(c, req.Tools)
+	}
+	return clampUint32(count)
+}
+
+// countName returns the number of tokens of the optional name of the message author.
+func countName(c Counter, name string) int {
+	if name == "" {
+		return 0
+	}
+	return tokensPerName + c.Count(name)
+}
+
+// countStringOrArray returns the number of tokens of the content which is either a string or the text parts.
+func countStringOrArray(c Counter, content *openai.StringOrArray) (count int) {
+	switch v := content.Value.(type) {
+	case string:
+		count = c.Count(v)
+	case []openai.ChatCompletionContentPartTextParam:
+		for i := range v {
+			count += c.Count(v[i].Text)
+		}
+	}
+	return
+}
+
+// countResponseMessageContent returns the number of tokens of the content of the message item.
+func countResponseMessageContent(c Counter, content *openai.ResponseMessageContent) (count int) {
+	if content == nil {
+		return 0
+	}
+	switch v := content.Value.(type) {
+	case string:
+		count = c.Count(v)
+	case []openai.ResponseContentPart:
+		for i := range v {
+			switch part := &v[i]; part.Type {
+			case openai.ResponseContentPartTypeInputImage:
+				count += tokensPerImage
+			case openai.ResponseContentPartTypeRefusal:
+				count += c.Count(part.Refusal)
+			default:
+				count += c.Count(part.Text)
+			}
+		}
+	}
+	return
+}
+
+// countJSON returns the number of tokens of the JSON representation of the value. This is used for the tool
+// definitions, which the backends render into the prompt in their own formats.
+func countJSON(c Counter, v any) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return c.Count(string(b))
+}
+
+// clampUint32 converts the count to uint32, clamping it to the maximum value.
+func clampUint32(count int) uint32 {
+	return uint32(min(uint64(count), math.MaxUint32)) //nolint:gosec
+}
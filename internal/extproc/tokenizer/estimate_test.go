@@ -0,0 +1,92 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// wordCounter implements [Counter] by counting the words for testing.
+type wordCounter struct{}
+
+// Count implements [Counter.Count].
+func (wordCounter) Count(text string) int { return len(strings.Fields(text)) }
+
+func TestChatCompletionInputTokens(t *testing.T) {
+	var req openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "model": "gpt-4o",
+  "messages": [
+    {"role": "system", "content": "be nice"},
+    {"role": "developer", "content": [{"type": "text", "text": "be brief"}]},
+    {"role": "user", "name": "bob", "content": [
+      {"type": "text", "text": "what is this"},
+      {"type": "image_url", "image_url": {"url": "https://example.com/cat.png"}}
+    ]},
+    {"role": "assistant", "content": "let me check", "tool_calls": [
+      {"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\": \"cat\"}"}}
+    ]},
+    {"role": "tool", "tool_call_id": "call_1", "content": "a cat"}
+  ],
+  "tools": [{"type": "function", "function": {"name": "lookup"}}]
+}`), &req))
+	exp := tokensPerReply +
+		tokensPerMessage + 2 + // system
+		tokensPerMessage + 2 + // developer
+		tokensPerMessage + tokensPerName + 1 + 3 + tokensPerImage + // user
+		tokensPerMessage + 3 + 1 + 2 + // assistant
+		tokensPerMessage + 2 + // tool
+		1 // tools
+	require.Equal(t, uint32(exp), ChatCompletionInputTokens(wordCounter{}, &req))
+}
+
+func TestEmbeddingInputTokens(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		exp   uint32
+	}{
+		{input: `"hello world"`, exp: 2},
+		{input: `["hello world", "foo"]`, exp: 3},
+		{input: `[1, 2, 3]`, exp: 3},
+		{input: `[[1, 2], [3]]`, exp: 3},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			var req openai.EmbeddingRequest
+			require.NoError(t, json.Unmarshal([]byte(`{"model":"foo","input":`+tc.input+`}`), &req))
+			require.Equal(t, tc.exp, EmbeddingInputTokens(wordCounter{}, &req))
+		})
+	}
+}
+
+func TestResponseInputTokens(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		var req openai.ResponseRequest
+		require.NoError(t, json.Unmarshal([]byte(`{"model":"foo","instructions":"be nice","input":"hello world"}`), &req))
+		require.Equal(t, uint32(tokensPerReply+tokensPerMessage+2+tokensPerMessage+2), ResponseInputTokens(wordCounter{}, &req))
+	})
+	t.Run("items", func(t *testing.T) {
+		var req openai.ResponseRequest
+		require.NoError(t, json.Unmarshal([]byte(`{"model":"foo","input":[
+  {"role": "user", "content": [{"type": "input_text", "text": "what is this"}, {"type": "input_image", "image_url": "https://example.com/cat.png"}]},
+  {"type": "function_call", "call_id": "call_1", "name": "lookup", "arguments": "{}"},
+  {"type": "function_call_output", "call_id": "call_1", "output": "a cat"},
+  {"type": "reasoning", "summary": [{"type": "summary_text", "text": "think hard"}]}
+],"tools":[{"type":"function","name":"lookup"}]}`), &req))
+		exp := tokensPerReply +
+			tokensPerMessage + 3 + tokensPerImage +
+			tokensPerMessage + 1 + 1 +
+			tokensPerMessage + 2 +
+			tokensPerMessage + 2 +
+			1
+		require.Equal(t, uint32(exp), ResponseInputTokens(wordCounter{}, &req))
+	})
+}
@@ -0,0 +1,218 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitter is the pre-tokenizer splitting the text into the pieces which are encoded separately.
+// The pieces are passed to the given function in order.
+type splitter func(text string, piece func(string))
+
+// The splitters below implement the pre-tokenization regular expressions of tiktoken by hand since they use
+// the possessive quantifiers and the lookahead which are not supported by the regexp package.
+
+// splitCL100K implements the pre-tokenizer of the cl100k_base encoding:
+//
+//	'(?i:[sdmt]|ll|ve|re)|[^\r\n\p{L}\p{N}]?+\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]++[\r\n]*|\s*[\r\n]|\s+(?!\S)|\s+
+func splitCL100K(text string, piece func(string)) {
+	for len(text) > 0 {
+		n := matchContraction(text)
+		if n == 0 {
+			n = matchLetters(text)
+		}
+		if n == 0 {
+			n = matchNumbers(text)
+		}
+		if n == 0 {
+			n = matchPunctuations(text, "\r\n")
+		}
+		if n == 0 {
+			n = matchSpaces(text)
+		}
+		if n == 0 {
+			// Unreachable as the alternatives above match any character, but never loop forever.
+			_, n = utf8.DecodeRuneInString(text)
+		}
+		piece(text[:n])
+		text = text[n:]
+	}
+}
+
+// splitO200K implements the pre-tokenizer of the o200k_base encoding:
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitO200K(text string, piece func(string)) {
+	for len(text) > 0 {
+		n := matchCasedWord(text)
+		if n == 0 {
+			n = matchNumbers(text)
+		}
+		if n == 0 {
+			n = matchPunctuations(text, "\r\n/")
+		}
+		if n == 0 {
+			n = matchSpaces(text)
+		}
+		if n == 0 {
+			_, n = utf8.DecodeRuneInString(text)
+		}
+		piece(text[:n])
+		text = text[n:]
+	}
+}
+
+// matchContraction matches '(?i:[sdmt]|ll|ve|re) at the beginning of the text, and returns the length of the match.
+// This is also the optional contraction suffix of the words in the o200k_base pre-tokenizer.
+func matchContraction(text string) int {
+	if len(text) < 2 || text[0] != '\'' {
+		return 0
+	}
+	switch unicode.ToLower(rune(text[1])) {
+	case 's', 'd', 'm', 't':
+		return 2
+	}
+	if len(text) >= 3 {
+		switch strings.ToLower(text[1:3]) {
+		case "ll", "ve", "re":
+			return 3
+		}
+	}
+	return 0
+}
+
+// isLetterPrefix returns true if the rune matches [^\r\n\p{L}\p{N}].
+func isLetterPrefix(r rune) bool {
+	return r != '\r' && r != '\n' && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// isPunctuation returns true if the rune matches [^\s\p{L}\p{N}].
+func isPunctuation(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// isUpperish returns true if the rune matches [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}].
+func isUpperish(r rune) bool {
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// isLowerish returns true if the rune matches [\p{Ll}\p{Lm}\p{Lo}\p{M}].
+func isLowerish(r rune) bool {
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// runLength returns the length of the longest prefix of the text whose runes satisfy f.
+func runLength(text string, f func(rune) bool) int {
+	for i, r := range text {
+		if !f(r) {
+			return i
+		}
+	}
+	return len(text)
+}
+
+// matchLetters matches [^\r\n\p{L}\p{N}]?+\p{L}+ at the beginning of the text.
+func matchLetters(text string) int {
+	start := 0
+	if r, size := utf8.DecodeRuneInString(text); isLetterPrefix(r) {
+		start = size
+	}
+	n := runLength(text[start:], unicode.IsLetter)
+	if n == 0 {
+		return 0
+	}
+	return start + n
+}
+
+// matchCasedWord matches the first two alternatives of the o200k_base pre-tokenizer at the beginning of the text.
+func matchCasedWord(text string) int {
+	r, size := utf8.DecodeRuneInString(text)
+	if isLetterPrefix(r) {
+		// The prefix is optional, so the alternatives are tried without it when they don't match with it.
+		// This only makes a difference for the marks which are both the prefix and the part of the word.
+		if n := matchCasedWordBody(text[size:]); n > 0 {
+			return size + n
+		}
+	}
+	return matchCasedWordBody(text)
+}
+
+// matchCasedWordBody matches the word parts of the first two alternatives of the o200k_base pre-tokenizer.
+func matchCasedWordBody(text string) int {
+	// [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+, where the first run backtracks until the second
+	// one matches.
+	upper := runLength(text, isUpperish)
+	for end := upper; end >= 0; {
+		if lower := runLength(text[end:], isLowerish); lower > 0 {
+			n := end + lower
+			return n + matchContraction(text[n:])
+		}
+		if end == 0 {
+			break
+		}
+		_, size := utf8.DecodeLastRuneInString(text[:end])
+		end -= size
+	}
+	// [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*.
+	if upper == 0 {
+		return 0
+	}
+	n := upper + runLength(text[upper:], isLowerish)
+	return n + matchContraction(text[n:])
+}
+
+// matchNumbers matches \p{N}{1,3} at the beginning of the text.
+func matchNumbers(text string) int {
+	n, count := 0, 0
+	for i, r := range text {
+		if count == 3 || !unicode.IsNumber(r) {
+			return i
+		}
+		n, count = i+utf8.RuneLen(r), count+1
+	}
+	return n
+}
+
+// matchPunctuations matches " ?[^\s\p{L}\p{N}]+[<trailing>]*" at the beginning of the text.
+func matchPunctuations(text string, trailing string) int {
+	start := 0
+	if len(text) > 1 && text[0] == ' ' {
+		start = 1
+	}
+	n := runLength(text[start:], isPunctuation)
+	if n == 0 {
+		return 0
+	}
+	n += start
+	return n + runLength(text[n:], func(r rune) bool { return strings.ContainsRune(trailing, r) })
+}
+
+// matchSpaces matches \s*[\r\n]|\s+(?!\S)|\s+ at the beginning of the text. The trailing [\r\n]+ of the
+// o200k_base pre-tokenizer results in the same match.
+func matchSpaces(text string) int {
+	n := runLength(text, unicode.IsSpace)
+	if n == 0 {
+		return 0
+	}
+	// \s*[\r\n] matches up to the last line break in the spaces.
+	if i := strings.LastIndexAny(text[:n], "\r\n"); i >= 0 {
+		return i + 1
+	}
+	// \s+(?!\S) leaves the last space for the following word unless the spaces are at the end of the text.
+	if n == len(text) {
+		return n
+	}
+	_, size := utf8.DecodeLastRuneInString(text[:n])
+	if n > size {
+		return n - size
+	}
+	return n
+}
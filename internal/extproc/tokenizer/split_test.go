@@ -0,0 +1,59 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func collectPieces(split splitter, text string) (pieces []string) {
+	split(text, func(piece string) { pieces = append(pieces, piece) })
+	return
+}
+
+func TestSplitCL100K(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		exp  []string
+	}{
+		{text: "", exp: nil},
+		{text: "Hello world", exp: []string{"Hello", " world"}},
+		{text: "I'm 12345 ok!!\n\n  x", exp: []string{"I", "'m", " ", "123", "45", " ok", "!!\n\n", " ", " x"}},
+		{text: "They'LL go", exp: []string{"They", "'LL", " go"}},
+		{text: "a  \n\tb", exp: []string{"a", "  \n", "\tb"}},
+		{text: "end   ", exp: []string{"end", "   "}},
+		{text: "x = {\"k\": 1}", exp: []string{"x", " =", " {\"", "k", "\":", " ", "1", "}"}},
+		{text: "こんにちは世界", exp: []string{"こんにちは世界"}},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			pieces := collectPieces(splitCL100K, tc.text)
+			require.Equal(t, tc.exp, pieces)
+			require.Equal(t, tc.text, strings.Join(pieces, ""))
+		})
+	}
+}
+
+func TestSplitO200K(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		exp  []string
+	}{
+		{text: "HelloWorld's test", exp: []string{"Hello", "World's", " test"}},
+		{text: "ABCdef ABC DEF", exp: []string{"ABCdef", " ABC", " DEF"}},
+		{text: "12345", exp: []string{"123", "45"}},
+		{text: "a/b\n", exp: []string{"a", "/b", "\n"}},
+		{text: "ok!/\n  x", exp: []string{"ok", "!/\n", " ", " x"}},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			pieces := collectPieces(splitO200K, tc.text)
+			require.Equal(t, tc.exp, pieces)
+			require.Equal(t, tc.text, strings.Join(pieces, ""))
+		})
+	}
+}
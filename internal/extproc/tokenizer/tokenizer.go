@@ -0,0 +1,124 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package tokenizer provides the offline token counters used to estimate the number of input tokens of a request
+// before it is sent to the backend.
+//
+// The counters for the OpenAI models are tiktoken-compatible byte pair encoders built from the rank tables embedded
+// in the "encodings" directory. For the other models, or when the table of the encoding is not embedded, the counter
+// approximates the number of tokens from the text.
+package tokenizer
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// Counter counts the number of tokens in a text.
+type Counter interface {
+	// Count returns the number of tokens in the given text.
+	Count(text string) int
+}
+
+// encodingName is the name of a tiktoken encoding, which is also the name of the rank table file without the
+// ".tiktoken" extension in the "encodings" directory.
+type encodingName string
+
+const (
+	encodingCL100KBase encodingName = "cl100k_base"
+	encodingO200KBase  encodingName = "o200k_base"
+)
+
+// modelEncodings maps the model name prefixes to the encodings. The longer prefixes must come first.
+var modelEncodings = []struct {
+	prefix   string
+	encoding encodingName
+}{
+	{prefix: "gpt-4o", encoding: encodingO200KBase},
+	{prefix: "chatgpt-4o", encoding: encodingO200KBase},
+	{prefix: "gpt-4.1", encoding: encodingO200KBase},
+	{prefix: "gpt-4.5", encoding: encodingO200KBase},
+	{prefix: "gpt-5", encoding: encodingO200KBase},
+	{prefix: "gpt-oss", encoding: encodingO200KBase},
+	{prefix: "o1", encoding: encodingO200KBase},
+	{prefix: "o3", encoding: encodingO200KBase},
+	{prefix: "o4", encoding: encodingO200KBase},
+	{prefix: "gpt-4", encoding: encodingCL100KBase},
+	{prefix: "gpt-3.5", encoding: encodingCL100KBase},
+	{prefix: "text-embedding-3", encoding: encodingCL100KBase},
+	{prefix: "text-embedding-ada-002", encoding: encodingCL100KBase},
+}
+
+// encodingSplitters maps the encodings to the pre-tokenizers splitting the text into the pieces.
+var encodingSplitters = map[encodingName]splitter{
+	encodingCL100KBase: splitCL100K,
+	encodingO200KBase:  splitO200K,
+}
+
+//go:embed encodings
+var encodingsFS embed.FS
+
+// encoders loads the byte pair encoders from the embedded tables once per encoding. The encoder is nil when the
+// table of the encoding is not embedded.
+var encoders = func() map[encodingName]func() (*bpe, error) {
+	m := make(map[encodingName]func() (*bpe, error), len(encodingSplitters))
+	for name := range encodingSplitters {
+		m[name] = sync.OnceValues(func() (*bpe, error) { return loadEncoder(encodingsFS, name) })
+	}
+	return m
+}()
+
+// approximate is the counter used when there's no byte pair encoder for the model.
+var approximate Counter = approximateCounter{}
+
+// Load parses the embedded tables of all the encodings. This is called when a configuration estimating the input
+// tokens is loaded so that an invalid table is reported then, and the requests do not wait for the parsing.
+func Load() error {
+	for name, load := range encoders {
+		if _, err := load(); err != nil {
+			return fmt.Errorf("failed to load encoding %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ForModel returns the counter for the given model name.
+func ForModel(model string) Counter {
+	// The model name might come with the organization prefix, e.g. "openai/gpt-4o".
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, e := range modelEncodings {
+		if strings.HasPrefix(model, e.prefix) {
+			// The error has been reported by Load, and the approximation is used instead.
+			if enc, err := encoders[e.encoding](); err == nil && enc != nil {
+				return enc
+			}
+			break
+		}
+	}
+	return approximate
+}
+
+// loadEncoder returns the byte pair encoder for the encoding from the table in fsys, or nil if the table doesn't
+// exist.
+func loadEncoder(fsys fs.FS, name encodingName) (*bpe, error) {
+	f, err := fsys.Open("encodings/" + string(name) + ".tiktoken")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	ranks, err := parseTiktokenRanks(f)
+	if err != nil {
+		return nil, err
+	}
+	return newBPE(ranks, encodingSplitters[name]), nil
+}
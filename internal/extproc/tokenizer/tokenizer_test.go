@@ -0,0 +1,104 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tokenizer
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForModel(t *testing.T) {
+	t.Run("embedded encoding", func(t *testing.T) {
+		enc := newBPE(map[string]int{"a": 0}, splitO200K)
+		orig := encoders[encodingO200KBase]
+		encoders[encodingO200KBase] = func() (*bpe, error) { return enc, nil }
+		t.Cleanup(func() { encoders[encodingO200KBase] = orig })
+		require.Same(t, enc, ForModel("gpt-4o-mini"))
+		require.Same(t, enc, ForModel("openai/o3"))
+		// gpt-4 uses cl100k_base.
+		require.NotSame(t, enc, ForModel("gpt-4"))
+		require.IsType(t, &bpe{}, ForModel("gpt-4-turbo"))
+	})
+	t.Run("invalid encoding", func(t *testing.T) {
+		orig := encoders[encodingO200KBase]
+		encoders[encodingO200KBase] = func() (*bpe, error) { return nil, errors.New("invalid") }
+		t.Cleanup(func() { encoders[encodingO200KBase] = orig })
+		require.Equal(t, approximate, ForModel("gpt-4o"))
+		require.EqualError(t, Load(), "failed to load encoding o200k_base: invalid")
+	})
+	for _, model := range []string{"llama3.3", "Qwen/Qwen3-8B", ""} {
+		t.Run(model, func(t *testing.T) {
+			require.Equal(t, approximate, ForModel(model))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	require.NoError(t, Load())
+}
+
+func Test_loadEncoder(t *testing.T) {
+	t.Run("embedded", func(t *testing.T) {
+		enc, err := loadEncoder(encodingsFS, encodingCL100KBase)
+		require.NoError(t, err)
+		require.NotNil(t, enc)
+	})
+	t.Run("not embedded", func(t *testing.T) {
+		enc, err := loadEncoder(fstest.MapFS{}, encodingCL100KBase)
+		require.NoError(t, err)
+		require.Nil(t, enc)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := loadEncoder(fstest.MapFS{
+			"encodings/cl100k_base.tiktoken": {Data: []byte("not-base64 0\n")},
+		}, encodingCL100KBase)
+		require.Error(t, err)
+	})
+}
+
+func TestForModel_embeddedEncodings(t *testing.T) {
+	// The expected counts are the lengths of the tokens encoded by tiktoken.
+	for _, tc := range []struct {
+		text                string
+		expCL100K, expO200K int
+	}{
+		{text: "hello world", expCL100K: 2, expO200K: 2},
+		{text: "tiktoken is great!", expCL100K: 6, expO200K: 6},
+		{text: "antidisestablishmentarianism", expCL100K: 6, expO200K: 6},
+		{text: "2 + 2 = 4", expCL100K: 7, expO200K: 7},
+		{text: "お誕生日おめでとう", expCL100K: 9, expO200K: 8},
+		{text: "The quick brown fox jumps over the lazy dog.", expCL100K: 10, expO200K: 10},
+		{text: "  leading spaces\n\nand newlines\r\n\ttabs", expCL100K: 10, expO200K: 10},
+		{text: "I'm sure it's THE answer: 12345678, isn't it?", expCL100K: 17, expO200K: 14},
+		{text: "func main() {\n\tfmt.Println(\"Hello, 世界\")\n}\n", expCL100K: 15, expO200K: 12},
+		{text: "Привет, как дела? 😀👍🏽", expCL100K: 15, expO200K: 10},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			require.Equal(t, tc.expCL100K, ForModel("gpt-4").Count(tc.text), "cl100k_base")
+			require.Equal(t, tc.expO200K, ForModel("gpt-4o").Count(tc.text), "o200k_base")
+		})
+	}
+}
+
+func TestApproximateCounter_Count(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		exp  int
+	}{
+		{text: "", exp: 0},
+		{text: "Hello world", exp: 2 + 2},
+		{text: "hi", exp: 1},
+		{text: "こんにちは", exp: 5},
+		{text: "привет", exp: 3},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			require.Equal(t, tc.exp, approximateCounter{}.Count(tc.text))
+		})
+	}
+}
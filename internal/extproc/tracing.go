@@ -0,0 +1,202 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// The attributes of the GenAI semantic conventions: https://opentelemetry.io/docs/specs/semconv/gen-ai/gen-ai-spans/
+const (
+	genAIOperationName         = attribute.Key("gen_ai.operation.name")
+	genAISystem                = attribute.Key("gen_ai.system")
+	genAIRequestModel          = attribute.Key("gen_ai.request.model")
+	genAIResponseID            = attribute.Key("gen_ai.response.id")
+	genAIResponseModel         = attribute.Key("gen_ai.response.model")
+	genAIResponseFinishReasons = attribute.Key("gen_ai.response.finish_reasons")
+	genAIUsageInputTokens      = attribute.Key("gen_ai.usage.input_tokens")
+	genAIUsageOutputTokens     = attribute.Key("gen_ai.usage.output_tokens")
+	genAIPrompt                = attribute.Key("gen_ai.prompt")
+	genAICompletion            = attribute.Key("gen_ai.completion")
+	aigwBackend                = attribute.Key("aigw.backend")
+	aigwBackendModel           = attribute.Key("aigw.backend.model")
+)
+
+// Names of the span events recording the prompts and the completions.
+const (
+	genAIContentPromptEvent     = "gen_ai.content.prompt"
+	genAIContentCompletionEvent = "gen_ai.content.completion"
+)
+
+// maxTracedContentBytes is the maximum size of the completion recorded as a span event.
+const maxTracedContentBytes = 64 << 10
+
+// genAISystemName returns the gen_ai.system attribute value of the backend API schema.
+func genAISystemName(schema filterapi.APISchemaName) string {
+	switch schema {
+	case filterapi.APISchemaOpenAI:
+		return "openai"
+	case filterapi.APISchemaAWSBedrock:
+		return "aws.bedrock"
+	case filterapi.APISchemaAzureOpenAI:
+		return "az.ai.openai"
+	case filterapi.APISchemaAnthropic:
+		return "anthropic"
+	case filterapi.APISchemaGCPVertexAI:
+		return "vertex_ai"
+	default:
+		return string(schema)
+	}
+}
+
+// setRequestSpanAttributes names the span of the request after the operation and the model
+// and sets their attributes as per the GenAI semantic conventions.
+func setRequestSpanAttributes(span trace.Span, operation, model string) {
+	span.SetName(operation + " " + model)
+	span.SetAttributes(genAIOperationName.String(operation), genAIRequestModel.String(model))
+}
+
+// startUpstreamSpan starts the span of the request sent to the upstream, which ends when its response completes.
+// The backend is also recorded on the span of the request, so the last one is kept on failover.
+func startUpstreamSpan(ctx context.Context, config *processorConfig, b *filterapi.Backend, up upstream) (context.Context, trace.Span) {
+	system := genAISystem.String(genAISystemName(b.Schema.Name))
+	trace.SpanFromContext(ctx).SetAttributes(system, aigwBackend.String(up.backend))
+	return config.tracing.Start(ctx, "upstream", trace.SpanKindClient,
+		system, aigwBackend.String(up.backend), aigwBackendModel.String(up.model))
+}
+
+// injectTraceContext adds the headers propagating the span in ctx to the upstream to the header mutation.
+func injectTraceContext(ctx context.Context, config *processorConfig, headerMutation *extprocv3.HeaderMutation) {
+	for k, v := range config.tracing.Inject(ctx) {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: k, RawValue: []byte(v)},
+		})
+	}
+}
+
+// endUpstreamSpan ends the span of the request sent to the upstream, if any, recording the token usage
+// on it and the span of the request.
+func endUpstreamSpan(ctx context.Context, span trace.Span, usage translator.LLMTokenUsage) {
+	if span == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		genAIUsageInputTokens.Int64(int64(usage.InputTokens)),
+		genAIUsageOutputTokens.Int64(int64(usage.OutputTokens)),
+	}
+	span.SetAttributes(attrs...)
+	span.End()
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// recordPrompt records the request body as the prompt event on the span of the request
+// if the content recording is enabled.
+func recordPrompt(ctx context.Context, config *processorConfig, body []byte) {
+	if config.tracing.RecordContent() {
+		trace.SpanFromContext(ctx).AddEvent(genAIContentPromptEvent,
+			trace.WithAttributes(genAIPrompt.String(string(body))))
+	}
+}
+
+// completionTrace accumulates the attributes of the chat completion response recorded on the span of the request.
+type completionTrace struct {
+	id, model     string
+	finishReasons []string
+	content       strings.Builder
+	// pending is the incomplete line of the streaming response carried over to the next chunk.
+	pending []byte
+}
+
+// tracedChatCompletion is the subset of the chat completion response and its chunks recorded on the span.
+type tracedChatCompletion struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		FinishReason string `json:"finish_reason"`
+		Message      struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// observe accumulates the attributes in the body of the response in the OpenAI format,
+// which is a chunk of the server-sent events if stream is true.
+func (t *completionTrace) observe(body []byte, stream bool) {
+	if !stream {
+		t.observeCompletion(body)
+		return
+	}
+	data := append(t.pending, body...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSpace(data[:i])
+		data = data[i+1:]
+		if payload, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			if payload = bytes.TrimSpace(payload); !bytes.Equal(payload, []byte("[DONE]")) {
+				t.observeCompletion(payload)
+			}
+		}
+	}
+	t.pending = append([]byte(nil), data...)
+}
+
+func (t *completionTrace) observeCompletion(body []byte) {
+	var c tracedChatCompletion
+	if err := json.Unmarshal(body, &c); err != nil {
+		return
+	}
+	if c.ID != "" {
+		t.id = c.ID
+	}
+	if c.Model != "" {
+		t.model = c.Model
+	}
+	for i := range c.Choices {
+		choice := &c.Choices[i]
+		if r := choice.FinishReason; r != "" && !slices.Contains(t.finishReasons, r) {
+			t.finishReasons = append(t.finishReasons, r)
+		}
+		for _, s := range []string{choice.Message.Content, choice.Delta.Content} {
+			if room := maxTracedContentBytes - t.content.Len(); room > 0 {
+				t.content.WriteString(s[:min(len(s), room)])
+			}
+		}
+	}
+}
+
+// record records the accumulated attributes on the span, and the completion event if recordContent is true.
+func (t *completionTrace) record(span trace.Span, recordContent bool) {
+	if t.id != "" {
+		span.SetAttributes(genAIResponseID.String(t.id))
+	}
+	if t.model != "" {
+		span.SetAttributes(genAIResponseModel.String(t.model))
+	}
+	if len(t.finishReasons) > 0 {
+		span.SetAttributes(genAIResponseFinishReasons.StringSlice(t.finishReasons))
+	}
+	if recordContent {
+		span.AddEvent(genAIContentCompletionEvent, trace.WithAttributes(genAICompletion.String(t.content.String())))
+	}
+}
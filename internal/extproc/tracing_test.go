@@ -0,0 +1,202 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/tracing"
+)
+
+func newTestTracing(recordContent bool) (*tracing.Tracing, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return tracing.NewFromTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recordContent), recorder
+}
+
+// spanAttributes returns the attributes of the span as a map.
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	ret := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		ret[kv.Key] = kv.Value
+	}
+	return ret
+}
+
+func TestChatCompletion_tracing(t *testing.T) {
+	const body = `{"model":"some-model","messages":[{"role":"user","content":"hello"}]}`
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+	tr, recorder := newTestTracing(true)
+
+	headers := map[string]string{":path": "/v1/chat/completions"}
+	p := &chatCompletionProcessor{
+		config: &processorConfig{
+			modelNameHeaderKey:       "x-model-name",
+			selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+			router:                   mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"},
+			tracing:                  tr,
+		},
+		requestHeaders:  headers,
+		responseHeaders: map[string]string{":status": "200"},
+		logger:          slog.Default(),
+		metrics:         &mockChatCompletionMetrics{},
+		translator: &mockTranslator{
+			t: t, expRequestBody: &expBody, retUsedToken: translator.LLMTokenUsage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5},
+		},
+	}
+	ctx, span := tr.Start(t.Context(), "POST /v1/chat/completions", trace.SpanKindServer)
+	res, err := p.ProcessRequestBody(ctx, &extprocv3.HttpBody{Body: []byte(body)})
+	require.NoError(t, err)
+	var traceparent string
+	for _, h := range res.GetRequestBody().GetResponse().GetHeaderMutation().GetSetHeaders() {
+		if h.Header.Key == "traceparent" {
+			traceparent = string(h.Header.RawValue)
+		}
+	}
+	require.Equal(t, "00-"+span.SpanContext().TraceID().String()+"-"+p.upstreamSpan.SpanContext().SpanID().String()+"-01", traceparent)
+
+	_, err = p.ProcessResponseBody(ctx, &extprocv3.HttpBody{
+		Body:        []byte(`{"id":"chatcmpl-1","model":"some-model-2025","choices":[{"message":{"content":"hi"},"finish_reason":"stop"}]}`),
+		EndOfStream: true,
+	})
+	require.NoError(t, err)
+	span.End()
+
+	spans := recorder.Ended()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name()
+	}
+	require.Equal(t, []string{"route", "translate request", "upstream", "chat some-model"}, names)
+	for _, s := range spans[:3] {
+		require.Equal(t, span.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+	require.Equal(t, "some-backend", spanAttributes(spans[0])[aigwBackend].AsString())
+	require.Equal(t, trace.SpanKindClient, spans[2].SpanKind())
+	require.Equal(t, int64(3), spanAttributes(spans[2])[genAIUsageInputTokens].AsInt64())
+
+	attrs := spanAttributes(spans[3])
+	require.Equal(t, "chat", attrs[genAIOperationName].AsString())
+	require.Equal(t, "some-model", attrs[genAIRequestModel].AsString())
+	require.Equal(t, "some-backend", attrs[aigwBackend].AsString())
+	require.Equal(t, "chatcmpl-1", attrs[genAIResponseID].AsString())
+	require.Equal(t, "some-model-2025", attrs[genAIResponseModel].AsString())
+	require.Equal(t, []string{"stop"}, attrs[genAIResponseFinishReasons].AsStringSlice())
+	require.Equal(t, int64(3), attrs[genAIUsageInputTokens].AsInt64())
+	require.Equal(t, int64(2), attrs[genAIUsageOutputTokens].AsInt64())
+	events := spans[3].Events()
+	require.Len(t, events, 2)
+	require.Equal(t, genAIContentPromptEvent, events[0].Name)
+	require.Equal(t, []attribute.KeyValue{genAIPrompt.String(body)}, events[0].Attributes)
+	require.Equal(t, genAIContentCompletionEvent, events[1].Name)
+	require.Equal(t, []attribute.KeyValue{genAICompletion.String("hi")}, events[1].Attributes)
+}
+
+func TestEmbeddings_tracing_routeError(t *testing.T) {
+	tr, recorder := newTestTracing(false)
+	headers := map[string]string{":path": "/v1/embeddings"}
+	p := &embeddingsProcessor{
+		config: &processorConfig{
+			modelNameHeaderKey: "x-model-name",
+			router:             mockRouter{t: t, expHeaders: headers, retErr: errors.New("no route")},
+			tracing:            tr,
+		},
+		requestHeaders: headers,
+		logger:         slog.Default(),
+		metrics:        &mockEmbeddingsMetrics{},
+	}
+	ctx, span := tr.Start(t.Context(), "POST /v1/embeddings", trace.SpanKindServer)
+	_, err := p.ProcessRequestBody(ctx, &extprocv3.HttpBody{Body: []byte(`{"model":"some-model","input":"hello"}`)})
+	require.ErrorContains(t, err, "no route")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "route", spans[0].Name())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, "embeddings some-model", spans[1].Name())
+	// Nothing is recorded when the content recording is disabled.
+	require.Empty(t, spans[1].Events())
+}
+
+func TestCompletionTrace_observe(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		var c completionTrace
+		c.observe([]byte(`{"id":"1","model":"m","choices":[{"message":{"content":"a"},"finish_reason":"stop"},`+
+			`{"message":{"content":"b"},"finish_reason":"length"}]}`), false)
+		require.Equal(t, "1", c.id)
+		require.Equal(t, "m", c.model)
+		require.Equal(t, []string{"stop", "length"}, c.finishReasons)
+		require.Equal(t, "ab", c.content.String())
+	})
+	t.Run("streaming", func(t *testing.T) {
+		var c completionTrace
+		// The events are split across the chunks.
+		c.observe([]byte("data: {\"id\":\"1\",\"model\":\"m\",\"choices\":[{\"delta\":{\"content\":\"hel\"}}]}\n\ndata: {\"choices\":[{\"del"), true)
+		c.observe([]byte("ta\":{\"content\":\"lo\"}}]}\n\ndata: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n"), true)
+		c.observe([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1}}\n\ndata: [DONE]\n\n"), true)
+		require.Equal(t, "1", c.id)
+		require.Equal(t, "m", c.model)
+		require.Equal(t, []string{"stop"}, c.finishReasons)
+		require.Equal(t, "hello", c.content.String())
+		require.Empty(t, c.pending)
+	})
+	t.Run("content limit", func(t *testing.T) {
+		var c completionTrace
+		long := make([]byte, maxTracedContentBytes+10)
+		for i := range long {
+			long[i] = 'a'
+		}
+		c.observe([]byte(`{"choices":[{"message":{"content":"`+string(long)+`"}}]}`), false)
+		c.observe([]byte(`{"choices":[{"message":{"content":"more"}}]}`), false)
+		require.Equal(t, maxTracedContentBytes, c.content.Len())
+	})
+}
+
+func TestGenAISystemName(t *testing.T) {
+	for schema, exp := range map[filterapi.APISchemaName]string{
+		filterapi.APISchemaOpenAI:      "openai",
+		filterapi.APISchemaAWSBedrock:  "aws.bedrock",
+		filterapi.APISchemaAzureOpenAI: "az.ai.openai",
+		filterapi.APISchemaAnthropic:   "anthropic",
+		filterapi.APISchemaGCPVertexAI: "vertex_ai",
+		"Custom":                       "Custom",
+	} {
+		require.Equal(t, exp, genAISystemName(schema))
+	}
+}
+
+func TestSetResponseStatusSpanAttributes(t *testing.T) {
+	tr, recorder := newTestTracing(false)
+	for _, status := range []string{"200", "503", "invalid"} {
+		_, span := tr.Start(t.Context(), status, trace.SpanKindServer)
+		setResponseStatusSpanAttributes(span, status)
+		span.End()
+	}
+	// The nil span is ignored.
+	setResponseStatusSpanAttributes(nil, "200")
+
+	spans := recorder.Ended()
+	require.Equal(t, []attribute.KeyValue{attribute.Int("http.response.status_code", 200)}, spans[0].Attributes())
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Equal(t, []attribute.KeyValue{attribute.Int("http.response.status_code", 503)}, spans[1].Attributes())
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+	require.Empty(t, spans[2].Attributes())
+}
@@ -0,0 +1,532 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/anthropic"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+const (
+	// anthropicDefaultVersion is the value of the anthropic-version header used when the schema version is not specified.
+	anthropicDefaultVersion = "2023-06-01"
+	// anthropicDefaultMaxTokens is used when the client does not specify max_tokens, which is required by Anthropic.
+	anthropicDefaultMaxTokens = 4096
+)
+
+// NewChatCompletionOpenAIToAnthropicTranslator implements [Factory] for OpenAI to Anthropic Messages API translation.
+// The apiVersion is sent as the anthropic-version header, and defaults to anthropicDefaultVersion if empty.
+func NewChatCompletionOpenAIToAnthropicTranslator(apiVersion string) OpenAIChatCompletionTranslator {
+	if apiVersion == "" {
+		apiVersion = anthropicDefaultVersion
+	}
+	return &openAIToAnthropicTranslatorV1ChatCompletion{apiVersion: apiVersion}
+}
+
+// openAIToAnthropicTranslatorV1ChatCompletion implements [OpenAIChatCompletionTranslator] for /v1/chat/completions.
+type openAIToAnthropicTranslatorV1ChatCompletion struct {
+	apiVersion string
+	stream     bool
+	buffered   []byte
+	// usage is accumulated from the message_start and message_delta events in streaming responses.
+	usage anthropic.Usage
+	// toolCallIndexes maps the content block index of a tool_use block to the index of the OpenAI tool call.
+	toolCallIndexes map[int]int
+}
+
+// RequestBody implements [OpenAIChatCompletionTranslator.RequestBody].
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) RequestBody(openAIReq *openai.ChatCompletionRequest) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.stream = openAIReq.Stream
+	req := anthropic.MessagesRequest{
+		Model:       openAIReq.Model,
+		MaxTokens:   anthropicDefaultMaxTokens,
+		Stream:      openAIReq.Stream,
+		Temperature: openAIReq.Temperature,
+		TopP:        openAIReq.TopP,
+	}
+	if openAIReq.MaxTokens != nil {
+		req.MaxTokens = *openAIReq.MaxTokens
+	}
+	for _, s := range openAIReq.Stop {
+		if s != nil {
+			req.StopSequences = append(req.StopSequences, *s)
+		}
+	}
+	if openAIReq.User != "" {
+		req.Metadata = &anthropic.Metadata{UserID: openAIReq.User}
+	}
+	if err = o.openAIMessagesToAnthropicMessages(openAIReq, &req); err != nil {
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError, err)
+	}
+	if err = o.openAIToolsToAnthropicTools(openAIReq, &req); err != nil {
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError, err)
+	}
+
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte("/v1/messages")}},
+			{Header: &corev3.HeaderValue{Key: "anthropic-version", RawValue: []byte(o.apiVersion)}},
+		},
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(req); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+// openAIMessagesToAnthropicMessages converts the OpenAI messages to the Anthropic messages and the system prompt.
+// Consecutive messages of the same role are merged since Anthropic requires the roles to alternate.
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) openAIMessagesToAnthropicMessages(openAIReq *openai.ChatCompletionRequest,
+	req *anthropic.MessagesRequest,
+) error {
+	req.Messages = make([]anthropic.Message, 0, len(openAIReq.Messages))
+	appendMessage := func(role string, content []anthropic.ContentBlock) {
+		if l := len(req.Messages); l > 0 && req.Messages[l-1].Role == role {
+			req.Messages[l-1].Content = append(req.Messages[l-1].Content, content...)
+			return
+		}
+		req.Messages = append(req.Messages, anthropic.Message{Role: role, Content: content})
+	}
+	for i := range openAIReq.Messages {
+		msg := &openAIReq.Messages[i]
+		switch msg.Type {
+		case openai.ChatMessageRoleSystem:
+			m := msg.Value.(openai.ChatCompletionSystemMessageParam)
+			blocks, err := stringOrArrayToAnthropicTextBlocks(&m.Content)
+			if err != nil {
+				return fmt.Errorf("unexpected content type for system message: %w", err)
+			}
+			req.System = append(req.System, blocks...)
+		case openai.ChatMessageRoleDeveloper:
+			m := msg.Value.(openai.ChatCompletionDeveloperMessageParam)
+			blocks, err := stringOrArrayToAnthropicTextBlocks(&m.Content)
+			if err != nil {
+				return fmt.Errorf("unexpected content type for developer message: %w", err)
+			}
+			req.System = append(req.System, blocks...)
+		case openai.ChatMessageRoleUser:
+			m := msg.Value.(openai.ChatCompletionUserMessageParam)
+			blocks, err := o.openAIUserContentToAnthropicBlocks(&m)
+			if err != nil {
+				return err
+			}
+			appendMessage(anthropic.MessageRoleUser, blocks)
+		case openai.ChatMessageRoleAssistant:
+			m := msg.Value.(openai.ChatCompletionAssistantMessageParam)
+			var blocks []anthropic.ContentBlock
+			if v, ok := m.Content.Value.(string); ok && len(v) > 0 {
+				blocks = append(blocks, anthropic.ContentBlock{Type: anthropic.ContentBlockTypeText, Text: ptr.To(v)})
+			} else if content, ok := m.Content.Value.(openai.ChatCompletionAssistantMessageParamContent); ok {
+				if content.Type == openai.ChatCompletionAssistantMessageParamContentTypeRefusal {
+					blocks = append(blocks, anthropic.ContentBlock{Type: anthropic.ContentBlockTypeText, Text: content.Refusal})
+				} else if content.Text != nil {
+					blocks = append(blocks, anthropic.ContentBlock{Type: anthropic.ContentBlockTypeText, Text: content.Text})
+				}
+			}
+			for j := range m.ToolCalls {
+				toolCall := &m.ToolCalls[j]
+				input, err := unmarshalToolCallArguments(toolCall.Function.Arguments)
+				if err != nil {
+					return err
+				}
+				blocks = append(blocks, anthropic.ContentBlock{
+					Type:  anthropic.ContentBlockTypeToolUse,
+					ID:    toolCall.ID,
+					Name:  toolCall.Function.Name,
+					Input: input,
+				})
+			}
+			appendMessage(anthropic.MessageRoleAssistant, blocks)
+		case openai.ChatMessageRoleTool:
+			m := msg.Value.(openai.ChatCompletionToolMessageParam)
+			content, err := stringOrArrayToAnthropicTextBlocks(&m.Content)
+			if err != nil {
+				return fmt.Errorf("unexpected content type for tool message: %w", err)
+			}
+			// Anthropic does not have the tool role, and the tool results are sent as the user message.
+			appendMessage(anthropic.MessageRoleUser, []anthropic.ContentBlock{{
+				Type:      anthropic.ContentBlockTypeToolResult,
+				ToolUseID: m.ToolCallID,
+				Content:   content,
+			}})
+		default:
+			return fmt.Errorf("unexpected role: %s", msg.Type)
+		}
+	}
+	return nil
+}
+
+// stringOrArrayToAnthropicTextBlocks converts the content of system, developer and tool messages to text blocks.
+func stringOrArrayToAnthropicTextBlocks(content *openai.StringOrArray) ([]anthropic.ContentBlock, error) {
+	switch v := content.Value.(type) {
+	case string:
+		return []anthropic.ContentBlock{{Type: anthropic.ContentBlockTypeText, Text: ptr.To(v)}}, nil
+	case []openai.ChatCompletionContentPartTextParam:
+		blocks := make([]anthropic.ContentBlock, 0, len(v))
+		for i := range v {
+			blocks = append(blocks, anthropic.ContentBlock{Type: anthropic.ContentBlockTypeText, Text: ptr.To(v[i].Text)})
+		}
+		return blocks, nil
+	default:
+		return nil, fmt.Errorf("%T", content.Value)
+	}
+}
+
+// openAIUserContentToAnthropicBlocks converts the content of the user message to the Anthropic content blocks.
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) openAIUserContentToAnthropicBlocks(
+	m *openai.ChatCompletionUserMessageParam,
+) ([]anthropic.ContentBlock, error) {
+	if v, ok := m.Content.Value.(string); ok {
+		return []anthropic.ContentBlock{{Type: anthropic.ContentBlockTypeText, Text: ptr.To(v)}}, nil
+	}
+	contents, ok := m.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+	if !ok {
+		return nil, fmt.Errorf("unexpected content type")
+	}
+	blocks := make([]anthropic.ContentBlock, 0, len(contents))
+	for i := range contents {
+		contentPart := &contents[i]
+		switch {
+		case contentPart.TextContent != nil:
+			blocks = append(blocks, anthropic.ContentBlock{Type: anthropic.ContentBlockTypeText, Text: ptr.To(contentPart.TextContent.Text)})
+		case contentPart.ImageContent != nil:
+			url := contentPart.ImageContent.ImageURL.URL
+			source := &anthropic.ImageSource{Type: anthropic.ImageSourceTypeURL, URL: url}
+			if strings.HasPrefix(url, "data:") {
+				contentType, b, err := parseDataURI(url)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse image URL: %s %w", url, err)
+				}
+				switch contentType {
+				case "image/png", "image/jpeg", "image/gif", "image/webp":
+				default:
+					return nil, fmt.Errorf("unsupported image type: %s please use one of [png, jpeg, gif, webp]", contentType)
+				}
+				source = &anthropic.ImageSource{
+					Type:      anthropic.ImageSourceTypeBase64,
+					MediaType: contentType,
+					Data:      base64.StdEncoding.EncodeToString(b),
+				}
+			}
+			blocks = append(blocks, anthropic.ContentBlock{Type: anthropic.ContentBlockTypeImage, Source: source})
+		}
+	}
+	return blocks, nil
+}
+
+// openAIToolsToAnthropicTools converts the OpenAI tools and tool_choice to the Anthropic ones.
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) openAIToolsToAnthropicTools(openAIReq *openai.ChatCompletionRequest,
+	req *anthropic.MessagesRequest,
+) error {
+	for i := range openAIReq.Tools {
+		tool := &openAIReq.Tools[i]
+		if tool.Function == nil {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if schema == nil {
+			// Anthropic requires the input_schema, so we default to the empty object schema.
+			schema = map[string]any{"type": "object"}
+		}
+		req.Tools = append(req.Tools, anthropic.Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	switch toolChoice := openAIReq.ToolChoice.(type) {
+	case nil:
+	case string:
+		switch toolChoice {
+		case "auto":
+			req.ToolChoice = &anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeAuto}
+		case "required":
+			req.ToolChoice = &anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeAny}
+		case "none":
+			req.ToolChoice = &anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeNone}
+		default:
+			return fmt.Errorf("unexpected tool_choice: %s", toolChoice)
+		}
+	case openai.ToolChoice:
+		req.ToolChoice = &anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeTool, Name: toolChoice.Function.Name}
+	case map[string]any:
+		// This is the case when the tool_choice is unmarshalled from the JSON request body.
+		function, _ := toolChoice["function"].(map[string]any)
+		name, _ := function["name"].(string)
+		if name == "" {
+			return fmt.Errorf("tool_choice.function.name is required")
+		}
+		req.ToolChoice = &anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeTool, Name: name}
+	default:
+		return fmt.Errorf("unexpected type: %T", openAIReq.ToolChoice)
+	}
+	if req.ToolChoice != nil && len(req.Tools) > 0 && !openAIReq.ParallelToolCalls {
+		// OpenAI enables parallel tool calls by default, but the zero value is indistinguishable from false
+		// here, so we only disable it when the client explicitly forces the tool usage.
+		if req.ToolChoice.Type == anthropic.ToolChoiceTypeTool {
+			req.ToolChoice.DisableParallelToolUse = ptr.To(true)
+		}
+	}
+	return nil
+}
+
+// ResponseHeaders implements [OpenAIChatCompletionTranslator.ResponseHeaders].
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) ResponseHeaders(map[string]string) (
+	headerMutation *extprocv3.HeaderMutation, err error,
+) {
+	return nil, nil
+}
+
+// ResponseError implements [OpenAIChatCompletionTranslator.ResponseError].
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	var anthropicError anthropic.ErrorResponse
+	if strings.HasPrefix(respHeaders[contentTypeHeaderName], jsonContentType) && json.Unmarshal(buf, &anthropicError) == nil {
+		return backendErrorResponse(respHeaders, anthropicError.Error.Type, anthropicError.Error.Message)
+	}
+	return backendErrorResponse(respHeaders, "", string(buf))
+}
+
+// ResponseBody implements [OpenAIChatCompletionTranslator.ResponseBody].
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if statusStr, ok := respHeaders[statusHeaderName]; ok {
+		var status int
+		if status, err = strconv.Atoi(statusStr); err == nil {
+			if !isGoodStatusCode(status) {
+				headerMutation, bodyMutation, err = o.ResponseError(respHeaders, body)
+				return headerMutation, bodyMutation, LLMTokenUsage{}, err
+			}
+		}
+	}
+	if o.stream {
+		return o.streamResponseBody(body)
+	}
+
+	var resp anthropic.MessagesResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage = LLMTokenUsage{
+		InputTokens:  uint32(resp.Usage.InputTokens),                           //nolint:gosec
+		OutputTokens: uint32(resp.Usage.OutputTokens),                          //nolint:gosec
+		TotalTokens:  uint32(resp.Usage.InputTokens + resp.Usage.OutputTokens), //nolint:gosec
+	}
+	choice := openai.ChatCompletionResponseChoice{
+		Index:        0,
+		Message:      openai.ChatCompletionResponseChoiceMessage{Role: openai.ChatMessageRoleAssistant},
+		FinishReason: anthropicStopReasonToOpenAIStopReason(resp.StopReason),
+	}
+	var text strings.Builder
+	for i := range resp.Content {
+		block := &resp.Content[i]
+		switch block.Type {
+		case anthropic.ContentBlockTypeText:
+			if block.Text != nil {
+				text.WriteString(*block.Text)
+			}
+		case anthropic.ContentBlockTypeToolUse:
+			arguments, err := json.Marshal(block.Input)
+			if err != nil {
+				return nil, nil, tokenUsage, fmt.Errorf("failed to marshal tool input: %w", err)
+			}
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+				ID:       block.ID,
+				Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: block.Name, Arguments: string(arguments)},
+				Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+			})
+		}
+	}
+	if text.Len() > 0 {
+		choice.Message.Content = ptr.To(text.String())
+	}
+	openAIResp := openai.ChatCompletionResponse{
+		Object:  "chat.completion",
+		Choices: []openai.ChatCompletionResponseChoice{choice},
+		Usage: openai.ChatCompletionResponseUsage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(openAIResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// streamResponseBody converts the buffered server-sent events of the Messages API to the OpenAI chat completion chunks.
+// The token usage is returned only once when the message_stop event is received.
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) streamResponseBody(body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+	}
+	o.buffered = append(o.buffered, buf...)
+
+	mut := &extprocv3.BodyMutation_Body{}
+	for {
+		i := bytes.Index(o.buffered, []byte("\n\n"))
+		if i == -1 {
+			break
+		}
+		rawEvent := o.buffered[:i]
+		o.buffered = o.buffered[i+2:]
+
+		var event anthropic.StreamEvent
+		for _, line := range bytes.Split(rawEvent, []byte("\n")) {
+			if bytes.HasPrefix(line, dataPrefix) {
+				if err := json.Unmarshal(bytes.TrimPrefix(line, dataPrefix), &event); err != nil {
+					return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal event: %w", err)
+				}
+			}
+		}
+		chunk, ok := o.convertEvent(&event)
+		if ok {
+			chunkBytes, err := json.Marshal(chunk)
+			if err != nil {
+				return nil, nil, tokenUsage, fmt.Errorf("failed to marshal chunk: %w", err)
+			}
+			mut.Body = append(mut.Body, dataPrefix...)
+			mut.Body = append(mut.Body, chunkBytes...)
+			mut.Body = append(mut.Body, []byte("\n\n")...)
+		}
+		if event.Type == anthropic.StreamEventTypeMessageStop {
+			tokenUsage = LLMTokenUsage{
+				InputTokens:  uint32(o.usage.InputTokens),                        //nolint:gosec
+				OutputTokens: uint32(o.usage.OutputTokens),                       //nolint:gosec
+				TotalTokens:  uint32(o.usage.InputTokens + o.usage.OutputTokens), //nolint:gosec
+			}
+			mut.Body = append(mut.Body, []byte("data: [DONE]\n\n")...)
+		}
+	}
+	return &extprocv3.HeaderMutation{}, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// convertEvent converts an [anthropic.StreamEvent] to an [openai.ChatCompletionResponseChunk].
+// This returns false if the event does not need to be sent to the client.
+func (o *openAIToAnthropicTranslatorV1ChatCompletion) convertEvent(event *anthropic.StreamEvent) (openai.ChatCompletionResponseChunk, bool) {
+	const object = "chat.completion.chunk"
+	chunk := openai.ChatCompletionResponseChunk{Object: object}
+	switch event.Type {
+	case anthropic.StreamEventTypeMessageStart:
+		if event.Message != nil {
+			o.usage.InputTokens = event.Message.Usage.InputTokens
+			o.usage.OutputTokens = event.Message.Usage.OutputTokens
+		}
+		chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
+			Delta: &openai.ChatCompletionResponseChunkChoiceDelta{Role: openai.ChatMessageRoleAssistant, Content: ptr.To(emptyString)},
+		})
+	case anthropic.StreamEventTypeContentBlockStart:
+		if event.ContentBlock == nil || event.ContentBlock.Type != anthropic.ContentBlockTypeToolUse {
+			return chunk, false
+		}
+		if o.toolCallIndexes == nil {
+			o.toolCallIndexes = make(map[int]int)
+		}
+		toolCallIndex := len(o.toolCallIndexes)
+		o.toolCallIndexes[event.Index] = toolCallIndex
+		chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
+			Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
+				Role: openai.ChatMessageRoleAssistant,
+				ToolCalls: []openai.ChatCompletionMessageToolCallParam{{
+					Index:    ptr.To(toolCallIndex),
+					ID:       event.ContentBlock.ID,
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: event.ContentBlock.Name},
+					Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+				}},
+			},
+		})
+	case anthropic.StreamEventTypeContentBlockDelta:
+		if event.Delta == nil {
+			return chunk, false
+		}
+		switch event.Delta.Type {
+		case anthropic.DeltaTypeText:
+			chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
+				Delta: &openai.ChatCompletionResponseChunkChoiceDelta{Role: openai.ChatMessageRoleAssistant, Content: ptr.To(event.Delta.Text)},
+			})
+		case anthropic.DeltaTypeInputJSON:
+			chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
+				Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
+					Role: openai.ChatMessageRoleAssistant,
+					ToolCalls: []openai.ChatCompletionMessageToolCallParam{{
+						Index:    ptr.To(o.toolCallIndexes[event.Index]),
+						Function: openai.ChatCompletionMessageToolCallFunctionParam{Arguments: event.Delta.PartialJSON},
+						Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+					}},
+				},
+			})
+		default:
+			return chunk, false
+		}
+	case anthropic.StreamEventTypeMessageDelta:
+		if event.Usage != nil {
+			// The usage in the message_delta event is cumulative.
+			o.usage.OutputTokens = event.Usage.OutputTokens
+		}
+		if event.Delta == nil || event.Delta.StopReason == nil {
+			return chunk, false
+		}
+		chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
+			Delta:        &openai.ChatCompletionResponseChunkChoiceDelta{Role: openai.ChatMessageRoleAssistant, Content: ptr.To(emptyString)},
+			FinishReason: anthropicStopReasonToOpenAIStopReason(event.Delta.StopReason),
+		})
+	case anthropic.StreamEventTypeMessageStop:
+		chunk.Usage = &openai.ChatCompletionResponseUsage{
+			PromptTokens:     o.usage.InputTokens,
+			CompletionTokens: o.usage.OutputTokens,
+			TotalTokens:      o.usage.InputTokens + o.usage.OutputTokens,
+		}
+	default:
+		return chunk, false
+	}
+	return chunk, true
+}
+
+// anthropicStopReasonToOpenAIStopReason converts the Anthropic stop reason to the OpenAI finish reason.
+func anthropicStopReasonToOpenAIStopReason(stopReason *string) openai.ChatCompletionChoicesFinishReason {
+	if stopReason == nil {
+		return openai.ChatCompletionChoicesFinishReasonStop
+	}
+	switch *stopReason {
+	case anthropic.StopReasonMaxTokens:
+		return openai.ChatCompletionChoicesFinishReasonLength
+	case anthropic.StopReasonToolUse:
+		return openai.ChatCompletionChoicesFinishReasonToolCalls
+	default:
+		return openai.ChatCompletionChoicesFinishReasonStop
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToAnthropicTranslatorV1ChatCompletion_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		apiVersion string
+		input      string
+		expVersion string
+		expBody    string
+	}{
+		{
+			name:       "system, user and defaults",
+			input:      `{"model":"claude-3-5-sonnet","messages":[{"role":"system","content":"be nice"},{"role":"developer","content":"be concise"},{"role":"user","content":"hi"}]}`,
+			expVersion: "2023-06-01",
+			expBody: `{"model":"claude-3-5-sonnet","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}],` +
+				`"system":[{"type":"text","text":"be nice"},{"type":"text","text":"be concise"}],"max_tokens":4096}`,
+		},
+		{
+			name:       "parameters",
+			apiVersion: "2024-01-01",
+			input: `{"model":"claude","max_tokens":10,"temperature":0.5,"top_p":0.9,"stop":["a","b"],"stream":true,"user":"u",` +
+				`"messages":[{"role":"user","content":"hi"}]}`,
+			expVersion: "2024-01-01",
+			expBody: `{"model":"claude","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}],"max_tokens":10,` +
+				`"stop_sequences":["a","b"],"stream":true,"temperature":0.5,"top_p":0.9,"metadata":{"user_id":"u"}}`,
+		},
+		{
+			name: "images",
+			input: `{"model":"claude","messages":[{"role":"user","content":[{"type":"text","text":"what?"},` +
+				`{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}},` +
+				`{"type":"image_url","image_url":{"url":"https://example.com/a.jpg"}}]}]}`,
+			expVersion: "2023-06-01",
+			expBody: `{"model":"claude","messages":[{"role":"user","content":[{"type":"text","text":"what?"},` +
+				`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"aGVsbG8="}},` +
+				`{"type":"image","source":{"type":"url","url":"https://example.com/a.jpg"}}]}],"max_tokens":4096}`,
+		},
+		{
+			name: "tools",
+			input: `{"model":"claude","messages":[{"role":"user","content":"weather?"},` +
+				`{"role":"assistant","content":"let me check","tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}},` +
+				`{"id":"call_2","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Tokyo\"}"}}]},` +
+				`{"role":"tool","tool_call_id":"call_1","content":"sunny"},{"role":"tool","tool_call_id":"call_2","content":"rainy"}],` +
+				`"tools":[{"type":"function","function":{"name":"weather","description":"get weather","parameters":{"type":"object"}}}],"tool_choice":"required"}`,
+			expVersion: "2023-06-01",
+			expBody: `{"model":"claude","messages":[{"role":"user","content":[{"type":"text","text":"weather?"}]},` +
+				`{"role":"assistant","content":[{"type":"text","text":"let me check"},{"type":"tool_use","id":"call_1","name":"weather","input":{"city":"Paris"}},` +
+				`{"type":"tool_use","id":"call_2","name":"weather","input":{"city":"Tokyo"}}]},` +
+				`{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_1","content":[{"type":"text","text":"sunny"}]},` +
+				`{"type":"tool_result","tool_use_id":"call_2","content":[{"type":"text","text":"rainy"}]}]}],"max_tokens":4096,` +
+				`"tools":[{"name":"weather","description":"get weather","input_schema":{"type":"object"}}],"tool_choice":{"type":"any"}}`,
+		},
+		{
+			name: "function tool choice",
+			input: `{"model":"claude","messages":[{"role":"user","content":"weather?"}],` +
+				`"tools":[{"type":"function","function":{"name":"weather"}}],"tool_choice":{"type":"function","function":{"name":"weather"}}}`,
+			expVersion: "2023-06-01",
+			expBody: `{"model":"claude","messages":[{"role":"user","content":[{"type":"text","text":"weather?"}]}],"max_tokens":4096,` +
+				`"tools":[{"name":"weather","input_schema":{"type":"object"}}],"tool_choice":{"type":"tool","name":"weather","disable_parallel_tool_use":true}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.input), &req))
+			o := NewChatCompletionOpenAIToAnthropicTranslator(tc.apiVersion).(*openAIToAnthropicTranslatorV1ChatCompletion)
+			hm, bm, err := o.RequestBody(&req)
+			require.NoError(t, err)
+			require.Equal(t, req.Stream, o.stream)
+
+			require.Len(t, hm.SetHeaders, 3)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, "/v1/messages", string(hm.SetHeaders[0].Header.RawValue))
+			require.Equal(t, "anthropic-version", hm.SetHeaders[1].Header.Key)
+			require.Equal(t, tc.expVersion, string(hm.SetHeaders[1].Header.RawValue))
+			require.Equal(t, "content-length", hm.SetHeaders[2].Header.Key)
+
+			body := bm.Mutation.(*extprocv3.BodyMutation_Body).Body
+			require.JSONEq(t, tc.expBody, string(body))
+		})
+	}
+
+	t.Run("unsupported image type", func(t *testing.T) {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(`{"model":"claude","messages":[{"role":"user","content":[`+
+			`{"type":"image_url","image_url":{"url":"data:image/tiff;base64,aGVsbG8="}}]}]}`), &req))
+		_, _, err := NewChatCompletionOpenAIToAnthropicTranslator("").RequestBody(&req)
+		require.ErrorContains(t, err, "unsupported image type: image/tiff")
+	})
+}
+
+func TestOpenAIToAnthropicTranslatorV1ChatCompletion_ResponseBody(t *testing.T) {
+	o := NewChatCompletionOpenAIToAnthropicTranslator("")
+	body := `{"id":"msg_1","type":"message","role":"assistant","model":"claude","stop_reason":"tool_use",` +
+		`"content":[{"type":"text","text":"let me check"},{"type":"tool_use","id":"toolu_1","name":"weather","input":{"city":"Paris"}}],` +
+		`"usage":{"input_tokens":10,"output_tokens":5}}`
+	hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(body), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}, usage)
+	require.Len(t, hm.SetHeaders, 1)
+	require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+
+	var resp openai.ChatCompletionResponse
+	require.NoError(t, json.Unmarshal(bm.Mutation.(*extprocv3.BodyMutation_Body).Body, &resp))
+	require.Len(t, resp.Choices, 1)
+	require.Equal(t, openai.ChatCompletionChoicesFinishReasonToolCalls, resp.Choices[0].FinishReason)
+	require.Equal(t, "let me check", *resp.Choices[0].Message.Content)
+	require.Len(t, resp.Choices[0].Message.ToolCalls, 1)
+	require.Equal(t, "toolu_1", resp.Choices[0].Message.ToolCalls[0].ID)
+	require.Equal(t, "weather", resp.Choices[0].Message.ToolCalls[0].Function.Name)
+	require.JSONEq(t, `{"city":"Paris"}`, resp.Choices[0].Message.ToolCalls[0].Function.Arguments)
+	require.Equal(t, 15, resp.Usage.TotalTokens)
+}
+
+func TestOpenAIToAnthropicTranslatorV1ChatCompletion_Streaming_ResponseBody(t *testing.T) {
+	o := NewChatCompletionOpenAIToAnthropicTranslator("")
+	_, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "claude", Stream: true})
+	require.NoError(t, err)
+
+	events := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude","usage":{"input_tokens":10,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":15}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	var results []byte
+	var usage LLMTokenUsage
+	// Feed the events in small pieces to make sure the partial events are buffered.
+	for i := 0; i < len(events); i += 7 {
+		end := min(i+7, len(events))
+		_, bm, u, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(events[i:end]), end == len(events))
+		require.NoError(t, err)
+		results = append(results, bm.Mutation.(*extprocv3.BodyMutation_Body).Body...)
+		if u.TotalTokens > 0 {
+			usage = u
+		}
+	}
+	require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 15, TotalTokens: 25}, usage)
+
+	var chunks []openai.ChatCompletionResponseChunk
+	var done bool
+	for _, line := range bytes.Split(results, []byte("\n\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		data := bytes.TrimPrefix(line, []byte("data: "))
+		if string(data) == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk openai.ChatCompletionResponseChunk
+		require.NoError(t, json.Unmarshal(data, &chunk))
+		chunks = append(chunks, chunk)
+	}
+	require.True(t, done)
+	require.Len(t, chunks, 7)
+	require.Equal(t, openai.ChatMessageRoleAssistant, chunks[0].Choices[0].Delta.Role)
+	require.Equal(t, "Hello", *chunks[1].Choices[0].Delta.Content)
+	require.Equal(t, "toolu_1", chunks[2].Choices[0].Delta.ToolCalls[0].ID)
+	require.Equal(t, 0, *chunks[2].Choices[0].Delta.ToolCalls[0].Index)
+	require.Equal(t, "weather", chunks[2].Choices[0].Delta.ToolCalls[0].Function.Name)
+	require.Equal(t, `{"city":`, chunks[3].Choices[0].Delta.ToolCalls[0].Function.Arguments)
+	require.Equal(t, `"Paris"}`, chunks[4].Choices[0].Delta.ToolCalls[0].Function.Arguments)
+	require.Equal(t, openai.ChatCompletionChoicesFinishReasonToolCalls, chunks[5].Choices[0].FinishReason)
+	require.Equal(t, 25, chunks[6].Usage.TotalTokens)
+}
+
+func TestOpenAIToAnthropicTranslatorV1ChatCompletion_ResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		headers    map[string]string
+		body       string
+		expType    string
+		expMessage string
+		expCode    string
+	}{
+		{
+			name:       "json error",
+			headers:    map[string]string{":status": "429", "content-type": "application/json"},
+			body:       `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`,
+			expType:    "rate_limit_error",
+			expMessage: "slow down",
+			expCode:    "rate_limit_error",
+		},
+		{
+			name:       "non json error",
+			headers:    map[string]string{":status": "503", "content-type": "text/plain"},
+			body:       "service unavailable",
+			expType:    "server_error",
+			expMessage: "service unavailable",
+			expCode:    "503",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToAnthropicTranslator("")
+			hm, bm, usage, err := o.ResponseBody(tc.headers, strings.NewReader(tc.body), true)
+			require.NoError(t, err)
+			require.Equal(t, LLMTokenUsage{}, usage)
+			require.NotNil(t, hm)
+
+			var openAIError openai.Error
+			require.NoError(t, json.Unmarshal(bm.Mutation.(*extprocv3.BodyMutation_Body).Body, &openAIError))
+			require.Equal(t, "error", openAIError.Type)
+			require.Equal(t, tc.expType, openAIError.Error.Type)
+			require.Equal(t, tc.expMessage, openAIError.Error.Message)
+			require.Equal(t, tc.expCode, *openAIError.Error.Code)
+		})
+	}
+}
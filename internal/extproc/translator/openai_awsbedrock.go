@@ -20,17 +20,21 @@ import (
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"k8s.io/utils/ptr"
 
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 )
 
 // NewChatCompletionOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI to AWS Bedrock translation.
-func NewChatCompletionOpenAIToAWSBedrockTranslator() OpenAIChatCompletionTranslator {
-	return &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+//
+// The guardrail is set in the translated requests if not nil so that Bedrock applies it natively.
+func NewChatCompletionOpenAIToAWSBedrockTranslator(guardrail *awsbedrock.GuardrailConfiguration) OpenAIChatCompletionTranslator {
+	return &openAIToAWSBedrockTranslatorV1ChatCompletion{guardrail: guardrail}
 }
 
 // openAIToAWSBedrockTranslator implements [Translator] for /v1/chat/completions.
 type openAIToAWSBedrockTranslatorV1ChatCompletion struct {
+	guardrail    *awsbedrock.GuardrailConfiguration
 	stream       bool
 	bufferedBody []byte
 	events       []awsbedrock.ConverseStreamEvent
@@ -67,16 +71,17 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) RequestBody(openAIReq *op
 	bedrockReq.InferenceConfig.StopSequences = openAIReq.Stop
 	bedrockReq.InferenceConfig.Temperature = openAIReq.Temperature
 	bedrockReq.InferenceConfig.TopP = openAIReq.TopP
+	bedrockReq.GuardrailConfig = o.guardrail
 	// Convert Chat Completion messages.
 	err = o.openAIMessageToBedrockMessage(openAIReq, &bedrockReq)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError, err)
 	}
 	// Convert ToolConfiguration.
 	if len(openAIReq.Tools) > 0 {
 		err = o.openAIToolsToBedrockToolConfiguration(openAIReq, &bedrockReq)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError, err)
 		}
 	}
 
@@ -172,6 +177,34 @@ func parseDataURI(uri string) (string, []byte, error) {
 	return contentType, bin, nil
 }
 
+// dataURIToBedrockImage converts the image data URI to the [awsbedrock.ImageBlock].
+func dataURIToBedrockImage(uri string) (*awsbedrock.ImageBlock, error) {
+	contentType, b, err := parseDataURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image URL: %s %w", uri, err)
+	}
+	var format string
+	switch contentType {
+	case "image/png":
+		format = "png"
+	case "image/jpeg":
+		format = "jpeg"
+	case "image/gif":
+		format = "gif"
+	case "image/webp":
+		format = "webp"
+	default:
+		return nil, fmt.Errorf("unsupported image type: %s please use one of [png, jpeg, gif, webp]",
+			contentType)
+	}
+	return &awsbedrock.ImageBlock{
+		Format: format,
+		Source: awsbedrock.ImageSource{
+			Bytes: b, // Decoded data as bytes.
+		},
+	}, nil
+}
+
 // openAIMessageToBedrockMessageRoleUser converts openai user role message.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMessageRoleUser(
 	openAiMessage *openai.ChatCompletionUserMessageParam, role string,
@@ -194,34 +227,11 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 					Text: &textContentPart.Text,
 				})
 			} else if contentPart.ImageContent != nil {
-				imageContentPart := contentPart.ImageContent
-				contentType, b, err := parseDataURI(imageContentPart.ImageURL.URL)
+				image, err := dataURIToBedrockImage(contentPart.ImageContent.ImageURL.URL)
 				if err != nil {
-					return nil, fmt.Errorf("failed to parse image URL: %s %w", imageContentPart.ImageURL.URL, err)
-				}
-				var format string
-				switch contentType {
-				case "image/png":
-					format = "png"
-				case "image/jpeg":
-					format = "jpeg"
-				case "image/gif":
-					format = "gif"
-				case "image/webp":
-					format = "webp"
-				default:
-					return nil, fmt.Errorf("unsupported image type: %s please use one of [png, jpeg, gif, webp]",
-						contentType)
+					return nil, err
 				}
-
-				chatMessage.Content = append(chatMessage.Content, &awsbedrock.ContentBlock{
-					Image: &awsbedrock.ImageBlock{
-						Format: format,
-						Source: awsbedrock.ImageSource{
-							Bytes: b, // Decoded data as bytes.
-						},
-					},
-				})
+				chatMessage.Content = append(chatMessage.Content, &awsbedrock.ContentBlock{Image: image})
 			}
 		}
 		return chatMessage, nil
@@ -436,7 +446,7 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) bedrockStopReasonToOpenAI
 		return openai.ChatCompletionChoicesFinishReasonStop
 	case awsbedrock.StopReasonMaxTokens:
 		return openai.ChatCompletionChoicesFinishReasonLength
-	case awsbedrock.StopReasonContentFiltered:
+	case awsbedrock.StopReasonContentFiltered, awsbedrock.StopReasonGuardrailIntervened:
 		return openai.ChatCompletionChoicesFinishReasonContentFilter
 	case awsbedrock.StopReasonToolUse:
 		return openai.ChatCompletionChoicesFinishReasonToolCalls
@@ -465,51 +475,32 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) bedrockToolUseToOpenAICal
 	}
 }
 
-// ResponseError implements [Translator.ResponseError]
+// ResponseError implements [OpenAIChatCompletionTranslator.ResponseError].
 // Translate AWS Bedrock exceptions to OpenAI error type.
 // The error type is stored in the "x-amzn-errortype" HTTP header for AWS error responses.
 // If AWS Bedrock connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
 ) {
-	statusCode := respHeaders[statusHeaderName]
-	var openaiError openai.Error
+	return awsBedrockResponseError(respHeaders, body)
+}
+
+// awsBedrockResponseError translates the AWS Bedrock error response to the OpenAI error type.
+func awsBedrockResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
 	if v, ok := respHeaders[contentTypeHeaderName]; ok && v == jsonContentType {
 		var bedrockError awsbedrock.BedrockException
 		if err = json.NewDecoder(body).Decode(&bedrockError); err != nil {
 			return nil, nil, fmt.Errorf("failed to unmarshal error body: %w", err)
 		}
-		openaiError = openai.Error{
-			Type: "error",
-			Error: openai.ErrorType{
-				Type:    respHeaders[awsErrorTypeHeaderName],
-				Message: bedrockError.Message,
-				Code:    &statusCode,
-			},
-		}
-	} else {
-		var buf []byte
-		buf, err = io.ReadAll(body)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read error body: %w", err)
-		}
-		openaiError = openai.Error{
-			Type: "error",
-			Error: openai.ErrorType{
-				Type:    awsBedrockBackendError,
-				Message: string(buf),
-				Code:    &statusCode,
-			},
-		}
+		return backendErrorResponse(respHeaders, respHeaders[awsErrorTypeHeaderName], bedrockError.Message)
 	}
-	mut := &extprocv3.BodyMutation_Body{}
-	mut.Body, err = json.Marshal(openaiError)
+	buf, err := io.ReadAll(body)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
 	}
-	headerMutation = &extprocv3.HeaderMutation{}
-	setContentLength(headerMutation, mut.Body)
-	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+	return backendErrorResponse(respHeaders, respHeaders[awsErrorTypeHeaderName], string(buf))
 }
 
 // ResponseBody implements [Translator.ResponseBody].
@@ -618,23 +609,27 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(respHeaders
 // extractAmazonEventStreamEvents extracts [awsbedrock.ConverseStreamEvent] from the buffered body.
 // The extracted events are stored in the processor's events field.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) extractAmazonEventStreamEvents() {
+	o.bufferedBody, o.events = decodeAmazonEventStream(o.bufferedBody, o.events[:0])
+}
+
+// decodeAmazonEventStream decodes the complete [awsbedrock.ConverseStreamEvent]s in the buffer and appends them to
+// the events. This returns the buffer holding the remaining bytes of the incomplete event, if any.
+func decodeAmazonEventStream(buffered []byte, events []awsbedrock.ConverseStreamEvent) ([]byte, []awsbedrock.ConverseStreamEvent) {
 	// TODO: Maybe reuse the reader and decoder.
-	r := bytes.NewReader(o.bufferedBody)
+	r := bytes.NewReader(buffered)
 	dec := eventstream.NewDecoder()
-	o.events = o.events[:0]
 	var lastRead int64
 	for {
 		msg, err := dec.Decode(r, nil)
 		if err != nil {
 			// When failed, we stop processing the events.
 			// Copy the unread bytes to the beginning of the buffer.
-			copy(o.bufferedBody, o.bufferedBody[lastRead:])
-			o.bufferedBody = o.bufferedBody[:len(o.bufferedBody)-int(lastRead)]
-			return
+			copy(buffered, buffered[lastRead:])
+			return buffered[:len(buffered)-int(lastRead)], events
 		}
 		var event awsbedrock.ConverseStreamEvent
 		if err := json.Unmarshal(msg.Payload, &event); err == nil {
-			o.events = append(o.events, event)
+			events = append(events, event)
 		}
 		lastRead = r.Size() - int64(r.Len())
 	}
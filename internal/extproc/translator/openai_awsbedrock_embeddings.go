@@ -0,0 +1,189 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// awsBedrockInputTokenCountHeaderName is the response header of the InvokeModel API carrying the number of input tokens.
+const awsBedrockInputTokenCountHeaderName = "x-amzn-bedrock-input-token-count"
+
+// awsBedrockEmbeddingModelFamily is the family of the embedding model served by the InvokeModel API.
+// The request and response body format differs per family.
+type awsBedrockEmbeddingModelFamily int
+
+const (
+	awsBedrockEmbeddingModelFamilyTitan awsBedrockEmbeddingModelFamily = iota
+	awsBedrockEmbeddingModelFamilyCohere
+)
+
+// NewEmbeddingOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI to AWS Bedrock embeddings translation.
+//
+// AWS Bedrock does not have a unified embeddings API, so this translates the request into the InvokeModel API
+// body of either Amazon Titan or Cohere Embed models depending on the model ID.
+func NewEmbeddingOpenAIToAWSBedrockTranslator() OpenAIEmbeddingTranslator {
+	return &openAIToAWSBedrockTranslatorV1Embedding{}
+}
+
+// openAIToAWSBedrockTranslatorV1Embedding implements [OpenAIEmbeddingTranslator] for /v1/embeddings.
+type openAIToAWSBedrockTranslatorV1Embedding struct {
+	family awsBedrockEmbeddingModelFamily
+	// model is the model name in the request which is used in the translated response.
+	model string
+	// base64 is true if the client requested the base64 encoding format.
+	base64 bool
+}
+
+// RequestBody implements [OpenAIEmbeddingTranslator.RequestBody].
+func (o *openAIToAWSBedrockTranslatorV1Embedding) RequestBody(openAIReq *openai.EmbeddingRequest) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.model = openAIReq.Model
+	o.base64 = openAIReq.EncodingFormat != nil && *openAIReq.EncodingFormat == "base64"
+
+	var texts []string
+	switch v := openAIReq.Input.Value.(type) {
+	case string:
+		texts = []string{v}
+	case []string:
+		texts = v
+	default:
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError,
+			errors.New("AWS Bedrock embeddings only support string or array of strings as input"))
+	}
+
+	var bedrockReq any
+	// Model IDs may be prefixed by the region of the cross-region inference profile, e.g. "us.cohere.embed-english-v3".
+	switch {
+	case strings.Contains(openAIReq.Model, "amazon.titan-embed"):
+		o.family = awsBedrockEmbeddingModelFamilyTitan
+		if len(texts) != 1 {
+			return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError,
+				fmt.Errorf("AWS Bedrock Titan embedding models accept exactly one input, got %d", len(texts)))
+		}
+		bedrockReq = &awsbedrock.TitanEmbeddingRequest{InputText: texts[0], Dimensions: openAIReq.Dimensions}
+	case strings.Contains(openAIReq.Model, "cohere.embed"):
+		o.family = awsBedrockEmbeddingModelFamilyCohere
+		// OpenAI does not have the concept of input_type, so we default to the document embedding which is
+		// the most common usage of the embeddings API, e.g. indexing documents for RAG.
+		bedrockReq = &awsbedrock.CohereEmbeddingRequest{Texts: texts, InputType: "search_document"}
+	default:
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError,
+			fmt.Errorf("unsupported AWS Bedrock embedding model: %s", openAIReq.Model))
+	}
+
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{
+				Key:      ":path",
+				RawValue: []byte(fmt.Sprintf("/model/%s/invoke", openAIReq.Model)),
+			}},
+		},
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(bedrockReq); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+// ResponseHeaders implements [OpenAIEmbeddingTranslator.ResponseHeaders].
+func (o *openAIToAWSBedrockTranslatorV1Embedding) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIEmbeddingTranslator.ResponseBody].
+func (o *openAIToAWSBedrockTranslatorV1Embedding) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if statusStr, ok := respHeaders[statusHeaderName]; ok {
+		var status int
+		if status, err = strconv.Atoi(statusStr); err == nil {
+			if !isGoodStatusCode(status) {
+				headerMutation, bodyMutation, err = awsBedrockResponseError(respHeaders, body)
+				return headerMutation, bodyMutation, LLMTokenUsage{}, err
+			}
+		}
+	}
+
+	var embeddings [][]float64
+	var inputTokens int
+	switch o.family {
+	case awsBedrockEmbeddingModelFamilyTitan:
+		var bedrockResp awsbedrock.TitanEmbeddingResponse
+		if err = json.NewDecoder(body).Decode(&bedrockResp); err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+		}
+		embeddings = [][]float64{bedrockResp.Embedding}
+		inputTokens = bedrockResp.InputTextTokenCount
+	case awsBedrockEmbeddingModelFamilyCohere:
+		var bedrockResp awsbedrock.CohereEmbeddingResponse
+		if err = json.NewDecoder(body).Decode(&bedrockResp); err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+		}
+		embeddings = bedrockResp.Embeddings
+	}
+	// Cohere models do not report the token usage in the body, but InvokeModel always reports it in the header.
+	if v, ok := respHeaders[awsBedrockInputTokenCountHeaderName]; ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			inputTokens = n
+		}
+	}
+
+	openAIResp := openai.EmbeddingResponse{
+		Object: "list",
+		Data:   make([]openai.Embedding, 0, len(embeddings)),
+		Model:  o.model,
+		Usage:  openai.EmbeddingUsage{PromptTokens: inputTokens, TotalTokens: inputTokens},
+	}
+	for i, e := range embeddings {
+		embedding := openai.Embedding{Object: "embedding", Index: i, Embedding: openai.EmbeddingUnion{Value: e}}
+		if o.base64 {
+			embedding.Embedding.Value = encodeEmbeddingBase64(e)
+		}
+		openAIResp.Data = append(openAIResp.Data, embedding)
+	}
+	tokenUsage = LLMTokenUsage{
+		InputTokens: uint32(inputTokens), //nolint:gosec
+		TotalTokens: uint32(inputTokens), //nolint:gosec
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	mut.Body, err = json.Marshal(openAIResp)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// encodeEmbeddingBase64 encodes the embedding the same way as OpenAI does for the base64 encoding format,
+// which is the base64 of the little-endian float32 array.
+func encodeEmbeddingBase64(embedding []float64) string {
+	buf := make([]byte, 4*len(embedding))
+	for i, f := range embedding {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(f)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,119 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToAWSBedrockTranslatorV1Embedding_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   *openai.EmbeddingRequest
+		expPath string
+		expBody string
+		expErr  string
+	}{
+		{
+			name:    "titan",
+			input:   &openai.EmbeddingRequest{Model: "amazon.titan-embed-text-v2:0", Input: openai.EmbeddingRequestInput{Value: "hello"}, Dimensions: ptr.To(256)},
+			expPath: "/model/amazon.titan-embed-text-v2:0/invoke",
+			expBody: `{"inputText":"hello","dimensions":256}`,
+		},
+		{
+			name:    "titan single element array",
+			input:   &openai.EmbeddingRequest{Model: "amazon.titan-embed-text-v1", Input: openai.EmbeddingRequestInput{Value: []string{"hello"}}},
+			expPath: "/model/amazon.titan-embed-text-v1/invoke",
+			expBody: `{"inputText":"hello"}`,
+		},
+		{
+			name:   "titan multiple inputs",
+			input:  &openai.EmbeddingRequest{Model: "amazon.titan-embed-text-v1", Input: openai.EmbeddingRequestInput{Value: []string{"a", "b"}}},
+			expErr: "accept exactly one input, got 2",
+		},
+		{
+			name:    "cohere",
+			input:   &openai.EmbeddingRequest{Model: "us.cohere.embed-english-v3", Input: openai.EmbeddingRequestInput{Value: []string{"a", "b"}}},
+			expPath: "/model/us.cohere.embed-english-v3/invoke",
+			expBody: `{"texts":["a","b"],"input_type":"search_document"}`,
+		},
+		{
+			name:   "tokens input",
+			input:  &openai.EmbeddingRequest{Model: "cohere.embed-english-v3", Input: openai.EmbeddingRequestInput{Value: []int64{1, 2}}},
+			expErr: "only support string or array of strings as input",
+		},
+		{
+			name:   "unsupported model",
+			input:  &openai.EmbeddingRequest{Model: "anthropic.claude-v2", Input: openai.EmbeddingRequestInput{Value: "hello"}},
+			expErr: "unsupported AWS Bedrock embedding model: anthropic.claude-v2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewEmbeddingOpenAIToAWSBedrockTranslator()
+			hm, bm, err := o.RequestBody(tc.input)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				require.Equal(t, aigwerrors.ClientError, aigwerrors.TypeOf(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			body := bm.Mutation.(*extprocv3.BodyMutation_Body).Body
+			require.JSONEq(t, tc.expBody, string(body))
+			require.Equal(t, "content-length", hm.SetHeaders[1].Header.Key)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Embedding_ResponseBody(t *testing.T) {
+	t.Run("titan", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToAWSBedrockTranslator()
+		_, _, err := o.RequestBody(&openai.EmbeddingRequest{Model: "amazon.titan-embed-text-v2:0", Input: openai.EmbeddingRequestInput{Value: "hello"}})
+		require.NoError(t, err)
+		_, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"},
+			strings.NewReader(`{"embedding":[0.5,-0.25],"inputTextTokenCount":3}`), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 3, TotalTokens: 3}, usage)
+		require.JSONEq(t, `{"object":"list","data":[{"object":"embedding","embedding":[0.5,-0.25],"index":0}],"model":"amazon.titan-embed-text-v2:0","usage":{"prompt_tokens":3,"total_tokens":3}}`,
+			string(bm.Mutation.(*extprocv3.BodyMutation_Body).Body))
+	})
+	t.Run("cohere base64", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToAWSBedrockTranslator()
+		_, _, err := o.RequestBody(&openai.EmbeddingRequest{
+			Model: "cohere.embed-english-v3", Input: openai.EmbeddingRequestInput{Value: []string{"a", "b"}}, EncodingFormat: ptr.To("base64"),
+		})
+		require.NoError(t, err)
+		_, bm, usage, err := o.ResponseBody(map[string]string{":status": "200", "x-amzn-bedrock-input-token-count": "4"},
+			strings.NewReader(`{"id":"foo","embeddings":[[1.0],[0.0]],"texts":["a","b"],"response_type":"embeddings_floats"}`), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 4, TotalTokens: 4}, usage)
+		var resp openai.EmbeddingResponse
+		require.NoError(t, json.Unmarshal(bm.Mutation.(*extprocv3.BodyMutation_Body).Body, &resp))
+		require.Len(t, resp.Data, 2)
+		require.Equal(t, "AACAPw==", resp.Data[0].Embedding.Value)
+		require.Equal(t, "AAAAAA==", resp.Data[1].Embedding.Value)
+		require.Equal(t, 1, resp.Data[1].Index)
+	})
+	t.Run("error", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToAWSBedrockTranslator()
+		_, bm, _, err := o.ResponseBody(map[string]string{
+			":status": "400", "content-type": "application/json", "x-amzn-errortype": "ValidationException",
+		}, strings.NewReader(`{"message":"bad input"}`), true)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","code":"ValidationException","message":"bad input"}}`,
+			string(bm.Mutation.(*extprocv3.BodyMutation_Body).Body))
+	})
+}
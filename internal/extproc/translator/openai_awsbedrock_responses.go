@@ -0,0 +1,632 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewResponsesOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI to AWS Bedrock responses translation.
+//
+// The Responses API is translated to the Converse API, so only the subset that maps to it is supported:
+// the stateful features such as previous_response_id and item_reference, the built-in tools and the file inputs
+// are rejected, and the reasoning items in the input are dropped.
+//
+// The guardrail is set in the translated requests if not nil so that Bedrock applies it natively.
+func NewResponsesOpenAIToAWSBedrockTranslator(guardrail *awsbedrock.GuardrailConfiguration) OpenAIResponsesTranslator {
+	return &openAIToAWSBedrockTranslatorV1Responses{guardrail: guardrail, newID: newResponseItemID, now: time.Now}
+}
+
+// openAIToAWSBedrockTranslatorV1Responses implements [OpenAIResponsesTranslator] for /v1/responses.
+type openAIToAWSBedrockTranslatorV1Responses struct {
+	guardrail    *awsbedrock.GuardrailConfiguration
+	model        string
+	stream       bool
+	bufferedBody []byte
+	events       []awsbedrock.ConverseStreamEvent
+
+	// The fields below are the state of the streamed response, which is built incrementally from the events.
+	response openai.Response
+	// sequenceNumber is the sequence number of the next server-sent event.
+	sequenceNumber int
+	// openBlock is the Bedrock content block index of the last output item, if it is not done yet.
+	openBlock *int
+	// stopReason is the stop reason from the MessageStopEvent.
+	stopReason *string
+	// terminated is true once the terminal response.* event is sent.
+	terminated bool
+
+	// newID returns a new unique ID with the given prefix. This is replaced in tests.
+	newID func(prefix string) string
+	// now returns the current time. This is replaced in tests.
+	now func() time.Time
+}
+
+// newResponseItemID returns a random ID with the given prefix, like the ones generated by OpenAI.
+func newResponseItemID(prefix string) string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return prefix + "_" + hex.EncodeToString(id[:])
+}
+
+// RequestBody implements [OpenAIResponsesTranslator.RequestBody].
+func (o *openAIToAWSBedrockTranslatorV1Responses) RequestBody(req *openai.ResponseRequest) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	if req.PreviousResponseID != nil {
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError,
+			errors.New("previous_response_id is not supported for AWS Bedrock"))
+	}
+	pathTemplate := "/model/%s/converse"
+	if req.Stream {
+		o.stream = true
+		pathTemplate = "/model/%s/converse-stream"
+	}
+	o.model = req.Model
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{
+				Key:      ":path",
+				RawValue: []byte(fmt.Sprintf(pathTemplate, req.Model)),
+			}},
+		},
+	}
+
+	bedrockReq := awsbedrock.ConverseInput{
+		InferenceConfig: &awsbedrock.InferenceConfiguration{
+			MaxTokens:   req.MaxOutputTokens,
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+		},
+		GuardrailConfig: o.guardrail,
+	}
+	if req.Instructions != nil {
+		bedrockReq.System = append(bedrockReq.System, &awsbedrock.SystemContentBlock{Text: *req.Instructions})
+	}
+	switch input := req.Input.Value.(type) {
+	case string:
+		bedrockReq.Messages = []*awsbedrock.Message{
+			{Role: openai.ChatMessageRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To(input)}}},
+		}
+	case []openai.ResponseItem:
+		for i := range input {
+			if err = responseItemToBedrock(&input[i], &bedrockReq); err != nil {
+				return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError,
+					fmt.Errorf("failed to convert input item %d: %w", i, err))
+			}
+		}
+	default:
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError,
+			fmt.Errorf("unexpected input type: %T", req.Input.Value))
+	}
+	if len(req.Tools) > 0 {
+		if bedrockReq.ToolConfig, err = responseToolsToBedrockToolConfiguration(req); err != nil {
+			return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError, err)
+		}
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(bedrockReq); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+// responseItemToBedrock converts the input item to the Bedrock messages or system prompts, and appends it to the request.
+// Consecutive contents of the same role are merged into one message since Bedrock requires the roles to alternate.
+func responseItemToBedrock(item *openai.ResponseItem, bedrockReq *awsbedrock.ConverseInput) error {
+	var role string
+	var content []*awsbedrock.ContentBlock
+	switch item.Type {
+	case "", openai.ResponseItemTypeMessage:
+		if item.Content == nil {
+			return errors.New("message content is required")
+		}
+		switch item.Role {
+		case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+			texts, err := responseMessageContentToTexts(item.Content)
+			if err != nil {
+				return err
+			}
+			for _, text := range texts {
+				bedrockReq.System = append(bedrockReq.System, &awsbedrock.SystemContentBlock{Text: text})
+			}
+			return nil
+		case openai.ChatMessageRoleUser, openai.ChatMessageRoleAssistant:
+			role = item.Role
+			var err error
+			if content, err = responseMessageContentToBedrock(item.Content); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported message role: %s", item.Role)
+		}
+	case openai.ResponseItemTypeFunctionCall:
+		input, err := unmarshalToolCallArguments(item.Arguments)
+		if err != nil {
+			return err
+		}
+		role = openai.ChatMessageRoleAssistant
+		content = []*awsbedrock.ContentBlock{
+			{ToolUse: &awsbedrock.ToolUseBlock{Name: item.Name, ToolUseID: item.CallID, Input: input}},
+		}
+	case openai.ResponseItemTypeFunctionCallOutput:
+		role = openai.ChatMessageRoleUser
+		content = []*awsbedrock.ContentBlock{
+			{ToolResult: &awsbedrock.ToolResultBlock{
+				Content:   []*awsbedrock.ToolResultContentBlock{{Text: ptr.To(item.Output)}},
+				ToolUseID: ptr.To(item.CallID),
+			}},
+		}
+	case openai.ResponseItemTypeReasoning:
+		// The reasoning items are specific to the OpenAI reasoning models, so they are dropped.
+		return nil
+	default:
+		return fmt.Errorf("unsupported input item type: %s", item.Type)
+	}
+
+	if n := len(bedrockReq.Messages); n > 0 && bedrockReq.Messages[n-1].Role == role {
+		bedrockReq.Messages[n-1].Content = append(bedrockReq.Messages[n-1].Content, content...)
+	} else {
+		bedrockReq.Messages = append(bedrockReq.Messages, &awsbedrock.Message{Role: role, Content: content})
+	}
+	return nil
+}
+
+// responseMessageContentToTexts returns the texts of the message content, which must consist only of text.
+func responseMessageContentToTexts(content *openai.ResponseMessageContent) ([]string, error) {
+	switch v := content.Value.(type) {
+	case string:
+		return []string{v}, nil
+	case []openai.ResponseContentPart:
+		texts := make([]string, 0, len(v))
+		for i := range v {
+			switch v[i].Type {
+			case openai.ResponseContentPartTypeInputText, openai.ResponseContentPartTypeOutputText:
+				texts = append(texts, v[i].Text)
+			default:
+				return nil, fmt.Errorf("unsupported content part type for system message: %s", v[i].Type)
+			}
+		}
+		return texts, nil
+	default:
+		return nil, fmt.Errorf("unexpected content type: %T", content.Value)
+	}
+}
+
+// responseMessageContentToBedrock converts the message content to the Bedrock content blocks.
+func responseMessageContentToBedrock(content *openai.ResponseMessageContent) ([]*awsbedrock.ContentBlock, error) {
+	switch v := content.Value.(type) {
+	case string:
+		return []*awsbedrock.ContentBlock{{Text: ptr.To(v)}}, nil
+	case []openai.ResponseContentPart:
+		blocks := make([]*awsbedrock.ContentBlock, 0, len(v))
+		for i := range v {
+			part := &v[i]
+			switch part.Type {
+			case openai.ResponseContentPartTypeInputText, openai.ResponseContentPartTypeOutputText:
+				blocks = append(blocks, &awsbedrock.ContentBlock{Text: ptr.To(part.Text)})
+			case openai.ResponseContentPartTypeRefusal:
+				blocks = append(blocks, &awsbedrock.ContentBlock{Text: ptr.To(part.Refusal)})
+			case openai.ResponseContentPartTypeInputImage:
+				if part.ImageURL == nil {
+					return nil, errors.New("image_url is required for input_image since file_id is not supported")
+				}
+				image, err := dataURIToBedrockImage(*part.ImageURL)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, &awsbedrock.ContentBlock{Image: image})
+			default:
+				return nil, fmt.Errorf("unsupported content part type: %s", part.Type)
+			}
+		}
+		return blocks, nil
+	default:
+		return nil, fmt.Errorf("unexpected content type: %T", content.Value)
+	}
+}
+
+// responseToolsToBedrockToolConfiguration converts the function tools and the tool choice to the Bedrock tool configuration.
+// The tool choice "none" is translated to no tool choice since Bedrock requires the tools to be
+// configured when the messages contain the tool uses.
+func responseToolsToBedrockToolConfiguration(req *openai.ResponseRequest) (*awsbedrock.ToolConfiguration, error) {
+	toolConfig := &awsbedrock.ToolConfiguration{}
+	for i := range req.Tools {
+		tool := &req.Tools[i]
+		if tool.Type != openai.ResponseToolTypeFunction {
+			return nil, fmt.Errorf("unsupported tool type: %s", tool.Type)
+		}
+		toolConfig.Tools = append(toolConfig.Tools, &awsbedrock.Tool{
+			ToolSpec: &awsbedrock.ToolSpecification{
+				Name:        ptr.To(tool.Name),
+				Description: ptr.To(tool.Description),
+				InputSchema: &awsbedrock.ToolInputSchema{JSON: tool.Parameters},
+			},
+		})
+	}
+
+	switch choice := req.ToolChoice.(type) {
+	case nil:
+	case string:
+		switch choice {
+		case "auto":
+			toolConfig.ToolChoice = &awsbedrock.ToolChoice{Auto: &awsbedrock.AutoToolChoice{}}
+		case "required":
+			toolConfig.ToolChoice = &awsbedrock.ToolChoice{Any: &awsbedrock.AnyToolChoice{}}
+		case "none":
+		default:
+			return nil, fmt.Errorf("unsupported tool choice: %s", choice)
+		}
+	case map[string]any:
+		name, _ := choice["name"].(string)
+		if choice["type"] != string(openai.ResponseToolTypeFunction) || name == "" {
+			return nil, fmt.Errorf("unsupported tool choice: %v", choice)
+		}
+		toolConfig.ToolChoice = &awsbedrock.ToolChoice{Tool: &awsbedrock.SpecificToolChoice{Name: ptr.To(name)}}
+	default:
+		return nil, fmt.Errorf("unexpected tool choice type: %T", req.ToolChoice)
+	}
+	return toolConfig, nil
+}
+
+// ResponseHeaders implements [OpenAIResponsesTranslator.ResponseHeaders].
+func (o *openAIToAWSBedrockTranslatorV1Responses) ResponseHeaders(headers map[string]string) (
+	headerMutation *extprocv3.HeaderMutation, err error,
+) {
+	if o.stream && headers["content-type"] == "application/vnd.amazon.eventstream" {
+		// We need to change the content-type to text/event-stream for streaming responses.
+		return &extprocv3.HeaderMutation{
+			SetHeaders: []*corev3.HeaderValueOption{
+				{Header: &corev3.HeaderValue{Key: "content-type", Value: "text/event-stream"}},
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIResponsesTranslator.ResponseBody].
+func (o *openAIToAWSBedrockTranslatorV1Responses) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if statusStr, ok := respHeaders[statusHeaderName]; ok {
+		var status int
+		if status, err = strconv.Atoi(statusStr); err == nil {
+			if !isGoodStatusCode(status) {
+				headerMutation, bodyMutation, err = awsBedrockResponseError(respHeaders, body)
+				return headerMutation, bodyMutation, LLMTokenUsage{}, err
+			}
+		}
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if o.stream {
+		var buf []byte
+		buf, err = io.ReadAll(body)
+		if err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+		}
+		o.bufferedBody = append(o.bufferedBody, buf...)
+		o.bufferedBody, o.events = decodeAmazonEventStream(o.bufferedBody, o.events[:0])
+		for i := range o.events {
+			event := &o.events[i]
+			if event.Usage != nil {
+				tokenUsage = bedrockUsageToLLMTokenUsage(event.Usage)
+			}
+			if mut.Body, err = o.appendStreamEvents(mut.Body, event); err != nil {
+				return nil, nil, tokenUsage, err
+			}
+		}
+		if endOfStream && !o.terminated {
+			if mut.Body, err = o.appendTerminalEvent(mut.Body); err != nil {
+				return nil, nil, tokenUsage, err
+			}
+		}
+		return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+	}
+
+	var bedrockResp awsbedrock.ConverseResponse
+	if err = json.NewDecoder(body).Decode(&bedrockResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	resp := o.newResponse()
+	resp.Status, resp.IncompleteDetails = bedrockStopReasonToResponseStatus(bedrockResp.StopReason)
+	if bedrockResp.Usage != nil {
+		tokenUsage = bedrockUsageToLLMTokenUsage(bedrockResp.Usage)
+		resp.Usage = bedrockUsageToResponseUsage(bedrockResp.Usage)
+	}
+	if bedrockResp.Output != nil {
+		var message *openai.ResponseItem
+		for _, block := range bedrockResp.Output.Message.Content {
+			switch {
+			case block.Text != nil:
+				if message == nil {
+					resp.Output = append(resp.Output, o.newMessageItem(string(openai.ResponseStatusCompleted)))
+					message = &resp.Output[len(resp.Output)-1]
+				}
+				parts := message.Content.Value.([]openai.ResponseContentPart)
+				message.Content.Value = append(parts, newOutputTextPart(*block.Text))
+			case block.ToolUse != nil:
+				item, err := o.bedrockToolUseToFunctionCallItem(block.ToolUse)
+				if err != nil {
+					return nil, nil, tokenUsage, err
+				}
+				resp.Output = append(resp.Output, item)
+				// The text after the tool use goes to a new message item to keep the order.
+				message = nil
+			}
+		}
+	}
+
+	mut.Body, err = json.Marshal(resp)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// newResponse returns a new [openai.Response] in progress.
+func (o *openAIToAWSBedrockTranslatorV1Responses) newResponse() openai.Response {
+	return openai.Response{
+		ID:        o.newID("resp"),
+		Object:    "response",
+		CreatedAt: o.now().Unix(),
+		Model:     o.model,
+		Status:    openai.ResponseStatusInProgress,
+		Output:    []openai.ResponseItem{},
+	}
+}
+
+// newMessageItem returns a new assistant message item without content parts.
+func (o *openAIToAWSBedrockTranslatorV1Responses) newMessageItem(status string) openai.ResponseItem {
+	return openai.ResponseItem{
+		Type:    openai.ResponseItemTypeMessage,
+		ID:      o.newID("msg"),
+		Status:  status,
+		Role:    openai.ChatMessageRoleAssistant,
+		Content: &openai.ResponseMessageContent{Value: []openai.ResponseContentPart{}},
+	}
+}
+
+// newOutputTextPart returns a new output_text content part.
+func newOutputTextPart(text string) openai.ResponseContentPart {
+	return openai.ResponseContentPart{Type: openai.ResponseContentPartTypeOutputText, Text: text}
+}
+
+// bedrockToolUseToFunctionCallItem converts the Bedrock tool use to the function_call item.
+func (o *openAIToAWSBedrockTranslatorV1Responses) bedrockToolUseToFunctionCallItem(toolUse *awsbedrock.ToolUseBlock) (openai.ResponseItem, error) {
+	arguments, err := json.Marshal(toolUse.Input)
+	if err != nil {
+		return openai.ResponseItem{}, fmt.Errorf("failed to marshal tool use input: %w", err)
+	}
+	return openai.ResponseItem{
+		Type:      openai.ResponseItemTypeFunctionCall,
+		ID:        o.newID("fc"),
+		Status:    string(openai.ResponseStatusCompleted),
+		CallID:    toolUse.ToolUseID,
+		Name:      toolUse.Name,
+		Arguments: string(arguments),
+	}, nil
+}
+
+// bedrockStopReasonToResponseStatus converts the Bedrock stop reason to the response status and the incomplete details.
+func bedrockStopReasonToResponseStatus(stopReason *string) (openai.ResponseStatus, *openai.ResponseIncompleteDetails) {
+	if stopReason == nil {
+		return openai.ResponseStatusCompleted, nil
+	}
+	switch *stopReason {
+	case awsbedrock.StopReasonMaxTokens:
+		return openai.ResponseStatusIncomplete, &openai.ResponseIncompleteDetails{Reason: "max_output_tokens"}
+	case awsbedrock.StopReasonContentFiltered, awsbedrock.StopReasonGuardrailIntervened:
+		return openai.ResponseStatusIncomplete, &openai.ResponseIncompleteDetails{Reason: "content_filter"}
+	default:
+		return openai.ResponseStatusCompleted, nil
+	}
+}
+
+// bedrockUsageToLLMTokenUsage converts the Bedrock usage to [LLMTokenUsage].
+func bedrockUsageToLLMTokenUsage(usage *awsbedrock.TokenUsage) LLMTokenUsage {
+	return LLMTokenUsage{
+		InputTokens:  uint32(usage.InputTokens),  //nolint:gosec
+		OutputTokens: uint32(usage.OutputTokens), //nolint:gosec
+		TotalTokens:  uint32(usage.TotalTokens),  //nolint:gosec
+	}
+}
+
+// bedrockUsageToResponseUsage converts the Bedrock usage to [openai.ResponseUsage].
+func bedrockUsageToResponseUsage(usage *awsbedrock.TokenUsage) *openai.ResponseUsage {
+	return &openai.ResponseUsage{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.TotalTokens,
+	}
+}
+
+// appendStreamEvents converts the Bedrock stream event to the server-sent events of the Responses API and appends them to the buf.
+func (o *openAIToAWSBedrockTranslatorV1Responses) appendStreamEvents(buf []byte, event *awsbedrock.ConverseStreamEvent) ([]byte, error) {
+	var err error
+	switch {
+	case event.Role != nil:
+		o.response = o.newResponse()
+		if buf, err = o.appendEvent(buf, openai.ResponseStreamEvent{Type: openai.ResponseStreamEventTypeCreated, Response: o.responseSnapshot()}); err != nil {
+			return nil, err
+		}
+		return o.appendEvent(buf, openai.ResponseStreamEvent{Type: openai.ResponseStreamEventTypeInProgress, Response: o.responseSnapshot()})
+	case event.Start != nil && event.Start.ToolUse != nil:
+		if buf, err = o.appendItemDoneEvents(buf); err != nil {
+			return nil, err
+		}
+		o.response.Output = append(o.response.Output, openai.ResponseItem{
+			Type:   openai.ResponseItemTypeFunctionCall,
+			ID:     o.newID("fc"),
+			Status: string(openai.ResponseStatusInProgress),
+			CallID: event.Start.ToolUse.ToolUseID,
+			Name:   event.Start.ToolUse.Name,
+		})
+		o.openBlock = ptr.To(event.ContentBlockIndex)
+		return o.appendItemEvent(buf, openai.ResponseStreamEventTypeOutputItemAdded)
+	case event.Delta != nil && event.Delta.Text != nil:
+		if o.openBlock == nil || *o.openBlock != event.ContentBlockIndex {
+			if buf, err = o.appendItemDoneEvents(buf); err != nil {
+				return nil, err
+			}
+			o.response.Output = append(o.response.Output, o.newMessageItem(string(openai.ResponseStatusInProgress)))
+			o.openBlock = ptr.To(event.ContentBlockIndex)
+			if buf, err = o.appendItemEvent(buf, openai.ResponseStreamEventTypeOutputItemAdded); err != nil {
+				return nil, err
+			}
+			if buf, err = o.appendPartEvent(buf, openai.ResponseStreamEventTypeContentPartAdded, newOutputTextPart("")); err != nil {
+				return nil, err
+			}
+		}
+		item := o.lastItem()
+		item.Content.Value = []openai.ResponseContentPart{newOutputTextPart(o.lastItemText() + *event.Delta.Text)}
+		return o.appendDeltaEvent(buf, openai.ResponseStreamEventTypeOutputTextDelta, *event.Delta.Text)
+	case event.Delta != nil && event.Delta.ToolUse != nil:
+		if o.openBlock == nil {
+			// The tool use delta without the start event is not expected, so it is ignored.
+			return buf, nil
+		}
+		o.lastItem().Arguments += event.Delta.ToolUse.Input
+		return o.appendDeltaEvent(buf, openai.ResponseStreamEventTypeFunctionCallArgumentsDelta, event.Delta.ToolUse.Input)
+	case event.StopReason != nil:
+		o.stopReason = event.StopReason
+		return o.appendItemDoneEvents(buf)
+	case event.Usage != nil:
+		o.response.Usage = bedrockUsageToResponseUsage(event.Usage)
+		return o.appendTerminalEvent(buf)
+	}
+	return buf, nil
+}
+
+// appendTerminalEvent closes the open output item, if any, and appends the response.completed or response.incomplete event.
+func (o *openAIToAWSBedrockTranslatorV1Responses) appendTerminalEvent(buf []byte) ([]byte, error) {
+	buf, err := o.appendItemDoneEvents(buf)
+	if err != nil {
+		return nil, err
+	}
+	o.response.Status, o.response.IncompleteDetails = bedrockStopReasonToResponseStatus(o.stopReason)
+	eventType := openai.ResponseStreamEventTypeCompleted
+	if o.response.Status == openai.ResponseStatusIncomplete {
+		eventType = openai.ResponseStreamEventTypeIncomplete
+	}
+	o.terminated = true
+	return o.appendEvent(buf, openai.ResponseStreamEvent{Type: eventType, Response: o.responseSnapshot()})
+}
+
+// appendItemDoneEvents appends the events to complete the open output item, if any.
+func (o *openAIToAWSBedrockTranslatorV1Responses) appendItemDoneEvents(buf []byte) ([]byte, error) {
+	if o.openBlock == nil {
+		return buf, nil
+	}
+	o.openBlock = nil
+	item := o.lastItem()
+	item.Status = string(openai.ResponseStatusCompleted)
+	var err error
+	switch item.Type {
+	case openai.ResponseItemTypeMessage:
+		text := o.lastItemText()
+		if buf, err = o.appendEvent(buf, openai.ResponseStreamEvent{
+			Type: openai.ResponseStreamEventTypeOutputTextDone, OutputIndex: o.lastIndex(), ContentIndex: ptr.To(0), ItemID: item.ID, Text: text,
+		}); err != nil {
+			return nil, err
+		}
+		if buf, err = o.appendPartEvent(buf, openai.ResponseStreamEventTypeContentPartDone, newOutputTextPart(text)); err != nil {
+			return nil, err
+		}
+	case openai.ResponseItemTypeFunctionCall:
+		if item.Arguments == "" {
+			// Bedrock omits the input of the tool without parameters.
+			item.Arguments = "{}"
+		}
+		if buf, err = o.appendEvent(buf, openai.ResponseStreamEvent{
+			Type: openai.ResponseStreamEventTypeFunctionCallArgumentsDone, OutputIndex: o.lastIndex(), ItemID: item.ID, Arguments: item.Arguments,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return o.appendItemEvent(buf, openai.ResponseStreamEventTypeOutputItemDone)
+}
+
+// appendItemEvent appends the output_item event of the last output item.
+func (o *openAIToAWSBedrockTranslatorV1Responses) appendItemEvent(buf []byte, eventType string) ([]byte, error) {
+	item := *o.lastItem()
+	return o.appendEvent(buf, openai.ResponseStreamEvent{Type: eventType, OutputIndex: o.lastIndex(), Item: &item})
+}
+
+// appendPartEvent appends the content_part event of the last output item.
+func (o *openAIToAWSBedrockTranslatorV1Responses) appendPartEvent(buf []byte, eventType string, part openai.ResponseContentPart) ([]byte, error) {
+	return o.appendEvent(buf, openai.ResponseStreamEvent{
+		Type: eventType, OutputIndex: o.lastIndex(), ContentIndex: ptr.To(0), ItemID: o.lastItem().ID, Part: &part,
+	})
+}
+
+// appendDeltaEvent appends the delta event of the last output item.
+func (o *openAIToAWSBedrockTranslatorV1Responses) appendDeltaEvent(buf []byte, eventType string, delta string) ([]byte, error) {
+	event := openai.ResponseStreamEvent{Type: eventType, OutputIndex: o.lastIndex(), ItemID: o.lastItem().ID, Delta: delta}
+	if eventType == openai.ResponseStreamEventTypeOutputTextDelta {
+		event.ContentIndex = ptr.To(0)
+	}
+	return o.appendEvent(buf, event)
+}
+
+// appendEvent appends the server-sent event to the buf with the next sequence number.
+func (o *openAIToAWSBedrockTranslatorV1Responses) appendEvent(buf []byte, event openai.ResponseStreamEvent) ([]byte, error) {
+	event.SequenceNumber = o.sequenceNumber
+	o.sequenceNumber++
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	buf = append(buf, "event: "+event.Type+"\n"...)
+	buf = append(buf, dataPrefix...)
+	buf = append(buf, data...)
+	return append(buf, "\n\n"...), nil
+}
+
+// responseSnapshot returns a copy of the response being built so that the later changes are not reflected.
+func (o *openAIToAWSBedrockTranslatorV1Responses) responseSnapshot() *openai.Response {
+	resp := o.response
+	resp.Output = append([]openai.ResponseItem{}, o.response.Output...)
+	return &resp
+}
+
+func (o *openAIToAWSBedrockTranslatorV1Responses) lastItem() *openai.ResponseItem {
+	return &o.response.Output[len(o.response.Output)-1]
+}
+
+func (o *openAIToAWSBedrockTranslatorV1Responses) lastIndex() *int {
+	return ptr.To(len(o.response.Output) - 1)
+}
+
+// lastItemText returns the text of the last message item, which has at most one output_text part while streaming.
+func (o *openAIToAWSBedrockTranslatorV1Responses) lastItemText() string {
+	var b strings.Builder
+	if parts, ok := o.lastItem().Content.Value.([]openai.ResponseContentPart); ok {
+		for i := range parts {
+			b.WriteString(parts[i].Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,312 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// newTestBedrockResponsesTranslator returns the translator with the deterministic IDs and time.
+func newTestBedrockResponsesTranslator(guardrail *awsbedrock.GuardrailConfiguration) *openAIToAWSBedrockTranslatorV1Responses {
+	o := NewResponsesOpenAIToAWSBedrockTranslator(guardrail).(*openAIToAWSBedrockTranslatorV1Responses)
+	var n int
+	o.newID = func(prefix string) string {
+		n++
+		return fmt.Sprintf("%s_%d", prefix, n)
+	}
+	o.now = func() time.Time { return time.Unix(1741476542, 0) }
+	return o
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Responses_RequestBody(t *testing.T) {
+	const pngDataURL = "data:image/png;base64,iVBORw0KGgo="
+	for _, tc := range []struct {
+		name    string
+		req     string
+		expPath string
+		exp     string
+	}{
+		{
+			name:    "string input",
+			req:     `{"model":"anthropic.claude-v2","input":"hello","instructions":"be nice","max_output_tokens":10,"temperature":0.5,"top_p":0.9}`,
+			expPath: "/model/anthropic.claude-v2/converse",
+			exp: `{"inferenceConfig":{"maxTokens":10,"temperature":0.5,"topP":0.9},"modelId":null,"system":[{"text":"be nice"}],
+"messages":[{"role":"user","content":[{"text":"hello"}]}]}`,
+		},
+		{
+			name: "items",
+			req: `{"model":"m","stream":true,"input":[
+{"role":"developer","content":[{"type":"input_text","text":"sys"}]},
+{"role":"user","content":"what is the weather?"},
+{"type":"message","role":"user","content":[{"type":"input_text","text":"in Tokyo"},{"type":"input_image","image_url":"` + pngDataURL + `"}]},
+{"type":"reasoning","id":"rs_1","summary":[]},
+{"type":"message","role":"assistant","content":[{"type":"output_text","text":"let me check"}]},
+{"type":"function_call","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"},
+{"type":"function_call_output","call_id":"call_1","output":"sunny"}
+],"tools":[{"type":"function","name":"get_weather","description":"weather","parameters":{"type":"object"}}],"tool_choice":"required"}`,
+			expPath: "/model/m/converse-stream",
+			exp: `{"inferenceConfig":{},"modelId":null,"system":[{"text":"sys"}],"messages":[
+{"role":"user","content":[{"text":"what is the weather?"},{"text":"in Tokyo"},{"image":{"format":"png","source":{"bytes":"iVBORw0KGgo="}}}]},
+{"role":"assistant","content":[{"text":"let me check"},{"toolUse":{"name":"get_weather","input":{"city":"Tokyo"},"toolUseId":"call_1"}}]},
+{"role":"user","content":[{"toolResult":{"content":[{"text":"sunny"}],"status":null,"toolUseId":"call_1"}}]}],
+"toolConfig":{"toolChoice":{"any":{}},"tools":[{"toolSpec":{"description":"weather","inputSchema":{"json":{"type":"object"}},"name":"get_weather"}}]}}`,
+		},
+		{
+			name:    "specific tool choice",
+			req:     `{"model":"m","input":"hi","tools":[{"type":"function","name":"f"}],"tool_choice":{"type":"function","name":"f"}}`,
+			expPath: "/model/m/converse",
+			exp: `{"inferenceConfig":{},"modelId":null,"messages":[{"role":"user","content":[{"text":"hi"}]}],
+"toolConfig":{"toolChoice":{"tool":{"name":"f"}},"tools":[{"toolSpec":{"description":"","inputSchema":{"json":null},"name":"f"}}]}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ResponseRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.req), &req))
+			o := newTestBedrockResponsesTranslator(nil)
+			hm, bm, err := o.RequestBody(&req)
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			require.Equal(t, "content-length", hm.SetHeaders[1].Header.Key)
+			require.JSONEq(t, tc.exp, string(bm.GetBody()))
+			require.Equal(t, req.Stream, o.stream)
+		})
+	}
+
+	t.Run("guardrail", func(t *testing.T) {
+		guardrail := &awsbedrock.GuardrailConfiguration{GuardrailIdentifier: ptr.To("gr"), GuardrailVersion: ptr.To("1")}
+		o := newTestBedrockResponsesTranslator(guardrail)
+		_, bm, err := o.RequestBody(&openai.ResponseRequest{Model: "m", Input: openai.ResponseInput{Value: "hi"}})
+		require.NoError(t, err)
+		var awsReq awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &awsReq))
+		require.Equal(t, guardrail, awsReq.GuardrailConfig)
+	})
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Responses_RequestBody_errors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		req    string
+		expErr string
+	}{
+		{
+			name:   "previous response",
+			req:    `{"model":"m","input":"hi","previous_response_id":"resp_1"}`,
+			expErr: "previous_response_id is not supported for AWS Bedrock",
+		},
+		{
+			name:   "item reference",
+			req:    `{"model":"m","input":[{"type":"item_reference","id":"msg_1"}]}`,
+			expErr: "failed to convert input item 0: unsupported input item type: item_reference",
+		},
+		{
+			name:   "file",
+			req:    `{"model":"m","input":[{"role":"user","content":[{"type":"input_file","file_id":"file_1"}]}]}`,
+			expErr: "unsupported content part type: input_file",
+		},
+		{
+			name:   "image file",
+			req:    `{"model":"m","input":[{"role":"user","content":[{"type":"input_image","file_id":"file_1"}]}]}`,
+			expErr: "image_url is required for input_image since file_id is not supported",
+		},
+		{
+			name:   "image url",
+			req:    `{"model":"m","input":[{"role":"user","content":[{"type":"input_image","image_url":"data:image/bmp;base64,AAAA"}]}]}`,
+			expErr: "unsupported image type: image/bmp",
+		},
+		{
+			name:   "invalid arguments",
+			req:    `{"model":"m","input":[{"type":"function_call","call_id":"c","name":"f","arguments":"{"}]}`,
+			expErr: "failed to convert input item 0",
+		},
+		{
+			name:   "unknown role",
+			req:    `{"model":"m","input":[{"role":"tool","content":"hi"}]}`,
+			expErr: "unsupported message role: tool",
+		},
+		{
+			name:   "built-in tool",
+			req:    `{"model":"m","input":"hi","tools":[{"type":"web_search_preview"}]}`,
+			expErr: "unsupported tool type: web_search_preview",
+		},
+		{
+			name:   "tool choice",
+			req:    `{"model":"m","input":"hi","tools":[{"type":"function","name":"f"}],"tool_choice":{"type":"file_search"}}`,
+			expErr: "unsupported tool choice",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ResponseRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.req), &req))
+			_, _, err := newTestBedrockResponsesTranslator(nil).RequestBody(&req)
+			require.ErrorContains(t, err, tc.expErr)
+			require.Equal(t, aigwerrors.ClientError, aigwerrors.TypeOf(err))
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Responses_ResponseHeaders(t *testing.T) {
+	o := newTestBedrockResponsesTranslator(nil)
+	hm, err := o.ResponseHeaders(map[string]string{"content-type": "application/vnd.amazon.eventstream"})
+	require.NoError(t, err)
+	require.Nil(t, hm)
+
+	o.stream = true
+	hm, err = o.ResponseHeaders(map[string]string{"content-type": "application/vnd.amazon.eventstream"})
+	require.NoError(t, err)
+	require.Len(t, hm.SetHeaders, 1)
+	require.Equal(t, "text/event-stream", hm.SetHeaders[0].Header.Value)
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Responses_ResponseBody(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		body     string
+		exp      string
+		expUsage LLMTokenUsage
+	}{
+		{
+			name: "text and tool use",
+			body: `{"output":{"message":{"role":"assistant","content":[{"text":"Let me check."},
+{"toolUse":{"name":"get_weather","input":{"city":"Tokyo"},"toolUseId":"tooluse_1"}}]}},
+"stopReason":"tool_use","usage":{"inputTokens":10,"outputTokens":20,"totalTokens":30}}`,
+			exp: `{"id":"resp_1","object":"response","created_at":1741476542,"model":"m","status":"completed","output":[
+{"type":"message","id":"msg_2","status":"completed","role":"assistant","content":[{"type":"output_text","text":"Let me check."}]},
+{"type":"function_call","id":"fc_3","status":"completed","call_id":"tooluse_1","name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"}],
+"usage":{"input_tokens":10,"output_tokens":20,"total_tokens":30}}`,
+			expUsage: LLMTokenUsage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30},
+		},
+		{
+			name: "max tokens",
+			body: `{"output":{"message":{"role":"assistant","content":[{"text":"a"},{"text":"b"}]}},"stopReason":"max_tokens"}`,
+			exp: `{"id":"resp_1","object":"response","created_at":1741476542,"model":"m","status":"incomplete","output":[
+{"type":"message","id":"msg_2","status":"completed","role":"assistant","content":[
+{"type":"output_text","text":"a"},{"type":"output_text","text":"b"}]}],
+"incomplete_details":{"reason":"max_output_tokens"}}`,
+		},
+		{
+			name: "guardrail",
+			body: `{"output":{"message":{"role":"assistant","content":[]}},"stopReason":"guardrail_intervened"}`,
+			exp: `{"id":"resp_1","object":"response","created_at":1741476542,"model":"m","status":"incomplete","output":[],
+"incomplete_details":{"reason":"content_filter"}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := newTestBedrockResponsesTranslator(nil)
+			o.model = "m"
+			hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(tc.body), true)
+			require.NoError(t, err)
+			require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+			require.JSONEq(t, tc.exp, string(bm.GetBody()))
+			require.Equal(t, tc.expUsage, usage)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		o := newTestBedrockResponsesTranslator(nil)
+		_, bm, _, err := o.ResponseBody(map[string]string{
+			":status": "400", "content-type": "application/json", "x-amzn-errortype": "ValidationException",
+		}, strings.NewReader(`{"message":"bad"}`), true)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"error","error":{"type":"invalid_request_error","message":"bad","code":"ValidationException"}}`, string(bm.GetBody()))
+	})
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Responses_Streaming_ResponseBody(t *testing.T) {
+	o := newTestBedrockResponsesTranslator(nil)
+	o.stream = true
+	o.model = "m"
+	buf, err := base64.StdEncoding.DecodeString(base64RealStreamingEvents)
+	require.NoError(t, err)
+
+	var out []byte
+	var usage LLMTokenUsage
+	for i := 0; i < len(buf); i++ {
+		_, bm, tokenUsage, err := o.ResponseBody(nil, bytes.NewBuffer([]byte{buf[i]}), i == len(buf)-1)
+		require.NoError(t, err)
+		out = append(out, bm.GetBody()...)
+		if tokenUsage != (LLMTokenUsage{}) {
+			usage = tokenUsage
+		}
+	}
+	require.Equal(t, LLMTokenUsage{InputTokens: 386, OutputTokens: 75, TotalTokens: 461}, usage)
+
+	var types []string
+	var last openai.ResponseStreamEvent
+	for i, sse := range strings.Split(strings.TrimSuffix(string(out), "\n\n"), "\n\n") {
+		lines := strings.Split(sse, "\n")
+		require.Len(t, lines, 2)
+		var event openai.ResponseStreamEvent
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event))
+		require.Equal(t, "event: "+event.Type, lines[0])
+		require.Equal(t, i, event.SequenceNumber)
+		if len(types) == 0 || types[len(types)-1] != event.Type {
+			types = append(types, event.Type)
+		}
+		last = event
+	}
+	require.Equal(t, []string{
+		openai.ResponseStreamEventTypeCreated,
+		openai.ResponseStreamEventTypeInProgress,
+		openai.ResponseStreamEventTypeOutputItemAdded,
+		openai.ResponseStreamEventTypeContentPartAdded,
+		openai.ResponseStreamEventTypeOutputTextDelta,
+		openai.ResponseStreamEventTypeOutputTextDone,
+		openai.ResponseStreamEventTypeContentPartDone,
+		openai.ResponseStreamEventTypeOutputItemDone,
+		openai.ResponseStreamEventTypeOutputItemAdded,
+		openai.ResponseStreamEventTypeFunctionCallArgumentsDelta,
+		openai.ResponseStreamEventTypeFunctionCallArgumentsDone,
+		openai.ResponseStreamEventTypeOutputItemDone,
+		openai.ResponseStreamEventTypeCompleted,
+	}, types)
+
+	resp, err := json.Marshal(last.Response)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"resp_1","object":"response","created_at":1741476542,"model":"m","status":"completed","output":[
+{"type":"message","id":"msg_2","status":"completed","role":"assistant","content":[{"type":"output_text",
+"text":"To calculate the cosine of 7, we can use the \"cosine\" function that is available to us. Let's use this function to get the result."}]},
+{"type":"function_call","id":"fc_3","status":"completed","call_id":"tooluse_QklrEHKjRu6Oc4BQUfy7ZQ","name":"cosine","arguments":"{\"x\": 7}"}],
+"usage":{"input_tokens":386,"output_tokens":75,"total_tokens":461}}`, string(resp))
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1Responses_Streaming_endOfStream(t *testing.T) {
+	o := newTestBedrockResponsesTranslator(nil)
+	o.model = "m"
+	var out []byte
+	for _, event := range []awsbedrock.ConverseStreamEvent{
+		{Role: ptr.To("assistant")},
+		{Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{Text: ptr.To("hi")}},
+		{StopReason: ptr.To(awsbedrock.StopReasonMaxTokens)},
+	} {
+		var err error
+		out, err = o.appendStreamEvents(out, &event)
+		require.NoError(t, err)
+	}
+	require.False(t, o.terminated)
+	out, err := o.appendTerminalEvent(out)
+	require.NoError(t, err)
+	require.True(t, o.terminated)
+
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n\n"), "\n\n")
+	last := lines[len(lines)-1]
+	require.True(t, strings.HasPrefix(last, "event: "+openai.ResponseStreamEventTypeIncomplete+"\n"))
+	require.Contains(t, last, `"incomplete_details":{"reason":"max_output_tokens"}`)
+}
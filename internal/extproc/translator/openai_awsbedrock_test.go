@@ -739,6 +739,31 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody(t *testing.T)
 	}
 }
 
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_guardrail(t *testing.T) {
+	guardrail := &awsbedrock.GuardrailConfiguration{
+		GuardrailIdentifier: ptr.To("some-guardrail"),
+		GuardrailVersion:    ptr.To("1"),
+		Trace:               ptr.To("enabled"),
+	}
+	o := NewChatCompletionOpenAIToAWSBedrockTranslator(guardrail)
+	_, bm, err := o.RequestBody(&openai.ChatCompletionRequest{
+		Model: "some-model",
+		Messages: []openai.ChatCompletionMessageParamUnion{{
+			Value: openai.ChatCompletionUserMessageParam{Content: openai.StringOrUserRoleContentUnion{Value: "hi"}},
+			Type:  openai.ChatMessageRoleUser,
+		}},
+	})
+	require.NoError(t, err)
+
+	var awsReq awsbedrock.ConverseInput
+	require.NoError(t, json.Unmarshal(bm.GetBody(), &awsReq))
+	require.Equal(t, guardrail, awsReq.GuardrailConfig)
+
+	// The intervention of the guardrail is reported as the content filter.
+	require.Equal(t, openai.ChatCompletionChoicesFinishReasonContentFilter,
+		o.(*openAIToAWSBedrockTranslatorV1ChatCompletion).bedrockStopReasonToOpenAIStopReason(ptr.To(awsbedrock.StopReasonGuardrailIntervened)))
+}
+
 func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseHeaders(t *testing.T) {
 	t.Run("streaming", func(t *testing.T) {
 		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true}
@@ -853,7 +878,7 @@ func TestOpenAIToAWSBedrockTranslator_ResponseError(t *testing.T) {
 			output: openai.Error{
 				Type: "error",
 				Error: openai.ErrorType{
-					Type:    awsBedrockBackendError,
+					Type:    "server_error",
 					Code:    ptr.To("503"),
 					Message: "service not available",
 				},
@@ -870,8 +895,8 @@ func TestOpenAIToAWSBedrockTranslator_ResponseError(t *testing.T) {
 			output: openai.Error{
 				Type: "error",
 				Error: openai.ErrorType{
-					Type:    "ThrottledException",
-					Code:    ptr.To("429"),
+					Type:    "rate_limit_error",
+					Code:    ptr.To("ThrottledException"),
 					Message: "aws bedrock rate limit exceeded",
 				},
 			},
@@ -892,8 +917,9 @@ func TestOpenAIToAWSBedrockTranslator_ResponseError(t *testing.T) {
 			require.NotNil(t, newBody)
 			require.NotNil(t, hm)
 			require.NotNil(t, hm.SetHeaders)
-			require.Len(t, hm.SetHeaders, 1)
+			require.Len(t, hm.SetHeaders, 2)
 			require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, "content-type", hm.SetHeaders[1].Header.Key)
 			require.Equal(t, strconv.Itoa(len(newBody)), string(hm.SetHeaders[0].Header.RawValue))
 
 			var openAIError openai.Error
@@ -0,0 +1,45 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewEmbeddingOpenAIToAzureOpenAITranslator implements [Factory] for OpenAI to Azure OpenAI embeddings translation.
+// Except RequestBody method requires modification to satisfy Microsoft Azure OpenAI spec
+// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#embeddings, other interface methods
+// are identical to NewEmbeddingOpenAIToOpenAITranslator's interface implementations.
+func NewEmbeddingOpenAIToAzureOpenAITranslator(apiVersion string) OpenAIEmbeddingTranslator {
+	return &openAIToAzureOpenAITranslatorV1Embedding{apiVersion: apiVersion}
+}
+
+type openAIToAzureOpenAITranslatorV1Embedding struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1Embedding
+}
+
+// RequestBody implements [OpenAIEmbeddingTranslator.RequestBody].
+func (o *openAIToAzureOpenAITranslatorV1Embedding) RequestBody(req *openai.EmbeddingRequest) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	// Assume deployment_id is same as model name.
+	pathTemplate := "/openai/deployments/%s/embeddings?api-version=%s"
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{
+				Key:      ":path",
+				RawValue: []byte(fmt.Sprintf(pathTemplate, req.Model, o.apiVersion)),
+			}},
+		},
+	}
+	return headerMutation, nil, nil
+}
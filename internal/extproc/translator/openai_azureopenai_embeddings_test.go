@@ -0,0 +1,24 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToAzureOpenAITranslatorV1Embedding_RequestBody(t *testing.T) {
+	o := NewEmbeddingOpenAIToAzureOpenAITranslator("some-version")
+	hm, bm, err := o.RequestBody(&openai.EmbeddingRequest{Model: "foo-bar-ai", Input: openai.EmbeddingRequestInput{Value: "hello"}})
+	require.NoError(t, err)
+	require.Nil(t, bm)
+	require.NotNil(t, hm)
+	require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+	require.Equal(t, "/openai/deployments/foo-bar-ai/embeddings?api-version=some-version", string(hm.SetHeaders[0].Header.RawValue))
+}
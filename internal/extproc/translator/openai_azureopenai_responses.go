@@ -0,0 +1,43 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"fmt"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewResponsesOpenAIToAzureOpenAITranslator implements [Factory] for OpenAI to Azure OpenAI responses translations.
+// Azure OpenAI takes the deployment name in the model field of the request body for the Responses API
+// https://learn.microsoft.com/en-us/azure/ai-services/openai/how-to/responses, so only the path is rewritten.
+func NewResponsesOpenAIToAzureOpenAITranslator(apiVersion string) OpenAIResponsesTranslator {
+	return &openAIToAzureOpenAITranslatorV1Responses{apiVersion: apiVersion}
+}
+
+type openAIToAzureOpenAITranslatorV1Responses struct {
+	apiVersion string
+	openAIToOpenAITranslatorV1Responses
+}
+
+// RequestBody implements [OpenAIResponsesTranslator.RequestBody].
+func (o *openAIToAzureOpenAITranslatorV1Responses) RequestBody(req *openai.ResponseRequest) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{
+				Key:      ":path",
+				RawValue: []byte(fmt.Sprintf("/openai/responses?api-version=%s", o.apiVersion)),
+			}},
+		},
+	}
+	o.stream = req.Stream
+	return headerMutation, nil, nil
+}
@@ -0,0 +1,543 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/gcp"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewChatCompletionOpenAIToGCPVertexAITranslator implements [Factory] for OpenAI to GCP Vertex AI Gemini translation.
+//
+// The translated path is relative to the model resource, i.e. "publishers/google/models/{model}:generateContent".
+// The project and location prefix is added by the GCP backend auth handler since they are part of the
+// BackendSecurityPolicy.
+func NewChatCompletionOpenAIToGCPVertexAITranslator() OpenAIChatCompletionTranslator {
+	return &openAIToGCPVertexAITranslatorV1ChatCompletion{}
+}
+
+// openAIToGCPVertexAITranslatorV1ChatCompletion implements [OpenAIChatCompletionTranslator] for /v1/chat/completions.
+type openAIToGCPVertexAITranslatorV1ChatCompletion struct {
+	stream   bool
+	buffered []byte
+	// usage is the latest usage metadata received in the streaming response, which is cumulative.
+	usage gcp.UsageMetadata
+	// toolCallIndex is the index of the next tool call in the streaming response.
+	toolCallIndex int
+}
+
+// RequestBody implements [OpenAIChatCompletionTranslator.RequestBody].
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) RequestBody(openAIReq *openai.ChatCompletionRequest) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.stream = openAIReq.Stream
+	var pathTemplate string
+	if openAIReq.Stream {
+		pathTemplate = "publishers/google/models/%s:streamGenerateContent?alt=sse"
+	} else {
+		pathTemplate = "publishers/google/models/%s:generateContent"
+	}
+
+	req := gcp.GenerateContentRequest{GenerationConfig: openAIToGCPGenerationConfig(openAIReq)}
+	if err = o.openAIMessagesToGCPContents(openAIReq, &req); err != nil {
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError, err)
+	}
+	if err = o.openAIToolsToGCPTools(openAIReq, &req); err != nil {
+		return nil, nil, aigwerrors.Wrap(aigwerrors.ClientError, err)
+	}
+
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte(fmt.Sprintf(pathTemplate, openAIReq.Model))}},
+		},
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(req); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+// openAIToGCPGenerationConfig converts the sampling parameters of the OpenAI request to the generation config.
+func openAIToGCPGenerationConfig(openAIReq *openai.ChatCompletionRequest) *gcp.GenerationConfig {
+	config := &gcp.GenerationConfig{
+		Temperature:      openAIReq.Temperature,
+		TopP:             openAIReq.TopP,
+		CandidateCount:   openAIReq.N,
+		MaxOutputTokens:  openAIReq.MaxTokens,
+		PresencePenalty:  openAIReq.PresencePenalty,
+		FrequencyPenalty: openAIReq.FrequencyPenalty,
+		Seed:             openAIReq.Seed,
+	}
+	for _, s := range openAIReq.Stop {
+		if s != nil {
+			config.StopSequences = append(config.StopSequences, *s)
+		}
+	}
+	if f := openAIReq.ResponseFormat; f != nil &&
+		(f.Type == openai.ChatCompletionResponseFormatTypeJSONObject || f.Type == openai.ChatCompletionResponseFormatTypeJSONSchema) {
+		config.ResponseMimeType = jsonContentType
+	}
+	return config
+}
+
+// openAIMessagesToGCPContents converts the OpenAI messages to the Gemini contents and the system instruction.
+// Consecutive messages of the same role are merged into a single content.
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) openAIMessagesToGCPContents(openAIReq *openai.ChatCompletionRequest,
+	req *gcp.GenerateContentRequest,
+) error {
+	// toolNames maps the tool call ID to the function name since Gemini requires the name in the function response
+	// while the OpenAI tool message only carries the tool call ID.
+	toolNames := make(map[string]string)
+	appendContent := func(role string, parts []gcp.Part) {
+		if l := len(req.Contents); l > 0 && req.Contents[l-1].Role == role {
+			req.Contents[l-1].Parts = append(req.Contents[l-1].Parts, parts...)
+			return
+		}
+		req.Contents = append(req.Contents, gcp.Content{Role: role, Parts: parts})
+	}
+	appendSystemInstruction := func(content *openai.StringOrArray, role string) error {
+		parts, err := stringOrArrayToGCPTextParts(content)
+		if err != nil {
+			return fmt.Errorf("unexpected content type for %s message: %w", role, err)
+		}
+		if req.SystemInstruction == nil {
+			req.SystemInstruction = &gcp.Content{}
+		}
+		req.SystemInstruction.Parts = append(req.SystemInstruction.Parts, parts...)
+		return nil
+	}
+	for i := range openAIReq.Messages {
+		msg := &openAIReq.Messages[i]
+		switch msg.Type {
+		case openai.ChatMessageRoleSystem:
+			m := msg.Value.(openai.ChatCompletionSystemMessageParam)
+			if err := appendSystemInstruction(&m.Content, msg.Type); err != nil {
+				return err
+			}
+		case openai.ChatMessageRoleDeveloper:
+			m := msg.Value.(openai.ChatCompletionDeveloperMessageParam)
+			if err := appendSystemInstruction(&m.Content, msg.Type); err != nil {
+				return err
+			}
+		case openai.ChatMessageRoleUser:
+			m := msg.Value.(openai.ChatCompletionUserMessageParam)
+			parts, err := openAIUserContentToGCPParts(&m)
+			if err != nil {
+				return err
+			}
+			appendContent(gcp.RoleUser, parts)
+		case openai.ChatMessageRoleAssistant:
+			m := msg.Value.(openai.ChatCompletionAssistantMessageParam)
+			var parts []gcp.Part
+			if v, ok := m.Content.Value.(string); ok && len(v) > 0 {
+				parts = append(parts, gcp.Part{Text: ptr.To(v)})
+			} else if content, ok := m.Content.Value.(openai.ChatCompletionAssistantMessageParamContent); ok {
+				if content.Type == openai.ChatCompletionAssistantMessageParamContentTypeRefusal {
+					parts = append(parts, gcp.Part{Text: content.Refusal})
+				} else if content.Text != nil {
+					parts = append(parts, gcp.Part{Text: content.Text})
+				}
+			}
+			for j := range m.ToolCalls {
+				toolCall := &m.ToolCalls[j]
+				args, err := unmarshalToolCallArguments(toolCall.Function.Arguments)
+				if err != nil {
+					return err
+				}
+				toolNames[toolCall.ID] = toolCall.Function.Name
+				parts = append(parts, gcp.Part{FunctionCall: &gcp.FunctionCall{Name: toolCall.Function.Name, Args: args}})
+			}
+			appendContent(gcp.RoleModel, parts)
+		case openai.ChatMessageRoleTool:
+			m := msg.Value.(openai.ChatCompletionToolMessageParam)
+			name, ok := toolNames[m.ToolCallID]
+			if !ok {
+				return fmt.Errorf("tool message refers to unknown tool call: %s", m.ToolCallID)
+			}
+			textParts, err := stringOrArrayToGCPTextParts(&m.Content)
+			if err != nil {
+				return fmt.Errorf("unexpected content type for tool message: %w", err)
+			}
+			var text strings.Builder
+			for _, p := range textParts {
+				text.WriteString(*p.Text)
+			}
+			// The function response must be a JSON object, so non-object results are wrapped.
+			var response map[string]any
+			if json.Unmarshal([]byte(text.String()), &response) != nil || response == nil {
+				response = map[string]any{"content": text.String()}
+			}
+			appendContent(gcp.RoleUser, []gcp.Part{{FunctionResponse: &gcp.FunctionResponse{Name: name, Response: response}}})
+		default:
+			return fmt.Errorf("unexpected role: %s", msg.Type)
+		}
+	}
+	return nil
+}
+
+// stringOrArrayToGCPTextParts converts the content of system, developer and tool messages to text parts.
+func stringOrArrayToGCPTextParts(content *openai.StringOrArray) ([]gcp.Part, error) {
+	switch v := content.Value.(type) {
+	case string:
+		return []gcp.Part{{Text: ptr.To(v)}}, nil
+	case []openai.ChatCompletionContentPartTextParam:
+		parts := make([]gcp.Part, 0, len(v))
+		for i := range v {
+			parts = append(parts, gcp.Part{Text: ptr.To(v[i].Text)})
+		}
+		return parts, nil
+	default:
+		return nil, fmt.Errorf("%T", content.Value)
+	}
+}
+
+// openAIUserContentToGCPParts converts the content of the user message to the Gemini parts.
+func openAIUserContentToGCPParts(m *openai.ChatCompletionUserMessageParam) ([]gcp.Part, error) {
+	if v, ok := m.Content.Value.(string); ok {
+		return []gcp.Part{{Text: ptr.To(v)}}, nil
+	}
+	contents, ok := m.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+	if !ok {
+		return nil, fmt.Errorf("unexpected content type")
+	}
+	parts := make([]gcp.Part, 0, len(contents))
+	for i := range contents {
+		contentPart := &contents[i]
+		switch {
+		case contentPart.TextContent != nil:
+			parts = append(parts, gcp.Part{Text: ptr.To(contentPart.TextContent.Text)})
+		case contentPart.ImageContent != nil:
+			url := contentPart.ImageContent.ImageURL.URL
+			if strings.HasPrefix(url, "data:") {
+				contentType, b, err := parseDataURI(url)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse image URL: %s %w", url, err)
+				}
+				parts = append(parts, gcp.Part{InlineData: &gcp.Blob{MimeType: contentType, Data: base64.StdEncoding.EncodeToString(b)}})
+				continue
+			}
+			// The MIME type is required for the file data, so we infer it from the extension of the URL.
+			mimeType := mime.TypeByExtension(path.Ext(strings.SplitN(url, "?", 2)[0]))
+			if mimeType == "" {
+				mimeType = "image/jpeg"
+			}
+			parts = append(parts, gcp.Part{FileData: &gcp.FileData{MimeType: mimeType, FileURI: url}})
+		}
+	}
+	return parts, nil
+}
+
+// openAIToolsToGCPTools converts the OpenAI tools and tool_choice to the Gemini function declarations and tool config.
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) openAIToolsToGCPTools(openAIReq *openai.ChatCompletionRequest,
+	req *gcp.GenerateContentRequest,
+) error {
+	var declarations []gcp.FunctionDeclaration
+	for i := range openAIReq.Tools {
+		tool := &openAIReq.Tools[i]
+		if tool.Function == nil {
+			continue
+		}
+		declarations = append(declarations, gcp.FunctionDeclaration{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+		})
+	}
+	if len(declarations) > 0 {
+		req.Tools = []gcp.Tool{{FunctionDeclarations: declarations}}
+	}
+
+	var config gcp.FunctionCallingConfig
+	switch toolChoice := openAIReq.ToolChoice.(type) {
+	case nil:
+		return nil
+	case string:
+		switch toolChoice {
+		case "auto":
+			config.Mode = gcp.FunctionCallingModeAuto
+		case "required":
+			config.Mode = gcp.FunctionCallingModeAny
+		case "none":
+			config.Mode = gcp.FunctionCallingModeNone
+		default:
+			return fmt.Errorf("unexpected tool_choice: %s", toolChoice)
+		}
+	case openai.ToolChoice:
+		config.Mode = gcp.FunctionCallingModeAny
+		config.AllowedFunctionNames = []string{toolChoice.Function.Name}
+	case map[string]any:
+		// This is the case when the tool_choice is unmarshalled from the JSON request body.
+		function, _ := toolChoice["function"].(map[string]any)
+		name, _ := function["name"].(string)
+		if name == "" {
+			return fmt.Errorf("tool_choice.function.name is required")
+		}
+		config.Mode = gcp.FunctionCallingModeAny
+		config.AllowedFunctionNames = []string{name}
+	default:
+		return fmt.Errorf("unexpected type: %T", openAIReq.ToolChoice)
+	}
+	req.ToolConfig = &gcp.ToolConfig{FunctionCallingConfig: &config}
+	return nil
+}
+
+// ResponseHeaders implements [OpenAIChatCompletionTranslator.ResponseHeaders].
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) ResponseHeaders(map[string]string) (
+	headerMutation *extprocv3.HeaderMutation, err error,
+) {
+	return nil, nil
+}
+
+// ResponseError implements [OpenAIChatCompletionTranslator.ResponseError].
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	// The streaming endpoint returns the error response as a JSON array.
+	trimmed := bytes.TrimSuffix(bytes.TrimPrefix(bytes.TrimSpace(buf), []byte("[")), []byte("]"))
+	var gcpError gcp.ErrorResponse
+	if strings.HasPrefix(respHeaders[contentTypeHeaderName], jsonContentType) && json.Unmarshal(trimmed, &gcpError) == nil {
+		return backendErrorResponse(respHeaders, gcpError.Error.Status, gcpError.Error.Message)
+	}
+	return backendErrorResponse(respHeaders, "", string(buf))
+}
+
+// ResponseBody implements [OpenAIChatCompletionTranslator.ResponseBody].
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if statusStr, ok := respHeaders[statusHeaderName]; ok {
+		var status int
+		if status, err = strconv.Atoi(statusStr); err == nil {
+			if !isGoodStatusCode(status) {
+				headerMutation, bodyMutation, err = o.ResponseError(respHeaders, body)
+				return headerMutation, bodyMutation, LLMTokenUsage{}, err
+			}
+		}
+	}
+	if o.stream {
+		return o.streamResponseBody(body, endOfStream)
+	}
+
+	var resp gcp.GenerateContentResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	openAIResp := openai.ChatCompletionResponse{
+		Object:  "chat.completion",
+		Choices: make([]openai.ChatCompletionResponseChoice, 0, len(resp.Candidates)),
+	}
+	for i := range resp.Candidates {
+		candidate := &resp.Candidates[i]
+		choice := openai.ChatCompletionResponseChoice{
+			Index:        int64(candidate.Index),
+			Message:      openai.ChatCompletionResponseChoiceMessage{Role: openai.ChatMessageRoleAssistant},
+			FinishReason: gcpFinishReasonToOpenAIFinishReason(candidate.FinishReason),
+		}
+		var text strings.Builder
+		for j := range candidate.Content.Parts {
+			part := &candidate.Content.Parts[j]
+			switch {
+			case part.Text != nil:
+				text.WriteString(*part.Text)
+			case part.FunctionCall != nil:
+				toolCall, err := gcpFunctionCallToOpenAIToolCall(part.FunctionCall)
+				if err != nil {
+					return nil, nil, tokenUsage, err
+				}
+				choice.Message.ToolCalls = append(choice.Message.ToolCalls, toolCall)
+			}
+		}
+		if text.Len() > 0 {
+			choice.Message.Content = ptr.To(text.String())
+		}
+		if len(choice.Message.ToolCalls) > 0 {
+			choice.FinishReason = openai.ChatCompletionChoicesFinishReasonToolCalls
+		}
+		openAIResp.Choices = append(openAIResp.Choices, choice)
+	}
+	if resp.UsageMetadata != nil {
+		tokenUsage = gcpUsageToLLMTokenUsage(resp.UsageMetadata)
+		openAIResp.Usage = openai.ChatCompletionResponseUsage{
+			PromptTokens:     resp.UsageMetadata.PromptTokenCount,
+			CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      resp.UsageMetadata.TotalTokenCount,
+		}
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(openAIResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// streamResponseBody converts the buffered server-sent events of streamGenerateContent to the OpenAI chat completion chunks.
+// Gemini does not send a terminal event, so the usage chunk and [DONE] are emitted at the end of the stream.
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) streamResponseBody(body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+	}
+	// Normalize after appending since a CRLF might be split across two chunks.
+	o.buffered = bytes.ReplaceAll(append(o.buffered, buf...), []byte("\r\n"), []byte("\n"))
+
+	mut := &extprocv3.BodyMutation_Body{}
+	appendChunk := func(chunk *openai.ChatCompletionResponseChunk) error {
+		chunkBytes, err := json.Marshal(chunk)
+		if err != nil {
+			return fmt.Errorf("failed to marshal chunk: %w", err)
+		}
+		mut.Body = append(mut.Body, dataPrefix...)
+		mut.Body = append(mut.Body, chunkBytes...)
+		mut.Body = append(mut.Body, []byte("\n\n")...)
+		return nil
+	}
+	for {
+		i := bytes.Index(o.buffered, []byte("\n\n"))
+		if i == -1 {
+			break
+		}
+		rawEvent := o.buffered[:i]
+		o.buffered = o.buffered[i+2:]
+		if !bytes.HasPrefix(rawEvent, dataPrefix) {
+			continue
+		}
+		var resp gcp.GenerateContentResponse
+		if err = json.Unmarshal(bytes.TrimPrefix(rawEvent, dataPrefix), &resp); err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		chunk, err := o.convertStreamResponse(&resp)
+		if err != nil {
+			return nil, nil, tokenUsage, err
+		}
+		if len(chunk.Choices) > 0 {
+			if err = appendChunk(&chunk); err != nil {
+				return nil, nil, tokenUsage, err
+			}
+		}
+	}
+	if endOfStream {
+		tokenUsage = gcpUsageToLLMTokenUsage(&o.usage)
+		if err = appendChunk(&openai.ChatCompletionResponseChunk{
+			Object: "chat.completion.chunk",
+			Usage: &openai.ChatCompletionResponseUsage{
+				PromptTokens:     o.usage.PromptTokenCount,
+				CompletionTokens: o.usage.CandidatesTokenCount,
+				TotalTokens:      o.usage.TotalTokenCount,
+			},
+		}); err != nil {
+			return nil, nil, tokenUsage, err
+		}
+		mut.Body = append(mut.Body, []byte("data: [DONE]\n\n")...)
+	}
+	return &extprocv3.HeaderMutation{}, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// convertStreamResponse converts a single event of the streaming response to an [openai.ChatCompletionResponseChunk].
+func (o *openAIToGCPVertexAITranslatorV1ChatCompletion) convertStreamResponse(resp *gcp.GenerateContentResponse) (
+	openai.ChatCompletionResponseChunk, error,
+) {
+	if resp.UsageMetadata != nil {
+		o.usage = *resp.UsageMetadata
+	}
+	chunk := openai.ChatCompletionResponseChunk{Object: "chat.completion.chunk"}
+	for i := range resp.Candidates {
+		candidate := &resp.Candidates[i]
+		delta := &openai.ChatCompletionResponseChunkChoiceDelta{Role: openai.ChatMessageRoleAssistant}
+		var text strings.Builder
+		for j := range candidate.Content.Parts {
+			part := &candidate.Content.Parts[j]
+			switch {
+			case part.Text != nil:
+				text.WriteString(*part.Text)
+			case part.FunctionCall != nil:
+				toolCall, err := gcpFunctionCallToOpenAIToolCall(part.FunctionCall)
+				if err != nil {
+					return chunk, err
+				}
+				toolCall.Index = ptr.To(o.toolCallIndex)
+				o.toolCallIndex++
+				delta.ToolCalls = append(delta.ToolCalls, toolCall)
+			}
+		}
+		if text.Len() > 0 || len(delta.ToolCalls) == 0 {
+			delta.Content = ptr.To(text.String())
+		}
+		choice := openai.ChatCompletionResponseChunkChoice{Delta: delta}
+		if candidate.FinishReason != "" {
+			choice.FinishReason = gcpFinishReasonToOpenAIFinishReason(candidate.FinishReason)
+			if o.toolCallIndex > 0 {
+				choice.FinishReason = openai.ChatCompletionChoicesFinishReasonToolCalls
+			}
+		}
+		chunk.Choices = append(chunk.Choices, choice)
+	}
+	return chunk, nil
+}
+
+// gcpFunctionCallToOpenAIToolCall converts the Gemini function call to the OpenAI tool call.
+// Gemini does not assign an ID to the function call, so a random one is generated.
+func gcpFunctionCallToOpenAIToolCall(fc *gcp.FunctionCall) (openai.ChatCompletionMessageToolCallParam, error) {
+	args, err := json.Marshal(fc.Args)
+	if err != nil {
+		return openai.ChatCompletionMessageToolCallParam{}, fmt.Errorf("failed to marshal function call args: %w", err)
+	}
+	var id [12]byte
+	_, _ = rand.Read(id[:])
+	return openai.ChatCompletionMessageToolCallParam{
+		ID:       "call_" + hex.EncodeToString(id[:]),
+		Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: fc.Name, Arguments: string(args)},
+		Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+	}, nil
+}
+
+// gcpUsageToLLMTokenUsage converts the Gemini usage metadata to [LLMTokenUsage].
+func gcpUsageToLLMTokenUsage(usage *gcp.UsageMetadata) LLMTokenUsage {
+	return LLMTokenUsage{
+		InputTokens:  uint32(usage.PromptTokenCount),     //nolint:gosec
+		OutputTokens: uint32(usage.CandidatesTokenCount), //nolint:gosec
+		TotalTokens:  uint32(usage.TotalTokenCount),      //nolint:gosec
+	}
+}
+
+// gcpFinishReasonToOpenAIFinishReason converts the Gemini finish reason to the OpenAI finish reason.
+func gcpFinishReasonToOpenAIFinishReason(reason string) openai.ChatCompletionChoicesFinishReason {
+	switch reason {
+	case gcp.FinishReasonMaxTokens:
+		return openai.ChatCompletionChoicesFinishReasonLength
+	case gcp.FinishReasonSafety:
+		return openai.ChatCompletionChoicesFinishReasonContentFilter
+	default:
+		return openai.ChatCompletionChoicesFinishReasonStop
+	}
+}
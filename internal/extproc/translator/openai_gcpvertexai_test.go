@@ -0,0 +1,208 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_RequestBody(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   string
+		expPath string
+		expBody string
+	}{
+		{
+			name: "system, user and parameters",
+			input: `{"model":"gemini-2.0-flash","messages":[{"role":"system","content":"be nice"},{"role":"user","content":"hi"}],` +
+				`"temperature":0.5,"top_p":0.9,"max_tokens":10,"stop":["a"],"n":2,"response_format":{"type":"json_object"}}`,
+			expPath: "publishers/google/models/gemini-2.0-flash:generateContent",
+			expBody: `{"contents":[{"role":"user","parts":[{"text":"hi"}]}],"systemInstruction":{"parts":[{"text":"be nice"}]},` +
+				`"generationConfig":{"temperature":0.5,"topP":0.9,"candidateCount":2,"maxOutputTokens":10,"stopSequences":["a"],"responseMimeType":"application/json"}}`,
+		},
+		{
+			name:    "stream",
+			input:   `{"model":"gemini-2.0-flash","stream":true,"messages":[{"role":"user","content":"hi"}]}`,
+			expPath: "publishers/google/models/gemini-2.0-flash:streamGenerateContent?alt=sse",
+			expBody: `{"contents":[{"role":"user","parts":[{"text":"hi"}]}],"generationConfig":{}}`,
+		},
+		{
+			name: "images",
+			input: `{"model":"gemini","messages":[{"role":"user","content":[{"type":"text","text":"what?"},` +
+				`{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}},` +
+				`{"type":"image_url","image_url":{"url":"gs://bucket/a.png"}}]}]}`,
+			expPath: "publishers/google/models/gemini:generateContent",
+			expBody: `{"contents":[{"role":"user","parts":[{"text":"what?"},{"inlineData":{"mimeType":"image/png","data":"aGVsbG8="}},` +
+				`{"fileData":{"mimeType":"image/png","fileUri":"gs://bucket/a.png"}}]}],"generationConfig":{}}`,
+		},
+		{
+			name: "function calling",
+			input: `{"model":"gemini","messages":[{"role":"user","content":"weather?"},` +
+				`{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}},` +
+				`{"id":"call_2","type":"function","function":{"name":"time","arguments":"{}"}}]},` +
+				`{"role":"tool","tool_call_id":"call_1","content":"{\"temp\":20}"},{"role":"tool","tool_call_id":"call_2","content":"noon"}],` +
+				`"tools":[{"type":"function","function":{"name":"weather","description":"get weather","parameters":{"type":"object"}}}],` +
+				`"tool_choice":{"type":"function","function":{"name":"weather"}}}`,
+			expPath: "publishers/google/models/gemini:generateContent",
+			expBody: `{"contents":[{"role":"user","parts":[{"text":"weather?"}]},` +
+				`{"role":"model","parts":[{"functionCall":{"name":"weather","args":{"city":"Paris"}}},{"functionCall":{"name":"time"}}]},` +
+				`{"role":"user","parts":[{"functionResponse":{"name":"weather","response":{"temp":20}}},` +
+				`{"functionResponse":{"name":"time","response":{"content":"noon"}}}]}],` +
+				`"tools":[{"functionDeclarations":[{"name":"weather","description":"get weather","parameters":{"type":"object"}}]}],` +
+				`"toolConfig":{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["weather"]}},"generationConfig":{}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.input), &req))
+			o := NewChatCompletionOpenAIToGCPVertexAITranslator().(*openAIToGCPVertexAITranslatorV1ChatCompletion)
+			hm, bm, err := o.RequestBody(&req)
+			require.NoError(t, err)
+			require.Equal(t, req.Stream, o.stream)
+
+			require.Len(t, hm.SetHeaders, 2)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			require.Equal(t, "content-length", hm.SetHeaders[1].Header.Key)
+			require.JSONEq(t, tc.expBody, string(bm.Mutation.(*extprocv3.BodyMutation_Body).Body))
+		})
+	}
+
+	t.Run("unknown tool call", func(t *testing.T) {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(`{"model":"gemini","messages":[{"role":"tool","tool_call_id":"call_1","content":"x"}]}`), &req))
+		_, _, err := NewChatCompletionOpenAIToGCPVertexAITranslator().RequestBody(&req)
+		require.ErrorContains(t, err, "tool message refers to unknown tool call: call_1")
+	})
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_ResponseBody(t *testing.T) {
+	o := NewChatCompletionOpenAIToGCPVertexAITranslator()
+	body := `{"candidates":[{"content":{"role":"model","parts":[{"text":"let me check"},` +
+		`{"functionCall":{"name":"weather","args":{"city":"Paris"}}}]},"finishReason":"STOP"}],` +
+		`"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5,"totalTokenCount":15}}`
+	hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(body), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}, usage)
+	require.Len(t, hm.SetHeaders, 1)
+
+	var resp openai.ChatCompletionResponse
+	require.NoError(t, json.Unmarshal(bm.Mutation.(*extprocv3.BodyMutation_Body).Body, &resp))
+	require.Len(t, resp.Choices, 1)
+	require.Equal(t, openai.ChatCompletionChoicesFinishReasonToolCalls, resp.Choices[0].FinishReason)
+	require.Equal(t, "let me check", *resp.Choices[0].Message.Content)
+	require.Len(t, resp.Choices[0].Message.ToolCalls, 1)
+	require.True(t, strings.HasPrefix(resp.Choices[0].Message.ToolCalls[0].ID, "call_"))
+	require.Equal(t, "weather", resp.Choices[0].Message.ToolCalls[0].Function.Name)
+	require.JSONEq(t, `{"city":"Paris"}`, resp.Choices[0].Message.ToolCalls[0].Function.Arguments)
+	require.Equal(t, 15, resp.Usage.TotalTokens)
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_Streaming_ResponseBody(t *testing.T) {
+	o := NewChatCompletionOpenAIToGCPVertexAITranslator()
+	_, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "gemini", Stream: true})
+	require.NoError(t, err)
+
+	events := "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hel\"}]}}],\"usageMetadata\":{\"promptTokenCount\":10}}\r\n\r\n" +
+		"data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"lo\"}]}}]}\r\n\r\n" +
+		"data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"functionCall\":{\"name\":\"weather\",\"args\":{\"city\":\"Paris\"}}}]},\"finishReason\":\"STOP\"}]," +
+		"\"usageMetadata\":{\"promptTokenCount\":10,\"candidatesTokenCount\":7,\"totalTokenCount\":17}}\r\n\r\n"
+
+	var results []byte
+	var usage LLMTokenUsage
+	for i := 0; i < len(events); i += 11 {
+		end := min(i+11, len(events))
+		_, bm, u, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(events[i:end]), end == len(events))
+		require.NoError(t, err)
+		results = append(results, bm.Mutation.(*extprocv3.BodyMutation_Body).Body...)
+		if u.TotalTokens > 0 {
+			usage = u
+		}
+	}
+	require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 7, TotalTokens: 17}, usage)
+
+	var chunks []openai.ChatCompletionResponseChunk
+	var done bool
+	for _, line := range bytes.Split(results, []byte("\n\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		data := bytes.TrimPrefix(line, []byte("data: "))
+		if string(data) == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk openai.ChatCompletionResponseChunk
+		require.NoError(t, json.Unmarshal(data, &chunk))
+		chunks = append(chunks, chunk)
+	}
+	require.True(t, done)
+	require.Len(t, chunks, 4)
+	require.Equal(t, "Hel", *chunks[0].Choices[0].Delta.Content)
+	require.Equal(t, "lo", *chunks[1].Choices[0].Delta.Content)
+	require.Equal(t, 0, *chunks[2].Choices[0].Delta.ToolCalls[0].Index)
+	require.Equal(t, "weather", chunks[2].Choices[0].Delta.ToolCalls[0].Function.Name)
+	require.Equal(t, openai.ChatCompletionChoicesFinishReasonToolCalls, chunks[2].Choices[0].FinishReason)
+	require.Equal(t, 17, chunks[3].Usage.TotalTokens)
+}
+
+func TestOpenAIToGCPVertexAITranslatorV1ChatCompletion_ResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		headers    map[string]string
+		body       string
+		expType    string
+		expCode    string
+		expMessage string
+	}{
+		{
+			name:       "json error",
+			headers:    map[string]string{":status": "400", "content-type": "application/json; charset=UTF-8"},
+			body:       `{"error":{"code":400,"message":"invalid model","status":"INVALID_ARGUMENT"}}`,
+			expType:    "invalid_request_error",
+			expCode:    "INVALID_ARGUMENT",
+			expMessage: "invalid model",
+		},
+		{
+			name:       "streaming json error",
+			headers:    map[string]string{":status": "429", "content-type": "application/json"},
+			body:       `[{"error":{"code":429,"message":"quota exceeded","status":"RESOURCE_EXHAUSTED"}}]`,
+			expType:    "rate_limit_error",
+			expCode:    "RESOURCE_EXHAUSTED",
+			expMessage: "quota exceeded",
+		},
+		{
+			name:       "non json error",
+			headers:    map[string]string{":status": "503", "content-type": "text/plain"},
+			body:       "service unavailable",
+			expType:    "server_error",
+			expCode:    "503",
+			expMessage: "service unavailable",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToGCPVertexAITranslator()
+			_, bm, usage, err := o.ResponseBody(tc.headers, strings.NewReader(tc.body), true)
+			require.NoError(t, err)
+			require.Equal(t, LLMTokenUsage{}, usage)
+
+			var openAIError openai.Error
+			require.NoError(t, json.Unmarshal(bm.Mutation.(*extprocv3.BodyMutation_Body).Body, &openAIError))
+			require.Equal(t, tc.expType, openAIError.Error.Type)
+			require.Equal(t, tc.expMessage, openAIError.Error.Message)
+			require.Equal(t, tc.expCode, *openAIError.Error.Code)
+		})
+	}
+}
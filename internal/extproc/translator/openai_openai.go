@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 
@@ -39,35 +40,26 @@ func (o *openAIToOpenAITranslatorV1ChatCompletion) RequestBody(req *openai.ChatC
 	return nil, nil, nil
 }
 
-// ResponseError implements [Translator.ResponseError]
+// ResponseError implements [OpenAIChatCompletionTranslator.ResponseError].
 // For OpenAI based backend we return the OpenAI error type as is.
 // If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
 func (o *openAIToOpenAITranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
 ) {
-	statusCode := respHeaders[statusHeaderName]
-	if v, ok := respHeaders[contentTypeHeaderName]; ok && v != jsonContentType {
-		var openaiError openai.Error
+	return openAIResponseError(respHeaders, body)
+}
+
+// openAIResponseError translates the non-JSON error body of an OpenAI compatible backend to the OpenAI error type.
+// JSON error bodies are assumed to be in the OpenAI error format already, so they are returned as is.
+func openAIResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	if v, ok := respHeaders[contentTypeHeaderName]; ok && !strings.HasPrefix(v, jsonContentType) {
 		buf, err := io.ReadAll(body)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read error body: %w", err)
 		}
-		openaiError = openai.Error{
-			Type: "error",
-			Error: openai.ErrorType{
-				Type:    openAIBackendError,
-				Message: string(buf),
-				Code:    &statusCode,
-			},
-		}
-		mut := &extprocv3.BodyMutation_Body{}
-		mut.Body, err = json.Marshal(openaiError)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
-		}
-		headerMutation = &extprocv3.HeaderMutation{}
-		setContentLength(headerMutation, mut.Body)
-		return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+		return backendErrorResponse(respHeaders, "", string(buf))
 	}
 	return nil, nil, nil
 }
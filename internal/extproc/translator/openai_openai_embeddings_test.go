@@ -0,0 +1,52 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToOpenAITranslatorV1Embedding_RequestBody(t *testing.T) {
+	o := NewEmbeddingOpenAIToOpenAITranslator()
+	hm, bm, err := o.RequestBody(&openai.EmbeddingRequest{Model: "text-embedding-3-small", Input: openai.EmbeddingRequestInput{Value: "hello"}})
+	require.NoError(t, err)
+	require.Nil(t, hm)
+	require.Nil(t, bm)
+}
+
+func TestOpenAIToOpenAITranslatorV1Embedding_ResponseBody(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToOpenAITranslator()
+		body := `{"object":"list","data":[{"object":"embedding","embedding":[0.1],"index":0}],"model":"m","usage":{"prompt_tokens":8,"total_tokens":8}}`
+		hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 8, TotalTokens: 8}, usage)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToOpenAITranslator()
+		_, _, _, err := o.ResponseBody(map[string]string{}, strings.NewReader("{"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+	t.Run("non-json error", func(t *testing.T) {
+		o := NewEmbeddingOpenAIToOpenAITranslator()
+		hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "503", "content-type": "text/plain"},
+			bytes.NewReader([]byte("service not available")), true)
+		require.NoError(t, err)
+		require.NotNil(t, hm)
+		require.Equal(t, LLMTokenUsage{}, usage)
+		require.JSONEq(t, `{"type":"error","error":{"type":"server_error","code":"503","message":"service not available"}}`,
+			string(bm.Mutation.(*extprocv3.BodyMutation_Body).Body))
+	})
+}
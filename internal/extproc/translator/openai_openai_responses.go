@@ -0,0 +1,113 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// NewResponsesOpenAIToOpenAITranslator implements [Factory] for OpenAI to OpenAI responses translation.
+func NewResponsesOpenAIToOpenAITranslator() OpenAIResponsesTranslator {
+	return &openAIToOpenAITranslatorV1Responses{}
+}
+
+// openAIToOpenAITranslatorV1Responses implements [OpenAIResponsesTranslator] for /v1/responses.
+type openAIToOpenAITranslatorV1Responses struct {
+	stream        bool
+	buffered      []byte
+	bufferingDone bool
+}
+
+// RequestBody implements [OpenAIResponsesTranslator.RequestBody].
+func (o *openAIToOpenAITranslatorV1Responses) RequestBody(req *openai.ResponseRequest) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	o.stream = req.Stream
+	return nil, nil, nil
+}
+
+// ResponseHeaders implements [OpenAIResponsesTranslator.ResponseHeaders].
+func (o *openAIToOpenAITranslatorV1Responses) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [OpenAIResponsesTranslator.ResponseBody].
+func (o *openAIToOpenAITranslatorV1Responses) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	if v, ok := respHeaders[statusHeaderName]; ok {
+		if v, err := strconv.Atoi(v); err == nil {
+			if !isGoodStatusCode(v) {
+				headerMutation, bodyMutation, err = openAIResponseError(respHeaders, body)
+				return headerMutation, bodyMutation, LLMTokenUsage{}, err
+			}
+		}
+	}
+	if o.stream {
+		if !o.bufferingDone {
+			buf, err := io.ReadAll(body)
+			if err != nil {
+				return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+			}
+			o.buffered = append(o.buffered, buf...)
+			tokenUsage = o.extractUsageFromBufferEvent()
+		}
+		return
+	}
+	var resp openai.Response
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage = responseUsageToLLMTokenUsage(resp.Usage)
+	return
+}
+
+// extractUsageFromBufferEvent extracts the token usage from the buffered events. The usage is only reported
+// in the terminal event of the response, i.e. response.completed, response.incomplete or response.failed.
+// Once the usage is extracted, bufferingDone is set to true.
+func (o *openAIToOpenAITranslatorV1Responses) extractUsageFromBufferEvent() (tokenUsage LLMTokenUsage) {
+	for {
+		i := bytes.IndexByte(o.buffered, '\n')
+		if i == -1 {
+			return
+		}
+		line := o.buffered[:i]
+		o.buffered = o.buffered[i+1:]
+		if !bytes.HasPrefix(line, dataPrefix) {
+			continue
+		}
+		var event openai.ResponseStreamEvent
+		if err := json.Unmarshal(bytes.TrimPrefix(line, dataPrefix), &event); err != nil {
+			continue
+		}
+		if event.Response != nil && event.Response.Usage != nil {
+			tokenUsage = responseUsageToLLMTokenUsage(event.Response.Usage)
+			o.bufferingDone = true
+			o.buffered = nil
+			return
+		}
+	}
+}
+
+// responseUsageToLLMTokenUsage converts the usage of the Responses API to [LLMTokenUsage]. The nil usage results in zero.
+func responseUsageToLLMTokenUsage(usage *openai.ResponseUsage) LLMTokenUsage {
+	if usage == nil {
+		return LLMTokenUsage{}
+	}
+	return LLMTokenUsage{
+		InputTokens:  uint32(usage.InputTokens),  //nolint:gosec
+		OutputTokens: uint32(usage.OutputTokens), //nolint:gosec
+		TotalTokens:  uint32(usage.TotalTokens),  //nolint:gosec
+	}
+}
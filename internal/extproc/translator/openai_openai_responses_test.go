@@ -0,0 +1,85 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToOpenAITranslatorV1Responses_RequestBody(t *testing.T) {
+	o := NewResponsesOpenAIToOpenAITranslator()
+	hm, bm, err := o.RequestBody(&openai.ResponseRequest{Model: "gpt-4o", Input: openai.ResponseInput{Value: "hello"}, Stream: true})
+	require.NoError(t, err)
+	require.Nil(t, hm)
+	require.Nil(t, bm)
+	require.True(t, o.(*openAIToOpenAITranslatorV1Responses).stream)
+}
+
+func TestOpenAIToOpenAITranslatorV1Responses_ResponseBody(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		o := NewResponsesOpenAIToOpenAITranslator()
+		body := `{"id":"resp_1","object":"response","created_at":1,"model":"gpt-4o","status":"completed","output":[],
+"usage":{"input_tokens":8,"output_tokens":3,"total_tokens":11}}`
+		hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 8, OutputTokens: 3, TotalTokens: 11}, usage)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		o := NewResponsesOpenAIToOpenAITranslator()
+		_, _, _, err := o.ResponseBody(map[string]string{}, strings.NewReader("{"), true)
+		require.ErrorContains(t, err, "failed to unmarshal body")
+	})
+	t.Run("non-json error", func(t *testing.T) {
+		o := NewResponsesOpenAIToOpenAITranslator()
+		hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "503", "content-type": "text/plain"},
+			bytes.NewReader([]byte("service not available")), true)
+		require.NoError(t, err)
+		require.NotNil(t, hm)
+		require.Equal(t, LLMTokenUsage{}, usage)
+		require.JSONEq(t, `{"type":"error","error":{"type":"server_error","code":"503","message":"service not available"}}`,
+			string(bm.Mutation.(*extprocv3.BodyMutation_Body).Body))
+	})
+	t.Run("stream", func(t *testing.T) {
+		o := NewResponsesOpenAIToOpenAITranslator()
+		_, _, err := o.RequestBody(&openai.ResponseRequest{Stream: true})
+		require.NoError(t, err)
+		chunks := []string{
+			"event: response.created\ndata: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_1\",\"status\":\"in_progress\"}}\n\n",
+			"event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"sequence_number\":1,\"delta\":\"Hi\"}\n\n" +
+				"event: response.completed\ndata: {\"type\":\"response.completed\",\"sequence_number\":2,\"response\":{\"id\":\"resp_1\",",
+			"\"status\":\"completed\",\"usage\":{\"input_tokens\":5,\"output_tokens\":1,\"total_tokens\":6}}}\n\n",
+		}
+		var usages []LLMTokenUsage
+		for i, chunk := range chunks {
+			hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(chunk), i == len(chunks)-1)
+			require.NoError(t, err)
+			require.Nil(t, hm)
+			require.Nil(t, bm)
+			usages = append(usages, usage)
+		}
+		require.Equal(t, []LLMTokenUsage{{}, {}, {InputTokens: 5, OutputTokens: 1, TotalTokens: 6}}, usages)
+	})
+}
+
+func TestOpenAIToAzureOpenAITranslatorV1Responses_RequestBody(t *testing.T) {
+	o := NewResponsesOpenAIToAzureOpenAITranslator("2025-03-01-preview")
+	hm, bm, err := o.RequestBody(&openai.ResponseRequest{Model: "my-deployment", Input: openai.ResponseInput{Value: "hello"}, Stream: true})
+	require.NoError(t, err)
+	require.Nil(t, bm)
+	require.NotNil(t, hm)
+	require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+	require.Equal(t, "/openai/responses?api-version=2025-03-01-preview", string(hm.SetHeaders[0].Header.RawValue))
+	require.True(t, o.(*openAIToAzureOpenAITranslatorV1Responses).stream)
+}
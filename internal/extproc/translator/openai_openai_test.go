@@ -58,7 +58,7 @@ func TestOpenAIToOpenAITranslator_ResponseError(t *testing.T) {
 			output: openai.Error{
 				Type: "error",
 				Error: openai.ErrorType{
-					Type:    openAIBackendError,
+					Type:    "server_error",
 					Code:    ptr.To("503"),
 					Message: "service not available",
 				},
@@ -101,8 +101,9 @@ func TestOpenAIToOpenAITranslator_ResponseError(t *testing.T) {
 				require.NotNil(t, newBody)
 				require.NotNil(t, hm)
 				require.NotNil(t, hm.SetHeaders)
-				require.Len(t, hm.SetHeaders, 1)
+				require.Len(t, hm.SetHeaders, 2)
 				require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+				require.Equal(t, "content-type", hm.SetHeaders[1].Header.Key)
 				require.Equal(t, strconv.Itoa(len(newBody)), string(hm.SetHeaders[0].Header.RawValue))
 			}
 
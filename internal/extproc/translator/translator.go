@@ -6,12 +6,15 @@
 package translator
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 )
 
@@ -20,8 +23,6 @@ const (
 	contentTypeHeaderName  = "content-type"
 	awsErrorTypeHeaderName = "x-amzn-errortype"
 	jsonContentType        = "application/json"
-	openAIBackendError     = "OpenAIBackendError"
-	awsBedrockBackendError = "AWSBedrockBackendError"
 )
 
 // isGoodStatusCode checks if the HTTP status code of the upstream response is successful.
@@ -63,6 +64,86 @@ type OpenAIChatCompletionTranslator interface {
 		tokenUsage LLMTokenUsage,
 		err error,
 	)
+
+	// ResponseError translates the error response of the backend, i.e. the one with a non-2xx status, to the OpenAI
+	// error format. The "type" of the error is determined by the status so that it is consistent regardless of the backend.
+	// 	- `respHeaders` is the response headers including the status.
+	// 	- `body` is the error response body.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	ResponseError(respHeaders map[string]string, body io.Reader) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+}
+
+// OpenAIEmbeddingTranslator translates the request and response messages between the client and the backend API schemas
+// for /v1/embeddings endpoint of OpenAI.
+//
+// This is created per request and is not thread-safe.
+type OpenAIEmbeddingTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `body` is the request body parsed into the [openai.EmbeddingRequest].
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(body *openai.EmbeddingRequest) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body. Embeddings responses are never streamed, so this is called
+	// once with the entire body.
+	// 	- `body` is the entire response body.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that is extracted from the body and will be used to do token rate limiting.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
+}
+
+// OpenAIResponsesTranslator translates the request and response messages between the client and the backend API schemas
+// for /v1/responses endpoint of OpenAI.
+//
+// This is created per request and is not thread-safe.
+type OpenAIResponsesTranslator interface {
+	// RequestBody translates the request body.
+	// 	- `body` is the request body parsed into the [openai.ResponseRequest].
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	RequestBody(body *openai.ResponseRequest) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		err error,
+	)
+
+	// ResponseHeaders translates the response headers.
+	// 	- `headers` is the response headers.
+	//	- This returns `headerMutation` that can be nil to indicate no mutation.
+	ResponseHeaders(headers map[string]string) (
+		headerMutation *extprocv3.HeaderMutation,
+		err error,
+	)
+
+	// ResponseBody translates the response body. When stream=true, this is called for each chunk of the response body.
+	// 	- `body` is the response body either chunk or the entire body, depending on the context.
+	//	- This returns `headerMutation` and `bodyMutation` that can be nil to indicate no mutation.
+	//  - This returns `tokenUsage` that is extracted from the body and will be used to do token rate limiting.
+	ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+		headerMutation *extprocv3.HeaderMutation,
+		bodyMutation *extprocv3.BodyMutation,
+		tokenUsage LLMTokenUsage,
+		err error,
+	)
 }
 
 func setContentLength(headers *extprocv3.HeaderMutation, body []byte) {
@@ -74,6 +155,33 @@ func setContentLength(headers *extprocv3.HeaderMutation, body []byte) {
 	})
 }
 
+// backendErrorResponse builds the mutations replacing the error response of the backend with the OpenAI error.
+// The type of the error is determined by the status of the response, and code is the error type specific
+// to the backend, falling back to the status when the backend doesn't provide one.
+func backendErrorResponse(respHeaders map[string]string, code, message string) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	status, _ := strconv.Atoi(respHeaders[statusHeaderName])
+	openaiError := openai.Error{
+		Type:  "error",
+		Error: openai.ErrorType{Type: aigwerrors.OpenAIErrorType(status), Message: message},
+	}
+	if code == "" {
+		code = strconv.Itoa(status)
+	}
+	openaiError.Error.Code = &code
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(openaiError); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: contentTypeHeaderName, RawValue: []byte(jsonContentType)},
+	})
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
 // LLMTokenUsage represents the token usage reported usually by the backend API in the response body.
 type LLMTokenUsage struct {
 	// InputTokens is the number of tokens consumed from the input.
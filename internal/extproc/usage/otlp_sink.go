@@ -0,0 +1,123 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package usage
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// otlpEventName is the event name of the log records.
+	otlpEventName = "aigw.usage_record"
+	// otlpScopeName is the instrumentation scope of the log records.
+	otlpScopeName = "github.com/envoyproxy/ai-gateway/internal/extproc/usage"
+	// otlpServiceName is the service.name resource attribute of the log records.
+	otlpServiceName = "ai-gateway-extproc"
+)
+
+// otlpSink is the Sink exporting the records as OpenTelemetry log records with OTLP over gRPC.
+type otlpSink struct {
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+}
+
+// NewOTLPSink creates a new Sink exporting to the OTLP gRPC receiver at the endpoint.
+func NewOTLPSink(endpoint string, insecureConn bool) (Sink, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if insecureConn {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	return &otlpSink{conn: conn, client: collogspb.NewLogsServiceClient(conn)}, nil
+}
+
+// Export implements [Sink.Export].
+func (s *otlpSink) Export(ctx context.Context, records []*Record) error {
+	logRecords := make([]*logspb.LogRecord, 0, len(records))
+	for _, r := range records {
+		logRecords = append(logRecords, otlpLogRecord(r))
+	}
+	// The partially rejected records are not retryable per the specification, so only the error is checked.
+	_, err := s.client.Export(ctx, &collogspb.ExportLogsServiceRequest{ResourceLogs: []*logspb.ResourceLogs{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+			otlpString("service.name", otlpServiceName),
+		}},
+		ScopeLogs: []*logspb.ScopeLogs{{
+			Scope:      &commonpb.InstrumentationScope{Name: otlpScopeName},
+			LogRecords: logRecords,
+		}},
+	}}})
+	return err
+}
+
+// Close implements [Sink.Close].
+func (s *otlpSink) Close() error {
+	return s.conn.Close()
+}
+
+// otlpLogRecord converts the record to the log record whose attributes are the fields of the record.
+func otlpLogRecord(r *Record) *logspb.LogRecord {
+	attrs := []*commonpb.KeyValue{
+		otlpString("gen_ai.operation.name", r.Operation),
+		otlpString("gen_ai.request.model", r.Model),
+		otlpInt("gen_ai.usage.input_tokens", int64(r.InputTokens)),
+		otlpInt("gen_ai.usage.output_tokens", int64(r.OutputTokens)),
+		otlpInt("aigw.usage.total_tokens", int64(r.TotalTokens)),
+		otlpInt("aigw.latency_ms", r.LatencyMillis),
+		otlpInt("http.response.status_code", int64(r.Status)),
+	}
+	if r.RequestID != "" {
+		attrs = append(attrs, otlpString("aigw.request_id", r.RequestID))
+	}
+	if r.Backend != "" {
+		attrs = append(attrs, otlpString("aigw.backend", r.Backend))
+	}
+	if r.BackendModel != "" {
+		attrs = append(attrs, otlpString("aigw.backend_model", r.BackendModel))
+	}
+	if r.Stream {
+		attrs = append(attrs, &commonpb.KeyValue{Key: "aigw.stream", Value: &commonpb.AnyValue{
+			Value: &commonpb.AnyValue_BoolValue{BoolValue: true},
+		}})
+	}
+	if r.CostNanoUSD != nil {
+		attrs = append(attrs, otlpInt("aigw.cost_nano_usd", *r.CostNanoUSD))
+	}
+	for k, v := range r.Headers {
+		attrs = append(attrs, otlpString("aigw.request.header."+k, v))
+	}
+	ts := uint64(r.Timestamp.UnixNano()) //nolint:gosec
+	return &logspb.LogRecord{
+		TimeUnixNano:         ts,
+		ObservedTimeUnixNano: ts,
+		SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+		SeverityText:         "INFO",
+		EventName:            otlpEventName,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "usage record"}},
+		Attributes:           attrs,
+	}
+}
+
+func otlpString(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func otlpInt(key string, value int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value}}}
+}
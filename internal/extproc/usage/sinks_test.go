@@ -0,0 +1,130 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package usage
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/extproc/export"
+)
+
+func testRecord() *Record {
+	return &Record{
+		Timestamp:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		RequestID:     "req-1",
+		Operation:     "chat",
+		Headers:       map[string]string{"x-team": "search"},
+		Model:         "gpt-4o",
+		Backend:       "openai",
+		BackendModel:  "gpt-4o-2024-08-06",
+		InputTokens:   10,
+		OutputTokens:  20,
+		TotalTokens:   30,
+		LatencyMillis: 1234,
+		Status:        200,
+		Stream:        true,
+		CostNanoUSD:   ptr.To[int64](500),
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	s := export.NewFileSink[*Record](path, 1, 1)
+	require.NoError(t, s.Export(context.Background(), []*Record{testRecord(), testRecord()}))
+	require.NoError(t, s.Export(context.Background(), []*Record{testRecord()}))
+	require.NoError(t, s.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 3)
+	require.JSONEq(t, `{"timestamp":"2025-01-02T03:04:05Z","requestID":"req-1","operation":"chat",
+"headers":{"x-team":"search"},"model":"gpt-4o","backend":"openai","backendModel":"gpt-4o-2024-08-06",
+"inputTokens":10,"outputTokens":20,"totalTokens":30,"latencyMillis":1234,"status":200,"stream":true,"costNanoUSD":500}`,
+		lines[0])
+}
+
+type testLogsServer struct {
+	collogspb.UnimplementedLogsServiceServer
+	requests chan *collogspb.ExportLogsServiceRequest
+	err      error
+}
+
+func (s *testLogsServer) Export(_ context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.requests <- req
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func TestOTLPSink(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	logsServer := &testLogsServer{requests: make(chan *collogspb.ExportLogsServiceRequest, 1)}
+	collogspb.RegisterLogsServiceServer(srv, logsServer)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	s, err := NewOTLPSink(lis.Addr().String(), true)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+	require.NoError(t, s.Export(context.Background(), []*Record{testRecord()}))
+
+	req := <-logsServer.requests
+	require.Len(t, req.ResourceLogs, 1)
+	require.Equal(t, "service.name", req.ResourceLogs[0].Resource.Attributes[0].Key)
+	require.Equal(t, otlpServiceName, req.ResourceLogs[0].Resource.Attributes[0].Value.GetStringValue())
+	require.Len(t, req.ResourceLogs[0].ScopeLogs, 1)
+	require.Equal(t, otlpScopeName, req.ResourceLogs[0].ScopeLogs[0].Scope.Name)
+	logRecords := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, logRecords, 1)
+	require.Equal(t, otlpEventName, logRecords[0].EventName)
+	require.Equal(t, uint64(testRecord().Timestamp.UnixNano()), logRecords[0].TimeUnixNano) //nolint:gosec
+
+	attrs := map[string]any{}
+	for _, kv := range logRecords[0].Attributes {
+		switch v := kv.Value.Value.(type) {
+		case *commonpb.AnyValue_StringValue:
+			attrs[kv.Key] = v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			attrs[kv.Key] = v.IntValue
+		case *commonpb.AnyValue_BoolValue:
+			attrs[kv.Key] = v.BoolValue
+		}
+	}
+	require.Equal(t, "chat", attrs["gen_ai.operation.name"])
+	require.Equal(t, "gpt-4o", attrs["gen_ai.request.model"])
+	require.Equal(t, "req-1", attrs["aigw.request_id"])
+	require.Equal(t, "openai", attrs["aigw.backend"])
+	require.Equal(t, "gpt-4o-2024-08-06", attrs["aigw.backend_model"])
+	require.Equal(t, int64(10), attrs["gen_ai.usage.input_tokens"])
+	require.Equal(t, int64(20), attrs["gen_ai.usage.output_tokens"])
+	require.Equal(t, int64(30), attrs["aigw.usage.total_tokens"])
+	require.Equal(t, int64(1234), attrs["aigw.latency_ms"])
+	require.Equal(t, int64(200), attrs["http.response.status_code"])
+	require.Equal(t, int64(500), attrs["aigw.cost_nano_usd"])
+	require.Equal(t, true, attrs["aigw.stream"])
+	require.Equal(t, "search", attrs["aigw.request.header.x-team"])
+
+	logsServer.err = status.Error(codes.Unavailable, "unavailable")
+	require.Error(t, s.Export(context.Background(), []*Record{testRecord()}))
+}
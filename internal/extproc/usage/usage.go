@@ -0,0 +1,93 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package usage implements the export of the usage records of the requests.
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/export"
+)
+
+// Record is the usage record of a request.
+type Record struct {
+	// Timestamp is when the request was received.
+	Timestamp time.Time `json:"timestamp"`
+	// RequestID is the value of the x-request-id header.
+	RequestID string `json:"requestID,omitempty"`
+	// Operation is the kind of the request, i.e. "chat", "embeddings" or "responses".
+	Operation string `json:"operation"`
+	// Headers are the configured request headers identifying the client.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Model is the model requested by the client.
+	Model string `json:"model"`
+	// Backend is the name of the backend the request was sent to.
+	Backend string `json:"backend,omitempty"`
+	// BackendModel is the model sent to the backend, which differs from Model when the backend maps the model name.
+	BackendModel string `json:"backendModel,omitempty"`
+	// InputTokens, OutputTokens and TotalTokens are the token usage reported by the backend.
+	InputTokens  uint32 `json:"inputTokens"`
+	OutputTokens uint32 `json:"outputTokens"`
+	TotalTokens  uint32 `json:"totalTokens"`
+	// LatencyMillis is the duration from when the request was received until the response completed.
+	LatencyMillis int64 `json:"latencyMillis"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Stream is true if the response was streamed.
+	Stream bool `json:"stream,omitempty"`
+	// CostNanoUSD is the cost of the request in nano USD. This is only set when the spend budgets are configured.
+	CostNanoUSD *int64 `json:"costNanoUSD,omitempty"`
+}
+
+// Sink exports the batches of the records.
+type Sink = export.Sink[*Record]
+
+// NewSink creates a new sink for the configuration.
+func NewSink(config *filterapi.UsageRecordSink) (Sink, error) {
+	switch config.Type {
+	case filterapi.UsageRecordSinkTypeFile:
+		return export.NewFileSink[*Record](config.Path, config.MaxSizeMegabytes, config.MaxBackups), nil
+	case filterapi.UsageRecordSinkTypeWebhook:
+		return export.NewWebhookSink[*Record](config.URL), nil
+	case filterapi.UsageRecordSinkTypeOTLP:
+		return NewOTLPSink(config.OTLPEndpoint, config.OTLPInsecure)
+	default:
+		return nil, fmt.Errorf("unknown usage record sink type: %s", config.Type)
+	}
+}
+
+// Pipeline exports the records to the sinks in the background.
+type Pipeline struct {
+	*export.Pipeline[*Record]
+	headers []string
+}
+
+// NewPipeline creates a new pipeline for the configuration and starts exporting to the sinks.
+func NewPipeline(config *filterapi.UsageRecordsConfig, logger *slog.Logger) (*Pipeline, error) {
+	p := &Pipeline{Pipeline: export.NewPipeline[*Record]("usage record", logger), headers: config.Headers}
+	for i := range config.Sinks {
+		sc := &config.Sinks[i]
+		if sc.BatchSize <= 0 || sc.FlushInterval <= 0 {
+			return nil, errors.Join(fmt.Errorf("invalid batch config of sink %d: batchSize=%d, flushInterval=%s",
+				i, sc.BatchSize, sc.FlushInterval), p.Close())
+		}
+		sink, err := NewSink(sc)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("cannot create sink %d: %w", i, err), p.Close())
+		}
+		p.AddSink(fmt.Sprintf("%d-%s", i, sc.Type), sink, sc.BatchSize, sc.FlushInterval, sc.MaxRetries)
+	}
+	return p, nil
+}
+
+// Headers returns the names of the request headers included in the records.
+func (p *Pipeline) Headers() []string {
+	return p.headers
+}
@@ -0,0 +1,48 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package usage
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNewPipeline(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Run("ok", func(t *testing.T) {
+		p, err := NewPipeline(&filterapi.UsageRecordsConfig{
+			Headers: []string{"x-team"},
+			Sinks: []filterapi.UsageRecordSink{
+				{Type: filterapi.UsageRecordSinkTypeFile, Path: t.TempDir() + "/usage.jsonl", MaxSizeMegabytes: 1, BatchSize: 1, FlushInterval: time.Second},
+				{Type: filterapi.UsageRecordSinkTypeWebhook, URL: "http://localhost:1", BatchSize: 1, FlushInterval: time.Second},
+				{Type: filterapi.UsageRecordSinkTypeOTLP, OTLPEndpoint: "localhost:1", OTLPInsecure: true, BatchSize: 1, FlushInterval: time.Second},
+			},
+		}, logger)
+		require.NoError(t, err)
+		require.Equal(t, []string{"x-team"}, p.Headers())
+		require.Equal(t, 3, p.Sinks())
+		require.NoError(t, p.Close())
+	})
+	t.Run("invalid batch", func(t *testing.T) {
+		_, err := NewPipeline(&filterapi.UsageRecordsConfig{Sinks: []filterapi.UsageRecordSink{
+			{Type: filterapi.UsageRecordSinkTypeWebhook, URL: "http://localhost:1"},
+		}}, logger)
+		require.ErrorContains(t, err, "invalid batch config of sink 0")
+	})
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := NewPipeline(&filterapi.UsageRecordsConfig{Sinks: []filterapi.UsageRecordSink{
+			{Type: filterapi.UsageRecordSinkTypeWebhook, URL: "http://localhost:1", BatchSize: 1, FlushInterval: time.Second},
+			{Type: "Kafka", BatchSize: 1, FlushInterval: time.Second},
+		}}, logger)
+		require.ErrorContains(t, err, "cannot create sink 1: unknown usage record sink type: Kafka")
+	})
+}
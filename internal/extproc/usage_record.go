@@ -0,0 +1,76 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"strconv"
+	"time"
+
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/usage"
+)
+
+// Operations of the usage records.
+const (
+	usageRecordOperationChat       = "chat"
+	usageRecordOperationEmbeddings = "embeddings"
+	usageRecordOperationResponses  = "responses"
+)
+
+// usageRecorder emits the usage record of a request once its response completes.
+type usageRecorder struct {
+	// operation is the kind of the request.
+	operation string
+	// start is when the request was received.
+	start time.Time
+	// emitted is true once the record is emitted so that a request is recorded only once.
+	emitted bool
+}
+
+// newUsageRecorder creates a new usageRecorder for the request of the operation received now.
+func newUsageRecorder(operation string) usageRecorder {
+	return usageRecorder{operation: operation, start: time.Now()}
+}
+
+// emit emits the usage record of the request sent to the upstream with the token usage.
+// This is a no-op if the usage records are not configured.
+func (u *usageRecorder) emit(config *processorConfig, requestHeaders, responseHeaders map[string]string,
+	up upstream, tokenUsage translator.LLMTokenUsage, stream bool,
+) {
+	if config.usageRecords == nil || u.emitted {
+		return
+	}
+	u.emitted = true
+	status, _ := strconv.Atoi(responseHeaders[":status"])
+	r := &usage.Record{
+		Timestamp:     u.start,
+		RequestID:     requestHeaders["x-request-id"],
+		Operation:     u.operation,
+		Model:         requestHeaders[config.modelNameHeaderKey],
+		Backend:       up.backend,
+		BackendModel:  up.model,
+		InputTokens:   tokenUsage.InputTokens,
+		OutputTokens:  tokenUsage.OutputTokens,
+		TotalTokens:   tokenUsage.TotalTokens,
+		LatencyMillis: time.Since(u.start).Milliseconds(),
+		Status:        status,
+		Stream:        stream,
+	}
+	if headers := config.usageRecords.Headers(); len(headers) > 0 {
+		r.Headers = make(map[string]string, len(headers))
+		for _, h := range headers {
+			if v, ok := requestHeaders[h]; ok {
+				r.Headers[h] = v
+			}
+		}
+	}
+	if config.spendBudget != nil {
+		r.CostNanoUSD = ptr.To(config.spendBudget.Cost(up.backend, up.model, tokenUsage))
+	}
+	config.usageRecords.Emit(r)
+}
@@ -0,0 +1,214 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/usage"
+)
+
+// newTestUsageRecords creates the usage record pipeline writing to the file at the returned path.
+func newTestUsageRecords(t *testing.T) (*usage.Pipeline, string) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	p, err := usage.NewPipeline(&filterapi.UsageRecordsConfig{
+		Headers: []string{"x-team", "x-missing"},
+		Sinks: []filterapi.UsageRecordSink{{
+			Type: filterapi.UsageRecordSinkTypeFile, Path: path, MaxSizeMegabytes: 1, BatchSize: 100, FlushInterval: time.Hour,
+		}},
+	}, slog.Default())
+	require.NoError(t, err)
+	return p, path
+}
+
+// readUsageRecords closes the pipeline and returns the records written to the file at the path.
+func readUsageRecords(t *testing.T, p *usage.Pipeline, path string) []usage.Record {
+	require.NoError(t, p.Close())
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	var records []usage.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var r usage.Record
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestChatCompletion_usageRecord(t *testing.T) {
+	const body = `{"model":"some-model","messages":[{"role":"user","content":"hello"}]}`
+	var expBody openai.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+	pipeline, path := newTestUsageRecords(t)
+	m, _ := newTestSpendBudget(t, 0)
+
+	headers := map[string]string{":path": "/v1/chat/completions", "x-request-id": "req-1", "x-team": "search"}
+	p := &chatCompletionProcessor{
+		config: &processorConfig{
+			modelNameHeaderKey:       "x-model-name",
+			selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+			router:                   mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"},
+			spendBudget:              m,
+			usageRecords:             pipeline,
+		},
+		requestHeaders:  headers,
+		responseHeaders: map[string]string{":status": "200"},
+		logger:          slog.Default(),
+		metrics:         &mockChatCompletionMetrics{},
+		translator: &mockTranslator{
+			t: t, expRequestBody: &expBody, retUsedToken: translator.LLMTokenUsage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5},
+		},
+		usage: newUsageRecorder(usageRecordOperationChat),
+	}
+	_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("part")})
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("end"), EndOfStream: true})
+	require.NoError(t, err)
+	// The record is emitted only once.
+	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("end"), EndOfStream: true})
+	require.NoError(t, err)
+
+	records := readUsageRecords(t, pipeline, path)
+	require.Len(t, records, 1)
+	r := records[0]
+	require.Equal(t, p.usage.start.UTC(), r.Timestamp.UTC())
+	require.GreaterOrEqual(t, r.LatencyMillis, int64(0))
+	r.Timestamp, r.LatencyMillis = time.Time{}, 0
+	cost := int64(10)
+	require.Equal(t, usage.Record{
+		RequestID:    "req-1",
+		Operation:    "chat",
+		Headers:      map[string]string{"x-team": "search"},
+		Model:        "some-model",
+		Backend:      "some-backend",
+		BackendModel: "some-model",
+		InputTokens:  6,
+		OutputTokens: 4,
+		TotalTokens:  10,
+		Status:       200,
+		CostNanoUSD:  &cost,
+	}, r)
+}
+
+func TestEmbeddings_usageRecord(t *testing.T) {
+	pipeline, path := newTestUsageRecords(t)
+	headers := map[string]string{":path": "/v1/embeddings"}
+	const body = `{"model":"some-model","input":"hello"}`
+	var expBody openai.EmbeddingRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &expBody))
+	p := &embeddingsProcessor{
+		config: &processorConfig{
+			modelNameHeaderKey:       "x-model-name",
+			selectedBackendHeaderKey: "x-ai-eg-selected-backend",
+			router:                   mockRouter{t: t, expHeaders: headers, retBackendName: "some-backend"},
+			usageRecords:             pipeline,
+		},
+		requestHeaders:  headers,
+		responseHeaders: map[string]string{":status": "400"},
+		logger:          slog.Default(),
+		metrics:         &mockEmbeddingsMetrics{},
+		translator: &mockEmbeddingTranslator{
+			t: t, expRequestBody: &expBody, retUsedToken: translator.LLMTokenUsage{InputTokens: 7, TotalTokens: 7},
+		},
+		usage: newUsageRecorder(usageRecordOperationEmbeddings),
+	}
+	_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+	require.NoError(t, err)
+	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{EndOfStream: true})
+	require.NoError(t, err)
+
+	records := readUsageRecords(t, pipeline, path)
+	require.Len(t, records, 1)
+	require.Equal(t, "embeddings", records[0].Operation)
+	require.Equal(t, "some-model", records[0].Model)
+	require.Equal(t, "some-backend", records[0].Backend)
+	require.Equal(t, uint32(7), records[0].InputTokens)
+	require.Equal(t, 400, records[0].Status)
+	require.Empty(t, records[0].Headers)
+	// The cost is only known when the spend budgets are configured.
+	require.Nil(t, records[0].CostNanoUSD)
+}
+
+func TestUsageRecorder_emit_disabled(t *testing.T) {
+	u := newUsageRecorder(usageRecordOperationResponses)
+	u.emit(&processorConfig{}, map[string]string{}, map[string]string{}, upstream{}, translator.LLMTokenUsage{}, true)
+	require.False(t, u.emitted)
+}
+
+func TestServer_LoadConfig_usageRecords(t *testing.T) {
+	s, err := NewServer(slog.Default(), nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	config := &filterapi.Config{UsageRecords: &filterapi.UsageRecordsConfig{
+		Sinks: []filterapi.UsageRecordSink{{
+			Type: filterapi.UsageRecordSinkTypeFile, Path: filepath.Join(dir, "usage.jsonl"), BatchSize: 1, FlushInterval: time.Second,
+		}},
+	}}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	pipeline := s.config.usageRecords
+	require.NotNil(t, pipeline)
+
+	// The pipeline is reused as long as its configuration is unchanged.
+	config.UsageRecords = &filterapi.UsageRecordsConfig{
+		Sinks: []filterapi.UsageRecordSink{{
+			Type: filterapi.UsageRecordSinkTypeFile, Path: filepath.Join(dir, "usage.jsonl"), BatchSize: 1, FlushInterval: time.Second,
+		}},
+	}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Same(t, pipeline, s.config.usageRecords)
+
+	config.UsageRecords.Headers = []string{"x-team"}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.NotSame(t, pipeline, s.config.usageRecords)
+	require.Equal(t, []string{"x-team"}, s.config.usageRecords.Headers())
+
+	config.UsageRecords = nil
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	require.Nil(t, s.config.usageRecords)
+
+	config.UsageRecords = &filterapi.UsageRecordsConfig{Sinks: []filterapi.UsageRecordSink{{Type: "Kafka", BatchSize: 1, FlushInterval: time.Second}}}
+	require.ErrorContains(t, s.LoadConfig(t.Context(), config), "cannot create usage records: cannot create sink 0: unknown usage record sink type: Kafka")
+
+	// The reload failing after the new pipeline is created keeps the current one running.
+	newConfig := func(name string) *filterapi.UsageRecordsConfig {
+		return &filterapi.UsageRecordsConfig{Sinks: []filterapi.UsageRecordSink{{
+			Type: filterapi.UsageRecordSinkTypeFile, Path: filepath.Join(dir, name), BatchSize: 1, FlushInterval: time.Second,
+		}}}
+	}
+	config = &filterapi.Config{UsageRecords: newConfig("current.jsonl")}
+	require.NoError(t, s.LoadConfig(t.Context(), config))
+	pipeline = s.config.usageRecords
+	config.UsageRecords = newConfig("next.jsonl")
+	config.AuditLog = &filterapi.AuditLogConfig{Redaction: &filterapi.AuditLogRedaction{Fields: []string{"response.id"}}}
+	require.ErrorContains(t, s.LoadConfig(t.Context(), config), "cannot create audit log")
+	require.Same(t, pipeline, s.config.usageRecords)
+	// The key of the current pipeline is kept as well, so reloading its configuration still reuses it.
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{UsageRecords: newConfig("current.jsonl")}))
+	require.Same(t, pipeline, s.config.usageRecords)
+	pipeline.Emit(&usage.Record{Model: "gpt-4o"})
+	require.NoError(t, pipeline.Close())
+	content, err := os.ReadFile(filepath.Join(dir, "current.jsonl"))
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"model":"gpt-4o"`)
+}
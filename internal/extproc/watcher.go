@@ -30,9 +30,6 @@ type configWatcher struct {
 	l               *slog.Logger
 	current         string
 	usingDefaultCfg bool
-	// hasDynamicLB is true if the current configuration has at least one backend with dynamic load balancing.
-	// We need to force a reload of the configuration regardless of the file modification time.
-	hasDynamicLB bool
 }
 
 // StartConfigWatcher starts a watcher for the given path and Receiver.
@@ -95,7 +92,7 @@ func (cw *configWatcher) loadConfig(ctx context.Context) error {
 		cw.usingDefaultCfg = true
 	} else {
 		cw.usingDefaultCfg = false
-		if stat.ModTime().Sub(cw.lastMod) <= 0 && !cw.hasDynamicLB {
+		if stat.ModTime().Sub(cw.lastMod) <= 0 {
 			return nil
 		}
 		cw.l.Info("loading a new config", slog.String("path", cw.path))
@@ -117,16 +114,6 @@ func (cw *configWatcher) loadConfig(ctx context.Context) error {
 	if err = cw.rcv.LoadConfig(ctx, cfg); err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
-
-	cw.hasDynamicLB = false
-	for _, rule := range cfg.Rules {
-		for _, backend := range rule.Backends {
-			if backend.DynamicLoadBalancing != nil {
-				cw.hasDynamicLB = true
-				break
-			}
-		}
-	}
 	return nil
 }
 
@@ -187,7 +187,7 @@ modelNameHeaderKey: x-model-name
 rules:
 - backends:
   - name: openai
-    dynamicLoadBalancing: {} # This should force the reload regardless of the config change.
+    dynamicLoadBalancing: {}
     schema:
       name: OpenAI
   headers:
@@ -203,10 +203,9 @@ rules:
 	require.NotEqual(t, firstCfg, rcv.getConfig())
 	require.Equal(t, int32(4), rcv.loadCount.Load())
 
-	// Then waits for the 10 counts of the config to be loaded.
-	require.Eventually(t, func() bool {
-		return rcv.loadCount.Load() == 10
-	}, 5*time.Second, tickInterval)
+	// The dynamic load balancing does not force the reload since it re-resolves the endpoints by itself.
+	time.Sleep(2 * tickInterval)
+	require.Equal(t, int32(4), rcv.loadCount.Load())
 }
 
 func TestDiff(t *testing.T) {
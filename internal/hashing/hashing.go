@@ -0,0 +1,19 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package hashing provides the helpers shared by the hash-based load balancing, such as the session affinity and
+// the sticky weighted routing.
+package hashing
+
+// Mix64 is the finalizer of SplitMix64. This is applied to the FNV hashes, whose higher bits are poorly distributed,
+// so that all the bits of the result are evenly distributed.
+func Mix64(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
@@ -0,0 +1,25 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package hashing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMix64(t *testing.T) {
+	// The expected values are the outputs of the SplitMix64 finalizer.
+	require.Equal(t, uint64(0), Mix64(0))
+	require.Equal(t, uint64(0x5692161d100b05e5), Mix64(1))
+
+	// The consecutive inputs are spread over the higher bits.
+	seen := make(map[uint64]struct{})
+	for i := range uint64(256) {
+		seen[Mix64(i)>>56] = struct{}{}
+	}
+	require.Greater(t, len(seen), 128)
+}
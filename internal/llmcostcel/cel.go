@@ -11,6 +11,7 @@ package llmcostcel
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/google/cel-go/cel"
 )
@@ -21,6 +22,8 @@ const (
 	celInputTokensKey  = "input_tokens"
 	celOutputTokensKey = "output_tokens"
 	celTotalTokensKey  = "total_tokens"
+	// celEstimatedInputTokensKey is the number of input tokens estimated before the request is sent to the backend.
+	celEstimatedInputTokensKey = "estimated_input_tokens"
 )
 
 var env *cel.Env
@@ -33,6 +36,7 @@ func init() {
 		cel.Variable(celInputTokensKey, cel.UintType),
 		cel.Variable(celOutputTokensKey, cel.UintType),
 		cel.Variable(celTotalTokensKey, cel.UintType),
+		cel.Variable(celEstimatedInputTokensKey, cel.UintType),
 	)
 	if err != nil {
 		panic(fmt.Sprintf("cannot create CEL environment: %v", err))
@@ -52,21 +56,28 @@ func NewProgram(expr string) (prog cel.Program, err error) {
 	}
 
 	// Sanity check by evaluating the expression with some dummy values.
-	_, err = EvaluateProgram(prog, "dummy", "dummy", 0, 0, 0)
+	_, err = EvaluateProgram(prog, "dummy", "dummy", 0, 0, 0, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
 	}
 	return prog, nil
 }
 
+// UsesEstimatedInputTokens returns true if the given expression refers to the estimated input tokens, which
+// need to be calculated before the request is sent to the backend.
+func UsesEstimatedInputTokens(expr string) bool {
+	return strings.Contains(expr, celEstimatedInputTokensKey)
+}
+
 // EvaluateProgram evaluates the given CEL program with the given variables.
-func EvaluateProgram(prog cel.Program, modelName, backend string, inputTokens, outputTokens, totalTokens uint32) (uint64, error) {
+func EvaluateProgram(prog cel.Program, modelName, backend string, inputTokens, outputTokens, totalTokens, estimatedInputTokens uint32) (uint64, error) {
 	out, _, err := prog.Eval(map[string]interface{}{
-		celModelNameKey:    modelName,
-		celBackendKey:      backend,
-		celInputTokensKey:  inputTokens,
-		celOutputTokensKey: outputTokens,
-		celTotalTokensKey:  totalTokens,
+		celModelNameKey:            modelName,
+		celBackendKey:              backend,
+		celInputTokensKey:          inputTokens,
+		celOutputTokensKey:         outputTokens,
+		celTotalTokensKey:          totalTokens,
+		celEstimatedInputTokensKey: estimatedInputTokens,
 	})
 	if err != nil || out == nil {
 		return 0, fmt.Errorf("failed to evaluate CEL expression: %w", err)
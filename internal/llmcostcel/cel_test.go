@@ -28,14 +28,21 @@ func TestNewProgram(t *testing.T) {
 	t.Run("variables", func(t *testing.T) {
 		prog, err := NewProgram("model == 'cool_model' ?  input_tokens * output_tokens : total_tokens")
 		require.NoError(t, err)
-		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3)
+		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3, 0)
 		require.NoError(t, err)
 		require.Equal(t, uint64(200), v)
 
-		v, err = EvaluateProgram(prog, "not_cool_model", "cool_backend", 100, 2, 3)
+		v, err = EvaluateProgram(prog, "not_cool_model", "cool_backend", 100, 2, 3, 0)
 		require.NoError(t, err)
 		require.Equal(t, uint64(3), v)
 	})
+	t.Run("estimated input tokens", func(t *testing.T) {
+		prog, err := NewProgram("estimated_input_tokens > uint(1000) ? estimated_input_tokens * uint(2) : estimated_input_tokens")
+		require.NoError(t, err)
+		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 0, 0, 0, 2000)
+		require.NoError(t, err)
+		require.Equal(t, uint64(4000), v)
+	})
 
 	t.Run("uint", func(t *testing.T) {
 		_, err := NewProgram("uint(1)-uint(1200)")
@@ -57,17 +64,23 @@ func TestNewProgram(t *testing.T) {
 	})
 }
 
+func TestUsesEstimatedInputTokens(t *testing.T) {
+	require.True(t, UsesEstimatedInputTokens("estimated_input_tokens * uint(2)"))
+	require.False(t, UsesEstimatedInputTokens("input_tokens + output_tokens"))
+	require.False(t, UsesEstimatedInputTokens(""))
+}
+
 func TestEvaluateProgram(t *testing.T) {
 	t.Run("signed integer negative", func(t *testing.T) {
 		prog, err := NewProgram("int(input_tokens) - int(output_tokens)")
 		require.NoError(t, err)
-		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3)
+		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3, 0)
 		require.ErrorContains(t, err, "CEL expression result is negative (-1900)")
 	})
 	t.Run("unsigned integer overflow", func(t *testing.T) {
 		prog, err := NewProgram("input_tokens - output_tokens")
 		require.NoError(t, err)
-		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3)
+		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3, 0)
 		require.ErrorContains(t, err, "failed to evaluate CEL expression: unsigned integer overflow")
 	})
 	t.Run("ensure concurrency safety", func(t *testing.T) {
@@ -80,7 +93,7 @@ func TestEvaluateProgram(t *testing.T) {
 		for i := 0; i < 100; i++ {
 			go func() {
 				defer wg.Done()
-				v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3)
+				v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3, 0)
 				require.NoError(t, err)
 				require.Equal(t, uint64(200), v)
 			}()
@@ -16,6 +16,14 @@ import (
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
 )
 
+// chatCompletion implements all the optional interfaces of [x.ChatCompletionMetrics].
+var (
+	_ x.ResponseCacheMetrics  = &chatCompletion{}
+	_ x.HedgeMetrics          = &chatCompletion{}
+	_ x.MirrorMetrics         = &chatCompletion{}
+	_ x.CircuitBreakerMetrics = &chatCompletion{}
+)
+
 // chatCompletion is the implementation for the chat completion AI Gateway metrics.
 type chatCompletion struct {
 	metrics        *genAI
@@ -62,6 +70,10 @@ func (c *chatCompletion) SetBackend(backend *filterapi.Backend) {
 		c.backend = genaiSystemOpenAI
 	case filterapi.APISchemaAWSBedrock:
 		c.backend = genAISystemAWSBedrock
+	case filterapi.APISchemaAnthropic:
+		c.backend = genaiSystemAnthropic
+	case filterapi.APISchemaGCPVertexAI:
+		c.backend = genaiSystemGCPVertexAI
 	default:
 		c.backend = backend.Name
 	}
@@ -105,12 +117,7 @@ func (c *chatCompletion) RecordRequestCompletion(ctx context.Context, success bo
 		// According to the semantic conventions, the error attribute should not be added for successful operations
 		c.metrics.requestLatency.Record(ctx, time.Since(c.requestStart).Seconds(), metric.WithAttributes(attrs...))
 	} else {
-		// We don't have a set of typed errors yet, or a set of low-cardinality values, so we can just set the value to the
-		// placeholder one. See: https://opentelemetry.io/docs/specs/semconv/attributes-registry/error/#error-type
-		c.metrics.requestLatency.Record(ctx, time.Since(c.requestStart).Seconds(),
-			metric.WithAttributes(attrs...),
-			metric.WithAttributes(attribute.Key(genaiAttributeErrorType).String(genaiErrorTypeFallback)),
-		)
+		c.metrics.requestLatency.Record(ctx, time.Since(c.requestStart).Seconds(), metric.WithAttributes(withErrorType(attrs)...))
 	}
 }
 
@@ -134,3 +141,97 @@ func (c *chatCompletion) RecordTokenLatency(ctx context.Context, tokens uint32,
 	}
 	c.lastTokenTime = time.Now()
 }
+
+// RecordResponseCacheLookup implements [x.ResponseCacheMetrics.RecordResponseCacheLookup].
+func (c *chatCompletion) RecordResponseCacheLookup(ctx context.Context, hit bool, extraAttrs ...attribute.KeyValue) {
+	result := aigwResponseCacheResultMiss
+	if hit {
+		result = aigwResponseCacheResultHit
+	}
+	attrs := make([]attribute.KeyValue, 0, 3+len(extraAttrs))
+	attrs = append(attrs,
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(genaiAttributeRequestModel).String(c.model),
+		attribute.Key(aigwAttributeResponseCacheResult).String(result),
+	)
+	attrs = append(attrs, extraAttrs...)
+	c.metrics.responseCacheLookups.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
+
+// RecordHedge implements [x.HedgeMetrics.RecordHedge].
+func (c *chatCompletion) RecordHedge(ctx context.Context, result x.HedgeResult, extraAttrs ...attribute.KeyValue) {
+	attrs := make([]attribute.KeyValue, 0, 3+len(extraAttrs))
+	attrs = append(attrs,
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(genaiAttributeRequestModel).String(c.model),
+		attribute.Key(aigwAttributeHedgeResult).String(string(result)),
+	)
+	attrs = append(attrs, extraAttrs...)
+	c.metrics.hedgeRequests.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
+
+// RecordMirror implements [x.MirrorMetrics.RecordMirror].
+func (c *chatCompletion) RecordMirror(ctx context.Context, comparison *x.MirrorComparison, extraAttrs ...attribute.KeyValue) {
+	attrs := make([]attribute.KeyValue, 0, 3+len(extraAttrs))
+	attrs = append(attrs,
+		attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+		attribute.Key(genaiAttributeRequestModel).String(c.model),
+		attribute.Key(aigwAttributeMirrorBackend).String(comparison.ShadowBackend),
+	)
+	attrs = append(attrs, extraAttrs...)
+
+	result := aigwMirrorResultError
+	if comparison.ShadowSucceeded {
+		result = aigwMirrorResultSuccess
+	}
+	c.metrics.mirrorRequests.Add(ctx, 1,
+		metric.WithAttributes(attrs...),
+		metric.WithAttributes(
+			attribute.Key(aigwAttributeMirrorResult).String(result),
+			attribute.Key(aigwAttributeMirrorFinishReasonMatch).Bool(comparison.FinishReasonMatch),
+		),
+	)
+	c.metrics.mirrorDuration.Record(ctx, comparison.PrimaryLatency.Seconds(),
+		metric.WithAttributes(attrs...),
+		metric.WithAttributes(attribute.Key(aigwAttributeMirrorSide).String(aigwMirrorSidePrimary)),
+	)
+	c.metrics.mirrorDuration.Record(ctx, comparison.ShadowLatency.Seconds(),
+		metric.WithAttributes(attrs...),
+		metric.WithAttributes(attribute.Key(aigwAttributeMirrorSide).String(aigwMirrorSideShadow)),
+	)
+	// The token usage is only reported by the successful responses.
+	if comparison.ShadowSucceeded {
+		c.metrics.mirrorTokenUsage.Record(ctx, float64(comparison.ShadowInputTokens),
+			metric.WithAttributes(attrs...),
+			metric.WithAttributes(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeInput)),
+		)
+		c.metrics.mirrorTokenUsage.Record(ctx, float64(comparison.ShadowOutputTokens),
+			metric.WithAttributes(attrs...),
+			metric.WithAttributes(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeOutput)),
+		)
+	}
+	if comparison.Score != nil {
+		c.metrics.mirrorScore.Record(ctx, *comparison.Score, metric.WithAttributes(attrs...))
+	}
+}
+
+// RecordCircuitBreakerTransition implements [x.CircuitBreakerMetrics.RecordCircuitBreakerTransition].
+func (c *chatCompletion) RecordCircuitBreakerTransition(ctx context.Context, backend string, _, to x.CircuitBreakerState) {
+	backendAttr := attribute.Key(aigwAttributeCircuitBreakerBackend).String(backend)
+	c.metrics.circuitBreakerTransitions.Add(ctx, 1, metric.WithAttributes(
+		backendAttr,
+		attribute.Key(aigwAttributeCircuitBreakerState).String(string(to)),
+	))
+	for _, state := range []x.CircuitBreakerState{
+		x.CircuitBreakerStateClosed, x.CircuitBreakerStateOpen, x.CircuitBreakerStateHalfOpen,
+	} {
+		var value int64
+		if state == to {
+			value = 1
+		}
+		c.metrics.circuitBreakerState.Record(ctx, value, metric.WithAttributes(
+			backendAttr,
+			attribute.Key(aigwAttributeCircuitBreakerState).String(string(state)),
+		))
+	}
+}
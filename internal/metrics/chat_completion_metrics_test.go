@@ -6,6 +6,7 @@
 package metrics
 
 import (
+	"slices"
 	"testing"
 	"time"
 
@@ -16,6 +17,8 @@ import (
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
 )
 
 func TestNewProcessorMetrics(t *testing.T) {
@@ -155,6 +158,187 @@ func TestRecordRequestCompletion(t *testing.T) {
 	count, sum = getHistogramValues(t, mr, genaiMetricServerRequestDuration, attrsFailure)
 	assert.Equal(t, uint64(2), count)
 	assert.Greater(t, sum, 0.0)
+
+	// The classified error type replaces the placeholder one.
+	pm.RecordRequestCompletion(t.Context(), false, extra, ErrorTypeAttribute(aigwerrors.RateLimited))
+	count, _ = getHistogramValues(t, mr, genaiMetricServerRequestDuration,
+		attribute.NewSet(append(attrs, attribute.Key(genaiAttributeErrorType).String("rate_limited"))...))
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestRecordResponseCacheLookup(t *testing.T) {
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = DefaultChatCompletion(meter).(*chatCompletion)
+
+		attrs = []attribute.KeyValue{
+			attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+			attribute.Key(genaiAttributeRequestModel).String("test-model"),
+		}
+		hitAttrs  = attribute.NewSet(append(attrs, attribute.Key(aigwAttributeResponseCacheResult).String(aigwResponseCacheResultHit))...)
+		missAttrs = attribute.NewSet(append(attrs, attribute.Key(aigwAttributeResponseCacheResult).String(aigwResponseCacheResultMiss))...)
+	)
+
+	pm.SetModel("test-model")
+	pm.RecordResponseCacheLookup(t.Context(), true)
+	pm.RecordResponseCacheLookup(t.Context(), true)
+	pm.RecordResponseCacheLookup(t.Context(), false)
+
+	assert.Equal(t, int64(2), getCounterValue(t, mr, aigwMetricResponseCacheLookups, hitAttrs))
+	assert.Equal(t, int64(1), getCounterValue(t, mr, aigwMetricResponseCacheLookups, missAttrs))
+}
+
+func TestRecordHedge(t *testing.T) {
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = DefaultChatCompletion(meter).(*chatCompletion)
+
+		attrs = []attribute.KeyValue{
+			attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+			attribute.Key(genaiAttributeRequestModel).String("test-model"),
+		}
+		resultAttrs = func(result x.HedgeResult) attribute.Set {
+			return attribute.NewSet(append(attrs, attribute.Key(aigwAttributeHedgeResult).String(string(result)))...)
+		}
+	)
+
+	pm.SetModel("test-model")
+	pm.RecordHedge(t.Context(), x.HedgeResultNotFired)
+	pm.RecordHedge(t.Context(), x.HedgeResultHedge)
+	pm.RecordHedge(t.Context(), x.HedgeResultHedge)
+	pm.RecordHedge(t.Context(), x.HedgeResultPrimary)
+
+	assert.Equal(t, int64(1), getCounterValue(t, mr, aigwMetricHedgeRequests, resultAttrs(x.HedgeResultNotFired)))
+	assert.Equal(t, int64(1), getCounterValue(t, mr, aigwMetricHedgeRequests, resultAttrs(x.HedgeResultPrimary)))
+	assert.Equal(t, int64(2), getCounterValue(t, mr, aigwMetricHedgeRequests, resultAttrs(x.HedgeResultHedge)))
+}
+
+func TestRecordMirror(t *testing.T) {
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = DefaultChatCompletion(meter).(*chatCompletion)
+
+		attrs = []attribute.KeyValue{
+			attribute.Key(genaiAttributeOperationName).String(genaiOperationChat),
+			attribute.Key(genaiAttributeRequestModel).String("test-model"),
+			attribute.Key(aigwAttributeMirrorBackend).String("shadow"),
+		}
+		withAttrs = func(extra ...attribute.KeyValue) attribute.Set {
+			return attribute.NewSet(append(slices.Clone(attrs), extra...)...)
+		}
+		score = 0.8
+	)
+
+	pm.SetModel("test-model")
+	pm.RecordMirror(t.Context(), &x.MirrorComparison{
+		ShadowBackend:      "shadow",
+		ShadowSucceeded:    true,
+		PrimaryLatency:     time.Second,
+		ShadowLatency:      2 * time.Second,
+		ShadowInputTokens:  10,
+		ShadowOutputTokens: 5,
+		FinishReasonMatch:  true,
+		Score:              &score,
+	})
+	pm.RecordMirror(t.Context(), &x.MirrorComparison{
+		ShadowBackend:  "shadow",
+		PrimaryLatency: time.Second,
+		ShadowLatency:  time.Second,
+	})
+
+	assert.Equal(t, int64(1), getCounterValue(t, mr, aigwMetricMirrorRequests, withAttrs(
+		attribute.Key(aigwAttributeMirrorResult).String(aigwMirrorResultSuccess),
+		attribute.Key(aigwAttributeMirrorFinishReasonMatch).Bool(true),
+	)))
+	assert.Equal(t, int64(1), getCounterValue(t, mr, aigwMetricMirrorRequests, withAttrs(
+		attribute.Key(aigwAttributeMirrorResult).String(aigwMirrorResultError),
+		attribute.Key(aigwAttributeMirrorFinishReasonMatch).Bool(false),
+	)))
+	count, sum := getHistogramValues(t, mr, aigwMetricMirrorDuration, withAttrs(attribute.Key(aigwAttributeMirrorSide).String(aigwMirrorSidePrimary)))
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, 2.0, sum)
+	count, sum = getHistogramValues(t, mr, aigwMetricMirrorDuration, withAttrs(attribute.Key(aigwAttributeMirrorSide).String(aigwMirrorSideShadow)))
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, 3.0, sum)
+	count, sum = getHistogramValues(t, mr, aigwMetricMirrorTokenUsage, withAttrs(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeInput)))
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 10.0, sum)
+	count, sum = getHistogramValues(t, mr, aigwMetricMirrorTokenUsage, withAttrs(attribute.Key(genaiAttributeTokenType).String(genaiTokenTypeOutput)))
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 5.0, sum)
+	count, sum = getHistogramValues(t, mr, aigwMetricMirrorScore, withAttrs())
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 0.8, sum)
+}
+
+func TestRecordCircuitBreakerTransition(t *testing.T) {
+	var (
+		mr    = metric.NewManualReader()
+		meter = metric.NewMeterProvider(metric.WithReader(mr)).Meter("test")
+		pm    = DefaultChatCompletion(meter).(*chatCompletion)
+
+		withState = func(state x.CircuitBreakerState) attribute.Set {
+			return attribute.NewSet(
+				attribute.Key(aigwAttributeCircuitBreakerBackend).String("backend"),
+				attribute.Key(aigwAttributeCircuitBreakerState).String(string(state)),
+			)
+		}
+	)
+
+	pm.RecordCircuitBreakerTransition(t.Context(), "backend", x.CircuitBreakerStateClosed, x.CircuitBreakerStateOpen)
+	pm.RecordCircuitBreakerTransition(t.Context(), "backend", x.CircuitBreakerStateOpen, x.CircuitBreakerStateHalfOpen)
+	pm.RecordCircuitBreakerTransition(t.Context(), "backend", x.CircuitBreakerStateHalfOpen, x.CircuitBreakerStateOpen)
+
+	assert.Equal(t, int64(2), getCounterValue(t, mr, aigwMetricCircuitBreakerTransitions, withState(x.CircuitBreakerStateOpen)))
+	assert.Equal(t, int64(1), getCounterValue(t, mr, aigwMetricCircuitBreakerTransitions, withState(x.CircuitBreakerStateHalfOpen)))
+	assert.Equal(t, int64(0), getGaugeValue(t, mr, aigwMetricCircuitBreakerState, withState(x.CircuitBreakerStateClosed)))
+	assert.Equal(t, int64(1), getGaugeValue(t, mr, aigwMetricCircuitBreakerState, withState(x.CircuitBreakerStateOpen)))
+	assert.Equal(t, int64(0), getGaugeValue(t, mr, aigwMetricCircuitBreakerState, withState(x.CircuitBreakerStateHalfOpen)))
+}
+
+// getCounterValue returns the value of a counter metric with the given attributes.
+func getCounterValue(t *testing.T, reader metric.Reader, metric string, attrs attribute.Set) int64 {
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &data))
+
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != metric {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				if dp.Attributes.Equals(&attrs) {
+					return dp.Value
+				}
+			}
+		}
+	}
+	require.Failf(t, "datapoint not found", "attributes: %v", attrs)
+	return 0
+}
+
+// getGaugeValue returns the value of a gauge metric with the given attributes.
+func getGaugeValue(t *testing.T, reader metric.Reader, metric string, attrs attribute.Set) int64 {
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &data))
+
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != metric {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Gauge[int64]).DataPoints {
+				if dp.Attributes.Equals(&attrs) {
+					return dp.Value
+				}
+			}
+		}
+	}
+	require.Failf(t, "datapoint not found", "attributes: %v", attrs)
+	return 0
 }
 
 // getHistogramValues returns the count and sum of a histogram metric with the given attributes.
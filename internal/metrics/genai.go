@@ -5,7 +5,12 @@
 
 package metrics
 
-import "go.opentelemetry.io/otel/metric"
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/envoyproxy/ai-gateway/internal/aigwerrors"
+)
 
 const (
 	// Metric names, attributes and values according to the Semantic Conventions for Generative AI Metrics.
@@ -22,13 +27,47 @@ const (
 	genaiAttributeTokenType     = "gen_ai.token.type" // #nosec G101: Potential hardcoded credentials
 	genaiAttributeErrorType     = "error.type"
 
-	genaiOperationChat     = "chat"
-	genaiSystemOpenAI      = "openai"
-	genAISystemAWSBedrock  = "aws.bedrock"
-	genaiTokenTypeInput    = "input"
-	genaiTokenTypeOutput   = "output"
-	genaiTokenTypeTotal    = "total"
-	genaiErrorTypeFallback = "_OTHER"
+	genaiOperationChat      = "chat"
+	genaiOperationEmbedding = "embeddings"
+	genaiSystemOpenAI       = "openai"
+	genAISystemAWSBedrock   = "aws.bedrock"
+	genaiSystemAnthropic    = "anthropic"
+	genaiSystemGCPVertexAI  = "gcp.vertex_ai"
+	genaiTokenTypeInput     = "input"
+	genaiTokenTypeOutput    = "output"
+	genaiTokenTypeTotal     = "total"
+	genaiErrorTypeFallback  = "_OTHER"
+
+	// The response cache metrics are specific to AI Gateway and not defined in the Semantic Conventions.
+
+	aigwMetricResponseCacheLookups   = "aigw.response_cache.lookups"
+	aigwAttributeResponseCacheResult = "aigw.response_cache.result"
+	aigwResponseCacheResultHit       = "hit"
+	aigwResponseCacheResultMiss      = "miss"
+
+	aigwMetricHedgeRequests  = "aigw.hedge.requests"
+	aigwAttributeHedgeResult = "aigw.hedge.result"
+
+	aigwMetricMirrorRequests             = "aigw.mirror.requests"
+	aigwMetricMirrorDuration             = "aigw.mirror.duration"
+	aigwMetricMirrorTokenUsage           = "aigw.mirror.token.usage" // #nosec G101: Potential hardcoded credentials
+	aigwMetricMirrorScore                = "aigw.mirror.score"
+	aigwAttributeMirrorBackend           = "aigw.mirror.backend"
+	aigwAttributeMirrorResult            = "aigw.mirror.result"
+	aigwAttributeMirrorFinishReasonMatch = "aigw.mirror.finish_reason_match"
+	aigwAttributeMirrorSide              = "aigw.mirror.side"
+	aigwMirrorResultSuccess              = "success"
+	aigwMirrorResultError                = "error"
+	aigwMirrorSidePrimary                = "primary"
+	aigwMirrorSideShadow                 = "shadow"
+
+	aigwMetricCircuitBreakerState       = "aigw.circuit_breaker.state"
+	aigwMetricCircuitBreakerTransitions = "aigw.circuit_breaker.transitions"
+	aigwAttributeCircuitBreakerBackend  = "aigw.circuit_breaker.backend"
+	aigwAttributeCircuitBreakerState    = "aigw.circuit_breaker.state"
+
+	aigwAttributeExperiment    = "aigw.experiment"
+	aigwAttributeExperimentArm = "aigw.experiment.arm"
 )
 
 // genAI holds metrics according to the Semantic Conventions for Generative AI Metrics.
@@ -48,6 +87,22 @@ type genAI struct {
 	// outputTokenLatency is the latency between consecutive tokens, if supported, or by chunks/tokens otherwise, by backend, model.
 	// See: https://opentelemetry.io/docs/specs/semconv/gen-ai/gen-ai-metrics/#metric-gen_aiservertime_per_output_token
 	outputTokenLatency metric.Float64Histogram
+	// responseCacheLookups is the number of the response cache lookups by the result, hit or miss.
+	responseCacheLookups metric.Int64Counter
+	// hedgeRequests is the number of the requests eligible for hedging by the result, i.e. which backend won.
+	hedgeRequests metric.Int64Counter
+	// mirrorRequests is the number of the mirrored requests by the shadow backend and whether it succeeded.
+	mirrorRequests metric.Int64Counter
+	// mirrorDuration is the latency of the primary and shadow backends to the mirrored requests.
+	mirrorDuration metric.Float64Histogram
+	// mirrorTokenUsage is the number of tokens processed by the shadow backends, which are not in tokenUsage.
+	mirrorTokenUsage metric.Float64Histogram
+	// mirrorScore is the similarity score of the outputs of the primary and shadow backends.
+	mirrorScore metric.Float64Histogram
+	// circuitBreakerState is 1 for the current state of the circuit breaker of each backend, and 0 for the others.
+	circuitBreakerState metric.Int64Gauge
+	// circuitBreakerTransitions is the number of the changes of the state of the circuit breakers by the new state.
+	circuitBreakerTransitions metric.Int64Counter
 }
 
 // newGenAI creates a new genAI metrics instance.
@@ -77,6 +132,47 @@ func newGenAI(meter metric.Meter) *genAI {
 			metric.WithUnit("s"),
 			metric.WithExplicitBucketBoundaries(0.01, 0.025, 0.05, 0.075, 0.1, 0.15, 0.2, 0.3, 0.4, 0.5, 0.75, 1.0, 2.5),
 		),
+		responseCacheLookups: mustRegisterCounter(meter,
+			aigwMetricResponseCacheLookups,
+			metric.WithDescription("Number of response cache lookups."),
+			metric.WithUnit("{lookup}"),
+		),
+		hedgeRequests: mustRegisterCounter(meter,
+			aigwMetricHedgeRequests,
+			metric.WithDescription("Number of requests eligible for hedging."),
+			metric.WithUnit("{request}"),
+		),
+		mirrorRequests: mustRegisterCounter(meter,
+			aigwMetricMirrorRequests,
+			metric.WithDescription("Number of requests mirrored to the shadow backends."),
+			metric.WithUnit("{request}"),
+		),
+		mirrorDuration: mustRegisterHistogram(meter,
+			aigwMetricMirrorDuration,
+			metric.WithDescription("Time until the primary and shadow backends complete the responses to the mirrored requests."),
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92),
+		),
+		mirrorTokenUsage: mustRegisterHistogram(meter,
+			aigwMetricMirrorTokenUsage,
+			metric.WithDescription("Number of tokens processed by the shadow backends."),
+			metric.WithUnit("{token}"),
+			metric.WithExplicitBucketBoundaries(1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864),
+		),
+		mirrorScore: mustRegisterHistogram(meter,
+			aigwMetricMirrorScore,
+			metric.WithDescription("Similarity score of the outputs of the primary and shadow backends."),
+			metric.WithExplicitBucketBoundaries(0, 0.5, 0.7, 0.8, 0.9, 0.95, 0.99, 1),
+		),
+		circuitBreakerState: mustRegisterGauge(meter,
+			aigwMetricCircuitBreakerState,
+			metric.WithDescription("Current state of the circuit breakers of the backends."),
+		),
+		circuitBreakerTransitions: mustRegisterCounter(meter,
+			aigwMetricCircuitBreakerTransitions,
+			metric.WithDescription("Number of changes of the state of the circuit breakers of the backends."),
+			metric.WithUnit("{transition}"),
+		),
 	}
 }
 
@@ -88,3 +184,48 @@ func mustRegisterHistogram(meter metric.Meter, name string, options ...metric.Fl
 	}
 	return h
 }
+
+// mustRegisterCounter registers a counter with the meter and panics if it fails.
+func mustRegisterCounter(meter metric.Meter, name string, options ...metric.Int64CounterOption) metric.Int64Counter {
+	c, err := meter.Int64Counter(name, options...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ErrorTypeAttribute returns the error.type attribute of the given type to be passed as an extra attribute
+// of the failed request completion.
+// See: https://opentelemetry.io/docs/specs/semconv/attributes-registry/error/#error-type
+func ErrorTypeAttribute(t aigwerrors.Type) attribute.KeyValue {
+	return attribute.Key(genaiAttributeErrorType).String(string(t))
+}
+
+// ExperimentAttributes returns the attributes of the experiment and its arm the request is assigned to, which are
+// passed as the extra attributes of the metrics of the request.
+func ExperimentAttributes(experiment, arm string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Key(aigwAttributeExperiment).String(experiment),
+		attribute.Key(aigwAttributeExperimentArm).String(arm),
+	}
+}
+
+// withErrorType returns the attributes with the error.type attribute, which is the placeholder one
+// unless the caller has already classified the error.
+func withErrorType(attrs []attribute.KeyValue) []attribute.KeyValue {
+	for _, attr := range attrs {
+		if attr.Key == genaiAttributeErrorType {
+			return attrs
+		}
+	}
+	return append(attrs, attribute.Key(genaiAttributeErrorType).String(genaiErrorTypeFallback))
+}
+
+// mustRegisterGauge registers a gauge with the meter and panics if it fails.
+func mustRegisterGauge(meter metric.Meter, name string, options ...metric.Int64GaugeOption) metric.Int64Gauge {
+	g, err := meter.Int64Gauge(name, options...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
@@ -0,0 +1,74 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// aigwAttributeValueOther is the value of the request header attributes reported once the number of
+// their distinct values reaches the limit.
+const aigwAttributeValueOther = "_OTHER"
+
+// RequestHeaderAttributes derives the metric attributes from the request headers as per [filterapi.MetricsConfig].
+//
+// The number of the distinct values of each attribute is capped to bound the cardinality of the metrics, and the
+// values seen are remembered for the lifetime of this instance. A nil *RequestHeaderAttributes derives no attributes.
+type RequestHeaderAttributes struct {
+	attributes []filterapi.MetricsRequestHeaderAttribute
+	maxValues  int
+
+	mu sync.Mutex
+	// values are the distinct values seen of each attribute in the same order as attributes.
+	values []map[string]struct{}
+}
+
+// NewRequestHeaderAttributes creates a new RequestHeaderAttributes for the configuration.
+// This returns nil if there's no attribute configured.
+func NewRequestHeaderAttributes(config *filterapi.MetricsConfig) *RequestHeaderAttributes {
+	if config == nil || len(config.RequestHeaderAttributes) == 0 {
+		return nil
+	}
+	values := make([]map[string]struct{}, len(config.RequestHeaderAttributes))
+	for i := range values {
+		values[i] = make(map[string]struct{})
+	}
+	return &RequestHeaderAttributes{
+		attributes: config.RequestHeaderAttributes,
+		maxValues:  config.MaxAttributeValues,
+		values:     values,
+	}
+}
+
+// Attributes returns the attributes of the request headers. The attribute is omitted when the header is absent,
+// and its value is "_OTHER" when it would exceed the number of the distinct values allowed.
+func (r *RequestHeaderAttributes) Attributes(headers map[string]string) []attribute.KeyValue {
+	if r == nil {
+		return nil
+	}
+	ret := make([]attribute.KeyValue, 0, len(r.attributes))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, a := range r.attributes {
+		v, ok := headers[a.Header]
+		if !ok {
+			continue
+		}
+		if _, seen := r.values[i][v]; !seen {
+			if len(r.values[i]) >= r.maxValues {
+				v = aigwAttributeValueOther
+			} else {
+				r.values[i][v] = struct{}{}
+			}
+		}
+		ret = append(ret, attribute.String(a.Attribute, v))
+	}
+	return ret
+}
@@ -0,0 +1,45 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestRequestHeaderAttributes(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		require.Nil(t, NewRequestHeaderAttributes(nil))
+		require.Nil(t, NewRequestHeaderAttributes(&filterapi.MetricsConfig{MaxAttributeValues: 10}))
+		var r *RequestHeaderAttributes
+		require.Nil(t, r.Attributes(map[string]string{"x-tenant-id": "a"}))
+	})
+
+	r := NewRequestHeaderAttributes(&filterapi.MetricsConfig{
+		RequestHeaderAttributes: []filterapi.MetricsRequestHeaderAttribute{
+			{Header: "x-tenant-id", Attribute: "tenant.id"},
+			{Header: "x-api-key-id", Attribute: "api_key.id"},
+		},
+		MaxAttributeValues: 2,
+	})
+	require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "a"), attribute.String("api_key.id", "k1")},
+		r.Attributes(map[string]string{"x-tenant-id": "a", "x-api-key-id": "k1"}))
+	// The attribute is omitted when the header is absent.
+	require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "b")},
+		r.Attributes(map[string]string{"x-tenant-id": "b"}))
+	require.Empty(t, r.Attributes(map[string]string{}))
+	// The limit of the distinct values is per attribute.
+	require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "_OTHER"), attribute.String("api_key.id", "k2")},
+		r.Attributes(map[string]string{"x-tenant-id": "c", "x-api-key-id": "k2"}))
+	require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "a"), attribute.String("api_key.id", "_OTHER")},
+		r.Attributes(map[string]string{"x-tenant-id": "a", "x-api-key-id": "k3"}))
+	require.Equal(t, []attribute.KeyValue{attribute.String("tenant.id", "b"), attribute.String("api_key.id", "k1")},
+		r.Attributes(map[string]string{"x-tenant-id": "b", "x-api-key-id": "k1"}))
+}
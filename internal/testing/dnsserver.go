@@ -14,37 +14,76 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// RequireNewTestDNSServer starts a new DNS server that responds to all queries with A records
-// with the fixed IP addresses.
+// RequireNewTestDNSServer starts a new DNS server that responds to the queries with the fixed records:
+//
+//   - A records with the fixed IP addresses for all names except "ipv6.io." and "nxdomain.io.".
+//   - AAAA records for "ipv6.io." and "dualstack.io.", and no AAAA records for the others.
+//   - SRV records for "_http._tcp.srv.io." whose target addresses are in the additional section,
+//     and for "_http._tcp.noextra.io." whose target addresses are not.
+//   - NXDOMAIN for "nxdomain.io.".
 func RequireNewTestDNSServer(t *testing.T) (addr string) {
+	return RequireNewTestDNSServerWithRecords(t, func(name string, qtype uint16) (answer, extra []string, rcode int) {
+		if name == "nxdomain.io." {
+			return nil, nil, dns.RcodeNameError
+		}
+		switch qtype {
+		case dns.TypeA:
+			switch name {
+			case "foo.io.":
+				answer = append(answer, name+" A 1.1.1.1")
+			case "example.com.":
+				answer = append(answer, name+" A 2.2.2.2")
+			case "mylocal.io.":
+				answer = append(answer, name+" A 0.0.0.0")
+			case "dualstack.io.":
+				answer = append(answer, name+" A 5.5.5.5")
+			case "ipv6.io.":
+			default:
+				answer = append(answer, name+" A 3.3.3.3", name+" A 4.4.4.4")
+			}
+		case dns.TypeAAAA:
+			switch name {
+			case "ipv6.io.":
+				answer = append(answer, name+" AAAA 2001:db8::1")
+			case "dualstack.io.":
+				answer = append(answer, name+" AAAA 2001:db8::2")
+			}
+		case dns.TypeSRV:
+			switch name {
+			case "_http._tcp.srv.io.":
+				answer = append(answer, name+" SRV 0 0 8000 pod-a.srv.io.", name+" SRV 0 0 8001 pod-b.srv.io.")
+				extra = append(extra, "pod-a.srv.io. A 6.6.6.6", "pod-b.srv.io. AAAA 2001:db8::3")
+			case "_http._tcp.noextra.io.":
+				answer = append(answer, name+" SRV 0 0 8000 example.com.")
+			}
+		default:
+			t.Fatalf("Unsupported query type: %v", qtype)
+		}
+		return
+	})
+}
+
+// RequireNewTestDNSServerWithRecords starts a new DNS server that responds to the queries with the records
+// in the zone file format returned by the given function.
+func RequireNewTestDNSServerWithRecords(t *testing.T, records func(name string, qtype uint16) (answer, extra []string, rcode int)) (addr string) {
 	mux := dns.NewServeMux()
 	mux.HandleFunc(".", func(w dns.ResponseWriter, r *dns.Msg) {
 		msg := dns.Msg{}
 		msg.SetReply(r)
 		msg.Authoritative = true
 		for _, q := range r.Question {
-			var ips []string
-			switch q.Qtype {
-			case dns.TypeA:
-				switch q.Name {
-				case "foo.io.":
-					ips = append(ips, "1.1.1.1")
-				case "example.com.":
-					ips = append(ips, "2.2.2.2")
-				case "mylocal.io.":
-					ips = append(ips, "0.0.0.0")
-				default:
-					ips = append(ips, "3.3.3.3")
-					ips = append(ips, "4.4.4.4")
-				}
-			default:
-				t.Fatalf("Unsupported query type: %v", q.Qtype)
-			}
-			for _, ip := range ips {
-				rr, err := dns.NewRR(q.Name + " A " + ip)
+			answer, extra, rcode := records(q.Name, q.Qtype)
+			msg.Rcode = rcode
+			for _, record := range answer {
+				rr, err := dns.NewRR(record)
 				require.NoError(t, err)
 				msg.Answer = append(msg.Answer, rr)
 			}
+			for _, record := range extra {
+				rr, err := dns.NewRR(record)
+				require.NoError(t, err)
+				msg.Extra = append(msg.Extra, rr)
+			}
 		}
 		require.NoError(t, w.WriteMsg(&msg))
 	})
@@ -65,25 +104,11 @@ func RequireNewTestDNSServer(t *testing.T) (addr string) {
 		client := dns.Client{Net: "udp"}
 		msg := new(dns.Msg)
 		msg.SetQuestion("example.com.", dns.TypeA)
-		response, _, err := client.ExchangeContext(t.Context(), msg, addr)
-		if err != nil {
+		if _, _, err := client.ExchangeContext(t.Context(), msg, addr); err != nil {
 			t.Logf("Failed to exchange DNS message: %v", err)
 			return false
 		}
-		if response.Rcode != dns.RcodeSuccess {
-			t.Logf("DNS query failed: %s", dns.RcodeToString[response.Rcode])
-			return false
-		}
-		for _, answer := range response.Answer {
-			if aRecord, ok := answer.(*dns.A); ok {
-				if aRecord.A.String() == "2.2.2.2" {
-					return true
-				}
-			}
-			t.Logf("Unexpected answer: %v", answer)
-		}
-		t.Logf("No A record found")
-		return false
+		return true
 	}, 5*time.Second, 100*time.Millisecond)
 	return
 }
@@ -0,0 +1,148 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package tracing provides the OpenTelemetry distributed tracing of the requests processed by the external processor.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/envoyproxy/ai-gateway/internal/version"
+)
+
+const (
+	// instrumentationName is the name of the tracer.
+	instrumentationName = "github.com/envoyproxy/ai-gateway/internal/extproc"
+	// serviceName is the default service.name resource attribute, which can be overridden by OTEL_SERVICE_NAME.
+	serviceName = "ai-gateway-extproc"
+)
+
+// Config is the configuration of the tracing.
+type Config struct {
+	// Endpoint is the "host:port" address of the OTLP gRPC receiver of the spans. When empty, the standard
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used, and the tracing
+	// is disabled if neither is set.
+	Endpoint string
+	// Insecure disables TLS for the connection to the receiver.
+	Insecure bool
+	// SampleRatio is the ratio of the traces sampled when the incoming request has no sampling decision.
+	SampleRatio float64
+	// RecordContent enables recording the prompts and the completions as span events. This is off by default since
+	// they can contain sensitive information.
+	RecordContent bool
+}
+
+// Tracing creates the spans of the requests and propagates the trace context with the W3C Trace Context headers.
+//
+// A nil *Tracing disables the tracing: the spans it starts are not recording, and nothing is propagated.
+type Tracing struct {
+	provider      *sdktrace.TracerProvider
+	tracer        trace.Tracer
+	propagator    propagation.TextMapPropagator
+	recordContent bool
+}
+
+// New creates a new Tracing exporting the spans for the configuration. This returns nil if the tracing is disabled.
+func New(ctx context.Context, config Config) (*Tracing, error) {
+	if config.Endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" &&
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return nil, nil
+	}
+	var opts []otlptracegrpc.Option
+	if config.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(config.Endpoint))
+	}
+	if config.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName), semconv.ServiceVersion(version.Version)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	return NewFromTracerProvider(provider, config.RecordContent), nil
+}
+
+// NewFromTracerProvider creates a new Tracing with the tracer provider, e.g. the one with a custom exporter.
+func NewFromTracerProvider(provider *sdktrace.TracerProvider, recordContent bool) *Tracing {
+	return &Tracing{
+		provider:      provider,
+		tracer:        provider.Tracer(instrumentationName, trace.WithInstrumentationVersion(version.Version)),
+		propagator:    propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		recordContent: recordContent,
+	}
+}
+
+// Shutdown exports the remaining spans and stops the tracing.
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// RecordContent returns true if the prompts and the completions should be recorded as span events.
+func (t *Tracing) RecordContent() bool {
+	return t != nil && t.recordContent
+}
+
+// Extract returns the context with the remote span context in the request headers, if any.
+func (t *Tracing) Extract(ctx context.Context, headers map[string]string) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return t.propagator.Extract(ctx, propagation.MapCarrier(headers))
+}
+
+// Inject returns the headers propagating the span context in ctx to the upstream.
+func (t *Tracing) Inject(ctx context.Context) map[string]string {
+	if t == nil {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	t.propagator.Inject(ctx, carrier)
+	return carrier
+}
+
+// Start starts a new span as the child of the span in ctx, if any.
+func (t *Tracing) Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, noop.Span{}
+	}
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End ends the span, recording the error if it is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,82 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package tracing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	tr, err := New(t.Context(), Config{SampleRatio: 1})
+	require.NoError(t, err)
+	require.Nil(t, tr)
+
+	tr, err = New(t.Context(), Config{Endpoint: "localhost:4317", Insecure: true, SampleRatio: 1, RecordContent: true})
+	require.NoError(t, err)
+	require.NotNil(t, tr)
+	require.True(t, tr.RecordContent())
+	require.NoError(t, tr.Shutdown(t.Context()))
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4317")
+	tr, err = New(t.Context(), Config{SampleRatio: 1})
+	require.NoError(t, err)
+	require.NotNil(t, tr)
+	require.False(t, tr.RecordContent())
+	require.NoError(t, tr.Shutdown(t.Context()))
+}
+
+func TestTracing_nil(t *testing.T) {
+	var tr *Tracing
+	ctx := t.Context()
+	require.Equal(t, ctx, tr.Extract(ctx, map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}))
+	spanCtx, span := tr.Start(ctx, "span", trace.SpanKindInternal)
+	require.Equal(t, ctx, spanCtx)
+	require.False(t, span.IsRecording())
+	End(span, errors.New("ignored"))
+	require.Nil(t, tr.Inject(ctx))
+	require.False(t, tr.RecordContent())
+	require.NoError(t, tr.Shutdown(ctx))
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tr := NewFromTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), false)
+
+	const traceID = "0af7651916cd43dd8448eb211c80319c"
+	ctx := tr.Extract(t.Context(), map[string]string{"traceparent": "00-" + traceID + "-b7ad6b7169203331-01"})
+	ctx, parent := tr.Start(ctx, "parent", trace.SpanKindServer, attribute.String("key", "value"))
+	_, child := tr.Start(ctx, "child", trace.SpanKindInternal)
+	End(child, errors.New("failed"))
+	headers := tr.Inject(ctx)
+	End(parent, nil)
+
+	require.Equal(t, "00-"+traceID+"-"+parent.SpanContext().SpanID().String()+"-01", headers["traceparent"])
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "child", spans[0].Name())
+	require.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, "failed", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+
+	require.Equal(t, "parent", spans[1].Name())
+	require.Equal(t, trace.SpanKindServer, spans[1].SpanKind())
+	require.Equal(t, []attribute.KeyValue{attribute.String("key", "value")}, spans[1].Attributes())
+	require.True(t, spans[1].Parent().IsRemote())
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+}
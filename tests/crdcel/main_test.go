@@ -32,6 +32,20 @@ func TestAIGatewayRoutes(t *testing.T) {
 	}{
 		{name: "basic.yaml"},
 		{name: "llmcosts.yaml"},
+		{name: "guardrails.yaml"},
+		{
+			name:   "guardrails_mismatched_rule.yaml",
+			expErr: "regularExpression must be set only for the RegularExpression type",
+		},
+		{name: "inference_pool_load_balancing.yaml"},
+		{
+			name:   "inference_pool_invalid_session_affinity.yaml",
+			expErr: "spec.rules[0].backendRefs[0].loadBalancing.sessionAffinity.maxLoadPercentage: Invalid value: 100: spec.rules[0].backendRefs[0].loadBalancing.sessionAffinity.maxLoadPercentage in body should be greater than or equal to 101",
+		},
+		{
+			name:   "load_balancing_non_inference_pool.yaml",
+			expErr: "loadBalancing is only valid for the InferencePool kind",
+		},
 		{
 			name:   "non_openai_schema.yaml",
 			expErr: `spec.schema: Invalid value: "object": failed rule: self.name == 'OpenAI'`,
@@ -40,9 +54,50 @@ func TestAIGatewayRoutes(t *testing.T) {
 			name:   "unknown_schema.yaml",
 			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"AWSBedrock\"",
 		},
+		{name: "regex_match.yaml"},
+		{name: "model_name_mappings.yaml"},
+		{name: "spend_budgets.yaml"},
+		{
+			name:   "spend_budgets_both_identifiers.yaml",
+			expErr: "exactly one of header or jwtClaim must be set",
+		},
+		{
+			name:   "spend_budgets_invalid_price.yaml",
+			expErr: `spec.spendBudgets.pricing[0].inputPerMillionTokens in body should match '^[0-9]+(\.[0-9]{1,9})?$'`,
+		},
+		{name: "usage_records.yaml"},
+		{
+			name:   "usage_records_mismatched_type.yaml",
+			expErr: "webhook must be set only for the Webhook type",
+		},
+		{name: "audit_log.yaml"},
+		{
+			name:   "audit_log_mismatched_type.yaml",
+			expErr: "http must be set only for the HTTP type",
+		},
+		{
+			name:   "audit_log_invalid_sampling.yaml",
+			expErr: "spec.auditLog.samplingPercentage: Invalid value: 101: spec.auditLog.samplingPercentage in body should be less than or equal to 100",
+		},
+		{name: "circuit_breaker.yaml"},
+		{
+			name:   "circuit_breaker_invalid_error_rate.yaml",
+			expErr: "spec.rules[0].circuitBreaker.errorRatePercentage: Invalid value: 101: spec.rules[0].circuitBreaker.errorRatePercentage in body should be less than or equal to 100",
+		},
+		{name: "experiment.yaml"},
+		{
+			name:   "experiment_unassigned_backend.yaml",
+			expErr: "each backendRef must belong to exactly one arm of the experiment",
+		},
+		{name: "metrics.yaml"},
 		{
-			name:   "unsupported_match.yaml",
-			expErr: "spec.rules[0].matches[0].headers: Invalid value: \"array\": currently only exact match is supported",
+			name:   "metrics_invalid_attribute.yaml",
+			expErr: `spec.metrics.requestHeaderAttributes[0].attribute in body should match '^[a-zA-Z_][a-zA-Z0-9_.]*$'`,
+		},
+		{name: "mirror.yaml"},
+		{
+			name:   "mirror_missing_embedding.yaml",
+			expErr: "embeddingSimilarity must be set for the EmbeddingSimilarity type",
 		},
 		{
 			name:   "no_target_refs.yaml",
@@ -82,6 +137,11 @@ func TestAIServiceBackends(t *testing.T) {
 		{name: "basic.yaml"},
 		{name: "basic-eg-backend-aws.yaml"},
 		{name: "basic-eg-backend-azure.yaml"},
+		{name: "aws_bedrock_guardrail.yaml"},
+		{
+			name:   "aws_bedrock_guardrail_non_bedrock.yaml",
+			expErr: "awsBedrockGuardrail is only valid for the AWSBedrock schema",
+		},
 		{
 			name:   "unknown_schema.yaml",
 			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"AWSBedrock\"",
@@ -116,11 +176,11 @@ func TestBackendSecurityPolicies(t *testing.T) {
 		{name: "basic.yaml"},
 		{
 			name:   "unknown_provider.yaml",
-			expErr: "spec.type: Unsupported value: \"UnknownType\": supported values: \"APIKey\", \"AWSCredentials\", \"AzureCredentials\"",
+			expErr: "spec.type: Unsupported value: \"UnknownType\": supported values: \"APIKey\", \"AWSCredentials\", \"AzureCredentials\", \"GCPCredentials\"",
 		},
 		{
 			name:   "missing_type.yaml",
-			expErr: "spec.type: Unsupported value: \"\": supported values: \"APIKey\", \"AWSCredentials\", \"AzureCredentials\"",
+			expErr: "spec.type: Unsupported value: \"\": supported values: \"APIKey\", \"AWSCredentials\", \"AzureCredentials\", \"GCPCredentials\"",
 		},
 		{
 			name:   "multiple_security_policies.yaml",
@@ -137,6 +197,11 @@ func TestBackendSecurityPolicies(t *testing.T) {
 		{name: "azure_valid_credentials.yaml"},
 		{name: "aws_credential_file.yaml"},
 		{name: "aws_oidc.yaml"},
+		{name: "gcp_service_account_key.yaml"},
+		{
+			name:   "gcp_missing_credentials.yaml",
+			expErr: "exactly one of serviceAccountKeyRef or workloadIdentityFederation must be specified",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			data, err := testdata.ReadFile(path.Join("testdata/backendsecuritypolicies", tc.name))
@@ -242,7 +242,7 @@ data: [DONE]
 			expStatus:       http.StatusTooManyRequests,
 			responseHeaders: "x-amzn-errortype:ThrottledException",
 			responseBody:    `{"message": "aws bedrock rate limit exceeded"}`,
-			expResponseBody: `{"type":"error","error":{"type":"ThrottledException","code":"429","message":"aws bedrock rate limit exceeded"}}`,
+			expResponseBody: `{"type":"error","error":{"type":"rate_limit_error","code":"ThrottledException","message":"aws bedrock rate limit exceeded"}}`,
 		},
 		{
 			name:                "openai - /v1/models",